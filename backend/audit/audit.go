@@ -0,0 +1,97 @@
+// ABOUTME: Append-only audit log of authentication and state-changing actions
+// ABOUTME: Records who did what and when, to stdout via slog or to AUDIT_LOG_FILE, never credentials
+
+package audit
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/markalston/diego-capacity-analyzer/backend/logger"
+)
+
+// Actions recorded in the audit log
+const (
+	ActionLogin                = "login"
+	ActionLogout               = "logout"
+	ActionSessionRefresh       = "session_refresh"
+	ActionSessionRevoke        = "session_revoke"
+	ActionUserSessionsRevoke   = "user_sessions_revoke"
+	ActionScenarioCompare      = "scenario_compare"
+	ActionScenarioBaselineSave = "scenario_baseline_save"
+	ActionInfrastructureUpdate = "infrastructure_update"
+	ActionInfrastructureClear  = "infrastructure_clear"
+)
+
+// Outcomes of an audited action
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// Event is one audited action. The fields are fixed so that request bodies,
+// tokens, passwords, and session IDs cannot reach the log through it.
+type Event struct {
+	Action   string
+	Username string // Empty when the caller is anonymous (e.g. AUTH_MODE=disabled)
+	Outcome  string
+	Target   string // What the action applied to, such as a user ID or state source. Optional.
+	Reason   string // Why the action failed. Optional.
+}
+
+// Logger writes audit events. A nil *Logger records through slog.Default, so
+// events land on stdout with the application log, marked audit=true.
+type Logger struct {
+	logger *slog.Logger
+	closer io.Closer
+}
+
+// New returns a Logger writing one JSON object per event to w
+func New(w io.Writer) *Logger {
+	return &Logger{logger: slog.New(logger.NewContextHandler(slog.NewJSONHandler(w, nil)))}
+}
+
+// Open returns a Logger appending to the file at path, creating it if needed.
+// Existing entries are never rewritten.
+func Open(path string) (*Logger, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open audit log: %w", err)
+	}
+	l := New(f)
+	l.closer = f
+	return l, nil
+}
+
+// Close closes the audit log file, if the Logger has one
+func (l *Logger) Close() error {
+	if l == nil || l.closer == nil {
+		return nil
+	}
+	return l.closer.Close()
+}
+
+// Record writes e with the current time and the request ID carried by ctx
+func (l *Logger) Record(ctx context.Context, e Event) {
+	attrs := []slog.Attr{
+		slog.Bool("audit", true),
+		slog.String("action", e.Action),
+		slog.String("username", e.Username),
+		slog.String("outcome", e.Outcome),
+	}
+	if e.Target != "" {
+		attrs = append(attrs, slog.String("target", e.Target))
+	}
+	if e.Reason != "" {
+		attrs = append(attrs, slog.String("reason", e.Reason))
+	}
+
+	out := slog.Default()
+	if l != nil && l.logger != nil {
+		out = l.logger
+	}
+	out.LogAttrs(ctx, slog.LevelInfo, "audit", attrs...)
+}
@@ -0,0 +1,81 @@
+// ABOUTME: Tests for the audit log
+// ABOUTME: Checks record fields, request ID propagation, and append-only file output
+
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/markalston/diego-capacity-analyzer/backend/logger"
+)
+
+func TestRecord(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf)
+
+	ctx := logger.WithRequestID(context.Background(), "req-123")
+	l.Record(ctx, Event{Action: ActionLogin, Username: "alice", Outcome: OutcomeFailure, Reason: "invalid_credentials"})
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Expected one JSON entry, got %q: %v", buf.String(), err)
+	}
+	want := map[string]any{
+		"msg":        "audit",
+		"audit":      true,
+		"action":     "login",
+		"username":   "alice",
+		"outcome":    "failure",
+		"reason":     "invalid_credentials",
+		"request_id": "req-123",
+	}
+	for key, value := range want {
+		if entry[key] != value {
+			t.Errorf("Expected %s=%v, got %v", key, value, entry[key])
+		}
+	}
+	if _, ok := entry["time"]; !ok {
+		t.Error("Expected a timestamp")
+	}
+	if _, ok := entry["target"]; ok {
+		t.Error("Expected an empty target to be left out")
+	}
+}
+
+func TestOpen_Appends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	for _, user := range []string{"alice", "bob"} {
+		l, err := Open(path)
+		if err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+		l.Record(context.Background(), Event{Action: ActionLogout, Username: user, Outcome: OutcomeSuccess})
+		if err := l.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read audit log: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"alice"`) || !strings.Contains(lines[1], `"bob"`) {
+		t.Errorf("Expected both entries in order, got:\n%s", data)
+	}
+}
+
+func TestNilLoggerUsesDefault(t *testing.T) {
+	var l *Logger
+	l.Record(context.Background(), Event{Action: ActionLogout, Outcome: OutcomeSuccess})
+	if err := l.Close(); err != nil {
+		t.Errorf("Expected Close on a nil Logger to succeed, got %v", err)
+	}
+}
@@ -1,11 +1,19 @@
 // ABOUTME: In-memory cache with TTL-based expiration
-// ABOUTME: Thread-safe cache using sync.Map with automatic cleanup
+// ABOUTME: Thread-safe cache using sync.Map with automatic cleanup, optional file persistence, and hit/miss stats
 
 package cache
 
 import (
+	"encoding/json"
+	"fmt"
 	"log/slog"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -15,21 +23,58 @@ type entry struct {
 }
 
 type Cache struct {
-	store sync.Map
-	ttl   time.Duration
+	store     sync.Map
+	ttl       time.Duration
+	jitterPct float64
+	hits      atomic.Int64
+	misses    atomic.Int64
+}
+
+// KeyStats describes one live cache entry
+type KeyStats struct {
+	Key                 string `json:"key"`
+	TTLRemainingSeconds int    `json:"ttl_remaining_seconds"`
+}
+
+// Stats is a point-in-time snapshot of the cache. Hits and misses count Get
+// calls since the cache was created; a Get of an expired entry is a miss.
+type Stats struct {
+	Entries int        `json:"entries"`
+	Hits    int64      `json:"hits"`
+	Misses  int64      `json:"misses"`
+	Keys    []KeyStats `json:"keys"`
 }
 
 func New(ttl time.Duration) *Cache {
+	return NewWithJitter(ttl, 0)
+}
+
+// NewWithJitter creates a cache whose Set and SetWithTTL spread each entry's
+// TTL uniformly within ±jitterPct percent, so entries populated together
+// (e.g. on startup) do not all expire and refresh at once. A jitterPct of 0
+// keeps exact TTLs.
+func NewWithJitter(ttl time.Duration, jitterPct float64) *Cache {
 	c := &Cache{
-		ttl: ttl,
+		ttl:       ttl,
+		jitterPct: jitterPct,
 	}
 	go c.startCleanup()
 	return c
 }
 
+// jittered returns ttl moved by a random amount within ±jitterPct percent
+func (c *Cache) jittered(ttl time.Duration) time.Duration {
+	if c.jitterPct <= 0 {
+		return ttl
+	}
+	spread := float64(ttl) * c.jitterPct / 100
+	return ttl + time.Duration((rand.Float64()*2-1)*spread)
+}
+
 func (c *Cache) Get(key string) (interface{}, bool) {
 	val, ok := c.store.Load(key)
 	if !ok {
+		c.misses.Add(1)
 		slog.Debug("Cache miss", "key", key)
 		return nil, false
 	}
@@ -37,25 +82,29 @@ func (c *Cache) Get(key string) (interface{}, bool) {
 	e := val.(entry)
 	if time.Now().After(e.expiresAt) {
 		c.store.Delete(key)
+		c.misses.Add(1)
 		slog.Debug("Cache expired", "key", key)
 		return nil, false
 	}
 
+	c.hits.Add(1)
 	slog.Debug("Cache hit", "key", key)
 	return e.data, true
 }
 
 func (c *Cache) Set(key string, value interface{}) {
-	e := entry{
-		data:      value,
-		expiresAt: time.Now().Add(c.ttl),
-	}
-	c.store.Store(key, e)
-	slog.Debug("Cache set", "key", key, "ttl", c.ttl)
+	c.SetExactTTL(key, value, c.jittered(c.ttl))
 }
 
-// SetWithTTL stores a value with a custom TTL
+// SetWithTTL stores a value with a custom TTL, jittered like Set
 func (c *Cache) SetWithTTL(key string, value interface{}, ttl time.Duration) {
+	c.SetExactTTL(key, value, c.jittered(ttl))
+}
+
+// SetExactTTL stores a value that expires after exactly ttl, ignoring jitter.
+// Use it when expiry must line up with something outside the cache, such as
+// a session's token lifetime.
+func (c *Cache) SetExactTTL(key string, value interface{}, ttl time.Duration) {
 	e := entry{
 		data:      value,
 		expiresAt: time.Now().Add(ttl),
@@ -64,10 +113,97 @@ func (c *Cache) SetWithTTL(key string, value interface{}, ttl time.Duration) {
 	slog.Debug("Cache set", "key", key, "ttl", ttl)
 }
 
+// SetPersistent stores a value and writes it as JSON to path so it can be
+// restored with LoadPersistent after a restart. The file is written to a
+// temporary file in the same directory and renamed into place, so readers
+// never see a partially written file. The in-memory value is stored even if
+// the write fails.
+func (c *Cache) SetPersistent(key string, value interface{}, path string) error {
+	c.Set(key, value)
+
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", key, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file for %s: %w", path, err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // no-op after a successful rename
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", tmpPath, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync %s: %w", tmpPath, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", tmpPath, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+
+	slog.Debug("Cache persisted", "key", key, "path", path)
+	return nil
+}
+
+// LoadPersistent reads a file written by SetPersistent into dest (a non-nil
+// pointer) and stores the decoded value under key. A missing file returns an
+// error wrapping os.ErrNotExist.
+func (c *Cache) LoadPersistent(key, path string, dest interface{}) error {
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("dest must be a non-nil pointer, got %T", dest)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, dest); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	c.Set(key, rv.Elem().Interface())
+	return nil
+}
+
 func (c *Cache) Clear(key string) {
 	c.store.Delete(key)
 }
 
+// Stats returns the live entries, sorted by key, with their remaining TTL
+// rounded up to whole seconds, and the hit/miss counters. Expired entries
+// awaiting cleanup are not counted.
+func (c *Cache) Stats() Stats {
+	now := time.Now()
+	stats := Stats{
+		Hits:   c.hits.Load(),
+		Misses: c.misses.Load(),
+		Keys:   []KeyStats{},
+	}
+	c.store.Range(func(key, val interface{}) bool {
+		e := val.(entry)
+		remaining := e.expiresAt.Sub(now)
+		if remaining <= 0 {
+			return true
+		}
+		stats.Keys = append(stats.Keys, KeyStats{
+			Key:                 key.(string),
+			TTLRemainingSeconds: int((remaining + time.Second - 1) / time.Second),
+		})
+		return true
+	})
+	sort.Slice(stats.Keys, func(i, j int) bool { return stats.Keys[i].Key < stats.Keys[j].Key })
+	stats.Entries = len(stats.Keys)
+	return stats
+}
+
 func (c *Cache) startCleanup() {
 	ticker := time.NewTicker(1 * time.Minute)
 	defer ticker.Stop()
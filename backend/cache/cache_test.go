@@ -1,6 +1,10 @@
 package cache
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -50,3 +54,151 @@ func TestCache_Clear(t *testing.T) {
 		t.Error("Expected key1 to be cleared")
 	}
 }
+
+func TestCache_Stats(t *testing.T) {
+	c := New(1 * time.Minute)
+
+	c.Set("b", "value")
+	c.SetWithTTL("a", "value", 10*time.Second)
+	c.SetWithTTL("expired", "value", -time.Second)
+
+	c.Get("a")
+	c.Get("b")
+	c.Get("missing")
+	c.Get("expired")
+
+	stats := c.Stats()
+	if stats.Hits != 2 || stats.Misses != 2 {
+		t.Errorf("Expected 2 hits and 2 misses, got %d hits and %d misses", stats.Hits, stats.Misses)
+	}
+	if stats.Entries != 2 || len(stats.Keys) != 2 {
+		t.Fatalf("Expected 2 live entries, got %d: %+v", stats.Entries, stats.Keys)
+	}
+	if stats.Keys[0].Key != "a" || stats.Keys[1].Key != "b" {
+		t.Errorf("Expected keys sorted a, b; got %+v", stats.Keys)
+	}
+	if ttl := stats.Keys[0].TTLRemainingSeconds; ttl < 9 || ttl > 10 {
+		t.Errorf("Expected ~10s remaining for a, got %d", ttl)
+	}
+	if ttl := stats.Keys[1].TTLRemainingSeconds; ttl < 59 || ttl > 60 {
+		t.Errorf("Expected ~60s remaining for b, got %d", ttl)
+	}
+}
+
+// expiresIn returns how long until key expires
+func expiresIn(t *testing.T, c *Cache, key string) time.Duration {
+	t.Helper()
+	val, ok := c.store.Load(key)
+	if !ok {
+		t.Fatalf("Expected %s to be stored", key)
+	}
+	return time.Until(val.(entry).expiresAt)
+}
+
+func TestCache_JitterSpreadsWithinBounds(t *testing.T) {
+	ttl := 100 * time.Second
+	c := NewWithJitter(ttl, 20)
+
+	lowest, highest := ttl, ttl
+	for i := range 200 {
+		key := fmt.Sprintf("key%d", i)
+		if i%2 == 0 {
+			c.Set(key, i)
+		} else {
+			c.SetWithTTL(key, i, ttl)
+		}
+		remaining := expiresIn(t, c, key)
+		if remaining < 79*time.Second || remaining > 120*time.Second {
+			t.Fatalf("Expected %s TTL within ±20%% of %s, got %s", key, ttl, remaining)
+		}
+		lowest = min(lowest, remaining)
+		highest = max(highest, remaining)
+	}
+
+	// 200 uniform draws over ±20s leave a wide spread; equal TTLs would mean no jitter
+	if highest-lowest < 20*time.Second {
+		t.Errorf("Expected expirations spread over at least 20s, got %s to %s", lowest, highest)
+	}
+}
+
+func TestCache_ZeroJitterKeepsExactTTL(t *testing.T) {
+	ttl := 100 * time.Second
+	for _, c := range []*Cache{New(ttl), NewWithJitter(ttl, 0)} {
+		c.Set("key", "value")
+		if remaining := expiresIn(t, c, "key"); remaining < ttl-time.Second || remaining > ttl {
+			t.Errorf("Expected exact TTL %s, got %s", ttl, remaining)
+		}
+	}
+
+	// SetExactTTL ignores jitter even when it is configured
+	c := NewWithJitter(ttl, 50)
+	for i := range 20 {
+		c.SetExactTTL("exact", i, ttl)
+		if remaining := expiresIn(t, c, "exact"); remaining < ttl-time.Second || remaining > ttl {
+			t.Fatalf("Expected SetExactTTL to keep %s, got %s", ttl, remaining)
+		}
+	}
+}
+
+type persistedValue struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func TestCache_PersistentRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	c := New(1 * time.Second)
+	if err := c.SetPersistent("key1", persistedValue{Name: "prod", Count: 3}, path); err != nil {
+		t.Fatalf("SetPersistent failed: %v", err)
+	}
+
+	// No temp files left behind after the rename
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("Expected only the state file in directory, got %d entries", len(entries))
+	}
+
+	restored := New(1 * time.Second)
+	var got persistedValue
+	if err := restored.LoadPersistent("key1", path, &got); err != nil {
+		t.Fatalf("LoadPersistent failed: %v", err)
+	}
+	if got.Name != "prod" || got.Count != 3 {
+		t.Errorf("Expected restored value {prod 3}, got %+v", got)
+	}
+
+	val, found := restored.Get("key1")
+	if !found {
+		t.Fatal("Expected LoadPersistent to populate the cache")
+	}
+	if val != (persistedValue{Name: "prod", Count: 3}) {
+		t.Errorf("Expected cached value to match, got %+v", val)
+	}
+}
+
+func TestCache_LoadPersistentErrors(t *testing.T) {
+	dir := t.TempDir()
+	c := New(1 * time.Second)
+	var dest persistedValue
+
+	err := c.LoadPersistent("key1", filepath.Join(dir, "missing.json"), &dest)
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected os.ErrNotExist for missing file, got %v", err)
+	}
+
+	corrupt := filepath.Join(dir, "corrupt.json")
+	if err := os.WriteFile(corrupt, []byte("{not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.LoadPersistent("key1", corrupt, &dest); err == nil {
+		t.Error("Expected error for unparseable file")
+	}
+	if _, found := c.Get("key1"); found {
+		t.Error("Expected failed load to leave cache empty")
+	}
+
+	if err := c.LoadPersistent("key1", corrupt, dest); err == nil {
+		t.Error("Expected error for non-pointer dest")
+	}
+}
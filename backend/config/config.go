@@ -4,25 +4,49 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"path"
+	"regexp"
 	"strconv"
 	"strings"
+
+	"github.com/markalston/diego-capacity-analyzer/backend/models"
 )
 
 type Config struct {
 	// Server
 	Port               string
 	CacheTTL           int      // seconds, default for general cache
+	CacheTTLJitterPct  int      // ±% each cache entry's TTL is randomly spread by (default 0 = exact)
 	DashboardTTL       int      // seconds, for BOSH/CF data (default 30s)
+	AppDataTTL         int      // seconds, for CF app totals behind infrastructure state (default 60s, 0 = no caching)
 	AuthMode           string   // disabled, optional, required (default: optional)
 	CORSAllowedOrigins []string // allowed CORS origins (empty = block all cross-origin)
 	CookieSecure       bool     // Set Secure flag on session cookies (default: true)
+	StateFile          string   // Path where infrastructure state is persisted across restarts (empty = disabled)
+	AuditLogFile       string   // Path the audit log is appended to (empty = stdout with the application log)
+	ShutdownTimeout    int      // seconds to drain in-flight requests on SIGINT/SIGTERM (default 15)
+	MetricsPublic      bool     // Serve /metrics without authentication even when AUTH_MODE=required (default: false)
 
 	// OAuth Client (for UAA password/refresh grants)
 	OAuthClientID     string
 	OAuthClientSecret string
 
+	// Session storage (redis lets multiple backend replicas share sessions)
+	SessionStore string // memory or redis (default: memory)
+	RedisURL     string // redis://[user:password@]host:port[/db], required when SESSION_STORE=redis
+
+	// Session limits in seconds, independent of token expiry (0 = disabled)
+	SessionIdleTimeout int // end sessions unused this long (default: 0)
+	SessionMaxLifetime int // end sessions this old however often refreshed (default: 0)
+
+	// JWKS (UAA token signing keys and Bearer token claim checks)
+	JWKSRefreshInterval  int    // seconds between background key reloads (default 300, 0 = disabled)
+	AuthExpectedIssuer   string // required iss claim for Bearer tokens (empty = not checked)
+	AuthExpectedAudience string // value that must appear in the aud claim (empty = not checked)
+
 	// Rate Limiting
 	RateLimitEnabled bool // Enable rate limiting (default: true)
 	RateLimitAuth    int  // Requests per minute for auth endpoints (default: 5)
@@ -30,19 +54,43 @@ type Config struct {
 	RateLimitWrite   int  // Requests per minute for write endpoints (default: 10)
 	RateLimitDefault int  // Requests per minute for all other endpoints (default: 100)
 
+	// Login lockout (failed attempts before a username or client IP is locked out)
+	LoginMaxFailures      int // per username (default: 5, 0 = disabled)
+	LoginMaxFailuresPerIP int // per client IP (default: 20, 0 = disabled)
+	LoginLockoutSecs      int // lockout window in seconds (default: 900)
+
 	// CF API
 	CFAPIUrl            string
 	CFUsername          string
 	CFPassword          string
 	CFSkipSSLValidation bool // explicit opt-in for insecure connections
 
+	// Prometheus (optional) for app usage totals read with PromQL
+	PrometheusURL               string
+	PrometheusBearerToken       string // sent as Authorization: Bearer (empty = no token)
+	PrometheusUsername          string // basic auth, exclusive with the bearer token
+	PrometheusPassword          string
+	PrometheusSkipSSLValidation bool   // explicit opt-in for insecure connections
+	PrometheusQueryTimeout      int    // seconds each query may run (default 30)
+	PrometheusAppMemoryQuery    string // PromQL for total app memory in bytes (required with PROMETHEUS_URL)
+	PrometheusAppInstancesQuery string // PromQL for total app instances (required with PROMETHEUS_URL)
+	PrometheusAppDiskQuery      string // PromQL for total app disk in bytes (empty = disk totals left as they are)
+
 	// BOSH API (optional)
 	BOSHEnvironment       string
 	BOSHClient            string
 	BOSHSecret            string
 	BOSHCACert            string
 	BOSHDeployment        string
-	BOSHSkipSSLValidation bool // explicit opt-in for insecure connections (only if no CA cert)
+	BOSHSkipSSLValidation bool     // explicit opt-in for insecure connections (only if no CA cert)
+	BOSHDeploymentInclude []string // glob patterns selecting deployments to scan (empty = cf-* and p-isolation-segment*)
+	BOSHDeploymentExclude []string // glob patterns for deployments to skip, applied after include
+	BOSHMaxConcurrency    int      // deployments queried in parallel (default 4)
+	BOSHTaskTimeout       int      // seconds to wait for a BOSH task to finish (default 120)
+	BOSHCellJobNames      []string // instance group names of Diego cells (empty = diego_cell, compute, *diego_cell*)
+
+	// Deployment name to isolation segment name (BOSH_SEGMENT_MAP); unmapped deployments use the name heuristic
+	BOSHSegmentMap map[string]string
 
 	// CredHub (optional)
 	CredHubURL    string
@@ -55,7 +103,12 @@ type Config struct {
 	VSpherePassword   string
 	VSphereDatacenter string
 	VSphereInsecure   bool
-	VSphereCacheTTL   int // seconds, default 300 (5 min)
+	VSphereCACert     string // PEM CA bundle for vCenter (empty = system roots)
+	VSphereCacheTTL   int    // seconds, default 300 (5 min)
+
+	// vSphere Diego cell detection (optional; empty = built-in name heuristics)
+	VSphereCellNamePatterns []string // regexes matched against the job name attribute or VM name
+	VSphereCellCustomAttr   string   // BOSH custom attribute carrying the job name (e.g. "job")
 
 	// AI Provider (optional)
 	AIProvider        string
@@ -66,6 +119,10 @@ type Config struct {
 
 	// Rate Limiting (chat)
 	RateLimitChat int // Requests per minute for chat endpoint (default: 10)
+
+	// Scenario modeling (optional)
+	TPSCurve   []models.TPSPt    // Measured TPS curve used when a request omits tps_curve
+	Thresholds models.Thresholds // Capacity thresholds: THRESHOLDS, then CPU_RATIO_MEDIUM/CPU_RATIO_HIGH, over the defaults
 }
 
 // VSphereConfigured returns true if vSphere credentials are set
@@ -82,31 +139,66 @@ func Load() (*Config, error) {
 	cfg := &Config{
 		Port:               getEnv("PORT", "8080"),
 		CacheTTL:           getEnvInt("CACHE_TTL", 300),
+		CacheTTLJitterPct:  getEnvInt("CACHE_TTL_JITTER_PCT", 0),
 		DashboardTTL:       getEnvInt("DASHBOARD_CACHE_TTL", 30),
+		AppDataTTL:         getEnvInt("APP_DATA_CACHE_TTL", 60),
 		AuthMode:           getEnv("AUTH_MODE", "optional"),
 		CORSAllowedOrigins: getEnvStringList("CORS_ALLOWED_ORIGINS"),
 		CookieSecure:       getEnvBool("COOKIE_SECURE", true),
+		StateFile:          os.Getenv("STATE_FILE"),
+		AuditLogFile:       os.Getenv("AUDIT_LOG_FILE"),
+		ShutdownTimeout:    getEnvInt("SHUTDOWN_TIMEOUT", 15),
+		MetricsPublic:      getEnvBool("METRICS_PUBLIC", false),
 
 		OAuthClientID:     getEnv("OAUTH_CLIENT_ID", "cf"),
 		OAuthClientSecret: os.Getenv("OAUTH_CLIENT_SECRET"),
 
+		SessionStore: strings.ToLower(strings.TrimSpace(getEnv("SESSION_STORE", "memory"))),
+		RedisURL:     os.Getenv("REDIS_URL"),
+
+		SessionIdleTimeout: getEnvInt("SESSION_IDLE_TIMEOUT", 0),
+		SessionMaxLifetime: getEnvInt("SESSION_MAX_LIFETIME", 0),
+
+		JWKSRefreshInterval:  getEnvInt("JWKS_REFRESH_INTERVAL", 300),
+		AuthExpectedIssuer:   strings.TrimSpace(os.Getenv("AUTH_EXPECTED_ISSUER")),
+		AuthExpectedAudience: strings.TrimSpace(os.Getenv("AUTH_EXPECTED_AUDIENCE")),
+
 		RateLimitEnabled: getEnvBool("RATE_LIMIT_ENABLED", true),
 		RateLimitAuth:    getEnvInt("RATE_LIMIT_AUTH", 5),
 		RateLimitRefresh: getEnvInt("RATE_LIMIT_REFRESH", 10),
 		RateLimitWrite:   getEnvInt("RATE_LIMIT_WRITE", 10),
 		RateLimitDefault: getEnvInt("RATE_LIMIT_DEFAULT", 100),
 
+		LoginMaxFailures:      getEnvInt("LOGIN_MAX_FAILURES", 5),
+		LoginMaxFailuresPerIP: getEnvInt("LOGIN_MAX_FAILURES_PER_IP", 20),
+		LoginLockoutSecs:      getEnvInt("LOGIN_LOCKOUT_SECS", 900),
+
 		CFAPIUrl:            ensureScheme(os.Getenv("CF_API_URL")),
 		CFUsername:          os.Getenv("CF_USERNAME"),
 		CFPassword:          os.Getenv("CF_PASSWORD"),
 		CFSkipSSLValidation: getEnvBool("CF_SKIP_SSL_VALIDATION", false),
 
+		PrometheusURL:               ensureScheme(strings.TrimRight(os.Getenv("PROMETHEUS_URL"), "/")),
+		PrometheusBearerToken:       os.Getenv("PROMETHEUS_BEARER_TOKEN"),
+		PrometheusUsername:          os.Getenv("PROMETHEUS_USERNAME"),
+		PrometheusPassword:          os.Getenv("PROMETHEUS_PASSWORD"),
+		PrometheusSkipSSLValidation: getEnvBool("PROMETHEUS_SKIP_SSL_VALIDATION", false),
+		PrometheusQueryTimeout:      getEnvInt("PROMETHEUS_QUERY_TIMEOUT", 30),
+		PrometheusAppMemoryQuery:    strings.TrimSpace(os.Getenv("PROMETHEUS_APP_MEMORY_QUERY")),
+		PrometheusAppInstancesQuery: strings.TrimSpace(os.Getenv("PROMETHEUS_APP_INSTANCES_QUERY")),
+		PrometheusAppDiskQuery:      strings.TrimSpace(os.Getenv("PROMETHEUS_APP_DISK_QUERY")),
+
 		BOSHEnvironment:       ensureScheme(os.Getenv("BOSH_ENVIRONMENT")),
 		BOSHClient:            os.Getenv("BOSH_CLIENT"),
 		BOSHSecret:            os.Getenv("BOSH_CLIENT_SECRET"),
 		BOSHCACert:            os.Getenv("BOSH_CA_CERT"),
 		BOSHDeployment:        os.Getenv("BOSH_DEPLOYMENT"),
 		BOSHSkipSSLValidation: getEnvBool("BOSH_SKIP_SSL_VALIDATION", false),
+		BOSHDeploymentInclude: getEnvStringList("BOSH_DEPLOYMENT_INCLUDE"),
+		BOSHDeploymentExclude: getEnvStringList("BOSH_DEPLOYMENT_EXCLUDE"),
+		BOSHMaxConcurrency:    getEnvInt("BOSH_MAX_CONCURRENCY", 4),
+		BOSHTaskTimeout:       getEnvInt("BOSH_TASK_TIMEOUT", 120),
+		BOSHCellJobNames:      getEnvStringList("BOSH_CELL_JOB_NAMES"),
 
 		CredHubURL:    ensureScheme(os.Getenv("CREDHUB_URL")),
 		CredHubClient: os.Getenv("CREDHUB_CLIENT"),
@@ -117,8 +209,12 @@ func Load() (*Config, error) {
 		VSpherePassword:   os.Getenv("VSPHERE_PASSWORD"),
 		VSphereDatacenter: os.Getenv("VSPHERE_DATACENTER"),
 		VSphereInsecure:   getEnvBool("VSPHERE_INSECURE", false),
+		VSphereCACert:     os.Getenv("VSPHERE_CA_CERT"),
 		VSphereCacheTTL:   getEnvInt("VSPHERE_CACHE_TTL", 300),
 
+		VSphereCellNamePatterns: getEnvStringList("VSPHERE_CELL_NAME_PATTERNS"),
+		VSphereCellCustomAttr:   strings.TrimSpace(os.Getenv("VSPHERE_CELL_CUSTOM_ATTR")),
+
 		AIProvider:        os.Getenv("AI_PROVIDER"),
 		AIAPIKey:          os.Getenv("AI_API_KEY"),
 		AIModel:           getEnv("AI_MODEL", "claude-sonnet-4-5-20250514"),
@@ -159,6 +255,123 @@ func Load() (*Config, error) {
 		}
 	}
 
+	// Parse operator-supplied TPS curve; malformed input is a startup error
+	tpsCurve, err := parseTPSCurve(os.Getenv("TPS_CURVE"))
+	if err != nil {
+		return nil, err
+	}
+	cfg.TPSCurve = tpsCurve
+
+	// Parse the deployment-to-segment map; a malformed entry is a startup error
+	segmentMap, err := parseSegmentMap(os.Getenv("BOSH_SEGMENT_MAP"))
+	if err != nil {
+		return nil, err
+	}
+	cfg.BOSHSegmentMap = segmentMap
+
+	// Parse operator threshold overrides; unknown keys and inverted limits fail at startup
+	thresholds, err := parseThresholds(os.Getenv("THRESHOLDS"))
+	if err != nil {
+		return nil, err
+	}
+
+	// CPU_RATIO_MEDIUM and CPU_RATIO_HIGH set the vCPU:pCPU risk tiers,
+	// taking precedence over the same keys in THRESHOLDS
+	thresholds.VCPURatioModerate = getEnvFloat("CPU_RATIO_MEDIUM", thresholds.VCPURatioModerate)
+	thresholds.VCPURatioAggressive = getEnvFloat("CPU_RATIO_HIGH", thresholds.VCPURatioAggressive)
+	if thresholds.VCPURatioModerate <= 0 {
+		return nil, fmt.Errorf("CPU_RATIO_MEDIUM must be positive, got %g", thresholds.VCPURatioModerate)
+	}
+	if thresholds.VCPURatioAggressive < thresholds.VCPURatioModerate {
+		return nil, fmt.Errorf("CPU_RATIO_HIGH (%g) must not be below CPU_RATIO_MEDIUM (%g)",
+			thresholds.VCPURatioAggressive, thresholds.VCPURatioModerate)
+	}
+	cfg.Thresholds = thresholds
+
+	// Validate BOSH deployment filter patterns so typos fail at startup, not silently at discovery
+	for _, filter := range []struct {
+		name     string
+		patterns []string
+	}{
+		{"BOSH_DEPLOYMENT_INCLUDE", cfg.BOSHDeploymentInclude},
+		{"BOSH_DEPLOYMENT_EXCLUDE", cfg.BOSHDeploymentExclude},
+	} {
+		for _, pattern := range filter.patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("%s contains invalid pattern %q: %w", filter.name, pattern, err)
+			}
+		}
+	}
+
+	if cfg.CacheTTLJitterPct < 0 || cfg.CacheTTLJitterPct > 50 {
+		return nil, fmt.Errorf("CACHE_TTL_JITTER_PCT must be between 0 and 50, got %d", cfg.CacheTTLJitterPct)
+	}
+
+	switch cfg.SessionStore {
+	case "memory":
+	case "redis":
+		if cfg.RedisURL == "" {
+			return nil, fmt.Errorf("REDIS_URL is required when SESSION_STORE=redis")
+		}
+	default:
+		return nil, fmt.Errorf("unknown SESSION_STORE %q, supported values: memory, redis", cfg.SessionStore)
+	}
+
+	if cfg.SessionIdleTimeout < 0 {
+		return nil, fmt.Errorf("SESSION_IDLE_TIMEOUT must not be negative, got %d", cfg.SessionIdleTimeout)
+	}
+	if cfg.SessionMaxLifetime < 0 {
+		return nil, fmt.Errorf("SESSION_MAX_LIFETIME must not be negative, got %d", cfg.SessionMaxLifetime)
+	}
+
+	if cfg.ShutdownTimeout < 1 {
+		return nil, fmt.Errorf("SHUTDOWN_TIMEOUT must be positive, got %d", cfg.ShutdownTimeout)
+	}
+
+	if cfg.AppDataTTL < 0 {
+		return nil, fmt.Errorf("APP_DATA_CACHE_TTL must not be negative, got %d", cfg.AppDataTTL)
+	}
+
+	if cfg.JWKSRefreshInterval < 0 {
+		return nil, fmt.Errorf("JWKS_REFRESH_INTERVAL must not be negative, got %d", cfg.JWKSRefreshInterval)
+	}
+
+	if cfg.PrometheusURL != "" {
+		if cfg.PrometheusAppMemoryQuery == "" || cfg.PrometheusAppInstancesQuery == "" {
+			return nil, fmt.Errorf("PROMETHEUS_APP_MEMORY_QUERY and PROMETHEUS_APP_INSTANCES_QUERY are required when PROMETHEUS_URL is set")
+		}
+		if cfg.PrometheusBearerToken != "" && cfg.PrometheusUsername != "" {
+			return nil, fmt.Errorf("PROMETHEUS_BEARER_TOKEN and PROMETHEUS_USERNAME are mutually exclusive")
+		}
+		if cfg.PrometheusQueryTimeout < 1 {
+			return nil, fmt.Errorf("PROMETHEUS_QUERY_TIMEOUT must be positive, got %d", cfg.PrometheusQueryTimeout)
+		}
+	}
+
+	if cfg.BOSHMaxConcurrency < 1 {
+		return nil, fmt.Errorf("BOSH_MAX_CONCURRENCY must be positive, got %d", cfg.BOSHMaxConcurrency)
+	}
+	if cfg.BOSHTaskTimeout < 1 {
+		return nil, fmt.Errorf("BOSH_TASK_TIMEOUT must be positive, got %d", cfg.BOSHTaskTimeout)
+	}
+
+	// Validate vSphere cell detection regexes
+	for _, pattern := range cfg.VSphereCellNamePatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("VSPHERE_CELL_NAME_PATTERNS contains invalid regex %q: %w", pattern, err)
+		}
+	}
+
+	if cfg.LoginMaxFailures < 0 {
+		return nil, fmt.Errorf("LOGIN_MAX_FAILURES must not be negative, got %d", cfg.LoginMaxFailures)
+	}
+	if cfg.LoginMaxFailuresPerIP < 0 {
+		return nil, fmt.Errorf("LOGIN_MAX_FAILURES_PER_IP must not be negative, got %d", cfg.LoginMaxFailuresPerIP)
+	}
+	if cfg.LoginLockoutSecs < 1 {
+		return nil, fmt.Errorf("LOGIN_LOCKOUT_SECS must be positive, got %d", cfg.LoginLockoutSecs)
+	}
+
 	// Validate rate limit values
 	for _, rl := range []struct {
 		name  string
@@ -178,6 +391,89 @@ func Load() (*Config, error) {
 	return cfg, nil
 }
 
+// parseTPSCurve parses a JSON array of {"cells":N,"tps":N} points.
+// Points must be ordered by strictly increasing cell count.
+func parseTPSCurve(value string) ([]models.TPSPt, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+
+	var curve []models.TPSPt
+	if err := json.Unmarshal([]byte(value), &curve); err != nil {
+		return nil, fmt.Errorf("TPS_CURVE must be a JSON array of {\"cells\",\"tps\"} points: %w", err)
+	}
+	if len(curve) == 0 {
+		return nil, fmt.Errorf("TPS_CURVE must contain at least one point")
+	}
+
+	for i, pt := range curve {
+		if pt.Cells < 1 {
+			return nil, fmt.Errorf("TPS_CURVE point %d: cells must be positive, got %d", i, pt.Cells)
+		}
+		if pt.TPS < 0 {
+			return nil, fmt.Errorf("TPS_CURVE point %d: tps must not be negative, got %d", i, pt.TPS)
+		}
+		if i > 0 && pt.Cells <= curve[i-1].Cells {
+			return nil, fmt.Errorf("TPS_CURVE points must have strictly increasing cells, got %d after %d", pt.Cells, curve[i-1].Cells)
+		}
+	}
+
+	return curve, nil
+}
+
+// parseSegmentMap parses deployment-to-isolation-segment names, given either
+// as a JSON object ({"p-isolation-segment-abc123":"blue"}) or as
+// comma-separated deployment=segment pairs. Names must not be empty.
+func parseSegmentMap(value string) (map[string]string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+
+	segments := make(map[string]string)
+	if strings.HasPrefix(value, "{") {
+		if err := json.Unmarshal([]byte(value), &segments); err != nil {
+			return nil, fmt.Errorf("BOSH_SEGMENT_MAP must be a JSON object of deployment names to segment names: %w", err)
+		}
+	} else {
+		for _, pair := range strings.Split(value, ",") {
+			deployment, segment, ok := strings.Cut(pair, "=")
+			if !ok {
+				return nil, fmt.Errorf("BOSH_SEGMENT_MAP entry %q must be deployment=segment", strings.TrimSpace(pair))
+			}
+			segments[strings.TrimSpace(deployment)] = strings.TrimSpace(segment)
+		}
+	}
+
+	for deployment, segment := range segments {
+		if deployment == "" || segment == "" {
+			return nil, fmt.Errorf("BOSH_SEGMENT_MAP entry %q=%q must name both a deployment and a segment", deployment, segment)
+		}
+	}
+	return segments, nil
+}
+
+// parseThresholds parses a JSON object of threshold overrides, such as
+// {"n1_critical_pct":80}. Omitted thresholds keep their defaults.
+func parseThresholds(value string) (models.Thresholds, error) {
+	if strings.TrimSpace(value) == "" {
+		return models.DefaultThresholds(), nil
+	}
+
+	var t models.Thresholds
+	dec := json.NewDecoder(strings.NewReader(value))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&t); err != nil {
+		return models.Thresholds{}, fmt.Errorf("THRESHOLDS must be a JSON object of threshold overrides: %w", err)
+	}
+
+	t = t.Resolve()
+	if err := t.Validate(); err != nil {
+		return models.Thresholds{}, fmt.Errorf("THRESHOLDS: %w", err)
+	}
+	return t, nil
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -194,6 +490,15 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatVal, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatVal
+		}
+	}
+	return defaultValue
+}
+
 func getEnvBool(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
 		if boolVal, err := strconv.ParseBool(value); err == nil {
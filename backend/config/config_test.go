@@ -2,8 +2,11 @@ package config
 
 import (
 	"os"
+	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/markalston/diego-capacity-analyzer/backend/models"
 )
 
 func TestLoadConfig_RequiredFields(t *testing.T) {
@@ -438,3 +441,755 @@ func TestLoadConfig_AITimeoutDefaults(t *testing.T) {
 		t.Errorf("Expected default AIMaxDurationSecs 300, got %d", cfg.AIMaxDurationSecs)
 	}
 }
+
+func TestLoadConfig_BOSHDeploymentFilters(t *testing.T) {
+	t.Cleanup(withCleanCFEnvAndExtra(t, map[string]string{
+		"BOSH_DEPLOYMENT_INCLUDE": "prod-cf, seg-*",
+		"BOSH_DEPLOYMENT_EXCLUDE": "*-sandbox",
+	}))
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(cfg.BOSHDeploymentInclude) != 2 || cfg.BOSHDeploymentInclude[1] != "seg-*" {
+		t.Errorf("Expected include [prod-cf seg-*], got %v", cfg.BOSHDeploymentInclude)
+	}
+	if len(cfg.BOSHDeploymentExclude) != 1 || cfg.BOSHDeploymentExclude[0] != "*-sandbox" {
+		t.Errorf("Expected exclude [*-sandbox], got %v", cfg.BOSHDeploymentExclude)
+	}
+}
+
+func TestLoadConfig_BOSHDeploymentFiltersInvalid(t *testing.T) {
+	t.Cleanup(withCleanCFEnvAndExtra(t, map[string]string{
+		"BOSH_DEPLOYMENT_EXCLUDE": "seg-[",
+	}))
+
+	_, err := Load()
+	if err == nil {
+		t.Fatal("Expected error for malformed pattern")
+	}
+	if !strings.Contains(err.Error(), "BOSH_DEPLOYMENT_EXCLUDE") {
+		t.Errorf("Expected error to name BOSH_DEPLOYMENT_EXCLUDE, got %v", err)
+	}
+}
+
+func TestLoadConfig_BOSHMaxConcurrency(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		t.Cleanup(withCleanCFEnv(t))
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if cfg.BOSHMaxConcurrency != 4 {
+			t.Errorf("Expected default BOSHMaxConcurrency 4, got %d", cfg.BOSHMaxConcurrency)
+		}
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		t.Cleanup(withCleanCFEnvAndExtra(t, map[string]string{"BOSH_MAX_CONCURRENCY": "8"}))
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if cfg.BOSHMaxConcurrency != 8 {
+			t.Errorf("Expected BOSHMaxConcurrency 8, got %d", cfg.BOSHMaxConcurrency)
+		}
+	})
+
+	t.Run("zero is rejected", func(t *testing.T) {
+		t.Cleanup(withCleanCFEnvAndExtra(t, map[string]string{"BOSH_MAX_CONCURRENCY": "0"}))
+		_, err := Load()
+		if err == nil || !strings.Contains(err.Error(), "BOSH_MAX_CONCURRENCY") {
+			t.Errorf("Expected BOSH_MAX_CONCURRENCY error, got %v", err)
+		}
+	})
+}
+
+func TestLoadConfig_CacheTTLJitterPct(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		t.Cleanup(withCleanCFEnv(t))
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if cfg.CacheTTLJitterPct != 0 {
+			t.Errorf("Expected default CacheTTLJitterPct 0, got %d", cfg.CacheTTLJitterPct)
+		}
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		t.Cleanup(withCleanCFEnvAndExtra(t, map[string]string{"CACHE_TTL_JITTER_PCT": "10"}))
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if cfg.CacheTTLJitterPct != 10 {
+			t.Errorf("Expected CacheTTLJitterPct 10, got %d", cfg.CacheTTLJitterPct)
+		}
+	})
+
+	for _, value := range []string{"-1", "51"} {
+		t.Run("rejects "+value, func(t *testing.T) {
+			t.Cleanup(withCleanCFEnvAndExtra(t, map[string]string{"CACHE_TTL_JITTER_PCT": value}))
+			_, err := Load()
+			if err == nil || !strings.Contains(err.Error(), "CACHE_TTL_JITTER_PCT") {
+				t.Errorf("Expected CACHE_TTL_JITTER_PCT error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_SessionStore(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		t.Cleanup(withCleanCFEnv(t))
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if cfg.SessionStore != "memory" {
+			t.Errorf("Expected default SessionStore memory, got %q", cfg.SessionStore)
+		}
+	})
+
+	t.Run("redis", func(t *testing.T) {
+		t.Cleanup(withCleanCFEnvAndExtra(t, map[string]string{
+			"SESSION_STORE": "Redis",
+			"REDIS_URL":     "redis://localhost:6379/0",
+		}))
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if cfg.SessionStore != "redis" {
+			t.Errorf("Expected SessionStore redis, got %q", cfg.SessionStore)
+		}
+		if cfg.RedisURL != "redis://localhost:6379/0" {
+			t.Errorf("Expected RedisURL to be loaded, got %q", cfg.RedisURL)
+		}
+	})
+
+	t.Run("redis requires REDIS_URL", func(t *testing.T) {
+		t.Cleanup(withCleanCFEnvAndExtra(t, map[string]string{"SESSION_STORE": "redis"}))
+		_, err := Load()
+		if err == nil || !strings.Contains(err.Error(), "REDIS_URL") {
+			t.Errorf("Expected REDIS_URL error, got %v", err)
+		}
+	})
+
+	t.Run("rejects unknown store", func(t *testing.T) {
+		t.Cleanup(withCleanCFEnvAndExtra(t, map[string]string{"SESSION_STORE": "memcached"}))
+		_, err := Load()
+		if err == nil || !strings.Contains(err.Error(), "SESSION_STORE") {
+			t.Errorf("Expected SESSION_STORE error, got %v", err)
+		}
+	})
+}
+
+func TestLoadConfig_SessionLimits(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		t.Cleanup(withCleanCFEnv(t))
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if cfg.SessionIdleTimeout != 0 || cfg.SessionMaxLifetime != 0 {
+			t.Errorf("Expected session limits disabled by default, got %d/%d", cfg.SessionIdleTimeout, cfg.SessionMaxLifetime)
+		}
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		t.Cleanup(withCleanCFEnvAndExtra(t, map[string]string{
+			"SESSION_IDLE_TIMEOUT": "900",
+			"SESSION_MAX_LIFETIME": "43200",
+		}))
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if cfg.SessionIdleTimeout != 900 || cfg.SessionMaxLifetime != 43200 {
+			t.Errorf("Expected 900/43200, got %d/%d", cfg.SessionIdleTimeout, cfg.SessionMaxLifetime)
+		}
+	})
+
+	for _, env := range []string{"SESSION_IDLE_TIMEOUT", "SESSION_MAX_LIFETIME"} {
+		t.Run("rejects negative "+env, func(t *testing.T) {
+			t.Cleanup(withCleanCFEnvAndExtra(t, map[string]string{env: "-1"}))
+			_, err := Load()
+			if err == nil || !strings.Contains(err.Error(), env) {
+				t.Errorf("Expected %s error, got %v", env, err)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_LoginLockout(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		t.Cleanup(withCleanCFEnv(t))
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if cfg.LoginMaxFailures != 5 || cfg.LoginMaxFailuresPerIP != 20 || cfg.LoginLockoutSecs != 900 {
+			t.Errorf("Expected defaults 5/20/900, got %d/%d/%d",
+				cfg.LoginMaxFailures, cfg.LoginMaxFailuresPerIP, cfg.LoginLockoutSecs)
+		}
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		t.Cleanup(withCleanCFEnvAndExtra(t, map[string]string{
+			"LOGIN_MAX_FAILURES":        "3",
+			"LOGIN_MAX_FAILURES_PER_IP": "0",
+			"LOGIN_LOCKOUT_SECS":        "60",
+		}))
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if cfg.LoginMaxFailures != 3 || cfg.LoginMaxFailuresPerIP != 0 || cfg.LoginLockoutSecs != 60 {
+			t.Errorf("Expected 3/0/60, got %d/%d/%d",
+				cfg.LoginMaxFailures, cfg.LoginMaxFailuresPerIP, cfg.LoginLockoutSecs)
+		}
+	})
+
+	for env, value := range map[string]string{
+		"LOGIN_MAX_FAILURES":        "-1",
+		"LOGIN_MAX_FAILURES_PER_IP": "-1",
+		"LOGIN_LOCKOUT_SECS":        "0",
+	} {
+		t.Run("rejects "+env, func(t *testing.T) {
+			t.Cleanup(withCleanCFEnvAndExtra(t, map[string]string{env: value}))
+			_, err := Load()
+			if err == nil || !strings.Contains(err.Error(), env) {
+				t.Errorf("Expected %s error, got %v", env, err)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_BOSHTaskTimeout(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		t.Cleanup(withCleanCFEnv(t))
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if cfg.BOSHTaskTimeout != 120 {
+			t.Errorf("Expected default BOSHTaskTimeout 120, got %d", cfg.BOSHTaskTimeout)
+		}
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		t.Cleanup(withCleanCFEnvAndExtra(t, map[string]string{"BOSH_TASK_TIMEOUT": "300"}))
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if cfg.BOSHTaskTimeout != 300 {
+			t.Errorf("Expected BOSHTaskTimeout 300, got %d", cfg.BOSHTaskTimeout)
+		}
+	})
+
+	t.Run("negative is rejected", func(t *testing.T) {
+		t.Cleanup(withCleanCFEnvAndExtra(t, map[string]string{"BOSH_TASK_TIMEOUT": "-5"}))
+		_, err := Load()
+		if err == nil || !strings.Contains(err.Error(), "BOSH_TASK_TIMEOUT") {
+			t.Errorf("Expected BOSH_TASK_TIMEOUT error, got %v", err)
+		}
+	})
+}
+
+func TestLoadConfig_JWKSRefreshInterval(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		t.Cleanup(withCleanCFEnv(t))
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if cfg.JWKSRefreshInterval != 300 {
+			t.Errorf("Expected default JWKSRefreshInterval 300, got %d", cfg.JWKSRefreshInterval)
+		}
+	})
+
+	t.Run("zero disables", func(t *testing.T) {
+		t.Cleanup(withCleanCFEnvAndExtra(t, map[string]string{"JWKS_REFRESH_INTERVAL": "0"}))
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if cfg.JWKSRefreshInterval != 0 {
+			t.Errorf("Expected JWKSRefreshInterval 0, got %d", cfg.JWKSRefreshInterval)
+		}
+	})
+
+	t.Run("negative is rejected", func(t *testing.T) {
+		t.Cleanup(withCleanCFEnvAndExtra(t, map[string]string{"JWKS_REFRESH_INTERVAL": "-1"}))
+		_, err := Load()
+		if err == nil || !strings.Contains(err.Error(), "JWKS_REFRESH_INTERVAL") {
+			t.Errorf("Expected JWKS_REFRESH_INTERVAL error, got %v", err)
+		}
+	})
+}
+
+func TestLoadConfig_AppDataTTL(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		t.Cleanup(withCleanCFEnv(t))
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if cfg.AppDataTTL != 60 {
+			t.Errorf("Expected default AppDataTTL 60, got %d", cfg.AppDataTTL)
+		}
+	})
+
+	t.Run("negative is rejected", func(t *testing.T) {
+		t.Cleanup(withCleanCFEnvAndExtra(t, map[string]string{"APP_DATA_CACHE_TTL": "-1"}))
+		_, err := Load()
+		if err == nil || !strings.Contains(err.Error(), "APP_DATA_CACHE_TTL") {
+			t.Errorf("Expected APP_DATA_CACHE_TTL error, got %v", err)
+		}
+	})
+}
+
+func TestLoadConfig_ShutdownTimeout(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		t.Cleanup(withCleanCFEnv(t))
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if cfg.ShutdownTimeout != 15 {
+			t.Errorf("Expected default ShutdownTimeout 15, got %d", cfg.ShutdownTimeout)
+		}
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		t.Cleanup(withCleanCFEnvAndExtra(t, map[string]string{"SHUTDOWN_TIMEOUT": "45"}))
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if cfg.ShutdownTimeout != 45 {
+			t.Errorf("Expected ShutdownTimeout 45, got %d", cfg.ShutdownTimeout)
+		}
+	})
+
+	t.Run("zero is rejected", func(t *testing.T) {
+		t.Cleanup(withCleanCFEnvAndExtra(t, map[string]string{"SHUTDOWN_TIMEOUT": "0"}))
+		_, err := Load()
+		if err == nil || !strings.Contains(err.Error(), "SHUTDOWN_TIMEOUT") {
+			t.Errorf("Expected SHUTDOWN_TIMEOUT error, got %v", err)
+		}
+	})
+}
+
+func TestLoadConfig_AuthExpectedClaims(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		t.Cleanup(withCleanCFEnv(t))
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if cfg.AuthExpectedIssuer != "" || cfg.AuthExpectedAudience != "" {
+			t.Errorf("Expected no issuer/audience checks by default, got %q/%q", cfg.AuthExpectedIssuer, cfg.AuthExpectedAudience)
+		}
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		t.Cleanup(withCleanCFEnvAndExtra(t, map[string]string{
+			"AUTH_EXPECTED_ISSUER":   "https://uaa.sys.example.com/oauth/token",
+			"AUTH_EXPECTED_AUDIENCE": " diego-analyzer ",
+		}))
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if cfg.AuthExpectedIssuer != "https://uaa.sys.example.com/oauth/token" {
+			t.Errorf("Expected issuer to be set, got %q", cfg.AuthExpectedIssuer)
+		}
+		if cfg.AuthExpectedAudience != "diego-analyzer" {
+			t.Errorf("Expected trimmed audience 'diego-analyzer', got %q", cfg.AuthExpectedAudience)
+		}
+	})
+}
+
+func TestLoadConfig_VSphereCellDetection(t *testing.T) {
+	t.Cleanup(withCleanCFEnvAndExtra(t, map[string]string{
+		"VSPHERE_CELL_NAME_PATTERNS": `^diego_cell$, ^isolated_diego_cell$`,
+		"VSPHERE_CELL_CUSTOM_ATTR":   "job",
+	}))
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(cfg.VSphereCellNamePatterns) != 2 || cfg.VSphereCellNamePatterns[1] != "^isolated_diego_cell$" {
+		t.Errorf("Expected two cell patterns, got %v", cfg.VSphereCellNamePatterns)
+	}
+	if cfg.VSphereCellCustomAttr != "job" {
+		t.Errorf("Expected custom attr 'job', got %q", cfg.VSphereCellCustomAttr)
+	}
+}
+
+func TestLoadConfig_VSphereCellPatternsInvalid(t *testing.T) {
+	t.Cleanup(withCleanCFEnvAndExtra(t, map[string]string{
+		"VSPHERE_CELL_NAME_PATTERNS": "diego_cell([",
+	}))
+
+	_, err := Load()
+	if err == nil {
+		t.Fatal("Expected error for invalid regex")
+	}
+	if !strings.Contains(err.Error(), "VSPHERE_CELL_NAME_PATTERNS") {
+		t.Errorf("Expected error to name VSPHERE_CELL_NAME_PATTERNS, got %v", err)
+	}
+}
+
+func TestLoadConfig_StateFile(t *testing.T) {
+	t.Cleanup(withCleanCFEnv(t))
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.StateFile != "" {
+		t.Errorf("Expected empty StateFile when STATE_FILE unset, got %q", cfg.StateFile)
+	}
+
+	os.Setenv("STATE_FILE", "/var/lib/diego-capacity/state.json")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.StateFile != "/var/lib/diego-capacity/state.json" {
+		t.Errorf("Expected StateFile from env, got %q", cfg.StateFile)
+	}
+}
+
+func TestLoadConfig_AuditLogFile(t *testing.T) {
+	t.Cleanup(withCleanCFEnvAndExtra(t, map[string]string{
+		"AUDIT_LOG_FILE": "/var/log/diego-capacity/audit.log",
+	}))
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.AuditLogFile != "/var/log/diego-capacity/audit.log" {
+		t.Errorf("Expected AuditLogFile from env, got %q", cfg.AuditLogFile)
+	}
+}
+
+func TestLoadConfig_Prometheus(t *testing.T) {
+	t.Cleanup(withCleanCFEnvAndExtra(t, map[string]string{
+		"PROMETHEUS_URL":                 "prometheus.example.com/",
+		"PROMETHEUS_BEARER_TOKEN":        "prom-token",
+		"PROMETHEUS_APP_MEMORY_QUERY":    "sum(app_memory_bytes)",
+		"PROMETHEUS_APP_INSTANCES_QUERY": "sum(app_instances)",
+	}))
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.PrometheusURL != "https://prometheus.example.com" {
+		t.Errorf("Expected scheme added and trailing slash dropped, got %q", cfg.PrometheusURL)
+	}
+	if cfg.PrometheusQueryTimeout != 30 {
+		t.Errorf("Expected default query timeout 30, got %d", cfg.PrometheusQueryTimeout)
+	}
+	if cfg.PrometheusAppDiskQuery != "" {
+		t.Errorf("Expected no disk query by default, got %q", cfg.PrometheusAppDiskQuery)
+	}
+}
+
+func TestLoadConfig_PrometheusInvalid(t *testing.T) {
+	// withQueries returns a valid Prometheus config with extra on top
+	withQueries := func(extra map[string]string) map[string]string {
+		env := map[string]string{
+			"PROMETHEUS_URL":                 "https://prometheus.example.com",
+			"PROMETHEUS_APP_MEMORY_QUERY":    "sum(app_memory_bytes)",
+			"PROMETHEUS_APP_INSTANCES_QUERY": "sum(app_instances)",
+		}
+		for k, v := range extra {
+			env[k] = v
+		}
+		return env
+	}
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"missing queries", map[string]string{"PROMETHEUS_URL": "https://prometheus.example.com"}, "PROMETHEUS_APP_MEMORY_QUERY"},
+		{"token and basic auth", withQueries(map[string]string{"PROMETHEUS_BEARER_TOKEN": "t", "PROMETHEUS_USERNAME": "u"}), "mutually exclusive"},
+		{"zero timeout", withQueries(map[string]string{"PROMETHEUS_QUERY_TIMEOUT": "0"}), "PROMETHEUS_QUERY_TIMEOUT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(withCleanCFEnvAndExtra(t, tt.env))
+
+			_, err := Load()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error mentioning %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_TPSCurveDefault(t *testing.T) {
+	t.Cleanup(withCleanCFEnv(t))
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.TPSCurve != nil {
+		t.Errorf("Expected nil TPSCurve when TPS_CURVE unset, got %v", cfg.TPSCurve)
+	}
+}
+
+func TestLoadConfig_TPSCurveFromEnv(t *testing.T) {
+	t.Cleanup(withCleanCFEnvAndExtra(t, map[string]string{
+		"TPS_CURVE": `[{"cells":1,"tps":300},{"cells":10,"tps":2000},{"cells":200,"tps":150}]`,
+	}))
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(cfg.TPSCurve) != 3 {
+		t.Fatalf("Expected 3 TPS curve points, got %d", len(cfg.TPSCurve))
+	}
+	if cfg.TPSCurve[1].Cells != 10 || cfg.TPSCurve[1].TPS != 2000 {
+		t.Errorf("Expected second point {10 2000}, got %+v", cfg.TPSCurve[1])
+	}
+}
+
+func TestLoadConfig_TPSCurveInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		wantErr string
+	}{
+		{"malformed JSON", `[{"cells":1,`, "JSON array"},
+		{"not an array", `{"cells":1,"tps":2}`, "JSON array"},
+		{"empty array", `[]`, "at least one point"},
+		{"zero cells", `[{"cells":0,"tps":100}]`, "cells must be positive"},
+		{"negative tps", `[{"cells":1,"tps":-1}]`, "tps must not be negative"},
+		{"unsorted", `[{"cells":10,"tps":100},{"cells":5,"tps":200}]`, "strictly increasing"},
+		{"duplicate cells", `[{"cells":5,"tps":100},{"cells":5,"tps":200}]`, "strictly increasing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(withCleanCFEnvAndExtra(t, map[string]string{"TPS_CURVE": tt.value}))
+
+			_, err := Load()
+			if err == nil {
+				t.Fatalf("Expected error for TPS_CURVE %q, got nil", tt.value)
+			}
+			if !strings.Contains(err.Error(), "TPS_CURVE") || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error mentioning TPS_CURVE and %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_ThresholdsDefault(t *testing.T) {
+	t.Cleanup(withCleanCFEnv(t))
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.Thresholds != models.DefaultThresholds() {
+		t.Errorf("Expected default thresholds when THRESHOLDS unset, got %+v", cfg.Thresholds)
+	}
+}
+
+func TestLoadConfig_ThresholdsFromEnv(t *testing.T) {
+	t.Cleanup(withCleanCFEnvAndExtra(t, map[string]string{
+		"THRESHOLDS": `{"n1_critical_pct":80,"free_chunks_critical":5,"vcpu_ratio_aggressive":6}`,
+	}))
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	th := cfg.Thresholds
+	if th.N1CriticalPct != 80 || th.N1WarningPct != 70 {
+		t.Errorf("Expected N-1 70/80 (default spacing), got %v/%v", th.N1WarningPct, th.N1CriticalPct)
+	}
+	if th.FreeChunksCritical != 5 || th.FreeChunksWarning != 10 {
+		t.Errorf("Expected free chunks 10/5 (default multiple), got %v/%v", th.FreeChunksWarning, th.FreeChunksCritical)
+	}
+	if th.VCPURatioModerate != models.DefaultVCPURatioModerate || th.VCPURatioAggressive != 6 {
+		t.Errorf("Expected vCPU tiers 4/6, got %v/%v", th.VCPURatioModerate, th.VCPURatioAggressive)
+	}
+	if th.DiskCriticalPct != models.DefaultDiskCriticalPct {
+		t.Errorf("Expected unset disk critical to keep default, got %v", th.DiskCriticalPct)
+	}
+}
+
+func TestLoadConfig_CPURatioTiers(t *testing.T) {
+	t.Cleanup(withCleanCFEnvAndExtra(t, map[string]string{
+		"THRESHOLDS":       `{"vcpu_ratio_moderate":3,"vcpu_ratio_aggressive":6}`,
+		"CPU_RATIO_MEDIUM": "2",
+		"CPU_RATIO_HIGH":   "4",
+	}))
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.Thresholds.VCPURatioModerate != 2 || cfg.Thresholds.VCPURatioAggressive != 4 {
+		t.Errorf("Expected CPU_RATIO_* to override THRESHOLDS with 2/4, got %v/%v",
+			cfg.Thresholds.VCPURatioModerate, cfg.Thresholds.VCPURatioAggressive)
+	}
+}
+
+func TestLoadConfig_CPURatioTiersInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr string
+	}{
+		{"zero medium", map[string]string{"CPU_RATIO_MEDIUM": "0"}, "CPU_RATIO_MEDIUM must be positive"},
+		{"high below medium", map[string]string{"CPU_RATIO_MEDIUM": "6", "CPU_RATIO_HIGH": "5"}, "CPU_RATIO_HIGH (5) must not be below CPU_RATIO_MEDIUM (6)"},
+		{"medium above default high", map[string]string{"CPU_RATIO_MEDIUM": "10"}, "must not be below CPU_RATIO_MEDIUM"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(withCleanCFEnvAndExtra(t, tt.env))
+
+			_, err := Load()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_ThresholdsInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		wantErr string
+	}{
+		{"malformed JSON", `{"n1_critical_pct":`, "JSON object"},
+		{"not an object", `[80]`, "JSON object"},
+		{"unknown key", `{"n1_critcal_pct":80}`, "unknown field"},
+		{"negative", `{"disk_warning_pct":-5}`, "must not be negative"},
+		{"warning above critical", `{"utilization_warning_pct":95,"utilization_critical_pct":90}`, "must not exceed"},
+		{"inverted vcpu tiers", `{"vcpu_ratio_moderate":10}`, "vcpu_ratio_moderate must not exceed"},
+		{"free chunks inverted", `{"free_chunks_warning":5,"free_chunks_critical":10}`, "free_chunks_warning"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(withCleanCFEnvAndExtra(t, map[string]string{"THRESHOLDS": tt.value}))
+
+			_, err := Load()
+			if err == nil {
+				t.Fatalf("Expected error for THRESHOLDS %q, got nil", tt.value)
+			}
+			if !strings.Contains(err.Error(), "THRESHOLDS") || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error mentioning THRESHOLDS and %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_BOSHSegmentMap(t *testing.T) {
+	want := map[string]string{"p-isolation-segment-abc123": "blue", "p-isolation-segment-def456": "green"}
+	for name, value := range map[string]string{
+		"pairs": "p-isolation-segment-abc123=blue, p-isolation-segment-def456 = green",
+		"json":  `{"p-isolation-segment-abc123":"blue","p-isolation-segment-def456":"green"}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Cleanup(withCleanCFEnvAndExtra(t, map[string]string{"BOSH_SEGMENT_MAP": value}))
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !reflect.DeepEqual(cfg.BOSHSegmentMap, want) {
+				t.Errorf("Expected BOSHSegmentMap %v, got %v", want, cfg.BOSHSegmentMap)
+			}
+		})
+	}
+
+	t.Run("unset", func(t *testing.T) {
+		t.Cleanup(withCleanCFEnv(t))
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if cfg.BOSHSegmentMap != nil {
+			t.Errorf("Expected no segment map, got %v", cfg.BOSHSegmentMap)
+		}
+	})
+}
+
+func TestLoadConfig_BOSHSegmentMapInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		wantErr string
+	}{
+		{"missing equals", "p-isolation-segment-abc123", "must be deployment=segment"},
+		{"empty segment", "p-isolation-segment-abc123=", "must name both"},
+		{"empty deployment", "=blue", "must name both"},
+		{"malformed JSON", `{"p-isolation-segment-abc123":`, "JSON object"},
+		{"non-string JSON value", `{"p-isolation-segment-abc123":1}`, "JSON object"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(withCleanCFEnvAndExtra(t, map[string]string{"BOSH_SEGMENT_MAP": tt.value}))
+
+			_, err := Load()
+			if err == nil || !strings.Contains(err.Error(), "BOSH_SEGMENT_MAP") || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error mentioning BOSH_SEGMENT_MAP and %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_BOSHCellJobNames(t *testing.T) {
+	t.Cleanup(withCleanCFEnvAndExtra(t, map[string]string{
+		"BOSH_CELL_JOB_NAMES": "diego_cell, app_cell",
+	}))
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !reflect.DeepEqual(cfg.BOSHCellJobNames, []string{"diego_cell", "app_cell"}) {
+		t.Errorf("Expected cell job names [diego_cell app_cell], got %v", cfg.BOSHCellJobNames)
+	}
+}
+
+func TestLoadConfig_MetricsPublic(t *testing.T) {
+	t.Cleanup(withCleanCFEnv(t))
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.MetricsPublic {
+		t.Error("Expected MetricsPublic default false, got true")
+	}
+
+	t.Cleanup(withCleanCFEnvAndExtra(t, map[string]string{"METRICS_PUBLIC": "true"}))
+
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !cfg.MetricsPublic {
+		t.Error("Expected MetricsPublic true, got false")
+	}
+}
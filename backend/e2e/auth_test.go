@@ -137,7 +137,7 @@ func TestAuthIntegration_BearerTokenWithJWKS(t *testing.T) {
 	defer uaaServer.Close()
 
 	// Create JWKS client pointing to mock UAA
-	jwksClient, err := services.NewJWKSClient(uaaServer.URL, nil)
+	jwksClient, err := services.NewJWKSClient(uaaServer.URL, nil, 0)
 	if err != nil {
 		t.Fatalf("failed to create JWKS client: %v", err)
 	}
@@ -356,7 +356,7 @@ func TestAuthIntegration_BearerTakesPrecedenceOverSession(t *testing.T) {
 	defer uaaServer.Close()
 
 	// Create JWKS client
-	jwksClient, err := services.NewJWKSClient(uaaServer.URL, nil)
+	jwksClient, err := services.NewJWKSClient(uaaServer.URL, nil, 0)
 	if err != nil {
 		t.Fatalf("failed to create JWKS client: %v", err)
 	}
@@ -460,7 +460,7 @@ func TestAuthIntegration_JWKSKeyRefresh(t *testing.T) {
 	defer uaaServer.Close()
 
 	// Create JWKS client - will fetch "old-key-id" initially
-	jwksClient, err := services.NewJWKSClient(uaaServer.URL, nil)
+	jwksClient, err := services.NewJWKSClient(uaaServer.URL, nil, 0)
 	if err != nil {
 		t.Fatalf("failed to create JWKS client: %v", err)
 	}
@@ -6,10 +6,12 @@ package e2e
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/markalston/diego-capacity-analyzer/backend/config"
 	"github.com/markalston/diego-capacity-analyzer/backend/handlers"
 	"github.com/markalston/diego-capacity-analyzer/backend/models"
 )
@@ -113,7 +115,7 @@ func TestCPURiskLevelThresholdsE2E(t *testing.T) {
 		expectedRisk  string
 	}{
 		{
-			name:          "Low Risk (ratio <= 4:1)",
+			name:          fmt.Sprintf("Low Risk (ratio <= %g:1)", models.DefaultVCPURatioModerate),
 			hostCount:     4,
 			cpuPerHost:    32,
 			cellCount:     30, // 30 × 4 = 120 vCPU / 128 pCPU ≈ 0.94:1
@@ -122,7 +124,7 @@ func TestCPURiskLevelThresholdsE2E(t *testing.T) {
 			expectedRisk:  "low",
 		},
 		{
-			name:          "Medium Risk (4:1 < ratio <= 8:1)",
+			name:          fmt.Sprintf("Medium Risk (%g:1 < ratio <= %g:1)", models.DefaultVCPURatioModerate, models.DefaultVCPURatioAggressive),
 			hostCount:     4,
 			cpuPerHost:    32,
 			cellCount:     100, // 100 × 6 = 600 vCPU / 128 pCPU ≈ 4.69:1
@@ -131,7 +133,7 @@ func TestCPURiskLevelThresholdsE2E(t *testing.T) {
 			expectedRisk:  "medium",
 		},
 		{
-			name:          "High Risk (ratio > 8:1)",
+			name:          fmt.Sprintf("High Risk (ratio > %g:1)", models.DefaultVCPURatioAggressive),
 			hostCount:     4,
 			cpuPerHost:    32,
 			cellCount:     150, // 150 × 8 = 1200 vCPU / 128 pCPU ≈ 9.38:1
@@ -149,7 +151,7 @@ func TestCPURiskLevelThresholdsE2E(t *testing.T) {
 					{
 						Name:              "test-cluster",
 						HostCount:         tc.hostCount,
-						MemoryGBPerHost:   512,
+						MemoryGBPerHost:   2048,
 						CPUThreadsPerHost:   tc.cpuPerHost,
 						DiegoCellCount:    tc.cellCount,
 						DiegoCellMemoryGB: 32,
@@ -181,6 +183,83 @@ func TestCPURiskLevelThresholdsE2E(t *testing.T) {
 	}
 }
 
+// TestCPURiskLevelConfiguredThresholdsE2E tests that CPU_RATIO_MEDIUM and
+// CPU_RATIO_HIGH drive risk levels and are reported by /api/v1/thresholds
+func TestCPURiskLevelConfiguredThresholdsE2E(t *testing.T) {
+	t.Cleanup(withTestCFEnv(t, map[string]string{
+		"CPU_RATIO_MEDIUM": "2",
+		"CPU_RATIO_HIGH":   "4",
+	}))
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	handler := handlers.NewHandler(cfg, nil)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/infrastructure/manual", handler.SetManualInfrastructure)
+	mux.HandleFunc("/api/thresholds", handler.Thresholds)
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/thresholds")
+	if err != nil {
+		t.Fatalf("Failed to get thresholds: %v", err)
+	}
+	var thresholds models.Thresholds
+	json.NewDecoder(resp.Body).Decode(&thresholds)
+	resp.Body.Close()
+	if thresholds.VCPURatioModerate != 2 || thresholds.VCPURatioAggressive != 4 {
+		t.Fatalf("Expected reported vCPU tiers 2/4, got %v/%v", thresholds.VCPURatioModerate, thresholds.VCPURatioAggressive)
+	}
+
+	testCases := []struct {
+		name         string
+		cellCount    int
+		cpuPerCell   int
+		expectedRisk string
+	}{
+		{"Low Risk (ratio <= 2:1)", 30, 4, "low"},              // 120 vCPU / 128 pCPU ≈ 0.94:1
+		{"Medium Risk (2:1 < ratio <= 4:1)", 100, 3, "medium"}, // 300 / 128 ≈ 2.34:1
+		{"High Risk (ratio > 4:1)", 150, 4, "high"},            // 600 / 128 ≈ 4.69:1, medium at the defaults
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			input := models.ManualInput{
+				Name: tc.name,
+				Clusters: []models.ClusterInput{
+					{
+						Name:              "test-cluster",
+						HostCount:         4,
+						MemoryGBPerHost:   2048,
+						CPUThreadsPerHost: 32,
+						DiegoCellCount:    tc.cellCount,
+						DiegoCellMemoryGB: 32,
+						DiegoCellCPU:      tc.cpuPerCell,
+					},
+				},
+				TotalAppMemoryGB: 500,
+			}
+
+			body, _ := json.Marshal(input)
+			resp, err := http.Post(server.URL+"/api/infrastructure/manual", "application/json", bytes.NewReader(body))
+			if err != nil {
+				t.Fatalf("Failed to post: %v", err)
+			}
+			defer resp.Body.Close()
+
+			var state models.InfrastructureState
+			json.NewDecoder(resp.Body).Decode(&state)
+
+			if state.CPURiskLevel != tc.expectedRisk {
+				t.Errorf("Expected CPURiskLevel '%s', got '%s' (ratio: %.2f)", tc.expectedRisk, state.CPURiskLevel, state.VCPURatio)
+			}
+		})
+	}
+}
+
 // TestHostLevelAnalysisE2E tests host-level metrics calculation
 func TestHostLevelAnalysisE2E(t *testing.T) {
 	handler := handlers.NewHandler(nil, nil)
@@ -465,6 +544,95 @@ func TestRecommendationsE2E(t *testing.T) {
 	}
 }
 
+// TestRebalanceRecommendationE2E tests that a skewed two-cluster foundation is
+// told to move cells before buying hardware
+func TestRebalanceRecommendationE2E(t *testing.T) {
+	handler := handlers.NewHandler(nil, nil)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/infrastructure/manual", handler.SetManualInfrastructure)
+	mux.HandleFunc("/api/recommendations", handler.GetRecommendations)
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	// cluster-hot: 58 × 32 GB on 2048 GB of hosts (~91%)
+	// cluster-cold: 26 × 32 GB on 2048 GB of hosts (~41%)
+	manualInput := models.ManualInput{
+		Name: "Skewed Clusters",
+		Clusters: []models.ClusterInput{
+			{
+				Name:              "cluster-hot",
+				HostCount:         4,
+				MemoryGBPerHost:   512,
+				CPUThreadsPerHost: 64,
+				DiegoCellCount:    58,
+				DiegoCellMemoryGB: 32,
+				DiegoCellCPU:      4,
+			},
+			{
+				Name:              "cluster-cold",
+				HostCount:         4,
+				MemoryGBPerHost:   512,
+				CPUThreadsPerHost: 64,
+				DiegoCellCount:    26,
+				DiegoCellMemoryGB: 32,
+				DiegoCellCPU:      4,
+			},
+		},
+		TotalAppMemoryGB:  2000,
+		TotalAppInstances: 1000,
+	}
+
+	body, _ := json.Marshal(manualInput)
+	resp, err := http.Post(server.URL+"/api/infrastructure/manual", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("Failed to post: %v", err)
+	}
+	resp.Body.Close()
+
+	resp, err = http.Get(server.URL + "/api/recommendations")
+	if err != nil {
+		t.Fatalf("Failed to get recommendations: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var result models.RecommendationsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode recommendations: %v", err)
+	}
+	if len(result.Recommendations) == 0 {
+		t.Fatal("Expected recommendations to be generated")
+	}
+
+	// Rebalancing is the cheapest fix, so it leads
+	rebalance := result.Recommendations[0]
+	if rebalance.Type != models.RecommendationRebalance {
+		t.Fatalf("Expected rebalance as the first recommendation, got %s", rebalance.Type)
+	}
+	// 84 cells split 42/42
+	if rebalance.CellsToMove != 16 {
+		t.Errorf("Expected 16 cells to move, got %d", rebalance.CellsToMove)
+	}
+	for _, d := range rebalance.TargetDistribution {
+		if d.TargetCellCount != 42 {
+			t.Errorf("Expected %s to end with 42 cells, got %d", d.ClusterName, d.TargetCellCount)
+		}
+		if d.TargetUtilizationPct < 65 || d.TargetUtilizationPct > 66 {
+			t.Errorf("Expected %s at ~65.6%% after rebalance, got %.1f%%", d.ClusterName, d.TargetUtilizationPct)
+		}
+	}
+
+	// Hardware recommendations follow, still sorted by priority
+	for i := 1; i < len(result.Recommendations); i++ {
+		if result.Recommendations[i].Priority <= result.Recommendations[i-1].Priority {
+			t.Errorf("Recommendations not sorted by priority: %d <= %d",
+				result.Recommendations[i].Priority, result.Recommendations[i-1].Priority)
+		}
+	}
+
+	t.Logf("Rebalance: %s (%s)", rebalance.Description, rebalance.Impact)
+}
+
 // TestLargeFoundationCellCount verifies the Large Foundation sample has correct cell count
 // This tests the exact scenario reported as a bug: 500 cells should appear, not 50
 func TestLargeFoundationCellCount(t *testing.T) {
@@ -64,7 +64,7 @@ func TestCSRF_E2E_FullLoginFlow(t *testing.T) {
 		CookieSecure: false, // Allow non-HTTPS in tests
 	}
 	c := cache.New(5 * time.Minute)
-	sessionSvc := services.NewSessionService(c)
+	sessionSvc := services.NewSessionService(services.NewCacheSessionStore(c))
 
 	h := handlers.NewHandler(cfg, c)
 	h.SetSessionService(sessionSvc)
@@ -169,7 +169,7 @@ func TestCSRF_E2E_LoginWithStaleCookie(t *testing.T) {
 		CookieSecure: false,
 	}
 	c := cache.New(5 * time.Minute)
-	sessionSvc := services.NewSessionService(c)
+	sessionSvc := services.NewSessionService(services.NewCacheSessionStore(c))
 
 	h := handlers.NewHandler(cfg, c)
 	h.SetSessionService(sessionSvc)
@@ -361,7 +361,7 @@ func TestCSRF_E2E_LogoutClearsCookies(t *testing.T) {
 		CookieSecure: false,
 	}
 	c := cache.New(5 * time.Minute)
-	sessionSvc := services.NewSessionService(c)
+	sessionSvc := services.NewSessionService(services.NewCacheSessionStore(c))
 
 	h := handlers.NewHandler(cfg, c)
 	h.SetSessionService(sessionSvc)
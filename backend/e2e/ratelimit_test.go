@@ -29,7 +29,7 @@ func TestRateLimit_E2E_LoginEndpoint(t *testing.T) {
 		CookieSecure: false,
 	}
 	c := cache.New(5 * time.Minute)
-	sessionSvc := services.NewSessionService(c)
+	sessionSvc := services.NewSessionService(services.NewCacheSessionStore(c))
 
 	h := handlers.NewHandler(cfg, c)
 	h.SetSessionService(sessionSvc)
@@ -121,7 +121,7 @@ func TestRateLimit_E2E_DisabledMode(t *testing.T) {
 		CookieSecure: false,
 	}
 	c := cache.New(5 * time.Minute)
-	sessionSvc := services.NewSessionService(c)
+	sessionSvc := services.NewSessionService(services.NewCacheSessionStore(c))
 
 	h := handlers.NewHandler(cfg, c)
 	h.SetSessionService(sessionSvc)
@@ -155,7 +155,7 @@ func TestRateLimit_E2E_SeparateIPQuotas(t *testing.T) {
 		CookieSecure: false,
 	}
 	c := cache.New(5 * time.Minute)
-	sessionSvc := services.NewSessionService(c)
+	sessionSvc := services.NewSessionService(services.NewCacheSessionStore(c))
 
 	h := handlers.NewHandler(cfg, c)
 	h.SetSessionService(sessionSvc)
@@ -149,7 +149,7 @@ func TestRBAC_NonGatedPOST_WithViewerToken_Returns200(t *testing.T) {
 	f := newRBACTestFixture(t)
 
 	var handlerCalled bool
-	// No RequireRole -- /scenario/compare has no Role set in the route table
+	// No RequireRole or RequireScope -- /check has neither set in the route table
 	handler := middleware.Chain(
 		func(w http.ResponseWriter, r *http.Request) {
 			handlerCalled = true
@@ -158,7 +158,7 @@ func TestRBAC_NonGatedPOST_WithViewerToken_Returns200(t *testing.T) {
 		middleware.Auth(f.authCfg),
 	)
 
-	req := f.bearerRequest(t, http.MethodPost, "/api/v1/scenario/compare", jwtClaims{
+	req := f.bearerRequest(t, http.MethodPost, "/api/v1/check", jwtClaims{
 		UserName: "viewer-user",
 		UserID:   "view-id",
 		Exp:      time.Now().Add(time.Hour).Unix(),
@@ -176,6 +176,75 @@ func TestRBAC_NonGatedPOST_WithViewerToken_Returns200(t *testing.T) {
 	}
 }
 
+func TestRBAC_ScopeGatedEndpoint(t *testing.T) {
+	f := newRBACTestFixture(t)
+
+	tests := []struct {
+		name       string
+		scopes     []string
+		wantStatus int
+	}{
+		{"operator scope", []string{"openid", "diego-analyzer.operator"}, http.StatusOK},
+		{"viewer scope", []string{"openid", "diego-analyzer.viewer"}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := middleware.Chain(
+				func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusOK)
+				},
+				middleware.Auth(f.authCfg),
+				middleware.RequireScope(middleware.ScopeOperator),
+			)
+
+			req := f.bearerRequest(t, http.MethodPost, "/api/v1/scenario/compare", jwtClaims{
+				UserName: "scoped-user",
+				UserID:   "scoped-id",
+				Exp:      time.Now().Add(time.Hour).Unix(),
+				Iat:      time.Now().Unix(),
+				Scope:    tt.scopes,
+			})
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("Status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestRBAC_ScopeGatedEndpoint_SessionScopes(t *testing.T) {
+	authCfg := middleware.AuthConfig{
+		Mode: middleware.AuthModeRequired,
+		SessionValidator: func(sessionID string) *middleware.UserClaims {
+			return &middleware.UserClaims{
+				Username: "session-user",
+				Scopes:   []string{"openid", middleware.ScopeOperator},
+				Role:     middleware.RoleOperator,
+			}
+		},
+	}
+
+	handler := middleware.Chain(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		},
+		middleware.Auth(authCfg),
+		middleware.RequireScope(middleware.ScopeOperator),
+	)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/scenario/compare", nil)
+	req.AddCookie(&http.Cookie{Name: "DIEGO_SESSION", Value: "session-id"})
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("Status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
 func TestRBAC_OperatorEndpoint_AuthDisabled_NoRBACCheck(t *testing.T) {
 	authCfg := middleware.AuthConfig{
 		Mode: middleware.AuthModeDisabled,
@@ -6,6 +6,7 @@ package e2e
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -117,7 +118,7 @@ func TestScenarioCompare_WithCPUConfig(t *testing.T) {
 		t.Errorf("VCPURatio = %f, want ~%f", proposed.VCPURatio, expectedRatio)
 	}
 
-	// At 0.83:1 ratio, risk level should be "conservative" (<=4:1)
+	// At 0.83:1 ratio, risk level should be "conservative" (at or below DefaultVCPURatioModerate)
 	if proposed.CPURiskLevel != "conservative" {
 		t.Errorf("CPURiskLevel = %q, want %q for ratio %.2f", proposed.CPURiskLevel, "conservative", proposed.VCPURatio)
 	}
@@ -166,7 +167,7 @@ func TestScenarioCompare_CPURiskLevels(t *testing.T) {
 		expectedRiskLevel string
 	}{
 		{
-			name:             "Conservative ratio (<= 4:1)",
+			name:             fmt.Sprintf("Conservative ratio (<= %g:1)", models.DefaultVCPURatioModerate),
 			cellCount:        8,
 			cellCPU:          8,
 			physicalCores:    32,
@@ -174,7 +175,7 @@ func TestScenarioCompare_CPURiskLevels(t *testing.T) {
 			expectedRiskLevel: "conservative",
 		},
 		{
-			name:             "Moderate ratio (4-8:1)",
+			name:             fmt.Sprintf("Moderate ratio (%g-%g:1)", models.DefaultVCPURatioModerate, models.DefaultVCPURatioAggressive),
 			cellCount:        40,
 			cellCPU:          8,
 			physicalCores:    32,
@@ -182,7 +183,7 @@ func TestScenarioCompare_CPURiskLevels(t *testing.T) {
 			expectedRiskLevel: "moderate",
 		},
 		{
-			name:             "Aggressive ratio (> 8:1)",
+			name:             fmt.Sprintf("Aggressive ratio (> %g:1)", models.DefaultVCPURatioAggressive),
 			cellCount:        80,
 			cellCPU:          8,
 			physicalCores:    32,
@@ -1,17 +1,25 @@
 // ABOUTME: HTTP handlers for bottleneck analysis and recommendations endpoints
-// ABOUTME: Provides multi-resource analysis and upgrade path recommendations
+// ABOUTME: Provides multi-resource analysis, time-to-full forecasts, and upgrade path recommendations
 
 package handlers
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/markalston/diego-capacity-analyzer/backend/models"
 )
 
-// AnalyzeBottleneck returns multi-resource bottleneck analysis.
+// AnalyzeBottleneck returns multi-resource bottleneck analysis. Query
+// parameter: rank ("utilization", the default, or "headroom").
 // HTTP method validation handled by Go 1.22+ router pattern matching.
 func (h *Handler) AnalyzeBottleneck(w http.ResponseWriter, r *http.Request) {
+	ranking, ok := bottleneckRanking(r)
+	if !ok {
+		h.writeError(w, "rank must be utilization or headroom", http.StatusBadRequest)
+		return
+	}
+
 	h.infraMutex.RLock()
 	state := h.infrastructureState
 	h.infraMutex.RUnlock()
@@ -21,11 +29,48 @@ func (h *Handler) AnalyzeBottleneck(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	analysis := models.AnalyzeBottleneck(*state)
+	analysis := h.thresholds().AnalyzeBottleneckRanked(*state, ranking)
 
 	h.writeJSON(w, http.StatusOK, analysis)
 }
 
+// bottleneckRanking reads the rank query parameter, defaulting to
+// utilization. Returns false for an unknown ranking.
+func bottleneckRanking(r *http.Request) (string, bool) {
+	switch rank := r.URL.Query().Get("rank"); rank {
+	case "", models.RankByUtilization:
+		return models.RankByUtilization, true
+	case models.RankByHeadroom:
+		return rank, true
+	default:
+		return "", false
+	}
+}
+
+// GetForecast projects how many months until each resource reaches 85% and
+// 100% utilization. Query parameter: growth (monthly demand growth percentage).
+// HTTP method validation handled by Go 1.22+ router pattern matching.
+func (h *Handler) GetForecast(w http.ResponseWriter, r *http.Request) {
+	growthPct, err := strconv.ParseFloat(r.URL.Query().Get("growth"), 64)
+	if err != nil || growthPct <= 0 || growthPct > 100 {
+		h.writeError(w, "growth must be a monthly percentage between 0 and 100", http.StatusBadRequest)
+		return
+	}
+
+	h.infraMutex.RLock()
+	state := h.infrastructureState
+	h.infraMutex.RUnlock()
+
+	if state == nil {
+		h.writeError(w, "No infrastructure data. Load via /api/v1/infrastructure or /api/v1/infrastructure/manual first.", http.StatusBadRequest)
+		return
+	}
+
+	forecast := models.ProjectTimeToFull(*state, growthPct)
+
+	h.writeJSON(w, http.StatusOK, forecast)
+}
+
 // GetRecommendations returns upgrade path recommendations.
 // HTTP method validation handled by Go 1.22+ router pattern matching.
 func (h *Handler) GetRecommendations(w http.ResponseWriter, r *http.Request) {
@@ -38,7 +83,7 @@ func (h *Handler) GetRecommendations(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	analysis := models.AnalyzeBottleneck(*state)
+	analysis := h.thresholds().AnalyzeBottleneck(*state)
 	recommendations := models.GenerateRecommendations(*state)
 
 	response := models.RecommendationsResponse{
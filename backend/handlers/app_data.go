@@ -0,0 +1,127 @@
+// ABOUTME: CF app totals cached apart from the vSphere discovery they enrich
+// ABOUTME: App usage fluctuates faster than cell topology, so it expires on APP_DATA_CACHE_TTL
+
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/markalston/diego-capacity-analyzer/backend/models"
+	"github.com/markalston/diego-capacity-analyzer/backend/units"
+)
+
+// appDataCacheKey caches the CF app totals for APP_DATA_CACHE_TTL
+const appDataCacheKey = "cf:app-data"
+
+// cachedAppData returns the cached CF app totals, if they have not expired
+func (h *Handler) cachedAppData() (models.AppDataSnapshot, bool) {
+	if h.cache == nil {
+		return models.AppDataSnapshot{}, false
+	}
+	cached, found := h.cache.Get(appDataCacheKey)
+	if !found {
+		return models.AppDataSnapshot{}, false
+	}
+	snapshot, ok := cached.(models.AppDataSnapshot)
+	return snapshot, ok
+}
+
+// cfAppData returns the CF app totals: the cached snapshot while it is younger
+// than APP_DATA_CACHE_TTL, otherwise summed from the CF API and cached. A
+// fresh read is also applied to the stored state, so later calculations see
+// it without a new discovery.
+func (h *Handler) cfAppData(ctx context.Context) (models.AppDataSnapshot, error) {
+	if snapshot, ok := h.cachedAppData(); ok {
+		return snapshot, nil
+	}
+
+	// Check if context is already cancelled before starting CF API calls
+	if err := ctx.Err(); err != nil {
+		return models.AppDataSnapshot{}, fmt.Errorf("context cancelled before CF enrichment: %w", err)
+	}
+
+	if err := h.cfClient.Authenticate(ctx); err != nil {
+		return models.AppDataSnapshot{}, fmt.Errorf("CF authentication during enrichment: %w", err)
+	}
+
+	apps, err := h.cfClient.GetApps(ctx)
+	if err != nil {
+		return models.AppDataSnapshot{}, fmt.Errorf("CF GetApps during enrichment: %w", err)
+	}
+
+	var totalMemoryMB, totalDiskMB, totalInstances int
+	var maxMemPerInstanceMB int
+	for _, app := range apps {
+		totalMemoryMB += app.RequestedMB
+		totalDiskMB += app.RequestedDiskMB
+		totalInstances += app.Instances
+
+		// Track max per-instance memory for chunk size calculation.
+		// CF API returns RequestedMB as total memory allocated to all instances
+		// of the app (per-instance limit × instance count). We divide to get
+		// the per-instance memory limit, which determines staging chunk size.
+		if app.Instances > 0 {
+			perInstanceMB := app.RequestedMB / app.Instances
+			if perInstanceMB > maxMemPerInstanceMB {
+				maxMemPerInstanceMB = perInstanceMB
+			}
+		}
+	}
+
+	snapshot := models.AppDataSnapshot{
+		// Round to nearest GB instead of truncating (add 512MB before dividing)
+		TotalAppMemoryGB:    units.MBToGiBRounded(totalMemoryMB),
+		TotalAppDiskGB:      units.MBToGiBRounded(totalDiskMB),
+		TotalAppInstances:   totalInstances,
+		MaxInstanceMemoryMB: maxMemPerInstanceMB,
+		Timestamp:           time.Now(),
+	}
+	if h.cache != nil && h.cfg.AppDataTTL > 0 {
+		h.cache.SetWithTTL(appDataCacheKey, snapshot, time.Duration(h.cfg.AppDataTTL)*time.Second)
+	}
+	h.applyAppDataToStoredState(snapshot)
+
+	return snapshot, nil
+}
+
+// applyAppDataToStoredState replaces the app totals of the stored state with
+// snapshot when the stored totals are older CF totals of a vSphere discovery.
+// States whose totals came with manual input are left alone.
+func (h *Handler) applyAppDataToStoredState(snapshot models.AppDataSnapshot) {
+	h.infraMutex.Lock()
+	defer h.infraMutex.Unlock()
+
+	stored := h.infrastructureState
+	if stored == nil || stored.Source != "vsphere" || !snapshot.NewerThan(*stored) {
+		return
+	}
+	state := *stored
+	snapshot.ApplyTo(&state)
+	h.storeInfrastructureStateLocked(&state)
+}
+
+// withCurrentAppData returns state with current CF app totals when its totals
+// came from CF for a vSphere discovery, re-reading them once the cached ones
+// pass APP_DATA_CACHE_TTL. The cell and host topology is left as discovered.
+// If CF cannot be read, state is returned unchanged.
+func (h *Handler) withCurrentAppData(ctx context.Context, state models.InfrastructureState) models.InfrastructureState {
+	if state.Source != "vsphere" || state.AppDataTimestamp == nil {
+		return state
+	}
+	if h.cfClient == nil || h.cfg == nil || h.cfg.CFAPIUrl == "" {
+		return state
+	}
+
+	snapshot, err := h.cfAppData(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to refresh CF app data, using stored app totals", "error", err)
+		return state
+	}
+	if snapshot.NewerThan(state) {
+		snapshot.ApplyTo(&state)
+	}
+	return state
+}
@@ -0,0 +1,212 @@
+// ABOUTME: Tests for CF app totals cached apart from the vSphere discovery
+// ABOUTME: Verifies app data refreshes on its own TTL and reaches scenario calculations
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/markalston/diego-capacity-analyzer/backend/cache"
+	"github.com/markalston/diego-capacity-analyzer/backend/config"
+	"github.com/markalston/diego-capacity-analyzer/backend/models"
+)
+
+// newAppDataHandler returns a handler backed by the mock CF server, whose apps
+// sum to 2 GB across 4 instances, caching app totals for a minute
+func newAppDataHandler(t *testing.T) (*Handler, *cache.Cache) {
+	t.Helper()
+	cfServer, uaaServer := setupMockCFServerWithApps()
+	t.Cleanup(cfServer.Close)
+	t.Cleanup(uaaServer.Close)
+
+	c := cache.New(5 * time.Minute)
+	return NewHandler(&config.Config{
+		CFAPIUrl:   cfServer.URL,
+		CFUsername: "admin",
+		CFPassword: "secret",
+		AppDataTTL: 60,
+	}, c), c
+}
+
+func TestEnrichWithCFAppData_CachesSnapshot(t *testing.T) {
+	handler, c := newAppDataHandler(t)
+
+	state := &models.InfrastructureState{Source: "vsphere"}
+	if err := handler.enrichWithCFAppData(context.Background(), state); err != nil {
+		t.Fatalf("enrichWithCFAppData failed: %v", err)
+	}
+	if state.AppDataTimestamp == nil || state.TotalAppInstances != 4 {
+		t.Fatalf("Expected CF app totals with a timestamp, got instances=%d timestamp=%v",
+			state.TotalAppInstances, state.AppDataTimestamp)
+	}
+
+	// A cached snapshot is used without querying CF again
+	c.Set(appDataCacheKey, models.AppDataSnapshot{TotalAppMemoryGB: 50, TotalAppInstances: 100, Timestamp: time.Now()})
+	if err := handler.enrichWithCFAppData(context.Background(), state); err != nil {
+		t.Fatalf("enrichWithCFAppData failed: %v", err)
+	}
+	if state.TotalAppMemoryGB != 50 || state.TotalAppInstances != 100 {
+		t.Errorf("Expected cached app totals, got memory=%d instances=%d", state.TotalAppMemoryGB, state.TotalAppInstances)
+	}
+}
+
+func TestCachedVSphereState_RefreshesExpiredAppData(t *testing.T) {
+	handler, c := newAppDataHandler(t)
+
+	discovered := time.Now().Add(-4 * time.Minute)
+	stale := models.InfrastructureState{
+		Source:            "vsphere",
+		Name:              "vcenter",
+		Timestamp:         discovered,
+		AppDataTimestamp:  &discovered,
+		TotalAppMemoryGB:  90,
+		TotalAppInstances: 900,
+	}
+	c.Set(vsphereInfrastructureCacheKey, stale)
+	handler.storeInfrastructureState(&stale)
+
+	state, ok := handler.cachedVSphereState(httptest.NewRequest(http.MethodGet, "/api/v1/infrastructure", nil))
+	if !ok {
+		t.Fatal("Expected the cached discovery")
+	}
+	if state.TotalAppMemoryGB != 2 || state.TotalAppInstances != 4 {
+		t.Errorf("Expected app totals re-read from CF, got memory=%d instances=%d", state.TotalAppMemoryGB, state.TotalAppInstances)
+	}
+	if !state.Timestamp.Equal(discovered) || !state.AppDataTimestamp.After(discovered) {
+		t.Errorf("Expected the discovery timestamp kept and a newer app data timestamp, got %v and %v",
+			state.Timestamp, state.AppDataTimestamp)
+	}
+	if stored := handler.CurrentInfrastructureState(); stored.TotalAppInstances != 4 {
+		t.Errorf("Expected the stored state to get the refreshed app totals, got %d instances", stored.TotalAppInstances)
+	}
+	if _, found := c.Get(appDataCacheKey); !found {
+		t.Error("Expected the refreshed app totals to be cached")
+	}
+
+	// force=true drops the cached app totals along with the discovery
+	if _, ok := handler.cachedVSphereState(httptest.NewRequest(http.MethodGet, "/api/v1/infrastructure?force=true", nil)); ok {
+		t.Error("Expected force=true to skip the cache")
+	}
+	if _, found := c.Get(appDataCacheKey); found {
+		t.Error("Expected force=true to clear the cached app totals")
+	}
+}
+
+func TestCompareScenario_UsesCachedAppData(t *testing.T) {
+	handler, c := newAppDataHandler(t)
+
+	readAt := time.Now().Add(-10 * time.Minute)
+	state := models.InfrastructureState{
+		Source:           "vsphere",
+		AppDataTimestamp: &readAt,
+		TotalAppMemoryGB: 100,
+		Clusters: []models.ClusterState{{
+			Name: "cluster-01", HostCount: 4, MemoryGB: 4096, CPUCores: 128,
+			DiegoCellCount: 10, DiegoCellMemoryGB: 64, DiegoCellCPU: 8,
+		}},
+		TotalCellCount: 10,
+	}
+	handler.storeInfrastructureState(&state)
+	c.Set(appDataCacheKey, models.AppDataSnapshot{TotalAppMemoryGB: 300, TotalAppInstances: 30, Timestamp: time.Now()})
+
+	body := `{"proposed_cell_memory_gb": 64, "proposed_cell_cpu": 8, "proposed_cell_count": 10}`
+	rr := httptest.NewRecorder()
+	handler.CompareScenario(rr, httptest.NewRequest(http.MethodPost, "/api/v1/scenario/compare", strings.NewReader(body)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var comparison models.ScenarioComparison
+	if err := json.NewDecoder(rr.Body).Decode(&comparison); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if comparison.Current.InstancesPerCell != 3 {
+		t.Errorf("Expected the cached 30 instances across 10 cells, got %.1f per cell", comparison.Current.InstancesPerCell)
+	}
+}
+
+func TestGetScenarioBaseline_UsesCachedAppData(t *testing.T) {
+	handler, c := newAppDataHandler(t)
+
+	readAt := time.Now().Add(-10 * time.Minute)
+	state := models.InfrastructureState{
+		Source:            "vsphere",
+		AppDataTimestamp:  &readAt,
+		TotalAppMemoryGB:  100,
+		TotalAppInstances: 20,
+		Clusters: []models.ClusterState{
+			{Name: "cluster-01", HostCount: 4, MemoryGB: 4096, CPUCores: 128, DiegoCellCount: 10, DiegoCellMemoryGB: 64, DiegoCellCPU: 8, TotalCellMemoryGB: 640},
+			{Name: "cluster-02", HostCount: 4, MemoryGB: 4096, CPUCores: 128, DiegoCellCount: 10, DiegoCellMemoryGB: 64, DiegoCellCPU: 8, TotalCellMemoryGB: 640},
+		},
+		TotalCellCount:    20,
+		TotalCellMemoryGB: 1280,
+	}
+	handler.storeInfrastructureState(&state)
+
+	body := `{"name": "cluster-plan", "input": {"target_cluster": "cluster-01", "proposed_cell_memory_gb": 64, "proposed_cell_cpu": 8, "proposed_cell_count": 10}}`
+	rr := httptest.NewRecorder()
+	handler.SaveScenarioBaseline(rr, httptest.NewRequest(http.MethodPost, "/api/v1/scenario/baseline", strings.NewReader(body)))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	// App totals change after the state was stored
+	c.Set(appDataCacheKey, models.AppDataSnapshot{TotalAppMemoryGB: 300, TotalAppInstances: 60, Timestamp: time.Now()})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/scenario/baseline/cluster-plan", nil)
+	req.SetPathValue("name", "cluster-plan")
+	rr = httptest.NewRecorder()
+	handler.GetScenarioBaseline(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var comparison models.BaselineComparison
+	if err := json.NewDecoder(rr.Body).Decode(&comparison); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if comparison.Proposed.InstancesPerCell != 3 {
+		t.Errorf("Expected cluster-01's half of the cached 60 instances across 10 cells, got %.1f per cell", comparison.Proposed.InstancesPerCell)
+	}
+}
+
+func TestWithCurrentAppData_LeavesInputTotalsAlone(t *testing.T) {
+	handler, c := newAppDataHandler(t)
+	c.Set(appDataCacheKey, models.AppDataSnapshot{TotalAppMemoryGB: 300, Timestamp: time.Now()})
+
+	manual := models.InfrastructureState{Source: "manual", TotalAppMemoryGB: 100}
+	if got := handler.withCurrentAppData(context.Background(), manual); got.TotalAppMemoryGB != 100 || got.AppDataTimestamp != nil {
+		t.Errorf("Expected manual app totals unchanged, got %d", got.TotalAppMemoryGB)
+	}
+
+	// vSphere discovery without CF app data has no totals to refresh
+	vsphereOnly := models.InfrastructureState{Source: "vsphere"}
+	if got := handler.withCurrentAppData(context.Background(), vsphereOnly); got.TotalAppMemoryGB != 0 {
+		t.Errorf("Expected vSphere-only totals unchanged, got %d", got.TotalAppMemoryGB)
+	}
+}
+
+func TestHealth_ReportsCachedApps(t *testing.T) {
+	handler, c := newAppDataHandler(t)
+	c.Set(appDataCacheKey, models.AppDataSnapshot{Timestamp: time.Now()})
+	c.Set("dashboard:all", models.DashboardResponse{Cells: []models.DiegoCell{{ID: "cell-01"}}})
+
+	rr := httptest.NewRecorder()
+	handler.Health(rr, httptest.NewRequest(http.MethodGet, "/api/v1/health", nil))
+
+	var resp struct {
+		CacheStatus map[string]bool `json:"cache_status"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if !resp.CacheStatus["apps_cached"] || !resp.CacheStatus["cells_cached"] {
+		t.Errorf("Expected apps and cells cached, got %v", resp.CacheStatus)
+	}
+}
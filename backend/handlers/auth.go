@@ -7,16 +7,21 @@ import (
 	"crypto/tls"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"math"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/markalston/diego-capacity-analyzer/backend/audit"
 	"github.com/markalston/diego-capacity-analyzer/backend/middleware"
 	"github.com/markalston/diego-capacity-analyzer/backend/models"
+	"github.com/markalston/diego-capacity-analyzer/backend/services"
 )
 
 const sessionCookieName = "DIEGO_SESSION"
@@ -35,16 +40,45 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Refuse locked-out usernames and IPs before their guesses reach UAA
+	clientIP := strings.TrimPrefix(middleware.ClientIP(r), "ip:")
+	if h.loginGuard != nil {
+		if remaining := h.loginGuard.Locked(req.Username, clientIP); remaining > 0 {
+			retrySeconds := int(math.Ceil(remaining.Seconds()))
+			slog.WarnContext(r.Context(), "Login rejected: locked out", "username", req.Username, "client_ip", clientIP, "retry_after", retrySeconds)
+			h.recordAudit(r, audit.Event{Action: audit.ActionLogin, Username: req.Username, Outcome: audit.OutcomeFailure, Reason: "locked_out"})
+			w.Header().Set("Retry-After", strconv.Itoa(retrySeconds))
+			h.writeJSON(w, http.StatusTooManyRequests, models.LoginResponse{
+				Success: false,
+				Error:   "Too many failed login attempts, try again later",
+			})
+			return
+		}
+	}
+
 	// Authenticate with CF UAA
 	tokenResp, err := h.authenticateWithCFUAA(req.Username, req.Password)
 	if err != nil {
-		slog.Warn("Authentication failed", "username", req.Username, "error", err)
+		slog.WarnContext(r.Context(), "Authentication failed", "username", req.Username, "error", err)
+		h.recordAudit(r, audit.Event{Action: audit.ActionLogin, Username: req.Username, Outcome: audit.OutcomeFailure, Reason: "invalid_credentials"})
+		if h.loginGuard != nil {
+			usernameLocked, ipLocked := h.loginGuard.RecordFailure(req.Username, clientIP)
+			if usernameLocked {
+				slog.WarnContext(r.Context(), "Login locked out: too many failures for username", "username", req.Username, "client_ip", clientIP)
+			}
+			if ipLocked {
+				slog.WarnContext(r.Context(), "Login locked out: too many failures from client IP", "username", req.Username, "client_ip", clientIP)
+			}
+		}
 		h.writeJSON(w, http.StatusUnauthorized, models.LoginResponse{
 			Success: false,
 			Error:   "Invalid credentials",
 		})
 		return
 	}
+	if h.loginGuard != nil {
+		h.loginGuard.Reset(req.Username)
+	}
 
 	// Calculate token expiry
 	expiry := time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
@@ -53,16 +87,17 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	scopes := extractScopesFromToken(tokenResp.AccessToken)
 
 	// Create session (stores tokens server-side)
-	sessionID, err := h.sessionService.Create(
+	sessionID, err := h.sessionService.CreateWithUserAgent(
 		req.Username,
 		tokenResp.UserID,
 		tokenResp.AccessToken,
 		tokenResp.RefreshToken,
 		scopes,
 		expiry,
+		truncateUserAgent(r.UserAgent()),
 	)
 	if err != nil {
-		slog.Error("Failed to create session", "error", err)
+		slog.ErrorContext(r.Context(), "Failed to create session", "error", err)
 		h.writeError(w, "Failed to create session", http.StatusInternalServerError)
 		return
 	}
@@ -73,11 +108,12 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	// Set CSRF cookie (readable by JavaScript for inclusion in headers)
 	csrfToken, err := h.sessionService.GetCSRFToken(sessionID)
 	if err != nil {
-		slog.Error("Failed to get CSRF token", "sessionID", sessionID, "error", err)
+		slog.ErrorContext(r.Context(), "Failed to get CSRF token", "sessionID", sessionID, "error", err)
 		h.writeError(w, "Failed to create session", http.StatusInternalServerError)
 		return
 	}
 	h.setCSRFCookie(w, csrfToken, tokenResp.ExpiresIn)
+	h.recordAudit(r, audit.Event{Action: audit.ActionLogin, Username: req.Username, Outcome: audit.OutcomeSuccess})
 
 	// Return success response (no tokens!)
 	h.writeJSON(w, http.StatusOK, models.LoginResponse{
@@ -120,6 +156,9 @@ func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
 	if err == nil && cookie.Value != "" {
 		// Delete session from cache (if sessionService is configured)
 		if h.sessionService != nil {
+			if session, err := h.sessionService.Get(cookie.Value); err == nil {
+				h.recordAudit(r, audit.Event{Action: audit.ActionLogout, Username: session.Username, Outcome: audit.OutcomeSuccess})
+			}
 			h.sessionService.Delete(cookie.Value)
 		}
 	}
@@ -139,37 +178,49 @@ func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check if refresh is needed
-	if !h.sessionService.NeedsRefresh(session) {
-		h.writeJSON(w, http.StatusOK, map[string]bool{"refreshed": false})
-		return
-	}
-
-	// Refresh the token with UAA
-	tokenResp, err := h.refreshWithCFUAA(session.RefreshToken)
+	// Refresh the token with UAA if it is near expiry
+	_, refreshed, err := h.sessionService.RefreshIfNeeded(session.ID)
 	if err != nil {
-		slog.Warn("Token refresh failed", "error", err)
+		slog.WarnContext(r.Context(), "Token refresh failed", "error", err)
+		reason := "refresh_failed"
+		if errors.Is(err, services.ErrRefreshTokenReused) {
+			reason = "refresh_token_reused"
+		}
+		h.recordAudit(r, audit.Event{Action: audit.ActionSessionRefresh, Username: session.Username, Outcome: audit.OutcomeFailure, Reason: reason})
 		// Delete session to force re-login (per issue #85 acceptance criteria)
-		h.sessionService.Delete(session.ID)
-		h.clearSessionCookie(w)
-		h.writeError(w, "Token refresh failed", http.StatusUnauthorized)
+		h.expireSession(w, session.ID)
 		return
 	}
 
-	// Calculate new token expiry
-	expiry := time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
-
-	// Extract scopes from refreshed token so role changes take effect
-	scopes := extractScopesFromToken(tokenResp.AccessToken)
+	if refreshed {
+		h.recordAudit(r, audit.Event{Action: audit.ActionSessionRefresh, Username: session.Username, Outcome: audit.OutcomeSuccess})
+	}
+	h.writeJSON(w, http.StatusOK, map[string]bool{"refreshed": refreshed})
+}
 
-	// Update session with new tokens and scopes (use session.ID, not re-reading cookie)
-	if err := h.sessionService.UpdateTokens(session.ID, tokenResp.AccessToken, tokenResp.RefreshToken, scopes, expiry); err != nil {
-		slog.Error("Failed to update session tokens", "error", err)
-		h.writeError(w, "Failed to update session", http.StatusInternalServerError)
-		return
+// refreshSessionTokens renews session tokens with CF UAA; it is the session
+// service's TokenRefresher
+func (h *Handler) refreshSessionTokens(refreshToken string) (services.RefreshedTokens, error) {
+	tokenResp, err := h.refreshWithCFUAA(refreshToken)
+	if err != nil {
+		return services.RefreshedTokens{}, err
 	}
 
-	h.writeJSON(w, http.StatusOK, map[string]bool{"refreshed": true})
+	return services.RefreshedTokens{
+		AccessToken:  tokenResp.AccessToken,
+		RefreshToken: tokenResp.RefreshToken,
+		// Extract scopes from refreshed token so role changes take effect
+		Scopes: extractScopesFromToken(tokenResp.AccessToken),
+		Expiry: time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second),
+	}, nil
+}
+
+// expireSession ends a session whose tokens can no longer be renewed and tells
+// the client to log in again
+func (h *Handler) expireSession(w http.ResponseWriter, sessionID string) {
+	h.sessionService.Delete(sessionID)
+	h.clearSessionCookie(w)
+	h.writeError(w, "Session expired, please log in again", http.StatusUnauthorized)
 }
 
 // uaaTokenResponse represents the OAuth token response from CF UAA
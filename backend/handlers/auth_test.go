@@ -4,16 +4,20 @@
 package handlers
 
 import (
+	"bytes"
 	"encoding/base64"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/markalston/diego-capacity-analyzer/backend/audit"
 	"github.com/markalston/diego-capacity-analyzer/backend/cache"
 	"github.com/markalston/diego-capacity-analyzer/backend/config"
+	"github.com/markalston/diego-capacity-analyzer/backend/logger"
 	"github.com/markalston/diego-capacity-analyzer/backend/middleware"
 	"github.com/markalston/diego-capacity-analyzer/backend/models"
 	"github.com/markalston/diego-capacity-analyzer/backend/services"
@@ -214,7 +218,7 @@ func TestLogin_Success(t *testing.T) {
 	defer uaaServer.Close()
 
 	c := cache.New(5 * time.Minute)
-	sessionSvc := services.NewSessionService(c)
+	sessionSvc := services.NewSessionService(services.NewCacheSessionStore(c))
 	cfg := &config.Config{
 		CFAPIUrl:      cfServer.URL,
 		CookieSecure:  false, // false for test (http)
@@ -290,7 +294,7 @@ func TestLogin_InvalidCredentials(t *testing.T) {
 	defer uaaServer.Close()
 
 	c := cache.New(5 * time.Minute)
-	sessionSvc := services.NewSessionService(c)
+	sessionSvc := services.NewSessionService(services.NewCacheSessionStore(c))
 	cfg := &config.Config{
 		CFAPIUrl:      cfServer.URL,
 		CookieSecure:  false,
@@ -325,9 +329,131 @@ func TestLogin_InvalidCredentials(t *testing.T) {
 	}
 }
 
+func TestLogin_LockoutAfterRepeatedFailures(t *testing.T) {
+	cfServer, uaaServer := setupMockCFAndUAAServers("admin", "secret")
+	defer cfServer.Close()
+	defer uaaServer.Close()
+
+	c := cache.New(5 * time.Minute)
+	cfg := &config.Config{
+		CFAPIUrl:              cfServer.URL,
+		CookieSecure:          false,
+		OAuthClientID:         "cf",
+		LoginMaxFailures:      2,
+		LoginMaxFailuresPerIP: 10,
+		LoginLockoutSecs:      60,
+	}
+
+	h := NewHandler(cfg, c)
+	h.SetSessionService(services.NewSessionService(services.NewCacheSessionStore(c)))
+
+	login := func(password string) *http.Response {
+		body := `{"username":"admin","password":"` + password + `"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		h.Login(w, req)
+		return w.Result()
+	}
+
+	for i := 0; i < 2; i++ {
+		if resp := login("wrong"); resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("Attempt %d: status = %d, want %d", i+1, resp.StatusCode, http.StatusUnauthorized)
+		}
+	}
+
+	// Locked out: even the correct password is refused without reaching UAA
+	resp := login("secret")
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("Status = %d, want %d", resp.StatusCode, http.StatusTooManyRequests)
+	}
+	retryAfter, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || retryAfter < 1 || retryAfter > 60 {
+		t.Errorf("Retry-After = %q, want 1-60 seconds", resp.Header.Get("Retry-After"))
+	}
+	var loginResp models.LoginResponse
+	if err := json.NewDecoder(resp.Body).Decode(&loginResp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if loginResp.Success || loginResp.Error == "" {
+		t.Errorf("Expected failed login with error, got %+v", loginResp)
+	}
+}
+
+func TestLogin_SuccessResetsFailures(t *testing.T) {
+	cfServer, uaaServer := setupMockCFAndUAAServers("admin", "secret")
+	defer cfServer.Close()
+	defer uaaServer.Close()
+
+	c := cache.New(5 * time.Minute)
+	cfg := &config.Config{
+		CFAPIUrl:              cfServer.URL,
+		CookieSecure:          false,
+		OAuthClientID:         "cf",
+		LoginMaxFailures:      2,
+		LoginMaxFailuresPerIP: 10,
+		LoginLockoutSecs:      60,
+	}
+
+	h := NewHandler(cfg, c)
+	h.SetSessionService(services.NewSessionService(services.NewCacheSessionStore(c)))
+
+	for _, tc := range []struct {
+		password string
+		want     int
+	}{
+		{"wrong", http.StatusUnauthorized},
+		{"secret", http.StatusOK},
+		{"wrong", http.StatusUnauthorized},
+		{"secret", http.StatusOK},
+	} {
+		body := `{"username":"admin","password":"` + tc.password + `"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		h.Login(w, req)
+		if w.Code != tc.want {
+			t.Fatalf("Login with %q: status = %d, want %d", tc.password, w.Code, tc.want)
+		}
+	}
+}
+
+func TestMe_IdleSessionEnded(t *testing.T) {
+	c := cache.New(5 * time.Minute)
+	store := services.NewCacheSessionStore(c)
+	sessionSvc := services.NewSessionService(store)
+	sessionSvc.SetTimeouts(15*time.Minute, 0)
+	store.Save(&models.Session{
+		ID:          "idle-session",
+		Username:    "admin",
+		CreatedAt:   time.Now().Add(-time.Hour),
+		LastSeen:    time.Now().Add(-20 * time.Minute),
+		TokenExpiry: time.Now().Add(time.Hour),
+	}, time.Hour)
+
+	h := NewHandler(&config.Config{AuthMode: "optional"}, c)
+	h.SetSessionService(sessionSvc)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/auth/me", nil)
+	req.AddCookie(&http.Cookie{Name: "DIEGO_SESSION", Value: "idle-session"})
+	w := httptest.NewRecorder()
+	h.Me(w, req)
+
+	var resp models.UserInfoResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Authenticated {
+		t.Error("Expected idle session to be treated as logged out")
+	}
+	if _, err := store.Load("idle-session"); err == nil {
+		t.Error("Expected idle session to be deleted")
+	}
+}
+
 func TestLogin_MissingCredentials(t *testing.T) {
 	c := cache.New(5 * time.Minute)
-	sessionSvc := services.NewSessionService(c)
+	sessionSvc := services.NewSessionService(services.NewCacheSessionStore(c))
 	cfg := &config.Config{CookieSecure: false}
 
 	h := NewHandler(cfg, c)
@@ -348,7 +474,7 @@ func TestLogin_MissingCredentials(t *testing.T) {
 
 func TestMe_Authenticated(t *testing.T) {
 	c := cache.New(5 * time.Minute)
-	sessionSvc := services.NewSessionService(c)
+	sessionSvc := services.NewSessionService(services.NewCacheSessionStore(c))
 
 	// Create a session
 	sessionID, err := sessionSvc.Create("testuser", "user-123", "access", "refresh", nil, time.Now().Add(time.Hour))
@@ -389,7 +515,7 @@ func TestMe_Authenticated(t *testing.T) {
 
 func TestMe_NotAuthenticated(t *testing.T) {
 	c := cache.New(5 * time.Minute)
-	sessionSvc := services.NewSessionService(c)
+	sessionSvc := services.NewSessionService(services.NewCacheSessionStore(c))
 	cfg := &config.Config{CookieSecure: false}
 
 	h := NewHandler(cfg, c)
@@ -417,7 +543,7 @@ func TestMe_NotAuthenticated(t *testing.T) {
 
 func TestMe_InvalidSession(t *testing.T) {
 	c := cache.New(5 * time.Minute)
-	sessionSvc := services.NewSessionService(c)
+	sessionSvc := services.NewSessionService(services.NewCacheSessionStore(c))
 	cfg := &config.Config{CookieSecure: false}
 
 	h := NewHandler(cfg, c)
@@ -478,7 +604,7 @@ func TestMe_AuthDisabled_ReturnsAuthenticated(t *testing.T) {
 
 func TestLogout_Success(t *testing.T) {
 	c := cache.New(5 * time.Minute)
-	sessionSvc := services.NewSessionService(c)
+	sessionSvc := services.NewSessionService(services.NewCacheSessionStore(c))
 
 	// Create a session
 	sessionID, err := sessionSvc.Create("testuser", "user-123", "access", "refresh", nil, time.Now().Add(time.Hour))
@@ -527,7 +653,7 @@ func TestLogout_Success(t *testing.T) {
 
 func TestLogout_NoSession(t *testing.T) {
 	c := cache.New(5 * time.Minute)
-	sessionSvc := services.NewSessionService(c)
+	sessionSvc := services.NewSessionService(services.NewCacheSessionStore(c))
 	cfg := &config.Config{CookieSecure: false}
 
 	h := NewHandler(cfg, c)
@@ -551,7 +677,7 @@ func TestLogin_CookieSecureFlag(t *testing.T) {
 	defer uaaServer.Close()
 
 	c := cache.New(5 * time.Minute)
-	sessionSvc := services.NewSessionService(c)
+	sessionSvc := services.NewSessionService(services.NewCacheSessionStore(c))
 	cfg := &config.Config{
 		CFAPIUrl:      cfServer.URL,
 		CookieSecure:  true, // Production setting
@@ -595,7 +721,7 @@ func TestRefresh_TokensUpdated(t *testing.T) {
 	defer uaaServer.Close()
 
 	c := cache.New(5 * time.Minute)
-	sessionSvc := services.NewSessionService(c)
+	sessionSvc := services.NewSessionService(services.NewCacheSessionStore(c))
 
 	// Create a session with token expiring within 5 minutes (triggers refresh)
 	sessionID, err := sessionSvc.Create(
@@ -661,7 +787,7 @@ func TestRefresh_TokensUpdated(t *testing.T) {
 
 func TestRefresh_NotNeeded(t *testing.T) {
 	c := cache.New(5 * time.Minute)
-	sessionSvc := services.NewSessionService(c)
+	sessionSvc := services.NewSessionService(services.NewCacheSessionStore(c))
 
 	// Create a session with token NOT expiring soon (more than 5 min out)
 	sessionID, err := sessionSvc.Create(
@@ -709,7 +835,7 @@ func TestRefresh_InvalidRefreshToken(t *testing.T) {
 	defer uaaServer.Close()
 
 	c := cache.New(5 * time.Minute)
-	sessionSvc := services.NewSessionService(c)
+	sessionSvc := services.NewSessionService(services.NewCacheSessionStore(c))
 
 	// Create a session with an INVALID refresh token
 	sessionID, err := sessionSvc.Create(
@@ -767,9 +893,112 @@ func TestRefresh_InvalidRefreshToken(t *testing.T) {
 	}
 }
 
+func TestRefresh_ConcurrentReplicaRenewalKeepsSession(t *testing.T) {
+	cfServer, uaaServer := setupMockCFAndUAAServersWithRefresh("admin", "secret", "refresh-1")
+	defer cfServer.Close()
+	defer uaaServer.Close()
+
+	c := cache.New(5 * time.Minute)
+	sessionSvc := services.NewSessionService(services.NewCacheSessionStore(c))
+	otherReplica := services.NewSessionService(services.NewCacheSessionStore(c))
+
+	sessionID, err := sessionSvc.Create("testuser", "user-123", "access-1", "refresh-1", nil, time.Now().Add(2*time.Minute))
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	cfg := &config.Config{
+		CFAPIUrl:      cfServer.URL,
+		CookieSecure:  false,
+		OAuthClientID: "cf",
+	}
+	h := NewHandler(cfg, c)
+	h.SetSessionService(sessionSvc)
+
+	// Another replica renews the session with refresh-1 while this one is redeeming it
+	sessionSvc.SetTokenRefresher(func(refreshToken string) (services.RefreshedTokens, error) {
+		if _, err := otherReplica.RotateTokens(sessionID, refreshToken, services.RefreshedTokens{
+			AccessToken:  "access-other",
+			RefreshToken: "refresh-other",
+			Expiry:       time.Now().Add(time.Hour),
+		}); err != nil {
+			t.Errorf("RotateTokens on other replica failed: %v", err)
+		}
+		return h.refreshSessionTokens(refreshToken)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/refresh", nil)
+	req.AddCookie(&http.Cookie{Name: "DIEGO_SESSION", Value: sessionID})
+	w := httptest.NewRecorder()
+
+	h.Refresh(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Status = %d, want %d", w.Code, http.StatusOK)
+	}
+	session, err := sessionSvc.Get(sessionID)
+	if err != nil {
+		t.Fatalf("Session should survive a concurrent renewal: %v", err)
+	}
+	if session.RefreshToken != "refresh-other" {
+		t.Errorf("Expected the other replica's tokens to be kept, got %q", session.RefreshToken)
+	}
+}
+
+func TestRefresh_ReusedRefreshTokenRevokesSession(t *testing.T) {
+	cfServer, uaaServer := setupMockCFAndUAAServersWithRefresh("admin", "secret", "refresh-1")
+	defer cfServer.Close()
+	defer uaaServer.Close()
+
+	c := cache.New(5 * time.Minute)
+	sessionSvc := services.NewSessionService(services.NewCacheSessionStore(c))
+	otherReplica := services.NewSessionService(services.NewCacheSessionStore(c))
+
+	sessionID, err := sessionSvc.Create("testuser", "user-123", "access-1", "refresh-1", nil, time.Now().Add(2*time.Minute))
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	cfg := &config.Config{
+		CFAPIUrl:      cfServer.URL,
+		CookieSecure:  false,
+		OAuthClientID: "cf",
+	}
+	h := NewHandler(cfg, c)
+	h.SetSessionService(sessionSvc)
+
+	// The session moved on twice while refresh-1 was held, so redeeming it
+	// now is a replay of a superseded token rather than a concurrent renewal
+	sessionSvc.SetTokenRefresher(func(refreshToken string) (services.RefreshedTokens, error) {
+		for _, tokens := range [][2]string{{"refresh-1", "refresh-2"}, {"refresh-2", "refresh-3"}} {
+			if _, err := otherReplica.RotateTokens(sessionID, tokens[0], services.RefreshedTokens{
+				AccessToken:  "access-other",
+				RefreshToken: tokens[1],
+				Expiry:       time.Now().Add(time.Hour),
+			}); err != nil {
+				t.Errorf("RotateTokens on other replica failed: %v", err)
+			}
+		}
+		return h.refreshSessionTokens(refreshToken)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/refresh", nil)
+	req.AddCookie(&http.Cookie{Name: "DIEGO_SESSION", Value: sessionID})
+	w := httptest.NewRecorder()
+
+	h.Refresh(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+	if _, err := sessionSvc.Get(sessionID); err == nil {
+		t.Error("Session should be revoked after refresh token reuse")
+	}
+}
+
 func TestRefresh_NoSession(t *testing.T) {
 	c := cache.New(5 * time.Minute)
-	sessionSvc := services.NewSessionService(c)
+	sessionSvc := services.NewSessionService(services.NewCacheSessionStore(c))
 	cfg := &config.Config{CookieSecure: false}
 
 	h := NewHandler(cfg, c)
@@ -792,7 +1021,7 @@ func TestLogin_SetsCSRFCookie(t *testing.T) {
 	defer uaaServer.Close()
 
 	c := cache.New(5 * time.Minute)
-	sessionSvc := services.NewSessionService(c)
+	sessionSvc := services.NewSessionService(services.NewCacheSessionStore(c))
 	cfg := &config.Config{
 		CFAPIUrl:      cfServer.URL,
 		CookieSecure:  false, // false for test (http)
@@ -843,7 +1072,7 @@ func TestLogin_SetsCSRFCookie(t *testing.T) {
 
 func TestLogout_ClearsCSRFCookie(t *testing.T) {
 	c := cache.New(5 * time.Minute)
-	sessionSvc := services.NewSessionService(c)
+	sessionSvc := services.NewSessionService(services.NewCacheSessionStore(c))
 
 	// Create a session
 	sessionID, err := sessionSvc.Create("testuser", "user-123", "access", "refresh", nil, time.Now().Add(time.Hour))
@@ -891,7 +1120,7 @@ func TestLogin_WrongOAuthClientCredentials(t *testing.T) {
 	defer uaaServer.Close()
 
 	c := cache.New(5 * time.Minute)
-	sessionSvc := services.NewSessionService(c)
+	sessionSvc := services.NewSessionService(services.NewCacheSessionStore(c))
 	cfg := &config.Config{
 		CFAPIUrl:          cfServer.URL,
 		CookieSecure:      false,
@@ -934,7 +1163,7 @@ func TestLogin_UsesConfiguredOAuthClient(t *testing.T) {
 	defer uaaServer.Close()
 
 	c := cache.New(5 * time.Minute)
-	sessionSvc := services.NewSessionService(c)
+	sessionSvc := services.NewSessionService(services.NewCacheSessionStore(c))
 	cfg := &config.Config{
 		CFAPIUrl:          cfServer.URL,
 		CookieSecure:      false,
@@ -975,7 +1204,7 @@ func TestRefresh_UsesConfiguredOAuthClient(t *testing.T) {
 	defer uaaServer.Close()
 
 	c := cache.New(5 * time.Minute)
-	sessionSvc := services.NewSessionService(c)
+	sessionSvc := services.NewSessionService(services.NewCacheSessionStore(c))
 
 	sessionID, err := sessionSvc.Create(
 		"testuser", "user-123", "old-access-token",
@@ -1014,3 +1243,90 @@ func TestRefresh_UsesConfiguredOAuthClient(t *testing.T) {
 		t.Error("Expected refreshed=true with custom OAuth client")
 	}
 }
+
+func TestLogin_RecordsAuditEvents(t *testing.T) {
+	cfServer, uaaServer := setupMockCFAndUAAServers("admin", "secret")
+	defer cfServer.Close()
+	defer uaaServer.Close()
+
+	c := cache.New(5 * time.Minute)
+	cfg := &config.Config{
+		CFAPIUrl:      cfServer.URL,
+		CookieSecure:  false,
+		OAuthClientID: "cf",
+	}
+	h := NewHandler(cfg, c)
+	h.SetSessionService(services.NewSessionService(services.NewCacheSessionStore(c)))
+
+	tests := []struct {
+		name        string
+		body        string
+		wantStatus  int
+		wantOutcome string
+		wantReason  string
+	}{
+		{"success", `{"username":"admin","password":"secret"}`, http.StatusOK, audit.OutcomeSuccess, ""},
+		{"failure", `{"username":"admin","password":"wrongpassword"}`, http.StatusUnauthorized, audit.OutcomeFailure, "invalid_credentials"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			h.SetAuditLogger(audit.New(&buf))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(tt.body))
+			req = req.WithContext(logger.WithRequestID(req.Context(), "req-"+tt.name))
+			w := httptest.NewRecorder()
+			h.Login(w, req)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Status = %d, want %d", w.Code, tt.wantStatus)
+			}
+
+			var entry map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("Expected one audit entry, got %q: %v", buf.String(), err)
+			}
+			if entry["audit"] != true || entry["action"] != audit.ActionLogin || entry["username"] != "admin" ||
+				entry["outcome"] != tt.wantOutcome || entry["request_id"] != "req-"+tt.name {
+				t.Errorf("Unexpected audit entry: %v", entry)
+			}
+			if reason, _ := entry["reason"].(string); reason != tt.wantReason {
+				t.Errorf("Reason = %q, want %q", reason, tt.wantReason)
+			}
+			if _, ok := entry["time"]; !ok {
+				t.Error("Expected a timestamp in the audit entry")
+			}
+
+			for _, secret := range []string{"secret", "wrongpassword", "test-access-token-xyz", "test-refresh-token-xyz"} {
+				if strings.Contains(buf.String(), secret) {
+					t.Errorf("Audit entry must not contain %q: %s", secret, buf.String())
+				}
+			}
+		})
+	}
+}
+
+func TestLogout_RecordsAuditEvent(t *testing.T) {
+	c := cache.New(5 * time.Minute)
+	sessionSvc := services.NewSessionService(services.NewCacheSessionStore(c))
+	sessionID, err := sessionSvc.Create("testuser", "user-123", "access", "refresh", nil, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	h := NewHandler(&config.Config{CookieSecure: false}, c)
+	h.SetSessionService(sessionSvc)
+	var buf bytes.Buffer
+	h.SetAuditLogger(audit.New(&buf))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/logout", nil)
+	req.AddCookie(&http.Cookie{Name: "DIEGO_SESSION", Value: sessionID})
+	h.Logout(httptest.NewRecorder(), req)
+
+	if !strings.Contains(buf.String(), `"action":"logout"`) || !strings.Contains(buf.String(), `"username":"testuser"`) {
+		t.Errorf("Expected a logout audit entry for testuser, got %q", buf.String())
+	}
+	if strings.Contains(buf.String(), sessionID) {
+		t.Error("Audit entry must not contain the session ID")
+	}
+}
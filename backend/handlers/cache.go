@@ -0,0 +1,78 @@
+// ABOUTME: HTTP handlers for cache statistics and invalidation
+// ABOUTME: Lets operators see what is cached and force infrastructure re-discovery
+
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/markalston/diego-capacity-analyzer/backend/audit"
+	"github.com/markalston/diego-capacity-analyzer/backend/cache"
+)
+
+// CacheInvalidateResponse is the response for POST /api/v1/cache/invalidate
+type CacheInvalidateResponse struct {
+	Invalidated []string `json:"invalidated"`
+}
+
+// CacheStatsResponse is the response for GET /api/v1/cache/stats. Keys lists
+// only infrastructure and app data entries; OtherEntries counts the rest.
+type CacheStatsResponse struct {
+	Entries      int              `json:"entries"`
+	Hits         int64            `json:"hits"`
+	Misses       int64            `json:"misses"`
+	Keys         []cache.KeyStats `json:"keys"`
+	OtherEntries int              `json:"other_entries"`
+}
+
+// listedCacheKeyPrefixes are the cache keys CacheStats may name. Sessions and
+// login failures share the cache, and their keys carry session IDs and
+// usernames, so they are only counted.
+var listedCacheKeyPrefixes = []string{"infrastructure:", "cf:", "dashboard:", "scenario:"}
+
+// CacheStats returns the cache entry count, hit/miss counters, and the TTL
+// remaining on each live infrastructure and app data key.
+// HTTP method validation handled by Go 1.22+ router pattern matching.
+func (h *Handler) CacheStats(w http.ResponseWriter, r *http.Request) {
+	resp := CacheStatsResponse{Keys: []cache.KeyStats{}}
+	if h.cache == nil {
+		h.writeJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	stats := h.cache.Stats()
+	resp.Entries, resp.Hits, resp.Misses = stats.Entries, stats.Hits, stats.Misses
+	for _, key := range stats.Keys {
+		if isListedCacheKey(key.Key) {
+			resp.Keys = append(resp.Keys, key)
+		} else {
+			resp.OtherEntries++
+		}
+	}
+	h.writeJSON(w, http.StatusOK, resp)
+}
+
+// isListedCacheKey reports whether key may be named in CacheStats
+func isListedCacheKey(key string) bool {
+	for _, prefix := range listedCacheKeyPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// InvalidateCache clears the infrastructure state, cached vSphere discovery,
+// and cached CF app totals so the next GET /api/v1/infrastructure re-discovers.
+// HTTP method validation handled by Go 1.22+ router pattern matching.
+func (h *Handler) InvalidateCache(w http.ResponseWriter, r *http.Request) {
+	h.clearInfrastructureState()
+	slog.InfoContext(r.Context(), "Infrastructure state invalidated")
+	h.recordAudit(r, audit.Event{Action: audit.ActionInfrastructureClear, Outcome: audit.OutcomeSuccess})
+
+	h.writeJSON(w, http.StatusOK, CacheInvalidateResponse{
+		Invalidated: []string{infrastructureStateKey, vsphereInfrastructureCacheKey, appDataCacheKey},
+	})
+}
@@ -0,0 +1,170 @@
+// ABOUTME: Tests for cache statistics and invalidation handlers
+// ABOUTME: Verifies stats reporting hides session keys and that invalidation forces re-discovery
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/markalston/diego-capacity-analyzer/backend/cache"
+	"github.com/markalston/diego-capacity-analyzer/backend/config"
+	"github.com/markalston/diego-capacity-analyzer/backend/middleware"
+	"github.com/markalston/diego-capacity-analyzer/backend/models"
+	"github.com/markalston/diego-capacity-analyzer/backend/services"
+)
+
+func TestCacheStats(t *testing.T) {
+	c := cache.New(5 * time.Minute)
+	handler := NewHandler(&config.Config{}, c)
+
+	c.SetWithTTL(vsphereInfrastructureCacheKey, models.InfrastructureState{Name: "prod"}, 30*time.Second)
+	c.Get(vsphereInfrastructureCacheKey)
+	c.Get("dashboard:all")
+
+	req := httptest.NewRequest("GET", "/api/v1/cache/stats", nil)
+	w := httptest.NewRecorder()
+	handler.CacheStats(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var stats CacheStatsResponse
+	if err := json.NewDecoder(w.Body).Decode(&stats); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if stats.Entries != 1 || len(stats.Keys) != 1 || stats.Keys[0].Key != vsphereInfrastructureCacheKey {
+		t.Errorf("Expected one %s entry, got %+v", vsphereInfrastructureCacheKey, stats)
+	}
+	if stats.Keys[0].TTLRemainingSeconds <= 0 || stats.Keys[0].TTLRemainingSeconds > 30 {
+		t.Errorf("Expected TTL remaining within 30s, got %d", stats.Keys[0].TTLRemainingSeconds)
+	}
+	if stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("Expected 1 hit and 1 miss, got %d hits and %d misses", stats.Hits, stats.Misses)
+	}
+}
+
+func TestCacheStats_HidesSessionKeys(t *testing.T) {
+	cfServer, uaaServer := setupMockCFAndUAAServers("admin", "secret")
+	defer cfServer.Close()
+	defer uaaServer.Close()
+
+	c := cache.New(5 * time.Minute)
+	cfg := &config.Config{CFAPIUrl: cfServer.URL, OAuthClientID: "cf", LoginMaxFailures: 5, LoginMaxFailuresPerIP: 10, LoginLockoutSecs: 60}
+	handler := NewHandler(cfg, c)
+	handler.SetSessionService(services.NewSessionService(services.NewCacheSessionStore(c)))
+	c.Set(vsphereInfrastructureCacheKey, models.InfrastructureState{Name: "prod"})
+
+	login := func(password string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(`{"username":"admin","password":"`+password+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		handler.Login(w, req)
+		return w
+	}
+	if w := login("wrong"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected failed login, got %d", w.Code)
+	}
+	w := login("secret")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected login to succeed, got %d: %s", w.Code, w.Body.String())
+	}
+	var sessionID string
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == "DIEGO_SESSION" {
+			sessionID = cookie.Value
+		}
+	}
+	if sessionID == "" {
+		t.Fatal("Expected a DIEGO_SESSION cookie")
+	}
+
+	w = httptest.NewRecorder()
+	handler.CacheStats(w, httptest.NewRequest("GET", "/api/v1/cache/stats", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), sessionID) {
+		t.Errorf("Expected the session ID not to appear in the response, got %s", w.Body.String())
+	}
+
+	var stats CacheStatsResponse
+	if err := json.NewDecoder(w.Body).Decode(&stats); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	for _, key := range stats.Keys {
+		if strings.HasPrefix(key.Key, "session") || strings.HasPrefix(key.Key, "login_failures:") {
+			t.Errorf("Expected session and login failure keys to be hidden, got %q", key.Key)
+		}
+	}
+	if len(stats.Keys) != 1 || stats.Keys[0].Key != vsphereInfrastructureCacheKey {
+		t.Errorf("Expected only %s listed, got %+v", vsphereInfrastructureCacheKey, stats.Keys)
+	}
+	if stats.OtherEntries == 0 || stats.Entries != len(stats.Keys)+stats.OtherEntries {
+		t.Errorf("Expected the hidden entries to be counted, got %d of %d", stats.OtherEntries, stats.Entries)
+	}
+
+	for _, route := range handler.Routes() {
+		if route.Path == "/api/v1/cache/stats" && route.Role != middleware.RoleOperator {
+			t.Errorf("Expected the stats route to require the operator role, got %q", route.Role)
+		}
+	}
+}
+
+func TestInvalidateCache(t *testing.T) {
+	cfg := &config.Config{StateFile: filepath.Join(t.TempDir(), "state.json")}
+	c := cache.New(5 * time.Minute)
+	handler := NewHandler(cfg, c)
+
+	body := `{"name": "Stale", "clusters": [{"name": "c1", "host_count": 4, "memory_gb_per_host": 512, "diego_cell_count": 10, "diego_cell_memory_gb": 32}]}`
+	req := httptest.NewRequest("POST", "/api/v1/infrastructure/manual", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	handler.SetManualInfrastructure(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Failed to set manual infrastructure: %s", w.Body.String())
+	}
+	c.Set(vsphereInfrastructureCacheKey, models.InfrastructureState{Name: "Stale"})
+	c.Set(appDataCacheKey, models.AppDataSnapshot{TotalAppInstances: 10})
+
+	req = httptest.NewRequest("POST", "/api/v1/cache/invalidate", nil)
+	w = httptest.NewRecorder()
+	handler.InvalidateCache(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp CacheInvalidateResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(resp.Invalidated) != 3 {
+		t.Errorf("Expected three invalidated keys, got %v", resp.Invalidated)
+	}
+
+	if handler.CurrentInfrastructureState() != nil {
+		t.Error("Expected infrastructure state to be cleared")
+	}
+	if _, found := c.Get(vsphereInfrastructureCacheKey); found {
+		t.Error("Expected cached vSphere discovery to be cleared")
+	}
+	if _, found := c.Get(appDataCacheKey); found {
+		t.Error("Expected cached CF app totals to be cleared")
+	}
+	if _, err := os.Stat(cfg.StateFile); !os.IsNotExist(err) {
+		t.Errorf("Expected state file to be removed, stat error: %v", err)
+	}
+
+	// A restart must not bring the invalidated state back
+	restarted := NewHandler(cfg, cache.New(5*time.Minute))
+	restarted.RestoreInfrastructureState()
+	if restarted.CurrentInfrastructureState() != nil {
+		t.Error("Expected no state to restore after invalidation")
+	}
+}
@@ -4,32 +4,46 @@
 package handlers
 
 import (
+	"context"
 	"crypto/tls"
+	"encoding/json"
+	"errors"
 	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
+
+	"github.com/markalston/diego-capacity-analyzer/backend/services"
 )
 
-// getSessionToken retrieves the CF access token from the session cookie.
-// Returns empty string and writes 401 response if session is invalid.
+// getSessionToken retrieves the CF access token from the session cookie,
+// renewing it first when it is close to expiry so proxied calls never carry a
+// stale token. Returns empty string and writes 401 response if session is
+// invalid or can no longer be renewed.
 func (h *Handler) getSessionToken(w http.ResponseWriter, r *http.Request) string {
 	cookie, err := r.Cookie("DIEGO_SESSION")
 	if err != nil {
-		slog.Debug("CF proxy: no session cookie", "path", r.URL.Path)
+		slog.DebugContext(r.Context(), "CF proxy: no session cookie", "path", r.URL.Path)
 		h.writeError(w, "Authentication required", http.StatusUnauthorized)
 		return ""
 	}
 
 	if h.sessionService == nil {
-		slog.Error("CF proxy: session service not configured")
+		slog.ErrorContext(r.Context(), "CF proxy: session service not configured")
 		h.writeError(w, "Server configuration error", http.StatusInternalServerError)
 		return ""
 	}
 
-	session, err := h.sessionService.Get(cookie.Value)
+	session, _, err := h.sessionService.RefreshIfNeeded(cookie.Value)
+	if errors.Is(err, services.ErrSessionExpired) {
+		slog.WarnContext(r.Context(), "CF proxy: token refresh failed", "path", r.URL.Path, "error", err)
+		h.expireSession(w, cookie.Value)
+		return ""
+	}
 	if err != nil {
-		slog.Debug("CF proxy: invalid session", "path", r.URL.Path)
+		slog.DebugContext(r.Context(), "CF proxy: invalid session", "path", r.URL.Path)
 		h.writeError(w, "Invalid session", http.StatusUnauthorized)
 		return ""
 	}
@@ -37,15 +51,18 @@ func (h *Handler) getSessionToken(w http.ResponseWriter, r *http.Request) string
 	return session.AccessToken
 }
 
-// proxyCFRequest makes an authenticated request to the CF API and streams the response.
-func (h *Handler) proxyCFRequest(w http.ResponseWriter, cfPath, token string) {
-	cfURL := h.cfg.CFAPIUrl + cfPath
+// maxCFProxyPages caps how many CF pages a list proxy follows, so one request
+// against a huge foundation cannot fan out into unbounded CF API calls
+const maxCFProxyPages = 50
+
+// cfProxyPerPage is the page size requested from CF when the caller sets none
+const cfProxyPerPage = "500"
 
-	req, err := http.NewRequest(http.MethodGet, cfURL, nil)
+// doCFRequest makes an authenticated GET request to the CF API.
+func (h *Handler) doCFRequest(ctx context.Context, cfPath, token string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.cfg.CFAPIUrl+cfPath, nil)
 	if err != nil {
-		slog.Error("CF proxy: failed to create request", "error", err)
-		h.writeError(w, "Internal error", http.StatusInternalServerError)
-		return
+		return nil, err
 	}
 
 	req.Header.Set("Authorization", "Bearer "+token)
@@ -57,52 +74,180 @@ func (h *Handler) proxyCFRequest(w http.ResponseWriter, cfPath, token string) {
 			TLSClientConfig: &tls.Config{InsecureSkipVerify: h.cfg.CFSkipSSLValidation},
 		},
 	}
-	resp, err := client.Do(req)
+	return client.Do(req)
+}
+
+// proxyCFRequest makes an authenticated request to the CF API and streams the response.
+func (h *Handler) proxyCFRequest(w http.ResponseWriter, r *http.Request, cfPath, token string) {
+	cfURL := h.cfg.CFAPIUrl + cfPath
+
+	resp, err := h.doCFRequest(r.Context(), cfPath, token)
 	if err != nil {
-		slog.Error("CF proxy: request failed", "url", cfURL, "error", err)
+		slog.ErrorContext(r.Context(), "CF proxy: request failed", "url", cfURL, "error", err)
 		h.writeError(w, "CF API request failed", http.StatusBadGateway)
 		return
 	}
 	defer resp.Body.Close()
 
-	// Copy CF API response headers
+	copyCFResponse(w, r, resp, cfURL)
+}
+
+// copyCFResponse writes a CF API response's headers, status, and body to w.
+func copyCFResponse(w http.ResponseWriter, r *http.Request, resp *http.Response, cfURL string) {
 	for key, values := range resp.Header {
 		for _, value := range values {
 			w.Header().Add(key, value)
 		}
 	}
 
-	// Stream the response
 	w.WriteHeader(resp.StatusCode)
 	if _, err := io.Copy(w, resp.Body); err != nil {
-		slog.Error("CF proxy: failed while streaming response body", "error", err, "status", resp.StatusCode, "url", cfURL)
+		slog.ErrorContext(r.Context(), "CF proxy: failed while streaming response body", "error", err, "status", resp.StatusCode, "url", cfURL)
+	}
+}
+
+// cfListPage is one page of a CF v3 list response
+type cfListPage struct {
+	Resources  []json.RawMessage `json:"resources"`
+	Pagination struct {
+		Next *struct {
+			Href string `json:"href"`
+		} `json:"next"`
+	} `json:"pagination"`
+}
+
+// cfListResponse is the aggregated list returned by proxyCFList. It keeps the
+// CF v3 shape with a single page so clients that follow pagination.next stop
+// after one request.
+type cfListResponse struct {
+	Pagination cfListPagination  `json:"pagination"`
+	Resources  []json.RawMessage `json:"resources"`
+}
+
+// cfListPagination describes an aggregated list; Truncated is set when the
+// page cap was reached before CF ran out of pages
+type cfListPagination struct {
+	TotalResults int  `json:"total_results"`
+	TotalPages   int  `json:"total_pages"`
+	Next         any  `json:"next"`
+	Truncated    bool `json:"truncated"`
+}
+
+// proxyCFList fetches every page of a CF v3 list endpoint, following
+// pagination.next up to maxCFProxyPages, and returns the combined resources.
+// The caller's query string is forwarded, except name, which filters the
+// combined resources by a case-insensitive substring of their name. An error
+// status from CF on the first page is passed through unchanged.
+func (h *Handler) proxyCFList(w http.ResponseWriter, r *http.Request, cfPath, token string) {
+	ctx := r.Context()
+
+	query := r.URL.Query()
+	nameFilter := strings.ToLower(strings.TrimSpace(query.Get("name")))
+	query.Del("name")
+	if query.Get("per_page") == "" {
+		query.Set("per_page", cfProxyPerPage)
+	}
+	path := cfPath + "?" + query.Encode()
+
+	resources := []json.RawMessage{}
+	truncated := false
+	for page := 1; path != ""; page++ {
+		if page > maxCFProxyPages {
+			slog.WarnContext(ctx, "CF proxy: page cap reached, returning partial results", "path", cfPath, "max_pages", maxCFProxyPages)
+			truncated = true
+			break
+		}
+
+		cfURL := h.cfg.CFAPIUrl + path
+		resp, err := h.doCFRequest(ctx, path, token)
+		if err != nil {
+			slog.ErrorContext(ctx, "CF proxy: request failed", "url", cfURL, "page", page, "error", err)
+			h.writeError(w, "CF API request failed", http.StatusBadGateway)
+			return
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			defer resp.Body.Close()
+			if page == 1 {
+				copyCFResponse(w, r, resp, cfURL)
+				return
+			}
+			slog.ErrorContext(ctx, "CF proxy: page request failed", "url", cfURL, "page", page, "status", resp.StatusCode)
+			h.writeError(w, "CF API request failed", http.StatusBadGateway)
+			return
+		}
+
+		var body cfListPage
+		err = json.NewDecoder(resp.Body).Decode(&body)
+		resp.Body.Close()
+		if err != nil {
+			slog.ErrorContext(ctx, "CF proxy: invalid page", "url", cfURL, "page", page, "error", err)
+			h.writeError(w, "CF API returned an invalid response", http.StatusBadGateway)
+			return
+		}
+		resources = append(resources, body.Resources...)
+
+		path = ""
+		if body.Pagination.Next != nil && body.Pagination.Next.Href != "" {
+			next, err := url.Parse(body.Pagination.Next.Href)
+			if err != nil {
+				slog.ErrorContext(ctx, "CF proxy: invalid next page URL", "href", body.Pagination.Next.Href, "error", err)
+				h.writeError(w, "CF API returned an invalid response", http.StatusBadGateway)
+				return
+			}
+			path = next.Path + "?" + next.RawQuery
+		}
 	}
+
+	if nameFilter != "" {
+		resources = filterCFResourcesByName(resources, nameFilter)
+	}
+
+	h.writeJSON(w, http.StatusOK, cfListResponse{
+		Pagination: cfListPagination{
+			TotalResults: len(resources),
+			TotalPages:   1,
+			Truncated:    truncated,
+		},
+		Resources: resources,
+	})
 }
 
-// CFProxyIsolationSegments proxies GET /v3/isolation_segments to CF API.
+// filterCFResourcesByName keeps the resources whose name contains filter,
+// which must already be lower-cased
+func filterCFResourcesByName(resources []json.RawMessage, filter string) []json.RawMessage {
+	filtered := []json.RawMessage{}
+	for _, raw := range resources {
+		var named struct {
+			Name string `json:"name"`
+		}
+		if json.Unmarshal(raw, &named) == nil && strings.Contains(strings.ToLower(named.Name), filter) {
+			filtered = append(filtered, raw)
+		}
+	}
+	return filtered
+}
+
+// CFProxyIsolationSegments proxies GET /v3/isolation_segments to CF API,
+// aggregating all pages. An optional ?name= filters by segment name.
 func (h *Handler) CFProxyIsolationSegments(w http.ResponseWriter, r *http.Request) {
 	token := h.getSessionToken(w, r)
 	if token == "" {
 		return
 	}
 
-	h.proxyCFRequest(w, "/v3/isolation_segments", token)
+	h.proxyCFList(w, r, "/v3/isolation_segments", token)
 }
 
-// CFProxyApps proxies GET /v3/apps to CF API.
+// CFProxyApps proxies GET /v3/apps to CF API, aggregating all pages. Query
+// parameters are forwarded to CF; an optional ?name= filters by app name.
 func (h *Handler) CFProxyApps(w http.ResponseWriter, r *http.Request) {
 	token := h.getSessionToken(w, r)
 	if token == "" {
 		return
 	}
 
-	// Preserve query string for pagination
-	cfPath := "/v3/apps"
-	if r.URL.RawQuery != "" {
-		cfPath += "?" + r.URL.RawQuery
-	}
-
-	h.proxyCFRequest(w, cfPath, token)
+	h.proxyCFList(w, r, "/v3/apps", token)
 }
 
 // CFProxyAppProcesses proxies GET /v3/apps/{guid}/processes to CF API.
@@ -118,7 +263,7 @@ func (h *Handler) CFProxyAppProcesses(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.proxyCFRequest(w, "/v3/apps/"+guid+"/processes", token)
+	h.proxyCFRequest(w, r, "/v3/apps/"+guid+"/processes", token)
 }
 
 // CFProxyProcessStats proxies GET /v3/processes/{guid}/stats to CF API.
@@ -134,7 +279,7 @@ func (h *Handler) CFProxyProcessStats(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.proxyCFRequest(w, "/v3/processes/"+guid+"/stats", token)
+	h.proxyCFRequest(w, r, "/v3/processes/"+guid+"/stats", token)
 }
 
 // CFProxySpaces proxies GET /v3/spaces/{guid} to CF API.
@@ -150,7 +295,7 @@ func (h *Handler) CFProxySpaces(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.proxyCFRequest(w, "/v3/spaces/"+guid, token)
+	h.proxyCFRequest(w, r, "/v3/spaces/"+guid, token)
 }
 
 // CFProxyIsolationSegmentByGUID proxies GET /v3/isolation_segments/{guid} to CF API.
@@ -166,5 +311,5 @@ func (h *Handler) CFProxyIsolationSegmentByGUID(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	h.proxyCFRequest(w, "/v3/isolation_segments/"+guid, token)
+	h.proxyCFRequest(w, r, "/v3/isolation_segments/"+guid, token)
 }
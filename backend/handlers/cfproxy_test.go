@@ -4,6 +4,7 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -14,6 +15,7 @@ import (
 
 	"github.com/markalston/diego-capacity-analyzer/backend/cache"
 	"github.com/markalston/diego-capacity-analyzer/backend/config"
+	"github.com/markalston/diego-capacity-analyzer/backend/models"
 	"github.com/markalston/diego-capacity-analyzer/backend/services"
 )
 
@@ -46,7 +48,7 @@ func TestCFProxyIsolationSegments(t *testing.T) {
 	cfg := &config.Config{CFAPIUrl: cfServer.URL}
 	h := NewHandler(cfg, c)
 
-	sessionSvc := services.NewSessionService(c)
+	sessionSvc := services.NewSessionService(services.NewCacheSessionStore(c))
 	h.SetSessionService(sessionSvc)
 
 	// Create a session with CF token
@@ -127,7 +129,7 @@ func TestCFProxyApps(t *testing.T) {
 	cfg := &config.Config{CFAPIUrl: cfServer.URL}
 	h := NewHandler(cfg, c)
 
-	sessionSvc := services.NewSessionService(c)
+	sessionSvc := services.NewSessionService(services.NewCacheSessionStore(c))
 	h.SetSessionService(sessionSvc)
 
 	sessionID, _ := sessionSvc.Create("testuser", "user-123", "test-cf-token", "test-refresh", nil, time.Now().Add(time.Hour))
@@ -155,6 +157,160 @@ func TestCFProxyApps(t *testing.T) {
 	})
 }
 
+// newPagedCFServer serves /v3/apps as pages of one app each, with names
+// taken from names, linking each page to the next with an absolute URL
+func newPagedCFServer(t *testing.T, names []string, requested *[]string) *httptest.Server {
+	t.Helper()
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requested != nil {
+			*requested = append(*requested, r.URL.RawQuery)
+		}
+		page := 1
+		fmt.Sscanf(r.URL.Query().Get("page"), "%d", &page)
+		if page < 1 || page > len(names) {
+			http.NotFound(w, r)
+			return
+		}
+
+		var next interface{}
+		if page < len(names) {
+			next = map[string]string{"href": fmt.Sprintf("%s/v3/apps?page=%d&per_page=1", server.URL, page+1)}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"pagination": map[string]interface{}{"total_results": len(names), "next": next},
+			"resources":  []map[string]string{{"guid": fmt.Sprintf("app-%d", page), "name": names[page-1]}},
+		})
+	}))
+	return server
+}
+
+func TestCFProxyList_Pagination(t *testing.T) {
+	names := []string{"billing-api", "Billing-Worker", "checkout", "inventory"}
+
+	newHandler := func(t *testing.T, cfURL string) (*Handler, string) {
+		c := cache.New(5 * time.Minute)
+		h := NewHandler(&config.Config{CFAPIUrl: cfURL}, c)
+		sessionSvc := services.NewSessionService(services.NewCacheSessionStore(c))
+		h.SetSessionService(sessionSvc)
+		sessionID, _ := sessionSvc.Create("testuser", "user-123", "test-cf-token", "test-refresh", nil, time.Now().Add(time.Hour))
+		return h, sessionID
+	}
+
+	get := func(h *Handler, sessionID, target string) (*httptest.ResponseRecorder, cfListResponse) {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.AddCookie(&http.Cookie{Name: "DIEGO_SESSION", Value: sessionID})
+		rr := httptest.NewRecorder()
+		h.CFProxyApps(rr, req)
+		var resp cfListResponse
+		json.Unmarshal(rr.Body.Bytes(), &resp)
+		return rr, resp
+	}
+
+	t.Run("aggregates every page", func(t *testing.T) {
+		var requested []string
+		cfServer := newPagedCFServer(t, names, &requested)
+		defer cfServer.Close()
+		h, sessionID := newHandler(t, cfServer.URL)
+
+		rr, resp := get(h, sessionID, "/api/v1/cf/apps")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		if len(resp.Resources) != len(names) || resp.Pagination.TotalResults != len(names) {
+			t.Errorf("Expected %d resources, got %d (total_results %d)", len(names), len(resp.Resources), resp.Pagination.TotalResults)
+		}
+		if resp.Pagination.Next != nil || resp.Pagination.Truncated {
+			t.Errorf("Expected a single untruncated page, got %+v", resp.Pagination)
+		}
+		if len(requested) != len(names) {
+			t.Errorf("Expected %d CF requests, got %d", len(names), len(requested))
+		}
+		if !strings.Contains(requested[0], "per_page="+cfProxyPerPage) {
+			t.Errorf("Expected default per_page on first request, got %q", requested[0])
+		}
+	})
+
+	t.Run("filters by name without forwarding it", func(t *testing.T) {
+		var requested []string
+		cfServer := newPagedCFServer(t, names, &requested)
+		defer cfServer.Close()
+		h, sessionID := newHandler(t, cfServer.URL)
+
+		rr, resp := get(h, sessionID, "/api/v1/cf/apps?name=billing")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		if len(resp.Resources) != 2 || resp.Pagination.TotalResults != 2 {
+			t.Errorf("Expected 2 billing apps, got %s", rr.Body.String())
+		}
+		if strings.Contains(requested[0], "name=") {
+			t.Errorf("name filter should not be forwarded to CF, got %q", requested[0])
+		}
+	})
+
+	t.Run("stops at the page cap", func(t *testing.T) {
+		many := make([]string, maxCFProxyPages+5)
+		for i := range many {
+			many[i] = fmt.Sprintf("app-%d", i)
+		}
+		var requested []string
+		cfServer := newPagedCFServer(t, many, &requested)
+		defer cfServer.Close()
+		h, sessionID := newHandler(t, cfServer.URL)
+
+		rr, resp := get(h, sessionID, "/api/v1/cf/apps")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		if len(requested) != maxCFProxyPages {
+			t.Errorf("Expected %d CF requests, got %d", maxCFProxyPages, len(requested))
+		}
+		if !resp.Pagination.Truncated || len(resp.Resources) != maxCFProxyPages {
+			t.Errorf("Expected %d truncated resources, got %d (truncated %v)", maxCFProxyPages, len(resp.Resources), resp.Pagination.Truncated)
+		}
+	})
+
+	t.Run("fails when a later page fails", func(t *testing.T) {
+		cfServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("page") == "2" {
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				return
+			}
+			fmt.Fprintf(w, `{"pagination":{"next":{"href":"http://%s/v3/apps?page=2"}},"resources":[{"name":"a"}]}`, r.Host)
+		}))
+		defer cfServer.Close()
+		h, sessionID := newHandler(t, cfServer.URL)
+
+		rr, _ := get(h, sessionID, "/api/v1/cf/apps")
+		if rr.Code != http.StatusBadGateway {
+			t.Errorf("Expected 502, got %d: %s", rr.Code, rr.Body.String())
+		}
+	})
+
+	t.Run("stops when the request is cancelled", func(t *testing.T) {
+		var requested []string
+		cfServer := newPagedCFServer(t, names, &requested)
+		defer cfServer.Close()
+		h, sessionID := newHandler(t, cfServer.URL)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/cf/apps", nil).WithContext(ctx)
+		req.AddCookie(&http.Cookie{Name: "DIEGO_SESSION", Value: sessionID})
+		rr := httptest.NewRecorder()
+		h.CFProxyApps(rr, req)
+
+		if rr.Code != http.StatusBadGateway {
+			t.Errorf("Expected 502, got %d", rr.Code)
+		}
+		if len(requested) != 0 {
+			t.Errorf("Expected no CF requests after cancellation, got %d", len(requested))
+		}
+	})
+}
+
 func TestCFProxyAppProcesses(t *testing.T) {
 	cfServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		auth := r.Header.Get("Authorization")
@@ -181,7 +337,7 @@ func TestCFProxyAppProcesses(t *testing.T) {
 	cfg := &config.Config{CFAPIUrl: cfServer.URL}
 	h := NewHandler(cfg, c)
 
-	sessionSvc := services.NewSessionService(c)
+	sessionSvc := services.NewSessionService(services.NewCacheSessionStore(c))
 	h.SetSessionService(sessionSvc)
 
 	sessionID, _ := sessionSvc.Create("testuser", "user-123", "test-cf-token", "test-refresh", nil, time.Now().Add(time.Hour))
@@ -227,7 +383,7 @@ func TestCFProxyProcessStats(t *testing.T) {
 	cfg := &config.Config{CFAPIUrl: cfServer.URL}
 	h := NewHandler(cfg, c)
 
-	sessionSvc := services.NewSessionService(c)
+	sessionSvc := services.NewSessionService(services.NewCacheSessionStore(c))
 	h.SetSessionService(sessionSvc)
 
 	sessionID, _ := sessionSvc.Create("testuser", "user-123", "test-cf-token", "test-refresh", nil, time.Now().Add(time.Hour))
@@ -271,7 +427,7 @@ func TestCFProxySpaces(t *testing.T) {
 	cfg := &config.Config{CFAPIUrl: cfServer.URL}
 	h := NewHandler(cfg, c)
 
-	sessionSvc := services.NewSessionService(c)
+	sessionSvc := services.NewSessionService(services.NewCacheSessionStore(c))
 	h.SetSessionService(sessionSvc)
 
 	sessionID, _ := sessionSvc.Create("testuser", "user-123", "test-cf-token", "test-refresh", nil, time.Now().Add(time.Hour))
@@ -315,7 +471,7 @@ func TestCFProxyIsolationSegmentByGUID(t *testing.T) {
 	cfg := &config.Config{CFAPIUrl: cfServer.URL}
 	h := NewHandler(cfg, c)
 
-	sessionSvc := services.NewSessionService(c)
+	sessionSvc := services.NewSessionService(services.NewCacheSessionStore(c))
 	h.SetSessionService(sessionSvc)
 
 	sessionID, _ := sessionSvc.Create("testuser", "user-123", "test-cf-token", "test-refresh", nil, time.Now().Add(time.Hour))
@@ -345,7 +501,7 @@ func TestCFProxyHandlesCFAPIErrors(t *testing.T) {
 	cfg := &config.Config{CFAPIUrl: cfServer.URL}
 	h := NewHandler(cfg, c)
 
-	sessionSvc := services.NewSessionService(c)
+	sessionSvc := services.NewSessionService(services.NewCacheSessionStore(c))
 	h.SetSessionService(sessionSvc)
 
 	sessionID, _ := sessionSvc.Create("testuser", "user-123", "test-cf-token", "test-refresh", nil, time.Now().Add(time.Hour))
@@ -378,7 +534,7 @@ func TestCFProxySessionTokenUsedCorrectly(t *testing.T) {
 	cfg := &config.Config{CFAPIUrl: cfServer.URL}
 	h := NewHandler(cfg, c)
 
-	sessionSvc := services.NewSessionService(c)
+	sessionSvc := services.NewSessionService(services.NewCacheSessionStore(c))
 	h.SetSessionService(sessionSvc)
 
 	// Create session with specific token
@@ -396,3 +552,140 @@ func TestCFProxySessionTokenUsedCorrectly(t *testing.T) {
 		t.Errorf("Expected Authorization header %q, got %q", expectedAuth, capturedAuth)
 	}
 }
+
+func TestCFProxyRefreshesTokenNearExpiry(t *testing.T) {
+	_, uaaServer := setupMockCFAndUAAServersWithRefresh("admin", "secret", "valid-refresh")
+	defer uaaServer.Close()
+
+	var gotAuth string
+	cfServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/v3/info" {
+			fmt.Fprintf(w, `{"links":{"login":{"href":%q}}}`, uaaServer.URL)
+			return
+		}
+		gotAuth = r.Header.Get("Authorization")
+		w.Write([]byte(`{"resources":[]}`))
+	}))
+	defer cfServer.Close()
+
+	c := cache.New(5 * time.Minute)
+	h := NewHandler(&config.Config{CFAPIUrl: cfServer.URL, OAuthClientID: "cf"}, c)
+	sessionSvc := services.NewSessionService(services.NewCacheSessionStore(c))
+	h.SetSessionService(sessionSvc)
+
+	t.Run("renews token before forwarding", func(t *testing.T) {
+		sessionID, _ := sessionSvc.Create("testuser", "user-123", "stale-token", "valid-refresh", nil, time.Now().Add(2*time.Minute))
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/cf/apps", nil)
+		req.AddCookie(&http.Cookie{Name: "DIEGO_SESSION", Value: sessionID})
+		rr := httptest.NewRecorder()
+		h.CFProxyApps(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		if gotAuth != "Bearer new-access-token-refreshed" {
+			t.Errorf("Expected refreshed token to be forwarded, got %q", gotAuth)
+		}
+	})
+
+	t.Run("failed renewal expires the session", func(t *testing.T) {
+		sessionID, _ := sessionSvc.Create("testuser", "user-123", "stale-token", "revoked-refresh", nil, time.Now().Add(2*time.Minute))
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/cf/apps", nil)
+		req.AddCookie(&http.Cookie{Name: "DIEGO_SESSION", Value: sessionID})
+		rr := httptest.NewRecorder()
+		h.CFProxyApps(rr, req)
+
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("Expected 401, got %d", rr.Code)
+		}
+		if !strings.Contains(rr.Body.String(), "Session expired") {
+			t.Errorf("Expected session expired error, got %s", rr.Body.String())
+		}
+		if _, err := sessionSvc.Get(sessionID); err == nil {
+			t.Error("Expected session to be deleted after failed renewal")
+		}
+		var cleared bool
+		for _, cookie := range rr.Result().Cookies() {
+			if cookie.Name == "DIEGO_SESSION" && cookie.MaxAge == -1 {
+				cleared = true
+			}
+		}
+		if !cleared {
+			t.Error("Expected DIEGO_SESSION cookie to be cleared")
+		}
+	})
+}
+
+func TestSetInfrastructureFromCF(t *testing.T) {
+	cfServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer renewed-token" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v3/apps":
+			w.Write([]byte(`{"resources":[{"guid":"app-1"}],"pagination":{"next":null}}`))
+		case "/v3/processes":
+			w.Write([]byte(`{"resources":[{"instances":4,"memory_in_mb":1024,"disk_in_mb":2048,"relationships":{"app":{"data":{"guid":"app-1"}}}}],"pagination":{"next":null}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer cfServer.Close()
+
+	c := cache.New(5 * time.Minute)
+	h := NewHandler(&config.Config{CFAPIUrl: cfServer.URL}, c)
+	sessionSvc := services.NewSessionService(services.NewCacheSessionStore(c))
+	h.SetSessionService(sessionSvc)
+
+	// The session token is about to expire, so the request must renew it first
+	sessionSvc.SetTokenRefresher(func(refreshToken string) (services.RefreshedTokens, error) {
+		return services.RefreshedTokens{AccessToken: "renewed-token", RefreshToken: "r2", Expiry: time.Now().Add(time.Hour)}, nil
+	})
+	sessionID, _ := sessionSvc.Create("testuser", "user-123", "stale-token", "r1", nil, time.Now().Add(10*time.Second))
+
+	post := func(withSession bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/infrastructure/from-cf", nil)
+		if withSession {
+			req.AddCookie(&http.Cookie{Name: "DIEGO_SESSION", Value: sessionID})
+		}
+		rr := httptest.NewRecorder()
+		h.SetInfrastructureFromCF(rr, req)
+		return rr
+	}
+
+	if rr := post(true); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without infrastructure data, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	h.storeInfrastructureState(&models.InfrastructureState{Name: "env", TotalCellCount: 10, TotalAppMemoryGB: 999})
+
+	if rr := post(false); rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without session, got %d", rr.Code)
+	}
+
+	rr := post(true)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var state models.InfrastructureState
+	if err := json.NewDecoder(rr.Body).Decode(&state); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if state.TotalAppMemoryGB != 4 || state.TotalAppDiskGB != 8 || state.TotalAppInstances != 4 {
+		t.Errorf("Unexpected app totals: memory=%d disk=%d instances=%d", state.TotalAppMemoryGB, state.TotalAppDiskGB, state.TotalAppInstances)
+	}
+	if state.MaxInstanceMemoryMB != 1024 || state.AvgInstanceMemoryMB != 1024 {
+		t.Errorf("Unexpected instance memory: max=%d avg=%d", state.MaxInstanceMemoryMB, state.AvgInstanceMemoryMB)
+	}
+	if state.Name != "env" || state.TotalCellCount != 10 {
+		t.Errorf("Expected non-app fields to be preserved, got name=%q cells=%d", state.Name, state.TotalCellCount)
+	}
+	if stored := h.CurrentInfrastructureState(); stored.TotalAppMemoryGB != 4 {
+		t.Errorf("Expected stored state to be updated, got %d GB", stored.TotalAppMemoryGB)
+	}
+}
@@ -171,10 +171,10 @@ func (h *Handler) Chat(w http.ResponseWriter, r *http.Request) {
 	// Disable read/write deadlines for long-lived streaming connection
 	rc := http.NewResponseController(w)
 	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
-		slog.Warn("failed to clear write deadline", "error", err)
+		slog.WarnContext(r.Context(), "failed to clear write deadline", "error", err)
 	}
 	if err := rc.SetReadDeadline(time.Time{}); err != nil {
-		slog.Warn("failed to clear read deadline", "error", err)
+		slog.WarnContext(r.Context(), "failed to clear read deadline", "error", err)
 	}
 
 	// Convert ChatMessages to ai.Messages
@@ -213,7 +213,7 @@ func (h *Handler) Chat(w http.ResponseWriter, r *http.Request) {
 		case event, chanOpen := <-tokenCh:
 			if !chanOpen {
 				// Channel closed without a done event -- provider ended unexpectedly
-				slog.Error("AI provider channel closed without done event",
+				slog.ErrorContext(r.Context(), "AI provider channel closed without done event",
 					"username", claims.Username,
 					"tokens_sent", seq,
 				)
@@ -221,13 +221,13 @@ func (h *Handler) Chat(w http.ResponseWriter, r *http.Request) {
 					Code:    "provider_error",
 					Message: "AI provider terminated unexpectedly",
 				}); err != nil {
-					slog.Warn("failed to write SSE error for channel close", "error", err)
+					slog.WarnContext(r.Context(), "failed to write SSE error for channel close", "error", err)
 				}
 				return
 			}
 
 			if event.Err != nil {
-				slog.Error("AI provider error during streaming",
+				slog.ErrorContext(r.Context(), "AI provider error during streaming",
 					"error", event.Err,
 					"username", claims.Username,
 					"messages", len(req.Messages),
@@ -236,7 +236,7 @@ func (h *Handler) Chat(w http.ResponseWriter, r *http.Request) {
 					Code:    "provider_error",
 					Message: event.Err.Error(),
 				}); err != nil {
-					slog.Warn("failed to write SSE error event", "error", err)
+					slog.WarnContext(r.Context(), "failed to write SSE error event", "error", err)
 				}
 				return
 			}
@@ -253,7 +253,7 @@ func (h *Handler) Chat(w http.ResponseWriter, r *http.Request) {
 					StopReason: event.StopReason,
 					Usage:      usage,
 				}); err != nil {
-					slog.Warn("failed to write SSE done event", "error", err)
+					slog.WarnContext(r.Context(), "failed to write SSE done event", "error", err)
 				}
 				return
 			}
@@ -283,7 +283,7 @@ func (h *Handler) Chat(w http.ResponseWriter, r *http.Request) {
 				Code:    "timeout",
 				Message: "No response from AI provider within timeout window",
 			}); err != nil {
-				slog.Warn("failed to write SSE idle timeout event", "error", err)
+				slog.WarnContext(r.Context(), "failed to write SSE idle timeout event", "error", err)
 			}
 			return
 
@@ -295,7 +295,7 @@ func (h *Handler) Chat(w http.ResponseWriter, r *http.Request) {
 					Code:    "timeout",
 					Message: "Response exceeded maximum duration",
 				}); err != nil {
-					slog.Warn("failed to write SSE max duration event", "error", err)
+					slog.WarnContext(r.Context(), "failed to write SSE max duration event", "error", err)
 				}
 			default:
 				// Client disconnected -- no one to send to
@@ -0,0 +1,116 @@
+// ABOUTME: Concurrency tests for the handler's stored infrastructure state
+// ABOUTME: Run with -race to check that concurrent updates and reads are synchronized
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/markalston/diego-capacity-analyzer/backend/cache"
+	"github.com/markalston/diego-capacity-analyzer/backend/config"
+	"github.com/markalston/diego-capacity-analyzer/backend/models"
+	"github.com/markalston/diego-capacity-analyzer/backend/services"
+)
+
+func TestInfrastructureState_ConcurrentUpdatesAndReads(t *testing.T) {
+	h := NewHandler(&config.Config{}, cache.New(5*time.Minute))
+
+	manual := func(cells int) string {
+		return fmt.Sprintf(`{"name": "env", "clusters": [{"name": "cluster-01", "host_count": 4, "memory_gb_per_host": 1024,
+			"cpu_threads_per_host": 64, "diego_cell_count": %d, "diego_cell_memory_gb": 32, "diego_cell_cpu": 4}],
+			"total_app_memory_gb": 400, "total_app_instances": 2000}`, cells)
+	}
+	call := func(handler http.HandlerFunc, method, path, body string) {
+		rr := httptest.NewRecorder()
+		handler(rr, httptest.NewRequest(method, path, strings.NewReader(body)))
+		if rr.Code != http.StatusOK {
+			t.Errorf("%s %s: expected 200, got %d: %s", method, path, rr.Code, rr.Body.String())
+		}
+	}
+
+	call(h.SetManualInfrastructure, http.MethodPost, "/api/v1/infrastructure/manual", manual(40))
+
+	compare := `{"proposed_cell_memory_gb": 64, "proposed_cell_cpu": 8, "proposed_cell_count": 24,
+		"host_count": 4, "memory_per_host_gb": 1024, "physical_cores_per_host": 32}`
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			call(h.SetManualInfrastructure, http.MethodPost, "/api/v1/infrastructure/manual", manual(40+i))
+			call(h.CompareScenario, http.MethodPost, "/api/v1/scenario/compare?explain=true", compare)
+			call(h.GetInfrastructureStatus, http.MethodGet, "/api/v1/infrastructure/status", "")
+			call(h.AnalyzeBottleneck, http.MethodGet, "/api/v1/bottleneck", "")
+			call(h.PlanInfrastructure, http.MethodPost, "/api/v1/infrastructure/planning", `{"cell_memory_gb": 32, "cell_cpu": 4}`)
+			if state := h.CurrentInfrastructureState(); state != nil {
+				call(h.SetInfrastructureState, http.MethodPost, "/api/v1/infrastructure/state", mustJSON(t, state))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if state := h.CurrentInfrastructureState(); state == nil || state.TotalCellCount < 40 {
+		t.Errorf("Expected a stored state from one of the updates, got %+v", state)
+	}
+}
+
+func TestSetInfrastructureFromCF_KeepsConcurrentUpdate(t *testing.T) {
+	c := cache.New(5 * time.Minute)
+	h := NewHandler(&config.Config{}, c)
+
+	// A manual update lands while the CF API is still being queried
+	cfServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v3/apps":
+			h.storeInfrastructureState(&models.InfrastructureState{Name: "updated", TotalCellCount: 50})
+			w.Write([]byte(`{"resources":[{"guid":"app-1"}],"pagination":{"next":null}}`))
+		case "/v3/processes":
+			w.Write([]byte(`{"resources":[{"instances":4,"memory_in_mb":1024,"disk_in_mb":2048,"relationships":{"app":{"data":{"guid":"app-1"}}}}],"pagination":{"next":null}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer cfServer.Close()
+	h.cfg.CFAPIUrl = cfServer.URL
+
+	sessionSvc := services.NewSessionService(services.NewCacheSessionStore(c))
+	h.SetSessionService(sessionSvc)
+	sessionID, _ := sessionSvc.Create("testuser", "user-123", "token", "refresh", nil, time.Now().Add(time.Hour))
+
+	h.storeInfrastructureState(&models.InfrastructureState{Name: "original", TotalCellCount: 10})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/infrastructure/from-cf", nil)
+	req.AddCookie(&http.Cookie{Name: "DIEGO_SESSION", Value: sessionID})
+	rr := httptest.NewRecorder()
+	h.SetInfrastructureFromCF(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	stored := h.CurrentInfrastructureState()
+	if stored.Name != "updated" || stored.TotalCellCount != 50 {
+		t.Errorf("Expected the concurrent update to be kept, got name=%q cells=%d", stored.Name, stored.TotalCellCount)
+	}
+	if stored.TotalAppMemoryGB != 4 || stored.TotalAppInstances != 4 {
+		t.Errorf("Expected CF app totals applied to the updated state, got memory=%d instances=%d",
+			stored.TotalAppMemoryGB, stored.TotalAppInstances)
+	}
+}
+
+func mustJSON(t *testing.T, v any) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	return string(data)
+}
@@ -0,0 +1,47 @@
+// ABOUTME: HTTP handler exposing the backend's effective configuration
+// ABOUTME: Reports non-secret settings only, for troubleshooting deployments
+
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/markalston/diego-capacity-analyzer/backend/models"
+	"github.com/markalston/diego-capacity-analyzer/backend/version"
+)
+
+// Config returns the non-secret parts of the loaded configuration: auth mode,
+// CORS origins, cache TTLs, and which integrations are configured. Credentials,
+// hostnames, and usernames are never included.
+func (h *Handler) Config(w http.ResponseWriter, r *http.Request) {
+	resp := models.ConfigResponse{
+		Version:            version.Version,
+		CORSAllowedOrigins: []string{},
+		Configured: map[string]bool{
+			"cf":      false,
+			"bosh":    false,
+			"vsphere": false,
+			"credhub": false,
+			"ai":      false,
+		},
+	}
+
+	if cfg := h.cfg; cfg != nil {
+		resp.AuthMode = cfg.AuthMode
+		if len(cfg.CORSAllowedOrigins) > 0 {
+			resp.CORSAllowedOrigins = cfg.CORSAllowedOrigins
+		}
+		resp.CacheTTLSeconds = cfg.CacheTTL
+		resp.DashboardTTLSeconds = cfg.DashboardTTL
+		resp.VSphereCacheTTLSeconds = cfg.VSphereCacheTTL
+		resp.StatePersisted = cfg.StateFile != ""
+		resp.RateLimitEnabled = cfg.RateLimitEnabled
+		resp.Configured["cf"] = cfg.CFAPIUrl != ""
+		resp.Configured["bosh"] = cfg.BOSHEnvironment != ""
+		resp.Configured["vsphere"] = cfg.VSphereConfigured()
+		resp.Configured["credhub"] = cfg.CredHubURL != ""
+		resp.Configured["ai"] = cfg.AIConfigured()
+	}
+
+	h.writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,98 @@
+// ABOUTME: Tests for the effective configuration endpoint
+// ABOUTME: Verifies reported settings and that no secrets appear in the response
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/markalston/diego-capacity-analyzer/backend/cache"
+	"github.com/markalston/diego-capacity-analyzer/backend/config"
+	"github.com/markalston/diego-capacity-analyzer/backend/models"
+)
+
+func TestConfigHandler(t *testing.T) {
+	cfg := &config.Config{
+		AuthMode:           "required",
+		CORSAllowedOrigins: []string{"https://ui.example.com"},
+		CacheTTL:           300,
+		DashboardTTL:       30,
+		VSphereCacheTTL:    600,
+		StateFile:          "/var/lib/analyzer/state.json",
+		RateLimitEnabled:   true,
+		CFAPIUrl:           "https://api.sys.example.com",
+		CFUsername:         "admin",
+		CFPassword:         "cf-super-secret",
+		VSphereHost:        "vcenter.example.com",
+		VSphereUsername:    "administrator@vsphere.local",
+		VSpherePassword:    "vsphere-super-secret",
+		VSphereDatacenter:  "dc1",
+		AIProvider:         "anthropic",
+		AIAPIKey:           "sk-ai-super-secret",
+	}
+	h := NewHandler(cfg, cache.New(5*time.Minute))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/config", nil)
+	w := httptest.NewRecorder()
+	h.Config(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	body := w.Body.String()
+	for _, secret := range []string{"cf-super-secret", "vsphere-super-secret", "sk-ai-super-secret", "admin", "vcenter.example.com", "state.json"} {
+		if strings.Contains(body, secret) {
+			t.Errorf("Response leaks %q: %s", secret, body)
+		}
+	}
+
+	var resp models.ConfigResponse
+	if err := json.Unmarshal([]byte(body), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.AuthMode != "required" {
+		t.Errorf("AuthMode = %q, want required", resp.AuthMode)
+	}
+	if len(resp.CORSAllowedOrigins) != 1 || resp.CORSAllowedOrigins[0] != "https://ui.example.com" {
+		t.Errorf("CORSAllowedOrigins = %v", resp.CORSAllowedOrigins)
+	}
+	if resp.CacheTTLSeconds != 300 || resp.DashboardTTLSeconds != 30 || resp.VSphereCacheTTLSeconds != 600 {
+		t.Errorf("TTLs = %d/%d/%d, want 300/30/600", resp.CacheTTLSeconds, resp.DashboardTTLSeconds, resp.VSphereCacheTTLSeconds)
+	}
+	if !resp.StatePersisted || !resp.RateLimitEnabled {
+		t.Errorf("StatePersisted = %v, RateLimitEnabled = %v, want both true", resp.StatePersisted, resp.RateLimitEnabled)
+	}
+
+	wantConfigured := map[string]bool{"cf": true, "bosh": false, "vsphere": true, "credhub": false, "ai": true}
+	for name, want := range wantConfigured {
+		if got := resp.Configured[name]; got != want {
+			t.Errorf("Configured[%s] = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestConfigHandler_NilConfig(t *testing.T) {
+	h := NewHandler(nil, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/config", nil)
+	w := httptest.NewRecorder()
+	h.Config(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var resp map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if origins, ok := resp["cors_allowed_origins"].([]interface{}); !ok || len(origins) != 0 {
+		t.Errorf("cors_allowed_origins = %v, want empty array", resp["cors_allowed_origins"])
+	}
+}
@@ -0,0 +1,78 @@
+// ABOUTME: SSE streaming of vSphere discovery progress
+// ABOUTME: Emits progress events while discovery runs, then the discovered state or an error
+
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/markalston/diego-capacity-analyzer/backend/services"
+)
+
+// DiscoverInfrastructureStream handles GET /api/v1/infrastructure/discover/stream.
+// Clients that accept text/event-stream receive "progress" events as discovery
+// proceeds, then a "done" event carrying the InfrastructureState or an "error"
+// event. Other clients get the synchronous GET /api/v1/infrastructure response.
+// HTTP method validation handled by Go 1.22+ router pattern matching.
+func (h *Handler) DiscoverInfrastructureStream(w http.ResponseWriter, r *http.Request) {
+	if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		h.GetInfrastructure(w, r)
+		return
+	}
+
+	if h.vsphereClient == nil {
+		h.writeError(w, vsphereNotConfiguredMessage, http.StatusServiceUnavailable)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		h.writeError(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	// Discovery can outlast the server's write timeout
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		slog.WarnContext(r.Context(), "failed to clear write deadline", "error", err)
+	}
+
+	if state, ok := h.cachedVSphereState(r); ok {
+		if err := writeSSEEvent(w, flusher, "done", state); err != nil {
+			slog.WarnContext(r.Context(), "failed to write SSE done event", "error", err)
+		}
+		return
+	}
+
+	progress := func(p services.DiscoveryProgress) {
+		if err := writeSSEEvent(w, flusher, "progress", p); err != nil {
+			slog.DebugContext(r.Context(), "failed to write SSE progress event", "error", err)
+		}
+	}
+
+	state, err := h.discoverVSphere(r.Context(), progress)
+	if err != nil {
+		payload := ErrorPayload{Code: "discovery_failed", Message: "Failed to retrieve infrastructure data"}
+		var derr *discoveryError
+		if errors.As(err, &derr) {
+			payload.Message = derr.message
+		}
+		if err := writeSSEEvent(w, flusher, "error", payload); err != nil {
+			slog.WarnContext(r.Context(), "failed to write SSE error event", "error", err)
+		}
+		return
+	}
+
+	if err := writeSSEEvent(w, flusher, "done", state); err != nil {
+		slog.WarnContext(r.Context(), "failed to write SSE done event", "error", err)
+	}
+}
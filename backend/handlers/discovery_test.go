@@ -0,0 +1,237 @@
+// ABOUTME: Tests for the SSE vSphere discovery stream
+// ABOUTME: Covers progress ordering, cache hits, errors, and the synchronous fallback
+
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/markalston/diego-capacity-analyzer/backend/cache"
+	"github.com/markalston/diego-capacity-analyzer/backend/config"
+	"github.com/markalston/diego-capacity-analyzer/backend/models"
+	"github.com/markalston/diego-capacity-analyzer/backend/services"
+	"github.com/vmware/govmomi/simulator"
+)
+
+// newDiscoveryTestHandler returns a handler whose vSphere client points at a
+// fresh vcsim inventory, along with its cache
+func newDiscoveryTestHandler(t *testing.T) (*Handler, *cache.Cache) {
+	t.Helper()
+
+	model := simulator.VPX()
+	if err := model.Create(); err != nil {
+		t.Fatalf("Failed to create vSphere simulator: %v", err)
+	}
+	server := model.Service.NewServer()
+	t.Cleanup(func() {
+		server.Close()
+		model.Remove()
+	})
+
+	password, _ := server.URL.User.Password()
+	cfg := &config.Config{
+		VSphereHost:       server.URL.Scheme + "://" + server.URL.Host,
+		VSphereUsername:   server.URL.User.Username(),
+		VSpherePassword:   password,
+		VSphereDatacenter: "DC0",
+		VSphereCacheTTL:   300,
+		// vcsim names its VMs DC0_H0_VM0, DC0_C0_RP0_VM0, ...; treat them all as cells
+		VSphereCellNamePatterns: []string{`_VM\d+$`},
+	}
+	c := cache.New(5 * time.Minute)
+	return NewHandler(cfg, c), c
+}
+
+// streamDiscovery requests the discovery stream and returns its events
+func streamDiscovery(t *testing.T, h *Handler, target string) []sseEvent {
+	t.Helper()
+
+	ts := httptest.NewServer(http.HandlerFunc(h.DiscoverInfrastructureStream))
+	defer ts.Close()
+
+	req, _ := http.NewRequest("GET", ts.URL+target, nil)
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("expected Content-Type 'text/event-stream', got %q", ct)
+	}
+	return parseSSEEvents(t, bufio.NewReader(resp.Body))
+}
+
+func TestDiscoverInfrastructureStream_ProgressThenDone(t *testing.T) {
+	h, c := newDiscoveryTestHandler(t)
+
+	events := streamDiscovery(t, h, "/api/v1/infrastructure/discover/stream")
+
+	wantStages := []string{
+		services.StageConnecting,
+		services.StageScanningVMs,
+		services.StageCellsFound,
+		services.StageClustersFound,
+		services.StageEnriching,
+	}
+	if len(events) != len(wantStages)+1 {
+		t.Fatalf("expected %d events, got %d: %+v", len(wantStages)+1, len(events), events)
+	}
+	for i, stage := range wantStages {
+		if events[i].eventType != "progress" {
+			t.Fatalf("event %d: expected type 'progress', got %q", i, events[i].eventType)
+		}
+		var p services.DiscoveryProgress
+		if err := json.Unmarshal([]byte(events[i].data), &p); err != nil {
+			t.Fatalf("event %d: invalid progress data: %v", i, err)
+		}
+		if p.Stage != stage || p.Message == "" {
+			t.Errorf("event %d: got stage %q message %q, want stage %q", i, p.Stage, p.Message, stage)
+		}
+	}
+
+	last := events[len(events)-1]
+	if last.eventType != "done" {
+		t.Fatalf("last event: expected type 'done', got %q", last.eventType)
+	}
+	var state models.InfrastructureState
+	if err := json.Unmarshal([]byte(last.data), &state); err != nil {
+		t.Fatalf("invalid done data: %v", err)
+	}
+	if state.Name != "DC0" || state.Cached {
+		t.Errorf("expected fresh discovery of DC0, got name=%q cached=%v", state.Name, state.Cached)
+	}
+
+	// Streamed discovery caches like the synchronous endpoint
+	if _, found := c.Get(vsphereInfrastructureCacheKey); !found {
+		t.Error("expected streamed discovery to be cached")
+	}
+}
+
+func TestDiscoverInfrastructureStream_CacheHit(t *testing.T) {
+	h, c := newDiscoveryTestHandler(t)
+	c.Set(vsphereInfrastructureCacheKey, models.InfrastructureState{Name: "cached-discovery"})
+
+	events := streamDiscovery(t, h, "/api/v1/infrastructure/discover/stream")
+	if len(events) != 1 || events[0].eventType != "done" {
+		t.Fatalf("expected a single done event, got %+v", events)
+	}
+	var state models.InfrastructureState
+	if err := json.Unmarshal([]byte(events[0].data), &state); err != nil {
+		t.Fatalf("invalid done data: %v", err)
+	}
+	if state.Name != "cached-discovery" || !state.Cached {
+		t.Errorf("expected cached state, got name=%q cached=%v", state.Name, state.Cached)
+	}
+
+	events = streamDiscovery(t, h, "/api/v1/infrastructure/discover/stream?force=true")
+	if len(events) < 2 || events[0].eventType != "progress" {
+		t.Errorf("expected force=true to run discovery, got %+v", events)
+	}
+}
+
+func TestDiscoverInfrastructureStream_ConnectionError(t *testing.T) {
+	cfg := &config.Config{
+		VSphereHost:       "http://127.0.0.1:1",
+		VSphereUsername:   "admin",
+		VSpherePassword:   "secret",
+		VSphereDatacenter: "DC0",
+	}
+	h := NewHandler(cfg, cache.New(5*time.Minute))
+
+	events := streamDiscovery(t, h, "/api/v1/infrastructure/discover/stream")
+	if len(events) != 2 {
+		t.Fatalf("expected connecting and error events, got %+v", events)
+	}
+	if events[1].eventType != "error" {
+		t.Fatalf("expected type 'error', got %q", events[1].eventType)
+	}
+	var payload ErrorPayload
+	if err := json.Unmarshal([]byte(events[1].data), &payload); err != nil {
+		t.Fatalf("invalid error data: %v", err)
+	}
+	if payload.Code != "discovery_failed" || payload.Message != "Infrastructure service temporarily unavailable" {
+		t.Errorf("unexpected error payload: %+v", payload)
+	}
+}
+
+func TestDiscoverInfrastructureStream_FallsBackWithoutEventStream(t *testing.T) {
+	h, c := newDiscoveryTestHandler(t)
+	c.Set(vsphereInfrastructureCacheKey, models.InfrastructureState{Name: "cached-discovery"})
+
+	w := httptest.NewRecorder()
+	h.DiscoverInfrastructureStream(w, httptest.NewRequest("GET", "/api/v1/infrastructure/discover/stream", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected JSON response, got Content-Type %q", ct)
+	}
+	var state models.InfrastructureState
+	if err := json.NewDecoder(w.Body).Decode(&state); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if state.Name != "cached-discovery" {
+		t.Errorf("expected the synchronous cached response, got name=%q", state.Name)
+	}
+}
+
+func TestDiscoverInfrastructureStream_VSphereNotConfigured(t *testing.T) {
+	h := NewHandler(&config.Config{}, cache.New(5*time.Minute))
+
+	req := httptest.NewRequest("GET", "/api/v1/infrastructure/discover/stream", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	w := httptest.NewRecorder()
+	h.DiscoverInfrastructureStream(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503, got %d", w.Code)
+	}
+}
+
+func TestDiscoverInfrastructure_NoDiegoCells(t *testing.T) {
+	h, c := newDiscoveryTestHandler(t)
+	if err := h.vsphereClient.SetCellDetection([]string{`^no_such_cell$`}, ""); err != nil {
+		t.Fatalf("SetCellDetection failed: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	h.GetInfrastructure(w, httptest.NewRequest("GET", "/api/v1/infrastructure", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected status 500, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp models.ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if !strings.Contains(resp.Error, "no Diego cells discovered; check VSPHERE_CELL_NAME_PATTERNS and datacenter") {
+		t.Errorf("Expected the no-cells error, got %q", resp.Error)
+	}
+	if _, found := c.Get(vsphereInfrastructureCacheKey); found {
+		t.Error("Expected an empty discovery not to be cached")
+	}
+	if h.CurrentInfrastructureState() != nil {
+		t.Error("Expected an empty discovery not to be stored")
+	}
+
+	events := streamDiscovery(t, h, "/api/v1/infrastructure/discover/stream")
+	last := events[len(events)-1]
+	if last.eventType != "error" {
+		t.Fatalf("Expected the stream to end with an error event, got %+v", events)
+	}
+	var payload ErrorPayload
+	if err := json.Unmarshal([]byte(last.data), &payload); err != nil {
+		t.Fatalf("invalid error data: %v", err)
+	}
+	if !strings.Contains(payload.Message, "no Diego cells discovered") {
+		t.Errorf("Expected the no-cells error in the stream, got %+v", payload)
+	}
+}
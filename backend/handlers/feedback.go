@@ -58,7 +58,7 @@ func (h *Handler) ChatFeedback(w http.ResponseWriter, r *http.Request) {
 		question = string(runes[:maxQuestionLength])
 	}
 
-	slog.Info("chat feedback",
+	slog.InfoContext(r.Context(), "chat feedback",
 		"username", claims.Username,
 		"rating", req.Rating,
 		"message_index", req.MessageIndex,
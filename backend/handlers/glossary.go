@@ -0,0 +1,17 @@
+// ABOUTME: Handler serving definitions of the computed capacity metrics
+// ABOUTME: Lets clients explain each metric and its formula from one shared source
+
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/markalston/diego-capacity-analyzer/backend/models"
+)
+
+// MetricGlossary returns the name, meaning, and formula of each metric the
+// analyzer computes. Public so help screens can load it before login.
+// HTTP method validation handled by Go 1.22+ router pattern matching.
+func (h *Handler) MetricGlossary(w http.ResponseWriter, r *http.Request) {
+	h.writeJSON(w, http.StatusOK, models.MetricGlossaryResponse{Metrics: models.MetricGlossary()})
+}
@@ -0,0 +1,38 @@
+// ABOUTME: Tests for the metric glossary endpoint
+// ABOUTME: Verifies the endpoint serves the shared metric definitions
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/markalston/diego-capacity-analyzer/backend/models"
+)
+
+func TestMetricGlossaryHandler(t *testing.T) {
+	h := NewHandler(nil, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/metrics/glossary", nil)
+	w := httptest.NewRecorder()
+	h.MetricGlossary(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var resp models.MetricGlossaryResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	want := models.MetricGlossary()
+	if len(resp.Metrics) != len(want) {
+		t.Fatalf("Expected %d metrics, got %d", len(want), len(resp.Metrics))
+	}
+	for i, m := range resp.Metrics {
+		if m != want[i] {
+			t.Errorf("Metric %d: expected %+v, got %+v", i, want[i], m)
+		}
+	}
+}
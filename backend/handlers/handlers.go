@@ -4,13 +4,21 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/markalston/diego-capacity-analyzer/backend/audit"
 	"github.com/markalston/diego-capacity-analyzer/backend/cache"
 	"github.com/markalston/diego-capacity-analyzer/backend/config"
+	"github.com/markalston/diego-capacity-analyzer/backend/middleware"
 	"github.com/markalston/diego-capacity-analyzer/backend/models"
 	"github.com/markalston/diego-capacity-analyzer/backend/services"
 	"github.com/markalston/diego-capacity-analyzer/backend/services/ai"
@@ -22,10 +30,14 @@ type Handler struct {
 	cfClient            *services.CFClient
 	boshClient          *services.BOSHClient
 	vsphereClient       *services.VSphereClient
+	prometheusClient    *services.PrometheusClient
 	infrastructureState *models.InfrastructureState
 	scenarioCalc        *services.ScenarioCalculator
 	planningCalc        *services.PlanningCalculator
+	baselines           *services.BaselineStore
 	sessionService      *services.SessionService
+	loginGuard          *services.LoginGuard
+	auditLog            *audit.Logger // nil records audit events to stdout
 	chatProvider        ai.ChatProvider
 	infraMutex          sync.RWMutex
 	userScenarios       map[string]*models.ScenarioComparison
@@ -41,6 +53,19 @@ func NewHandler(cfg *config.Config, cache *cache.Cache) *Handler {
 		userScenarios: make(map[string]*models.ScenarioComparison),
 	}
 
+	var baselinesPath string
+	if cfg != nil {
+		baselinesPath = baselinesFilePath(cfg.StateFile)
+	}
+	h.baselines = services.NewBaselineStore(cache, baselinesPath)
+	h.scenarioCalc.SetThresholds(h.thresholds())
+
+	// Login lockout needs a window; configs built without Load leave it zero
+	if cfg != nil && cfg.LoginLockoutSecs > 0 {
+		h.loginGuard = services.NewLoginGuard(cache, cfg.LoginMaxFailures, cfg.LoginMaxFailuresPerIP,
+			time.Duration(cfg.LoginLockoutSecs)*time.Second)
+	}
+
 	// CF client is optional (for testing)
 	if cfg != nil {
 		h.cfClient = services.NewCFClient(cfg.CFAPIUrl, cfg.CFUsername, cfg.CFPassword, cfg.CFSkipSSLValidation)
@@ -58,18 +83,43 @@ func NewHandler(cfg *config.Config, cache *cache.Cache) *Handler {
 			if err != nil {
 				slog.Error("Failed to create BOSH client, running in degraded mode", "error", err)
 			} else {
+				boshClient.SetDeploymentFilters(cfg.BOSHDeploymentInclude, cfg.BOSHDeploymentExclude)
+				boshClient.SetMaxConcurrency(cfg.BOSHMaxConcurrency)
+				boshClient.SetTaskTimeout(time.Duration(cfg.BOSHTaskTimeout) * time.Second)
+				boshClient.SetSegmentMap(cfg.BOSHSegmentMap)
+				boshClient.SetCellJobNames(cfg.BOSHCellJobNames)
 				h.boshClient = boshClient
 			}
 		}
 
 		// vSphere client is optional
 		if cfg.VSphereConfigured() {
-			h.vsphereClient = services.VSphereClientFromEnv(
+			vsphereClient, err := services.VSphereClientFromEnv(
 				cfg.VSphereHost,
 				cfg.VSphereUsername,
 				cfg.VSpherePassword,
 				cfg.VSphereDatacenter,
+				cfg.VSphereCACert,
+				cfg.VSphereInsecure,
 			)
+			if err != nil {
+				slog.Error("Failed to create vSphere client, running without vSphere", "error", err)
+			} else if err := vsphereClient.SetCellDetection(cfg.VSphereCellNamePatterns, cfg.VSphereCellCustomAttr); err != nil {
+				slog.Error("Invalid vSphere cell detection config, running without vSphere", "error", err)
+			} else {
+				h.vsphereClient = vsphereClient
+			}
+		}
+
+		// Prometheus client is optional
+		if cfg.PrometheusURL != "" {
+			h.prometheusClient = services.NewPrometheusClient(cfg.PrometheusURL,
+				time.Duration(cfg.PrometheusQueryTimeout)*time.Second, cfg.PrometheusSkipSSLValidation)
+			if cfg.PrometheusBearerToken != "" {
+				h.prometheusClient.SetBearerToken(cfg.PrometheusBearerToken)
+			} else if cfg.PrometheusUsername != "" {
+				h.prometheusClient.SetBasicAuth(cfg.PrometheusUsername, cfg.PrometheusPassword)
+			}
 		}
 	}
 
@@ -102,12 +152,201 @@ func (h *Handler) writeErrorWithDetails(w http.ResponseWriter, message, details
 	})
 }
 
-// SetSessionService sets the session service for auth handlers
+// writeValidationErrors writes a 400 response listing each invalid field.
+func (h *Handler) writeValidationErrors(w http.ResponseWriter, errs []models.FieldError) {
+	h.writeJSON(w, http.StatusBadRequest, models.ErrorResponse{
+		Error:  "Invalid infrastructure input",
+		Code:   http.StatusBadRequest,
+		Errors: errs,
+	})
+}
+
+// SetSessionService sets the session service for auth handlers and wires it
+// to renew tokens through CF UAA
 func (h *Handler) SetSessionService(svc *services.SessionService) {
 	h.sessionService = svc
+	if svc != nil {
+		svc.SetTokenRefresher(h.refreshSessionTokens)
+	}
+}
+
+// CurrentInfrastructureState returns a copy of the latest stored infrastructure
+// state, or nil if none has been loaded. It never triggers a data source refresh.
+func (h *Handler) CurrentInfrastructureState() *models.InfrastructureState {
+	h.infraMutex.RLock()
+	defer h.infraMutex.RUnlock()
+
+	if h.infrastructureState == nil {
+		return nil
+	}
+	state := *h.infrastructureState
+	return &state
+}
+
+// infrastructureStateKey is the cache key used when persisting infrastructure state
+const infrastructureStateKey = "infrastructure:state"
+
+// vsphereInfrastructureCacheKey caches the last successful vSphere discovery for VSPHERE_CACHE_TTL
+const vsphereInfrastructureCacheKey = "infrastructure:vsphere"
+
+// storeInfrastructureState replaces the current infrastructure state and, when
+// STATE_FILE is configured, writes it to disk so it survives restarts.
+// Persistence failures are logged; the in-memory state is always updated.
+// CPU risk is reclassified against the configured vCPU ratio tiers.
+func (h *Handler) storeInfrastructureState(state *models.InfrastructureState) {
+	h.infraMutex.Lock()
+	defer h.infraMutex.Unlock()
+
+	h.storeInfrastructureStateLocked(state)
+}
+
+// updateInfrastructureState applies update to a copy of the current state and
+// stores the result like storeInfrastructureState. The lock is held
+// throughout, so a state stored by another request in the meantime is
+// updated rather than overwritten. Returns the stored state, or nil when none
+// is loaded.
+func (h *Handler) updateInfrastructureState(update func(state *models.InfrastructureState)) *models.InfrastructureState {
+	h.infraMutex.Lock()
+	defer h.infraMutex.Unlock()
+
+	if h.infrastructureState == nil {
+		return nil
+	}
+	state := *h.infrastructureState
+	update(&state)
+	h.storeInfrastructureStateLocked(&state)
+	return &state
+}
+
+// storeInfrastructureStateLocked stores and persists state; the caller holds
+// infraMutex for writing
+func (h *Handler) storeInfrastructureStateLocked(state *models.InfrastructureState) {
+	state.CPURiskLevel = h.thresholds().CPURiskLevel(state.VCPURatio)
+	h.infrastructureState = state
+
+	if h.cfg == nil || h.cfg.StateFile == "" || h.cache == nil {
+		return
+	}
+	if err := h.cache.SetPersistent(infrastructureStateKey, *state, h.cfg.StateFile); err != nil {
+		slog.Warn("Failed to persist infrastructure state", "path", h.cfg.StateFile, "error", err)
+	}
+}
+
+// thresholds returns the capacity thresholds in effect: the THRESHOLDS
+// overrides resolved over the defaults
+func (h *Handler) thresholds() models.Thresholds {
+	if h.cfg == nil {
+		return models.DefaultThresholds()
+	}
+	return h.cfg.Thresholds.Resolve()
+}
+
+// cellJobNames returns the BOSH_CELL_JOB_NAMES instance groups, or nil for
+// the built-in cell job matching
+func (h *Handler) cellJobNames() []string {
+	if h.cfg == nil {
+		return nil
+	}
+	return h.cfg.BOSHCellJobNames
+}
+
+// clearInfrastructureState drops the current infrastructure state, its cached
+// vSphere discovery and CF app totals, and the STATE_FILE copy, so the next fetch re-discovers.
+// A STATE_FILE that cannot be removed is logged.
+func (h *Handler) clearInfrastructureState() {
+	h.infraMutex.Lock()
+	defer h.infraMutex.Unlock()
+
+	h.infrastructureState = nil
+	if h.cache != nil {
+		h.cache.Clear(vsphereInfrastructureCacheKey)
+		h.cache.Clear(appDataCacheKey)
+		h.cache.Clear(infrastructureStateKey)
+	}
+
+	if h.cfg == nil || h.cfg.StateFile == "" {
+		return
+	}
+	if err := os.Remove(h.cfg.StateFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.Warn("Failed to remove persisted infrastructure state", "path", h.cfg.StateFile, "error", err)
+	}
+}
+
+// RestoreInfrastructureState loads infrastructure state persisted at STATE_FILE.
+// A missing or unparseable file is logged and ignored so the backend starts empty.
+func (h *Handler) RestoreInfrastructureState() {
+	if h.cfg == nil || h.cfg.StateFile == "" || h.cache == nil {
+		return
+	}
+
+	var state models.InfrastructureState
+	if err := h.cache.LoadPersistent(infrastructureStateKey, h.cfg.StateFile, &state); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			slog.Warn("No persisted infrastructure state found, starting empty", "path", h.cfg.StateFile)
+		} else {
+			slog.Warn("Ignoring unreadable persisted infrastructure state", "path", h.cfg.StateFile, "error", err)
+		}
+		return
+	}
+
+	h.infraMutex.Lock()
+	h.infrastructureState = &state
+	h.infraMutex.Unlock()
+
+	slog.Info("Restored persisted infrastructure state",
+		"path", h.cfg.StateFile,
+		"name", state.Name,
+		"source", state.Source,
+		"clusters", len(state.Clusters))
+}
+
+// baselinesFilePath returns where scenario baselines are persisted, next to
+// STATE_FILE (state.json -> state-baselines.json), or "" when STATE_FILE is unset
+func baselinesFilePath(stateFile string) string {
+	if stateFile == "" {
+		return ""
+	}
+	return strings.TrimSuffix(stateFile, filepath.Ext(stateFile)) + "-baselines.json"
+}
+
+// RestoreScenarioBaselines loads scenario baselines persisted next to STATE_FILE.
+// A missing or unparseable file is logged and ignored.
+func (h *Handler) RestoreScenarioBaselines() {
+	if err := h.baselines.Restore(); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			slog.Debug("No persisted scenario baselines found", "error", err)
+		} else {
+			slog.Warn("Ignoring unreadable persisted scenario baselines", "error", err)
+		}
+	}
+}
+
+// SetAuditLogger sets where audit events are written; nil keeps them on stdout
+func (h *Handler) SetAuditLogger(l *audit.Logger) {
+	h.auditLog = l
+}
+
+// recordAudit writes e to the audit log, with the authenticated caller of r
+// as its username unless e names one
+func (h *Handler) recordAudit(r *http.Request, e audit.Event) {
+	if e.Username == "" {
+		if claims := middleware.GetUserClaims(r); claims != nil {
+			e.Username = claims.Username
+		}
+	}
+	h.auditLog.Record(r.Context(), e)
 }
 
 // SetChatProvider sets the AI chat provider for advisor endpoints
 func (h *Handler) SetChatProvider(p ai.ChatProvider) {
 	h.chatProvider = p
 }
+
+// Close releases long-lived backend connections during shutdown, logging out
+// any vCenter session left open so it does not linger on the server.
+func (h *Handler) Close(ctx context.Context) error {
+	if h.vsphereClient == nil || !h.vsphereClient.IsConnected() {
+		return nil
+	}
+	return h.vsphereClient.Disconnect(ctx)
+}
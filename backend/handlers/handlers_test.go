@@ -6,6 +6,8 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -15,6 +17,8 @@ import (
 	"github.com/markalston/diego-capacity-analyzer/backend/models"
 	"github.com/markalston/diego-capacity-analyzer/backend/services"
 	"github.com/markalston/diego-capacity-analyzer/backend/services/ai"
+	"github.com/markalston/diego-capacity-analyzer/backend/version"
+	"github.com/vmware/govmomi/simulator"
 )
 
 // stubChatProvider satisfies ai.ChatProvider for health endpoint tests.
@@ -287,6 +291,26 @@ func TestHealthHandler(t *testing.T) {
 	}
 }
 
+func TestHealthHandler_Version(t *testing.T) {
+	orig := version.Version
+	version.Version = "v1.2.3"
+	defer func() { version.Version = orig }()
+
+	h := NewHandler(&config.Config{}, cache.New(5*time.Minute))
+	req := httptest.NewRequest("GET", "/api/v1/health", nil)
+	w := httptest.NewRecorder()
+
+	h.Health(w, req)
+
+	var resp map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp["version"] != "v1.2.3" {
+		t.Errorf("Expected version v1.2.3, got %v", resp["version"])
+	}
+}
+
 func TestHealthHandler_WithBOSH(t *testing.T) {
 	cfg := &config.Config{
 		CFAPIUrl:        "https://api.test.com",
@@ -634,6 +658,133 @@ func TestHealthHandler_DataSources(t *testing.T) {
 	}
 }
 
+func TestReadyHandler(t *testing.T) {
+	okServer := func(path string) *httptest.Server {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != path {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{}`))
+		}))
+		t.Cleanup(server.Close)
+		return server
+	}
+	failingServer := func() *httptest.Server {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		t.Cleanup(server.Close)
+		return server
+	}
+	vcsim := func() *config.Config {
+		model := simulator.VPX()
+		if err := model.Create(); err != nil {
+			t.Fatalf("Failed to create vSphere simulator: %v", err)
+		}
+		server := model.Service.NewServer()
+		t.Cleanup(func() {
+			server.Close()
+			model.Remove()
+		})
+		password, _ := server.URL.User.Password()
+		return &config.Config{
+			VSphereHost:       server.URL.Scheme + "://" + server.URL.Host,
+			VSphereUsername:   server.URL.User.Username(),
+			VSpherePassword:   password,
+			VSphereDatacenter: "DC0",
+		}
+	}
+
+	tests := []struct {
+		name         string
+		config       func() *config.Config
+		expectedCode int
+		expected     map[string]string
+	}{
+		{
+			name: "all dependencies reachable",
+			config: func() *config.Config {
+				cfg := vcsim()
+				cfg.CFAPIUrl = okServer("/v3/info").URL
+				cfg.BOSHEnvironment = okServer("/info").URL
+				return cfg
+			},
+			expectedCode: http.StatusOK,
+			expected:     map[string]string{"cf_api": "ok", "bosh_api": "ok", "vsphere": "ok"},
+		},
+		{
+			name: "BOSH and vSphere not configured",
+			config: func() *config.Config {
+				return &config.Config{CFAPIUrl: okServer("/v3/info").URL}
+			},
+			expectedCode: http.StatusOK,
+			expected:     map[string]string{"cf_api": "ok", "bosh_api": "not_configured", "vsphere": "not_configured"},
+		},
+		{
+			name: "CF API unhealthy",
+			config: func() *config.Config {
+				return &config.Config{
+					CFAPIUrl:        failingServer().URL,
+					BOSHEnvironment: okServer("/info").URL,
+				}
+			},
+			expectedCode: http.StatusServiceUnavailable,
+			expected:     map[string]string{"cf_api": "error", "bosh_api": "ok", "vsphere": "not_configured"},
+		},
+		{
+			name: "vCenter unreachable",
+			config: func() *config.Config {
+				return &config.Config{
+					CFAPIUrl:          okServer("/v3/info").URL,
+					VSphereHost:       "http://127.0.0.1:1",
+					VSphereUsername:   "admin",
+					VSpherePassword:   "secret",
+					VSphereDatacenter: "DC0",
+				}
+			},
+			expectedCode: http.StatusServiceUnavailable,
+			expected:     map[string]string{"cf_api": "ok", "bosh_api": "not_configured", "vsphere": "error"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandler(tt.config(), cache.New(5*time.Minute))
+
+			w := httptest.NewRecorder()
+			h.Ready(w, httptest.NewRequest("GET", "/api/v1/health/ready", nil))
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedCode, w.Code, w.Body.String())
+			}
+
+			var resp models.ReadinessResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+
+			wantStatus := "ready"
+			if tt.expectedCode != http.StatusOK {
+				wantStatus = "not_ready"
+			}
+			if resp.Status != wantStatus {
+				t.Errorf("status: got %q, want %q", resp.Status, wantStatus)
+			}
+			for name, want := range tt.expected {
+				dep := resp.Dependencies[name]
+				if dep.Status != want {
+					t.Errorf("%s: got status %q, want %q", name, dep.Status, want)
+				}
+				if want == "error" && dep.Error == "" {
+					t.Errorf("%s: expected an error message", name)
+				}
+			}
+		})
+	}
+}
+
 func TestDashboardHandler_NoBOSH(t *testing.T) {
 	cfServer, uaaServer := setupMockCFServer()
 	defer cfServer.Close()
@@ -724,6 +875,36 @@ func TestDashboardHandler_Cache(t *testing.T) {
 	}
 }
 
+func TestDashboardHandler_Redact(t *testing.T) {
+	cfServer, uaaServer := setupMockCFServer()
+	defer cfServer.Close()
+	defer uaaServer.Close()
+
+	cfg := &config.Config{
+		CFAPIUrl:     cfServer.URL,
+		CFUsername:   "admin",
+		CFPassword:   "secret",
+		DashboardTTL: 30,
+	}
+	h := NewHandler(cfg, cache.New(5*time.Minute))
+
+	// The second request is served from the cache, which must stay unredacted
+	for _, path := range []string{"/api/dashboard?redact=true", "/api/dashboard", "/api/dashboard?redact=true"} {
+		w := httptest.NewRecorder()
+		h.Dashboard(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d", path, w.Code)
+		}
+		redacted := strings.HasSuffix(path, "redact=true")
+		if got := strings.Contains(w.Body.String(), `"test-app"`); got == redacted {
+			t.Errorf("%s: expected app name present=%v, got %s", path, !redacted, w.Body.String())
+		}
+		if redacted && !strings.Contains(w.Body.String(), `"app-1"`) {
+			t.Errorf("%s: expected app pseudonyms, got %s", path, w.Body.String())
+		}
+	}
+}
+
 func TestHandleManualInfrastructure(t *testing.T) {
 	body := `{
 		"name": "Test Env",
@@ -767,6 +948,140 @@ func TestHandleManualInfrastructure(t *testing.T) {
 	}
 }
 
+func TestHandleManualInfrastructure_HumanSizes(t *testing.T) {
+	body := `{
+		"name": "Test Env",
+		"clusters": [{
+			"name": "cluster-01",
+			"host_count": 8,
+			"memory_gb_per_host": 2048,
+			"diego_cell_count": 250,
+			"diego_cell_memory_gb": 32
+		}],
+		"total_app_memory_gb": 10500
+	}`
+	handler := NewHandler(&config.Config{}, cache.New(5*time.Minute))
+
+	req := httptest.NewRequest("POST", "/api/v1/infrastructure/manual", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	handler.SetManualInfrastructure(w, req)
+	if strings.Contains(w.Body.String(), "_human") {
+		t.Errorf("Expected no *_human fields without human=true, got %s", w.Body.String())
+	}
+
+	req = httptest.NewRequest("POST", "/api/v1/infrastructure/manual?human=true", strings.NewReader(body))
+	w = httptest.NewRecorder()
+	handler.SetManualInfrastructure(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response models.InfrastructureState
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.TotalMemoryGB != 16384 || response.TotalMemoryHuman != "16 TB" {
+		t.Errorf("Expected total memory 16384 GB shown as 16 TB, got %d / %q", response.TotalMemoryGB, response.TotalMemoryHuman)
+	}
+	if response.TotalCellMemoryHuman != "7.8 TB" {
+		t.Errorf("Expected cell memory 7.8 TB, got %q", response.TotalCellMemoryHuman)
+	}
+	if response.TotalAppMemoryGB != 10500 || response.TotalAppMemoryHuman != "10.3 TB" {
+		t.Errorf("Expected app memory 10500 GB shown as 10.3 TB, got %d / %q", response.TotalAppMemoryGB, response.TotalAppMemoryHuman)
+	}
+
+	if stored := handler.CurrentInfrastructureState(); stored.TotalMemoryHuman != "" {
+		t.Errorf("Expected stored state without display sizes, got %q", stored.TotalMemoryHuman)
+	}
+}
+
+func TestHandleInfrastructure_Redact(t *testing.T) {
+	body := `{
+		"name": "Prod West",
+		"clusters": [
+			{"name": "pci-cluster", "host_count": 4, "memory_gb_per_host": 1024, "diego_cell_count": 64, "diego_cell_memory_gb": 32},
+			{"name": "shared-cluster", "host_count": 2, "memory_gb_per_host": 512, "diego_cell_count": 8, "diego_cell_memory_gb": 32}
+		]
+	}`
+	handler := NewHandler(&config.Config{}, cache.New(5*time.Minute))
+
+	w := httptest.NewRecorder()
+	handler.SetManualInfrastructure(w, httptest.NewRequest("POST", "/api/v1/infrastructure/manual?redact=true", strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var state models.InfrastructureState
+	if err := json.NewDecoder(w.Body).Decode(&state); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if state.Name != "foundation-1" || state.Clusters[0].Name != "cluster-1" || state.Clusters[1].Name != "cluster-2" {
+		t.Errorf("Expected foundation-1 with cluster-1 and cluster-2, got %q %q %q", state.Name, state.Clusters[0].Name, state.Clusters[1].Name)
+	}
+	if state.TotalCellCount != 72 || state.Clusters[0].TotalCellMemoryGB != 2048 {
+		t.Errorf("Expected capacity numbers to be kept, got %d cells / %d GB", state.TotalCellCount, state.Clusters[0].TotalCellMemoryGB)
+	}
+
+	// Only the response is redacted; the stored state keeps the real names
+	w = httptest.NewRecorder()
+	handler.GetInfrastructureClusters(w, httptest.NewRequest("GET", "/api/v1/infrastructure/clusters", nil))
+	if !strings.Contains(w.Body.String(), "pci-cluster") {
+		t.Errorf("Expected the stored state to keep real names, got %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	handler.GetInfrastructureClusters(w, httptest.NewRequest("GET", "/api/v1/infrastructure/clusters?redact=true", nil))
+	for _, name := range []string{"Prod West", "pci-cluster", "shared-cluster"} {
+		if strings.Contains(w.Body.String(), name) {
+			t.Errorf("Expected %q to be redacted, got %s", name, w.Body.String())
+		}
+	}
+	if !strings.Contains(w.Body.String(), `"cluster-2"`) {
+		t.Errorf("Expected cluster pseudonyms, got %s", w.Body.String())
+	}
+}
+
+func TestHandleManualInfrastructure_MultipleClusters(t *testing.T) {
+	body := `{
+		"name": "Multi Cluster",
+		"clusters": [
+			{"name": "cluster-01", "host_count": 4, "memory_gb_per_host": 1024, "cpu_threads_per_host": 64, "diego_cell_count": 20, "diego_cell_memory_gb": 64, "diego_cell_cpu": 8},
+			{"name": "cluster-02", "host_count": 6, "memory_gb_per_host": 1024, "cpu_threads_per_host": 64, "diego_cell_count": 30, "diego_cell_memory_gb": 64, "diego_cell_cpu": 8}
+		],
+		"platform_vms_gb": 800,
+		"total_app_memory_gb": 1500,
+		"total_app_instances": 900
+	}`
+
+	req := httptest.NewRequest("POST", "/api/infrastructure/manual", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	handler := NewHandler(&config.Config{}, cache.New(5*time.Minute))
+	handler.SetManualInfrastructure(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response models.InfrastructureState
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if len(response.Clusters) != 2 {
+		t.Fatalf("Expected 2 clusters, got %d", len(response.Clusters))
+	}
+	if response.Clusters[0].Name != "cluster-01" || response.Clusters[1].Name != "cluster-02" {
+		t.Errorf("Expected clusters cluster-01 and cluster-02, got %s and %s", response.Clusters[0].Name, response.Clusters[1].Name)
+	}
+	if response.TotalHostCount != 10 {
+		t.Errorf("Expected TotalHostCount 10, got %d", response.TotalHostCount)
+	}
+	if response.TotalCellCount != 50 {
+		t.Errorf("Expected TotalCellCount 50, got %d", response.TotalCellCount)
+	}
+}
+
 func TestHandleManualInfrastructure_CPUMetrics(t *testing.T) {
 	// Test that CPU metrics are computed and returned in API response
 	body := `{
@@ -883,7 +1198,7 @@ func TestHandleManualInfrastructure_CPURiskLevels(t *testing.T) {
 					{
 						Name:              "cluster-01",
 						HostCount:         tt.hostCount,
-						MemoryGBPerHost:   1024,
+						MemoryGBPerHost:   2048,
 						CPUThreadsPerHost: tt.cpuCoresPerHost,
 						DiegoCellCount:    tt.cellCount,
 						DiegoCellMemoryGB: 32,
@@ -980,361 +1295,1836 @@ func TestCompareScenario(t *testing.T) {
 	}
 }
 
-func TestHandleManualInfrastructure_InvalidJSON(t *testing.T) {
-	cfg := &config.Config{}
-	c := cache.New(5 * time.Minute)
-	handler := NewHandler(cfg, c)
-
-	req := httptest.NewRequest("POST", "/api/infrastructure/manual", strings.NewReader("not valid json"))
-	req.Header.Set("Content-Type", "application/json")
-	w := httptest.NewRecorder()
-	handler.SetManualInfrastructure(w, req)
+func TestCompareScenario_UsesConfiguredTPSCurve(t *testing.T) {
+	manualBody := `{
+		"name": "Test Env",
+		"clusters": [{
+			"name": "cluster-01",
+			"host_count": 4,
+			"memory_gb_per_host": 1024,
+			"cpu_threads_per_host": 64,
+			"diego_cell_count": 10,
+			"diego_cell_memory_gb": 32,
+			"diego_cell_cpu": 4
+		}],
+		"total_app_memory_gb": 100
+	}`
 
-	if w.Code != http.StatusBadRequest {
-		t.Errorf("Expected status 400, got %d", w.Code)
+	cfg := &config.Config{
+		TPSCurve: []models.TPSPt{{Cells: 1, TPS: 500}, {Cells: 20, TPS: 1000}},
 	}
+	handler := NewHandler(cfg, cache.New(5*time.Minute))
 
-	var resp models.ErrorResponse
-	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
-		t.Fatalf("Failed to decode response: %v", err)
-	}
-	if resp.Error != "Invalid JSON" {
-		t.Errorf("Expected 'Invalid JSON' error, got '%s'", resp.Error)
+	req1 := httptest.NewRequest("POST", "/api/infrastructure/manual", strings.NewReader(manualBody))
+	w1 := httptest.NewRecorder()
+	handler.SetManualInfrastructure(w1, req1)
+	if w1.Code != http.StatusOK {
+		t.Fatalf("Failed to set manual infrastructure: %s", w1.Body.String())
 	}
-}
 
-func TestHandleInfrastructure_VSphereNotConfigured(t *testing.T) {
-	cfg := &config.Config{}
-	c := cache.New(5 * time.Minute)
-	handler := NewHandler(cfg, c)
+	tests := []struct {
+		name    string
+		body    string
+		wantTPS int
+	}{
+		{
+			name:    "request without curve uses configured curve",
+			body:    `{"proposed_cell_memory_gb": 32, "proposed_cell_cpu": 4, "proposed_cell_count": 20}`,
+			wantTPS: 1000,
+		},
+		{
+			name:    "request curve overrides configured curve",
+			body:    `{"proposed_cell_memory_gb": 32, "proposed_cell_cpu": 4, "proposed_cell_count": 20, "tps_curve": [{"cells": 20, "tps": 42}]}`,
+			wantTPS: 42,
+		},
+	}
 
-	req := httptest.NewRequest("GET", "/api/infrastructure", nil)
-	w := httptest.NewRecorder()
-	handler.GetInfrastructure(w, req)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/v1/scenario/compare", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			handler.CompareScenario(w, req)
 
-	if w.Code != http.StatusServiceUnavailable {
-		t.Errorf("Expected status 503, got %d", w.Code)
-	}
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+			}
 
-	var resp models.ErrorResponse
-	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
-		t.Fatalf("Failed to decode response: %v", err)
-	}
-	if !strings.Contains(resp.Error, "vSphere not configured") {
-		t.Errorf("Expected vSphere not configured error, got '%s'", resp.Error)
+			var comparison models.ScenarioComparison
+			if err := json.NewDecoder(w.Body).Decode(&comparison); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if comparison.Proposed.EstimatedTPS != tt.wantTPS {
+				t.Errorf("Expected Proposed.EstimatedTPS %d, got %d", tt.wantTPS, comparison.Proposed.EstimatedTPS)
+			}
+		})
 	}
 }
 
-func TestHandleInfrastructureStatus_NoData(t *testing.T) {
-	cfg := &config.Config{}
-	c := cache.New(5 * time.Minute)
-	handler := NewHandler(cfg, c)
+func TestGetScenarioSweep(t *testing.T) {
+	manualBody := `{
+		"name": "Test Env",
+		"clusters": [{
+			"name": "cluster-01",
+			"host_count": 4,
+			"memory_gb_per_host": 1024,
+			"cpu_threads_per_host": 64,
+			"diego_cell_count": 80,
+			"diego_cell_memory_gb": 32,
+			"diego_cell_cpu": 4
+		}],
+		"total_app_memory_gb": 1000
+	}`
 
-	req := httptest.NewRequest("GET", "/api/infrastructure/status", nil)
+	handler := NewHandler(&config.Config{}, cache.New(5*time.Minute))
+
+	req := httptest.NewRequest("GET", "/api/v1/scenario/sweep?min=10&max=100&step=5", nil)
 	w := httptest.NewRecorder()
-	handler.GetInfrastructureStatus(w, req)
+	handler.GetScenarioSweep(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without infrastructure data, got %d", w.Code)
+	}
+
+	req1 := httptest.NewRequest("POST", "/api/v1/infrastructure/manual", strings.NewReader(manualBody))
+	w1 := httptest.NewRecorder()
+	handler.SetManualInfrastructure(w1, req1)
+	if w1.Code != http.StatusOK {
+		t.Fatalf("Failed to set manual infrastructure: %s", w1.Body.String())
+	}
 
+	req = httptest.NewRequest("GET", "/api/v1/scenario/sweep?min=10&max=100&step=5&memory=64", nil)
+	w = httptest.NewRecorder()
+	handler.GetScenarioSweep(w, req)
 	if w.Code != http.StatusOK {
-		t.Errorf("Expected status 200, got %d", w.Code)
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
 	}
 
-	var resp map[string]interface{}
-	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+	var sweep models.ScenarioSweep
+	if err := json.NewDecoder(w.Body).Decode(&sweep); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
-
-	if resp["vsphere_configured"] != false {
-		t.Errorf("Expected vsphere_configured false, got %v", resp["vsphere_configured"])
+	if len(sweep.Results) != 19 {
+		t.Fatalf("Expected 19 results for 10..100 by 5, got %d", len(sweep.Results))
 	}
-	if resp["has_data"] != false {
-		t.Errorf("Expected has_data false, got %v", resp["has_data"])
+	first, last := sweep.Results[0], sweep.Results[len(sweep.Results)-1]
+	if first.CellCount != 10 || last.CellCount != 100 {
+		t.Errorf("Expected cell counts 10..100, got %d..%d", first.CellCount, last.CellCount)
+	}
+	if first.CellMemoryGB != 64 {
+		t.Errorf("Expected memory override of 64 GB, got %d", first.CellMemoryGB)
+	}
+	if last.TPSStatus == "disabled" {
+		t.Error("Expected TPS to be estimated at each point")
+	}
+
+	for _, query := range []string{"", "min=10", "min=10&max=5", "min=10&max=100&step=0", "min=10&max=100&cpu=-1"} {
+		req := httptest.NewRequest("GET", "/api/v1/scenario/sweep?"+query, nil)
+		w := httptest.NewRecorder()
+		handler.GetScenarioSweep(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("query %q: expected 400, got %d", query, w.Code)
+		}
 	}
 }
 
-func TestHandleInfrastructureStatus_WithData(t *testing.T) {
-	cfg := &config.Config{}
-	c := cache.New(5 * time.Minute)
-	handler := NewHandler(cfg, c)
-
-	// First load manual infrastructure
+func TestCompareScenario_HostsToRemove(t *testing.T) {
+	// 4 hosts × 1024 GB: N-1 = 3072 GB; 80 cells × 32 GB = 2560 GB
 	manualBody := `{
 		"name": "Test Env",
 		"clusters": [{
 			"name": "cluster-01",
-			"host_count": 8,
-			"memory_gb_per_host": 2048,
+			"host_count": 4,
+			"memory_gb_per_host": 1024,
 			"cpu_threads_per_host": 64,
-			"diego_cell_count": 250,
+			"diego_cell_count": 80,
 			"diego_cell_memory_gb": 32,
 			"diego_cell_cpu": 4
 		}],
-		"platform_vms_gb": 4800,
-		"total_app_memory_gb": 10500,
-		"total_app_instances": 7500
+		"total_app_memory_gb": 1000
 	}`
 
-	req1 := httptest.NewRequest("POST", "/api/infrastructure/manual", strings.NewReader(manualBody))
-	req1.Header.Set("Content-Type", "application/json")
+	handler := NewHandler(&config.Config{}, cache.New(5*time.Minute))
+
+	req1 := httptest.NewRequest("POST", "/api/v1/infrastructure/manual", strings.NewReader(manualBody))
 	w1 := httptest.NewRecorder()
 	handler.SetManualInfrastructure(w1, req1)
+	if w1.Code != http.StatusOK {
+		t.Fatalf("Failed to set manual infrastructure: %s", w1.Body.String())
+	}
 
-	// Now check status
-	req2 := httptest.NewRequest("GET", "/api/infrastructure/status", nil)
-	w2 := httptest.NewRecorder()
-	handler.GetInfrastructureStatus(w2, req2)
+	tests := []struct {
+		name         string
+		body         string
+		wantStatus   int
+		wantEvacuate int
+		wantCritical bool
+	}{
+		{
+			name:         "one host removed still fits",
+			body:         `{"proposed_cell_memory_gb": 32, "proposed_cell_cpu": 4, "proposed_cell_count": 60, "hosts_to_remove": 1}`,
+			wantStatus:   http.StatusOK,
+			wantEvacuate: 15,
+		},
+		{
+			name:         "one host removed overflows",
+			body:         `{"proposed_cell_memory_gb": 32, "proposed_cell_cpu": 4, "proposed_cell_count": 80, "hosts_to_remove": 1}`,
+			wantStatus:   http.StatusOK,
+			wantEvacuate: 20,
+			wantCritical: true,
+		},
+		{
+			name:       "removing every spare host is rejected",
+			body:       `{"proposed_cell_memory_gb": 32, "proposed_cell_cpu": 4, "proposed_cell_count": 80, "hosts_to_remove": 3}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "negative count is rejected",
+			body:       `{"proposed_cell_memory_gb": 32, "proposed_cell_cpu": 4, "proposed_cell_count": 80, "hosts_to_remove": -1}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
 
-	if w2.Code != http.StatusOK {
-		t.Errorf("Expected status 200, got %d", w2.Code)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/v1/scenario/compare", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			handler.CompareScenario(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var comparison models.ScenarioComparison
+			if err := json.NewDecoder(w.Body).Decode(&comparison); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if comparison.Proposed.HostsRemoved != 1 {
+				t.Errorf("Expected Proposed.HostsRemoved 1, got %d", comparison.Proposed.HostsRemoved)
+			}
+			if comparison.Proposed.CellsToEvacuate != tt.wantEvacuate {
+				t.Errorf("Expected Proposed.CellsToEvacuate %d, got %d", tt.wantEvacuate, comparison.Proposed.CellsToEvacuate)
+			}
+
+			hasCritical := false
+			for _, warning := range comparison.Warnings {
+				if strings.HasPrefix(warning.Message, "Removing 1 host(s)") {
+					hasCritical = true
+				}
+			}
+			if hasCritical != tt.wantCritical {
+				t.Errorf("Expected host removal warning=%v, got warnings %+v", tt.wantCritical, comparison.Warnings)
+			}
+		})
 	}
+}
 
-	var resp map[string]interface{}
-	if err := json.NewDecoder(w2.Body).Decode(&resp); err != nil {
-		t.Fatalf("Failed to decode response: %v", err)
+func TestCompareScenario_TargetCluster(t *testing.T) {
+	manualBody := `{
+		"name": "Test Env",
+		"clusters": [
+			{"name": "cluster-a", "host_count": 4, "memory_gb_per_host": 1024, "cpu_threads_per_host": 64,
+			 "diego_cell_count": 40, "diego_cell_memory_gb": 32, "diego_cell_cpu": 4},
+			{"name": "cluster-b", "host_count": 4, "memory_gb_per_host": 1024, "cpu_threads_per_host": 64,
+			 "diego_cell_count": 10, "diego_cell_memory_gb": 32, "diego_cell_cpu": 4}
+		],
+		"total_app_memory_gb": 800
+	}`
+
+	handler := NewHandler(&config.Config{}, cache.New(5*time.Minute))
+
+	req1 := httptest.NewRequest("POST", "/api/v1/infrastructure/manual", strings.NewReader(manualBody))
+	w1 := httptest.NewRecorder()
+	handler.SetManualInfrastructure(w1, req1)
+	if w1.Code != http.StatusOK {
+		t.Fatalf("Failed to set manual infrastructure: %s", w1.Body.String())
 	}
 
-	if resp["has_data"] != true {
-		t.Errorf("Expected has_data true, got %v", resp["has_data"])
+	body := `{"proposed_cell_memory_gb": 32, "proposed_cell_cpu": 4, "proposed_cell_count": 48, "target_cluster": "cluster-a"}`
+	req := httptest.NewRequest("POST", "/api/v1/scenario/compare", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	handler.CompareScenario(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
 	}
-	if resp["source"] != "manual" {
-		t.Errorf("Expected source 'manual', got %v", resp["source"])
+
+	var comparison models.ScenarioComparison
+	if err := json.NewDecoder(w.Body).Decode(&comparison); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
 	}
-	if resp["name"] != "Test Env" {
-		t.Errorf("Expected name 'Test Env', got %v", resp["name"])
+	if comparison.Current.CellCount != 40 {
+		t.Errorf("Expected current side to be cluster-a's 40 cells, got %d", comparison.Current.CellCount)
 	}
-	if resp["host_count"].(float64) != 8 {
-		t.Errorf("Expected host_count 8, got %v", resp["host_count"])
+	if comparison.TargetCluster != "cluster-a" || len(comparison.UnchangedClusters) != 1 || comparison.UnchangedClusters[0].Name != "cluster-b" {
+		t.Errorf("Expected cluster-b reported as unchanged, got target %q unchanged %+v", comparison.TargetCluster, comparison.UnchangedClusters)
 	}
-	if resp["cell_count"].(float64) != 250 {
-		t.Errorf("Expected cell_count 250, got %v", resp["cell_count"])
+
+	unknown := `{"proposed_cell_memory_gb": 32, "proposed_cell_cpu": 4, "proposed_cell_count": 48, "target_cluster": "cluster-z"}`
+	req = httptest.NewRequest("POST", "/api/v1/scenario/compare", strings.NewReader(unknown))
+	w = httptest.NewRecorder()
+	handler.CompareScenario(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown cluster, got %d: %s", w.Code, w.Body.String())
 	}
 }
 
-func TestCompareScenario_NoInfrastructureData(t *testing.T) {
-	cfg := &config.Config{}
-	c := cache.New(5 * time.Minute)
-	handler := NewHandler(cfg, c)
+func TestCompareScenario_Redact(t *testing.T) {
+	manualBody := `{
+		"name": "prod",
+		"clusters": [
+			{"name": "prod-pci", "host_count": 4, "memory_gb_per_host": 1024, "cpu_threads_per_host": 64,
+			 "diego_cell_count": 40, "diego_cell_memory_gb": 32, "diego_cell_cpu": 4},
+			{"name": "prod-web", "host_count": 4, "memory_gb_per_host": 1024, "cpu_threads_per_host": 64,
+			 "diego_cell_count": 10, "diego_cell_memory_gb": 32, "diego_cell_cpu": 4}
+		],
+		"total_app_memory_gb": 800
+	}`
+
+	handler := NewHandler(&config.Config{}, cache.New(5*time.Minute))
 
-	body := `{"proposed_cell_memory_gb": 64, "proposed_cell_cpu": 4, "proposed_cell_count": 235}`
-	req := httptest.NewRequest("POST", "/api/scenario/compare", strings.NewReader(body))
-	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
-	handler.CompareScenario(w, req)
+	handler.SetManualInfrastructure(w, httptest.NewRequest("POST", "/api/v1/infrastructure/manual", strings.NewReader(manualBody)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Failed to set manual infrastructure: %s", w.Body.String())
+	}
+	// As if discovered, so the HA admission mismatch warning names the cluster
+	handler.infrastructureState.Source = "vsphere"
+	handler.infrastructureState.Clusters[0].HAAdmissionControlPercentage = 25
 
-	if w.Code != http.StatusBadRequest {
-		t.Errorf("Expected status 400, got %d", w.Code)
+	body := `{"proposed_cell_memory_gb": 32, "proposed_cell_cpu": 4, "proposed_cell_count": 40, "target_cluster": "prod-pci", "ha_admission_pct": 50}`
+	w = httptest.NewRecorder()
+	handler.CompareScenario(w, httptest.NewRequest("POST", "/api/v1/scenario/compare?redact=true", strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "prod") {
+		t.Errorf("Expected no foundation or cluster names in the response, got %s", w.Body.String())
 	}
 
-	var resp models.ErrorResponse
-	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+	var comparison models.ScenarioComparison
+	if err := json.NewDecoder(w.Body).Decode(&comparison); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
-	if !strings.Contains(resp.Error, "No infrastructure data") {
-		t.Errorf("Expected 'No infrastructure data' error, got '%s'", resp.Error)
+	if comparison.TargetCluster != "cluster-1" || len(comparison.UnchangedClusters) != 1 || comparison.UnchangedClusters[0].Name != "cluster-2" {
+		t.Errorf("Expected cluster-1 targeted and cluster-2 unchanged, got %q / %+v", comparison.TargetCluster, comparison.UnchangedClusters)
+	}
+	var mismatch string
+	for _, warning := range comparison.Warnings {
+		if warning.Code == models.WarningHAAdmissionMismatch {
+			mismatch = warning.Message
+		}
+	}
+	if !strings.Contains(mismatch, "cluster-1 (25%)") {
+		t.Errorf("Expected the HA mismatch warning to name cluster-1, got %q", mismatch)
 	}
 }
 
-func TestCompareScenario_InvalidJSON(t *testing.T) {
-	cfg := &config.Config{}
-	c := cache.New(5 * time.Minute)
-	handler := NewHandler(cfg, c)
+func TestCompareScenario_TargetClusterScopesRecommendationsAndBaselines(t *testing.T) {
+	manualBody := `{
+		"name": "Test Env",
+		"clusters": [
+			{"name": "cluster-a", "host_count": 4, "memory_gb_per_host": 1024, "cpu_threads_per_host": 64,
+			 "diego_cell_count": 40, "diego_cell_memory_gb": 32, "diego_cell_cpu": 4},
+			{"name": "cluster-b", "host_count": 4, "memory_gb_per_host": 1024, "cpu_threads_per_host": 64,
+			 "diego_cell_count": 10, "diego_cell_memory_gb": 32, "diego_cell_cpu": 4}
+		],
+		"total_app_memory_gb": 800,
+		"total_app_instances": 5000
+	}`
 
-	// First load manual infrastructure
+	handler := NewHandler(&config.Config{}, cache.New(5*time.Minute))
+
+	w := httptest.NewRecorder()
+	handler.SetManualInfrastructure(w, httptest.NewRequest("POST", "/api/v1/infrastructure/manual", strings.NewReader(manualBody)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Failed to set manual infrastructure: %s", w.Body.String())
+	}
+	compare := func(body string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		handler.CompareScenario(w, httptest.NewRequest("POST", "/api/v1/scenario/compare", strings.NewReader(body)))
+		return w
+	}
+
+	// The fault impact recommendation sizes cluster-b's 10 cells, not the foundation's 50
+	w = compare(`{"proposed_cell_memory_gb": 32, "proposed_cell_cpu": 4, "proposed_cell_count": 10, "target_cluster": "cluster-b", "max_fault_impact": 50}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var comparison models.ScenarioComparison
+	if err := json.NewDecoder(w.Body).Decode(&comparison); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	var resize *models.Recommendation
+	for i, rec := range comparison.Recommendations {
+		if rec.Type == models.RecommendationResizeForFaultImpact {
+			resize = &comparison.Recommendations[i]
+		}
+	}
+	if resize == nil {
+		t.Fatalf("Expected a fault impact recommendation, got %+v", comparison.Recommendations)
+	}
+	if !strings.Contains(resize.Description, "instead of 10 cells") {
+		t.Errorf("Expected the recommendation to describe cluster-b's 10 cells, got %q", resize.Description)
+	}
+
+	// A baseline remembers its scope, and only comparisons of the same scope may use it
+	w = httptest.NewRecorder()
+	handler.SaveScenarioBaseline(w, httptest.NewRequest("POST", "/api/v1/scenario/baseline", strings.NewReader(
+		`{"name": "b-plan", "input": {"proposed_cell_memory_gb": 32, "proposed_cell_cpu": 4, "proposed_cell_count": 12, "target_cluster": "cluster-b"}}`)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var saved models.ScenarioBaseline
+	if err := json.NewDecoder(w.Body).Decode(&saved); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if saved.TargetCluster != "cluster-b" {
+		t.Errorf("Expected the baseline to record cluster-b, got %q", saved.TargetCluster)
+	}
+
+	if w := compare(`{"proposed_cell_memory_gb": 32, "proposed_cell_cpu": 4, "proposed_cell_count": 12, "target_cluster": "cluster-b", "baseline": "b-plan"}`); w.Code != http.StatusOK {
+		t.Errorf("Same scope: expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	for _, body := range []string{
+		`{"proposed_cell_memory_gb": 32, "proposed_cell_cpu": 4, "proposed_cell_count": 60, "baseline": "b-plan"}`,
+		`{"proposed_cell_memory_gb": 32, "proposed_cell_cpu": 4, "proposed_cell_count": 40, "target_cluster": "cluster-a", "baseline": "b-plan"}`,
+	} {
+		if w := compare(body); w.Code != http.StatusBadRequest {
+			t.Errorf("Different scope: expected status 400, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	// GET compares the baseline against cluster-b's current cells
+	req := httptest.NewRequest("GET", "/api/v1/scenario/baseline/b-plan", nil)
+	req.SetPathValue("name", "b-plan")
+	w = httptest.NewRecorder()
+	handler.GetScenarioBaseline(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var baseline models.BaselineComparison
+	if err := json.NewDecoder(w.Body).Decode(&baseline); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if baseline.Proposed.CellCount != 10 {
+		t.Errorf("Expected cluster-b's 10 current cells, got %d", baseline.Proposed.CellCount)
+	}
+}
+
+func TestCompareScenario_OverheadModel(t *testing.T) {
 	manualBody := `{
 		"name": "Test Env",
 		"clusters": [{
 			"name": "cluster-01",
-			"host_count": 8,
-			"memory_gb_per_host": 2048,
-			"cpu_threads_per_host": 64,
-			"diego_cell_count": 250,
+			"host_count": 4,
+			"memory_gb_per_host": 1024,
+			"diego_cell_count": 40,
 			"diego_cell_memory_gb": 32,
 			"diego_cell_cpu": 4
 		}],
-		"platform_vms_gb": 4800,
-		"total_app_memory_gb": 10500,
-		"total_app_instances": 7500
+		"total_app_memory_gb": 400,
+		"total_app_instances": 2000
 	}`
 
-	req1 := httptest.NewRequest("POST", "/api/infrastructure/manual", strings.NewReader(manualBody))
-	req1.Header.Set("Content-Type", "application/json")
+	handler := NewHandler(&config.Config{}, cache.New(5*time.Minute))
+
+	req1 := httptest.NewRequest("POST", "/api/v1/infrastructure/manual", strings.NewReader(manualBody))
 	w1 := httptest.NewRecorder()
 	handler.SetManualInfrastructure(w1, req1)
+	if w1.Code != http.StatusOK {
+		t.Fatalf("Failed to set manual infrastructure: %s", w1.Body.String())
+	}
 
-	// Now try with invalid JSON
-	req2 := httptest.NewRequest("POST", "/api/scenario/compare", strings.NewReader("not valid json"))
-	req2.Header.Set("Content-Type", "application/json")
-	w2 := httptest.NewRecorder()
-	handler.CompareScenario(w2, req2)
-
-	if w2.Code != http.StatusBadRequest {
-		t.Errorf("Expected status 400, got %d", w2.Code)
+	// 50 instances per cell × 40 MB = 1.95 GB, plus 2 GB fixed = 3 GB overhead
+	body := `{"proposed_cell_memory_gb": 32, "proposed_cell_cpu": 4, "proposed_cell_count": 40,
+		"overhead_model": {"fixed_gb": 2, "per_instance_mb": 40}}`
+	req := httptest.NewRequest("POST", "/api/v1/scenario/compare", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	handler.CompareScenario(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
 	}
 
-	var resp models.ErrorResponse
-	if err := json.NewDecoder(w2.Body).Decode(&resp); err != nil {
+	var comparison models.ScenarioComparison
+	if err := json.NewDecoder(w.Body).Decode(&comparison); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
-	if resp.Error != "Invalid JSON" {
-		t.Errorf("Expected 'Invalid JSON' error, got '%s'", resp.Error)
+	if comparison.Proposed.AppCapacityGB != 40*(32-3) {
+		t.Errorf("Expected Proposed.AppCapacityGB %d, got %d", 40*(32-3), comparison.Proposed.AppCapacityGB)
 	}
-}
 
-func TestDashboardHandler_AppMemoryCalculation(t *testing.T) {
-	// Set up mock CF server with apps
-	cfServer, uaaServer := setupMockCFServerWithApps()
-	defer cfServer.Close()
-	defer uaaServer.Close()
+	body = `{"proposed_cell_memory_gb": 32, "proposed_cell_cpu": 4, "proposed_cell_count": 40,
+		"overhead_model": {"fixed_gb": -2}}`
+	req = httptest.NewRequest("POST", "/api/v1/scenario/compare", strings.NewReader(body))
+	w = httptest.NewRecorder()
+	handler.CompareScenario(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for negative overhead, got %d: %s", w.Code, w.Body.String())
+	}
+}
 
-	// Set up mock BOSH server that returns cells with UsedMB = 0
-	boshServer := setupMockBOSHServer(true) // true = cells have no UsedMB
-	defer boshServer.Close()
+func TestCompareScenario_BaselineInput(t *testing.T) {
+	manualBody := `{
+		"name": "Test Env",
+		"clusters": [{
+			"name": "cluster-01",
+			"host_count": 4,
+			"memory_gb_per_host": 1024,
+			"diego_cell_count": 40,
+			"diego_cell_memory_gb": 32,
+			"diego_cell_cpu": 4
+		}],
+		"total_app_memory_gb": 400,
+		"total_app_instances": 2000
+	}`
 
-	cfg := &config.Config{
-		CFAPIUrl:        cfServer.URL,
-		CFUsername:      "admin",
-		CFPassword:      "secret",
-		BOSHEnvironment: boshServer.URL,
-		BOSHClient:      "ops_manager",
-		BOSHSecret:      "secret",
-		BOSHDeployment:  "cf-test",
-		DashboardTTL:    30,
-	}
-	c := cache.New(5 * time.Minute)
+	handler := NewHandler(&config.Config{}, cache.New(5*time.Minute))
 
-	// Create handler and inject a BOSH client with custom TLS config
-	h := &Handler{
-		cfg:          cfg,
-		cache:        c,
-		scenarioCalc: services.NewScenarioCalculator(),
+	req1 := httptest.NewRequest("POST", "/api/v1/infrastructure/manual", strings.NewReader(manualBody))
+	w1 := httptest.NewRecorder()
+	handler.SetManualInfrastructure(w1, req1)
+	if w1.Code != http.StatusOK {
+		t.Fatalf("Failed to set manual infrastructure: %s", w1.Body.String())
 	}
-	h.cfClient = services.NewCFClient(cfg.CFAPIUrl, cfg.CFUsername, cfg.CFPassword, true)
-
-	// Create BOSH client with TLS skip verify for test server
-	h.boshClient, _ = services.NewBOSHClient(
-		boshServer.URL,
-		cfg.BOSHClient,
-		cfg.BOSHSecret,
-		"", // no CA cert
-		cfg.BOSHDeployment,
-		true, // skip SSL validation for test
-	)
-	// Override HTTP client to skip TLS verification for test
-	h.boshClient.SetHTTPClient(&http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		},
-	})
 
-	req := httptest.NewRequest("GET", "/api/dashboard", nil)
+	body := `{"proposed_cell_memory_gb": 64, "proposed_cell_cpu": 8, "proposed_cell_count": 24,
+		"baseline_input": {"proposed_cell_memory_gb": 64, "proposed_cell_cpu": 8, "proposed_cell_count": 20}}`
+	req := httptest.NewRequest("POST", "/api/v1/scenario/compare", strings.NewReader(body))
 	w := httptest.NewRecorder()
-
-	h.Dashboard(w, req)
-
+	handler.CompareScenario(w, req)
 	if w.Code != http.StatusOK {
 		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
 	}
 
-	var resp models.DashboardResponse
-	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+	var comparison models.ScenarioComparison
+	if err := json.NewDecoder(w.Body).Decode(&comparison); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
-
-	// Verify cells are present
+	if comparison.Current.CellCount != 20 || comparison.Current.CellMemoryGB != 64 {
+		t.Errorf("Expected baseline option (20 x 64 GB) as current, got %d x %d GB",
+			comparison.Current.CellCount, comparison.Current.CellMemoryGB)
+	}
+	if want := comparison.Proposed.AppCapacityGB - comparison.Current.AppCapacityGB; comparison.Delta.CapacityChangeGB != want {
+		t.Errorf("Expected delta %d between the options, got %d", want, comparison.Delta.CapacityChangeGB)
+	}
+
+	for name, body := range map[string]string{
+		"nested baseline_input": `{"proposed_cell_count": 24, "proposed_cell_memory_gb": 64,
+			"baseline_input": {"proposed_cell_count": 20, "proposed_cell_memory_gb": 64, "baseline_input": {}}}`,
+		"invalid baseline_input": `{"proposed_cell_count": 24, "proposed_cell_memory_gb": 64,
+			"baseline_input": {"proposed_cell_count": 20, "proposed_cell_memory_gb": 64, "hosts_to_remove": -1}}`,
+	} {
+		req := httptest.NewRequest("POST", "/api/v1/scenario/compare", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.CompareScenario(w, req)
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "baseline_input") {
+			t.Errorf("%s: expected 400 naming baseline_input, got %d: %s", name, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestInfrastructureState_PersistsAcrossRestart(t *testing.T) {
+	cfg := &config.Config{StateFile: filepath.Join(t.TempDir(), "state.json")}
+	handler := NewHandler(cfg, cache.New(5*time.Minute))
+
+	body := `{"name": "Persisted", "clusters": [{"name": "c1", "host_count": 4, "memory_gb_per_host": 512, "diego_cell_count": 10, "diego_cell_memory_gb": 32}]}`
+	req := httptest.NewRequest("POST", "/api/v1/infrastructure/manual", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	handler.SetManualInfrastructure(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if _, err := os.Stat(cfg.StateFile); err != nil {
+		t.Fatalf("Expected state file to be written: %v", err)
+	}
+
+	// A fresh handler simulates a backend restart
+	restarted := NewHandler(cfg, cache.New(5*time.Minute))
+	restarted.RestoreInfrastructureState()
+
+	state := restarted.CurrentInfrastructureState()
+	if state == nil {
+		t.Fatal("Expected infrastructure state to be restored")
+	}
+	if state.Name != "Persisted" || state.TotalCellCount != 10 {
+		t.Errorf("Unexpected restored state: name=%q cells=%d", state.Name, state.TotalCellCount)
+	}
+}
+
+func TestScenarioBaseline_SaveCompareAndRestore(t *testing.T) {
+	cfg := &config.Config{StateFile: filepath.Join(t.TempDir(), "state.json")}
+	handler := NewHandler(cfg, cache.New(5*time.Minute))
+
+	manualBody := `{"name": "Baseline Env", "clusters": [{"name": "c1", "host_count": 8, "memory_gb_per_host": 1024, "diego_cell_count": 40, "diego_cell_memory_gb": 32, "diego_cell_cpu": 4}], "total_app_memory_gb": 600}`
+	w := httptest.NewRecorder()
+	handler.SetManualInfrastructure(w, httptest.NewRequest("POST", "/api/v1/infrastructure/manual", strings.NewReader(manualBody)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Failed to set manual infrastructure: %s", w.Body.String())
+	}
+
+	save := func(body string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		handler.SaveScenarioBaseline(w, httptest.NewRequest("POST", "/api/v1/scenario/baseline", strings.NewReader(body)))
+		return w
+	}
+	get := func(h *Handler, name string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/api/v1/scenario/baseline/"+name, nil)
+		req.SetPathValue("name", name)
+		w := httptest.NewRecorder()
+		h.GetScenarioBaseline(w, req)
+		return w
+	}
+
+	// Save the proposed result of a scenario as the baseline
+	w = save(`{"name": "q3-plan", "input": {"proposed_cell_memory_gb": 32, "proposed_cell_cpu": 4, "proposed_cell_count": 50}}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var saved models.ScenarioBaseline
+	if err := json.NewDecoder(w.Body).Decode(&saved); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if saved.Name != "q3-plan" || saved.Result.CellCount != 50 {
+		t.Errorf("Unexpected saved baseline: name=%q cells=%d", saved.Name, saved.Result.CellCount)
+	}
+
+	// Current state (40 cells) against the 50-cell baseline
+	w = get(handler, "q3-plan")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var comparison models.BaselineComparison
+	if err := json.NewDecoder(w.Body).Decode(&comparison); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if comparison.Proposed.CellCount != 40 || comparison.Delta.CapacityChangeGB >= 0 {
+		t.Errorf("Expected current 40 cells with less capacity than baseline, got cells=%d delta=%+v", comparison.Proposed.CellCount, comparison.Delta)
+	}
+
+	// Scenario comparison can include the baseline
+	w = httptest.NewRecorder()
+	handler.CompareScenario(w, httptest.NewRequest("POST", "/api/v1/scenario/compare", strings.NewReader(`{"proposed_cell_memory_gb": 32, "proposed_cell_cpu": 4, "proposed_cell_count": 50, "baseline": "q3-plan"}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var scenario models.ScenarioComparison
+	if err := json.NewDecoder(w.Body).Decode(&scenario); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if scenario.Baseline == nil {
+		t.Fatal("Expected baseline comparison in scenario response")
+	}
+	if scenario.Baseline.Delta.CapacityChangeGB != 0 {
+		t.Errorf("Expected identical proposal to match baseline, got delta %+v", scenario.Baseline.Delta)
+	}
+
+	// Unknown baselines and invalid names are rejected
+	if w := get(handler, "missing"); w.Code != http.StatusNotFound {
+		t.Errorf("GET unknown baseline: expected 404, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	handler.CompareScenario(w, httptest.NewRequest("POST", "/api/v1/scenario/compare", strings.NewReader(`{"proposed_cell_count": 50, "baseline": "missing"}`)))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Compare with unknown baseline: expected 404, got %d", w.Code)
+	}
+	if w := save(`{"name": "bad name"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Invalid name: expected 400, got %d", w.Code)
+	}
+
+	// Baselines survive a restart alongside STATE_FILE
+	restarted := NewHandler(cfg, cache.New(5*time.Minute))
+	restarted.RestoreInfrastructureState()
+	restarted.RestoreScenarioBaselines()
+	if w := get(restarted, "q3-plan"); w.Code != http.StatusOK {
+		t.Errorf("Expected baseline to be restored after restart, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestSaveScenarioBaseline_NoInfrastructureData(t *testing.T) {
+	handler := NewHandler(&config.Config{}, cache.New(5*time.Minute))
+
+	w := httptest.NewRecorder()
+	handler.SaveScenarioBaseline(w, httptest.NewRequest("POST", "/api/v1/scenario/baseline", strings.NewReader(`{"name": "q3-plan"}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func TestRestoreInfrastructureState_IgnoresBadFile(t *testing.T) {
+	dir := t.TempDir()
+	corrupt := filepath.Join(dir, "corrupt.json")
+	if err := os.WriteFile(corrupt, []byte("{not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{filepath.Join(dir, "missing.json"), corrupt} {
+		handler := NewHandler(&config.Config{StateFile: path}, cache.New(5*time.Minute))
+		handler.RestoreInfrastructureState()
+		if handler.CurrentInfrastructureState() != nil {
+			t.Errorf("Expected no state restored from %s", filepath.Base(path))
+		}
+	}
+}
+
+func TestHandleManualInfrastructure_InvalidJSON(t *testing.T) {
+	cfg := &config.Config{}
+	c := cache.New(5 * time.Minute)
+	handler := NewHandler(cfg, c)
+
+	req := httptest.NewRequest("POST", "/api/infrastructure/manual", strings.NewReader("not valid json"))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.SetManualInfrastructure(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+
+	var resp models.ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Error != "Invalid JSON" {
+		t.Errorf("Expected 'Invalid JSON' error, got '%s'", resp.Error)
+	}
+}
+
+func TestHandleManualInfrastructure_ValidationErrors(t *testing.T) {
+	cfg := &config.Config{}
+	c := cache.New(5 * time.Minute)
+	handler := NewHandler(cfg, c)
+
+	body := `{"name": "bad", "clusters": [{"name": "c1", "host_count": 0, "memory_gb_per_host": 512, "diego_cell_count": 4, "diego_cell_memory_gb": -32}]}`
+	req := httptest.NewRequest("POST", "/api/infrastructure/manual", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.SetManualInfrastructure(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d", w.Code)
+	}
+
+	var resp models.ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	fields := make(map[string]bool)
+	for _, e := range resp.Errors {
+		fields[e.Field] = true
+	}
+	for _, want := range []string{"clusters[0].host_count", "clusters[0].diego_cell_memory_gb"} {
+		if !fields[want] {
+			t.Errorf("Expected a validation error for %s, got %+v", want, resp.Errors)
+		}
+	}
+	if handler.CurrentInfrastructureState() != nil {
+		t.Error("Expected invalid input not to replace the infrastructure state")
+	}
+}
+
+func TestHandleInfrastructure_VSphereNotConfigured(t *testing.T) {
+	cfg := &config.Config{}
+	c := cache.New(5 * time.Minute)
+	handler := NewHandler(cfg, c)
+
+	req := httptest.NewRequest("GET", "/api/infrastructure", nil)
+	w := httptest.NewRecorder()
+	handler.GetInfrastructure(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d", w.Code)
+	}
+
+	var resp models.ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if !strings.Contains(resp.Error, "vSphere not configured") {
+		t.Errorf("Expected vSphere not configured error, got '%s'", resp.Error)
+	}
+}
+
+func TestHandleInfrastructure_ForceBypassesCache(t *testing.T) {
+	model := simulator.VPX()
+	if err := model.Create(); err != nil {
+		t.Fatalf("Failed to create vSphere simulator: %v", err)
+	}
+	server := model.Service.NewServer()
+	defer model.Remove()
+	defer server.Close()
+
+	password, _ := server.URL.User.Password()
+	cfg := &config.Config{
+		VSphereHost:       server.URL.Scheme + "://" + server.URL.Host,
+		VSphereUsername:   server.URL.User.Username(),
+		VSpherePassword:   password,
+		VSphereDatacenter: "DC0",
+		VSphereCacheTTL:   300,
+		// vcsim names its VMs DC0_H0_VM0, DC0_C0_RP0_VM0, ...; treat them all as cells
+		VSphereCellNamePatterns: []string{`_VM\d+$`},
+	}
+	c := cache.New(5 * time.Minute)
+	handler := NewHandler(cfg, c)
+	c.Set(vsphereInfrastructureCacheKey, models.InfrastructureState{Name: "cached-discovery"})
+
+	get := func(target string) models.InfrastructureState {
+		t.Helper()
+		w := httptest.NewRecorder()
+		handler.GetInfrastructure(w, httptest.NewRequest("GET", target, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: expected status 200, got %d: %s", target, w.Code, w.Body.String())
+		}
+		var state models.InfrastructureState
+		if err := json.NewDecoder(w.Body).Decode(&state); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return state
+	}
+
+	state := get("/api/v1/infrastructure")
+	if state.Name != "cached-discovery" || !state.Cached {
+		t.Errorf("Expected cached state, got name=%q cached=%v", state.Name, state.Cached)
+	}
+
+	state = get("/api/v1/infrastructure?force=true")
+	if state.Name != "DC0" || state.Cached {
+		t.Errorf("Expected fresh discovery of DC0, got name=%q cached=%v", state.Name, state.Cached)
+	}
+
+	state = get("/api/v1/infrastructure")
+	if state.Name != "DC0" || !state.Cached {
+		t.Errorf("Expected forced discovery to refresh the cache, got name=%q cached=%v", state.Name, state.Cached)
+	}
+}
+
+func TestHandleInfrastructure_FailedRefreshKeepsCache(t *testing.T) {
+	cfg := &config.Config{
+		VSphereHost:       "http://127.0.0.1:1",
+		VSphereUsername:   "admin",
+		VSpherePassword:   "secret",
+		VSphereDatacenter: "DC0",
+		VSphereCacheTTL:   300,
+	}
+	c := cache.New(5 * time.Minute)
+	handler := NewHandler(cfg, c)
+	c.Set(vsphereInfrastructureCacheKey, models.InfrastructureState{Name: "last-good"})
+
+	w := httptest.NewRecorder()
+	handler.GetInfrastructure(w, httptest.NewRequest("GET", "/api/v1/infrastructure?force=true", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status 503 for unreachable vCenter, got %d", w.Code)
+	}
+
+	cached, found := c.Get(vsphereInfrastructureCacheKey)
+	if !found {
+		t.Fatal("Expected cached discovery to survive a failed refresh")
+	}
+	if name := cached.(models.InfrastructureState).Name; name != "last-good" {
+		t.Errorf("Expected cached state 'last-good', got %q", name)
+	}
+}
+
+func TestObservedCellDiskPercent(t *testing.T) {
+	tests := []struct {
+		name  string
+		cells []models.DiegoCell
+		want  float64
+	}{
+		{"no cells", nil, 0},
+		{"no disk vitals", []models.DiegoCell{{ID: "a"}, {ID: "b"}}, 0},
+		{"skips cells without vitals", []models.DiegoCell{{EphemeralDiskPercent: 40}, {EphemeralDiskPercent: 70}, {}}, 55},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := observedCellDiskPercent(tt.cells); got != tt.want {
+				t.Errorf("observedCellDiskPercent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleInfrastructureStatus_NoData(t *testing.T) {
+	cfg := &config.Config{}
+	c := cache.New(5 * time.Minute)
+	handler := NewHandler(cfg, c)
+
+	req := httptest.NewRequest("GET", "/api/infrastructure/status", nil)
+	w := httptest.NewRecorder()
+	handler.GetInfrastructureStatus(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+
+	var resp map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if resp["vsphere_configured"] != false {
+		t.Errorf("Expected vsphere_configured false, got %v", resp["vsphere_configured"])
+	}
+	if resp["has_data"] != false {
+		t.Errorf("Expected has_data false, got %v", resp["has_data"])
+	}
+}
+
+func TestHandleInfrastructureStatus_WithData(t *testing.T) {
+	cfg := &config.Config{}
+	c := cache.New(5 * time.Minute)
+	handler := NewHandler(cfg, c)
+
+	// First load manual infrastructure
+	manualBody := `{
+		"name": "Test Env",
+		"clusters": [{
+			"name": "cluster-01",
+			"host_count": 8,
+			"memory_gb_per_host": 2048,
+			"cpu_threads_per_host": 64,
+			"diego_cell_count": 250,
+			"diego_cell_memory_gb": 32,
+			"diego_cell_cpu": 4
+		}],
+		"platform_vms_gb": 4800,
+		"total_app_memory_gb": 10500,
+		"total_app_instances": 7500
+	}`
+
+	req1 := httptest.NewRequest("POST", "/api/infrastructure/manual", strings.NewReader(manualBody))
+	req1.Header.Set("Content-Type", "application/json")
+	w1 := httptest.NewRecorder()
+	handler.SetManualInfrastructure(w1, req1)
+
+	// Now check status
+	req2 := httptest.NewRequest("GET", "/api/infrastructure/status", nil)
+	w2 := httptest.NewRecorder()
+	handler.GetInfrastructureStatus(w2, req2)
+
+	if w2.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w2.Code)
+	}
+
+	var resp map[string]interface{}
+	if err := json.NewDecoder(w2.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if resp["has_data"] != true {
+		t.Errorf("Expected has_data true, got %v", resp["has_data"])
+	}
+	if resp["source"] != "manual" {
+		t.Errorf("Expected source 'manual', got %v", resp["source"])
+	}
+	if resp["name"] != "Test Env" {
+		t.Errorf("Expected name 'Test Env', got %v", resp["name"])
+	}
+	if resp["host_count"].(float64) != 8 {
+		t.Errorf("Expected host_count 8, got %v", resp["host_count"])
+	}
+	if resp["cell_count"].(float64) != 250 {
+		t.Errorf("Expected cell_count 250, got %v", resp["cell_count"])
+	}
+}
+
+func TestCompareScenario_NoInfrastructureData(t *testing.T) {
+	cfg := &config.Config{}
+	c := cache.New(5 * time.Minute)
+	handler := NewHandler(cfg, c)
+
+	body := `{"proposed_cell_memory_gb": 64, "proposed_cell_cpu": 4, "proposed_cell_count": 235}`
+	req := httptest.NewRequest("POST", "/api/scenario/compare", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.CompareScenario(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+
+	var resp models.ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if !strings.Contains(resp.Error, "No infrastructure data") {
+		t.Errorf("Expected 'No infrastructure data' error, got '%s'", resp.Error)
+	}
+}
+
+func TestCompareScenario_InvalidJSON(t *testing.T) {
+	cfg := &config.Config{}
+	c := cache.New(5 * time.Minute)
+	handler := NewHandler(cfg, c)
+
+	// First load manual infrastructure
+	manualBody := `{
+		"name": "Test Env",
+		"clusters": [{
+			"name": "cluster-01",
+			"host_count": 8,
+			"memory_gb_per_host": 2048,
+			"cpu_threads_per_host": 64,
+			"diego_cell_count": 250,
+			"diego_cell_memory_gb": 32,
+			"diego_cell_cpu": 4
+		}],
+		"platform_vms_gb": 4800,
+		"total_app_memory_gb": 10500,
+		"total_app_instances": 7500
+	}`
+
+	req1 := httptest.NewRequest("POST", "/api/infrastructure/manual", strings.NewReader(manualBody))
+	req1.Header.Set("Content-Type", "application/json")
+	w1 := httptest.NewRecorder()
+	handler.SetManualInfrastructure(w1, req1)
+
+	// Now try with invalid JSON
+	req2 := httptest.NewRequest("POST", "/api/scenario/compare", strings.NewReader("not valid json"))
+	req2.Header.Set("Content-Type", "application/json")
+	w2 := httptest.NewRecorder()
+	handler.CompareScenario(w2, req2)
+
+	if w2.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w2.Code)
+	}
+
+	var resp models.ErrorResponse
+	if err := json.NewDecoder(w2.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Error != "Invalid JSON" {
+		t.Errorf("Expected 'Invalid JSON' error, got '%s'", resp.Error)
+	}
+}
+
+func TestDashboardHandler_AppMemoryCalculation(t *testing.T) {
+	// Set up mock CF server with apps
+	cfServer, uaaServer := setupMockCFServerWithApps()
+	defer cfServer.Close()
+	defer uaaServer.Close()
+
+	// Set up mock BOSH server that returns cells with UsedMB = 0
+	boshServer := setupMockBOSHServer(true) // true = cells have no UsedMB
+	defer boshServer.Close()
+
+	cfg := &config.Config{
+		CFAPIUrl:        cfServer.URL,
+		CFUsername:      "admin",
+		CFPassword:      "secret",
+		BOSHEnvironment: boshServer.URL,
+		BOSHClient:      "ops_manager",
+		BOSHSecret:      "secret",
+		BOSHDeployment:  "cf-test",
+		DashboardTTL:    30,
+	}
+	c := cache.New(5 * time.Minute)
+
+	// Create handler and inject a BOSH client with custom TLS config
+	h := &Handler{
+		cfg:          cfg,
+		cache:        c,
+		scenarioCalc: services.NewScenarioCalculator(),
+	}
+	h.cfClient = services.NewCFClient(cfg.CFAPIUrl, cfg.CFUsername, cfg.CFPassword, true)
+
+	// Create BOSH client with TLS skip verify for test server
+	h.boshClient, _ = services.NewBOSHClient(
+		boshServer.URL,
+		cfg.BOSHClient,
+		cfg.BOSHSecret,
+		"", // no CA cert
+		cfg.BOSHDeployment,
+		true, // skip SSL validation for test
+	)
+	// Override HTTP client to skip TLS verification for test
+	h.boshClient.SetHTTPClient(&http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		},
+	})
+
+	req := httptest.NewRequest("GET", "/api/dashboard", nil)
+	w := httptest.NewRecorder()
+
+	h.Dashboard(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp models.DashboardResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	// Verify cells are present
 	if len(resp.Cells) == 0 {
 		t.Fatal("Expected cells in response, got none")
 	}
 
-	// Verify apps are present
-	if len(resp.Apps) == 0 {
-		t.Fatal("Expected apps in response, got none")
+	// Verify apps are present
+	if len(resp.Apps) == 0 {
+		t.Fatal("Expected apps in response, got none")
+	}
+
+	// Verify the needsAppCalculation code path was exercised:
+	// - BOSH returned cells with UsedMB=0 (mem.percent="0")
+	// - CF returned apps with ActualMB (2 apps × 2 instances × 512MB = 2048MB)
+	// - Handler calculated UsedMB = 2048MB / 2 cells = 1024MB per cell
+	expectedUsedMB := 1024
+	for _, cell := range resp.Cells {
+		if cell.UsedMB != expectedUsedMB {
+			t.Errorf("Expected UsedMB=%d (calculated from app memory), got %d for cell %s",
+				expectedUsedMB, cell.UsedMB, cell.Name)
+		}
+		if cell.IsolationSegment != "default" {
+			t.Errorf("Expected IsolationSegment='default', got '%s' for cell %s",
+				cell.IsolationSegment, cell.Name)
+		}
+	}
+}
+
+func TestAnalyzeBottleneck(t *testing.T) {
+	cfg := &config.Config{}
+	c := cache.New(5 * time.Minute)
+	handler := NewHandler(cfg, c)
+
+	// First load manual infrastructure with high memory utilization
+	manualBody := `{
+		"name": "Bottleneck Test",
+		"clusters": [{
+			"name": "cluster-01",
+			"host_count": 4,
+			"memory_gb_per_host": 1024,
+			"cpu_threads_per_host": 64,
+			"diego_cell_count": 100,
+			"diego_cell_memory_gb": 32,
+			"diego_cell_cpu": 4,
+			"diego_cell_disk_gb": 100
+		}],
+		"total_app_memory_gb": 2800,
+		"total_app_disk_gb": 4000
+	}`
+
+	req1 := httptest.NewRequest("POST", "/api/infrastructure/manual", strings.NewReader(manualBody))
+	req1.Header.Set("Content-Type", "application/json")
+	w1 := httptest.NewRecorder()
+	handler.SetManualInfrastructure(w1, req1)
+
+	if w1.Code != http.StatusOK {
+		t.Fatalf("Failed to set manual infrastructure: %s", w1.Body.String())
+	}
+
+	// Now get bottleneck analysis
+	req2 := httptest.NewRequest("GET", "/api/bottleneck", nil)
+	w2 := httptest.NewRecorder()
+	handler.AnalyzeBottleneck(w2, req2)
+
+	if w2.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d: %s", w2.Code, w2.Body.String())
+	}
+
+	var analysis models.BottleneckAnalysis
+	if err := json.NewDecoder(w2.Body).Decode(&analysis); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if len(analysis.Resources) == 0 {
+		t.Error("Expected resources in bottleneck analysis")
+	}
+
+	if analysis.ConstrainingResource == "" {
+		t.Error("Expected a constraining resource")
+	}
+
+	if analysis.Summary == "" {
+		t.Error("Expected a summary in bottleneck analysis")
+	}
+}
+
+func TestAnalyzeBottleneck_NoData(t *testing.T) {
+	cfg := &config.Config{}
+	c := cache.New(5 * time.Minute)
+	handler := NewHandler(cfg, c)
+
+	req := httptest.NewRequest("GET", "/api/bottleneck", nil)
+	w := httptest.NewRecorder()
+	handler.AnalyzeBottleneck(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func TestAnalyzeManualBottleneck(t *testing.T) {
+	cfg := &config.Config{}
+	c := cache.New(5 * time.Minute)
+	handler := NewHandler(cfg, c)
+
+	body := `{
+		"name": "Stateless Test",
+		"clusters": [{
+			"name": "cluster-01",
+			"host_count": 4,
+			"memory_gb_per_host": 1024,
+			"cpu_threads_per_host": 64,
+			"diego_cell_count": 100,
+			"diego_cell_memory_gb": 32,
+			"diego_cell_cpu": 4,
+			"diego_cell_disk_gb": 100
+		}],
+		"total_app_memory_gb": 2800,
+		"total_app_disk_gb": 4000
+	}`
+
+	req := httptest.NewRequest("POST", "/api/v1/bottleneck", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.AnalyzeManualBottleneck(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var analysis models.BottleneckAnalysis
+	if err := json.NewDecoder(w.Body).Decode(&analysis); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(analysis.Resources) == 0 {
+		t.Error("Expected resources in bottleneck analysis")
+	}
+	if analysis.ConstrainingResource == "" {
+		t.Error("Expected a constraining resource")
+	}
+
+	// Stateless: submitted input must not become the stored infrastructure state
+	handler.infraMutex.RLock()
+	stored := handler.infrastructureState
+	handler.infraMutex.RUnlock()
+	if stored != nil {
+		t.Error("Expected infrastructure state to remain unset after stateless analysis")
+	}
+}
+
+func TestAnalyzeManualBottleneck_InvalidJSON(t *testing.T) {
+	handler := NewHandler(&config.Config{}, cache.New(5*time.Minute))
+
+	req := httptest.NewRequest("POST", "/api/v1/bottleneck", strings.NewReader("{not json"))
+	w := httptest.NewRecorder()
+	handler.AnalyzeManualBottleneck(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func TestAnalyzeManualBottleneck_Rank(t *testing.T) {
+	// Memory 2800/3200 GB = 87.5% against a 95% ceiling; disk 4000/10000 GB = 40% against 45%
+	cfg := &config.Config{Thresholds: models.Thresholds{MemoryCeilingPct: 95, DiskCeilingPct: 45}}
+	handler := NewHandler(cfg, cache.New(5*time.Minute))
+
+	body := `{
+		"name": "Rank Test",
+		"clusters": [{
+			"name": "cluster-01",
+			"host_count": 4,
+			"memory_gb_per_host": 1024,
+			"cpu_threads_per_host": 64,
+			"diego_cell_count": 100,
+			"diego_cell_memory_gb": 32,
+			"diego_cell_cpu": 4,
+			"diego_cell_disk_gb": 100
+		}],
+		"total_app_memory_gb": 2800,
+		"total_app_disk_gb": 4000
+	}`
+
+	tests := []struct {
+		query            string
+		wantStatus       int
+		wantRanking      string
+		wantConstraining string
+	}{
+		{"", http.StatusOK, models.RankByUtilization, "Memory"},
+		{"?rank=utilization", http.StatusOK, models.RankByUtilization, "Memory"},
+		{"?rank=headroom", http.StatusOK, models.RankByHeadroom, "Disk"},
+		{"?rank=cost", http.StatusBadRequest, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/v1/bottleneck"+tt.query, strings.NewReader(body))
+			w := httptest.NewRecorder()
+			handler.AnalyzeManualBottleneck(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var analysis models.BottleneckAnalysis
+			if err := json.NewDecoder(w.Body).Decode(&analysis); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if analysis.Ranking != tt.wantRanking {
+				t.Errorf("Ranking = %q, want %q", analysis.Ranking, tt.wantRanking)
+			}
+			if analysis.ConstrainingResource != tt.wantConstraining {
+				t.Errorf("ConstrainingResource = %q, want %q", analysis.ConstrainingResource, tt.wantConstraining)
+			}
+			if !strings.HasPrefix(analysis.Summary, tt.wantConstraining+" is your constraint") {
+				t.Errorf("Summary should name %s, got %q", tt.wantConstraining, analysis.Summary)
+			}
+		})
 	}
+}
 
-	// Verify the needsAppCalculation code path was exercised:
-	// - BOSH returned cells with UsedMB=0 (mem.percent="0")
-	// - CF returned apps with ActualMB (2 apps × 2 instances × 512MB = 2048MB)
-	// - Handler calculated UsedMB = 2048MB / 2 cells = 1024MB per cell
-	expectedUsedMB := 1024
-	for _, cell := range resp.Cells {
-		if cell.UsedMB != expectedUsedMB {
-			t.Errorf("Expected UsedMB=%d (calculated from app memory), got %d for cell %s",
-				expectedUsedMB, cell.UsedMB, cell.Name)
+func TestGenerateManualRecommendations(t *testing.T) {
+	handler := NewHandler(&config.Config{}, cache.New(5*time.Minute))
+
+	body := `{
+		"name": "Stateless Test",
+		"clusters": [{
+			"name": "cluster-01",
+			"host_count": 4,
+			"memory_gb_per_host": 1024,
+			"cpu_threads_per_host": 64,
+			"diego_cell_count": 100,
+			"diego_cell_memory_gb": 32,
+			"diego_cell_cpu": 4,
+			"diego_cell_disk_gb": 100
+		}],
+		"total_app_memory_gb": 2800,
+		"total_app_disk_gb": 4000
+	}`
+
+	req := httptest.NewRequest("POST", "/api/v1/recommendations", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.GenerateManualRecommendations(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response models.RecommendationsResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.ConstrainingResource == "" {
+		t.Error("Expected a constraining resource")
+	}
+	if len(response.Recommendations) == 0 {
+		t.Fatal("Expected recommendations for a constrained foundation")
+	}
+	for i, rec := range response.Recommendations {
+		if rec.Priority != i+1 {
+			t.Errorf("Expected recommendation %d to have priority %d, got %d", i, i+1, rec.Priority)
 		}
-		if cell.IsolationSegment != "default" {
-			t.Errorf("Expected IsolationSegment='default', got '%s' for cell %s",
-				cell.IsolationSegment, cell.Name)
+	}
+
+	// Stateless: submitted input must not become the stored infrastructure state
+	handler.infraMutex.RLock()
+	stored := handler.infrastructureState
+	handler.infraMutex.RUnlock()
+	if stored != nil {
+		t.Error("Expected infrastructure state to remain unset after stateless recommendations")
+	}
+}
+
+func TestGenerateManualRecommendations_InvalidInput(t *testing.T) {
+	handler := NewHandler(&config.Config{}, cache.New(5*time.Minute))
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"invalid JSON", "{not json"},
+		{"fails validation", `{"name": "Bad", "clusters": [{"name": "c1", "host_count": 4, "memory_gb_per_host": -1}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/v1/recommendations", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			handler.GenerateManualRecommendations(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestDiffInfrastructure(t *testing.T) {
+	handler := NewHandler(&config.Config{}, cache.New(5*time.Minute))
+
+	body := `{
+		"old": {
+			"name": "prod",
+			"clusters": [{"name": "cluster-01", "host_count": 4, "diego_cell_count": 20, "diego_cell_memory_gb": 32, "diego_cell_cpu": 4}],
+			"total_host_count": 4,
+			"total_cell_count": 20
+		},
+		"new": {
+			"name": "prod",
+			"clusters": [
+				{"name": "cluster-01", "host_count": 6, "diego_cell_count": 20, "diego_cell_memory_gb": 32, "diego_cell_cpu": 4},
+				{"name": "cluster-02", "host_count": 2, "diego_cell_count": 8, "diego_cell_memory_gb": 64, "diego_cell_cpu": 8}
+			],
+			"total_host_count": 8,
+			"total_cell_count": 28
+		}
+	}`
+
+	req := httptest.NewRequest("POST", "/api/v1/infrastructure/diff", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.DiffInfrastructure(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var diff models.StateDiff
+	if err := json.NewDecoder(w.Body).Decode(&diff); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if !diff.Changed || diff.HostCountDelta != 4 || diff.CellCountDelta != 8 {
+		t.Errorf("Diff = changed %v, hosts %+d, cells %+d; want changed, +4, +8",
+			diff.Changed, diff.HostCountDelta, diff.CellCountDelta)
+	}
+	if len(diff.AddedClusters) != 1 || diff.AddedClusters[0] != "cluster-02" {
+		t.Errorf("AddedClusters = %v, want [cluster-02]", diff.AddedClusters)
+	}
+
+	// Stateless: neither state becomes the stored infrastructure state
+	handler.infraMutex.RLock()
+	stored := handler.infrastructureState
+	handler.infraMutex.RUnlock()
+	if stored != nil {
+		t.Error("Expected infrastructure state to remain unset after diff")
+	}
+}
+
+func TestDiffInfrastructure_BadRequest(t *testing.T) {
+	handler := NewHandler(&config.Config{}, cache.New(5*time.Minute))
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"invalid JSON", "{not json"},
+		{"missing new state", `{"old": {"name": "prod"}}`},
+		{"empty body", `{}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/v1/infrastructure/diff", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			handler.DiffInfrastructure(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d", w.Code)
+			}
+		})
+	}
+}
+
+func TestCheckThresholds(t *testing.T) {
+	handler := NewHandler(&config.Config{}, cache.New(5*time.Minute))
+
+	input := `{
+		"name": "Check Test",
+		"clusters": [{
+			"name": "cluster-01",
+			"host_count": 4,
+			"memory_gb_per_host": 1024,
+			"cpu_threads_per_host": 64,
+			"diego_cell_count": 50,
+			"diego_cell_memory_gb": 32,
+			"diego_cell_cpu": 4,
+			"diego_cell_disk_gb": 100
+		}],
+		"total_app_memory_gb": 1000,
+		"total_app_disk_gb": 1000
+	}`
+
+	tests := []struct {
+		name       string
+		thresholds string
+		expected   string
+	}{
+		{"Default thresholds pass", `{}`, "passed"},
+		{"Free chunks warning", `{"free_chunks_critical": 100}`, "warning"},
+		{"N-1 critical", `{"n1_critical_pct": 1}`, "critical"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `{"input": ` + input + `, "thresholds": ` + tt.thresholds + `}`
+			req := httptest.NewRequest("POST", "/api/v1/check", strings.NewReader(body))
+			w := httptest.NewRecorder()
+			handler.CheckThresholds(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+			}
+
+			var resp models.CheckResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if resp.Status != tt.expected {
+				t.Errorf("Expected status %q, got %q (warnings: %+v)", tt.expected, resp.Status, resp.Warnings)
+			}
+			if resp.Thresholds.N1CriticalPct == 0 {
+				t.Error("Expected effective thresholds in response")
+			}
+			if len(resp.Infrastructure.Clusters) != 1 || resp.Infrastructure.Clusters[0].DiegoCellCount != 50 {
+				t.Errorf("Expected the computed infrastructure state in response, got %+v", resp.Infrastructure)
+			}
+		})
+	}
+
+	handler.infraMutex.RLock()
+	stored := handler.infrastructureState
+	handler.infraMutex.RUnlock()
+	if stored != nil {
+		t.Error("Expected check to leave stored infrastructure state unset")
+	}
+}
+
+func TestCheckThresholds_InvalidInput(t *testing.T) {
+	handler := NewHandler(&config.Config{}, cache.New(5*time.Minute))
+
+	for _, body := range []string{"{not json", `{"input": {"clusters": []}}`} {
+		req := httptest.NewRequest("POST", "/api/v1/check", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.CheckThresholds(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %q, got %d", body, w.Code)
+		}
+	}
+}
+
+func TestCheckThresholds_ValidationErrors(t *testing.T) {
+	handler := NewHandler(&config.Config{}, cache.New(5*time.Minute))
+
+	body := `{"input": {"name": "bad", "clusters": [{"name": "c1", "host_count": 0, "memory_gb_per_host": 512, "diego_cell_count": 4, "diego_cell_memory_gb": -32}]}}`
+	req := httptest.NewRequest("POST", "/api/v1/check", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	handler.CheckThresholds(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp models.ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	fields := make(map[string]bool)
+	for _, e := range resp.Errors {
+		fields[e.Field] = true
+	}
+	for _, want := range []string{"clusters[0].host_count", "clusters[0].diego_cell_memory_gb"} {
+		if !fields[want] {
+			t.Errorf("Expected a validation error for %s, got %+v", want, resp.Errors)
 		}
 	}
 }
 
-func TestAnalyzeBottleneck(t *testing.T) {
-	cfg := &config.Config{}
-	c := cache.New(5 * time.Minute)
-	handler := NewHandler(cfg, c)
+func TestGetMaxCells(t *testing.T) {
+	handler := NewHandler(&config.Config{}, cache.New(5*time.Minute))
+	handler.infrastructureState = &models.InfrastructureState{
+		TotalN1MemoryGB: 3072,
+		PlatformVMsGB:   200,
+		TotalCPUCores:   256,
+		TotalCellCount:  20,
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/planning/max-cells?memory=64&cpu=8&target=85", nil)
+	w := httptest.NewRecorder()
+	handler.GetMaxCells(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var result models.MaxCellsResult
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if result.MaxCellsByMemory != 37 {
+		t.Errorf("Expected 37 cells by memory, got %d", result.MaxCellsByMemory)
+	}
+	if result.MaxCellsByCPU != 128 {
+		t.Errorf("Expected 128 cells by CPU, got %d", result.MaxCellsByCPU)
+	}
+	if result.LimitingResource != "memory" {
+		t.Errorf("Expected memory to be limiting, got %q", result.LimitingResource)
+	}
+	if result.AdditionalCells != 17 {
+		t.Errorf("Expected 17 additional cells, got %d", result.AdditionalCells)
+	}
+}
+
+func TestGetMaxCells_BadRequest(t *testing.T) {
+	handler := NewHandler(&config.Config{}, cache.New(5*time.Minute))
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"missing memory", "cpu=8"},
+		{"invalid cpu", "memory=64&cpu=abc"},
+		{"target out of range", "memory=64&cpu=8&target=150"},
+		{"no infrastructure data", "memory=64&cpu=8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/v1/planning/max-cells?"+tt.query, nil)
+			w := httptest.NewRecorder()
+			handler.GetMaxCells(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d", w.Code)
+			}
+		})
+	}
+}
+
+func TestParseBOSHDump(t *testing.T) {
+	handler := NewHandler(&config.Config{}, cache.New(5*time.Minute))
+
+	body := `{"Tables":[{"Content":"vms","Rows":[
+		{"instance":"diego_cell/aaa","memory_usage":"50% (8.6 GB)","cpu_sys":"4.1%","ephemeral_disk_usage":"64% (3i%)"},
+		{"instance":"router/bbb","memory_usage":"30% (1.2 GB)"}
+	]}]}`
+	req := httptest.NewRequest("POST", "/api/v1/infrastructure/from-bosh-dump", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	handler.ParseBOSHDump(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp models.BOSHDumpResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(resp.Cells) != 1 || resp.Cells[0].Name != "diego_cell/aaa" || resp.Cells[0].EphemeralDiskPercent != 64 {
+		t.Errorf("Unexpected cells: %+v", resp.Cells)
+	}
+	if handler.CurrentInfrastructureState() != nil {
+		t.Error("Parsing a dump must not store infrastructure state")
+	}
+}
+
+func TestParseBOSHDump_BadRequest(t *testing.T) {
+	handler := NewHandler(&config.Config{}, cache.New(5*time.Minute))
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"table text instead of JSON", "Instance  Process State  AZ"},
+		{"no Diego cells", `{"Tables":[{"Rows":[{"instance":"router/aaa"}]}]}`},
+		{"too large", `{"Tables":[{"Rows":[{"instance":"` + strings.Repeat("x", maxBOSHDumpBodySize) + `"}]}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/v1/infrastructure/from-bosh-dump", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			handler.ParseBOSHDump(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d", w.Code)
+			}
+		})
+	}
+}
+
+func TestParseOpsManagerConfig(t *testing.T) {
+	handler := NewHandler(&config.Config{}, cache.New(5*time.Minute))
+
+	body := `{
+		"product_config": {
+			"product-name": "cf",
+			"resource-config": {
+				"diego_cell": {"instances": 12, "instance_type": {"id": "xlarge.disk"}},
+				"router": {"instances": 3, "instance_type": {"id": "micro"}}
+			}
+		},
+		"vm_types": [
+			{"name": "micro", "ram": 1024, "cpu": 1, "ephemeral_disk": 8192},
+			{"name": "xlarge.disk", "ram": 32768, "cpu": 4, "ephemeral_disk": 131072}
+		]
+	}`
+	req := httptest.NewRequest("POST", "/api/v1/infrastructure/from-om", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	handler.ParseOpsManagerConfig(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var input models.ManualInput
+	if err := json.NewDecoder(w.Body).Decode(&input); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	want := models.ClusterInput{Name: "diego_cell", DiegoCellCount: 12, DiegoCellMemoryGB: 32, DiegoCellCPU: 4, DiegoCellDiskGB: 128}
+	if input.Name != "cf" || len(input.Clusters) != 1 || input.Clusters[0] != want {
+		t.Errorf("Unexpected input: %+v", input)
+	}
+	if handler.CurrentInfrastructureState() != nil {
+		t.Error("Parsing Ops Manager config must not store infrastructure state")
+	}
+}
+
+func TestParseOpsManagerConfig_BadRequest(t *testing.T) {
+	handler := NewHandler(&config.Config{}, cache.New(5*time.Minute))
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"invalid JSON", "resource-config:"},
+		{"missing resource-config", `{"product_config": {"product-name": "cf"}}`},
+		{"unknown VM type", `{"product_config": {"resource-config": {"diego_cell": {"instances": 3, "instance_type": {"id": "huge"}}}}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/v1/infrastructure/from-om", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			handler.ParseOpsManagerConfig(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d", w.Code)
+			}
+		})
+	}
+}
+
+func TestGetForecast(t *testing.T) {
+	handler := NewHandler(&config.Config{}, cache.New(5*time.Minute))
+	handler.infrastructureState = &models.InfrastructureState{
+		TotalCellMemoryGB: 1000,
+		TotalAppMemoryGB:  500,
+	}
 
-	// First load manual infrastructure with high memory utilization
-	manualBody := `{
-		"name": "Bottleneck Test",
-		"clusters": [{
-			"name": "cluster-01",
-			"host_count": 4,
-			"memory_gb_per_host": 1024,
-			"cpu_threads_per_host": 64,
-			"diego_cell_count": 100,
-			"diego_cell_memory_gb": 32,
-			"diego_cell_cpu": 4,
-			"diego_cell_disk_gb": 100
-		}],
-		"total_app_memory_gb": 2800,
-		"total_app_disk_gb": 4000
-	}`
+	req := httptest.NewRequest("GET", "/api/v1/planning/forecast?growth=5", nil)
+	w := httptest.NewRecorder()
+	handler.GetForecast(w, req)
 
-	req1 := httptest.NewRequest("POST", "/api/infrastructure/manual", strings.NewReader(manualBody))
-	req1.Header.Set("Content-Type", "application/json")
-	w1 := httptest.NewRecorder()
-	handler.SetManualInfrastructure(w1, req1)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
 
-	if w1.Code != http.StatusOK {
-		t.Fatalf("Failed to set manual infrastructure: %s", w1.Body.String())
+	var result models.CapacityForecast
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if result.MonthlyGrowthPct != 5 || result.ConstrainingResource != "Memory" {
+		t.Errorf("Unexpected forecast: %+v", result)
+	}
+	if len(result.Resources) != 1 || result.Resources[0].MonthsToWarning == nil || *result.Resources[0].MonthsToWarning != 11 {
+		t.Errorf("Expected Memory to reach 85%% in 11 months, got %+v", result.Resources)
 	}
+}
 
-	// Now get bottleneck analysis
-	req2 := httptest.NewRequest("GET", "/api/bottleneck", nil)
-	w2 := httptest.NewRecorder()
-	handler.AnalyzeBottleneck(w2, req2)
+func TestGetForecast_BadRequest(t *testing.T) {
+	handler := NewHandler(&config.Config{}, cache.New(5*time.Minute))
 
-	if w2.Code != http.StatusOK {
-		t.Errorf("Expected status 200, got %d: %s", w2.Code, w2.Body.String())
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"missing growth", ""},
+		{"invalid growth", "growth=abc"},
+		{"zero growth", "growth=0"},
+		{"growth out of range", "growth=150"},
+		{"no infrastructure data", "growth=5"},
 	}
 
-	var analysis models.BottleneckAnalysis
-	if err := json.NewDecoder(w2.Body).Decode(&analysis); err != nil {
-		t.Fatalf("Failed to decode response: %v", err)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/v1/planning/forecast?"+tt.query, nil)
+			w := httptest.NewRecorder()
+			handler.GetForecast(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d", w.Code)
+			}
+		})
 	}
+}
 
-	if len(analysis.Resources) == 0 {
-		t.Error("Expected resources in bottleneck analysis")
+func TestGetFaultImpactSizing(t *testing.T) {
+	handler := NewHandler(&config.Config{}, cache.New(5*time.Minute))
+	handler.infrastructureState = &models.InfrastructureState{
+		TotalCellCount:    10,
+		TotalCellMemoryGB: 640,
+		TotalVCPUs:        80,
+		TotalAppInstances: 500,
 	}
 
-	if analysis.ConstrainingResource == "" {
-		t.Error("Expected a constraining resource")
+	req := httptest.NewRequest("GET", "/api/v1/planning/for-fault-impact?max=20", nil)
+	w := httptest.NewRecorder()
+	handler.GetFaultImpactSizing(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
 	}
 
-	if analysis.Summary == "" {
-		t.Error("Expected a summary in bottleneck analysis")
+	var result models.FaultImpactSizing
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if result.RecommendedCellCount != 25 || result.RecommendedCellMemoryGB != 26 || result.TargetMet {
+		t.Errorf("Unexpected sizing: %+v", result)
 	}
 }
 
-func TestAnalyzeBottleneck_NoData(t *testing.T) {
-	cfg := &config.Config{}
-	c := cache.New(5 * time.Minute)
-	handler := NewHandler(cfg, c)
+func TestGetFaultImpactSizing_BadRequest(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		state *models.InfrastructureState
+	}{
+		{"missing max", "", nil},
+		{"invalid max", "max=abc", nil},
+		{"zero max", "max=0", nil},
+		{"no infrastructure data", "max=20", nil},
+		{"no app instances", "max=20", &models.InfrastructureState{TotalCellCount: 10, TotalCellMemoryGB: 640}},
+	}
 
-	req := httptest.NewRequest("GET", "/api/bottleneck", nil)
-	w := httptest.NewRecorder()
-	handler.AnalyzeBottleneck(w, req)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewHandler(&config.Config{}, cache.New(5*time.Minute))
+			handler.infrastructureState = tt.state
 
-	if w.Code != http.StatusBadRequest {
-		t.Errorf("Expected status 400, got %d", w.Code)
+			req := httptest.NewRequest("GET", "/api/v1/planning/for-fault-impact?"+tt.query, nil)
+			w := httptest.NewRecorder()
+			handler.GetFaultImpactSizing(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d", w.Code)
+			}
+		})
 	}
 }
 
@@ -1413,6 +3203,60 @@ func TestGetRecommendations_NoData(t *testing.T) {
 	}
 }
 
+func TestHandleInfrastructureClusters(t *testing.T) {
+	handler := NewHandler(&config.Config{}, cache.New(5*time.Minute))
+
+	w := httptest.NewRecorder()
+	handler.GetInfrastructureClusters(w, httptest.NewRequest("GET", "/api/v1/infrastructure/clusters", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 without infrastructure data, got %d", w.Code)
+	}
+
+	manualBody := `{
+		"name": "Test Env",
+		"clusters": [
+			{"name": "cluster-01", "host_count": 4, "memory_gb_per_host": 1024, "cpu_threads_per_host": 64,
+			 "diego_cell_count": 64, "diego_cell_memory_gb": 32, "diego_cell_cpu": 4},
+			{"name": "cluster-02", "host_count": 2, "memory_gb_per_host": 512, "cpu_threads_per_host": 32,
+			 "diego_cell_count": 8, "diego_cell_memory_gb": 32, "diego_cell_cpu": 4}
+		]
+	}`
+	w = httptest.NewRecorder()
+	handler.SetManualInfrastructure(w, httptest.NewRequest("POST", "/api/v1/infrastructure/manual", strings.NewReader(manualBody)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Failed to set manual infrastructure: %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	handler.GetInfrastructureClusters(w, httptest.NewRequest("GET", "/api/v1/infrastructure/clusters", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp models.ClusterList
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Source != "manual" || resp.Name != "Test Env" {
+		t.Errorf("Expected manual source named Test Env, got %q %q", resp.Source, resp.Name)
+	}
+	if len(resp.Clusters) != 2 {
+		t.Fatalf("Expected 2 clusters, got %d", len(resp.Clusters))
+	}
+	first := resp.Clusters[0]
+	if first.Name != "cluster-01" || first.HostCount != 4 || first.DiegoCellCount != 64 {
+		t.Errorf("Unexpected first cluster: %+v", first)
+	}
+	// 64 cells x 32 GB on 4 x 1024 GB hosts
+	if first.TotalCellMemoryGB != 2048 || first.HostMemoryUtilizationPercent != 50 {
+		t.Errorf("Expected 2048 GB of cells at 50%% host memory, got %d GB at %.1f%%",
+			first.TotalCellMemoryGB, first.HostMemoryUtilizationPercent)
+	}
+	if resp.Clusters[1].Name != "cluster-02" || resp.Clusters[1].HostMemoryUtilizationPercent != 25 {
+		t.Errorf("Unexpected second cluster: %+v", resp.Clusters[1])
+	}
+}
+
 func TestHandleInfrastructureStatus_WithBottleneck(t *testing.T) {
 	cfg := &config.Config{}
 	c := cache.New(5 * time.Minute)
@@ -1681,6 +3525,67 @@ func TestCompareScenario_WithRecommendations(t *testing.T) {
 	}
 }
 
+func TestCompareScenario_MaxFaultImpact(t *testing.T) {
+	handler := NewHandler(&config.Config{}, cache.New(5*time.Minute))
+
+	manualBody := `{
+		"name": "Fault Impact Test",
+		"clusters": [{
+			"name": "cluster-01",
+			"host_count": 4,
+			"memory_gb_per_host": 1024,
+			"cpu_threads_per_host": 64,
+			"diego_cell_count": 10,
+			"diego_cell_memory_gb": 64,
+			"diego_cell_cpu": 8
+		}],
+		"total_app_memory_gb": 300,
+		"total_app_instances": 500
+	}`
+
+	req1 := httptest.NewRequest("POST", "/api/infrastructure/manual", strings.NewReader(manualBody))
+	req1.Header.Set("Content-Type", "application/json")
+	w1 := httptest.NewRecorder()
+	handler.SetManualInfrastructure(w1, req1)
+	if w1.Code != http.StatusOK {
+		t.Fatalf("Failed to set manual infrastructure: %s", w1.Body.String())
+	}
+
+	compareBody := `{
+		"proposed_cell_memory_gb": 64,
+		"proposed_cell_cpu": 8,
+		"proposed_cell_count": 10,
+		"max_fault_impact": 20
+	}`
+
+	req2 := httptest.NewRequest("POST", "/api/scenario/compare", strings.NewReader(compareBody))
+	req2.Header.Set("Content-Type", "application/json")
+	w2 := httptest.NewRecorder()
+	handler.CompareScenario(w2, req2)
+
+	if w2.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w2.Code, w2.Body.String())
+	}
+
+	var comparison models.ScenarioComparison
+	if err := json.NewDecoder(w2.Body).Decode(&comparison); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	var found bool
+	for _, rec := range comparison.Recommendations {
+		if rec.Type == models.RecommendationResizeForFaultImpact {
+			found = true
+			if rec.CellsToAdd != 15 {
+				t.Errorf("Expected 15 cells to add, got %d", rec.CellsToAdd)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("Expected a resize_for_fault_impact recommendation, got %+v", comparison.Recommendations)
+	}
+}
+
 func TestHandleInfrastructureApps(t *testing.T) {
 	cfServer, uaaServer := setupMockCFServerWithApps()
 	defer cfServer.Close()
@@ -1836,6 +3741,42 @@ func TestHandler_writeErrorWithDetails(t *testing.T) {
 	}
 }
 
+func TestHandler_Schemas(t *testing.T) {
+	h := &Handler{}
+
+	tests := []struct {
+		path    string
+		handler http.HandlerFunc
+		title   string
+	}{
+		{"/api/v1/schema/manual-input", h.ManualInputSchema, "ManualInput"},
+		{"/api/v1/schema/infrastructure-state", h.InfrastructureStateSchema, "InfrastructureState"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			rec := httptest.NewRecorder()
+			tt.handler(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("Status = %d, want %d", rec.Code, http.StatusOK)
+			}
+
+			var schema map[string]interface{}
+			if err := json.NewDecoder(rec.Body).Decode(&schema); err != nil {
+				t.Fatalf("Failed to decode schema: %v", err)
+			}
+			if schema["$schema"] != models.JSONSchemaDialect || schema["title"] != tt.title {
+				t.Errorf("Unexpected schema header: $schema=%v title=%v", schema["$schema"], schema["title"])
+			}
+			if _, ok := schema["properties"].(map[string]interface{})["clusters"]; !ok {
+				t.Error("Expected a clusters property")
+			}
+		})
+	}
+}
+
 func TestHandler_OpenAPISpec(t *testing.T) {
 	h := &Handler{}
 	req := httptest.NewRequest("GET", "/api/v1/openapi.yaml", nil)
@@ -1916,6 +3857,62 @@ func TestSetInfrastructureState_RejectsOversizedBody(t *testing.T) {
 	}
 }
 
+func TestSetInfrastructureState_ExplicitFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		wantCode int
+	}{
+		{"infrastructure_state accepted", models.FormatInfrastructureState, http.StatusOK},
+		{"manual_input rejected", models.FormatManualInput, http.StatusBadRequest},
+		{"unknown rejected", "csv", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewHandler(&config.Config{}, cache.New(5*time.Minute))
+			body := `{"format":"` + tt.format + `","name":"Test","source":"manual","clusters":[{"name":"c1","memory_gb":1024}]}`
+
+			req := httptest.NewRequest("POST", "/api/v1/infrastructure/state", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			handler.SetInfrastructureState(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantCode, w.Code, w.Body.String())
+			}
+			if stored := handler.infrastructureState != nil; stored != (tt.wantCode == http.StatusOK) {
+				t.Errorf("Expected state stored = %v", tt.wantCode == http.StatusOK)
+			}
+		})
+	}
+}
+
+func TestSetManualInfrastructure_RejectsInfrastructureStateFormat(t *testing.T) {
+	handler := NewHandler(&config.Config{}, cache.New(5*time.Minute))
+	body := `{
+		"format": "infrastructure_state",
+		"name": "Test",
+		"clusters": [{"name": "c1", "host_count": 4, "memory_gb_per_host": 512, "diego_cell_count": 10, "diego_cell_memory_gb": 32}]
+	}`
+
+	req := httptest.NewRequest("POST", "/api/v1/infrastructure/manual", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.SetManualInfrastructure(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp models.ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(resp.Errors) != 1 || resp.Errors[0].Field != "format" {
+		t.Errorf("Expected one error on format, got %+v", resp.Errors)
+	}
+}
+
 func TestPlanInfrastructure_RejectsOversizedBody(t *testing.T) {
 	cfg := &config.Config{}
 	c := cache.New(5 * time.Minute)
@@ -2267,3 +4264,151 @@ func TestEnrichWithCFAppData_MaxInstanceMemory(t *testing.T) {
 		t.Errorf("Expected TotalAppInstances=%d, got %d", expectedInstances, state.TotalAppInstances)
 	}
 }
+
+func TestHandlerClose_DisconnectsVSphere(t *testing.T) {
+	model := simulator.VPX()
+	if err := model.Create(); err != nil {
+		t.Fatalf("Failed to create vSphere simulator: %v", err)
+	}
+	server := model.Service.NewServer()
+	defer model.Remove()
+	defer server.Close()
+
+	password, _ := server.URL.User.Password()
+	handler := NewHandler(&config.Config{
+		VSphereHost:       server.URL.Scheme + "://" + server.URL.Host,
+		VSphereUsername:   server.URL.User.Username(),
+		VSpherePassword:   password,
+		VSphereDatacenter: "DC0",
+	}, cache.New(5*time.Minute))
+
+	if err := handler.vsphereClient.Connect(context.Background()); err != nil {
+		t.Fatalf("Failed to connect to simulator: %v", err)
+	}
+	if err := handler.Close(context.Background()); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	if handler.vsphereClient.IsConnected() {
+		t.Error("Expected vSphere session to be logged out after Close")
+	}
+
+	// Closing again, or with no vSphere configured, is a no-op
+	if err := handler.Close(context.Background()); err != nil {
+		t.Errorf("Second Close returned error: %v", err)
+	}
+	if err := NewHandler(nil, nil).Close(context.Background()); err != nil {
+		t.Errorf("Close without vSphere returned error: %v", err)
+	}
+}
+
+func TestCompareScenario_Explain(t *testing.T) {
+	handler := NewHandler(&config.Config{}, cache.New(5*time.Minute))
+	manualBody := `{"name": "Test Env", "clusters": [{"name": "cluster-01", "host_count": 4, "memory_gb_per_host": 1024,
+		"diego_cell_count": 40, "diego_cell_memory_gb": 32, "diego_cell_cpu": 4}],
+		"total_app_memory_gb": 400, "total_app_instances": 2000}`
+	w1 := httptest.NewRecorder()
+	handler.SetManualInfrastructure(w1, httptest.NewRequest("POST", "/api/v1/infrastructure/manual", strings.NewReader(manualBody)))
+	if w1.Code != http.StatusOK {
+		t.Fatalf("Failed to set manual infrastructure: %s", w1.Body.String())
+	}
+
+	body := `{"proposed_cell_memory_gb": 64, "proposed_cell_cpu": 8, "proposed_cell_count": 24}`
+	for path, wantTrace := range map[string]bool{
+		"/api/v1/scenario/compare":              false,
+		"/api/v1/scenario/compare?explain=true": true,
+	} {
+		w := httptest.NewRecorder()
+		handler.CompareScenario(w, httptest.NewRequest("POST", path, strings.NewReader(body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d: %s", path, w.Code, w.Body.String())
+		}
+
+		var comparison models.ScenarioComparison
+		if err := json.NewDecoder(w.Body).Decode(&comparison); err != nil {
+			t.Fatalf("%s: failed to decode response: %v", path, err)
+		}
+		if got := comparison.CalculationTrace != nil; got != wantTrace {
+			t.Fatalf("%s: expected calculation trace %v, got %v", path, wantTrace, got)
+		}
+		if wantTrace && (len(comparison.CalculationTrace.Current) == 0 || len(comparison.CalculationTrace.Proposed) == 0) {
+			t.Errorf("%s: expected steps for both sides, got %+v", path, comparison.CalculationTrace)
+		}
+	}
+}
+
+func TestSetInfrastructureFromPrometheus(t *testing.T) {
+	prometheus := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer prom-token" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		values := map[string]string{
+			"sum(app_memory_bytes)": "4294967296", // 4 GiB
+			"sum(app_instances)":    "4",
+		}
+		value, ok := values[r.URL.Query().Get("query")]
+		if !ok {
+			http.Error(w, `{"status":"error","errorType":"bad_data","error":"unknown"}`, http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1700000000,"` + value + `"]}]}}`))
+	}))
+	defer prometheus.Close()
+
+	post := func(h *Handler) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.SetInfrastructureFromPrometheus(rr, httptest.NewRequest(http.MethodPost, "/api/v1/infrastructure/from-prometheus", nil))
+		return rr
+	}
+
+	if rr := post(NewHandler(&config.Config{}, cache.New(5*time.Minute))); rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without PROMETHEUS_URL, got %d", rr.Code)
+	}
+
+	cfg := &config.Config{
+		PrometheusURL:               prometheus.URL,
+		PrometheusBearerToken:       "prom-token",
+		PrometheusQueryTimeout:      5,
+		PrometheusAppMemoryQuery:    "sum(app_memory_bytes)",
+		PrometheusAppInstancesQuery: "sum(app_instances)",
+	}
+	h := NewHandler(cfg, cache.New(5*time.Minute))
+	if rr := post(h); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without infrastructure data, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	readAt := time.Now().Add(-time.Minute)
+	h.storeInfrastructureState(&models.InfrastructureState{Name: "env", Source: "vsphere", TotalCellCount: 10,
+		TotalAppMemoryGB: 999, TotalAppDiskGB: 50, AppDataTimestamp: &readAt})
+
+	rr := post(h)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var state models.InfrastructureState
+	if err := json.NewDecoder(rr.Body).Decode(&state); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if state.TotalAppMemoryGB != 4 || state.TotalAppInstances != 4 || state.AvgInstanceMemoryMB != 1024 {
+		t.Errorf("Unexpected app totals: memory=%d instances=%d avg=%d", state.TotalAppMemoryGB, state.TotalAppInstances, state.AvgInstanceMemoryMB)
+	}
+	if state.TotalAppDiskGB != 50 {
+		t.Errorf("Expected disk total kept without a disk query, got %d", state.TotalAppDiskGB)
+	}
+	if state.AppDataTimestamp != nil {
+		t.Error("Expected app_data_timestamp cleared so CF refresh keeps the Prometheus totals")
+	}
+	if state.Name != "env" || state.TotalCellCount != 10 {
+		t.Errorf("Expected non-app fields to be preserved, got name=%q cells=%d", state.Name, state.TotalCellCount)
+	}
+
+	// A failing query leaves the stored state alone
+	cfg.PrometheusAppInstancesQuery = "sum(unknown)"
+	h.storeInfrastructureState(&models.InfrastructureState{Name: "env", TotalAppMemoryGB: 999})
+	if rr := post(h); rr.Code != http.StatusBadGateway {
+		t.Errorf("Expected 502 when a query fails, got %d", rr.Code)
+	}
+	if stored := h.CurrentInfrastructureState(); stored.TotalAppMemoryGB != 999 {
+		t.Errorf("Expected stored state unchanged after a failed query, got %d GB", stored.TotalAppMemoryGB)
+	}
+}
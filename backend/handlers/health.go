@@ -1,25 +1,30 @@
 // ABOUTME: HTTP handlers for health and dashboard endpoints
-// ABOUTME: Provides API status and live dashboard data
+// ABOUTME: Provides API status, dependency readiness, and live dashboard data
 
 package handlers
 
 import (
+	"context"
 	"log/slog"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/markalston/diego-capacity-analyzer/backend/models"
+	"github.com/markalston/diego-capacity-analyzer/backend/version"
 )
 
 // Health returns API health status including CF, BOSH, and cache status.
 func (h *Handler) Health(w http.ResponseWriter, r *http.Request) {
+	_, appsCached := h.cachedAppData()
 	resp := map[string]interface{}{
 		"cf_api":        "ok",
 		"bosh_api":      "not_configured",
 		"ai_configured": h.chatProvider != nil,
+		"version":       version.Version,
 		"cache_status": map[string]bool{
-			"cells_cached": false,
-			"apps_cached":  false,
+			"cells_cached": h.areCellsCached(),
+			"apps_cached":  appsCached,
 		},
 	}
 
@@ -41,6 +46,91 @@ func (h *Handler) Health(w http.ResponseWriter, r *http.Request) {
 	h.writeJSON(w, http.StatusOK, resp)
 }
 
+// readinessProbeTimeout bounds each dependency probe so one hung backend
+// cannot stall the readiness check past typical orchestrator probe timeouts
+const readinessProbeTimeout = 5 * time.Second
+
+// Ready probes each configured backing system (CF API, BOSH Director, vCenter)
+// and returns per-dependency status. The response is 503 when any configured
+// dependency is unreachable; unconfigured ones are reported as "not_configured"
+// and do not affect readiness.
+func (h *Handler) Ready(w http.ResponseWriter, r *http.Request) {
+	probes := map[string]func(context.Context) error{}
+	if h.cfClient != nil {
+		probes["cf_api"] = h.cfClient.Ping
+	}
+	if h.boshClient != nil {
+		probes["bosh_api"] = h.boshClient.Ping
+	}
+	if h.vsphereClient != nil {
+		probes["vsphere"] = h.vsphereClient.Ping
+	}
+
+	resp := models.ReadinessResponse{
+		Status: "ready",
+		Dependencies: map[string]models.DependencyStatus{
+			"cf_api":   {Status: "not_configured"},
+			"bosh_api": {Status: "not_configured"},
+			"vsphere":  {Status: "not_configured"},
+		},
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, probe := range probes {
+		wg.Add(1)
+		go func(name string, probe func(context.Context) error) {
+			defer wg.Done()
+			status := h.runReadinessProbe(r.Context(), name, probe)
+			mu.Lock()
+			resp.Dependencies[name] = status
+			mu.Unlock()
+		}(name, probe)
+	}
+	wg.Wait()
+
+	code := http.StatusOK
+	for _, dep := range resp.Dependencies {
+		if dep.Status == "error" {
+			resp.Status = "not_ready"
+			code = http.StatusServiceUnavailable
+			break
+		}
+	}
+
+	h.writeJSON(w, code, resp)
+}
+
+// runReadinessProbe runs a single dependency probe under readinessProbeTimeout.
+// Failure details are logged rather than returned, since the endpoint is public.
+func (h *Handler) runReadinessProbe(ctx context.Context, name string, probe func(context.Context) error) models.DependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, readinessProbeTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := probe(ctx)
+	status := models.DependencyStatus{Status: "ok", LatencyMS: time.Since(start).Milliseconds()}
+	if err != nil {
+		slog.WarnContext(ctx, "Readiness probe failed", "dependency", name, "error", err)
+		status.Status = "error"
+		status.Error = "unreachable"
+	}
+	return status
+}
+
+// areCellsCached reports whether cached dashboard data holds Diego cells
+func (h *Handler) areCellsCached() bool {
+	if h.cache == nil {
+		return false
+	}
+	if cached, found := h.cache.Get("dashboard:all"); found {
+		if dashboard, ok := cached.(models.DashboardResponse); ok {
+			return len(dashboard.Cells) > 0
+		}
+	}
+	return false
+}
+
 // isLogCacheAvailable checks cached dashboard data for any app with actual
 // memory metrics. ActualMB > 0 indicates Log Cache was reachable when the
 // dashboard was built, since that field is populated from Log Cache envelope data.
@@ -64,13 +154,17 @@ func (h *Handler) isLogCacheAvailable() bool {
 func (h *Handler) Dashboard(w http.ResponseWriter, r *http.Request) {
 	// Check cache
 	if cached, found := h.cache.Get("dashboard:all"); found {
-		slog.Debug("Dashboard cache hit")
+		slog.DebugContext(r.Context(), "Dashboard cache hit")
+		if dashboard, ok := cached.(models.DashboardResponse); ok {
+			h.writeDashboard(w, r, dashboard)
+			return
+		}
 		h.writeJSON(w, http.StatusOK, cached)
 		return
 	}
 
 	// Fetch fresh data
-	slog.Debug("Dashboard cache miss, fetching fresh data")
+	slog.DebugContext(r.Context(), "Dashboard cache miss, fetching fresh data")
 
 	resp := models.DashboardResponse{
 		Cells:    []models.DiegoCell{},
@@ -86,7 +180,7 @@ func (h *Handler) Dashboard(w http.ResponseWriter, r *http.Request) {
 	// Authenticate with CF API
 	ctx := r.Context()
 	if err := h.cfClient.Authenticate(ctx); err != nil {
-		slog.Error("CF API authentication failed", "error", err)
+		slog.ErrorContext(r.Context(), "CF API authentication failed", "error", err)
 		h.writeError(w, "Authentication service temporarily unavailable", http.StatusInternalServerError)
 		return
 	}
@@ -94,7 +188,7 @@ func (h *Handler) Dashboard(w http.ResponseWriter, r *http.Request) {
 	// Fetch apps from CF API
 	apps, err := h.cfClient.GetApps(ctx)
 	if err != nil {
-		slog.Error("CF API GetApps failed", "error", err)
+		slog.ErrorContext(r.Context(), "CF API GetApps failed", "error", err)
 		h.writeError(w, "Failed to retrieve application data", http.StatusInternalServerError)
 		return
 	}
@@ -103,7 +197,7 @@ func (h *Handler) Dashboard(w http.ResponseWriter, r *http.Request) {
 	// Fetch isolation segments from CF API
 	segments, err := h.cfClient.GetIsolationSegments(ctx)
 	if err != nil {
-		slog.Error("CF API GetIsolationSegments failed", "error", err)
+		slog.ErrorContext(r.Context(), "CF API GetIsolationSegments failed", "error", err)
 		h.writeError(w, "Failed to retrieve isolation segment data", http.StatusInternalServerError)
 		return
 	}
@@ -111,14 +205,22 @@ func (h *Handler) Dashboard(w http.ResponseWriter, r *http.Request) {
 
 	// Fetch BOSH cells (optional, degraded mode if fails)
 	if h.boshClient != nil {
-		cells, err := h.boshClient.GetDiegoCells()
+		cells, err := h.boshClient.GetDiegoCells(ctx)
 		if err != nil {
-			slog.Warn("BOSH API error, entering degraded mode", "error", err)
+			slog.WarnContext(r.Context(), "BOSH API error, entering degraded mode", "error", err)
 			resp.Metadata.BOSHAvailable = false
 		} else {
 			resp.Cells = cells
 		}
 	}
+	for _, cell := range resp.Cells {
+		if cell.VitalsUnavailable {
+			resp.Metadata.VitalsUnavailableCells++
+		}
+	}
+	if n := resp.Metadata.VitalsUnavailableCells; n > 0 {
+		slog.WarnContext(r.Context(), "Diego cells reporting no BOSH vitals", "count", n)
+	}
 
 	// If BOSH didn't provide UsedMB (vitals unavailable), calculate from app metrics
 	needsAppCalculation := false
@@ -157,5 +259,14 @@ func (h *Handler) Dashboard(w http.ResponseWriter, r *http.Request) {
 	// Cache result with shorter TTL for live BOSH/CF data
 	h.cache.SetWithTTL("dashboard:all", resp, time.Duration(h.cfg.DashboardTTL)*time.Second)
 
+	h.writeDashboard(w, r, resp)
+}
+
+// writeDashboard writes resp as the response, with cell, app, and segment
+// names replaced by pseudonyms when the request asks for redact=true
+func (h *Handler) writeDashboard(w http.ResponseWriter, r *http.Request, resp models.DashboardResponse) {
+	if wantsRedacted(r) {
+		resp = models.NewRedactor().Dashboard(resp)
+	}
 	h.writeJSON(w, http.StatusOK, resp)
 }
@@ -10,14 +10,25 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/markalston/diego-capacity-analyzer/backend/audit"
 	"github.com/markalston/diego-capacity-analyzer/backend/models"
+	"github.com/markalston/diego-capacity-analyzer/backend/services"
+	"github.com/markalston/diego-capacity-analyzer/backend/units"
 )
 
 // maxRequestBodySize limits JSON request bodies to 1MB to prevent DOS attacks
 const maxRequestBodySize = 1 << 20 // 1MB
 
+// maxBOSHDumpBodySize allows `bosh vms --vitals --json` output for large
+// foundations, which runs to roughly 1KB per VM
+const maxBOSHDumpBodySize = 8 << 20 // 8MB
+
+// vsphereNotConfiguredMessage is the 503 error for vSphere endpoints without credentials
+const vsphereNotConfiguredMessage = "vSphere not configured. Set VSPHERE_HOST, VSPHERE_USERNAME, VSPHERE_PASSWORD, and VSPHERE_DATACENTER environment variables."
+
 // AppDetailsResponse contains per-app breakdown of memory, disk, and instances
 type AppDetailsResponse struct {
 	TotalAppMemoryGB  int          `json:"total_app_memory_gb"`
@@ -31,57 +42,129 @@ type AppDetailsResponse struct {
 func (h *Handler) GetInfrastructure(w http.ResponseWriter, r *http.Request) {
 	// Check if vSphere is configured
 	if h.vsphereClient == nil {
-		h.writeError(w, "vSphere not configured. Set VSPHERE_HOST, VSPHERE_USERNAME, VSPHERE_PASSWORD, and VSPHERE_DATACENTER environment variables.", http.StatusServiceUnavailable)
+		h.writeError(w, vsphereNotConfiguredMessage, http.StatusServiceUnavailable)
+		return
+	}
+
+	// Check cache first unless the caller explicitly asked for re-discovery
+	if state, ok := h.cachedVSphereState(r); ok {
+		h.writeInfrastructureState(w, r, state)
 		return
 	}
 
-	// Check cache first
-	cacheKey := "infrastructure:vsphere"
-	if cached, found := h.cache.Get(cacheKey); found {
-		slog.Debug("Infrastructure cache hit")
-		state := cached.(models.InfrastructureState)
-		state.Cached = true
-		h.writeJSON(w, http.StatusOK, state)
+	state, err := h.discoverVSphere(r.Context(), nil)
+	if err != nil {
+		var derr *discoveryError
+		if errors.As(err, &derr) {
+			h.writeError(w, derr.message, derr.status)
+			return
+		}
+		h.writeError(w, "Failed to retrieve infrastructure data", http.StatusInternalServerError)
 		return
 	}
 
+	h.writeInfrastructureState(w, r, state)
+}
+
+// writeInfrastructureState writes state as the response, adding the *_human
+// display sizes when the request asks for them with human=true and replacing
+// names with pseudonyms with redact=true
+func (h *Handler) writeInfrastructureState(w http.ResponseWriter, r *http.Request, state models.InfrastructureState) {
+	if r.URL.Query().Get("human") == "true" {
+		state = state.WithHumanSizes()
+	}
+	if wantsRedacted(r) {
+		state = models.NewRedactor().InfrastructureState(state)
+	}
+	h.writeJSON(w, http.StatusOK, state)
+}
+
+// wantsRedacted reports whether the request asks for names to be replaced
+// with pseudonyms such as cluster-1, for responses shared outside the team
+func wantsRedacted(r *http.Request) bool {
+	return r.URL.Query().Get("redact") == "true"
+}
+
+// cachedVSphereState returns the cached vSphere discovery marked as cached,
+// with app totals refreshed once APP_DATA_CACHE_TTL has passed, unless the
+// request asks for re-discovery with force=true. Forcing also drops the
+// cached app totals so the rediscovery re-reads them.
+func (h *Handler) cachedVSphereState(r *http.Request) (models.InfrastructureState, bool) {
+	if r.URL.Query().Get("force") == "true" {
+		h.cache.Clear(appDataCacheKey)
+		return models.InfrastructureState{}, false
+	}
+	cached, found := h.cache.Get(vsphereInfrastructureCacheKey)
+	if !found {
+		return models.InfrastructureState{}, false
+	}
+	slog.DebugContext(r.Context(), "Infrastructure cache hit")
+	state := h.withCurrentAppData(r.Context(), cached.(models.InfrastructureState))
+	state.Cached = true
+	return state, true
+}
+
+// discoveryError is a vSphere discovery failure with the client-facing message
+// and status to report; the underlying error is only logged
+type discoveryError struct {
+	status  int
+	message string
+	err     error
+}
+
+func (e *discoveryError) Error() string { return e.message + ": " + e.err.Error() }
+func (e *discoveryError) Unwrap() error { return e.err }
+
+// discoverVSphere runs a full vSphere discovery, enriches it with CF and BOSH
+// data, then caches and stores the result. progress, which may be nil, is told
+// about each step. Failures are returned as *discoveryError.
+func (h *Handler) discoverVSphere(parent context.Context, progress services.ProgressFunc) (models.InfrastructureState, error) {
 	// Connect to vSphere (derive from request context so client disconnect cancels)
-	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(parent, 30*time.Second)
 	defer cancel()
 
+	progress.Report(services.StageConnecting, "connecting to vCenter", 0)
 	if err := h.vsphereClient.Connect(ctx); err != nil {
-		slog.Error("vSphere connection failed", "error", err)
-		h.writeError(w, "Infrastructure service temporarily unavailable", http.StatusServiceUnavailable)
-		return
+		slog.ErrorContext(parent, "vSphere connection failed", "error", err)
+		return models.InfrastructureState{}, &discoveryError{http.StatusServiceUnavailable, "Infrastructure service temporarily unavailable", err}
 	}
 	defer h.vsphereClient.Disconnect(ctx)
 
 	// Get infrastructure state
-	state, err := h.vsphereClient.GetInfrastructureState(ctx)
+	state, err := h.vsphereClient.GetInfrastructureState(ctx, progress)
 	if err != nil {
-		slog.Error("vSphere inventory fetch failed", "error", err)
-		h.writeError(w, "Failed to retrieve infrastructure data", http.StatusInternalServerError)
-		return
+		slog.ErrorContext(parent, "vSphere inventory fetch failed", "error", err)
+		// An empty discovery is a configuration problem the operator can fix, so say which
+		if errors.Is(err, services.ErrNoDiegoCells) {
+			return models.InfrastructureState{}, &discoveryError{http.StatusInternalServerError, err.Error(), err}
+		}
+		return models.InfrastructureState{}, &discoveryError{http.StatusInternalServerError, "Failed to retrieve infrastructure data", err}
 	}
 
 	// Enrich with CF app data (total app memory, disk, instances)
+	progress.Report(services.StageEnriching, "loading app totals and cell vitals", 0)
 	if err := h.enrichWithCFAppData(ctx, &state); err != nil {
-		slog.Warn("Failed to enrich with CF app data, continuing with vSphere-only data",
+		slog.WarnContext(parent, "Failed to enrich with CF app data, continuing with vSphere-only data",
 			"error", err,
 			"cf_configured", h.cfClient != nil,
 			"cf_api_url", h.cfg.CFAPIUrl)
 		// Continue without CF data - vSphere infrastructure data is still useful
 	}
 
-	// Cache result
-	h.cache.SetWithTTL(cacheKey, state, time.Duration(h.cfg.VSphereCacheTTL)*time.Second)
+	// Enrich with observed Diego cell disk usage from BOSH vitals
+	if err := h.enrichWithBOSHDiskVitals(ctx, &state); err != nil {
+		slog.WarnContext(parent, "Failed to enrich with BOSH disk vitals, continuing without observed disk usage", "error", err)
+	}
 
 	// Store as current infrastructure state for scenario calculations
-	h.infraMutex.Lock()
-	h.infrastructureState = &state
-	h.infraMutex.Unlock()
+	h.storeInfrastructureState(&state)
 
-	h.writeJSON(w, http.StatusOK, state)
+	// Cache result only after a successful discovery so failures never
+	// replace a previously good value. Storing first lets the cached copy
+	// carry the configured CPU risk level.
+	h.cache.SetWithTTL(vsphereInfrastructureCacheKey, state, time.Duration(h.cfg.VSphereCacheTTL)*time.Second)
+
+	return state, nil
 }
 
 // SetManualInfrastructure accepts manual infrastructure input.
@@ -102,13 +185,87 @@ func (h *Handler) SetManualInfrastructure(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	state := input.ToInfrastructureState()
+	if errs := input.Validate(); len(errs) > 0 {
+		h.writeValidationErrors(w, errs)
+		return
+	}
 
-	h.infraMutex.Lock()
-	h.infrastructureState = &state
-	h.infraMutex.Unlock()
+	state := input.ToInfrastructureStateWithThresholds(h.thresholds())
 
-	h.writeJSON(w, http.StatusOK, state)
+	h.storeInfrastructureState(&state)
+	h.recordAudit(r, audit.Event{Action: audit.ActionInfrastructureUpdate, Outcome: audit.OutcomeSuccess, Target: "manual"})
+
+	h.writeInfrastructureState(w, r, state)
+}
+
+// AnalyzeManualBottleneck runs bottleneck analysis over a submitted ManualInput
+// without storing it, so callers get a one-shot result with no server-side state.
+// Accepts the same rank query parameter as GET /api/v1/bottleneck.
+// HTTP method validation handled by Go 1.22+ router pattern matching.
+func (h *Handler) AnalyzeManualBottleneck(w http.ResponseWriter, r *http.Request) {
+	ranking, ok := bottleneckRanking(r)
+	if !ok {
+		h.writeError(w, "rank must be utilization or headroom", http.StatusBadRequest)
+		return
+	}
+
+	// Limit request body size to prevent DOS attacks (Issue #68)
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodySize)
+
+	var input models.ManualInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		// Check if error is due to body size limit (type assertion is more robust than string matching)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			h.writeError(w, "Request body too large", http.StatusBadRequest)
+			return
+		}
+		h.writeError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if errs := input.Validate(); len(errs) > 0 {
+		h.writeValidationErrors(w, errs)
+		return
+	}
+
+	state := input.ToInfrastructureStateWithThresholds(h.thresholds())
+	analysis := h.thresholds().AnalyzeBottleneckRanked(state, ranking)
+
+	h.writeJSON(w, http.StatusOK, analysis)
+}
+
+// GenerateManualRecommendations returns upgrade path recommendations for a
+// submitted ManualInput without storing it, like AnalyzeManualBottleneck.
+// HTTP method validation handled by Go 1.22+ router pattern matching.
+func (h *Handler) GenerateManualRecommendations(w http.ResponseWriter, r *http.Request) {
+	// Limit request body size to prevent DOS attacks (Issue #68)
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodySize)
+
+	var input models.ManualInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		// Check if error is due to body size limit (type assertion is more robust than string matching)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			h.writeError(w, "Request body too large", http.StatusBadRequest)
+			return
+		}
+		h.writeError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if errs := input.Validate(); len(errs) > 0 {
+		h.writeValidationErrors(w, errs)
+		return
+	}
+
+	state := input.ToInfrastructureStateWithThresholds(h.thresholds())
+	analysis := h.thresholds().AnalyzeBottleneck(state)
+
+	h.writeJSON(w, http.StatusOK, models.RecommendationsResponse{
+		Recommendations:      models.GenerateRecommendations(state),
+		ConstrainingResource: analysis.ConstrainingResource,
+	})
 }
 
 // SetInfrastructureState accepts an InfrastructureState directly (e.g., from vSphere cache).
@@ -129,11 +286,47 @@ func (h *Handler) SetInfrastructureState(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	h.infraMutex.Lock()
-	h.infrastructureState = &state
-	h.infraMutex.Unlock()
+	switch state.Format {
+	case "", models.FormatInfrastructureState:
+	case models.FormatManualInput:
+		h.writeError(w, `Body has format "manual_input"; submit manual input to /api/v1/infrastructure/manual`, http.StatusBadRequest)
+		return
+	default:
+		h.writeError(w, fmt.Sprintf("format must be %q or omitted, got %q", models.FormatInfrastructureState, state.Format), http.StatusBadRequest)
+		return
+	}
+
+	h.storeInfrastructureState(&state)
+	h.recordAudit(r, audit.Event{Action: audit.ActionInfrastructureUpdate, Outcome: audit.OutcomeSuccess, Target: "state"})
 
-	h.writeJSON(w, http.StatusOK, state)
+	h.writeInfrastructureState(w, r, state)
+}
+
+// DiffInfrastructure compares two infrastructure states, such as two weekly
+// discoveries, without storing either.
+// HTTP method validation handled by Go 1.22+ router pattern matching.
+func (h *Handler) DiffInfrastructure(w http.ResponseWriter, r *http.Request) {
+	// Limit request body size to prevent DOS attacks (Issue #68)
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodySize)
+
+	var req models.DiffRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		// Check if error is due to body size limit (type assertion is more robust than string matching)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			h.writeError(w, "Request body too large", http.StatusBadRequest)
+			return
+		}
+		h.writeError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if req.Old == nil || req.New == nil {
+		h.writeError(w, "Both old and new infrastructure states are required", http.StatusBadRequest)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, models.DiffStates(*req.Old, *req.New))
 }
 
 // GetInfrastructureStatus returns the current data source status.
@@ -157,7 +350,7 @@ func (h *Handler) GetInfrastructureStatus(w http.ResponseWriter, r *http.Request
 		status["timestamp"] = state.Timestamp
 
 		// Add bottleneck summary
-		analysis := models.AnalyzeBottleneck(*state)
+		analysis := h.thresholds().AnalyzeBottleneck(*state)
 		status["constraining_resource"] = analysis.ConstrainingResource
 		status["bottleneck_summary"] = analysis.Summary
 
@@ -180,6 +373,27 @@ func (h *Handler) GetInfrastructureStatus(w http.ResponseWriter, r *http.Request
 	h.writeJSON(w, http.StatusOK, status)
 }
 
+// GetInfrastructureClusters lists the clusters of the current infrastructure
+// state with their host and cell counts and utilization, so clients can offer
+// a scenario target_cluster without fetching the whole state.
+// HTTP method validation handled by Go 1.22+ router pattern matching.
+func (h *Handler) GetInfrastructureClusters(w http.ResponseWriter, r *http.Request) {
+	h.infraMutex.RLock()
+	state := h.infrastructureState
+	h.infraMutex.RUnlock()
+
+	if state == nil {
+		h.writeError(w, "No infrastructure data. Load via /api/v1/infrastructure or /api/v1/infrastructure/manual first.", http.StatusBadRequest)
+		return
+	}
+
+	list := state.ClusterList()
+	if wantsRedacted(r) {
+		list = models.NewRedactor().ClusterList(list)
+	}
+	h.writeJSON(w, http.StatusOK, list)
+}
+
 // PlanInfrastructure calculates max deployable cells given IaaS capacity.
 // HTTP method validation handled by Go 1.22+ router pattern matching.
 func (h *Handler) PlanInfrastructure(w http.ResponseWriter, r *http.Request) {
@@ -213,6 +427,75 @@ func (h *Handler) PlanInfrastructure(w http.ResponseWriter, r *http.Request) {
 	h.writeJSON(w, http.StatusOK, response)
 }
 
+// GetMaxCells estimates how many cells of a given size fit before breaching the
+// N-1 utilization target. Query parameters: memory (GB per cell), cpu (vCPUs per
+// cell), and target (N-1 percentage, default 85).
+// HTTP method validation handled by Go 1.22+ router pattern matching.
+func (h *Handler) GetMaxCells(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	cellMemoryGB, err := strconv.Atoi(query.Get("memory"))
+	if err != nil || cellMemoryGB <= 0 {
+		h.writeError(w, "memory must be a positive integer (GB per cell)", http.StatusBadRequest)
+		return
+	}
+
+	cellCPU, err := strconv.Atoi(query.Get("cpu"))
+	if err != nil || cellCPU <= 0 {
+		h.writeError(w, "cpu must be a positive integer (vCPUs per cell)", http.StatusBadRequest)
+		return
+	}
+
+	targetN1Pct := 85.0
+	if raw := query.Get("target"); raw != "" {
+		targetN1Pct, err = strconv.ParseFloat(raw, 64)
+		if err != nil || targetN1Pct <= 0 || targetN1Pct > 100 {
+			h.writeError(w, "target must be a percentage between 0 and 100", http.StatusBadRequest)
+			return
+		}
+	}
+
+	h.infraMutex.RLock()
+	state := h.infrastructureState
+	h.infraMutex.RUnlock()
+
+	if state == nil {
+		h.writeError(w, "No infrastructure data. Load via /api/v1/infrastructure or /api/v1/infrastructure/manual first.", http.StatusBadRequest)
+		return
+	}
+
+	result := h.scenarioCalc.CalculateMaxCells(*state, cellMemoryGB, cellCPU, targetN1Pct)
+
+	h.writeJSON(w, http.StatusOK, result)
+}
+
+// GetFaultImpactSizing recommends the cell count and size that keep a single
+// cell failure at or below max app instances at the same total capacity.
+// Query parameter: max (app instances per cell failure).
+// HTTP method validation handled by Go 1.22+ router pattern matching.
+func (h *Handler) GetFaultImpactSizing(w http.ResponseWriter, r *http.Request) {
+	maxFaultImpact, err := strconv.Atoi(r.URL.Query().Get("max"))
+	if err != nil || maxFaultImpact <= 0 {
+		h.writeError(w, "max must be a positive integer (app instances per cell failure)", http.StatusBadRequest)
+		return
+	}
+
+	h.infraMutex.RLock()
+	state := h.infrastructureState
+	h.infraMutex.RUnlock()
+
+	if state == nil {
+		h.writeError(w, "No infrastructure data. Load via /api/v1/infrastructure or /api/v1/infrastructure/manual first.", http.StatusBadRequest)
+		return
+	}
+	if state.TotalAppInstances == 0 {
+		h.writeError(w, "No app instance counts in infrastructure data. Load via /api/v1/infrastructure/from-cf or set total_app_instances.", http.StatusBadRequest)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, models.SizeForFaultImpact(*state, maxFaultImpact))
+}
+
 // GetInfrastructureApps returns detailed per-app memory, disk, and instance breakdown.
 // HTTP method validation handled by Go 1.22+ router pattern matching.
 func (h *Handler) GetInfrastructureApps(w http.ResponseWriter, r *http.Request) {
@@ -225,7 +508,7 @@ func (h *Handler) GetInfrastructureApps(w http.ResponseWriter, r *http.Request)
 	// Authenticate with CF
 	ctx := r.Context()
 	if err := h.cfClient.Authenticate(ctx); err != nil {
-		slog.Error("CF authentication failed", "error", err)
+		slog.ErrorContext(r.Context(), "CF authentication failed", "error", err)
 		h.writeError(w, "Authentication service temporarily unavailable", http.StatusServiceUnavailable)
 		return
 	}
@@ -233,7 +516,7 @@ func (h *Handler) GetInfrastructureApps(w http.ResponseWriter, r *http.Request)
 	// Fetch apps
 	apps, err := h.cfClient.GetApps(ctx)
 	if err != nil {
-		slog.Error("Failed to fetch apps from CF", "error", err)
+		slog.ErrorContext(r.Context(), "Failed to fetch apps from CF", "error", err)
 		h.writeError(w, "Failed to retrieve application data", http.StatusInternalServerError)
 		return
 	}
@@ -248,8 +531,8 @@ func (h *Handler) GetInfrastructureApps(w http.ResponseWriter, r *http.Request)
 
 	response := AppDetailsResponse{
 		// Round to nearest GB instead of truncating (add 512MB before dividing)
-		TotalAppMemoryGB:  (totalMemoryMB + 512) / 1024,
-		TotalAppDiskGB:    (totalDiskMB + 512) / 1024,
+		TotalAppMemoryGB:  units.MBToGiBRounded(totalMemoryMB),
+		TotalAppDiskGB:    units.MBToGiBRounded(totalDiskMB),
 		TotalAppInstances: totalInstances,
 		Apps:              apps,
 	}
@@ -257,50 +540,208 @@ func (h *Handler) GetInfrastructureApps(w http.ResponseWriter, r *http.Request)
 	h.writeJSON(w, http.StatusOK, response)
 }
 
-// enrichWithCFAppData populates app-related fields from CF API
-func (h *Handler) enrichWithCFAppData(ctx context.Context, state *models.InfrastructureState) error {
-	if h.cfClient == nil || h.cfg == nil || h.cfg.CFAPIUrl == "" {
-		return nil // No CF client configured, skip enrichment
+// SetInfrastructureFromCF replaces the app totals of the current infrastructure
+// state with allocation summed from the CF API, queried with the caller's
+// session token so results reflect what that user can see.
+// HTTP method validation handled by Go 1.22+ router pattern matching.
+func (h *Handler) SetInfrastructureFromCF(w http.ResponseWriter, r *http.Request) {
+	if h.cfg == nil || h.cfg.CFAPIUrl == "" {
+		h.writeError(w, "CF API not configured. Set CF_API_URL environment variable.", http.StatusServiceUnavailable)
+		return
 	}
 
-	// Check if context is already cancelled before starting CF API calls
-	if err := ctx.Err(); err != nil {
-		return fmt.Errorf("context cancelled before CF enrichment: %w", err)
+	token := h.getSessionToken(w, r)
+	if token == "" {
+		return
 	}
 
-	if err := h.cfClient.Authenticate(ctx); err != nil {
-		return fmt.Errorf("CF authentication during enrichment: %w", err)
+	state := h.CurrentInfrastructureState()
+	if state == nil {
+		h.writeError(w, "No infrastructure data. Set via /api/v1/infrastructure/manual first.", http.StatusBadRequest)
+		return
 	}
 
-	apps, err := h.cfClient.GetApps(ctx)
+	client := services.NewCFClientWithToken(h.cfg.CFAPIUrl, token, h.cfg.CFSkipSSLValidation)
+	alloc, err := client.GetAppAllocation(r.Context())
 	if err != nil {
-		return fmt.Errorf("CF GetApps during enrichment: %w", err)
+		slog.ErrorContext(r.Context(), "Failed to fetch app allocation from CF", "error", err)
+		h.writeError(w, "Failed to retrieve application data from CF API", http.StatusBadGateway)
+		return
 	}
 
-	var totalMemoryMB, totalDiskMB, totalInstances int
-	var maxMemPerInstanceMB int
-	for _, app := range apps {
-		totalMemoryMB += app.RequestedMB
-		totalDiskMB += app.RequestedDiskMB
-		totalInstances += app.Instances
+	// Apply the totals to the state current now, not the one read above, so
+	// an infrastructure update made while CF was queried is not lost
+	state = h.updateInfrastructureState(func(state *models.InfrastructureState) {
+		// Round to nearest GB instead of truncating (add 512MB before dividing)
+		state.TotalAppMemoryGB = units.MBToGiBRounded(alloc.MemoryMB)
+		state.TotalAppDiskGB = units.MBToGiBRounded(alloc.DiskMB)
+		state.TotalAppInstances = alloc.Instances
+		state.MaxInstanceMemoryMB = alloc.MaxInstanceMemoryMB
+		state.AvgInstanceMemoryMB = 0
+		if alloc.Instances > 0 {
+			state.AvgInstanceMemoryMB = alloc.MemoryMB / alloc.Instances
+		}
+		now := time.Now()
+		state.AppDataTimestamp = &now
+	})
+	if state == nil {
+		h.writeError(w, "No infrastructure data. Set via /api/v1/infrastructure/manual first.", http.StatusBadRequest)
+		return
+	}
+	h.recordAudit(r, audit.Event{Action: audit.ActionInfrastructureUpdate, Outcome: audit.OutcomeSuccess, Target: "cf"})
+
+	h.writeInfrastructureState(w, r, *state)
+}
+
+// SetInfrastructureFromPrometheus replaces the app totals of the current
+// infrastructure state with usage read from Prometheus, using the PromQL
+// expressions configured with PROMETHEUS_APP_*_QUERY. The disk total is kept
+// when no disk query is configured.
+// HTTP method validation handled by Go 1.22+ router pattern matching.
+func (h *Handler) SetInfrastructureFromPrometheus(w http.ResponseWriter, r *http.Request) {
+	if h.prometheusClient == nil {
+		h.writeError(w, "Prometheus not configured. Set PROMETHEUS_URL environment variable.", http.StatusServiceUnavailable)
+		return
+	}
+
+	if h.CurrentInfrastructureState() == nil {
+		h.writeError(w, "No infrastructure data. Set via /api/v1/infrastructure/manual first.", http.StatusBadRequest)
+		return
+	}
 
-		// Track max per-instance memory for chunk size calculation.
-		// CF API returns RequestedMB as total memory allocated to all instances
-		// of the app (per-instance limit × instance count). We divide to get
-		// the per-instance memory limit, which determines staging chunk size.
-		if app.Instances > 0 {
-			perInstanceMB := app.RequestedMB / app.Instances
-			if perInstanceMB > maxMemPerInstanceMB {
-				maxMemPerInstanceMB = perInstanceMB
-			}
+	usage, err := h.prometheusClient.GetAppUsage(r.Context(), services.PrometheusQueries{
+		MemoryBytes: h.cfg.PrometheusAppMemoryQuery,
+		Instances:   h.cfg.PrometheusAppInstancesQuery,
+		DiskBytes:   h.cfg.PrometheusAppDiskQuery,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to fetch app usage from Prometheus", "error", err)
+		if errors.Is(err, services.ErrPrometheusTimeout) {
+			h.writeError(w, "Prometheus query timed out", http.StatusGatewayTimeout)
+			return
+		}
+		h.writeError(w, "Failed to retrieve app usage from Prometheus", http.StatusBadGateway)
+		return
+	}
+
+	// Totals from Prometheus are held like manual ones: app_data_timestamp is
+	// cleared so the CF refresh of vSphere states does not replace them
+	state := h.updateInfrastructureState(func(state *models.InfrastructureState) {
+		state.TotalAppMemoryGB = units.MBToGiBRounded(usage.MemoryMB)
+		state.TotalAppInstances = usage.Instances
+		if usage.DiskQueried {
+			state.TotalAppDiskGB = units.MBToGiBRounded(usage.DiskMB)
+		}
+		state.AvgInstanceMemoryMB = 0
+		if usage.Instances > 0 {
+			state.AvgInstanceMemoryMB = usage.MemoryMB / usage.Instances
+		}
+		state.AppDataTimestamp = nil
+	})
+	if state == nil {
+		h.writeError(w, "No infrastructure data. Set via /api/v1/infrastructure/manual first.", http.StatusBadRequest)
+		return
+	}
+	h.recordAudit(r, audit.Event{Action: audit.ActionInfrastructureUpdate, Outcome: audit.OutcomeSuccess, Target: "prometheus"})
+
+	h.writeInfrastructureState(w, r, *state)
+}
+
+// ParseBOSHDump returns the Diego cells listed in pasted `bosh vms --json`
+// output (optionally with --vitals), for environments where the backend cannot
+// reach the BOSH Director. Nothing is stored.
+// HTTP method validation handled by Go 1.22+ router pattern matching.
+func (h *Handler) ParseBOSHDump(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBOSHDumpBodySize)
+
+	cells, err := services.ParseBOSHVMsDump(r.Body, h.cellJobNames())
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			h.writeError(w, "Request body too large", http.StatusBadRequest)
+			return
 		}
+		h.writeError(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
-	// Round to nearest GB instead of truncating (add 512MB before dividing)
-	state.TotalAppMemoryGB = (totalMemoryMB + 512) / 1024
-	state.TotalAppDiskGB = (totalDiskMB + 512) / 1024
-	state.TotalAppInstances = totalInstances
-	state.MaxInstanceMemoryMB = maxMemPerInstanceMB
+	slog.InfoContext(r.Context(), "Parsed BOSH VMs dump", "cell_count", len(cells))
+
+	h.writeJSON(w, http.StatusOK, models.BOSHDumpResponse{Cells: cells})
+}
+
+// ParseOpsManagerConfig derives Diego cell counts and sizing from a tile's
+// staged config and Ops Manager VM types, returning a ManualInput whose host
+// fields the caller completes before POSTing to /api/v1/infrastructure/manual.
+// Nothing is stored.
+// HTTP method validation handled by Go 1.22+ router pattern matching.
+func (h *Handler) ParseOpsManagerConfig(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodySize)
+
+	var req models.OpsManagerImportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			h.writeError(w, "Request body too large", http.StatusBadRequest)
+			return
+		}
+		h.writeError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	input, err := services.ParseOpsManagerResourceConfig(req.ProductConfig, req.VMTypes, h.cellJobNames())
+	if err != nil {
+		h.writeError(w, "Invalid Ops Manager config: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, input)
+}
+
+// enrichWithCFAppData populates app-related fields from CF API, using the
+// cached app totals while they are younger than APP_DATA_CACHE_TTL
+func (h *Handler) enrichWithCFAppData(ctx context.Context, state *models.InfrastructureState) error {
+	if h.cfClient == nil || h.cfg == nil || h.cfg.CFAPIUrl == "" {
+		return nil // No CF client configured, skip enrichment
+	}
+
+	snapshot, err := h.cfAppData(ctx)
+	if err != nil {
+		return err
+	}
+	snapshot.ApplyTo(state)
 
 	return nil
 }
+
+// enrichWithBOSHDiskVitals records the mean ephemeral disk usage observed on
+// Diego cells so bottleneck analysis can weigh real disk pressure. Cells that
+// report no disk vitals are left out of the average.
+func (h *Handler) enrichWithBOSHDiskVitals(ctx context.Context, state *models.InfrastructureState) error {
+	if h.boshClient == nil {
+		return nil // No BOSH client configured, skip enrichment
+	}
+
+	cells, err := h.boshClient.GetDiegoCells(ctx)
+	if err != nil {
+		return fmt.Errorf("BOSH GetDiegoCells during enrichment: %w", err)
+	}
+
+	state.ObservedCellDiskPercent = observedCellDiskPercent(cells)
+	return nil
+}
+
+// observedCellDiskPercent averages ephemeral disk usage across cells with disk vitals
+func observedCellDiskPercent(cells []models.DiegoCell) float64 {
+	var total, reporting int
+	for _, cell := range cells {
+		if cell.EphemeralDiskPercent > 0 {
+			total += cell.EphemeralDiskPercent
+			reporting++
+		}
+	}
+	if reporting == 0 {
+		return 0
+	}
+	return float64(total) / float64(reporting)
+}
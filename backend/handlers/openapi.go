@@ -16,6 +16,6 @@ var openapiSpec []byte
 func (h *Handler) OpenAPISpec(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/yaml")
 	if _, err := w.Write(openapiSpec); err != nil {
-		slog.Error("Failed to write OpenAPI spec response", "error", err)
+		slog.ErrorContext(r.Context(), "Failed to write OpenAPI spec response", "error", err)
 	}
 }
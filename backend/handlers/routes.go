@@ -17,6 +17,7 @@ type Route struct {
 	Public    bool             // If true, no authentication required
 	RateLimit string           // Rate limit tier: "auth", "refresh", "write", "none", or "" (default)
 	Role      string           // Required role: "operator", "viewer", or "" (no RBAC check)
+	Scope     string           // Required UAA scope (e.g., "diego-analyzer.operator"), or "" (no scope check)
 }
 
 // Routes returns all API routes for registration.
@@ -25,24 +26,44 @@ func (h *Handler) Routes() []Route {
 	return []Route{
 		// Health & Status (public, exempt from rate limiting)
 		{Method: http.MethodGet, Path: "/api/v1/health", Handler: h.Health, Public: true, RateLimit: "none"},
+		{Method: http.MethodGet, Path: "/api/v1/health/ready", Handler: h.Ready, Public: true, RateLimit: "none"},
 		{Method: http.MethodGet, Path: "/api/v1/dashboard", Handler: h.Dashboard},
+		{Method: http.MethodGet, Path: "/api/v1/config", Handler: h.Config},
+		{Method: http.MethodGet, Path: "/api/v1/thresholds", Handler: h.Thresholds},
 
 		// Authentication (public - handles own auth)
 		{Method: http.MethodPost, Path: "/api/v1/auth/login", Handler: h.Login, Public: true, RateLimit: "auth"},
 		{Method: http.MethodGet, Path: "/api/v1/auth/me", Handler: h.Me, Public: true, RateLimit: "none"},
 		{Method: http.MethodPost, Path: "/api/v1/auth/logout", Handler: h.Logout, Public: true, RateLimit: "auth"},
 		{Method: http.MethodPost, Path: "/api/v1/auth/refresh", Handler: h.Refresh, Public: true, RateLimit: "refresh"},
+		{Method: http.MethodGet, Path: "/api/v1/auth/sessions", Handler: h.ListSessions},
+		{Method: http.MethodDelete, Path: "/api/v1/auth/sessions/{id}", Handler: h.RevokeSession, RateLimit: "auth"},
+		{Method: http.MethodDelete, Path: "/api/v1/auth/users/{user_id}/sessions", Handler: h.RevokeUserSessions, RateLimit: "write", Role: middleware.RoleOperator},
 
 		// Infrastructure
 		{Method: http.MethodGet, Path: "/api/v1/infrastructure", Handler: h.GetInfrastructure},
 		{Method: http.MethodPost, Path: "/api/v1/infrastructure/manual", Handler: h.SetManualInfrastructure, RateLimit: "write", Role: middleware.RoleOperator},
 		{Method: http.MethodPost, Path: "/api/v1/infrastructure/state", Handler: h.SetInfrastructureState, RateLimit: "write", Role: middleware.RoleOperator},
+		{Method: http.MethodPost, Path: "/api/v1/infrastructure/from-cf", Handler: h.SetInfrastructureFromCF, RateLimit: "write", Role: middleware.RoleOperator},
+		{Method: http.MethodPost, Path: "/api/v1/infrastructure/from-prometheus", Handler: h.SetInfrastructureFromPrometheus, RateLimit: "write", Role: middleware.RoleOperator},
+		{Method: http.MethodPost, Path: "/api/v1/infrastructure/from-bosh-dump", Handler: h.ParseBOSHDump, RateLimit: "write"},
+		{Method: http.MethodPost, Path: "/api/v1/infrastructure/from-om", Handler: h.ParseOpsManagerConfig, RateLimit: "write"},
+		{Method: http.MethodGet, Path: "/api/v1/infrastructure/discover/stream", Handler: h.DiscoverInfrastructureStream},
 		{Method: http.MethodGet, Path: "/api/v1/infrastructure/status", Handler: h.GetInfrastructureStatus},
+		{Method: http.MethodGet, Path: "/api/v1/infrastructure/clusters", Handler: h.GetInfrastructureClusters},
 		{Method: http.MethodPost, Path: "/api/v1/infrastructure/planning", Handler: h.PlanInfrastructure, RateLimit: "write"},
+		{Method: http.MethodPost, Path: "/api/v1/infrastructure/diff", Handler: h.DiffInfrastructure, RateLimit: "write"},
 		{Method: http.MethodGet, Path: "/api/v1/infrastructure/apps", Handler: h.GetInfrastructureApps},
+		{Method: http.MethodGet, Path: "/api/v1/planning/max-cells", Handler: h.GetMaxCells},
+		{Method: http.MethodGet, Path: "/api/v1/planning/forecast", Handler: h.GetForecast},
+		{Method: http.MethodGet, Path: "/api/v1/planning/for-fault-impact", Handler: h.GetFaultImpactSizing},
 
 		// Scenario
-		{Method: http.MethodPost, Path: "/api/v1/scenario/compare", Handler: h.CompareScenario, RateLimit: "write"},
+		{Method: http.MethodPost, Path: "/api/v1/scenario/compare", Handler: h.CompareScenario, RateLimit: "write", Scope: middleware.ScopeOperator},
+		{Method: http.MethodPost, Path: "/api/v1/scenario/baseline", Handler: h.SaveScenarioBaseline, RateLimit: "write", Role: middleware.RoleOperator},
+		{Method: http.MethodGet, Path: "/api/v1/scenario/baseline/{name}", Handler: h.GetScenarioBaseline},
+		{Method: http.MethodGet, Path: "/api/v1/scenario/sweep", Handler: h.GetScenarioSweep},
+		{Method: http.MethodPost, Path: "/api/v1/check", Handler: h.CheckThresholds, RateLimit: "write"},
 
 		// AI Advisor
 		{Method: http.MethodPost, Path: "/api/v1/chat", Handler: h.Chat, RateLimit: "chat"},
@@ -50,7 +71,13 @@ func (h *Handler) Routes() []Route {
 
 		// Analysis
 		{Method: http.MethodGet, Path: "/api/v1/bottleneck", Handler: h.AnalyzeBottleneck},
+		{Method: http.MethodPost, Path: "/api/v1/bottleneck", Handler: h.AnalyzeManualBottleneck, RateLimit: "write"},
 		{Method: http.MethodGet, Path: "/api/v1/recommendations", Handler: h.GetRecommendations},
+		{Method: http.MethodPost, Path: "/api/v1/recommendations", Handler: h.GenerateManualRecommendations, RateLimit: "write"},
+
+		// Cache
+		{Method: http.MethodGet, Path: "/api/v1/cache/stats", Handler: h.CacheStats, Role: middleware.RoleOperator},
+		{Method: http.MethodPost, Path: "/api/v1/cache/invalidate", Handler: h.InvalidateCache, RateLimit: "write", Role: middleware.RoleOperator},
 
 		// CF API Proxy (requires valid session - tokens never exposed to frontend)
 		{Method: http.MethodGet, Path: "/api/v1/cf/isolation-segments", Handler: h.CFProxyIsolationSegments},
@@ -62,5 +89,8 @@ func (h *Handler) Routes() []Route {
 
 		// Documentation (public, exempt from rate limiting)
 		{Method: http.MethodGet, Path: "/api/v1/openapi.yaml", Handler: h.OpenAPISpec, Public: true, RateLimit: "none"},
+		{Method: http.MethodGet, Path: "/api/v1/schema/manual-input", Handler: h.ManualInputSchema, Public: true, RateLimit: "none"},
+		{Method: http.MethodGet, Path: "/api/v1/schema/infrastructure-state", Handler: h.InfrastructureStateSchema, Public: true, RateLimit: "none"},
+		{Method: http.MethodGet, Path: "/api/v1/metrics/glossary", Handler: h.MetricGlossary, Public: true, RateLimit: "none"},
 	}
 }
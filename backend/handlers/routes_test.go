@@ -6,6 +6,8 @@ package handlers
 import (
 	"strings"
 	"testing"
+
+	"github.com/markalston/diego-capacity-analyzer/backend/middleware"
 )
 
 func TestRoutes_AllRoutesHaveRequiredFields(t *testing.T) {
@@ -52,8 +54,12 @@ func TestRoutes_PublicEndpointsMarked(t *testing.T) {
 
 	// These endpoints should be public (no auth required)
 	expectedPublic := map[string]bool{
-		"/api/v1/health":       true,
-		"/api/v1/openapi.yaml": true,
+		"/api/v1/health":                      true,
+		"/api/v1/health/ready":                true,
+		"/api/v1/openapi.yaml":                true,
+		"/api/v1/schema/manual-input":         true,
+		"/api/v1/schema/infrastructure-state": true,
+		"/api/v1/metrics/glossary":            true,
 		// Auth endpoints handle their own authentication
 		"/api/v1/auth/login":   true,
 		"/api/v1/auth/me":      true,
@@ -86,17 +92,43 @@ func TestRoutes_ExpectedEndpoints(t *testing.T) {
 	routes := h.Routes()
 
 	expected := map[string]bool{
-		"GET /api/v1/health":                   false,
-		"GET /api/v1/dashboard":                false,
-		"GET /api/v1/infrastructure":           false,
-		"POST /api/v1/infrastructure/manual":   false,
-		"POST /api/v1/infrastructure/state":    false,
-		"GET /api/v1/infrastructure/status":    false,
-		"POST /api/v1/infrastructure/planning": false,
-		"GET /api/v1/infrastructure/apps":      false,
-		"POST /api/v1/scenario/compare":        false,
-		"GET /api/v1/bottleneck":               false,
-		"GET /api/v1/recommendations":          false,
+		"GET /api/v1/health":                           false,
+		"GET /api/v1/health/ready":                     false,
+		"GET /api/v1/dashboard":                        false,
+		"GET /api/v1/config":                           false,
+		"GET /api/v1/thresholds":                       false,
+		"GET /api/v1/infrastructure":                   false,
+		"POST /api/v1/infrastructure/manual":           false,
+		"POST /api/v1/infrastructure/state":            false,
+		"POST /api/v1/infrastructure/from-cf":          false,
+		"POST /api/v1/infrastructure/from-prometheus":  false,
+		"POST /api/v1/infrastructure/from-bosh-dump":   false,
+		"POST /api/v1/infrastructure/from-om":          false,
+		"GET /api/v1/infrastructure/discover/stream":   false,
+		"GET /api/v1/infrastructure/status":            false,
+		"GET /api/v1/infrastructure/clusters":          false,
+		"POST /api/v1/infrastructure/planning":         false,
+		"POST /api/v1/infrastructure/diff":             false,
+		"GET /api/v1/infrastructure/apps":              false,
+		"GET /api/v1/planning/max-cells":               false,
+		"GET /api/v1/planning/forecast":                false,
+		"GET /api/v1/planning/for-fault-impact":        false,
+		"GET /api/v1/schema/manual-input":              false,
+		"GET /api/v1/schema/infrastructure-state":      false,
+		"GET /api/v1/metrics/glossary":                 false,
+		"POST /api/v1/scenario/compare":                false,
+		"POST /api/v1/scenario/baseline":               false,
+		"GET /api/v1/scenario/baseline/{name}":         false,
+		"POST /api/v1/check":                           false,
+		"GET /api/v1/bottleneck":                       false,
+		"POST /api/v1/bottleneck":                      false,
+		"GET /api/v1/recommendations":                  false,
+		"POST /api/v1/recommendations":                 false,
+		"GET /api/v1/cache/stats":                      false,
+		"POST /api/v1/cache/invalidate":                false,
+		"GET /api/v1/auth/sessions":                    false,
+		"DELETE /api/v1/auth/sessions/{id}":            false,
+		"DELETE /api/v1/auth/users/{user_id}/sessions": false,
 	}
 
 	for _, route := range routes {
@@ -112,3 +144,18 @@ func TestRoutes_ExpectedEndpoints(t *testing.T) {
 		}
 	}
 }
+
+func TestRoutes_ScopeGatedEndpoints(t *testing.T) {
+	h := NewHandler(nil, nil)
+
+	expectedScopes := map[string]string{
+		"POST /api/v1/scenario/compare": middleware.ScopeOperator,
+	}
+
+	for _, route := range h.Routes() {
+		key := route.Method + " " + route.Path
+		if want := expectedScopes[key]; route.Scope != want {
+			t.Errorf("Route %s: Scope = %q, want %q", key, route.Scope, want)
+		}
+	}
+}
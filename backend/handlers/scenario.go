@@ -1,21 +1,28 @@
-// ABOUTME: HTTP handler for scenario comparison endpoint
-// ABOUTME: Provides what-if analysis comparing current vs proposed configurations
+// ABOUTME: HTTP handlers for scenario comparison and baseline endpoints
+// ABOUTME: Provides what-if analysis comparing proposed configurations to current state or saved baselines
 
 package handlers
 
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"strconv"
 
+	"github.com/markalston/diego-capacity-analyzer/backend/audit"
 	"github.com/markalston/diego-capacity-analyzer/backend/middleware"
 	"github.com/markalston/diego-capacity-analyzer/backend/models"
+	"github.com/markalston/diego-capacity-analyzer/backend/services"
 )
 
 const maxUserScenarios = 1000
 
 // CompareScenario compares current infrastructure against a proposed scenario.
+// With ?explain=true the response adds a calculation_trace of the
+// intermediate values behind both results; with ?redact=true foundation and
+// cluster names are replaced with pseudonyms.
 // HTTP method validation handled by Go 1.22+ router pattern matching.
 func (h *Handler) CompareScenario(w http.ResponseWriter, r *http.Request) {
 	// Limit request body size to prevent DOS attacks (Issue #68)
@@ -42,11 +49,77 @@ func (h *Handler) CompareScenario(w http.ResponseWriter, r *http.Request) {
 		h.writeError(w, "No infrastructure data. Set via /api/v1/infrastructure/manual first.", http.StatusBadRequest)
 		return
 	}
+	// App usage moves faster than topology; use app totals no older than APP_DATA_CACHE_TTL
+	current := h.withCurrentAppData(r.Context(), *state)
+	state = &current
 
-	comparison := h.scenarioCalc.Compare(*state, input)
+	if err := services.ValidateTargetCluster(*state, input); err != nil {
+		h.writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := services.ValidateHostRemoval(*state, input); err != nil {
+		h.writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := services.ValidateOverheadModel(*state, input); err != nil {
+		h.writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if input.BaselineInput != nil {
+		if input.BaselineInput.BaselineInput != nil {
+			h.writeError(w, "baseline_input must not set its own baseline_input", http.StatusBadRequest)
+			return
+		}
+		// The baseline is calculated on the same cluster as the proposal
+		baseline := *input.BaselineInput
+		baseline.TargetCluster = input.TargetCluster
+		if err := services.ValidateHostRemoval(*state, baseline); err != nil {
+			h.writeError(w, "baseline_input: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := services.ValidateOverheadModel(*state, baseline); err != nil {
+			h.writeError(w, "baseline_input: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Fall back to the operator-configured TPS curve when the request omits one
+	if !input.EnableTPS() && h.cfg != nil && len(h.cfg.TPSCurve) > 0 {
+		input.TPSCurve = h.cfg.TPSCurve
+	}
+
+	var comparison models.ScenarioComparison
+	if r.URL.Query().Get("explain") == "true" {
+		comparison = h.scenarioCalc.CompareExplained(*state, input)
+	} else {
+		comparison = h.scenarioCalc.Compare(*state, input)
+	}
+
+	// Add recommendations based on current state, narrowed to the target
+	// cluster so they describe the same cells as the comparison
+	scoped := *state
+	if input.TargetCluster != "" {
+		scoped, _ = state.ForCluster(input.TargetCluster)
+	}
+	comparison.Recommendations = models.GenerateRecommendations(scoped)
+	if input.MaxFaultImpact > 0 {
+		if rec := models.GenerateFaultImpactRecommendation(scoped, input.MaxFaultImpact); rec != nil {
+			comparison.Recommendations = append(comparison.Recommendations, *rec)
+		}
+	}
 
-	// Add recommendations based on current state
-	comparison.Recommendations = models.GenerateRecommendations(*state)
+	if input.Baseline != "" {
+		baselineComparison, err := h.baselines.CompareToBaseline(input.Baseline, input.TargetCluster, comparison.Proposed)
+		switch {
+		case errors.Is(err, services.ErrBaselineScopeMismatch):
+			h.writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		case err != nil:
+			h.writeError(w, "Baseline not found", http.StatusNotFound)
+			return
+		}
+		comparison.Baseline = &baselineComparison
+	}
 
 	// Store scenario result for authenticated users so the AI advisor can reference it.
 	// Existing users can always update their scenario; only new insertions are refused
@@ -56,7 +129,7 @@ func (h *Handler) CompareScenario(w http.ResponseWriter, r *http.Request) {
 		h.userScenariosMutex.Lock()
 		_, exists := h.userScenarios[claims.Username]
 		if !exists && len(h.userScenarios) >= maxUserScenarios {
-			slog.Warn("user scenarios map at capacity, cannot store for new user",
+			slog.WarnContext(r.Context(), "user scenarios map at capacity, cannot store for new user",
 				"username", claims.Username,
 				"capacity", maxUserScenarios,
 			)
@@ -66,5 +139,269 @@ func (h *Handler) CompareScenario(w http.ResponseWriter, r *http.Request) {
 		h.userScenariosMutex.Unlock()
 	}
 
+	h.recordAudit(r, audit.Event{Action: audit.ActionScenarioCompare, Outcome: audit.OutcomeSuccess, Target: input.TargetCluster})
+	// Only the response is redacted; the advisor keeps the stored scenario's real names
+	resp := comparison
+	if wantsRedacted(r) {
+		resp = models.NewRedactor().ScenarioComparison(comparison, *state)
+	}
+	h.writeJSON(w, http.StatusOK, resp)
+}
+
+// SaveScenarioBaseline saves a named baseline for later comparison. Without
+// input the current infrastructure result is saved; with input, the proposed
+// result of that scenario. Saving under an existing name replaces it.
+// HTTP method validation handled by Go 1.22+ router pattern matching.
+func (h *Handler) SaveScenarioBaseline(w http.ResponseWriter, r *http.Request) {
+	// Limit request body size to prevent DOS attacks (Issue #68)
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodySize)
+
+	var req models.SaveBaselineRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			h.writeError(w, "Request body too large", http.StatusBadRequest)
+			return
+		}
+		h.writeError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	h.infraMutex.RLock()
+	state := h.infrastructureState
+	h.infraMutex.RUnlock()
+
+	if state == nil {
+		h.writeError(w, "No infrastructure data. Set via /api/v1/infrastructure/manual first.", http.StatusBadRequest)
+		return
+	}
+
+	var tpsCurve []models.TPSPt
+	if h.cfg != nil {
+		tpsCurve = h.cfg.TPSCurve
+	}
+
+	current := h.withCurrentAppData(r.Context(), *state)
+
+	var result models.ScenarioResult
+	var targetCluster string
+	if req.Input != nil {
+		input := *req.Input
+		if err := services.ValidateTargetCluster(current, input); err != nil {
+			h.writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := services.ValidateHostRemoval(current, input); err != nil {
+			h.writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := services.ValidateOverheadModel(current, input); err != nil {
+			h.writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if !input.EnableTPS() {
+			input.TPSCurve = tpsCurve
+		}
+		result = h.scenarioCalc.CalculateProposed(current, input)
+		targetCluster = input.TargetCluster
+	} else {
+		result = h.scenarioCalc.CalculateCurrent(current, tpsCurve)
+	}
+
+	var savedBy string
+	if claims := middleware.GetUserClaims(r); claims != nil {
+		savedBy = claims.Username
+	}
+
+	baseline, err := h.baselines.SaveBaseline(req.Name, savedBy, targetCluster, &result)
+	switch {
+	case errors.Is(err, services.ErrInvalidBaselineName), errors.Is(err, services.ErrTooManyBaselines):
+		h.writeErrorWithDetails(w, "Invalid baseline", err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		// Saved in memory; only persistence failed
+		slog.WarnContext(r.Context(), "Failed to persist scenario baseline", "name", req.Name, "error", err)
+	}
+
+	h.recordAudit(r, audit.Event{Action: audit.ActionScenarioBaselineSave, Username: savedBy, Outcome: audit.OutcomeSuccess, Target: baseline.Name})
+	h.writeJSON(w, http.StatusCreated, baseline)
+}
+
+// GetScenarioBaseline returns a saved baseline compared against the current
+// infrastructure state, narrowed to the baseline's target cluster if it has one.
+// HTTP method validation handled by Go 1.22+ router pattern matching.
+func (h *Handler) GetScenarioBaseline(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	baseline, ok := h.baselines.Get(name)
+	if !ok {
+		h.writeError(w, "Baseline not found", http.StatusNotFound)
+		return
+	}
+
+	h.infraMutex.RLock()
+	state := h.infrastructureState
+	h.infraMutex.RUnlock()
+
+	if state == nil {
+		h.writeError(w, "No infrastructure data. Set via /api/v1/infrastructure/manual first.", http.StatusBadRequest)
+		return
+	}
+
+	var tpsCurve []models.TPSPt
+	if h.cfg != nil {
+		tpsCurve = h.cfg.TPSCurve
+	}
+
+	current := h.withCurrentAppData(r.Context(), *state)
+	if baseline.TargetCluster != "" {
+		scoped, ok := current.ForCluster(baseline.TargetCluster)
+		if !ok {
+			h.writeError(w, fmt.Sprintf("baseline target_cluster %q not found in infrastructure", baseline.TargetCluster), http.StatusBadRequest)
+			return
+		}
+		current = scoped
+	}
+
+	comparison, err := h.baselines.CompareToBaseline(name, baseline.TargetCluster, h.scenarioCalc.CalculateCurrent(current, tpsCurve))
+	if err != nil {
+		h.writeError(w, "Baseline not found", http.StatusNotFound)
+		return
+	}
+
 	h.writeJSON(w, http.StatusOK, comparison)
 }
+
+// GetScenarioSweep evaluates the current infrastructure at every cell count in
+// a range. Query parameters: min and max (cell counts, required), step
+// (default 1), and optional memory, cpu, and disk overrides for the cell size.
+// HTTP method validation handled by Go 1.22+ router pattern matching.
+func (h *Handler) GetScenarioSweep(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	minCells, err := strconv.Atoi(query.Get("min"))
+	if err != nil {
+		h.writeError(w, "min must be a positive integer (cell count)", http.StatusBadRequest)
+		return
+	}
+	maxCells, err := strconv.Atoi(query.Get("max"))
+	if err != nil {
+		h.writeError(w, "max must be a positive integer (cell count)", http.StatusBadRequest)
+		return
+	}
+	step := 1
+	if raw := query.Get("step"); raw != "" {
+		if step, err = strconv.Atoi(raw); err != nil {
+			h.writeError(w, "step must be a positive integer", http.StatusBadRequest)
+			return
+		}
+	}
+	if err := services.ValidateSweepRange(minCells, maxCells, step); err != nil {
+		h.writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	overrides := make(map[string]int)
+	for _, name := range []string{"memory", "cpu", "disk"} {
+		raw := query.Get(name)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.Atoi(raw)
+		if err != nil || value <= 0 {
+			h.writeError(w, name+" must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		overrides[name] = value
+	}
+
+	h.infraMutex.RLock()
+	state := h.infrastructureState
+	h.infraMutex.RUnlock()
+
+	if state == nil {
+		h.writeError(w, "No infrastructure data. Set via /api/v1/infrastructure/manual first.", http.StatusBadRequest)
+		return
+	}
+
+	input := services.SweepBaseInput(*state)
+	if v, ok := overrides["memory"]; ok {
+		input.ProposedCellMemoryGB = v
+	}
+	if v, ok := overrides["cpu"]; ok {
+		input.ProposedCellCPU = v
+	}
+	if v, ok := overrides["disk"]; ok {
+		input.ProposedCellDiskGB = v
+	}
+	if h.cfg != nil && len(h.cfg.TPSCurve) > 0 {
+		input.TPSCurve = h.cfg.TPSCurve
+	}
+
+	h.writeJSON(w, http.StatusOK, models.ScenarioSweep{
+		MinCells: minCells,
+		MaxCells: maxCells,
+		Step:     step,
+		Results:  h.scenarioCalc.CalculateSweep(*state, input, minCells, maxCells, step),
+	})
+}
+
+// CheckThresholds evaluates submitted infrastructure against warning thresholds
+// without storing it. Used by CI pipelines via `diego-capacity check --input`.
+// HTTP method validation handled by Go 1.22+ router pattern matching.
+func (h *Handler) CheckThresholds(w http.ResponseWriter, r *http.Request) {
+	// Limit request body size to prevent DOS attacks (Issue #68)
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodySize)
+
+	var req models.CheckRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		// Check if error is due to body size limit (type assertion is more robust than string matching)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			h.writeError(w, "Request body too large", http.StatusBadRequest)
+			return
+		}
+		h.writeError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if errs := req.Input.Validate(); len(errs) > 0 {
+		h.writeValidationErrors(w, errs)
+		return
+	}
+
+	var tpsCurve []models.TPSPt
+	if h.cfg != nil {
+		tpsCurve = h.cfg.TPSCurve
+	}
+
+	state := req.Input.ToInfrastructureStateWithThresholds(h.thresholds())
+	current := h.scenarioCalc.CalculateCurrent(state, tpsCurve)
+	thresholds := req.Thresholds.ResolveFrom(h.thresholds().WarningThresholds)
+	warnings := services.GenerateThresholdWarnings(current, current, nil, nil, thresholds)
+	if warnings == nil {
+		warnings = []models.ScenarioWarning{}
+	}
+
+	h.writeJSON(w, http.StatusOK, models.CheckResponse{
+		Status:         checkStatus(warnings),
+		Current:        current,
+		Thresholds:     thresholds,
+		Warnings:       warnings,
+		Infrastructure: state,
+	})
+}
+
+// checkStatus reduces warnings to the most severe level: "critical",
+// "warning", or "passed" when there are none.
+func checkStatus(warnings []models.ScenarioWarning) string {
+	status := "passed"
+	for _, warning := range warnings {
+		switch warning.Severity {
+		case "critical":
+			return "critical"
+		case "warning":
+			status = "warning"
+		}
+	}
+	return status
+}
@@ -0,0 +1,24 @@
+// ABOUTME: Handlers serving JSON Schema for the infrastructure file formats
+// ABOUTME: Lets users validate ManualInput and InfrastructureState files and editors autocomplete them
+
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/markalston/diego-capacity-analyzer/backend/models"
+)
+
+// ManualInputSchema serves the JSON Schema for ManualInput files. Public so
+// editors can fetch it from a file's "$schema" URL.
+// HTTP method validation handled by Go 1.22+ router pattern matching.
+func (h *Handler) ManualInputSchema(w http.ResponseWriter, r *http.Request) {
+	h.writeJSON(w, http.StatusOK, models.ManualInputSchema())
+}
+
+// InfrastructureStateSchema serves the JSON Schema for InfrastructureState
+// files. Public so editors can fetch it from a file's "$schema" URL.
+// HTTP method validation handled by Go 1.22+ router pattern matching.
+func (h *Handler) InfrastructureStateSchema(w http.ResponseWriter, r *http.Request) {
+	h.writeJSON(w, http.StatusOK, models.InfrastructureStateSchema())
+}
@@ -0,0 +1,110 @@
+// ABOUTME: Session list and revocation handlers
+// ABOUTME: Lets users see and end their own sessions, and operators end every session for a user
+
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/markalston/diego-capacity-analyzer/backend/audit"
+	"github.com/markalston/diego-capacity-analyzer/backend/middleware"
+	"github.com/markalston/diego-capacity-analyzer/backend/models"
+	"github.com/markalston/diego-capacity-analyzer/backend/services"
+)
+
+// maxUserAgentLength bounds the user agent stored with each session
+const maxUserAgentLength = 256
+
+// ListSessions handles GET /api/v1/auth/sessions, listing the caller's active
+// sessions. The session making the request is marked current.
+func (h *Handler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	session := h.getSessionFromCookie(r)
+	if session == nil {
+		h.writeError(w, "Not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	sessions, err := h.sessionService.ListForUser(session.UserID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list sessions", "error", err)
+		h.writeError(w, "Failed to list sessions", http.StatusInternalServerError)
+		return
+	}
+
+	resp := models.SessionListResponse{Sessions: make([]models.SessionInfo, 0, len(sessions))}
+	for _, s := range sessions {
+		resp.Sessions = append(resp.Sessions, models.SessionInfo{
+			ID:        services.SessionHandle(s.ID),
+			CreatedAt: s.CreatedAt,
+			LastSeen:  s.LastSeen,
+			UserAgent: s.UserAgent,
+			Current:   s.ID == session.ID,
+		})
+	}
+	h.writeJSON(w, http.StatusOK, resp)
+}
+
+// RevokeSession handles DELETE /api/v1/auth/sessions/{id}, ending one of the
+// caller's sessions by the ID from ListSessions. Revoking the current session
+// also clears its cookie.
+func (h *Handler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	session := h.getSessionFromCookie(r)
+	if session == nil {
+		h.writeError(w, "Not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	handle := r.PathValue("id")
+	err := h.sessionService.RevokeByHandle(session.UserID, handle)
+	if errors.Is(err, services.ErrSessionNotFound) {
+		h.writeError(w, "Session not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to revoke session", "error", err)
+		h.writeError(w, "Failed to revoke session", http.StatusInternalServerError)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Session revoked", "username", session.Username)
+	h.recordAudit(r, audit.Event{Action: audit.ActionSessionRevoke, Username: session.Username, Outcome: audit.OutcomeSuccess, Target: handle})
+	if handle == services.SessionHandle(session.ID) {
+		h.clearSessionCookie(w)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RevokeUserSessions handles DELETE /api/v1/auth/users/{user_id}/sessions,
+// ending every session the user has. Requires the operator role.
+func (h *Handler) RevokeUserSessions(w http.ResponseWriter, r *http.Request) {
+	if h.sessionService == nil {
+		h.writeError(w, "Sessions not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	userID := r.PathValue("user_id")
+	revoked, err := h.sessionService.RevokeAllForUser(userID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to revoke user sessions", "user_id", userID, "error", err)
+		h.writeError(w, "Failed to revoke sessions", http.StatusInternalServerError)
+		return
+	}
+
+	var revokedBy string
+	if claims := middleware.GetUserClaims(r); claims != nil {
+		revokedBy = claims.Username
+	}
+	slog.WarnContext(r.Context(), "All sessions revoked for user", "user_id", userID, "revoked", revoked, "revoked_by", revokedBy)
+	h.recordAudit(r, audit.Event{Action: audit.ActionUserSessionsRevoke, Username: revokedBy, Outcome: audit.OutcomeSuccess, Target: userID})
+	h.writeJSON(w, http.StatusOK, models.SessionRevokeResponse{UserID: userID, Revoked: revoked})
+}
+
+// truncateUserAgent bounds a client-supplied user agent for storage
+func truncateUserAgent(userAgent string) string {
+	if len(userAgent) > maxUserAgentLength {
+		return userAgent[:maxUserAgentLength]
+	}
+	return userAgent
+}
@@ -0,0 +1,200 @@
+// ABOUTME: Tests for session list and revocation handlers
+// ABOUTME: Verifies users only see and revoke their own sessions and operators can revoke all
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/markalston/diego-capacity-analyzer/backend/cache"
+	"github.com/markalston/diego-capacity-analyzer/backend/config"
+	"github.com/markalston/diego-capacity-analyzer/backend/models"
+	"github.com/markalston/diego-capacity-analyzer/backend/services"
+)
+
+// newSessionsTestHandler returns a handler with two sessions for user-1 and
+// one for user-2
+func newSessionsTestHandler(t *testing.T) (h *Handler, svc *services.SessionService, laptop, phone, other string) {
+	t.Helper()
+	c := cache.New(5 * time.Minute)
+	svc = services.NewSessionService(services.NewCacheSessionStore(c))
+	h = NewHandler(&config.Config{}, c)
+	h.SetSessionService(svc)
+
+	expiry := time.Now().Add(time.Hour)
+	var err error
+	if laptop, err = svc.CreateWithUserAgent("alice", "user-1", "a", "r", nil, expiry, "Firefox on Linux"); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if phone, err = svc.CreateWithUserAgent("alice", "user-1", "a", "r", nil, expiry, "Safari on iOS"); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if other, err = svc.CreateWithUserAgent("bob", "user-2", "a", "r", nil, expiry, "curl"); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	return h, svc, laptop, phone, other
+}
+
+func TestListSessions(t *testing.T) {
+	h, _, laptop, phone, _ := newSessionsTestHandler(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/auth/sessions", nil)
+	req.AddCookie(&http.Cookie{Name: sessionCookieName, Value: laptop})
+	w := httptest.NewRecorder()
+	h.ListSessions(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if body := w.Body.String(); strings.Contains(body, laptop) || strings.Contains(body, phone) {
+		t.Error("Response must not contain raw session IDs")
+	}
+
+	var resp models.SessionListResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(resp.Sessions) != 2 {
+		t.Fatalf("Expected 2 sessions for user-1, got %d", len(resp.Sessions))
+	}
+
+	byAgent := map[string]models.SessionInfo{}
+	for _, s := range resp.Sessions {
+		byAgent[s.UserAgent] = s
+	}
+	current, ok := byAgent["Firefox on Linux"]
+	if !ok || !current.Current || current.ID != services.SessionHandle(laptop) {
+		t.Errorf("Expected the laptop session marked current, got %+v", resp.Sessions)
+	}
+	if current.CreatedAt.IsZero() || current.LastSeen.IsZero() {
+		t.Errorf("Expected created and last-seen times, got %+v", current)
+	}
+	if s, ok := byAgent["Safari on iOS"]; !ok || s.Current {
+		t.Errorf("Expected the phone session listed and not current, got %+v", resp.Sessions)
+	}
+}
+
+func TestListSessions_NotAuthenticated(t *testing.T) {
+	h, _, _, _, _ := newSessionsTestHandler(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/auth/sessions", nil)
+	w := httptest.NewRecorder()
+	h.ListSessions(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401, got %d", w.Code)
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	t.Run("revokes another of the caller's sessions", func(t *testing.T) {
+		h, svc, laptop, phone, _ := newSessionsTestHandler(t)
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/auth/sessions/x", nil)
+		req.SetPathValue("id", services.SessionHandle(phone))
+		req.AddCookie(&http.Cookie{Name: sessionCookieName, Value: laptop})
+		w := httptest.NewRecorder()
+		h.RevokeSession(w, req)
+
+		if w.Code != http.StatusNoContent {
+			t.Fatalf("Expected status 204, got %d: %s", w.Code, w.Body.String())
+		}
+		if _, err := svc.Get(phone); err == nil {
+			t.Error("Expected phone session to be revoked")
+		}
+		if _, err := svc.Get(laptop); err != nil {
+			t.Error("Expected laptop session to remain")
+		}
+		for _, c := range w.Result().Cookies() {
+			if c.Name == sessionCookieName {
+				t.Error("Revoking another session must not clear the caller's cookie")
+			}
+		}
+	})
+
+	t.Run("cannot revoke another user's session", func(t *testing.T) {
+		h, svc, laptop, _, other := newSessionsTestHandler(t)
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/auth/sessions/x", nil)
+		req.SetPathValue("id", services.SessionHandle(other))
+		req.AddCookie(&http.Cookie{Name: sessionCookieName, Value: laptop})
+		w := httptest.NewRecorder()
+		h.RevokeSession(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+		if _, err := svc.Get(other); err != nil {
+			t.Error("Another user's session must not be revoked")
+		}
+	})
+
+	t.Run("revoking the current session clears its cookie", func(t *testing.T) {
+		h, svc, laptop, _, _ := newSessionsTestHandler(t)
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/auth/sessions/x", nil)
+		req.SetPathValue("id", services.SessionHandle(laptop))
+		req.AddCookie(&http.Cookie{Name: sessionCookieName, Value: laptop})
+		w := httptest.NewRecorder()
+		h.RevokeSession(w, req)
+
+		if w.Code != http.StatusNoContent {
+			t.Fatalf("Expected status 204, got %d", w.Code)
+		}
+		if _, err := svc.Get(laptop); err == nil {
+			t.Error("Expected current session to be revoked")
+		}
+		var cleared bool
+		for _, c := range w.Result().Cookies() {
+			if c.Name == sessionCookieName && c.MaxAge == -1 {
+				cleared = true
+			}
+		}
+		if !cleared {
+			t.Error("Expected session cookie to be cleared")
+		}
+	})
+}
+
+func TestRevokeUserSessions(t *testing.T) {
+	h, svc, laptop, phone, other := newSessionsTestHandler(t)
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/auth/users/user-1/sessions", nil)
+	req.SetPathValue("user_id", "user-1")
+	w := httptest.NewRecorder()
+	h.RevokeUserSessions(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp models.SessionRevokeResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.UserID != "user-1" || resp.Revoked != 2 {
+		t.Errorf("Expected 2 sessions revoked for user-1, got %+v", resp)
+	}
+	for _, id := range []string{laptop, phone} {
+		if _, err := svc.Get(id); err == nil {
+			t.Error("Expected user-1 session to be revoked")
+		}
+	}
+	if _, err := svc.Get(other); err != nil {
+		t.Error("Expected user-2 session to remain")
+	}
+}
+
+func TestTruncateUserAgent(t *testing.T) {
+	long := strings.Repeat("x", maxUserAgentLength+10)
+	if got := truncateUserAgent(long); len(got) != maxUserAgentLength {
+		t.Errorf("Expected user agent truncated to %d, got %d", maxUserAgentLength, len(got))
+	}
+	if got := truncateUserAgent("curl/8.0"); got != "curl/8.0" {
+		t.Errorf("Expected short user agent unchanged, got %q", got)
+	}
+}
@@ -0,0 +1,14 @@
+// ABOUTME: HTTP handler exposing the capacity thresholds the backend applies
+// ABOUTME: Lets the frontend and CLI render the same boundaries instead of hard-coding them
+
+package handlers
+
+import "net/http"
+
+// Thresholds returns the effective capacity thresholds: the defaults with any
+// THRESHOLDS overrides applied. Scenario warnings, POST /api/v1/check,
+// bottleneck analysis, and CPU risk levels all use these values.
+// HTTP method validation handled by Go 1.22+ router pattern matching.
+func (h *Handler) Thresholds(w http.ResponseWriter, r *http.Request) {
+	h.writeJSON(w, http.StatusOK, h.thresholds())
+}
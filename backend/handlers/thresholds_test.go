@@ -0,0 +1,97 @@
+// ABOUTME: Tests for the capacity thresholds endpoint
+// ABOUTME: Verifies defaults, configured overrides, and that overrides reach threshold checks
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/markalston/diego-capacity-analyzer/backend/config"
+	"github.com/markalston/diego-capacity-analyzer/backend/models"
+)
+
+func TestThresholdsHandler_Defaults(t *testing.T) {
+	h := NewHandler(nil, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/thresholds", nil)
+	w := httptest.NewRecorder()
+	h.Thresholds(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var resp models.Thresholds
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp != models.DefaultThresholds() {
+		t.Errorf("Expected default thresholds, got %+v", resp)
+	}
+}
+
+func TestThresholdsHandler_Overrides(t *testing.T) {
+	cfg := &config.Config{Thresholds: models.Thresholds{
+		WarningThresholds: models.WarningThresholds{FreeChunksCritical: 200, FreeChunksWarning: 400},
+		VCPURatioModerate: 3,
+	}}
+	h := NewHandler(cfg, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/thresholds", nil)
+	w := httptest.NewRecorder()
+	h.Thresholds(w, req)
+
+	var resp models.Thresholds
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.FreeChunksCritical != 200 || resp.FreeChunksWarning != 400 {
+		t.Errorf("Expected free chunks 400/200, got %d/%d", resp.FreeChunksWarning, resp.FreeChunksCritical)
+	}
+	if resp.VCPURatioModerate != 3 || resp.VCPURatioAggressive != models.DefaultVCPURatioAggressive {
+		t.Errorf("Expected vCPU tiers 3/%v, got %v/%v", models.DefaultVCPURatioAggressive, resp.VCPURatioModerate, resp.VCPURatioAggressive)
+	}
+	if resp.N1CriticalPct != models.DefaultN1CriticalPct {
+		t.Errorf("Expected unset N-1 critical to keep default, got %v", resp.N1CriticalPct)
+	}
+}
+
+func TestCheckThresholds_UsesConfiguredThresholds(t *testing.T) {
+	// 32 cells of 64GB leave roughly 300 free 4GB chunks: fine by default,
+	// critical once the site requires 400
+	body := `{"input":{"clusters":[{"name":"c1","host_count":8,"memory_gb_per_host":1024,"cpu_cores_per_host":64,
+		"diego_cell_count":32,"diego_cell_memory_gb":64,"diego_cell_cpu":8}],"total_app_memory_gb":800}}`
+
+	run := func(cfg *config.Config) models.CheckResponse {
+		t.Helper()
+		h := NewHandler(cfg, nil)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/check", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		h.CheckThresholds(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp models.CheckResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return resp
+	}
+
+	if resp := run(nil); resp.Thresholds.FreeChunksCritical != models.DefaultFreeChunksCritical {
+		t.Errorf("Expected default free chunks critical, got %d", resp.Thresholds.FreeChunksCritical)
+	}
+
+	resp := run(&config.Config{Thresholds: models.Thresholds{
+		WarningThresholds: models.WarningThresholds{FreeChunksCritical: 400},
+	}})
+	if resp.Thresholds.FreeChunksCritical != 400 || resp.Thresholds.FreeChunksWarning != 800 {
+		t.Errorf("Expected configured free chunks 800/400, got %d/%d", resp.Thresholds.FreeChunksWarning, resp.Thresholds.FreeChunksCritical)
+	}
+	if resp.Status != "critical" {
+		t.Errorf("Expected critical status against configured thresholds, got %q (%+v)", resp.Status, resp.Warnings)
+	}
+}
@@ -0,0 +1,55 @@
+// ABOUTME: Request-scoped logging context carrying the request correlation ID.
+// ABOUTME: Wraps slog handlers so *Context log calls include request_id automatically.
+
+package logger
+
+import (
+	"context"
+	"log/slog"
+)
+
+// requestIDKey is a private context key type to avoid collisions with other packages
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the given request ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID stored in ctx, or "" if none is set.
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// contextHandler adds the request ID from the record's context to every log line.
+type contextHandler struct {
+	slog.Handler
+}
+
+// NewContextHandler wraps h so records logged with a context (slog.InfoContext etc.)
+// carry a request_id attribute when the context has one.
+func NewContextHandler(h slog.Handler) slog.Handler {
+	return contextHandler{Handler: h}
+}
+
+// Handle adds request_id before delegating to the wrapped handler.
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs preserves the wrapper when attributes are added.
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup preserves the wrapper when a group is opened.
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithGroup(name)}
+}
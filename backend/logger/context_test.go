@@ -0,0 +1,42 @@
+// ABOUTME: Tests for request-scoped logging context
+// ABOUTME: Verifies request IDs are attached to log lines logged with a context
+
+package logger
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestContextHandler_AddsRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(NewContextHandler(slog.NewTextHandler(&buf, nil)))
+
+	ctx := WithRequestID(context.Background(), "abc123")
+	log.InfoContext(ctx, "with id")
+	log.With("component", "bosh").InfoContext(ctx, "derived logger")
+	log.Info("without context")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 log lines, got %d: %q", len(lines), buf.String())
+	}
+	if !strings.Contains(lines[0], "request_id=abc123") {
+		t.Errorf("expected request_id on context log line, got %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "component=bosh") || !strings.Contains(lines[1], "request_id=abc123") {
+		t.Errorf("expected request_id to survive With, got %q", lines[1])
+	}
+	if strings.Contains(lines[2], "request_id") {
+		t.Errorf("expected no request_id without context, got %q", lines[2])
+	}
+}
+
+func TestRequestID_Missing(t *testing.T) {
+	if id := RequestID(context.Background()); id != "" {
+		t.Errorf("expected empty request ID, got %q", id)
+	}
+}
@@ -12,6 +12,7 @@ import (
 // Init configures the default slog logger based on environment variables.
 // LOG_LEVEL: debug, info, warn, error (default: info)
 // LOG_FORMAT: text, json (default: text)
+// Log calls made with a request context include that request's request_id.
 func Init() {
 	level := parseLevel(os.Getenv("LOG_LEVEL"))
 	format := strings.ToLower(os.Getenv("LOG_FORMAT"))
@@ -27,7 +28,7 @@ func Init() {
 		handler = slog.NewTextHandler(os.Stdout, opts)
 	}
 
-	slog.SetDefault(slog.New(handler))
+	slog.SetDefault(slog.New(NewContextHandler(handler)))
 }
 
 // parseLevel converts a string log level to slog.Level.
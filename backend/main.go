@@ -4,23 +4,30 @@
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"io"
 	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/markalston/diego-capacity-analyzer/backend/audit"
 	"github.com/markalston/diego-capacity-analyzer/backend/cache"
 	"github.com/markalston/diego-capacity-analyzer/backend/config"
 	"github.com/markalston/diego-capacity-analyzer/backend/handlers"
 	"github.com/markalston/diego-capacity-analyzer/backend/logger"
+	"github.com/markalston/diego-capacity-analyzer/backend/metrics"
 	"github.com/markalston/diego-capacity-analyzer/backend/middleware"
 	"github.com/markalston/diego-capacity-analyzer/backend/services"
 	"github.com/markalston/diego-capacity-analyzer/backend/services/ai"
+	"github.com/markalston/diego-capacity-analyzer/backend/version"
 )
 
 func main() {
@@ -44,7 +51,7 @@ func main() {
 		os.Exit(1)
 	}
 
-	slog.Info("Starting Diego Capacity Analyzer Backend")
+	slog.Info("Starting Diego Capacity Analyzer Backend", "version", version.Version, "commit", version.Commit)
 	slog.Info("CF API configured")
 	slog.Debug("CF API endpoint", "url", cfg.CFAPIUrl)
 	if cfg.CFSkipSSLValidation {
@@ -71,12 +78,24 @@ func main() {
 
 	// Initialize cache
 	cacheTTL := time.Duration(cfg.CacheTTL) * time.Second
-	c := cache.New(cacheTTL)
-	slog.Info("Cache initialized", "ttl", cacheTTL)
+	c := cache.NewWithJitter(cacheTTL, float64(cfg.CacheTTLJitterPct))
+	slog.Info("Cache initialized", "ttl", cacheTTL, "jitter_pct", cfg.CacheTTLJitterPct)
 
 	// Initialize session service for BFF OAuth pattern
-	sessionService := services.NewSessionService(c)
-	slog.Info("Session service initialized")
+	var sessionStore services.SessionStore = services.NewCacheSessionStore(c)
+	if cfg.SessionStore == "redis" {
+		redisStore, err := services.NewRedisSessionStore(cfg.RedisURL)
+		if err != nil {
+			slog.Error("Failed to initialize Redis session store", "error", err)
+			os.Exit(1)
+		}
+		defer redisStore.Close()
+		sessionStore = redisStore
+	}
+	sessionService := services.NewSessionService(sessionStore)
+	sessionService.SetTimeouts(time.Duration(cfg.SessionIdleTimeout)*time.Second, time.Duration(cfg.SessionMaxLifetime)*time.Second)
+	slog.Info("Session service initialized", "store", cfg.SessionStore,
+		"idle_timeout_secs", cfg.SessionIdleTimeout, "max_lifetime_secs", cfg.SessionMaxLifetime)
 
 	// Initialize JWKS client for JWT signature verification (optional, graceful degradation)
 	var jwksClient *services.JWKSClient
@@ -92,14 +111,25 @@ func main() {
 		},
 	}
 
-	jwksClient, err = services.NewJWKSClient(uaaURL, httpClient)
+	jwksRefresh := time.Duration(cfg.JWKSRefreshInterval) * time.Second
+	jwksClient, err = services.NewJWKSClient(uaaURL, httpClient, jwksRefresh)
 	if err != nil {
 		slog.Warn("Failed to initialize JWKS client, Bearer token authentication unavailable",
 			"error", err,
 			"uaa_url", uaaURL,
 		)
 	} else {
-		slog.Info("JWKS client initialized", "uaa_url", uaaURL)
+		defer jwksClient.Close()
+		jwksClient.SetValidationOptions(services.JWTValidationOptions{
+			ExpectedIssuer:   cfg.AuthExpectedIssuer,
+			ExpectedAudience: cfg.AuthExpectedAudience,
+		})
+		slog.Info("JWKS client initialized",
+			"uaa_url", uaaURL,
+			"refresh_interval", jwksRefresh,
+			"expected_issuer", cfg.AuthExpectedIssuer,
+			"expected_audience", cfg.AuthExpectedAudience,
+		)
 	}
 
 	// Configure authentication middleware with session cookie support
@@ -167,6 +197,24 @@ func main() {
 	h := handlers.NewHandler(cfg, c)
 	h.SetSessionService(sessionService)
 
+	// Audit events go to stdout with the application log unless AUDIT_LOG_FILE is set
+	if cfg.AuditLogFile != "" {
+		auditLog, err := audit.Open(cfg.AuditLogFile)
+		if err != nil {
+			slog.Error("Failed to open audit log", "path", cfg.AuditLogFile, "error", err)
+			os.Exit(1)
+		}
+		defer auditLog.Close()
+		h.SetAuditLogger(auditLog)
+		slog.Info("Audit log enabled", "path", cfg.AuditLogFile)
+	}
+
+	// Restore infrastructure state and scenario baselines from the previous run before serving requests
+	if cfg.StateFile != "" {
+		h.RestoreInfrastructureState()
+		h.RestoreScenarioBaselines()
+	}
+
 	// Initialize AI provider (optional -- config.Load validates provider name and API key)
 	if cfg.AIProvider == "" {
 		slog.Info("AI provider not configured, advisor feature disabled")
@@ -190,14 +238,17 @@ func main() {
 		pattern := route.Method + " " + route.Path
 
 		// Build middleware chain based on route properties
-		// Order: CORS -> CSRF -> Auth (if protected) -> RBAC (if role required) -> RateLimit (if not exempt) -> LogRequest -> Handler
-		mws := []func(http.HandlerFunc) http.HandlerFunc{corsMiddleware, middleware.CSRF()}
+		// Order: RequestID -> CORS -> CSRF -> Auth (if protected) -> RBAC (if role/scope required) -> RateLimit (if not exempt) -> LogRequest -> Handler
+		mws := []func(http.HandlerFunc) http.HandlerFunc{middleware.RequestID, corsMiddleware, middleware.CSRF()}
 		if !route.Public {
 			mws = append(mws, middleware.Auth(authCfg))
 		}
 		if route.Role != "" && authCfg.Mode != middleware.AuthModeDisabled {
 			mws = append(mws, middleware.RequireRole(route.Role))
 		}
+		if route.Scope != "" && authCfg.Mode != middleware.AuthModeDisabled {
+			mws = append(mws, middleware.RequireScope(route.Scope))
+		}
 		if route.RateLimit != "none" {
 			rlMiddleware, ok := rateLimiters[route.RateLimit]
 			if !ok {
@@ -221,18 +272,63 @@ func main() {
 		}
 	}
 
+	// Prometheus scrape endpoint: reads the cached state only, never refreshes vSphere/BOSH.
+	// Scrapers authenticate like any API client unless METRICS_PUBLIC opts into anonymous access.
+	metricsMws := []func(http.HandlerFunc) http.HandlerFunc{middleware.RequestID}
+	if !cfg.MetricsPublic {
+		metricsMws = append(metricsMws, middleware.Auth(authCfg))
+	}
+	mux.HandleFunc("GET /metrics", middleware.Chain(metrics.NewRegistry(h.CurrentInfrastructureState).ServeHTTP, metricsMws...))
+	slog.Debug("Registered route", "pattern", "GET /metrics", "public", cfg.MetricsPublic, "rateLimit", "none")
+
 	// Handle OPTIONS for all /api/ paths (CORS preflight)
 	mux.HandleFunc("OPTIONS /api/", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
 		// Response is handled by CORS middleware for preflight
 	}))
 
-	// Start server
-	addr := ":" + cfg.Port
-	slog.Info("Server listening", "addr", addr)
-	if err := http.ListenAndServe(addr, mux); err != nil {
+	// Start server; SIGINT/SIGTERM triggers a graceful shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	server := &http.Server{Addr: ":" + cfg.Port, Handler: mux}
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("Server listening", "addr", server.Addr)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
 		slog.Error("Server failed", "error", err)
 		os.Exit(1)
+	case <-ctx.Done():
+		stop()
+	}
+
+	shutdownTimeout := time.Duration(cfg.ShutdownTimeout) * time.Second
+	slog.Info("Shutdown signal received, draining in-flight requests", "timeout", shutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("Drain timeout exceeded, closing remaining connections", "error", err)
+		server.Close()
+	} else {
+		slog.Info("In-flight requests drained")
+	}
+	if err := <-serverErr; !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("Server failed during shutdown", "error", err)
+	}
+
+	// Use a fresh deadline so connections are still released after a drain timeout
+	closeCtx, cancelClose := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelClose()
+	if err := h.Close(closeCtx); err != nil {
+		slog.Warn("Failed to close backend connections", "error", err)
+	} else {
+		slog.Info("Backend connections closed")
 	}
+	slog.Info("Shutdown complete")
 }
 
 // discoverUAAURL discovers the UAA URL from the CF API /v3/info endpoint.
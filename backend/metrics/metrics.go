@@ -0,0 +1,136 @@
+// ABOUTME: Prometheus text-format exposition of capacity gauges
+// ABOUTME: Renders the latest cached InfrastructureState without triggering a refresh
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/markalston/diego-capacity-analyzer/backend/models"
+	"github.com/markalston/diego-capacity-analyzer/backend/services"
+)
+
+// contentType is the Prometheus text exposition format version 0.0.4.
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// StateSource returns the latest computed infrastructure state, or nil if none is loaded.
+type StateSource func() *models.InfrastructureState
+
+// Sample is a single labeled gauge value.
+type Sample struct {
+	Labels map[string]string
+	Value  float64
+}
+
+// Family is a named gauge with its samples.
+type Family struct {
+	Name    string
+	Help    string
+	Samples []Sample
+}
+
+// Registry builds capacity gauges from a StateSource on each scrape.
+type Registry struct {
+	source StateSource
+	calc   *services.ScenarioCalculator
+}
+
+// NewRegistry creates a registry that reads state from source.
+func NewRegistry(source StateSource) *Registry {
+	return &Registry{
+		source: source,
+		calc:   services.NewScenarioCalculator(),
+	}
+}
+
+// Gather returns all gauge families for the current state.
+// Families are always present so scrapers see stable HELP/TYPE metadata;
+// samples are empty when no infrastructure data is loaded.
+func (r *Registry) Gather() []Family {
+	memory := Family{Name: "diego_memory_utilization_percent", Help: "Host memory utilization percent per cluster."}
+	n1 := Family{Name: "diego_n1_utilization_percent", Help: "Diego cell memory as a percent of N-1 host memory per cluster."}
+	chunks := Family{Name: "diego_free_chunks", Help: "Free staging chunks per cluster, with app memory apportioned by cell memory share."}
+	vcpu := Family{Name: "diego_vcpu_ratio", Help: "vCPU to physical core ratio per cluster."}
+	ha := Family{Name: "diego_ha_host_failures_survived", Help: "Host failures the cluster can survive under HA admission control."}
+
+	var state *models.InfrastructureState
+	if r.source != nil {
+		state = r.source()
+	}
+
+	if state != nil {
+		for _, cluster := range state.Clusters {
+			labels := map[string]string{"cluster": cluster.Name}
+
+			var n1Pct float64
+			if cluster.N1MemoryGB > 0 {
+				n1Pct = float64(cluster.TotalCellMemoryGB) / float64(cluster.N1MemoryGB) * 100
+			}
+
+			memory.Samples = append(memory.Samples, Sample{Labels: labels, Value: cluster.HostMemoryUtilizationPercent})
+			n1.Samples = append(n1.Samples, Sample{Labels: labels, Value: n1Pct})
+			vcpu.Samples = append(vcpu.Samples, Sample{Labels: labels, Value: cluster.VCPURatio})
+			ha.Samples = append(ha.Samples, Sample{Labels: labels, Value: float64(cluster.HAHostFailuresSurvived)})
+
+			// App memory is apportioned to the cluster by its share of cell memory
+			scoped, _ := state.ForCluster(cluster.Name)
+			current := r.calc.CalculateCurrent(scoped, nil)
+			chunks.Samples = append(chunks.Samples, Sample{Labels: labels, Value: float64(current.FreeChunks)})
+		}
+	}
+
+	return []Family{memory, n1, chunks, vcpu, ha}
+}
+
+// Write renders all gauge families in Prometheus text exposition format.
+func (r *Registry) Write(w io.Writer) error {
+	var sb strings.Builder
+	for _, f := range r.Gather() {
+		fmt.Fprintf(&sb, "# HELP %s %s\n", f.Name, f.Help)
+		fmt.Fprintf(&sb, "# TYPE %s gauge\n", f.Name)
+		for _, s := range f.Samples {
+			sb.WriteString(f.Name)
+			sb.WriteString(formatLabels(s.Labels))
+			sb.WriteByte(' ')
+			sb.WriteString(strconv.FormatFloat(s.Value, 'g', -1, 64))
+			sb.WriteByte('\n')
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// ServeHTTP implements http.Handler for the /metrics endpoint.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", contentType)
+	_ = r.Write(w)
+}
+
+// formatLabels renders labels as {k="v",...} in sorted key order.
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k + `="` + escapeLabelValue(labels[k]) + `"`
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// escapeLabelValue escapes backslash, double-quote, and newline per the exposition format.
+func escapeLabelValue(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
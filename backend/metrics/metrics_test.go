@@ -0,0 +1,113 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/markalston/diego-capacity-analyzer/backend/models"
+)
+
+func testState() *models.InfrastructureState {
+	input := models.ManualInput{
+		Name: "prod",
+		Clusters: []models.ClusterInput{
+			{
+				Name:              "cluster-a",
+				HostCount:         4,
+				MemoryGBPerHost:   1024,
+				CPUThreadsPerHost: 64,
+				DiegoCellCount:    20,
+				DiegoCellMemoryGB: 64,
+				DiegoCellCPU:      8,
+			},
+			{
+				Name:              "cluster-b",
+				HostCount:         2,
+				MemoryGBPerHost:   512,
+				CPUThreadsPerHost: 32,
+				DiegoCellCount:    4,
+				DiegoCellMemoryGB: 64,
+				DiegoCellCPU:      8,
+			},
+		},
+		TotalAppMemoryGB: 800,
+	}
+	state := input.ToInfrastructureState()
+	return &state
+}
+
+func TestRegistry_WriteWithState(t *testing.T) {
+	r := NewRegistry(func() *models.InfrastructureState { return testState() })
+
+	var sb strings.Builder
+	if err := r.Write(&sb); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	out := sb.String()
+
+	for _, name := range []string{
+		"diego_memory_utilization_percent",
+		"diego_n1_utilization_percent",
+		"diego_free_chunks",
+		"diego_vcpu_ratio",
+		"diego_ha_host_failures_survived",
+	} {
+		if !strings.Contains(out, "# TYPE "+name+" gauge\n") {
+			t.Errorf("Expected TYPE line for %s", name)
+		}
+	}
+
+	for _, want := range []string{
+		`diego_memory_utilization_percent{cluster="cluster-a"}`,
+		`diego_memory_utilization_percent{cluster="cluster-b"}`,
+		`diego_vcpu_ratio{cluster="cluster-a"} 0.625`,
+		`diego_free_chunks{cluster="cluster-a"}`,
+		`diego_free_chunks{cluster="cluster-b"}`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestRegistry_GatherNoState(t *testing.T) {
+	r := NewRegistry(func() *models.InfrastructureState { return nil })
+
+	families := r.Gather()
+	if len(families) != 5 {
+		t.Fatalf("Expected 5 gauge families, got %d", len(families))
+	}
+	for _, f := range families {
+		if len(f.Samples) != 0 {
+			t.Errorf("Expected no samples for %s without state, got %d", f.Name, len(f.Samples))
+		}
+	}
+}
+
+func TestRegistry_ServeHTTP(t *testing.T) {
+	r := NewRegistry(func() *models.InfrastructureState { return testState() })
+
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Expected Prometheus text content type, got %q", ct)
+	}
+	if !strings.Contains(w.Body.String(), `cluster="cluster-a"`) {
+		t.Error("Expected cluster label in response body")
+	}
+}
+
+func TestFormatLabels_Escaping(t *testing.T) {
+	got := formatLabels(map[string]string{"b": "x", "a": "say \"hi\"\\\n"})
+	want := `{a="say \"hi\"\\\n",b="x"}`
+	if got != want {
+		t.Errorf("formatLabels = %s, want %s", got, want)
+	}
+}
@@ -111,7 +111,7 @@ func Auth(cfg AuthConfig) func(http.HandlerFunc) http.HandlerFunc {
 			if authHeader != "" {
 				// Validate Bearer format
 				if !strings.HasPrefix(authHeader, "Bearer ") {
-					slog.Debug("Auth rejected: invalid format", "path", r.URL.Path)
+					slog.DebugContext(r.Context(), "Auth rejected: invalid format", "path", r.URL.Path)
 					writeJSONError(w, "Invalid authorization format", http.StatusUnauthorized)
 					return
 				}
@@ -120,7 +120,7 @@ func Auth(cfg AuthConfig) func(http.HandlerFunc) http.HandlerFunc {
 
 				// If JWKSClient is not configured, Bearer auth is unavailable
 				if cfg.JWKSClient == nil {
-					slog.Debug("Auth rejected: JWKSClient not configured", "path", r.URL.Path)
+					slog.DebugContext(r.Context(), "Auth rejected: JWKSClient not configured", "path", r.URL.Path)
 					writeJSONError(w, "Bearer authentication unavailable, please use web UI login", http.StatusUnauthorized)
 					return
 				}
@@ -130,7 +130,7 @@ func Auth(cfg AuthConfig) func(http.HandlerFunc) http.HandlerFunc {
 				if err != nil {
 					// Log detailed error for debugging, but return generic message to client
 					// to avoid leaking internal details (key IDs, algorithm info, etc.)
-					slog.Debug("Auth rejected: invalid token", "path", r.URL.Path, "error", err.Error())
+					slog.DebugContext(r.Context(), "Auth rejected: invalid token", "path", r.URL.Path, "error", err.Error())
 					writeJSONError(w, "Invalid or expired token", http.StatusUnauthorized)
 					return
 				}
@@ -143,7 +143,7 @@ func Auth(cfg AuthConfig) func(http.HandlerFunc) http.HandlerFunc {
 					Role:     ResolveRole(jwtClaims.Scopes),
 				}
 
-				slog.Debug("Auth: valid bearer token", "path", r.URL.Path, "user", claims.Username)
+				slog.DebugContext(r.Context(), "Auth: valid bearer token", "path", r.URL.Path, "user", claims.Username)
 				ctx := context.WithValue(r.Context(), userClaimsKey, claims)
 				next(w, r.WithContext(ctx))
 				return
@@ -155,13 +155,13 @@ func Auth(cfg AuthConfig) func(http.HandlerFunc) http.HandlerFunc {
 				if err == nil && cookie.Value != "" {
 					claims := cfg.SessionValidator(cookie.Value)
 					if claims != nil {
-						slog.Debug("Auth: valid session cookie", "path", r.URL.Path, "user", claims.Username)
+						slog.DebugContext(r.Context(), "Auth: valid session cookie", "path", r.URL.Path, "user", claims.Username)
 						ctx := context.WithValue(r.Context(), userClaimsKey, claims)
 						next(w, r.WithContext(ctx))
 						return
 					}
 					// Session cookie present but invalid
-					slog.Debug("Auth rejected: invalid session", "path", r.URL.Path)
+					slog.DebugContext(r.Context(), "Auth rejected: invalid session", "path", r.URL.Path)
 					writeJSONError(w, "Invalid session", http.StatusUnauthorized)
 					return
 				}
@@ -169,13 +169,13 @@ func Auth(cfg AuthConfig) func(http.HandlerFunc) http.HandlerFunc {
 
 			// No auth provided
 			if cfg.Mode == AuthModeRequired {
-				slog.Debug("Auth rejected: no auth provided", "path", r.URL.Path, "mode", cfg.Mode)
+				slog.DebugContext(r.Context(), "Auth rejected: no auth provided", "path", r.URL.Path, "mode", cfg.Mode)
 				writeJSONError(w, "Authentication required", http.StatusUnauthorized)
 				return
 			}
 
 			// Optional mode with no auth: pass through
-			slog.Debug("Auth: anonymous request allowed", "path", r.URL.Path, "mode", cfg.Mode)
+			slog.DebugContext(r.Context(), "Auth: anonymous request allowed", "path", r.URL.Path, "mode", cfg.Mode)
 			next(w, r)
 		}
 	}
@@ -37,7 +37,7 @@ func CSRF() func(http.HandlerFunc) http.HandlerFunc {
 			// Skip login endpoint -- it creates a new session and must work
 			// even when the browser has a stale session cookie with no CSRF cookie
 			if r.URL.Path == "/api/v1/auth/login" || r.URL.Path == "/api/auth/login" {
-				slog.Debug("CSRF skipped: login endpoint", "path", r.URL.Path)
+				slog.DebugContext(r.Context(), "CSRF skipped: login endpoint", "path", r.URL.Path)
 				next(w, r)
 				return
 			}
@@ -58,33 +58,33 @@ func CSRF() func(http.HandlerFunc) http.HandlerFunc {
 			// Session-authenticated request - validate CSRF token
 			csrfCookie, err := r.Cookie(csrfCookieName)
 			if err != nil || csrfCookie.Value == "" {
-				slog.Debug("CSRF rejected: missing cookie", "path", r.URL.Path)
+				slog.DebugContext(r.Context(), "CSRF rejected: missing cookie", "path", r.URL.Path)
 				writeJSONError(w, "CSRF token missing or invalid", http.StatusForbidden)
 				return
 			}
 
 			csrfHeader := r.Header.Get(csrfHeaderName)
 			if csrfHeader == "" {
-				slog.Debug("CSRF rejected: missing header", "path", r.URL.Path)
+				slog.DebugContext(r.Context(), "CSRF rejected: missing header", "path", r.URL.Path)
 				writeJSONError(w, "CSRF token missing or invalid", http.StatusForbidden)
 				return
 			}
 
 			// Validate token lengths before comparison
 			if len(csrfCookie.Value) != csrfTokenLength || len(csrfHeader) != csrfTokenLength {
-				slog.Debug("CSRF rejected: invalid token length", "path", r.URL.Path)
+				slog.DebugContext(r.Context(), "CSRF rejected: invalid token length", "path", r.URL.Path)
 				writeJSONError(w, "CSRF token missing or invalid", http.StatusForbidden)
 				return
 			}
 
 			// Constant-time comparison to prevent timing attacks
 			if subtle.ConstantTimeCompare([]byte(csrfCookie.Value), []byte(csrfHeader)) != 1 {
-				slog.Debug("CSRF rejected: token mismatch", "path", r.URL.Path)
+				slog.DebugContext(r.Context(), "CSRF rejected: token mismatch", "path", r.URL.Path)
 				writeJSONError(w, "CSRF token missing or invalid", http.StatusForbidden)
 				return
 			}
 
-			slog.Debug("CSRF validated", "path", r.URL.Path)
+			slog.DebugContext(r.Context(), "CSRF validated", "path", r.URL.Path)
 			next(w, r)
 		}
 	}
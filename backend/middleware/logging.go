@@ -10,6 +10,8 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/markalston/diego-capacity-analyzer/backend/logger"
 )
 
 // responseWriter wraps http.ResponseWriter to capture the status code.
@@ -42,13 +44,19 @@ func (rw *responseWriter) Unwrap() http.ResponseWriter {
 }
 
 // LogRequest logs HTTP requests with timing and correlation ID.
+// It reuses the ID assigned by RequestID; if that middleware is not in the chain,
+// it assigns one itself.
 func LogRequest(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
-		requestID := generateRequestID()
+		requestID := logger.RequestID(r.Context())
+		if requestID == "" {
+			requestID = generateRequestID()
+			r = r.WithContext(logger.WithRequestID(r.Context(), requestID))
 
-		// Add request ID to response header
-		w.Header().Set("X-Request-ID", requestID)
+			// Add request ID to response header
+			w.Header().Set(requestIDHeader, requestID)
+		}
 
 		slog.Info("Request started",
 			"request_id", requestID,
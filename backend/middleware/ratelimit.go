@@ -165,7 +165,7 @@ func RateLimit(limiter *RateLimiter, keyFunc func(*http.Request) string) func(ht
 
 			// Rate limited
 			retrySeconds := int(math.Ceil(retryAfter.Seconds()))
-			slog.Warn("Rate limit exceeded", "key", key, "path", r.URL.Path, "retry_after", retrySeconds)
+			slog.WarnContext(r.Context(), "Rate limit exceeded", "key", key, "path", r.URL.Path, "retry_after", retrySeconds)
 
 			w.Header().Set("Content-Type", "application/json")
 			w.Header().Set("Retry-After", fmt.Sprintf("%d", retrySeconds))
@@ -1,5 +1,5 @@
 // ABOUTME: Role-based access control middleware for API endpoints
-// ABOUTME: Gates endpoints by required role derived from JWT scopes
+// ABOUTME: Gates endpoints by required role or UAA scope from JWT/session claims
 
 package middleware
 
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"slices"
 )
 
 // roleHierarchy defines the privilege level for each role.
@@ -42,7 +43,7 @@ func RequireRole(requiredRole string) func(http.HandlerFunc) http.HandlerFunc {
 				if claims != nil {
 					username = claims.Username
 				}
-				slog.Warn("RBAC authorization denied",
+				slog.WarnContext(r.Context(), "RBAC authorization denied",
 					"path", r.URL.Path,
 					"method", r.Method,
 					"required_role", requiredRole,
@@ -57,3 +58,36 @@ func RequireRole(requiredRole string) func(http.HandlerFunc) http.HandlerFunc {
 		}
 	}
 }
+
+// RequireScope returns middleware that requires the caller's UAA scopes to include scope.
+// Scopes come from the UserClaims stored by Auth, whether from a Bearer token or a session.
+// Panics if scope is empty (catches config errors at startup).
+// Anonymous requests have no scopes and are rejected.
+// Returns 403 Forbidden if the scope is missing.
+func RequireScope(scope string) func(http.HandlerFunc) http.HandlerFunc {
+	if scope == "" {
+		panic("RequireScope: scope must not be empty")
+	}
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			claims := GetUserClaims(r)
+			if claims != nil && slices.Contains(claims.Scopes, scope) {
+				next(w, r)
+				return
+			}
+
+			username := ""
+			if claims != nil {
+				username = claims.Username
+			}
+			slog.WarnContext(r.Context(), "Scope authorization denied",
+				"path", r.URL.Path,
+				"method", r.Method,
+				"required_scope", scope,
+				"username", username,
+			)
+			writeJSONError(w, "Insufficient permissions", http.StatusForbidden)
+		}
+	}
+}
@@ -147,3 +147,46 @@ func TestRequireRole_OperatorClaims_ViewerRequired_Passes(t *testing.T) {
 		t.Errorf("Status = %d, want %d", rec.Code, http.StatusOK)
 	}
 }
+
+func TestRequireScope(t *testing.T) {
+	tests := []struct {
+		name       string
+		claims     *UserClaims
+		wantStatus int
+	}{
+		{"bearer or session scopes include required scope", &UserClaims{Username: "op-user", Scopes: []string{"openid", ScopeOperator}}, http.StatusOK},
+		{"scopes missing required scope", &UserClaims{Username: "view-user", Scopes: []string{"openid", ScopeViewer}}, http.StatusForbidden},
+		{"operator role without scope", &UserClaims{Username: "role-only", Role: RoleOperator}, http.StatusForbidden},
+		{"anonymous", nil, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := RequireScope(ScopeOperator)(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/scenario/compare", nil)
+			if tt.claims != nil {
+				req = WithUserClaims(req, tt.claims)
+			}
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("Status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestRequireScope_EmptyScope_Panics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("RequireScope should panic for empty scope")
+		}
+	}()
+
+	RequireScope("")
+	t.Fatal("Should not reach here")
+}
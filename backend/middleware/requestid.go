@@ -0,0 +1,49 @@
+// ABOUTME: Request ID middleware assigning a correlation ID to every request.
+// ABOUTME: Honors a well-formed incoming X-Request-ID, stores it in context, echoes it back.
+
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/markalston/diego-capacity-analyzer/backend/logger"
+)
+
+// requestIDHeader is the header used to receive and echo correlation IDs
+const requestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds caller-supplied IDs so they cannot bloat log lines
+const maxRequestIDLength = 64
+
+// RequestID assigns each request a correlation ID and stores it in the request context
+// (see logger.RequestID), so any slog *Context call made while serving the request
+// carries it. A caller-supplied X-Request-ID is reused if it is well-formed, letting a
+// frontend action be traced through backend, BOSH, and vSphere log lines. The ID is
+// echoed in the X-Request-ID response header.
+func RequestID(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = generateRequestID()
+		}
+
+		w.Header().Set(requestIDHeader, id)
+		next(w, r.WithContext(logger.WithRequestID(r.Context(), id)))
+	}
+}
+
+// validRequestID accepts 1-64 characters of letters, digits, '-', '_', and '.',
+// rejecting anything that could inject content into logs or headers.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
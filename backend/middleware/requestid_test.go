@@ -0,0 +1,71 @@
+// ABOUTME: Tests for request ID middleware
+// ABOUTME: Verifies ID generation, incoming header reuse, and context propagation
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/markalston/diego-capacity-analyzer/backend/logger"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		wantSame bool
+	}{
+		{"generates ID when header absent", "", false},
+		{"honors well-formed incoming ID", "frontend-7f3a.01_b", true},
+		{"replaces ID with control characters", "abc\ninjected", false},
+		{"replaces ID with spaces", "abc def", false},
+		{"replaces overly long ID", strings.Repeat("a", 65), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ctxID string
+			handler := Chain(func(w http.ResponseWriter, r *http.Request) {
+				ctxID = logger.RequestID(r.Context())
+			}, RequestID, LogRequest)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/infrastructure", nil)
+			if tt.incoming != "" {
+				req.Header.Set("X-Request-ID", tt.incoming)
+			}
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+
+			headerID := rec.Header().Get("X-Request-ID")
+			if headerID == "" {
+				t.Fatal("X-Request-ID header should be set")
+			}
+			if ctxID != headerID {
+				t.Errorf("context request ID %q does not match header %q", ctxID, headerID)
+			}
+			if tt.wantSame && headerID != tt.incoming {
+				t.Errorf("X-Request-ID = %q, want incoming %q", headerID, tt.incoming)
+			}
+			if !tt.wantSame && (headerID == tt.incoming || len(headerID) != 16) {
+				t.Errorf("X-Request-ID = %q, want freshly generated 16-char ID", headerID)
+			}
+		})
+	}
+}
+
+func TestLogRequest_StoresRequestIDInContext(t *testing.T) {
+	var ctxID string
+	handler := LogRequest(func(w http.ResponseWriter, r *http.Request) {
+		ctxID = logger.RequestID(r.Context())
+	})
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/api/test", nil))
+
+	if ctxID == "" || ctxID != rec.Header().Get("X-Request-ID") {
+		t.Errorf("context request ID %q should match header %q", ctxID, rec.Header().Get("X-Request-ID"))
+	}
+}
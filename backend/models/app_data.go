@@ -0,0 +1,32 @@
+// ABOUTME: Snapshot of CF app totals cached apart from the infrastructure topology
+// ABOUTME: App usage changes faster than cells and hosts, so it is refreshed on its own TTL
+
+package models
+
+import "time"
+
+// AppDataSnapshot holds the app totals summed from the CF API at Timestamp
+type AppDataSnapshot struct {
+	TotalAppMemoryGB    int       `json:"total_app_memory_gb"`
+	TotalAppDiskGB      int       `json:"total_app_disk_gb"`
+	TotalAppInstances   int       `json:"total_app_instances"`
+	MaxInstanceMemoryMB int       `json:"max_instance_memory_mb"`
+	Timestamp           time.Time `json:"timestamp"`
+}
+
+// ApplyTo replaces the app totals of state with the snapshot's and records
+// when they were read. The infrastructure Timestamp is left alone.
+func (s AppDataSnapshot) ApplyTo(state *InfrastructureState) {
+	state.TotalAppMemoryGB = s.TotalAppMemoryGB
+	state.TotalAppDiskGB = s.TotalAppDiskGB
+	state.TotalAppInstances = s.TotalAppInstances
+	state.MaxInstanceMemoryMB = s.MaxInstanceMemoryMB
+	ts := s.Timestamp
+	state.AppDataTimestamp = &ts
+}
+
+// NewerThan reports whether the snapshot was read after the app totals in
+// state. States whose totals came with the input are never older.
+func (s AppDataSnapshot) NewerThan(state InfrastructureState) bool {
+	return state.AppDataTimestamp != nil && s.Timestamp.After(*state.AppDataTimestamp)
+}
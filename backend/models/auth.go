@@ -38,4 +38,34 @@ type Session struct {
 	CSRFToken    string    `json:"-"` // Never expose to client
 	TokenExpiry  time.Time `json:"token_expiry"`
 	CreatedAt    time.Time `json:"created_at"`
+
+	UserAgent string    `json:"user_agent"` // captured at login
+	LastSeen  time.Time `json:"last_seen"`  // updated at most once a minute
+
+	// The refresh token the last rotation replaced, and when, so a replica
+	// renewing concurrently can be told apart from a replayed token
+	PreviousRefreshToken string    `json:"-"` // Never expose to client
+	RefreshRotatedAt     time.Time `json:"-"`
+}
+
+// SessionInfo describes one active session for the session list. ID is a
+// handle derived from the session ID, which is never returned since it
+// authenticates the session.
+type SessionInfo struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	LastSeen  time.Time `json:"last_seen"`
+	UserAgent string    `json:"user_agent"`
+	Current   bool      `json:"current"` // the session making the request
+}
+
+// SessionListResponse is the response for GET /api/v1/auth/sessions
+type SessionListResponse struct {
+	Sessions []SessionInfo `json:"sessions"`
+}
+
+// SessionRevokeResponse is the response for revoking all of a user's sessions
+type SessionRevokeResponse struct {
+	UserID  string `json:"user_id"`
+	Revoked int    `json:"revoked"`
 }
@@ -1,5 +1,5 @@
 // ABOUTME: Multi-resource bottleneck analysis for capacity planning
-// ABOUTME: Ranks resources by utilization and identifies constraining resource
+// ABOUTME: Ranks resources by utilization or safety-ceiling headroom and identifies the constraining resource
 
 package models
 
@@ -8,6 +8,17 @@ import (
 	"sort"
 )
 
+// DefaultTargetVCPURatio is the vCPU:pCPU ratio treated as full CPU capacity
+// in bottleneck analysis (4:1, the upper bound of the "conservative" risk level)
+const DefaultTargetVCPURatio = DefaultVCPURatioModerate
+
+// Bottleneck rankings accepted by AnalyzeBottleneckRanked and the rank query
+// parameter of /api/v1/bottleneck
+const (
+	RankByUtilization = "utilization" // Highest UsedPercent first (default)
+	RankByHeadroom    = "headroom"    // Least HeadroomPercent below the safety ceiling first
+)
+
 // ResourceUtilization represents the utilization of a single resource type
 type ResourceUtilization struct {
 	Name           string  `json:"name"`
@@ -16,6 +27,10 @@ type ResourceUtilization struct {
 	UsedCapacity   int     `json:"used_capacity"`
 	Unit           string  `json:"unit"`
 	IsConstraining bool    `json:"is_constraining"`
+	// CeilingPercent is the resource's safety ceiling; HeadroomPercent is the
+	// points of utilization left below it (negative once past it)
+	CeilingPercent  float64 `json:"ceiling_percent"`
+	HeadroomPercent float64 `json:"headroom_percent"`
 }
 
 // BottleneckAnalysis represents the complete bottleneck analysis result
@@ -23,6 +38,7 @@ type BottleneckAnalysis struct {
 	Resources            []ResourceUtilization `json:"resources"`
 	ConstrainingResource string                `json:"constraining_resource"`
 	Summary              string                `json:"summary"`
+	Ranking              string                `json:"ranking"` // RankByUtilization or RankByHeadroom
 }
 
 // RankResourcesByUtilization sorts resources by utilization percentage in descending order
@@ -49,6 +65,28 @@ func RankResourcesByUtilization(resources []ResourceUtilization) []ResourceUtili
 	return ranked
 }
 
+// RankResourcesByHeadroom sorts resources by headroom below their safety
+// ceiling in ascending order and marks the resource closest to (or furthest
+// past) its ceiling as constraining.
+func RankResourcesByHeadroom(resources []ResourceUtilization) []ResourceUtilization {
+	if len(resources) == 0 {
+		return resources
+	}
+
+	ranked := make([]ResourceUtilization, len(resources))
+	copy(ranked, resources)
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].HeadroomPercent < ranked[j].HeadroomPercent
+	})
+
+	for i := range ranked {
+		ranked[i].IsConstraining = (i == 0)
+	}
+
+	return ranked
+}
+
 // GetConstrainingResource returns the resource with the highest utilization
 func GetConstrainingResource(resources []ResourceUtilization) *ResourceUtilization {
 	if len(resources) == 0 {
@@ -60,24 +98,57 @@ func GetConstrainingResource(resources []ResourceUtilization) *ResourceUtilizati
 }
 
 // AnalyzeBottleneck performs multi-resource bottleneck analysis on infrastructure state
+// using the default thresholds
 func AnalyzeBottleneck(state InfrastructureState) BottleneckAnalysis {
-	resources := buildResourceList(state)
-	ranked := RankResourcesByUtilization(resources)
+	return DefaultThresholds().AnalyzeBottleneck(state)
+}
+
+// AnalyzeBottleneck performs multi-resource bottleneck analysis, treating
+// VCPURatioModerate as full CPU capacity and ranking by raw utilization
+func (t Thresholds) AnalyzeBottleneck(state InfrastructureState) BottleneckAnalysis {
+	return t.AnalyzeBottleneckRanked(state, RankByUtilization)
+}
+
+// AnalyzeBottleneckRanked performs multi-resource bottleneck analysis with
+// the given ranking. RankByHeadroom puts the resource with the least room
+// below its safety ceiling first, so an 80% resource with an 85% ceiling
+// outranks an 85% resource with a 95% ceiling. Any other ranking ranks by
+// utilization.
+func (t Thresholds) AnalyzeBottleneckRanked(state InfrastructureState, ranking string) BottleneckAnalysis {
+	targetVCPURatio, _ := t.vcpuTiers()
+	resources := buildResourceList(state, targetVCPURatio)
+	for i := range resources {
+		resources[i].CeilingPercent = t.CeilingPct(resources[i].Name)
+		resources[i].HeadroomPercent = resources[i].CeilingPercent - resources[i].UsedPercent
+	}
+
+	var ranked []ResourceUtilization
+	if ranking == RankByHeadroom {
+		ranked = RankResourcesByHeadroom(resources)
+	} else {
+		ranking = RankByUtilization
+		ranked = RankResourcesByUtilization(resources)
+	}
 
 	analysis := BottleneckAnalysis{
 		Resources: ranked,
+		Ranking:   ranking,
 	}
 
 	if len(ranked) > 0 {
 		analysis.ConstrainingResource = ranked[0].Name
-		analysis.Summary = buildSummary(ranked)
+		if ranking == RankByHeadroom {
+			analysis.Summary = buildHeadroomSummary(ranked)
+		} else {
+			analysis.Summary = buildSummary(state, ranked, targetVCPURatio)
+		}
 	}
 
 	return analysis
 }
 
 // buildResourceList extracts resource utilization data from infrastructure state
-func buildResourceList(state InfrastructureState) []ResourceUtilization {
+func buildResourceList(state InfrastructureState, targetVCPURatio float64) []ResourceUtilization {
 	var resources []ResourceUtilization
 
 	// Memory utilization (app memory used / total cell memory capacity)
@@ -92,27 +163,55 @@ func buildResourceList(state InfrastructureState) []ResourceUtilization {
 		})
 	}
 
-	// CPU utilization (based on vCPU:pCPU ratio)
-	// Uses host CPU utilization percent directly since it represents vCPU overcommit
+	// CPU utilization (vCPUs allocated / vCPUs allowed at the target vCPU:pCPU ratio),
+	// so 100% means cells are overcommitted exactly to the target ratio
 	if state.TotalCPUCores > 0 {
+		vcpuCapacity := int(float64(state.TotalCPUCores) * targetVCPURatio)
 		resources = append(resources, ResourceUtilization{
 			Name:          "CPU",
-			UsedPercent:   state.HostCPUUtilizationPercent,
-			TotalCapacity: state.TotalCPUCores,
+			UsedPercent:   (float64(state.TotalVCPUs) / float64(vcpuCapacity)) * 100.0,
+			TotalCapacity: vcpuCapacity,
 			UsedCapacity:  state.TotalVCPUs,
-			Unit:          "cores",
+			Unit:          "vCPUs",
 		})
 	}
 
-	// Disk utilization (app disk used / total cell disk capacity)
+	// Disk utilization (app disk used / total cell disk capacity), raised to the
+	// disk usage observed on cells via BOSH vitals when that is higher
 	totalCellDiskGB := calculateTotalCellDisk(state)
 	if totalCellDiskGB > 0 {
 		diskPercent := (float64(state.TotalAppDiskGB) / float64(totalCellDiskGB)) * 100.0
+		usedDiskGB := state.TotalAppDiskGB
+		if state.ObservedCellDiskPercent > diskPercent {
+			diskPercent = state.ObservedCellDiskPercent
+			usedDiskGB = int(float64(totalCellDiskGB) * diskPercent / 100.0)
+		}
 		resources = append(resources, ResourceUtilization{
 			Name:          "Disk",
 			UsedPercent:   diskPercent,
 			TotalCapacity: totalCellDiskGB,
-			UsedCapacity:  state.TotalAppDiskGB,
+			UsedCapacity:  usedDiskGB,
+			Unit:          "GB",
+		})
+	} else if state.ObservedCellDiskPercent > 0 {
+		// No configured cell disk size (e.g. vSphere discovery), so report observed usage as a percentage
+		resources = append(resources, ResourceUtilization{
+			Name:          "Disk",
+			UsedPercent:   state.ObservedCellDiskPercent,
+			TotalCapacity: 100,
+			UsedCapacity:  int(state.ObservedCellDiskPercent),
+			Unit:          "%",
+		})
+	}
+
+	// Datastore utilization (used / capacity of shared datastores discovered from vSphere)
+	if state.TotalDatastoreCapacityGB > 0 {
+		usedGB := state.TotalDatastoreCapacityGB - state.TotalDatastoreFreeGB
+		resources = append(resources, ResourceUtilization{
+			Name:          "Datastore",
+			UsedPercent:   (float64(usedGB) / float64(state.TotalDatastoreCapacityGB)) * 100.0,
+			TotalCapacity: state.TotalDatastoreCapacityGB,
+			UsedCapacity:  usedGB,
 			Unit:          "GB",
 		})
 	}
@@ -130,12 +229,27 @@ func calculateTotalCellDisk(state InfrastructureState) int {
 }
 
 // buildSummary generates a human-readable summary of the bottleneck analysis
-func buildSummary(ranked []ResourceUtilization) string {
+func buildSummary(state InfrastructureState, ranked []ResourceUtilization, targetVCPURatio float64) string {
 	if len(ranked) == 0 {
 		return "No resources to analyze."
 	}
 
 	constraining := ranked[0]
+	if constraining.Name == "CPU" {
+		return fmt.Sprintf("CPU is your constraint at %.1f%% utilization: %d vCPUs on %d pCPU cores is a %.1f:1 vCPU:pCPU ratio against a %.0f:1 target. Add hosts or reduce cell vCPUs before addressing other resources.",
+			constraining.UsedPercent, state.TotalVCPUs, state.TotalCPUCores, state.VCPURatio, targetVCPURatio)
+	}
 	return fmt.Sprintf("%s is your constraint at %.1f%% utilization. Address %s capacity before other resources.",
 		constraining.Name, constraining.UsedPercent, constraining.Name)
 }
+
+// buildHeadroomSummary generates a summary for headroom-ranked resources
+func buildHeadroomSummary(ranked []ResourceUtilization) string {
+	constraining := ranked[0]
+	if constraining.HeadroomPercent < 0 {
+		return fmt.Sprintf("%s is your constraint at %.1f%% utilization, %.1f points past its %.0f%% safety ceiling. Address %s capacity before other resources.",
+			constraining.Name, constraining.UsedPercent, -constraining.HeadroomPercent, constraining.CeilingPercent, constraining.Name)
+	}
+	return fmt.Sprintf("%s is your constraint at %.1f%% utilization, %.1f points below its %.0f%% safety ceiling. Address %s capacity before other resources.",
+		constraining.Name, constraining.UsedPercent, constraining.HeadroomPercent, constraining.CeilingPercent, constraining.Name)
+}
@@ -4,6 +4,7 @@
 package models
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -205,6 +206,87 @@ func TestBottleneckAnalysis_FromInfrastructureState(t *testing.T) {
 	}
 }
 
+func TestBottleneckAnalysis_ObservedDiskPressure(t *testing.T) {
+	findDisk := func(t *testing.T, analysis BottleneckAnalysis) ResourceUtilization {
+		t.Helper()
+		for _, r := range analysis.Resources {
+			if r.Name == "Disk" {
+				return r
+			}
+		}
+		t.Fatal("Expected a Disk resource")
+		return ResourceUtilization{}
+	}
+
+	t.Run("observed usage above configured raises disk utilization", func(t *testing.T) {
+		state := InfrastructureState{
+			Clusters:                []ClusterState{{DiegoCellCount: 10, DiegoCellDiskGB: 100}},
+			TotalAppDiskGB:          200, // 20% of configured disk
+			ObservedCellDiskPercent: 65,
+		}
+		disk := findDisk(t, AnalyzeBottleneck(state))
+		if disk.UsedPercent != 65 || disk.UsedCapacity != 650 || disk.TotalCapacity != 1000 {
+			t.Errorf("Disk = %.1f%% (%d/%d GB), want 65%% (650/1000 GB)", disk.UsedPercent, disk.UsedCapacity, disk.TotalCapacity)
+		}
+	})
+
+	t.Run("observed usage below configured keeps configured value", func(t *testing.T) {
+		state := InfrastructureState{
+			Clusters:                []ClusterState{{DiegoCellCount: 10, DiegoCellDiskGB: 100}},
+			TotalAppDiskGB:          500,
+			ObservedCellDiskPercent: 30,
+		}
+		disk := findDisk(t, AnalyzeBottleneck(state))
+		if disk.UsedPercent != 50 || disk.UsedCapacity != 500 {
+			t.Errorf("Disk = %.1f%% (%d GB), want 50%% (500 GB)", disk.UsedPercent, disk.UsedCapacity)
+		}
+	})
+
+	t.Run("observed usage without configured disk size", func(t *testing.T) {
+		state := InfrastructureState{
+			Clusters:                []ClusterState{{DiegoCellCount: 10}},
+			ObservedCellDiskPercent: 72.5,
+		}
+		disk := findDisk(t, AnalyzeBottleneck(state))
+		if disk.UsedPercent != 72.5 || disk.Unit != "%" {
+			t.Errorf("Disk = %.1f %s, want 72.5 %%", disk.UsedPercent, disk.Unit)
+		}
+	})
+}
+
+func TestBottleneckAnalysis_DatastoreCapacity(t *testing.T) {
+	state := InfrastructureState{
+		TotalCellMemoryGB:        1000,
+		TotalAppMemoryGB:         500,
+		TotalDatastoreCapacityGB: 10000,
+		TotalDatastoreFreeGB:     1500,
+	}
+
+	analysis := AnalyzeBottleneck(state)
+	var datastore *ResourceUtilization
+	for i := range analysis.Resources {
+		if analysis.Resources[i].Name == "Datastore" {
+			datastore = &analysis.Resources[i]
+		}
+	}
+	if datastore == nil {
+		t.Fatal("Expected a Datastore resource")
+	}
+	if datastore.UsedPercent != 85 || datastore.UsedCapacity != 8500 || datastore.TotalCapacity != 10000 {
+		t.Errorf("Datastore = %.1f%% (%d/%d GB), want 85%% (8500/10000 GB)", datastore.UsedPercent, datastore.UsedCapacity, datastore.TotalCapacity)
+	}
+	if analysis.ConstrainingResource != "Datastore" {
+		t.Errorf("ConstrainingResource = %q, want Datastore", analysis.ConstrainingResource)
+	}
+
+	// No discovered datastores means no Datastore resource
+	for _, r := range AnalyzeBottleneck(InfrastructureState{TotalCellMemoryGB: 1000}).Resources {
+		if r.Name == "Datastore" {
+			t.Error("Expected no Datastore resource without datastore capacity")
+		}
+	}
+}
+
 func TestBottleneckAnalysis_Summary(t *testing.T) {
 	mi := ManualInput{
 		Name: "Summary Test",
@@ -243,6 +325,71 @@ func TestBottleneckAnalysis_Summary(t *testing.T) {
 	}
 }
 
+func TestBottleneckAnalysis_CPUOvercommit(t *testing.T) {
+	// 4 hosts × 32 cores = 128 pCPUs; 80 cells × 8 vCPUs = 640 vCPUs (5:1)
+	mi := ManualInput{
+		Name: "CPU Bottleneck Test",
+		Clusters: []ClusterInput{
+			{
+				Name:              "cluster-01",
+				HostCount:         4,
+				MemoryGBPerHost:   1024,
+				CPUThreadsPerHost: 32,
+				DiegoCellCount:    80,
+				DiegoCellMemoryGB: 32,
+				DiegoCellCPU:      8,
+				DiegoCellDiskGB:   100,
+			},
+		},
+		TotalAppMemoryGB: 1536, // 60% of cell memory capacity (2560 GB)
+		TotalAppDiskGB:   4000, // 50% of cell disk capacity (8000 GB)
+	}
+
+	analysis := AnalyzeBottleneck(mi.ToInfrastructureState())
+
+	if analysis.ConstrainingResource != "CPU" {
+		t.Fatalf("Expected CPU to be constraining, got %q", analysis.ConstrainingResource)
+	}
+	cpu := analysis.Resources[0]
+	// 640 vCPUs / (128 pCPUs × 4) = 125%
+	if cpu.UsedCapacity != 640 || cpu.TotalCapacity != 512 || cpu.UsedPercent != 125 {
+		t.Errorf("CPU = %.1f%% (%d/%d %s), want 125%% (640/512 vCPUs)", cpu.UsedPercent, cpu.UsedCapacity, cpu.TotalCapacity, cpu.Unit)
+	}
+	if !strings.Contains(analysis.Summary, "5.0:1 vCPU:pCPU ratio against a 4:1 target") {
+		t.Errorf("Expected summary to explain the vCPU:pCPU ratio, got %q", analysis.Summary)
+	}
+}
+
+func TestBottleneckAnalysis_CPUWithinTarget(t *testing.T) {
+	// 2:1 overcommit is half the 4:1 target, so memory stays the constraint
+	mi := ManualInput{
+		Name: "CPU Within Target Test",
+		Clusters: []ClusterInput{
+			{
+				Name:              "cluster-01",
+				HostCount:         4,
+				MemoryGBPerHost:   1024,
+				CPUThreadsPerHost: 64,
+				DiegoCellCount:    64,
+				DiegoCellMemoryGB: 32,
+				DiegoCellCPU:      8,
+			},
+		},
+		TotalAppMemoryGB: 1638, // 80% of cell memory capacity (2048 GB)
+	}
+
+	analysis := AnalyzeBottleneck(mi.ToInfrastructureState())
+
+	if analysis.ConstrainingResource != "Memory" {
+		t.Errorf("Expected Memory to be constraining, got %q", analysis.ConstrainingResource)
+	}
+	for _, r := range analysis.Resources {
+		if r.Name == "CPU" && r.UsedPercent != 50 {
+			t.Errorf("Expected CPU at 50%% of target capacity, got %.1f%%", r.UsedPercent)
+		}
+	}
+}
+
 func TestBottleneckAnalysis_Serialization(t *testing.T) {
 	analysis := BottleneckAnalysis{
 		Resources: []ResourceUtilization{
@@ -261,3 +408,61 @@ func TestBottleneckAnalysis_Serialization(t *testing.T) {
 		t.Errorf("Expected 2 resources, got %d", len(analysis.Resources))
 	}
 }
+
+func TestRankResourcesByHeadroom(t *testing.T) {
+	resources := []ResourceUtilization{
+		{Name: "Memory", UsedPercent: 85, CeilingPercent: 95, HeadroomPercent: 10},
+		{Name: "Disk", UsedPercent: 80, CeilingPercent: 85, HeadroomPercent: 5},
+		{Name: "CPU", UsedPercent: 60, CeilingPercent: 100, HeadroomPercent: 40},
+	}
+
+	ranked := RankResourcesByHeadroom(resources)
+
+	want := []string{"Disk", "Memory", "CPU"}
+	for i, name := range want {
+		if ranked[i].Name != name {
+			t.Errorf("ranked[%d] = %s, want %s", i, ranked[i].Name, name)
+		}
+		if ranked[i].IsConstraining != (i == 0) {
+			t.Errorf("ranked[%d].IsConstraining = %v", i, ranked[i].IsConstraining)
+		}
+	}
+	if resources[0].IsConstraining {
+		t.Error("RankResourcesByHeadroom should not modify its input")
+	}
+}
+
+func TestBottleneckAnalysis_RankByHeadroom(t *testing.T) {
+	// Memory 85%, disk 80%
+	state := InfrastructureState{
+		TotalCellMemoryGB: 1000,
+		TotalAppMemoryGB:  850,
+		TotalAppDiskGB:    800,
+		Clusters:          []ClusterState{{DiegoCellCount: 10, DiegoCellDiskGB: 100}},
+	}
+	thresholds := Thresholds{MemoryCeilingPct: 95, DiskCeilingPct: 85}
+
+	byUtilization := thresholds.AnalyzeBottleneck(state)
+	if byUtilization.Ranking != RankByUtilization || byUtilization.ConstrainingResource != "Memory" {
+		t.Errorf("Utilization ranking = %s/%s, want utilization/Memory", byUtilization.Ranking, byUtilization.ConstrainingResource)
+	}
+
+	byHeadroom := thresholds.AnalyzeBottleneckRanked(state, RankByHeadroom)
+	if byHeadroom.Ranking != RankByHeadroom || byHeadroom.ConstrainingResource != "Disk" {
+		t.Fatalf("Headroom ranking = %s/%s, want headroom/Disk", byHeadroom.Ranking, byHeadroom.ConstrainingResource)
+	}
+	disk := byHeadroom.Resources[0]
+	if disk.CeilingPercent != 85 || disk.HeadroomPercent != 5 {
+		t.Errorf("Disk ceiling/headroom = %.1f/%.1f, want 85/5", disk.CeilingPercent, disk.HeadroomPercent)
+	}
+	if !strings.Contains(byHeadroom.Summary, "5.0 points below its 85% safety ceiling") {
+		t.Errorf("Unexpected headroom summary: %s", byHeadroom.Summary)
+	}
+
+	// Past the ceiling, headroom goes negative and the summary says so
+	state.TotalAppDiskGB = 900
+	past := thresholds.AnalyzeBottleneckRanked(state, RankByHeadroom)
+	if !strings.Contains(past.Summary, "5.0 points past its 85% safety ceiling") {
+		t.Errorf("Unexpected summary past the ceiling: %s", past.Summary)
+	}
+}
@@ -0,0 +1,155 @@
+// ABOUTME: Infrastructure diff between two discovered or saved states
+// ABOUTME: Reports host, cell, and utilization changes overall and per cluster
+
+package models
+
+import "time"
+
+// ClusterChange describes how a cluster differs between two states
+type ClusterChange string
+
+const (
+	ClusterAdded     ClusterChange = "added"
+	ClusterRemoved   ClusterChange = "removed"
+	ClusterChanged   ClusterChange = "changed"
+	ClusterUnchanged ClusterChange = "unchanged"
+)
+
+// DiffRequest is the request body for POST /api/v1/infrastructure/diff
+type DiffRequest struct {
+	Old *InfrastructureState `json:"old"`
+	New *InfrastructureState `json:"new"`
+}
+
+// ClusterDiff holds the changes to one cluster. Old and new values are kept
+// for cell sizing so a resize reads as "32 GB -> 64 GB" rather than a delta.
+// Added clusters have zero old values and removed clusters zero new values.
+type ClusterDiff struct {
+	Name                         string        `json:"name"`
+	Change                       ClusterChange `json:"change"`
+	HostCountDelta               int           `json:"host_count_delta"`
+	MemoryGBDelta                int           `json:"memory_gb_delta"`
+	CellCountDelta               int           `json:"cell_count_delta"`
+	OldCellMemoryGB              int           `json:"old_cell_memory_gb"`
+	NewCellMemoryGB              int           `json:"new_cell_memory_gb"`
+	OldCellCPU                   int           `json:"old_cell_cpu"`
+	NewCellCPU                   int           `json:"new_cell_cpu"`
+	CellsResized                 bool          `json:"cells_resized"`
+	HostMemoryUtilizationDelta   float64       `json:"host_memory_utilization_delta"` // percentage points
+	HostCPUUtilizationDelta      float64       `json:"host_cpu_utilization_delta"`    // percentage points
+	HAHostFailuresSurvivedDelta  int           `json:"ha_host_failures_survived_delta"`
+	HAAdmissionControlPctChanged bool          `json:"ha_admission_control_pct_changed"`
+}
+
+// StateDiff is the structured change between two infrastructure states
+type StateDiff struct {
+	OldTimestamp               time.Time     `json:"old_timestamp"`
+	NewTimestamp               time.Time     `json:"new_timestamp"`
+	Changed                    bool          `json:"changed"`
+	HostCountDelta             int           `json:"host_count_delta"`
+	CellCountDelta             int           `json:"cell_count_delta"`
+	MemoryGBDelta              int           `json:"memory_gb_delta"`
+	CellMemoryGBDelta          int           `json:"cell_memory_gb_delta"`
+	AppInstancesDelta          int           `json:"app_instances_delta"`
+	HostMemoryUtilizationDelta float64       `json:"host_memory_utilization_delta"` // percentage points
+	HostCPUUtilizationDelta    float64       `json:"host_cpu_utilization_delta"`    // percentage points
+	AddedClusters              []string      `json:"added_clusters"`
+	RemovedClusters            []string      `json:"removed_clusters"`
+	Clusters                   []ClusterDiff `json:"clusters"`
+}
+
+// DiffStates compares two infrastructure states, matching clusters by name.
+// Deltas are new minus old. Clusters are listed in the new state's order,
+// followed by removed clusters in the old state's order.
+func DiffStates(old, new InfrastructureState) StateDiff {
+	diff := StateDiff{
+		OldTimestamp:               old.Timestamp,
+		NewTimestamp:               new.Timestamp,
+		HostCountDelta:             new.TotalHostCount - old.TotalHostCount,
+		CellCountDelta:             new.TotalCellCount - old.TotalCellCount,
+		MemoryGBDelta:              new.TotalMemoryGB - old.TotalMemoryGB,
+		CellMemoryGBDelta:          new.TotalCellMemoryGB - old.TotalCellMemoryGB,
+		AppInstancesDelta:          new.TotalAppInstances - old.TotalAppInstances,
+		HostMemoryUtilizationDelta: new.HostMemoryUtilizationPercent - old.HostMemoryUtilizationPercent,
+		HostCPUUtilizationDelta:    new.HostCPUUtilizationPercent - old.HostCPUUtilizationPercent,
+		AddedClusters:              []string{},
+		RemovedClusters:            []string{},
+		Clusters:                   []ClusterDiff{},
+	}
+
+	oldByName := make(map[string]ClusterState, len(old.Clusters))
+	for _, c := range old.Clusters {
+		oldByName[c.Name] = c
+	}
+	newNames := make(map[string]bool, len(new.Clusters))
+
+	for _, c := range new.Clusters {
+		newNames[c.Name] = true
+		prev, ok := oldByName[c.Name]
+		if !ok {
+			diff.AddedClusters = append(diff.AddedClusters, c.Name)
+			cd := diffCluster(ClusterState{}, c)
+			cd.Change = ClusterAdded
+			diff.Clusters = append(diff.Clusters, cd)
+			continue
+		}
+		diff.Clusters = append(diff.Clusters, diffCluster(prev, c))
+	}
+
+	for _, c := range old.Clusters {
+		if newNames[c.Name] {
+			continue
+		}
+		diff.RemovedClusters = append(diff.RemovedClusters, c.Name)
+		cd := diffCluster(c, ClusterState{})
+		cd.Change = ClusterRemoved
+		diff.Clusters = append(diff.Clusters, cd)
+	}
+
+	diff.Changed = len(diff.AddedClusters) > 0 || len(diff.RemovedClusters) > 0 ||
+		diff.HostCountDelta != 0 || diff.CellCountDelta != 0 || diff.MemoryGBDelta != 0 ||
+		diff.CellMemoryGBDelta != 0 || diff.AppInstancesDelta != 0 ||
+		diff.HostMemoryUtilizationDelta != 0 || diff.HostCPUUtilizationDelta != 0
+	for _, cd := range diff.Clusters {
+		if cd.Change != ClusterUnchanged {
+			diff.Changed = true
+		}
+	}
+
+	return diff
+}
+
+// diffCluster compares two states of the same cluster
+func diffCluster(old, new ClusterState) ClusterDiff {
+	name := new.Name
+	if name == "" {
+		name = old.Name
+	}
+	cd := ClusterDiff{
+		Name:                         name,
+		HostCountDelta:               new.HostCount - old.HostCount,
+		MemoryGBDelta:                new.MemoryGB - old.MemoryGB,
+		CellCountDelta:               new.DiegoCellCount - old.DiegoCellCount,
+		OldCellMemoryGB:              old.DiegoCellMemoryGB,
+		NewCellMemoryGB:              new.DiegoCellMemoryGB,
+		OldCellCPU:                   old.DiegoCellCPU,
+		NewCellCPU:                   new.DiegoCellCPU,
+		HostMemoryUtilizationDelta:   new.HostMemoryUtilizationPercent - old.HostMemoryUtilizationPercent,
+		HostCPUUtilizationDelta:      new.HostCPUUtilizationPercent - old.HostCPUUtilizationPercent,
+		HAHostFailuresSurvivedDelta:  new.HAHostFailuresSurvived - old.HAHostFailuresSurvived,
+		HAAdmissionControlPctChanged: old.HAAdmissionControlPercentage != new.HAAdmissionControlPercentage,
+	}
+
+	// Only a cluster with cells on both sides can have had them resized
+	if old.DiegoCellCount > 0 && new.DiegoCellCount > 0 {
+		cd.CellsResized = old.DiegoCellMemoryGB != new.DiegoCellMemoryGB || old.DiegoCellCPU != new.DiegoCellCPU
+	}
+
+	cd.Change = ClusterUnchanged
+	if cd.HostCountDelta != 0 || cd.MemoryGBDelta != 0 || cd.CellCountDelta != 0 || cd.CellsResized ||
+		cd.HostMemoryUtilizationDelta != 0 || cd.HostCPUUtilizationDelta != 0 ||
+		cd.HAHostFailuresSurvivedDelta != 0 || cd.HAAdmissionControlPctChanged {
+		cd.Change = ClusterChanged
+	}
+	return cd
+}
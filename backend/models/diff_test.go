@@ -0,0 +1,128 @@
+// ABOUTME: Tests for infrastructure state diffs
+// ABOUTME: Validates totals, per-cluster changes, resizes, and added or removed clusters
+
+package models
+
+import "testing"
+
+func diffTestState() InfrastructureState {
+	return InfrastructureState{
+		Name: "prod",
+		Clusters: []ClusterState{
+			{Name: "cluster-a", HostCount: 4, MemoryGB: 2048, DiegoCellCount: 20, DiegoCellMemoryGB: 32, DiegoCellCPU: 4, HostMemoryUtilizationPercent: 31.25},
+			{Name: "cluster-b", HostCount: 4, MemoryGB: 2048, DiegoCellCount: 20, DiegoCellMemoryGB: 32, DiegoCellCPU: 4, HostMemoryUtilizationPercent: 31.25},
+		},
+		TotalHostCount:               8,
+		TotalCellCount:               40,
+		TotalMemoryGB:                4096,
+		TotalCellMemoryGB:            1280,
+		HostMemoryUtilizationPercent: 31.25,
+	}
+}
+
+func TestDiffStates_Unchanged(t *testing.T) {
+	diff := DiffStates(diffTestState(), diffTestState())
+
+	if diff.Changed {
+		t.Error("Expected identical states to be unchanged")
+	}
+	if len(diff.Clusters) != 2 {
+		t.Fatalf("Expected 2 cluster diffs, got %d", len(diff.Clusters))
+	}
+	for _, cd := range diff.Clusters {
+		if cd.Change != ClusterUnchanged {
+			t.Errorf("Cluster %s change = %s, want unchanged", cd.Name, cd.Change)
+		}
+	}
+	if diff.AddedClusters == nil || diff.RemovedClusters == nil {
+		t.Error("Expected empty, non-nil added and removed cluster lists")
+	}
+}
+
+func TestDiffStates_HostsAddedAndCellsResized(t *testing.T) {
+	old := diffTestState()
+	new := diffTestState()
+	new.Clusters[0].HostCount = 6
+	new.Clusters[0].MemoryGB = 3072
+	new.Clusters[0].HostMemoryUtilizationPercent = 20.8
+	new.Clusters[1].DiegoCellCount = 10
+	new.Clusters[1].DiegoCellMemoryGB = 64
+	new.Clusters[1].DiegoCellCPU = 8
+	new.TotalHostCount = 10
+	new.TotalMemoryGB = 5120
+	new.TotalCellCount = 30
+	new.HostMemoryUtilizationPercent = 25
+
+	diff := DiffStates(old, new)
+
+	if !diff.Changed {
+		t.Error("Expected changed")
+	}
+	if diff.HostCountDelta != 2 || diff.CellCountDelta != -10 || diff.MemoryGBDelta != 1024 {
+		t.Errorf("Totals = hosts %+d, cells %+d, memory %+d GB; want +2, -10, +1024",
+			diff.HostCountDelta, diff.CellCountDelta, diff.MemoryGBDelta)
+	}
+	if diff.HostMemoryUtilizationDelta != -6.25 {
+		t.Errorf("HostMemoryUtilizationDelta = %v, want -6.25", diff.HostMemoryUtilizationDelta)
+	}
+
+	a := diff.Clusters[0]
+	if a.Change != ClusterChanged || a.HostCountDelta != 2 || a.CellsResized {
+		t.Errorf("cluster-a = %+v, want changed with 2 more hosts and no resize", a)
+	}
+
+	b := diff.Clusters[1]
+	if !b.CellsResized || b.CellCountDelta != -10 {
+		t.Errorf("cluster-b = %+v, want 10 fewer cells, resized", b)
+	}
+	if b.OldCellMemoryGB != 32 || b.NewCellMemoryGB != 64 || b.OldCellCPU != 4 || b.NewCellCPU != 8 {
+		t.Errorf("cluster-b sizing = %dGB/%dvCPU -> %dGB/%dvCPU, want 32/4 -> 64/8",
+			b.OldCellMemoryGB, b.OldCellCPU, b.NewCellMemoryGB, b.NewCellCPU)
+	}
+}
+
+func TestDiffStates_AddedAndRemovedClusters(t *testing.T) {
+	old := diffTestState()
+	new := diffTestState()
+	new.Clusters = []ClusterState{
+		new.Clusters[0],
+		{Name: "cluster-c", HostCount: 2, MemoryGB: 1024, DiegoCellCount: 8, DiegoCellMemoryGB: 64, DiegoCellCPU: 8},
+	}
+
+	diff := DiffStates(old, new)
+
+	if len(diff.AddedClusters) != 1 || diff.AddedClusters[0] != "cluster-c" {
+		t.Errorf("AddedClusters = %v, want [cluster-c]", diff.AddedClusters)
+	}
+	if len(diff.RemovedClusters) != 1 || diff.RemovedClusters[0] != "cluster-b" {
+		t.Errorf("RemovedClusters = %v, want [cluster-b]", diff.RemovedClusters)
+	}
+
+	// New state's order, then removed clusters
+	want := []struct {
+		name   string
+		change ClusterChange
+	}{
+		{"cluster-a", ClusterUnchanged},
+		{"cluster-c", ClusterAdded},
+		{"cluster-b", ClusterRemoved},
+	}
+	if len(diff.Clusters) != len(want) {
+		t.Fatalf("Expected %d cluster diffs, got %d", len(want), len(diff.Clusters))
+	}
+	for i, w := range want {
+		cd := diff.Clusters[i]
+		if cd.Name != w.name || cd.Change != w.change {
+			t.Errorf("Clusters[%d] = %s %s, want %s %s", i, cd.Name, cd.Change, w.name, w.change)
+		}
+	}
+
+	added := diff.Clusters[1]
+	if added.HostCountDelta != 2 || added.CellCountDelta != 8 || added.CellsResized {
+		t.Errorf("Added cluster = %+v, want +2 hosts, +8 cells, not resized", added)
+	}
+	removed := diff.Clusters[2]
+	if removed.HostCountDelta != -4 || removed.CellCountDelta != -20 || removed.OldCellMemoryGB != 32 {
+		t.Errorf("Removed cluster = %+v, want -4 hosts, -20 cells, old cell memory 32", removed)
+	}
+}
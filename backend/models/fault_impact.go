@@ -0,0 +1,107 @@
+// ABOUTME: Cell sizing for a maximum single-cell fault impact
+// ABOUTME: Finds the fewest cells that keep app instances per cell under a target at constant total capacity
+
+package models
+
+import (
+	"fmt"
+	"math"
+)
+
+// FaultImpactSizing is the cell count and size that keep a single cell failure
+// under MaxFaultImpact app instances, with total cell memory, vCPU, and disk
+// held at least at their current totals. Fault impact is instances per cell
+// rounded to the nearest whole instance, as the scenario calculator reports it.
+type FaultImpactSizing struct {
+	MaxFaultImpact          int  `json:"max_fault_impact"`
+	TotalAppInstances       int  `json:"total_app_instances"`
+	CurrentCellCount        int  `json:"current_cell_count"`
+	CurrentCellMemoryGB     int  `json:"current_cell_memory_gb"`
+	CurrentCellCPU          int  `json:"current_cell_cpu"`
+	CurrentCellDiskGB       int  `json:"current_cell_disk_gb"`
+	CurrentFaultImpact      int  `json:"current_fault_impact"`
+	RecommendedCellCount    int  `json:"recommended_cell_count"` // ceil(instances / max_fault_impact)
+	RecommendedCellMemoryGB int  `json:"recommended_cell_memory_gb"`
+	RecommendedCellCPU      int  `json:"recommended_cell_cpu"`
+	RecommendedCellDiskGB   int  `json:"recommended_cell_disk_gb"`
+	RecommendedFaultImpact  int  `json:"recommended_fault_impact"`
+	TargetMet               bool `json:"target_met"` // Current fault impact is already within the target
+}
+
+// SizeForFaultImpact returns the minimum cell count that keeps fault impact at
+// or below maxFaultImpact, and the cell size that keeps total capacity
+// constant across that many cells. Sizes round up, so total capacity never
+// shrinks. maxFaultImpact must be positive.
+func SizeForFaultImpact(state InfrastructureState, maxFaultImpact int) FaultImpactSizing {
+	instances := state.TotalAppInstances
+	cells := state.TotalCellCount
+	totalDiskGB := calculateTotalCellDisk(state)
+
+	sizing := FaultImpactSizing{
+		MaxFaultImpact:    maxFaultImpact,
+		TotalAppInstances: instances,
+		CurrentCellCount:  cells,
+	}
+	if cells > 0 {
+		sizing.CurrentCellMemoryGB = state.TotalCellMemoryGB / cells
+		sizing.CurrentCellCPU = state.TotalVCPUs / cells
+		sizing.CurrentCellDiskGB = totalDiskGB / cells
+		sizing.CurrentFaultImpact = faultImpact(instances, cells)
+	}
+	if maxFaultImpact <= 0 || instances == 0 {
+		return sizing
+	}
+
+	recommended := ceilDivInt(instances, maxFaultImpact)
+	sizing.RecommendedCellCount = recommended
+	sizing.RecommendedCellMemoryGB = ceilDivInt(state.TotalCellMemoryGB, recommended)
+	sizing.RecommendedCellCPU = ceilDivInt(state.TotalVCPUs, recommended)
+	sizing.RecommendedCellDiskGB = ceilDivInt(totalDiskGB, recommended)
+	sizing.RecommendedFaultImpact = faultImpact(instances, recommended)
+	sizing.TargetMet = cells > 0 && sizing.CurrentFaultImpact <= maxFaultImpact
+
+	return sizing
+}
+
+// GenerateFaultImpactRecommendation creates a recommendation to resize cells
+// when a single cell failure would take down more than maxFaultImpact app
+// instances. Returns nil when the target is already met or app instance
+// counts are unknown.
+func GenerateFaultImpactRecommendation(state InfrastructureState, maxFaultImpact int) *Recommendation {
+	sizing := SizeForFaultImpact(state, maxFaultImpact)
+	if sizing.RecommendedCellCount == 0 || sizing.TargetMet {
+		return nil
+	}
+
+	return &Recommendation{
+		Type:     RecommendationResizeForFaultImpact,
+		Priority: 2,
+		Title:    "Resize Cells for Fault Impact",
+		Description: fmt.Sprintf("Run %d cells of %dGB / %d vCPU instead of %d cells of %dGB / %d vCPU",
+			sizing.RecommendedCellCount, sizing.RecommendedCellMemoryGB, sizing.RecommendedCellCPU,
+			sizing.CurrentCellCount, sizing.CurrentCellMemoryGB, sizing.CurrentCellCPU),
+		Impact: fmt.Sprintf("A single cell failure affects at most %d app instances instead of %d, at the same total capacity",
+			sizing.RecommendedFaultImpact, sizing.CurrentFaultImpact),
+		ImpactLevel:     "medium",
+		Resource:        "Memory",
+		CellsToAdd:      sizing.RecommendedCellCount - sizing.CurrentCellCount,
+		NewCellMemoryGB: sizing.RecommendedCellMemoryGB,
+		NewCellCPU:      sizing.RecommendedCellCPU,
+		EstimatedCost: &EstimatedCost{
+			MemoryGBDelta: sizing.RecommendedCellCount*sizing.RecommendedCellMemoryGB - state.TotalCellMemoryGB,
+			Tier:          CostTierLow,
+		},
+	}
+}
+
+// faultImpact is round(instances / cells), matching the scenario calculator's fault_impact
+func faultImpact(instances, cells int) int {
+	return int(math.Round(float64(instances) / float64(cells)))
+}
+
+func ceilDivInt(a, b int) int {
+	if b <= 0 {
+		return 0
+	}
+	return (a + b - 1) / b
+}
@@ -0,0 +1,121 @@
+// ABOUTME: Tests for fault-impact cell sizing
+// ABOUTME: Validates minimum cell count, constant-capacity cell sizes, and the resize recommendation
+
+package models
+
+import "testing"
+
+func faultImpactState() InfrastructureState {
+	return InfrastructureState{
+		Clusters:          []ClusterState{{DiegoCellCount: 10, DiegoCellDiskGB: 200}},
+		TotalCellCount:    10,
+		TotalCellMemoryGB: 640, // 10 x 64GB
+		TotalVCPUs:        80,  // 10 x 8 vCPU
+		TotalAppInstances: 500, // 50 per cell
+	}
+}
+
+func TestSizeForFaultImpact_ResizesToMeetTarget(t *testing.T) {
+	sizing := SizeForFaultImpact(faultImpactState(), 20)
+
+	if sizing.CurrentFaultImpact != 50 {
+		t.Errorf("CurrentFaultImpact = %d, want 50", sizing.CurrentFaultImpact)
+	}
+	if sizing.CurrentCellMemoryGB != 64 || sizing.CurrentCellCPU != 8 || sizing.CurrentCellDiskGB != 200 {
+		t.Errorf("Current cell = %dGB / %d vCPU / %dGB disk, want 64 / 8 / 200",
+			sizing.CurrentCellMemoryGB, sizing.CurrentCellCPU, sizing.CurrentCellDiskGB)
+	}
+
+	// ceil(500 / 20) = 25 cells; 640GB / 25 = 25.6 -> 26GB, 80 / 25 = 3.2 -> 4 vCPU, 2000 / 25 = 80GB
+	if sizing.RecommendedCellCount != 25 {
+		t.Errorf("RecommendedCellCount = %d, want 25", sizing.RecommendedCellCount)
+	}
+	if sizing.RecommendedCellMemoryGB != 26 || sizing.RecommendedCellCPU != 4 || sizing.RecommendedCellDiskGB != 80 {
+		t.Errorf("Recommended cell = %dGB / %d vCPU / %dGB disk, want 26 / 4 / 80",
+			sizing.RecommendedCellMemoryGB, sizing.RecommendedCellCPU, sizing.RecommendedCellDiskGB)
+	}
+	if sizing.RecommendedFaultImpact != 20 {
+		t.Errorf("RecommendedFaultImpact = %d, want 20", sizing.RecommendedFaultImpact)
+	}
+	if sizing.TargetMet {
+		t.Error("Expected target not met with 10 cells")
+	}
+}
+
+func TestSizeForFaultImpact_KeepsTotalCapacity(t *testing.T) {
+	state := faultImpactState()
+	sizing := SizeForFaultImpact(state, 7)
+
+	if got := sizing.RecommendedCellCount * sizing.RecommendedCellMemoryGB; got < state.TotalCellMemoryGB {
+		t.Errorf("Recommended memory %dGB is below current %dGB", got, state.TotalCellMemoryGB)
+	}
+	if got := sizing.RecommendedCellCount * sizing.RecommendedCellCPU; got < state.TotalVCPUs {
+		t.Errorf("Recommended vCPUs %d is below current %d", got, state.TotalVCPUs)
+	}
+	if sizing.RecommendedFaultImpact > 7 {
+		t.Errorf("RecommendedFaultImpact = %d, want at most 7", sizing.RecommendedFaultImpact)
+	}
+}
+
+func TestSizeForFaultImpact_TargetAlreadyMet(t *testing.T) {
+	sizing := SizeForFaultImpact(faultImpactState(), 50)
+
+	if !sizing.TargetMet {
+		t.Error("Expected 10 cells to meet a target of 50 instances per cell")
+	}
+	if GenerateFaultImpactRecommendation(faultImpactState(), 50) != nil {
+		t.Error("Expected no recommendation when the target is met")
+	}
+}
+
+// 504 instances on 10 cells is 50.4 per cell, which the scenario calculator
+// reports as a fault impact of 50
+func TestSizeForFaultImpact_RoundsLikeScenarioCalculator(t *testing.T) {
+	state := faultImpactState()
+	state.TotalAppInstances = 504
+
+	sizing := SizeForFaultImpact(state, 50)
+	if sizing.CurrentFaultImpact != 50 {
+		t.Errorf("CurrentFaultImpact = %d, want round(50.4) = 50", sizing.CurrentFaultImpact)
+	}
+	if !sizing.TargetMet {
+		t.Error("Expected a fault impact of 50 to meet a target of 50")
+	}
+	if GenerateFaultImpactRecommendation(state, 50) != nil {
+		t.Error("Expected no recommendation when the rounded fault impact meets the target")
+	}
+}
+
+func TestSizeForFaultImpact_NoInstances(t *testing.T) {
+	state := faultImpactState()
+	state.TotalAppInstances = 0
+
+	if sizing := SizeForFaultImpact(state, 20); sizing.RecommendedCellCount != 0 {
+		t.Errorf("RecommendedCellCount = %d, want 0 with no app instances", sizing.RecommendedCellCount)
+	}
+	if GenerateFaultImpactRecommendation(state, 20) != nil {
+		t.Error("Expected no recommendation without app instance counts")
+	}
+}
+
+func TestGenerateFaultImpactRecommendation(t *testing.T) {
+	rec := GenerateFaultImpactRecommendation(faultImpactState(), 20)
+	if rec == nil {
+		t.Fatal("Expected a recommendation")
+	}
+
+	if rec.Type != RecommendationResizeForFaultImpact {
+		t.Errorf("Type = %q, want %q", rec.Type, RecommendationResizeForFaultImpact)
+	}
+	if rec.CellsToAdd != 15 || rec.NewCellMemoryGB != 26 || rec.NewCellCPU != 4 {
+		t.Errorf("Got %d cells to add of %dGB / %d vCPU, want 15 of 26GB / 4 vCPU",
+			rec.CellsToAdd, rec.NewCellMemoryGB, rec.NewCellCPU)
+	}
+	if want := "Run 25 cells of 26GB / 4 vCPU instead of 10 cells of 64GB / 8 vCPU"; rec.Description != want {
+		t.Errorf("Description = %q, want %q", rec.Description, want)
+	}
+	// 25 x 26GB = 650GB vs 640GB today
+	if rec.EstimatedCost == nil || rec.EstimatedCost.MemoryGBDelta != 10 || rec.EstimatedCost.Tier != CostTierLow {
+		t.Errorf("Unexpected estimated cost %+v", rec.EstimatedCost)
+	}
+}
@@ -0,0 +1,112 @@
+// ABOUTME: Time-to-full capacity forecasting from a monthly growth rate
+// ABOUTME: Projects when each resource in the bottleneck analysis crosses 85% and 100% utilization
+
+package models
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ForecastWarningPercent is the utilization at which a forecast reports a
+// resource as needing attention, matching the default N-1 critical threshold
+const ForecastWarningPercent = 85.0
+
+// ResourceForecast is the projected time until one resource fills up.
+// A nil month count means the resource never reaches that level at the
+// given growth rate; 0 means it is already there.
+type ResourceForecast struct {
+	Name            string  `json:"name"`
+	UsedPercent     float64 `json:"used_percent"`
+	MonthsToWarning *int    `json:"months_to_warning"`
+	MonthsToFull    *int    `json:"months_to_full"`
+	IsConstraining  bool    `json:"is_constraining"`
+}
+
+// CapacityForecast is the time-to-full projection for every analyzed resource
+type CapacityForecast struct {
+	MonthlyGrowthPct     float64            `json:"monthly_growth_pct"`
+	WarningPercent       float64            `json:"warning_percent"`
+	Resources            []ResourceForecast `json:"resources"`
+	ConstrainingResource string             `json:"constraining_resource"`
+	Summary              string             `json:"summary"`
+}
+
+// ProjectTimeToFull projects how many months until each resource reaches
+// ForecastWarningPercent and 100% utilization, assuming demand on every
+// resource compounds at monthlyGrowthPct per month against today's capacity.
+// Resources are ordered as in AnalyzeBottleneck, so the constraining resource
+// is the first to fill.
+func ProjectTimeToFull(state InfrastructureState, monthlyGrowthPct float64) CapacityForecast {
+	analysis := AnalyzeBottleneck(state)
+
+	forecast := CapacityForecast{
+		MonthlyGrowthPct:     monthlyGrowthPct,
+		WarningPercent:       ForecastWarningPercent,
+		Resources:            make([]ResourceForecast, 0, len(analysis.Resources)),
+		ConstrainingResource: analysis.ConstrainingResource,
+	}
+
+	for _, r := range analysis.Resources {
+		forecast.Resources = append(forecast.Resources, ResourceForecast{
+			Name:            r.Name,
+			UsedPercent:     r.UsedPercent,
+			MonthsToWarning: monthsToReach(r.UsedPercent, ForecastWarningPercent, monthlyGrowthPct),
+			MonthsToFull:    monthsToReach(r.UsedPercent, 100, monthlyGrowthPct),
+			IsConstraining:  r.IsConstraining,
+		})
+	}
+	forecast.Summary = buildForecastSummary(forecast)
+
+	return forecast
+}
+
+// monthsToReach returns the first whole month at which usedPercent, growing
+// by growthPct per month, reaches targetPercent, or nil if it never does
+func monthsToReach(usedPercent, targetPercent, growthPct float64) *int {
+	if usedPercent >= targetPercent {
+		months := 0
+		return &months
+	}
+	if usedPercent <= 0 || growthPct <= 0 {
+		return nil
+	}
+	// Tolerance keeps exact crossings (50% doubling to 100%) from rounding up a month
+	months := int(math.Ceil(math.Log(targetPercent/usedPercent)/math.Log1p(growthPct/100) - 1e-9))
+	return &months
+}
+
+// buildForecastSummary lists when each resource reaches the warning level,
+// soonest first, e.g. "Memory reaches 85% in 8 months, Disk in 14 months"
+func buildForecastSummary(forecast CapacityForecast) string {
+	if len(forecast.Resources) == 0 {
+		return "No resources to forecast."
+	}
+
+	var parts []string
+	for _, r := range forecast.Resources {
+		if r.MonthsToWarning == nil {
+			continue
+		}
+		switch {
+		case *r.MonthsToWarning == 0:
+			parts = append(parts, fmt.Sprintf("%s is already above %.0f%%", r.Name, forecast.WarningPercent))
+		case len(parts) == 0:
+			parts = append(parts, fmt.Sprintf("%s reaches %.0f%% in %s", r.Name, forecast.WarningPercent, formatMonths(*r.MonthsToWarning)))
+		default:
+			parts = append(parts, fmt.Sprintf("%s in %s", r.Name, formatMonths(*r.MonthsToWarning)))
+		}
+	}
+	if len(parts) == 0 {
+		return fmt.Sprintf("No resource reaches %.0f%% at %.1f%% monthly growth.", forecast.WarningPercent, forecast.MonthlyGrowthPct)
+	}
+	return fmt.Sprintf("At %.1f%% monthly growth: %s.", forecast.MonthlyGrowthPct, strings.Join(parts, ", "))
+}
+
+func formatMonths(months int) string {
+	if months == 1 {
+		return "1 month"
+	}
+	return fmt.Sprintf("%d months", months)
+}
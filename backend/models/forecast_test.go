@@ -0,0 +1,118 @@
+// ABOUTME: Tests for time-to-full capacity forecasting
+// ABOUTME: Validates per-resource crossing months, already-full resources, and summary text
+
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func monthsString(m *int) string {
+	if m == nil {
+		return "never"
+	}
+	return formatMonths(*m)
+}
+
+func TestProjectTimeToFull_PerResourceCrossings(t *testing.T) {
+	state := InfrastructureState{
+		Clusters:          []ClusterState{{DiegoCellCount: 10, DiegoCellDiskGB: 100}},
+		TotalCellMemoryGB: 1000,
+		TotalAppMemoryGB:  500, // 50%
+		TotalAppDiskGB:    250, // 25%
+	}
+
+	forecast := ProjectTimeToFull(state, 5)
+
+	if forecast.ConstrainingResource != "Memory" {
+		t.Errorf("Expected Memory constraining, got %q", forecast.ConstrainingResource)
+	}
+	if len(forecast.Resources) != 2 {
+		t.Fatalf("Expected 2 resources, got %d", len(forecast.Resources))
+	}
+
+	// 50% * 1.05^11 = 85.5%, 50% * 1.05^15 = 103.9%
+	// 25% * 1.05^26 = 88.8%, 25% * 1.05^29 = 102.9%
+	want := []struct {
+		name          string
+		warning, full int
+	}{
+		{"Memory", 11, 15},
+		{"Disk", 26, 29},
+	}
+	for i, w := range want {
+		r := forecast.Resources[i]
+		if r.Name != w.name {
+			t.Fatalf("Resource %d = %q, want %q", i, r.Name, w.name)
+		}
+		if r.MonthsToWarning == nil || *r.MonthsToWarning != w.warning {
+			t.Errorf("%s months to 85%% = %s, want %d", r.Name, monthsString(r.MonthsToWarning), w.warning)
+		}
+		if r.MonthsToFull == nil || *r.MonthsToFull != w.full {
+			t.Errorf("%s months to full = %s, want %d", r.Name, monthsString(r.MonthsToFull), w.full)
+		}
+	}
+	if !forecast.Resources[0].IsConstraining || forecast.Resources[1].IsConstraining {
+		t.Error("Expected only Memory marked constraining")
+	}
+
+	if want := "At 5.0% monthly growth: Memory reaches 85% in 11 months, Disk in 26 months."; forecast.Summary != want {
+		t.Errorf("Summary = %q, want %q", forecast.Summary, want)
+	}
+}
+
+func TestProjectTimeToFull_AlreadyAboveThreshold(t *testing.T) {
+	state := InfrastructureState{
+		TotalCellMemoryGB: 1000,
+		TotalAppMemoryGB:  900,
+	}
+
+	r := ProjectTimeToFull(state, 2).Resources[0]
+
+	if r.MonthsToWarning == nil || *r.MonthsToWarning != 0 {
+		t.Errorf("Months to 85%% = %s, want 0", monthsString(r.MonthsToWarning))
+	}
+	// 90% * 1.02^6 = 101.4%
+	if r.MonthsToFull == nil || *r.MonthsToFull != 6 {
+		t.Errorf("Months to full = %s, want 6", monthsString(r.MonthsToFull))
+	}
+}
+
+func TestProjectTimeToFull_ExactCrossing(t *testing.T) {
+	state := InfrastructureState{
+		TotalCellMemoryGB: 1000,
+		TotalAppMemoryGB:  500,
+	}
+
+	r := ProjectTimeToFull(state, 100).Resources[0]
+
+	if r.MonthsToFull == nil || *r.MonthsToFull != 1 {
+		t.Errorf("Months to full = %s, want 1 (50%% doubles to exactly 100%%)", monthsString(r.MonthsToFull))
+	}
+}
+
+func TestProjectTimeToFull_NoUsageNeverFills(t *testing.T) {
+	state := InfrastructureState{TotalCellMemoryGB: 1000}
+
+	forecast := ProjectTimeToFull(state, 5)
+
+	r := forecast.Resources[0]
+	if r.MonthsToWarning != nil || r.MonthsToFull != nil {
+		t.Errorf("Expected unused resource never to fill, got %s / %s", monthsString(r.MonthsToWarning), monthsString(r.MonthsToFull))
+	}
+	if !strings.HasPrefix(forecast.Summary, "No resource reaches 85%") {
+		t.Errorf("Unexpected summary %q", forecast.Summary)
+	}
+}
+
+func TestProjectTimeToFull_NoResources(t *testing.T) {
+	forecast := ProjectTimeToFull(InfrastructureState{}, 5)
+
+	if len(forecast.Resources) != 0 || forecast.ConstrainingResource != "" {
+		t.Errorf("Expected empty forecast, got %+v", forecast)
+	}
+	if forecast.Summary != "No resources to forecast." {
+		t.Errorf("Unexpected summary %q", forecast.Summary)
+	}
+}
@@ -0,0 +1,99 @@
+// ABOUTME: Glossary of the capacity metrics the analyzer computes
+// ABOUTME: Served at /api/v1/metrics/glossary so the CLI and web UI explain metrics the same way
+
+package models
+
+// MetricDefinition explains one computed metric and the formula behind it
+type MetricDefinition struct {
+	Key         string `json:"key"`         // JSON field the metric appears as, e.g. "n1_utilization_pct"
+	Name        string `json:"name"`        // Display name
+	Description string `json:"description"` // What the metric tells an operator
+	Formula     string `json:"formula"`     // How it is computed
+}
+
+// MetricGlossaryResponse is the response body of GET /api/v1/metrics/glossary
+type MetricGlossaryResponse struct {
+	Metrics []MetricDefinition `json:"metrics"`
+}
+
+// metricGlossary is ordered as the metrics appear in a scenario comparison
+var metricGlossary = []MetricDefinition{
+	{
+		Key:         "app_capacity_gb",
+		Name:        "App capacity",
+		Description: "Memory the Diego cells can give to app instances once Garden and OS overhead inside each cell is set aside.",
+		Formula:     "cells × (cell memory − per-cell overhead); overhead defaults to 7% of cell memory",
+	},
+	{
+		Key:         "utilization_pct",
+		Name:        "Cell utilization",
+		Description: "Share of app capacity used by app memory. High values leave little room to scale or restage apps.",
+		Formula:     "app memory ÷ app capacity × 100",
+	},
+	{
+		Key:         "disk_utilization_pct",
+		Name:        "Disk utilization",
+		Description: "Share of cell ephemeral disk used by app disk allocations.",
+		Formula:     "app disk ÷ (cells × cell disk) × 100",
+	},
+	{
+		Key:         "free_chunks",
+		Name:        "Free chunks",
+		Description: "How many more app instances of the staging chunk size fit in the unused app capacity. Low counts mean pushes and restarts may find no room to stage.",
+		Formula:     "(app capacity − app memory) in MB ÷ chunk size in MB; the chunk size is the largest instance's memory, or 4 GB when unknown",
+	},
+	{
+		Key:         "max_single_chunk_gb",
+		Name:        "Largest stageable app",
+		Description: "Biggest app instance an average cell still has room for. Free chunks can look healthy while no single cell fits a large instance.",
+		Formula:     "cell memory − per-cell overhead − app memory ÷ cells",
+	},
+	{
+		Key:         "n1_utilization_pct",
+		Name:        "N-1 utilization",
+		Description: "Share of the memory left after one host fails that the cells and platform VMs need. Above 100%, a host failure strands workloads that cannot restart elsewhere.",
+		Formula:     "(cells × cell memory + platform VM memory) ÷ N-1 memory × 100; N-1 memory = (hosts − 1) × memory per host",
+	},
+	{
+		Key:         "ha_host_failures_survived",
+		Name:        "HA host failures survived",
+		Description: "How many hosts can fail while the rest, after the vSphere HA admission control reservation, still hold all cell memory. 0 means the cluster is at risk.",
+		Formula:     "largest k where (hosts − k) × memory per host × (100 − HA admission %) ÷ 100 ≥ cell memory",
+	},
+	{
+		Key:         "fault_impact",
+		Name:        "Fault impact",
+		Description: "App instances that restart when one Diego cell fails, assuming instances are spread evenly. A fault impact of 16 means losing a cell restarts about 16 instances.",
+		Formula:     "app instances ÷ cells, rounded",
+	},
+	{
+		Key:         "blast_radius_pct",
+		Name:        "Blast radius",
+		Description: "Share of app instances lost when the busiest host fails, with cells spread evenly across hosts. Without a host count, each cell is its own failure domain.",
+		Formula:     "⌈cells ÷ hosts⌉ ÷ cells × 100, or 100 ÷ cells without hosts",
+	},
+	{
+		Key:         "vcpu_ratio",
+		Name:        "vCPU:pCPU ratio",
+		Description: "Virtual CPUs allocated to cells per physical core. Above the moderate tier (4:1 by default) expect CPU contention under load; above the aggressive tier (8:1) watch CPU Ready time.",
+		Formula:     "cells × cell vCPUs ÷ (hosts × physical cores per host)",
+	},
+	{
+		Key:         "cpu_headroom_cells",
+		Name:        "CPU headroom",
+		Description: "Cells that can still be added before the vCPU:pCPU ratio passes its target, counting platform VM vCPUs. Negative means the proposal is already past it.",
+		Formula:     "(target ratio × physical cores − platform VM vCPUs) ÷ cell vCPUs − cells",
+	},
+	{
+		Key:         "estimated_tps",
+		Name:        "Estimated TPS",
+		Description: "Scheduler throughput in tasks per second expected at this cell count. Throughput falls as the cell count grows past the curve's peak.",
+		Formula:     "interpolated from the TPS curve (tps_curve or TPS_CURVE) at the cell count",
+	},
+}
+
+// MetricGlossary returns the metric definitions in display order. The slice
+// is a copy, so callers may modify it.
+func MetricGlossary() []MetricDefinition {
+	return append([]MetricDefinition(nil), metricGlossary...)
+}
@@ -0,0 +1,39 @@
+// ABOUTME: Tests for the metric glossary
+// ABOUTME: Verifies every definition is complete and keys are unique
+
+package models
+
+import "testing"
+
+func TestMetricGlossary_DefinitionsComplete(t *testing.T) {
+	glossary := MetricGlossary()
+	if len(glossary) == 0 {
+		t.Fatal("Expected metric definitions")
+	}
+
+	seen := make(map[string]bool)
+	for _, m := range glossary {
+		if m.Key == "" || m.Name == "" || m.Description == "" || m.Formula == "" {
+			t.Errorf("Incomplete definition: %+v", m)
+		}
+		if seen[m.Key] {
+			t.Errorf("Duplicate key %q", m.Key)
+		}
+		seen[m.Key] = true
+	}
+
+	for _, key := range []string{"free_chunks", "fault_impact", "blast_radius_pct", "n1_utilization_pct"} {
+		if !seen[key] {
+			t.Errorf("Expected a definition for %q", key)
+		}
+	}
+}
+
+func TestMetricGlossary_ReturnsCopy(t *testing.T) {
+	first := MetricGlossary()
+	first[0].Name = "changed"
+
+	if MetricGlossary()[0].Name == "changed" {
+		t.Error("Expected MetricGlossary to return a copy")
+	}
+}
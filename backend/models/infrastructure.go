@@ -3,7 +3,21 @@
 
 package models
 
-import "time"
+import (
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/markalston/diego-capacity-analyzer/backend/units"
+)
+
+// Values of the optional top-level "format" field, which names a file's format
+// explicitly instead of leaving it to be inferred from its clusters
+const (
+	FormatManualInput         = "manual_input"
+	FormatInfrastructureState = "infrastructure_state"
+)
 
 // ClusterInput represents user-provided cluster configuration
 type ClusterInput struct {
@@ -20,6 +34,7 @@ type ClusterInput struct {
 
 // ManualInput represents user-provided infrastructure data
 type ManualInput struct {
+	Format              string         `json:"format,omitempty"` // FormatManualInput or empty
 	Name                string         `json:"name"`
 	Clusters            []ClusterInput `json:"clusters"`
 	PlatformVMsGB       int            `json:"platform_vms_gb"`
@@ -29,6 +44,27 @@ type ManualInput struct {
 	MaxInstanceMemoryMB int            `json:"max_instance_memory_mb"`
 }
 
+// OpsManagerVMType is one entry of `om curl -p /api/v0/vm_types`. Sizes are in MB.
+type OpsManagerVMType struct {
+	Name          string `json:"name"`
+	RAM           int    `json:"ram"`
+	CPU           int    `json:"cpu"`
+	EphemeralDisk int    `json:"ephemeral_disk"`
+}
+
+// OpsManagerImportRequest carries a tile's staged config and Ops Manager's VM
+// types for deriving Diego cell sizing
+type OpsManagerImportRequest struct {
+	ProductConfig map[string]interface{} `json:"product_config"` // om staged-config -p <product>, as JSON
+	VMTypes       []OpsManagerVMType     `json:"vm_types"`
+}
+
+// FieldError describes one invalid field in a request body
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
 // ClusterState represents computed cluster metrics
 type ClusterState struct {
 	Name                         string  `json:"name"`
@@ -54,11 +90,43 @@ type ClusterState struct {
 	TotalVCPUs                   int     `json:"total_vcpus"`
 	TotalCellMemoryGB            int     `json:"total_cell_memory_gb"`
 	VCPURatio                    float64 `json:"vcpu_ratio"`
+	// Hosts excluded from the capacity figures above (vSphere only)
+	OfflineHostCount      int `json:"offline_host_count"`
+	OfflineMemoryGB       int `json:"offline_memory_gb"`
+	OfflineCPUThreads     int `json:"offline_cpu_threads"`
+	MaintenanceHostCount  int `json:"maintenance_host_count"`
+	MaintenanceMemoryGB   int `json:"maintenance_memory_gb"`
+	MaintenanceCPUThreads int `json:"maintenance_cpu_threads"`
+	// Resource pools holding Diego cells (vSphere only; cells in the root pool are not counted)
+	ResourcePoolReservationGB int `json:"resource_pool_reservation_gb"`
+	ResourcePoolLimitGB       int `json:"resource_pool_limit_gb"` // sum of effective limits of memory-limited pools
+	CellsInLimitedPools       int `json:"cells_in_limited_pools"`
+}
+
+// ClusterSummary is the sizing and utilization of one cluster, for picking a
+// scenario's target cluster without reading the full state
+type ClusterSummary struct {
+	Name                         string  `json:"name"`
+	HostCount                    int     `json:"host_count"`
+	DiegoCellCount               int     `json:"diego_cell_count"`
+	MemoryGB                     int     `json:"memory_gb"`
+	TotalCellMemoryGB            int     `json:"total_cell_memory_gb"`
+	HostMemoryUtilizationPercent float64 `json:"host_memory_utilization_percent"`
+	HostCPUUtilizationPercent    float64 `json:"host_cpu_utilization_percent"`
+	HAStatus                     string  `json:"ha_status"`
+}
+
+// ClusterList is the response of GET /api/v1/infrastructure/clusters
+type ClusterList struct {
+	Source   string           `json:"source"`
+	Name     string           `json:"name"`
+	Clusters []ClusterSummary `json:"clusters"`
 }
 
 // InfrastructureState represents computed infrastructure metrics
 type InfrastructureState struct {
-	Source                       string         `json:"source"` // "manual" or "vsphere"
+	Format                       string         `json:"format,omitempty"` // FormatInfrastructureState or empty
+	Source                       string         `json:"source"`           // "manual" or "vsphere"
 	Name                         string         `json:"name"`
 	Clusters                     []ClusterState `json:"clusters"`
 	TotalMemoryGB                int            `json:"total_memory_gb"`
@@ -82,20 +150,106 @@ type InfrastructureState struct {
 	TotalAppInstances            int            `json:"total_app_instances"`
 	AvgInstanceMemoryMB          int            `json:"avg_instance_memory_mb"`
 	MaxInstanceMemoryMB          int            `json:"max_instance_memory_mb"`
+	ObservedCellDiskPercent      float64        `json:"observed_cell_disk_percent"` // mean ephemeral disk usage from BOSH vitals, 0 if unavailable
 	Timestamp                    time.Time      `json:"timestamp"`
 	Cached                       bool           `json:"cached"`
+	AppDataTimestamp             *time.Time     `json:"app_data_timestamp,omitempty"` // when the app totals were read from CF; unset when they came with the input
+	TotalDatastoreCapacityGB     int            `json:"total_datastore_capacity_gb"`  // shared datastores on Diego clusters, vSphere only
+	TotalDatastoreFreeGB         int            `json:"total_datastore_free_gb"`
+
+	// Display strings set by WithHumanSizes, only when requested with ?human=true
+	TotalMemoryHuman     string `json:"total_memory_human,omitempty"`
+	TotalCellMemoryHuman string `json:"total_cell_memory_human,omitempty"`
+	TotalAppMemoryHuman  string `json:"total_app_memory_human,omitempty"`
+	TotalAppDiskHuman    string `json:"total_app_disk_human,omitempty"`
 }
 
-// CPURiskLevel returns the risk level based on vCPU:pCPU ratio
-// Thresholds: ≤4:1 = low, 4:1-8:1 = medium, >8:1 = high
-func CPURiskLevel(ratio float64) string {
-	if ratio <= 4.0 {
-		return "low"
+// WithHumanSizes returns a copy of the state with the *_human fields set,
+// such as "10.5 TB" beside total_app_memory_gb. The GB fields are unchanged.
+func (s InfrastructureState) WithHumanSizes() InfrastructureState {
+	s.TotalMemoryHuman = units.FormatGB(s.TotalMemoryGB)
+	s.TotalCellMemoryHuman = units.FormatGB(s.TotalCellMemoryGB)
+	s.TotalAppMemoryHuman = units.FormatGB(s.TotalAppMemoryGB)
+	s.TotalAppDiskHuman = units.FormatGB(s.TotalAppDiskGB)
+	return s
+}
+
+// ClusterList summarizes each cluster of the state, in state order
+func (s InfrastructureState) ClusterList() ClusterList {
+	list := ClusterList{
+		Source:   s.Source,
+		Name:     s.Name,
+		Clusters: make([]ClusterSummary, len(s.Clusters)),
 	}
-	if ratio <= 8.0 {
-		return "medium"
+	for i, c := range s.Clusters {
+		list.Clusters[i] = ClusterSummary{
+			Name:                         c.Name,
+			HostCount:                    c.HostCount,
+			DiegoCellCount:               c.DiegoCellCount,
+			MemoryGB:                     c.MemoryGB,
+			TotalCellMemoryGB:            c.TotalCellMemoryGB,
+			HostMemoryUtilizationPercent: c.HostMemoryUtilizationPercent,
+			HostCPUUtilizationPercent:    c.HostCPUUtilizationPercent,
+			HAStatus:                     c.HAStatus,
+		}
+	}
+	return list
+}
+
+// ForCluster returns the state narrowed to the named cluster, with its totals
+// taken from that cluster alone. App totals cover the whole foundation, so
+// the cluster is given the share matching its share of cell memory; platform
+// VM memory is shared by host memory. Returns false when no cluster has name.
+func (s InfrastructureState) ForCluster(name string) (InfrastructureState, bool) {
+	idx := slices.IndexFunc(s.Clusters, func(c ClusterState) bool { return c.Name == name })
+	if idx < 0 {
+		return s, false
+	}
+	c := s.Clusters[idx]
+
+	scoped := s
+	scoped.Clusters = []ClusterState{c}
+	scoped.TotalMemoryGB = c.MemoryGB
+	scoped.TotalN1MemoryGB = c.N1MemoryGB
+	scoped.TotalHAUsableMemoryGB = c.HAUsableMemoryGB
+	scoped.TotalHAUsableCPUCores = c.HAUsableCPUCores
+	scoped.HAMinHostFailuresSurvived = c.HAHostFailuresSurvived
+	scoped.HAStatus = c.HAStatus
+	scoped.TotalCellMemoryGB = c.TotalCellMemoryGB
+	scoped.HostMemoryUtilizationPercent = c.HostMemoryUtilizationPercent
+	scoped.HostCPUUtilizationPercent = c.HostCPUUtilizationPercent
+	scoped.TotalHostCount = c.HostCount
+	scoped.TotalCellCount = c.DiegoCellCount
+	scoped.TotalCPUCores = c.CPUCores
+	scoped.TotalVCPUs = c.TotalVCPUs
+	scoped.VCPURatio = c.VCPURatio
+	scoped.CPURiskLevel = CPURiskLevel(c.VCPURatio)
+
+	appShare := share(c.TotalCellMemoryGB, s.TotalCellMemoryGB)
+	scoped.TotalAppMemoryGB = apportion(s.TotalAppMemoryGB, appShare)
+	scoped.TotalAppDiskGB = apportion(s.TotalAppDiskGB, appShare)
+	scoped.TotalAppInstances = apportion(s.TotalAppInstances, appShare)
+	scoped.PlatformVMsGB = apportion(s.PlatformVMsGB, share(c.MemoryGB, s.TotalMemoryGB))
+	return scoped, true
+}
+
+// share returns part as a fraction of total, or 0 when total is not positive
+func share(part, total int) float64 {
+	if total <= 0 {
+		return 0
 	}
-	return "high"
+	return float64(part) / float64(total)
+}
+
+// apportion returns fraction of total, rounded to the nearest whole unit
+func apportion(total int, fraction float64) int {
+	return int(math.Round(float64(total) * fraction))
+}
+
+// CPURiskLevel returns the risk level based on vCPU:pCPU ratio
+// Thresholds: ≤4:1 = low, 4:1-8:1 = medium, >8:1 = high (see DefaultThresholds)
+func CPURiskLevel(ratio float64) string {
+	return DefaultThresholds().CPURiskLevel(ratio)
 }
 
 // CalculateHAHostFailures determines how many host failures a cluster can survive
@@ -130,8 +284,15 @@ func CalculateHAHostFailures(hostCount, memoryPerHost, haPercentage, requiredMem
 	return failuresSurvived, status
 }
 
-// ToInfrastructureState converts manual input to computed state
+// ToInfrastructureState converts manual input to computed state, classifying
+// CPU risk with the default vCPU:pCPU tiers
 func (mi *ManualInput) ToInfrastructureState() InfrastructureState {
+	return mi.ToInfrastructureStateWithThresholds(DefaultThresholds())
+}
+
+// ToInfrastructureStateWithThresholds converts manual input to computed state,
+// classifying CPU risk with the vCPU:pCPU tiers in t
+func (mi *ManualInput) ToInfrastructureStateWithThresholds(t Thresholds) InfrastructureState {
 	state := InfrastructureState{
 		Source:              "manual",
 		Name:                mi.Name,
@@ -223,7 +384,7 @@ func (mi *ManualInput) ToInfrastructureState() InfrastructureState {
 	if state.TotalCPUCores > 0 {
 		state.VCPURatio = float64(state.TotalVCPUs) / float64(state.TotalCPUCores)
 	}
-	state.CPURiskLevel = CPURiskLevel(state.VCPURatio)
+	state.CPURiskLevel = t.CPURiskLevel(state.VCPURatio)
 
 	// Calculate aggregate host utilization percentages
 	if state.TotalMemoryGB > 0 {
@@ -250,8 +411,128 @@ func (mi *ManualInput) ToInfrastructureState() InfrastructureState {
 
 	// Calculate average instance memory
 	if state.TotalAppInstances > 0 {
-		state.AvgInstanceMemoryMB = state.TotalAppMemoryGB * 1024 / state.TotalAppInstances
+		state.AvgInstanceMemoryMB = units.GBToMiB(state.TotalAppMemoryGB) / state.TotalAppInstances
 	}
 
 	return state
 }
+
+// ToManualInput reverses ToInfrastructureState, recovering the raw cluster
+// inputs and app totals so a discovered state can be edited and re-imported.
+// Converting the result back reproduces the computed fields; figures only
+// discovery reports, such as offline hosts and datastores, are dropped.
+func (s *InfrastructureState) ToManualInput() ManualInput {
+	mi := ManualInput{
+		Format:              FormatManualInput,
+		Name:                s.Name,
+		Clusters:            make([]ClusterInput, len(s.Clusters)),
+		PlatformVMsGB:       s.PlatformVMsGB,
+		TotalAppMemoryGB:    s.TotalAppMemoryGB,
+		TotalAppDiskGB:      s.TotalAppDiskGB,
+		TotalAppInstances:   s.TotalAppInstances,
+		MaxInstanceMemoryMB: s.MaxInstanceMemoryMB,
+	}
+
+	for i, c := range s.Clusters {
+		memoryPerHost := c.MemoryGBPerHost
+		threadsPerHost := c.CPUThreadsPerHost
+		// States written before the per-host fields existed carry only totals
+		if c.HostCount > 0 {
+			if memoryPerHost == 0 {
+				memoryPerHost = c.MemoryGB / c.HostCount
+			}
+			if threadsPerHost == 0 {
+				threadsPerHost = c.CPUCores / c.HostCount
+			}
+		}
+
+		mi.Clusters[i] = ClusterInput{
+			Name:                         c.Name,
+			HostCount:                    c.HostCount,
+			MemoryGBPerHost:              memoryPerHost,
+			CPUThreadsPerHost:            threadsPerHost,
+			HAAdmissionControlPercentage: c.HAAdmissionControlPercentage,
+			DiegoCellCount:               c.DiegoCellCount,
+			DiegoCellMemoryGB:            c.DiegoCellMemoryGB,
+			DiegoCellCPU:                 c.DiegoCellCPU,
+			DiegoCellDiskGB:              c.DiegoCellDiskGB,
+		}
+	}
+
+	return mi
+}
+
+// Validate checks that the input describes infrastructure that can be
+// analyzed, returning one FieldError per problem (nil when valid). Field
+// names are JSON paths such as "clusters[0].host_count".
+func (mi *ManualInput) Validate() []FieldError {
+	var errs []FieldError
+	add := func(field, format string, args ...any) {
+		errs = append(errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch mi.Format {
+	case "", FormatManualInput:
+	case FormatInfrastructureState:
+		add("format", "is %q; submit infrastructure state to /api/v1/infrastructure/state", mi.Format)
+	default:
+		add("format", "must be %q or omitted, got %q", FormatManualInput, mi.Format)
+	}
+
+	if len(mi.Clusters) == 0 {
+		add("clusters", "at least one cluster is required")
+	}
+
+	for i, c := range mi.Clusters {
+		prefix := fmt.Sprintf("clusters[%d].", i)
+		if c.HostCount <= 0 {
+			add(prefix+"host_count", "must be greater than 0")
+		}
+		if c.MemoryGBPerHost <= 0 {
+			add(prefix+"memory_gb_per_host", "must be greater than 0")
+		}
+		if c.CPUThreadsPerHost < 0 {
+			add(prefix+"cpu_threads_per_host", "must not be negative")
+		}
+		if c.HAAdmissionControlPercentage < 0 || c.HAAdmissionControlPercentage > 100 {
+			add(prefix+"ha_admission_control_percentage", "must be between 0 and 100")
+		}
+		if c.DiegoCellCount < 0 {
+			add(prefix+"diego_cell_count", "must not be negative")
+		}
+		if c.DiegoCellMemoryGB <= 0 {
+			add(prefix+"diego_cell_memory_gb", "must be greater than 0")
+		}
+		if c.DiegoCellCPU < 0 {
+			add(prefix+"diego_cell_cpu", "must not be negative")
+		}
+		if c.DiegoCellDiskGB < 0 {
+			add(prefix+"diego_cell_disk_gb", "must not be negative")
+		}
+
+		hostMemory := c.HostCount * c.MemoryGBPerHost
+		cellMemory := c.DiegoCellCount * c.DiegoCellMemoryGB
+		if hostMemory > 0 && cellMemory > hostMemory {
+			add(prefix+"diego_cell_count", "%d cells x %d GB = %d GB exceeds the %d GB of host memory",
+				c.DiegoCellCount, c.DiegoCellMemoryGB, cellMemory, hostMemory)
+		}
+	}
+
+	if mi.PlatformVMsGB < 0 {
+		add("platform_vms_gb", "must not be negative")
+	}
+	if mi.TotalAppMemoryGB < 0 {
+		add("total_app_memory_gb", "must not be negative")
+	}
+	if mi.TotalAppDiskGB < 0 {
+		add("total_app_disk_gb", "must not be negative")
+	}
+	if mi.TotalAppInstances < 0 {
+		add("total_app_instances", "must not be negative")
+	}
+	if mi.MaxInstanceMemoryMB < 0 {
+		add("max_instance_memory_mb", "must not be negative")
+	}
+
+	return errs
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"encoding/json"
+	"reflect"
 	"testing"
 )
 
@@ -180,3 +181,166 @@ func TestMaxInstanceMemoryMB_JSONParsing(t *testing.T) {
 		t.Errorf("Expected state.MaxInstanceMemoryMB 4096, got %d", state.MaxInstanceMemoryMB)
 	}
 }
+
+func TestManualInputValidate(t *testing.T) {
+	valid := func() ManualInput {
+		return ManualInput{
+			Name: "Valid",
+			Clusters: []ClusterInput{{
+				Name:              "cluster-01",
+				HostCount:         4,
+				MemoryGBPerHost:   512,
+				CPUThreadsPerHost: 64,
+				DiegoCellCount:    20,
+				DiegoCellMemoryGB: 64,
+				DiegoCellCPU:      8,
+			}},
+			TotalAppMemoryGB: 500,
+		}
+	}
+
+	tests := []struct {
+		name      string
+		mutate    func(*ManualInput)
+		wantField string
+	}{
+		{"valid input", func(*ManualInput) {}, ""},
+		{"no clusters", func(mi *ManualInput) { mi.Clusters = nil }, "clusters"},
+		{"zero hosts", func(mi *ManualInput) { mi.Clusters[0].HostCount = 0 }, "clusters[0].host_count"},
+		{"negative host memory", func(mi *ManualInput) { mi.Clusters[0].MemoryGBPerHost = -1 }, "clusters[0].memory_gb_per_host"},
+		{"zero cell memory", func(mi *ManualInput) { mi.Clusters[0].DiegoCellMemoryGB = 0 }, "clusters[0].diego_cell_memory_gb"},
+		{"HA over 100", func(mi *ManualInput) { mi.Clusters[0].HAAdmissionControlPercentage = 150 }, "clusters[0].ha_admission_control_percentage"},
+		{"cells exceed host memory", func(mi *ManualInput) { mi.Clusters[0].DiegoCellCount = 33 }, "clusters[0].diego_cell_count"},
+		{"negative app memory", func(mi *ManualInput) { mi.TotalAppMemoryGB = -10 }, "total_app_memory_gb"},
+		{"explicit manual_input format", func(mi *ManualInput) { mi.Format = FormatManualInput }, ""},
+		{"infrastructure_state format", func(mi *ManualInput) { mi.Format = FormatInfrastructureState }, "format"},
+		{"unknown format", func(mi *ManualInput) { mi.Format = "csv" }, "format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mi := valid()
+			tt.mutate(&mi)
+			errs := mi.Validate()
+
+			if tt.wantField == "" {
+				if len(errs) != 0 {
+					t.Fatalf("Expected no errors, got %+v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 {
+				t.Fatalf("Expected 1 error for %s, got %+v", tt.wantField, errs)
+			}
+			if errs[0].Field != tt.wantField {
+				t.Errorf("Expected error on %s, got %s", tt.wantField, errs[0].Field)
+			}
+		})
+	}
+}
+
+func TestToManualInput_RoundTrip(t *testing.T) {
+	input := ManualInput{
+		Name: "Round Trip",
+		Clusters: []ClusterInput{
+			{
+				Name:                         "cluster-01",
+				HostCount:                    8,
+				MemoryGBPerHost:              1024,
+				CPUThreadsPerHost:            64,
+				HAAdmissionControlPercentage: 25,
+				DiegoCellCount:               100,
+				DiegoCellMemoryGB:            64,
+				DiegoCellCPU:                 8,
+				DiegoCellDiskGB:              200,
+			},
+			{
+				Name:              "cluster-02",
+				HostCount:         3,
+				MemoryGBPerHost:   512,
+				CPUThreadsPerHost: 48,
+				DiegoCellCount:    20,
+				DiegoCellMemoryGB: 32,
+				DiegoCellCPU:      4,
+				DiegoCellDiskGB:   100,
+			},
+		},
+		PlatformVMsGB:       1200,
+		TotalAppMemoryGB:    4500,
+		TotalAppDiskGB:      9000,
+		TotalAppInstances:   1800,
+		MaxInstanceMemoryMB: 8192,
+	}
+	state := input.ToInfrastructureState()
+
+	exported := state.ToManualInput()
+	if exported.Format != FormatManualInput {
+		t.Errorf("Expected format %q, got %q", FormatManualInput, exported.Format)
+	}
+	if errs := exported.Validate(); len(errs) > 0 {
+		t.Fatalf("Exported input fails validation: %v", errs)
+	}
+
+	exported.Format = ""
+	if !reflect.DeepEqual(exported, input) {
+		t.Errorf("ToManualInput() = %+v, want %+v", exported, input)
+	}
+
+	roundTripped := exported.ToInfrastructureState()
+	roundTripped.Timestamp = state.Timestamp
+	if !reflect.DeepEqual(roundTripped, state) {
+		t.Errorf("Round-tripped state differs:\n got %+v\nwant %+v", roundTripped, state)
+	}
+}
+
+func TestToManualInput_DerivesPerHostFromTotals(t *testing.T) {
+	state := InfrastructureState{
+		Clusters: []ClusterState{{Name: "legacy", HostCount: 4, MemoryGB: 2048, CPUCores: 128, DiegoCellCount: 10, DiegoCellMemoryGB: 32}},
+	}
+
+	c := state.ToManualInput().Clusters[0]
+	if c.MemoryGBPerHost != 512 || c.CPUThreadsPerHost != 32 {
+		t.Errorf("Expected 512 GB and 32 threads per host, got %d GB and %d threads", c.MemoryGBPerHost, c.CPUThreadsPerHost)
+	}
+}
+
+func TestForCluster(t *testing.T) {
+	// cluster-a holds 1280 of 1600 GB of cell memory (80%) and half the host memory
+	input := ManualInput{
+		Clusters: []ClusterInput{
+			{Name: "cluster-a", HostCount: 4, MemoryGBPerHost: 1024, CPUThreadsPerHost: 64, DiegoCellCount: 40, DiegoCellMemoryGB: 32, DiegoCellCPU: 4},
+			{Name: "cluster-b", HostCount: 4, MemoryGBPerHost: 1024, CPUThreadsPerHost: 64, DiegoCellCount: 10, DiegoCellMemoryGB: 32, DiegoCellCPU: 4},
+		},
+		PlatformVMsGB:     200,
+		TotalAppMemoryGB:  800,
+		TotalAppDiskGB:    1000,
+		TotalAppInstances: 500,
+	}
+	state := input.ToInfrastructureState()
+
+	scoped, ok := state.ForCluster("cluster-a")
+	if !ok {
+		t.Fatal("Expected cluster-a to be found")
+	}
+	if len(scoped.Clusters) != 1 || scoped.Clusters[0].Name != "cluster-a" {
+		t.Fatalf("Expected only cluster-a, got %+v", scoped.Clusters)
+	}
+	if scoped.TotalCellCount != 40 || scoped.TotalHostCount != 4 || scoped.TotalMemoryGB != 4096 || scoped.TotalN1MemoryGB != 3072 {
+		t.Errorf("Expected cluster-a totals, got cells=%d hosts=%d memory=%d n1=%d",
+			scoped.TotalCellCount, scoped.TotalHostCount, scoped.TotalMemoryGB, scoped.TotalN1MemoryGB)
+	}
+	if scoped.TotalAppMemoryGB != 640 || scoped.TotalAppDiskGB != 800 || scoped.TotalAppInstances != 400 {
+		t.Errorf("Expected 80%% of app totals, got memory=%d disk=%d instances=%d",
+			scoped.TotalAppMemoryGB, scoped.TotalAppDiskGB, scoped.TotalAppInstances)
+	}
+	if scoped.PlatformVMsGB != 100 {
+		t.Errorf("Expected half the platform VM memory, got %d", scoped.PlatformVMsGB)
+	}
+	if len(state.Clusters) != 2 || state.TotalCellCount != 50 {
+		t.Error("Expected the original state to be unchanged")
+	}
+
+	if _, ok := state.ForCluster("missing"); ok {
+		t.Error("Expected an unknown cluster not to be found")
+	}
+}
@@ -7,13 +7,19 @@ import "time"
 
 // DiegoCell represents a Diego cell VM with capacity metrics
 type DiegoCell struct {
-	ID               string `json:"id"`
-	Name             string `json:"name"`
-	MemoryMB         int    `json:"memory_mb"`
-	AllocatedMB      int    `json:"allocated_mb"`
-	UsedMB           int    `json:"used_mb"`
-	CPUPercent       int    `json:"cpu_percent"`
-	IsolationSegment string `json:"isolation_segment"`
+	ID                   string `json:"id"`
+	Name                 string `json:"name"`
+	MemoryMB             int    `json:"memory_mb"`
+	AllocatedMB          int    `json:"allocated_mb"`
+	UsedMB               int    `json:"used_mb"`
+	CPUPercent           int    `json:"cpu_percent"`
+	DiskPercent          int    `json:"disk_percent"`           // persistent disk usage from BOSH vitals
+	EphemeralDiskPercent int    `json:"ephemeral_disk_percent"` // ephemeral disk usage, where container data lives
+	IsolationSegment     string `json:"isolation_segment"`
+	// VitalsUnavailable is set when BOSH reported no memory vitals for the cell
+	// (e.g. an unresponsive agent). Its usage metrics are then zero rather than
+	// measured, so utilization averages should leave it out.
+	VitalsUnavailable bool `json:"vitals_unavailable"`
 }
 
 // App represents a Cloud Foundry application with memory and disk metrics
@@ -41,16 +47,50 @@ type DashboardResponse struct {
 	Metadata Metadata           `json:"metadata"`
 }
 
+// BOSHDumpResponse is the set of Diego cells parsed from a `bosh vms --json` dump
+type BOSHDumpResponse struct {
+	Cells []DiegoCell `json:"cells"`
+}
+
 // Metadata contains response metadata
 type Metadata struct {
 	Timestamp     time.Time `json:"timestamp"`
 	Cached        bool      `json:"cached"`
 	BOSHAvailable bool      `json:"bosh_available"`
+	// VitalsUnavailableCells counts cells with VitalsUnavailable set
+	VitalsUnavailableCells int `json:"vitals_unavailable_cells"`
 }
 
 // ErrorResponse represents an error response
 type ErrorResponse struct {
-	Error   string `json:"error"`
-	Details string `json:"details,omitempty"`
-	Code    int    `json:"code"`
+	Error   string       `json:"error"`
+	Details string       `json:"details,omitempty"`
+	Code    int          `json:"code"`
+	Errors  []FieldError `json:"errors,omitempty"` // Per-field validation failures
+}
+
+// DependencyStatus reports the reachability of one backing system
+type DependencyStatus struct {
+	Status    string `json:"status"` // "ok", "error", or "not_configured"
+	LatencyMS int64  `json:"latency_ms,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ReadinessResponse is returned by the readiness check
+type ReadinessResponse struct {
+	Status       string                      `json:"status"` // "ready" or "not_ready"
+	Dependencies map[string]DependencyStatus `json:"dependencies"`
+}
+
+// ConfigResponse reports the backend's effective non-secret configuration
+type ConfigResponse struct {
+	Version                string          `json:"version"`
+	AuthMode               string          `json:"auth_mode"`
+	CORSAllowedOrigins     []string        `json:"cors_allowed_origins"`
+	CacheTTLSeconds        int             `json:"cache_ttl_seconds"`
+	DashboardTTLSeconds    int             `json:"dashboard_cache_ttl_seconds"`
+	VSphereCacheTTLSeconds int             `json:"vsphere_cache_ttl_seconds"`
+	StatePersisted         bool            `json:"state_persisted"` // STATE_FILE is set
+	RateLimitEnabled       bool            `json:"rate_limit_enabled"`
+	Configured             map[string]bool `json:"configured"` // cf, bosh, vsphere, credhub, ai
 }
@@ -42,3 +42,18 @@ type PlanningResponse struct {
 	Result          PlanningResult         `json:"result"`
 	Recommendations []SizingRecommendation `json:"recommendations"`
 }
+
+// MaxCellsResult reports how many cells of a given size fit before breaching
+// the N-1 memory target or the vCPU:pCPU ratio target
+type MaxCellsResult struct {
+	CellMemoryGB     int     `json:"cell_memory_gb"`
+	CellCPU          int     `json:"cell_cpu"`
+	TargetN1Pct      float64 `json:"target_n1_pct"`
+	TargetVCPURatio  float64 `json:"target_vcpu_ratio"`
+	CurrentCellCount int     `json:"current_cell_count"`
+	MaxCellsByMemory int     `json:"max_cells_by_memory"` // Keeps N-1 utilization at or below target
+	MaxCellsByCPU    int     `json:"max_cells_by_cpu"`    // Keeps vCPU:pCPU at or below target (0 if CPU data unavailable)
+	MaxCells         int     `json:"max_cells"`           // MIN of the applicable limits
+	AdditionalCells  int     `json:"additional_cells"`    // MaxCells - CurrentCellCount (negative if already over)
+	LimitingResource string  `json:"limiting_resource"`   // "memory", "cpu", or "balanced"
+}
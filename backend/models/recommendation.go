@@ -6,17 +6,24 @@ package models
 import (
 	"fmt"
 	"sort"
+	"strings"
 )
 
 // RecommendationType defines the type of upgrade recommendation
 type RecommendationType string
 
 const (
-	RecommendationAddCells    RecommendationType = "add_cells"
-	RecommendationResizeCells RecommendationType = "resize_cells"
-	RecommendationAddHosts    RecommendationType = "add_hosts"
+	RecommendationAddCells             RecommendationType = "add_cells"
+	RecommendationResizeCells          RecommendationType = "resize_cells"
+	RecommendationAddHosts             RecommendationType = "add_hosts"
+	RecommendationRebalance            RecommendationType = "rebalance"
+	RecommendationResizeForFaultImpact RecommendationType = "resize_for_fault_impact"
 )
 
+// RebalanceSpreadThresholdPct is the gap in host memory utilization between the
+// busiest and idlest cluster above which rebalancing cells is recommended
+const RebalanceSpreadThresholdPct = 25.0
+
 // Recommendation represents an actionable upgrade recommendation
 type Recommendation struct {
 	Type            RecommendationType `json:"type"`
@@ -30,6 +37,35 @@ type Recommendation struct {
 	HostsToAdd      int                `json:"hosts_to_add,omitempty"`
 	NewCellMemoryGB int                `json:"new_cell_memory_gb,omitempty"`
 	NewCellCPU      int                `json:"new_cell_cpu,omitempty"`
+	// Rebalance recommendations only
+	CellsToMove        int                   `json:"cells_to_move,omitempty"`
+	TargetDistribution []ClusterDistribution `json:"target_distribution,omitempty"`
+	EstimatedCost      *EstimatedCost        `json:"estimated_cost,omitempty"`
+}
+
+// Cost tiers, relative to each other rather than in currency
+const (
+	CostTierLow    = "low"
+	CostTierMedium = "medium"
+	CostTierHigh   = "high"
+)
+
+// EstimatedCost is the coarse relative cost of acting on a recommendation,
+// so remediations can be compared by what they require
+type EstimatedCost struct {
+	HostCountDelta int    `json:"host_count_delta"` // Physical hosts to buy
+	MemoryGBDelta  int    `json:"memory_gb_delta"`  // Memory added (cell memory for cells, host memory for hosts)
+	Tier           string `json:"tier"`             // "low", "medium", or "high"
+}
+
+// ClusterDistribution is one cluster's cell count and host memory utilization
+// before and after a recommended rebalance
+type ClusterDistribution struct {
+	ClusterName           string  `json:"cluster_name"`
+	CurrentCellCount      int     `json:"current_cell_count"`
+	TargetCellCount       int     `json:"target_cell_count"`
+	CurrentUtilizationPct float64 `json:"current_utilization_pct"`
+	TargetUtilizationPct  float64 `json:"target_utilization_pct"`
 }
 
 // RecommendationsResponse wraps the list of recommendations with context
@@ -90,17 +126,34 @@ func GenerateAddCellsRecommendation(state InfrastructureState, constrainingResou
 	}
 
 	return &Recommendation{
-		Type:        RecommendationAddCells,
-		Priority:    1,
-		Title:       "Add Diego Cells",
-		Description: fmt.Sprintf("Add %d more Diego cells to increase capacity", cellsToAdd),
-		Impact:      impact,
-		ImpactLevel: "high",
-		Resource:    constrainingResource,
-		CellsToAdd:  cellsToAdd,
+		Type:          RecommendationAddCells,
+		Priority:      1,
+		Title:         "Add Diego Cells",
+		Description:   fmt.Sprintf("Add %d more Diego cells to increase capacity", cellsToAdd),
+		Impact:        impact,
+		ImpactLevel:   "high",
+		Resource:      constrainingResource,
+		CellsToAdd:    cellsToAdd,
+		EstimatedCost: addCellsCost(state, cluster, cellsToAdd),
 	}
 }
 
+// addCellsCost is medium when the new cells fit in existing N-1 host memory,
+// and high when hosts must be bought to hold them
+func addCellsCost(state InfrastructureState, cluster ClusterState, cellsToAdd int) *EstimatedCost {
+	addedGB := cellsToAdd * cluster.DiegoCellMemoryGB
+	cost := &EstimatedCost{MemoryGBDelta: addedGB, Tier: CostTierMedium}
+
+	overflowGB := state.TotalCellMemoryGB + state.PlatformVMsGB + addedGB - state.TotalN1MemoryGB
+	if overflowGB > 0 {
+		cost.Tier = CostTierHigh
+		if cluster.MemoryGBPerHost > 0 {
+			cost.HostCountDelta = (overflowGB + cluster.MemoryGBPerHost - 1) / cluster.MemoryGBPerHost
+		}
+	}
+	return cost
+}
+
 // GenerateResizeCellsRecommendation creates a recommendation to resize Diego cells
 func GenerateResizeCellsRecommendation(state InfrastructureState, constrainingResource string) *Recommendation {
 	if len(state.Clusters) == 0 {
@@ -150,6 +203,10 @@ func GenerateResizeCellsRecommendation(state InfrastructureState, constrainingRe
 		Resource:        constrainingResource,
 		NewCellMemoryGB: newMemory,
 		NewCellCPU:      newCPU,
+		EstimatedCost: &EstimatedCost{
+			MemoryGBDelta: state.TotalCellCount * (newMemory - cluster.DiegoCellMemoryGB),
+			Tier:          CostTierLow,
+		},
 	}
 }
 
@@ -219,6 +276,118 @@ func GenerateAddHostsRecommendation(state InfrastructureState, constrainingResou
 		ImpactLevel: "low",
 		Resource:    constrainingResource,
 		HostsToAdd:  hostsToAdd,
+		EstimatedCost: &EstimatedCost{
+			HostCountDelta: hostsToAdd,
+			MemoryGBDelta:  hostsToAdd * cluster.MemoryGBPerHost,
+			Tier:           CostTierHigh,
+		},
+	}
+}
+
+// GenerateRebalanceRecommendation creates a recommendation to move cells from
+// busy clusters to idle ones when host memory utilization is uneven. Returns
+// nil for single-cluster foundations or when the spread is within threshold.
+func GenerateRebalanceRecommendation(state InfrastructureState) *Recommendation {
+	type clusterLoad struct {
+		dist     ClusterDistribution
+		hostGB   int
+		cellGB   int // memory of the cells currently placed on the cluster
+		cellSize int // memory of one of this cluster's cells
+	}
+
+	var loads []clusterLoad
+	for _, c := range state.Clusters {
+		if c.MemoryGB == 0 || c.DiegoCellMemoryGB == 0 {
+			continue
+		}
+		loads = append(loads, clusterLoad{
+			dist: ClusterDistribution{
+				ClusterName:           c.Name,
+				CurrentCellCount:      c.DiegoCellCount,
+				TargetCellCount:       c.DiegoCellCount,
+				CurrentUtilizationPct: c.HostMemoryUtilizationPercent,
+			},
+			hostGB:   c.MemoryGB,
+			cellGB:   c.TotalCellMemoryGB,
+			cellSize: c.DiegoCellMemoryGB,
+		})
+	}
+	if len(loads) < 2 {
+		return nil
+	}
+
+	util := func(cellGB, hostGB int) float64 {
+		return float64(cellGB) / float64(hostGB) * 100.0
+	}
+	busiestAndIdlest := func() (int, int) {
+		hi, lo := 0, 0
+		for i, l := range loads {
+			if util(l.cellGB, l.hostGB) > util(loads[hi].cellGB, loads[hi].hostGB) {
+				hi = i
+			}
+			if util(l.cellGB, l.hostGB) < util(loads[lo].cellGB, loads[lo].hostGB) {
+				lo = i
+			}
+		}
+		return hi, lo
+	}
+
+	hi, lo := busiestAndIdlest()
+	currentSpread := util(loads[hi].cellGB, loads[hi].hostGB) - util(loads[lo].cellGB, loads[lo].hostGB)
+	if currentSpread <= RebalanceSpreadThresholdPct {
+		return nil
+	}
+
+	// Move one cell at a time from the busiest to the idlest cluster, stopping
+	// before a move would leave the receiving cluster busier than the sender
+	cellsToMove := 0
+	for range state.TotalCellCount {
+		hi, lo = busiestAndIdlest()
+		src, dst := &loads[hi], &loads[lo]
+		if hi == lo || src.dist.TargetCellCount == 0 {
+			break
+		}
+		if util(dst.cellGB+src.cellSize, dst.hostGB) > util(src.cellGB-src.cellSize, src.hostGB) {
+			break
+		}
+		src.cellGB -= src.cellSize
+		src.dist.TargetCellCount--
+		dst.cellGB += src.cellSize
+		dst.dist.TargetCellCount++
+		cellsToMove++
+	}
+	if cellsToMove == 0 {
+		return nil
+	}
+
+	distribution := make([]ClusterDistribution, len(loads))
+	var sources, destinations []string
+	for i, l := range loads {
+		l.dist.TargetUtilizationPct = util(l.cellGB, l.hostGB)
+		distribution[i] = l.dist
+		switch {
+		case l.dist.TargetCellCount < l.dist.CurrentCellCount:
+			sources = append(sources, l.dist.ClusterName)
+		case l.dist.TargetCellCount > l.dist.CurrentCellCount:
+			destinations = append(destinations, l.dist.ClusterName)
+		}
+	}
+	hi, lo = busiestAndIdlest()
+	targetSpread := util(loads[hi].cellGB, loads[hi].hostGB) - util(loads[lo].cellGB, loads[lo].hostGB)
+
+	return &Recommendation{
+		Type:     RecommendationRebalance,
+		Priority: 1,
+		Title:    "Rebalance Cells Across Clusters",
+		Description: fmt.Sprintf("Move %d cells from %s to %s to even out host memory utilization",
+			cellsToMove, strings.Join(sources, ", "), strings.Join(destinations, ", ")),
+		Impact: fmt.Sprintf("Narrows the host memory utilization spread from %.0f to %.0f points without new hardware",
+			currentSpread, targetSpread),
+		ImpactLevel:        "high",
+		Resource:           "Memory",
+		CellsToMove:        cellsToMove,
+		TargetDistribution: distribution,
+		EstimatedCost:      &EstimatedCost{Tier: CostTierLow},
 	}
 }
 
@@ -234,6 +403,14 @@ func GenerateRecommendations(state InfrastructureState) []Recommendation {
 
 	var recs []Recommendation
 
+	// Rebalancing needs no new hardware, so when it applies it outranks the
+	// other options, which each drop one priority level
+	priorityOffset := 0
+	if rec := GenerateRebalanceRecommendation(state); rec != nil {
+		recs = append(recs, *rec)
+		priorityOffset = 1
+	}
+
 	// Generate recommendations for the constraining resource first
 	if rec := GenerateAddCellsRecommendation(state, constrainingResource); rec != nil {
 		recs = append(recs, *rec)
@@ -245,6 +422,12 @@ func GenerateRecommendations(state InfrastructureState) []Recommendation {
 		recs = append(recs, *rec)
 	}
 
+	for i := range recs {
+		if recs[i].Type != RecommendationRebalance {
+			recs[i].Priority += priorityOffset
+		}
+	}
+
 	// Sort by priority
 	sort.SliceStable(recs, func(i, j int) bool {
 		return recs[i].Priority < recs[j].Priority
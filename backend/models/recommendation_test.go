@@ -1,10 +1,11 @@
 // ABOUTME: Tests for upgrade path recommendations
-// ABOUTME: Validates add cells, resize cells, and add hosts recommendation logic
+// ABOUTME: Validates add cells, resize cells, add hosts, and rebalance recommendation logic
 
 package models
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 )
 
@@ -386,6 +387,106 @@ func TestRecommendationPriority_ConstrainingResourceFirst(t *testing.T) {
 	}
 }
 
+func TestGenerateRebalanceRecommendation(t *testing.T) {
+	skewed := ManualInput{
+		Name: "Skewed",
+		Clusters: []ClusterInput{
+			{Name: "cluster-a", HostCount: 4, MemoryGBPerHost: 512, CPUThreadsPerHost: 64, DiegoCellCount: 60, DiegoCellMemoryGB: 32, DiegoCellCPU: 4},
+			{Name: "cluster-b", HostCount: 4, MemoryGBPerHost: 512, CPUThreadsPerHost: 64, DiegoCellCount: 25, DiegoCellMemoryGB: 32, DiegoCellCPU: 4},
+		},
+		TotalAppMemoryGB: 1500,
+	}
+
+	rec := GenerateRebalanceRecommendation(skewed.ToInfrastructureState())
+	if rec == nil {
+		t.Fatal("Expected a rebalance recommendation for 94% vs 39% host memory utilization")
+	}
+	if rec.Type != RecommendationRebalance || rec.Priority != 1 {
+		t.Errorf("Expected rebalance at priority 1, got %s at %d", rec.Type, rec.Priority)
+	}
+	// 85 cells split 43/42 is as even as whole cells allow
+	if rec.CellsToMove != 17 {
+		t.Errorf("Expected 17 cells to move, got %d", rec.CellsToMove)
+	}
+	if len(rec.TargetDistribution) != 2 {
+		t.Fatalf("Expected 2 clusters in target distribution, got %d", len(rec.TargetDistribution))
+	}
+	a, b := rec.TargetDistribution[0], rec.TargetDistribution[1]
+	if a.TargetCellCount != 43 || b.TargetCellCount != 42 {
+		t.Errorf("Expected target 43/42 cells, got %d/%d", a.TargetCellCount, b.TargetCellCount)
+	}
+	if a.TargetUtilizationPct-b.TargetUtilizationPct > RebalanceSpreadThresholdPct {
+		t.Errorf("Expected rebalanced spread within threshold, got %.1f%% vs %.1f%%", a.TargetUtilizationPct, b.TargetUtilizationPct)
+	}
+	if !strings.Contains(rec.Description, "from cluster-a to cluster-b") {
+		t.Errorf("Expected description to name source and destination, got %q", rec.Description)
+	}
+
+	// Balanced and single-cluster foundations get no rebalance recommendation
+	balanced := skewed
+	balanced.Clusters = []ClusterInput{skewed.Clusters[0], skewed.Clusters[0]}
+	balanced.Clusters[1].Name = "cluster-b"
+	if rec := GenerateRebalanceRecommendation(balanced.ToInfrastructureState()); rec != nil {
+		t.Errorf("Expected no rebalance for balanced clusters, got %+v", rec)
+	}
+	single := skewed
+	single.Clusters = skewed.Clusters[:1]
+	if rec := GenerateRebalanceRecommendation(single.ToInfrastructureState()); rec != nil {
+		t.Errorf("Expected no rebalance for a single cluster, got %+v", rec)
+	}
+}
+
+func TestRecommendationEstimatedCost(t *testing.T) {
+	// 4 × 1024 GB hosts leave 3072 GB of N-1 memory for cells
+	t.Run("add cells within host capacity is medium", func(t *testing.T) {
+		state := createTestInfrastructure(4, 1024, 64, 50, 32, 4, 100, 1200, 2000)
+		rec := GenerateAddCellsRecommendation(state, "Memory")
+		if rec == nil || rec.EstimatedCost == nil {
+			t.Fatal("Expected add cells recommendation with a cost")
+		}
+		cost := rec.EstimatedCost
+		if cost.Tier != CostTierMedium || cost.HostCountDelta != 0 || cost.MemoryGBDelta != rec.CellsToAdd*32 {
+			t.Errorf("Expected medium cost adding %d GB and no hosts, got %+v", rec.CellsToAdd*32, cost)
+		}
+	})
+
+	t.Run("add cells beyond host capacity is high", func(t *testing.T) {
+		// 90 cells (2880 GB) + 27 more (864 GB) overflows N-1 by 672 GB
+		state := createTestInfrastructure(4, 1024, 64, 90, 32, 4, 100, 2600, 2000)
+		rec := GenerateAddCellsRecommendation(state, "Memory")
+		if rec == nil || rec.EstimatedCost == nil {
+			t.Fatal("Expected add cells recommendation with a cost")
+		}
+		if rec.EstimatedCost.Tier != CostTierHigh || rec.EstimatedCost.HostCountDelta != 1 {
+			t.Errorf("Expected high cost needing 1 host, got %+v", rec.EstimatedCost)
+		}
+	})
+
+	t.Run("resize cells is low", func(t *testing.T) {
+		state := createTestInfrastructure(4, 1024, 64, 50, 32, 4, 100, 1200, 2000)
+		rec := GenerateResizeCellsRecommendation(state, "Memory")
+		if rec == nil || rec.EstimatedCost == nil {
+			t.Fatal("Expected resize cells recommendation with a cost")
+		}
+		// 50 cells grow from 32 GB to 64 GB
+		if rec.EstimatedCost.Tier != CostTierLow || rec.EstimatedCost.MemoryGBDelta != 1600 {
+			t.Errorf("Expected low cost adding 1600 GB, got %+v", rec.EstimatedCost)
+		}
+	})
+
+	t.Run("add hosts is high", func(t *testing.T) {
+		state := createTestInfrastructure(4, 1024, 64, 100, 32, 4, 100, 3000, 2000)
+		rec := GenerateAddHostsRecommendation(state, "Memory")
+		if rec == nil || rec.EstimatedCost == nil {
+			t.Fatal("Expected add hosts recommendation with a cost")
+		}
+		cost := rec.EstimatedCost
+		if cost.Tier != CostTierHigh || cost.HostCountDelta != rec.HostsToAdd || cost.MemoryGBDelta != rec.HostsToAdd*1024 {
+			t.Errorf("Expected high cost for %d hosts, got %+v", rec.HostsToAdd, cost)
+		}
+	})
+}
+
 func TestRecommendationsResponse_Serialization(t *testing.T) {
 	response := RecommendationsResponse{
 		Recommendations: []Recommendation{
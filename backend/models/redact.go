@@ -0,0 +1,261 @@
+// ABOUTME: Pseudonymizes foundation, cluster, cell, segment, and app names for sharing
+// ABOUTME: Names map to stable aliases such as cluster-1 and cell-a; capacity numbers are kept
+
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Redactor replaces names with pseudonyms. Within one Redactor the same name
+// always maps to the same pseudonym, so a cell and its isolation segment still
+// line up after redaction. Use a new Redactor per response.
+type Redactor struct {
+	foundations map[string]string
+	clusters    map[string]string
+	cells       map[string]string
+	segments    map[string]string
+	apps        map[string]string
+}
+
+// NewRedactor returns a Redactor with no names assigned yet
+func NewRedactor() *Redactor {
+	return &Redactor{
+		foundations: make(map[string]string),
+		clusters:    make(map[string]string),
+		cells:       make(map[string]string),
+		segments:    make(map[string]string),
+		apps:        make(map[string]string),
+	}
+}
+
+// Foundation returns the pseudonym of a foundation name, e.g. foundation-1
+func (r *Redactor) Foundation(name string) string {
+	return alias(r.foundations, name, numbered("foundation"))
+}
+
+// Cluster returns the pseudonym of a cluster name, e.g. cluster-1
+func (r *Redactor) Cluster(name string) string {
+	return alias(r.clusters, name, numbered("cluster"))
+}
+
+// Cell returns the pseudonym of a cell name, e.g. cell-a
+func (r *Redactor) Cell(name string) string {
+	return alias(r.cells, name, func(n int) string { return "cell-" + letters(n) })
+}
+
+// Segment returns the pseudonym of an isolation segment name, e.g.
+// segment-1. The shared segment names ("default", "shared") reveal nothing
+// and are kept, so redacted output still tells shared cells apart.
+func (r *Redactor) Segment(name string) string {
+	if name == "default" || name == "shared" {
+		return name
+	}
+	return alias(r.segments, name, numbered("segment"))
+}
+
+// App returns the pseudonym of an app name, e.g. app-1
+func (r *Redactor) App(name string) string {
+	return alias(r.apps, name, numbered("app"))
+}
+
+// InfrastructureState returns a copy of s with the foundation and cluster
+// names redacted. s is not modified.
+func (r *Redactor) InfrastructureState(s InfrastructureState) InfrastructureState {
+	s.Name = r.Foundation(s.Name)
+	clusters := make([]ClusterState, len(s.Clusters))
+	for i, c := range s.Clusters {
+		c.Name = r.Cluster(c.Name)
+		clusters[i] = c
+	}
+	s.Clusters = clusters
+	return s
+}
+
+// ClusterList returns a copy of l with the foundation and cluster names redacted
+func (r *Redactor) ClusterList(l ClusterList) ClusterList {
+	l.Name = r.Foundation(l.Name)
+	clusters := make([]ClusterSummary, len(l.Clusters))
+	for i, c := range l.Clusters {
+		c.Name = r.Cluster(c.Name)
+		clusters[i] = c
+	}
+	l.Clusters = clusters
+	return l
+}
+
+// Dashboard returns a copy of d with cell, app, and segment names redacted.
+// Cell IDs become the cell's pseudonym and GUIDs are dropped, since both
+// identify the foundation's resources.
+func (r *Redactor) Dashboard(d DashboardResponse) DashboardResponse {
+	cells := make([]DiegoCell, len(d.Cells))
+	for i, c := range d.Cells {
+		c.Name = r.Cell(c.Name)
+		c.ID = c.Name
+		c.IsolationSegment = r.Segment(c.IsolationSegment)
+		cells[i] = c
+	}
+	apps := make([]App, len(d.Apps))
+	for i, a := range d.Apps {
+		a.Name = r.App(a.Name)
+		a.GUID = ""
+		a.IsolationSegment = r.Segment(a.IsolationSegment)
+		apps[i] = a
+	}
+	segments := make([]IsolationSegment, len(d.Segments))
+	for i, s := range d.Segments {
+		s.Name = r.Segment(s.Name)
+		s.GUID = ""
+		segments[i] = s
+	}
+	d.Cells, d.Apps, d.Segments = cells, apps, segments
+	return d
+}
+
+// ScenarioComparison returns a copy of c with the foundation and cluster
+// names of state redacted from target_cluster, unchanged_clusters, the
+// baseline's target cluster, and the text of warnings and recommendations.
+// Clusters are numbered in state order, as InfrastructureState names them.
+func (r *Redactor) ScenarioComparison(c ScenarioComparison, state InfrastructureState) ScenarioComparison {
+	r.InfrastructureState(state)
+
+	c.TargetCluster = r.Cluster(c.TargetCluster)
+	if c.UnchangedClusters != nil {
+		unchanged := make([]ClusterSummary, len(c.UnchangedClusters))
+		for i, u := range c.UnchangedClusters {
+			u.Name = r.Cluster(u.Name)
+			unchanged[i] = u
+		}
+		c.UnchangedClusters = unchanged
+	}
+
+	if c.Warnings != nil {
+		warnings := make([]ScenarioWarning, len(c.Warnings))
+		for i, w := range c.Warnings {
+			w.Message = r.text(w.Message)
+			if w.Fixes != nil {
+				fixes := make([]FixSuggestion, len(w.Fixes))
+				for j, f := range w.Fixes {
+					f.Description = r.text(f.Description)
+					fixes[j] = f
+				}
+				w.Fixes = fixes
+			}
+			warnings[i] = w
+		}
+		c.Warnings = warnings
+	}
+
+	if c.Recommendations != nil {
+		recs := make([]Recommendation, len(c.Recommendations))
+		for i, rec := range c.Recommendations {
+			rec.Title = r.text(rec.Title)
+			rec.Description = r.text(rec.Description)
+			rec.Impact = r.text(rec.Impact)
+			if rec.TargetDistribution != nil {
+				dist := make([]ClusterDistribution, len(rec.TargetDistribution))
+				for j, d := range rec.TargetDistribution {
+					d.ClusterName = r.Cluster(d.ClusterName)
+					dist[j] = d
+				}
+				rec.TargetDistribution = dist
+			}
+			recs[i] = rec
+		}
+		c.Recommendations = recs
+	}
+
+	if c.Baseline != nil {
+		baseline := *c.Baseline
+		baseline.Baseline.TargetCluster = r.Cluster(baseline.Baseline.TargetCluster)
+		c.Baseline = &baseline
+	}
+	return c
+}
+
+// text replaces the foundation and cluster names assigned so far wherever
+// they stand on their own in s, so a short name such as "prod" leaves
+// "production" alone. Longer names are tried first, so a name containing
+// another is replaced whole.
+func (r *Redactor) text(s string) string {
+	aliases := make(map[string]string, len(r.foundations)+len(r.clusters))
+	for name, a := range r.foundations {
+		aliases[name] = a
+	}
+	for name, a := range r.clusters {
+		aliases[name] = a
+	}
+	if len(aliases) == 0 {
+		return s
+	}
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if len(names[i]) != len(names[j]) {
+			return len(names[i]) > len(names[j])
+		}
+		return names[i] < names[j]
+	})
+
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		matched := ""
+		if i == 0 || !isNameByte(s[i-1]) {
+			for _, name := range names {
+				end := i + len(name)
+				if strings.HasPrefix(s[i:], name) && (end == len(s) || !isNameByte(s[end])) {
+					matched = name
+					break
+				}
+			}
+		}
+		if matched != "" {
+			b.WriteString(aliases[matched])
+			i += len(matched)
+			continue
+		}
+		b.WriteByte(s[i])
+		i++
+	}
+	return b.String()
+}
+
+// isNameByte reports whether b can continue a word in a name. Hyphens and
+// underscores count, since cluster names such as "prod-pci" use them.
+func isNameByte(b byte) bool {
+	return b == '-' || b == '_' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= 0x80
+}
+
+// alias returns the pseudonym of name in seen, assigning the next one from
+// next when name is new. Empty names stay empty.
+func alias(seen map[string]string, name string, next func(n int) string) string {
+	if name == "" {
+		return ""
+	}
+	if a, ok := seen[name]; ok {
+		return a
+	}
+	a := next(len(seen) + 1)
+	seen[name] = a
+	return a
+}
+
+// numbered returns a pseudonym generator of the form prefix-1, prefix-2, ...
+func numbered(prefix string) func(n int) string {
+	return func(n int) string { return fmt.Sprintf("%s-%d", prefix, n) }
+}
+
+// letters returns the n-th (1-based) spreadsheet-style column name: a ... z, aa, ab, ...
+func letters(n int) string {
+	var s []byte
+	for n > 0 {
+		n--
+		s = append([]byte{byte('a' + n%26)}, s...)
+		n /= 26
+	}
+	return string(s)
+}
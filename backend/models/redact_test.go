@@ -0,0 +1,135 @@
+// ABOUTME: Tests for name redaction
+// ABOUTME: Validates stable pseudonyms, preserved numbers, whole-name text matching, and that inputs are not modified
+
+package models
+
+import "testing"
+
+func TestRedactor_StablePseudonyms(t *testing.T) {
+	r := NewRedactor()
+
+	if got := r.Cluster("prod-west"); got != "cluster-1" {
+		t.Errorf("Expected cluster-1, got %q", got)
+	}
+	if got := r.Cluster("prod-east"); got != "cluster-2" {
+		t.Errorf("Expected cluster-2, got %q", got)
+	}
+	if got := r.Cluster("prod-west"); got != "cluster-1" {
+		t.Errorf("Expected a repeated name to keep cluster-1, got %q", got)
+	}
+	if got := r.Cluster(""); got != "" {
+		t.Errorf("Expected an empty name to stay empty, got %q", got)
+	}
+
+	if got := r.Cell("diego_cell/0"); got != "cell-a" {
+		t.Errorf("Expected cell-a, got %q", got)
+	}
+	for n, want := range map[int]string{26: "z", 27: "aa", 28: "ab", 702: "zz", 703: "aaa"} {
+		if got := letters(n); got != want {
+			t.Errorf("Expected letters(%d) = %s, got %s", n, want, got)
+		}
+	}
+
+	if got := r.Segment("default"); got != "default" {
+		t.Errorf("Expected the default segment to be kept, got %q", got)
+	}
+	if got := r.Segment("pci"); got != "segment-1" {
+		t.Errorf("Expected segment-1, got %q", got)
+	}
+}
+
+func TestRedactor_InfrastructureState(t *testing.T) {
+	state := diffTestState()
+	redacted := NewRedactor().InfrastructureState(state)
+
+	if redacted.Name != "foundation-1" {
+		t.Errorf("Expected foundation-1, got %q", redacted.Name)
+	}
+	if redacted.Clusters[0].Name != "cluster-1" || redacted.Clusters[1].Name != "cluster-2" {
+		t.Errorf("Expected cluster-1 and cluster-2, got %q and %q", redacted.Clusters[0].Name, redacted.Clusters[1].Name)
+	}
+	if redacted.TotalCellMemoryGB != 1280 || redacted.Clusters[0].DiegoCellCount != 20 {
+		t.Errorf("Expected capacity numbers to be kept, got %+v", redacted)
+	}
+	if state.Name != "prod" || state.Clusters[0].Name != "cluster-a" {
+		t.Errorf("Expected the original state to be unchanged, got %q / %q", state.Name, state.Clusters[0].Name)
+	}
+}
+
+func TestRedactor_Dashboard(t *testing.T) {
+	d := DashboardResponse{
+		Cells: []DiegoCell{
+			{ID: "vm-123", Name: "diego_cell/0", MemoryMB: 32768, IsolationSegment: "pci"},
+			{ID: "vm-456", Name: "diego_cell/1", MemoryMB: 32768, IsolationSegment: "default"},
+		},
+		Apps:     []App{{Name: "payments", GUID: "guid-1", RequestedMB: 1024, IsolationSegment: "pci"}},
+		Segments: []IsolationSegment{{GUID: "seg-guid", Name: "pci"}},
+	}
+	redacted := NewRedactor().Dashboard(d)
+
+	cell := redacted.Cells[0]
+	if cell.Name != "cell-a" || cell.ID != "cell-a" || cell.MemoryMB != 32768 {
+		t.Errorf("Expected cell-a with its memory kept, got %+v", cell)
+	}
+	if redacted.Cells[1].Name != "cell-b" || redacted.Cells[1].IsolationSegment != "default" {
+		t.Errorf("Expected cell-b on the default segment, got %+v", redacted.Cells[1])
+	}
+
+	// The cell, the app, and the segment list must agree on the segment pseudonym
+	app := redacted.Apps[0]
+	if cell.IsolationSegment != "segment-1" || app.IsolationSegment != "segment-1" || redacted.Segments[0].Name != "segment-1" {
+		t.Errorf("Expected segment-1 throughout, got cell %q, app %q, segment %q",
+			cell.IsolationSegment, app.IsolationSegment, redacted.Segments[0].Name)
+	}
+	if app.Name != "app-1" || app.GUID != "" || redacted.Segments[0].GUID != "" {
+		t.Errorf("Expected app-1 with GUIDs dropped, got %+v / %+v", app, redacted.Segments[0])
+	}
+	if d.Cells[0].Name != "diego_cell/0" || d.Apps[0].GUID != "guid-1" {
+		t.Error("Expected the original dashboard to be unchanged")
+	}
+}
+
+func TestRedactor_ScenarioComparison(t *testing.T) {
+	state := InfrastructureState{
+		Name:     "prod",
+		Clusters: []ClusterState{{Name: "c1"}, {Name: "c1-pci"}, {Name: "c2"}},
+	}
+	c := ScenarioComparison{
+		Proposed: ScenarioResult{CellCount: 30},
+		Warnings: []ScenarioWarning{{
+			Code:    WarningHAAdmissionMismatch,
+			Message: "HA differs for c1 (25%), c1-pci (50%) in production; c10 is unaffected",
+			Fixes:   []FixSuggestion{{Description: "Match c1-pci's reservation"}},
+		}},
+		Recommendations:   []Recommendation{{Title: "Rebalance", TargetDistribution: []ClusterDistribution{{ClusterName: "c2"}}}},
+		Baseline:          &BaselineComparison{Baseline: ScenarioBaseline{Name: "q3", TargetCluster: "c1-pci"}},
+		TargetCluster:     "c1-pci",
+		UnchangedClusters: []ClusterSummary{{Name: "c1"}, {Name: "c2"}},
+	}
+
+	redacted := NewRedactor().ScenarioComparison(c, state)
+
+	if redacted.TargetCluster != "cluster-2" || redacted.Baseline.Baseline.TargetCluster != "cluster-2" {
+		t.Errorf("Expected cluster-2 as the target, got %q / %q", redacted.TargetCluster, redacted.Baseline.Baseline.TargetCluster)
+	}
+	if redacted.UnchangedClusters[0].Name != "cluster-1" || redacted.UnchangedClusters[1].Name != "cluster-3" {
+		t.Errorf("Expected cluster-1 and cluster-3 unchanged, got %+v", redacted.UnchangedClusters)
+	}
+	want := "HA differs for cluster-1 (25%), cluster-2 (50%) in production; c10 is unaffected"
+	if redacted.Warnings[0].Message != want {
+		t.Errorf("Expected %q, got %q", want, redacted.Warnings[0].Message)
+	}
+	if got := redacted.Warnings[0].Fixes[0].Description; got != "Match cluster-2's reservation" {
+		t.Errorf("Expected the fix to name cluster-2, got %q", got)
+	}
+	if got := redacted.Recommendations[0].TargetDistribution[0].ClusterName; got != "cluster-3" {
+		t.Errorf("Expected cluster-3 in the distribution, got %q", got)
+	}
+	if redacted.Proposed.CellCount != 30 {
+		t.Errorf("Expected numbers to be kept, got %d cells", redacted.Proposed.CellCount)
+	}
+	if c.TargetCluster != "c1-pci" || c.UnchangedClusters[0].Name != "c1" || c.Baseline.Baseline.TargetCluster != "c1-pci" ||
+		c.Warnings[0].Fixes[0].Description != "Match c1-pci's reservation" {
+		t.Error("Expected the original comparison to be unchanged")
+	}
+}
@@ -3,7 +3,12 @@
 
 package models
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+
+	"github.com/markalston/diego-capacity-analyzer/backend/units"
+)
 
 // ScenarioInput represents proposed changes for what-if analysis
 type ScenarioInput struct {
@@ -16,6 +21,8 @@ type ScenarioInput struct {
 	OverheadPct          float64  `json:"overhead_pct"`       // Memory overhead % (default 7)
 	AdditionalApp        *AppSpec `json:"additional_app"`     // Optional app to add
 	TPSCurve             []TPSPt  `json:"tps_curve"`          // Custom TPS curve (only used if EnableTPS is true)
+	// OverheadModel replaces OverheadPct with a fixed + per-instance + percentage model. Nil = use OverheadPct.
+	OverheadModel *OverheadModel `json:"overhead_model,omitempty"`
 	// Host configuration for constraint analysis
 	HostCount       int `json:"host_count"`
 	MemoryPerHostGB int `json:"memory_per_host_gb"`
@@ -30,6 +37,17 @@ type ScenarioInput struct {
 	// ChunkSizeMB is an optional override for staging chunk size.
 	// If 0, uses MaxInstanceMemoryMB from state (min 1GB); if that's 0, defaults to 4096 MB.
 	ChunkSizeMB int `json:"chunk_size_mb"`
+	// HostsToRemove takes that many average-sized hosts out of the proposal (e.g. for maintenance).
+	// 0 means no hosts are removed.
+	HostsToRemove int `json:"hosts_to_remove"`
+	// MaxFaultImpact is the most app instances a single cell failure may take down.
+	// When the current cells exceed it, a resize recommendation is added. 0 means no target.
+	MaxFaultImpact int `json:"max_fault_impact"`
+	// Baseline names a saved baseline to also compare the proposed result against. Empty = none.
+	Baseline string `json:"baseline,omitempty"`
+	// BaselineInput replaces the current state as the "current" side of the comparison with
+	// this scenario's proposed result, so two hypothetical options can be compared. Nil = current state.
+	BaselineInput *ScenarioInput `json:"baseline_input,omitempty"`
 }
 
 // EnableTPS returns true if TPS analysis should be performed.
@@ -38,6 +56,23 @@ func (s *ScenarioInput) EnableTPS() bool {
 	return len(s.TPSCurve) > 0
 }
 
+// OverheadModel describes the memory inside each Diego cell that is not
+// available to app containers: a fixed amount for the rep, Garden, and OS,
+// a per-container amount, and a percentage of cell memory.
+// Pct is taken as given; it does not fall back to the 7% default.
+type OverheadModel struct {
+	FixedGB       float64 `json:"fixed_gb"`
+	PerInstanceMB float64 `json:"per_instance_mb"`
+	Pct           float64 `json:"pct"`
+}
+
+// CellOverheadGB returns the memory overhead of one cell of cellMemoryGB
+// running instancesPerCell app instances, truncated to whole GB
+func (m OverheadModel) CellOverheadGB(cellMemoryGB int, instancesPerCell float64) int {
+	overheadGB := m.FixedGB + float64(cellMemoryGB)*(m.Pct/100) + m.PerInstanceMB*instancesPerCell/units.KiB
+	return int(overheadGB)
+}
+
 // AppSpec represents a hypothetical app for capacity planning
 type AppSpec struct {
 	Name      string `json:"name"`
@@ -63,13 +98,16 @@ type ScenarioResult struct {
 	UtilizationPct     float64 `json:"utilization_pct"`
 	DiskUtilizationPct float64 `json:"disk_utilization_pct"`
 	FreeChunks         int     `json:"free_chunks"`
-	ChunkSizeMB        int     `json:"chunk_size_mb"` // Chunk size used in calculation (for UI transparency)
+	ChunkSizeMB        int     `json:"chunk_size_mb"`       // Chunk size used in calculation (for UI transparency)
+	MaxSingleChunkGB   int     `json:"max_single_chunk_gb"` // Largest app instance that still fits on an average cell
 	N1UtilizationPct   float64 `json:"n1_utilization_pct"`
 	FaultImpact        int     `json:"fault_impact"`
 	InstancesPerCell   float64 `json:"instances_per_cell"`
 	EstimatedTPS       int     `json:"estimated_tps"`
 	TPSStatus          string  `json:"tps_status"`       // "optimal", "degraded", "critical"
-	BlastRadiusPct     float64 `json:"blast_radius_pct"` // % of capacity lost per single cell failure
+	BlastRadiusPct     float64 `json:"blast_radius_pct"` // % of app instances lost per single host failure (per cell when host count unknown)
+	// MaxCellsPerHost is the cell count on the busiest host, which sets BlastRadiusPct. 0 = host count unknown.
+	MaxCellsPerHost int `json:"max_cells_per_host"`
 	// CPU ratio metrics (only populated when CPU analysis enabled, i.e., PhysicalCoresPerHost > 0)
 	TotalVCPUs       int     `json:"total_vcpus"`        // cellCount * cellCPU
 	TotalPCPUs       int     `json:"total_pcpus"`        // hostCount * physicalCoresPerHost
@@ -77,6 +115,13 @@ type ScenarioResult struct {
 	CPURiskLevel     string  `json:"cpu_risk_level"`     // "conservative" (<=4:1), "moderate" (4-8:1), "aggressive" (>8:1)
 	MaxCellsByCPU    int     `json:"max_cells_by_cpu"`   // Max cells deployable before hitting target vCPU:pCPU ratio
 	CPUHeadroomCells int     `json:"cpu_headroom_cells"` // Additional cells that can be added within target ratio
+	// TargetVCPURatio is the vCPU:pCPU ratio MaxCellsByCPU is sized for: the input's target, or the default 4:1
+	TargetVCPURatio float64 `json:"target_vcpu_ratio"`
+	// ExceedsCPUCapacity is set when CellCount is above MaxCellsByCPU, counting platform VM vCPUs
+	ExceedsCPUCapacity bool `json:"exceeds_cpu_capacity"`
+	// Host removal metrics (only populated when HostsToRemove > 0)
+	HostsRemoved    int `json:"hosts_removed"`     // Hosts taken out of the proposal
+	CellsToEvacuate int `json:"cells_to_evacuate"` // Cells running on the removed hosts that must be moved
 }
 
 // CellSize returns formatted cell size string like "4×32"
@@ -103,11 +148,69 @@ type FixSuggestion struct {
 // ScenarioWarning represents a tradeoff warning with optional context
 type ScenarioWarning struct {
 	Severity string          `json:"severity"`         // "info", "warning", "critical"
+	Code     string          `json:"code"`             // Stable identifier such as WarningN1Exceeded
 	Message  string          `json:"message"`          // Warning message
 	Change   *ConfigChange   `json:"change,omitempty"` // What caused this warning
 	Fixes    []FixSuggestion `json:"fixes,omitempty"`  // How to fix (max 2)
 }
 
+// Warning codes identify what a ScenarioWarning is about, so clients can
+// react to it without matching Message text. A code covers both the warning
+// and critical severity of the same check.
+const (
+	WarningN1Exceeded             = "n1_exceeded"
+	WarningN1Approaching          = "n1_approaching"
+	WarningHAAdmissionExceeded    = "ha_admission_exceeded"
+	WarningHAAdmissionApproaching = "ha_admission_approaching"
+	WarningHostRemovalOverflow    = "host_removal_overflow"
+	WarningLowFreeChunks          = "low_free_chunks"
+	WarningSmallSingleChunk       = "small_single_chunk"
+	WarningCellUtilization        = "cell_utilization_high"
+	WarningDiskUtilization        = "disk_utilization_high"
+	WarningTPSDegraded            = "tps_degraded"
+	WarningHostFailureImpact      = "host_failure_impact"
+	WarningCellFailureImpact      = "cell_failure_impact"
+	WarningVCPURatioExceeded      = "vcpu_ratio_exceeded"
+	WarningCPUCapacityExceeded    = "cpu_capacity_exceeded"
+	WarningVCPURatioAggressive    = "vcpu_ratio_aggressive"
+	WarningHAInsufficientForN1    = "ha_insufficient_for_n1"
+	WarningHAAdmissionMismatch    = "ha_admission_mismatch"
+	WarningMoreWarnings           = "more_warnings" // Summary standing in for warnings past the cap
+)
+
+// WarningThresholds sets the limits used when generating capacity warnings.
+// Zero values fall back to the defaults used by scenario comparison.
+type WarningThresholds struct {
+	N1WarningPct           float64 `json:"n1_warning_pct"`            // Warn when N-1 utilization exceeds this (default 75)
+	N1CriticalPct          float64 `json:"n1_critical_pct"`           // Critical when N-1 utilization exceeds this (default 85)
+	FreeChunksWarning      int     `json:"free_chunks_warning"`       // Warn when free chunks fall below this (default 20)
+	FreeChunksCritical     int     `json:"free_chunks_critical"`      // Critical when free chunks fall below this (default 10)
+	UtilizationWarningPct  float64 `json:"utilization_warning_pct"`   // Warn when cell utilization exceeds this (default 80)
+	UtilizationCriticalPct float64 `json:"utilization_critical_pct"`  // Critical when cell utilization exceeds this (default 90)
+	DiskWarningPct         float64 `json:"disk_warning_pct"`          // Warn when disk utilization exceeds this (default 80)
+	DiskCriticalPct        float64 `json:"disk_critical_pct"`         // Critical when disk utilization exceeds this (default 90)
+	BlastRadiusWarningPct  float64 `json:"blast_radius_warning_pct"`  // Warn when blast radius exceeds this (default 10)
+	BlastRadiusCriticalPct float64 `json:"blast_radius_critical_pct"` // Critical when blast radius exceeds this (default 20)
+	HostFailureWarningPct  float64 `json:"host_failure_warning_pct"`  // Warn when one host's failure loses more than this % of app instances (default 30)
+	HostFailureCriticalPct float64 `json:"host_failure_critical_pct"` // Critical when one host's failure loses more than this % of app instances (default 40)
+	MinSingleChunkGB       int     `json:"min_single_chunk_gb"`       // Warn when the largest stageable app falls below this (default 4)
+}
+
+// CheckRequest is the request body for a stateless threshold check
+type CheckRequest struct {
+	Input      ManualInput       `json:"input"`
+	Thresholds WarningThresholds `json:"thresholds"`
+}
+
+// CheckResponse reports threshold warnings for the submitted infrastructure
+type CheckResponse struct {
+	Status         string              `json:"status"` // "passed", "warning", or "critical"
+	Current        ScenarioResult      `json:"current"`
+	Thresholds     WarningThresholds   `json:"thresholds"` // Effective thresholds after defaults
+	Warnings       []ScenarioWarning   `json:"warnings"`
+	Infrastructure InfrastructureState `json:"infrastructure"` // State computed from the input, not stored
+}
+
 // ScenarioDelta represents changes between current and proposed
 type ScenarioDelta struct {
 	CapacityChangeGB         int     `json:"capacity_change_gb"`
@@ -118,6 +221,14 @@ type ScenarioDelta struct {
 	VCPURatioChange          float64 `json:"vcpu_ratio_change"` // Proposed ratio - current ratio
 }
 
+// ScenarioSweep holds scenario results for a range of proposed cell counts
+type ScenarioSweep struct {
+	MinCells int              `json:"min_cells"`
+	MaxCells int              `json:"max_cells"`
+	Step     int              `json:"step"`
+	Results  []ScenarioResult `json:"results"`
+}
+
 // ScenarioComparison represents full comparison response
 type ScenarioComparison struct {
 	Current         ScenarioResult      `json:"current"`
@@ -126,6 +237,53 @@ type ScenarioComparison struct {
 	Delta           ScenarioDelta       `json:"delta"`
 	Recommendations []Recommendation    `json:"recommendations,omitempty"`
 	Constraints     *ConstraintAnalysis `json:"constraints,omitempty"`
+	Baseline        *BaselineComparison `json:"baseline,omitempty"` // Set when the input names a saved baseline
+	// TargetCluster is the cluster the comparison is scoped to, when the input names one.
+	// UnchangedClusters are the other clusters, which the scenario leaves as they are.
+	TargetCluster     string           `json:"target_cluster,omitempty"`
+	UnchangedClusters []ClusterSummary `json:"unchanged_clusters,omitempty"`
+	// Set when the comparison is requested with ?explain=true
+	CalculationTrace *CalculationTrace `json:"calculation_trace,omitempty"`
+}
+
+// CalculationStep is one intermediate value of a scenario calculation
+type CalculationStep struct {
+	Key        string  `json:"key"`        // Name of the value, e.g. "app_capacity_gb"
+	Formula    string  `json:"formula"`    // How it is computed from earlier steps and inputs
+	Expression string  `json:"expression"` // The formula with this calculation's numbers
+	Value      float64 `json:"value"`
+}
+
+// CalculationTrace lists, in calculation order, the intermediate values
+// behind the current and proposed results of a comparison
+type CalculationTrace struct {
+	Current  []CalculationStep `json:"current"`
+	Proposed []CalculationStep `json:"proposed"`
+}
+
+// ScenarioBaseline is a named scenario result saved for later comparison
+type ScenarioBaseline struct {
+	Name    string    `json:"name"`
+	SavedAt time.Time `json:"saved_at"`
+	SavedBy string    `json:"saved_by,omitempty"` // Username of the saver, when authenticated
+	// TargetCluster is the cluster the result was scoped to. Empty = the whole foundation.
+	TargetCluster string         `json:"target_cluster,omitempty"`
+	Result        ScenarioResult `json:"result"`
+}
+
+// BaselineComparison compares a proposed result against a saved baseline
+type BaselineComparison struct {
+	Baseline ScenarioBaseline `json:"baseline"`
+	Proposed ScenarioResult   `json:"proposed"`
+	Delta    ScenarioDelta    `json:"delta"` // Proposed relative to the baseline
+}
+
+// SaveBaselineRequest is the request body for saving a baseline. Without
+// input the current infrastructure result is saved; with input, the proposed
+// result of that scenario is saved.
+type SaveBaselineRequest struct {
+	Name  string         `json:"name"`
+	Input *ScenarioInput `json:"input,omitempty"`
 }
 
 // CapacityConstraint represents a single constraint calculation (HA% or N-X)
@@ -0,0 +1,140 @@
+// ABOUTME: JSON Schema for the ManualInput and InfrastructureState file formats
+// ABOUTME: Generated from the Go structs by reflection, with the constraints ManualInput.Validate enforces
+
+package models
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// JSONSchemaDialect is the JSON Schema draft the published schemas use
+const JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchema is the subset of JSON Schema needed to describe the file formats
+type JSONSchema struct {
+	Schema      string                 `json:"$schema,omitempty"`
+	Title       string                 `json:"title,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Type        string                 `json:"type,omitempty"`
+	Format      string                 `json:"format,omitempty"`
+	Enum        []string               `json:"enum,omitempty"`
+	Properties  map[string]*JSONSchema `json:"properties,omitempty"`
+	Required    []string               `json:"required,omitempty"`
+	Items       *JSONSchema            `json:"items,omitempty"`
+	MinItems    *int                   `json:"minItems,omitempty"`
+	Minimum     *float64               `json:"minimum,omitempty"`
+	Maximum     *float64               `json:"maximum,omitempty"`
+}
+
+// ManualInputSchema returns the schema for files accepted by
+// POST /api/v1/infrastructure/manual. Required fields and bounds match
+// ManualInput.Validate; unknown properties such as "description" are allowed.
+func ManualInputSchema() *JSONSchema {
+	s := schemaForType(reflect.TypeOf(ManualInput{}))
+	s.Schema = JSONSchemaDialect
+	s.Title = "ManualInput"
+	s.Description = "User-provided infrastructure for what-if capacity analysis"
+	s.Required = []string{"clusters"}
+	s.Properties["format"].Enum = []string{FormatManualInput}
+	setMinimums(s, 0)
+
+	clusters := s.Properties["clusters"]
+	clusters.MinItems = intPtr(1)
+
+	cluster := clusters.Items
+	cluster.Required = []string{"host_count", "memory_gb_per_host", "diego_cell_memory_gb"}
+	setMinimums(cluster, 0)
+	for _, name := range cluster.Required {
+		cluster.Properties[name].Minimum = floatPtr(1)
+	}
+	cluster.Properties["ha_admission_control_percentage"].Maximum = floatPtr(100)
+
+	return s
+}
+
+// InfrastructureStateSchema returns the schema for computed state accepted by
+// POST /api/v1/infrastructure/state and returned by the infrastructure
+// endpoints. Clusters require memory_gb, which ManualInput clusters never
+// carry, so files without a "format" field can still be told apart.
+func InfrastructureStateSchema() *JSONSchema {
+	s := schemaForType(reflect.TypeOf(InfrastructureState{}))
+	s.Schema = JSONSchemaDialect
+	s.Title = "InfrastructureState"
+	s.Description = "Computed infrastructure state from vSphere discovery or manual input"
+	s.Required = []string{"clusters"}
+	s.Properties["format"].Enum = []string{FormatInfrastructureState}
+	s.Properties["clusters"].Items.Required = []string{"memory_gb"}
+	return s
+}
+
+// schemaForType describes t by the JSON encoding/json produces for it
+func schemaForType(t reflect.Type) *JSONSchema {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(time.Time{}) {
+		return &JSONSchema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &JSONSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &JSONSchema{Type: "number"}
+	case reflect.String:
+		return &JSONSchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &JSONSchema{Type: "array", Items: schemaForType(t.Elem())}
+	case reflect.Map:
+		return &JSONSchema{Type: "object"}
+	case reflect.Struct:
+		s := &JSONSchema{Type: "object", Properties: map[string]*JSONSchema{}}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, ok := jsonFieldName(field)
+			if !ok {
+				continue
+			}
+			s.Properties[name] = schemaForType(field.Type)
+		}
+		return s
+	default:
+		// Interfaces and anything else accept any JSON value
+		return &JSONSchema{}
+	}
+}
+
+// jsonFieldName returns the name encoding/json uses for field, or false if
+// the field is not encoded
+func jsonFieldName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+	return name, true
+}
+
+// setMinimums bounds every numeric property of s below by minimum
+func setMinimums(s *JSONSchema, minimum float64) {
+	for _, prop := range s.Properties {
+		if prop.Type == "integer" || prop.Type == "number" {
+			prop.Minimum = floatPtr(minimum)
+		}
+	}
+}
+
+func intPtr(v int) *int { return &v }
+
+func floatPtr(v float64) *float64 { return &v }
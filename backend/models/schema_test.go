@@ -0,0 +1,140 @@
+// ABOUTME: Tests for the generated ManualInput and InfrastructureState JSON Schemas
+// ABOUTME: Validates property coverage of the Go structs, Validate-matching constraints, and the format discriminator
+
+package models
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+// assertCoversFields checks that schema has one property per JSON field of t
+func assertCoversFields(t *testing.T, schema *JSONSchema, typ reflect.Type) {
+	t.Helper()
+	var fields int
+	for i := 0; i < typ.NumField(); i++ {
+		name, ok := jsonFieldName(typ.Field(i))
+		if !ok {
+			continue
+		}
+		fields++
+		if _, ok := schema.Properties[name]; !ok {
+			t.Errorf("%s schema is missing property %q", typ.Name(), name)
+		}
+	}
+	if len(schema.Properties) != fields {
+		t.Errorf("%s schema has %d properties, struct has %d JSON fields", typ.Name(), len(schema.Properties), fields)
+	}
+}
+
+func TestManualInputSchema_MatchesStructs(t *testing.T) {
+	s := ManualInputSchema()
+
+	if s.Schema != JSONSchemaDialect || s.Type != "object" {
+		t.Errorf("Unexpected root: $schema=%q type=%q", s.Schema, s.Type)
+	}
+	assertCoversFields(t, s, reflect.TypeOf(ManualInput{}))
+
+	clusters := s.Properties["clusters"]
+	if clusters.Type != "array" || clusters.Items == nil {
+		t.Fatalf("clusters should be an array of objects, got %+v", clusters)
+	}
+	assertCoversFields(t, clusters.Items, reflect.TypeOf(ClusterInput{}))
+
+	if got := clusters.Items.Properties["name"].Type; got != "string" {
+		t.Errorf("clusters[].name type = %q, want string", got)
+	}
+	if got := clusters.Items.Properties["host_count"].Type; got != "integer" {
+		t.Errorf("clusters[].host_count type = %q, want integer", got)
+	}
+}
+
+func TestManualInputSchema_MatchesValidate(t *testing.T) {
+	s := ManualInputSchema()
+
+	if !slices.Equal(s.Required, []string{"clusters"}) {
+		t.Errorf("Required = %v, want [clusters]", s.Required)
+	}
+	if m := s.Properties["clusters"].MinItems; m == nil || *m != 1 {
+		t.Error("Expected clusters minItems 1")
+	}
+	if m := s.Properties["total_app_instances"].Minimum; m == nil || *m != 0 {
+		t.Error("Expected total_app_instances minimum 0")
+	}
+
+	cluster := s.Properties["clusters"].Items
+	for _, name := range []string{"host_count", "memory_gb_per_host", "diego_cell_memory_gb"} {
+		if !slices.Contains(cluster.Required, name) {
+			t.Errorf("Expected clusters[].%s to be required", name)
+		}
+		if m := cluster.Properties[name].Minimum; m == nil || *m != 1 {
+			t.Errorf("Expected clusters[].%s minimum 1", name)
+		}
+	}
+	if m := cluster.Properties["diego_cell_count"].Minimum; m == nil || *m != 0 {
+		t.Error("Expected clusters[].diego_cell_count minimum 0")
+	}
+	if m := cluster.Properties["ha_admission_control_percentage"].Maximum; m == nil || *m != 100 {
+		t.Error("Expected clusters[].ha_admission_control_percentage maximum 100")
+	}
+}
+
+func TestInfrastructureStateSchema_MatchesStructs(t *testing.T) {
+	s := InfrastructureStateSchema()
+
+	assertCoversFields(t, s, reflect.TypeOf(InfrastructureState{}))
+	assertCoversFields(t, s.Properties["clusters"].Items, reflect.TypeOf(ClusterState{}))
+
+	if ts := s.Properties["timestamp"]; ts.Type != "string" || ts.Format != "date-time" {
+		t.Errorf("timestamp = %+v, want string date-time", ts)
+	}
+	if got := s.Properties["vcpu_ratio"].Type; got != "number" {
+		t.Errorf("vcpu_ratio type = %q, want number", got)
+	}
+}
+
+func TestSchemas_FormatEnum(t *testing.T) {
+	if got := ManualInputSchema().Properties["format"].Enum; !slices.Equal(got, []string{FormatManualInput}) {
+		t.Errorf("ManualInput format enum = %v, want [%s]", got, FormatManualInput)
+	}
+	if got := InfrastructureStateSchema().Properties["format"].Enum; !slices.Equal(got, []string{FormatInfrastructureState}) {
+		t.Errorf("InfrastructureState format enum = %v, want [%s]", got, FormatInfrastructureState)
+	}
+}
+
+func TestSchemas_DiscriminateOnClusterMemoryGB(t *testing.T) {
+	state := InfrastructureStateSchema().Properties["clusters"].Items
+	manual := ManualInputSchema().Properties["clusters"].Items
+
+	if !slices.Contains(state.Required, "memory_gb") {
+		t.Error("Expected InfrastructureState clusters to require memory_gb")
+	}
+	if _, ok := manual.Properties["memory_gb"]; ok {
+		t.Error("ManualInput clusters must not define memory_gb, or the formats cannot be told apart")
+	}
+}
+
+func TestSchemaForType_FollowsJSONTags(t *testing.T) {
+	type sample struct {
+		Named    string `json:"named,omitempty"`
+		Skipped  string `json:"-"`
+		Untagged bool
+		When     *time.Time        `json:"when"`
+		Labels   map[string]string `json:"labels"`
+		hidden   int
+	}
+
+	s := schemaForType(reflect.TypeOf(sample{}))
+
+	want := map[string]string{"named": "string", "Untagged": "boolean", "when": "string", "labels": "object"}
+	if len(s.Properties) != len(want) {
+		t.Errorf("Properties = %v, want %v", s.Properties, want)
+	}
+	for name, typ := range want {
+		if p, ok := s.Properties[name]; !ok || p.Type != typ {
+			t.Errorf("Property %q = %+v, want type %s", name, p, typ)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+// ABOUTME: Capacity thresholds shared by scenario warnings, threshold checks, and CPU risk
+// ABOUTME: Defines the named defaults and the Thresholds set exposed at GET /api/v1/thresholds
+
+package models
+
+import "fmt"
+
+// Default capacity thresholds. Scenario warnings, POST /api/v1/check, CPU
+// risk classification, and GET /api/v1/thresholds all start from these; the
+// THRESHOLDS setting can override them per deployment.
+const (
+	DefaultN1WarningPct           = 75.0 // N-1 utilization above this warns
+	DefaultN1CriticalPct          = 85.0 // N-1 utilization above this is critical
+	DefaultFreeChunksWarning      = 20   // Fewer free 4GB chunks than this warns
+	DefaultFreeChunksCritical     = 10   // Fewer free 4GB chunks than this is critical
+	DefaultUtilizationWarningPct  = 80.0 // Cell memory utilization above this warns
+	DefaultUtilizationCriticalPct = 90.0 // Cell memory utilization above this is critical
+	DefaultDiskWarningPct         = 80.0 // Cell disk utilization above this warns
+	DefaultDiskCriticalPct        = 90.0 // Cell disk utilization above this is critical
+	DefaultBlastRadiusWarningPct  = 10.0 // One cell holding more than this % of capacity warns
+	DefaultBlastRadiusCriticalPct = 20.0 // One cell holding more than this % of capacity is critical
+	DefaultHostFailureWarningPct  = 30.0 // One host failure losing more than this % of instances warns
+	DefaultHostFailureCriticalPct = 40.0 // One host failure losing more than this % of instances is critical
+	DefaultMinSingleChunkGB       = 4    // Largest stageable app below this many GB warns
+
+	// vCPU:pCPU ratios above which CPU risk becomes moderate and aggressive
+	// (reported as "medium" and "high" on infrastructure state)
+	DefaultVCPURatioModerate   = 4.0
+	DefaultVCPURatioAggressive = 8.0
+
+	// Safety ceilings for headroom-ranked bottleneck analysis: the utilization
+	// operators should not run each resource past. CPU is measured against the
+	// moderate vCPU ratio, so 100% is overcommit exactly at that ratio.
+	DefaultMemoryCeilingPct    = 90.0
+	DefaultCPUCeilingPct       = 100.0
+	DefaultDiskCeilingPct      = 90.0
+	DefaultDatastoreCeilingPct = 85.0
+)
+
+// Thresholds is every capacity boundary the backend applies: the scenario
+// warning limits, the vCPU:pCPU ratios that set CPU risk, and the safety
+// ceilings bottleneck analysis can rank against. Zero values fall back to
+// the defaults.
+type Thresholds struct {
+	WarningThresholds
+	VCPURatioModerate   float64 `json:"vcpu_ratio_moderate"`   // CPU risk is moderate above this ratio (default 4)
+	VCPURatioAggressive float64 `json:"vcpu_ratio_aggressive"` // CPU risk is aggressive above this ratio (default 8)
+
+	// Safety ceilings ranked against by GET /api/v1/bottleneck?rank=headroom
+	MemoryCeilingPct    float64 `json:"memory_ceiling_pct"`    // Default 90
+	CPUCeilingPct       float64 `json:"cpu_ceiling_pct"`       // Default 100 (the moderate vCPU ratio)
+	DiskCeilingPct      float64 `json:"disk_ceiling_pct"`      // Default 90
+	DatastoreCeilingPct float64 `json:"datastore_ceiling_pct"` // Default 85
+}
+
+// DefaultThresholds returns the built-in thresholds
+func DefaultThresholds() Thresholds {
+	return Thresholds{
+		WarningThresholds: WarningThresholds{
+			N1WarningPct:           DefaultN1WarningPct,
+			N1CriticalPct:          DefaultN1CriticalPct,
+			FreeChunksWarning:      DefaultFreeChunksWarning,
+			FreeChunksCritical:     DefaultFreeChunksCritical,
+			UtilizationWarningPct:  DefaultUtilizationWarningPct,
+			UtilizationCriticalPct: DefaultUtilizationCriticalPct,
+			DiskWarningPct:         DefaultDiskWarningPct,
+			DiskCriticalPct:        DefaultDiskCriticalPct,
+			BlastRadiusWarningPct:  DefaultBlastRadiusWarningPct,
+			BlastRadiusCriticalPct: DefaultBlastRadiusCriticalPct,
+			HostFailureWarningPct:  DefaultHostFailureWarningPct,
+			HostFailureCriticalPct: DefaultHostFailureCriticalPct,
+			MinSingleChunkGB:       DefaultMinSingleChunkGB,
+		},
+		VCPURatioModerate:   DefaultVCPURatioModerate,
+		VCPURatioAggressive: DefaultVCPURatioAggressive,
+		MemoryCeilingPct:    DefaultMemoryCeilingPct,
+		CPUCeilingPct:       DefaultCPUCeilingPct,
+		DiskCeilingPct:      DefaultDiskCeilingPct,
+		DatastoreCeilingPct: DefaultDatastoreCeilingPct,
+	}
+}
+
+// ResolveFrom fills zero-valued thresholds from d, which must itself be
+// fully resolved. When only a critical limit is set, the warning limit keeps
+// d's spacing: N-1 warns the same number of points below critical and free
+// chunks warn at the same multiple of the critical count.
+func (t WarningThresholds) ResolveFrom(d WarningThresholds) WarningThresholds {
+	if t.N1WarningPct == 0 {
+		if t.N1CriticalPct > 0 {
+			t.N1WarningPct = max(t.N1CriticalPct-(d.N1CriticalPct-d.N1WarningPct), 0)
+		} else {
+			t.N1WarningPct = d.N1WarningPct
+		}
+	}
+	if t.N1CriticalPct == 0 {
+		t.N1CriticalPct = d.N1CriticalPct
+	}
+	if t.FreeChunksWarning == 0 {
+		if t.FreeChunksCritical > 0 {
+			t.FreeChunksWarning = t.FreeChunksCritical * d.FreeChunksWarning / d.FreeChunksCritical
+		} else {
+			t.FreeChunksWarning = d.FreeChunksWarning
+		}
+	}
+	if t.FreeChunksCritical == 0 {
+		t.FreeChunksCritical = d.FreeChunksCritical
+	}
+	if t.UtilizationWarningPct == 0 {
+		t.UtilizationWarningPct = d.UtilizationWarningPct
+	}
+	if t.UtilizationCriticalPct == 0 {
+		t.UtilizationCriticalPct = d.UtilizationCriticalPct
+	}
+	if t.DiskWarningPct == 0 {
+		t.DiskWarningPct = d.DiskWarningPct
+	}
+	if t.DiskCriticalPct == 0 {
+		t.DiskCriticalPct = d.DiskCriticalPct
+	}
+	if t.BlastRadiusWarningPct == 0 {
+		t.BlastRadiusWarningPct = d.BlastRadiusWarningPct
+	}
+	if t.BlastRadiusCriticalPct == 0 {
+		t.BlastRadiusCriticalPct = d.BlastRadiusCriticalPct
+	}
+	if t.HostFailureWarningPct == 0 {
+		t.HostFailureWarningPct = d.HostFailureWarningPct
+	}
+	if t.HostFailureCriticalPct == 0 {
+		t.HostFailureCriticalPct = d.HostFailureCriticalPct
+	}
+	if t.MinSingleChunkGB == 0 {
+		t.MinSingleChunkGB = d.MinSingleChunkGB
+	}
+
+	return t
+}
+
+// Resolve fills zero-valued thresholds, including the vCPU ratio tiers and
+// safety ceilings, from the defaults
+func (t Thresholds) Resolve() Thresholds {
+	d := DefaultThresholds()
+	t.WarningThresholds = t.WarningThresholds.ResolveFrom(d.WarningThresholds)
+	if t.VCPURatioModerate == 0 {
+		t.VCPURatioModerate = d.VCPURatioModerate
+	}
+	if t.VCPURatioAggressive == 0 {
+		t.VCPURatioAggressive = d.VCPURatioAggressive
+	}
+	if t.MemoryCeilingPct == 0 {
+		t.MemoryCeilingPct = d.MemoryCeilingPct
+	}
+	if t.CPUCeilingPct == 0 {
+		t.CPUCeilingPct = d.CPUCeilingPct
+	}
+	if t.DiskCeilingPct == 0 {
+		t.DiskCeilingPct = d.DiskCeilingPct
+	}
+	if t.DatastoreCeilingPct == 0 {
+		t.DatastoreCeilingPct = d.DatastoreCeilingPct
+	}
+	return t
+}
+
+// CPURiskLevel classifies ratio as "low", "medium", or "high" for
+// infrastructure state. Zero tiers use the defaults.
+func (t Thresholds) CPURiskLevel(ratio float64) string {
+	moderate, aggressive := t.vcpuTiers()
+	switch {
+	case ratio <= moderate:
+		return "low"
+	case ratio <= aggressive:
+		return "medium"
+	default:
+		return "high"
+	}
+}
+
+// ScenarioCPURiskLevel classifies ratio as "conservative", "moderate", or
+// "aggressive" for scenario results. Zero tiers use the defaults.
+func (t Thresholds) ScenarioCPURiskLevel(ratio float64) string {
+	moderate, aggressive := t.vcpuTiers()
+	switch {
+	case ratio <= moderate:
+		return "conservative"
+	case ratio <= aggressive:
+		return "moderate"
+	default:
+		return "aggressive"
+	}
+}
+
+// CeilingPct returns the safety ceiling for a bottleneck resource by name
+// ("Memory", "CPU", "Disk", or "Datastore"). Zero ceilings use the defaults;
+// unknown resources get 100.
+func (t Thresholds) CeilingPct(resource string) float64 {
+	var ceiling, fallback float64
+	switch resource {
+	case "Memory":
+		ceiling, fallback = t.MemoryCeilingPct, DefaultMemoryCeilingPct
+	case "CPU":
+		ceiling, fallback = t.CPUCeilingPct, DefaultCPUCeilingPct
+	case "Disk":
+		ceiling, fallback = t.DiskCeilingPct, DefaultDiskCeilingPct
+	case "Datastore":
+		ceiling, fallback = t.DatastoreCeilingPct, DefaultDatastoreCeilingPct
+	default:
+		return 100
+	}
+	if ceiling == 0 {
+		return fallback
+	}
+	return ceiling
+}
+
+func (t Thresholds) vcpuTiers() (moderate, aggressive float64) {
+	moderate, aggressive = t.VCPURatioModerate, t.VCPURatioAggressive
+	if moderate == 0 {
+		moderate = DefaultVCPURatioModerate
+	}
+	if aggressive == 0 {
+		aggressive = DefaultVCPURatioAggressive
+	}
+	return moderate, aggressive
+}
+
+// Validate checks that no threshold is negative and that each warning limit
+// comes before its critical limit. Call it on resolved thresholds.
+func (t Thresholds) Validate() error {
+	for _, v := range []struct {
+		name  string
+		value float64
+	}{
+		{"n1_warning_pct", t.N1WarningPct},
+		{"n1_critical_pct", t.N1CriticalPct},
+		{"free_chunks_warning", float64(t.FreeChunksWarning)},
+		{"free_chunks_critical", float64(t.FreeChunksCritical)},
+		{"utilization_warning_pct", t.UtilizationWarningPct},
+		{"utilization_critical_pct", t.UtilizationCriticalPct},
+		{"disk_warning_pct", t.DiskWarningPct},
+		{"disk_critical_pct", t.DiskCriticalPct},
+		{"blast_radius_warning_pct", t.BlastRadiusWarningPct},
+		{"blast_radius_critical_pct", t.BlastRadiusCriticalPct},
+		{"host_failure_warning_pct", t.HostFailureWarningPct},
+		{"host_failure_critical_pct", t.HostFailureCriticalPct},
+		{"min_single_chunk_gb", float64(t.MinSingleChunkGB)},
+		{"vcpu_ratio_moderate", t.VCPURatioModerate},
+		{"vcpu_ratio_aggressive", t.VCPURatioAggressive},
+		{"memory_ceiling_pct", t.MemoryCeilingPct},
+		{"cpu_ceiling_pct", t.CPUCeilingPct},
+		{"disk_ceiling_pct", t.DiskCeilingPct},
+		{"datastore_ceiling_pct", t.DatastoreCeilingPct},
+	} {
+		if v.value < 0 {
+			return fmt.Errorf("%s must not be negative, got %g", v.name, v.value)
+		}
+	}
+
+	// Percentages and ratios warn below critical; free chunks warn above it
+	for _, pair := range []struct {
+		warning, critical string
+		ok                bool
+	}{
+		{"n1_warning_pct", "n1_critical_pct", t.N1WarningPct <= t.N1CriticalPct},
+		{"utilization_warning_pct", "utilization_critical_pct", t.UtilizationWarningPct <= t.UtilizationCriticalPct},
+		{"disk_warning_pct", "disk_critical_pct", t.DiskWarningPct <= t.DiskCriticalPct},
+		{"blast_radius_warning_pct", "blast_radius_critical_pct", t.BlastRadiusWarningPct <= t.BlastRadiusCriticalPct},
+		{"host_failure_warning_pct", "host_failure_critical_pct", t.HostFailureWarningPct <= t.HostFailureCriticalPct},
+		{"vcpu_ratio_moderate", "vcpu_ratio_aggressive", t.VCPURatioModerate <= t.VCPURatioAggressive},
+	} {
+		if !pair.ok {
+			return fmt.Errorf("%s must not exceed %s", pair.warning, pair.critical)
+		}
+	}
+	if t.FreeChunksWarning < t.FreeChunksCritical {
+		return fmt.Errorf("free_chunks_warning must not be below free_chunks_critical")
+	}
+	return nil
+}
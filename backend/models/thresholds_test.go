@@ -0,0 +1,148 @@
+// ABOUTME: Tests for the shared capacity thresholds
+// ABOUTME: Covers default resolution, validation, and CPU risk and bottleneck tiers
+
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestThresholds_ResolveDefaults(t *testing.T) {
+	if got := (Thresholds{}).Resolve(); got != DefaultThresholds() {
+		t.Errorf("Expected zero thresholds to resolve to defaults, got %+v", got)
+	}
+
+	got := Thresholds{
+		WarningThresholds:   WarningThresholds{N1CriticalPct: 80, FreeChunksCritical: 200},
+		VCPURatioAggressive: 6,
+	}.Resolve()
+	if got.N1WarningPct != 70 {
+		t.Errorf("Expected N-1 warning derived as 70, got %.1f", got.N1WarningPct)
+	}
+	if got.FreeChunksWarning != 400 {
+		t.Errorf("Expected free chunks warning derived as 400, got %d", got.FreeChunksWarning)
+	}
+	if got.VCPURatioModerate != DefaultVCPURatioModerate || got.VCPURatioAggressive != 6 {
+		t.Errorf("Expected vCPU tiers %v/6, got %v/%v", DefaultVCPURatioModerate, got.VCPURatioModerate, got.VCPURatioAggressive)
+	}
+}
+
+func TestThresholds_CeilingPct(t *testing.T) {
+	d := DefaultThresholds()
+	for _, tt := range []struct {
+		resource string
+		want     float64
+	}{
+		{"Memory", DefaultMemoryCeilingPct},
+		{"CPU", DefaultCPUCeilingPct},
+		{"Disk", DefaultDiskCeilingPct},
+		{"Datastore", DefaultDatastoreCeilingPct},
+		{"GPU", 100},
+	} {
+		if got := d.CeilingPct(tt.resource); got != tt.want {
+			t.Errorf("CeilingPct(%q) = %g, want %g", tt.resource, got, tt.want)
+		}
+	}
+
+	if got := (Thresholds{DiskCeilingPct: 70}).CeilingPct("Disk"); got != 70 {
+		t.Errorf("Expected configured disk ceiling 70, got %g", got)
+	}
+	if got := (Thresholds{}).CeilingPct("Memory"); got != DefaultMemoryCeilingPct {
+		t.Errorf("Expected zero memory ceiling to use the default, got %g", got)
+	}
+}
+
+func TestThresholds_Validate(t *testing.T) {
+	if err := DefaultThresholds().Validate(); err != nil {
+		t.Fatalf("Expected defaults to be valid, got %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(*Thresholds)
+		wantErr string
+	}{
+		{"negative", func(t *Thresholds) { t.DiskCriticalPct = -1 }, "disk_critical_pct must not be negative"},
+		{"N-1 inverted", func(t *Thresholds) { t.N1WarningPct = 90 }, "n1_warning_pct must not exceed n1_critical_pct"},
+		{"vCPU tiers inverted", func(t *Thresholds) { t.VCPURatioModerate = 10 }, "vcpu_ratio_moderate must not exceed"},
+		{"free chunks inverted", func(t *Thresholds) { t.FreeChunksWarning = 5 }, "free_chunks_warning must not be below"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			th := DefaultThresholds()
+			tt.modify(&th)
+			err := th.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestThresholds_CPURiskLevel(t *testing.T) {
+	custom := Thresholds{VCPURatioModerate: 2, VCPURatioAggressive: 3}
+	tests := []struct {
+		ratio    float64
+		low      string
+		scenario string
+	}{
+		{1.5, "low", "conservative"},
+		{2.5, "medium", "moderate"},
+		{3.5, "high", "aggressive"},
+	}
+	for _, tt := range tests {
+		if got := custom.CPURiskLevel(tt.ratio); got != tt.low {
+			t.Errorf("CPURiskLevel(%.1f) = %s, want %s", tt.ratio, got, tt.low)
+		}
+		if got := custom.ScenarioCPURiskLevel(tt.ratio); got != tt.scenario {
+			t.Errorf("ScenarioCPURiskLevel(%.1f) = %s, want %s", tt.ratio, got, tt.scenario)
+		}
+	}
+
+	// Zero tiers fall back to the defaults
+	if got := (Thresholds{}).CPURiskLevel(5); got != "medium" {
+		t.Errorf("CPURiskLevel(5) with zero tiers = %s, want medium", got)
+	}
+}
+
+func TestThresholds_AnalyzeBottleneckTarget(t *testing.T) {
+	state := InfrastructureState{TotalCPUCores: 100, TotalVCPUs: 300}
+
+	if got := AnalyzeBottleneck(state).Resources[0].UsedPercent; got != 75 {
+		t.Errorf("Expected 75%% CPU at the default 4:1 target, got %.1f", got)
+	}
+
+	custom := Thresholds{VCPURatioModerate: 3, VCPURatioAggressive: 6}
+	analysis := custom.AnalyzeBottleneck(state)
+	if got := analysis.Resources[0].UsedPercent; got != 100 {
+		t.Errorf("Expected 100%% CPU at a 3:1 target, got %.1f", got)
+	}
+	if !strings.Contains(analysis.Summary, "3:1 target") {
+		t.Errorf("Expected summary to name the 3:1 target, got %q", analysis.Summary)
+	}
+}
+
+func TestManualInput_ToInfrastructureStateWithThresholds(t *testing.T) {
+	// 4 hosts x 32 cores = 128 pCPU; 100 cells x 3 vCPU = 300 vCPU, about 2.3:1
+	input := ManualInput{
+		Clusters: []ClusterInput{{
+			Name:              "c1",
+			HostCount:         4,
+			MemoryGBPerHost:   2048,
+			CPUThreadsPerHost: 32,
+			DiegoCellCount:    100,
+			DiegoCellMemoryGB: 32,
+			DiegoCellCPU:      3,
+		}},
+	}
+
+	if got := input.ToInfrastructureState().CPURiskLevel; got != "low" {
+		t.Errorf("Expected low risk at the default tiers, got %s", got)
+	}
+	got := input.ToInfrastructureStateWithThresholds(Thresholds{VCPURatioModerate: 2, VCPURatioAggressive: 4}).CPURiskLevel
+	if got != "medium" {
+		t.Errorf("Expected medium risk at 2:1/4:1 tiers, got %s", got)
+	}
+}
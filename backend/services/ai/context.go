@@ -139,6 +139,7 @@ type segmentSummary struct {
 	TotalMemoryMB int
 	AllocatedMB   int
 	UsedMB        int
+	NoVitals      int // Cells without BOSH vitals, left out of the memory totals
 }
 
 func writeDiegoCells(b *strings.Builder, dashboard *models.DashboardResponse) {
@@ -171,6 +172,10 @@ func writeDiegoCells(b *strings.Builder, dashboard *models.DashboardResponse) {
 			segOrder = append(segOrder, seg)
 		}
 		s.CellCount++
+		if cell.VitalsUnavailable {
+			s.NoVitals++
+			continue
+		}
 		s.TotalMemoryMB += cell.MemoryMB
 		s.AllocatedMB += cell.AllocatedMB
 		s.UsedMB += cell.UsedMB
@@ -187,10 +192,11 @@ func writeDiegoCells(b *strings.Builder, dashboard *models.DashboardResponse) {
 		return segOrder[i] < segOrder[j]
 	})
 
-	var totalCells, totalMemMB, totalAllocMB, totalUsedMB int
+	var totalCells, totalMemMB, totalAllocMB, totalUsedMB, totalNoVitals int
 	for _, name := range segOrder {
 		s := segments[name]
 		totalCells += s.CellCount
+		totalNoVitals += s.NoVitals
 		totalMemMB += s.TotalMemoryMB
 		totalAllocMB += s.AllocatedMB
 		totalUsedMB += s.UsedMB
@@ -206,6 +212,9 @@ func writeDiegoCells(b *strings.Builder, dashboard *models.DashboardResponse) {
 		if s.UsedMB > 0 {
 			fmt.Fprintf(b, ", %d MB used", s.UsedMB)
 		}
+		if s.NoVitals > 0 {
+			fmt.Fprintf(b, "; %d cells reporting no vitals, excluded", s.NoVitals)
+		}
 		b.WriteString("\n")
 	}
 
@@ -215,8 +224,12 @@ func writeDiegoCells(b *strings.Builder, dashboard *models.DashboardResponse) {
 	if totalMemMB > 0 {
 		overallUtil = float64(totalAllocMB) / float64(totalMemMB) * 100
 	}
-	fmt.Fprintf(b, "**Totals**: %d cells, %d MB memory, %.1f%% utilization%s\n\n",
+	fmt.Fprintf(b, "**Totals**: %d cells, %d MB memory, %.1f%% utilization%s\n",
 		totalCells, totalMemMB, overallUtil, utilizationFlag(overallUtil))
+	if totalNoVitals > 0 {
+		fmt.Fprintf(b, "%d cells reporting no vitals; utilization covers only cells with vitals.\n", totalNoVitals)
+	}
+	b.WriteString("\n")
 }
 
 func writeApps(b *strings.Builder, dashboard *models.DashboardResponse) {
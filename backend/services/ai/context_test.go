@@ -678,6 +678,29 @@ func TestBuildContext_SegmentAggregation(t *testing.T) {
 	}
 }
 
+func TestBuildContext_CellsWithoutVitals(t *testing.T) {
+	input := ContextInput{
+		Dashboard: &models.DashboardResponse{
+			Cells: []models.DiegoCell{
+				{ID: "c1", MemoryMB: 32768, AllocatedMB: 16384, UsedMB: 16384},
+				{ID: "c2", VitalsUnavailable: true},
+			},
+			Metadata: models.Metadata{Timestamp: time.Now(), BOSHAvailable: true, VitalsUnavailableCells: 1},
+		},
+		BOSHConfigured: true,
+	}
+
+	result := BuildContext(input)
+
+	// The cell without vitals is counted but does not halve utilization
+	if !strings.Contains(result, "**shared**: 2 cells, 32768 MB total, 16384 MB allocated (50.0%), 16384 MB used; 1 cells reporting no vitals, excluded") {
+		t.Errorf("expected segment line to exclude the cell without vitals.\nOutput:\n%s", result)
+	}
+	if !strings.Contains(result, "1 cells reporting no vitals; utilization covers only cells with vitals.") {
+		t.Errorf("expected totals note about cells without vitals.\nOutput:\n%s", result)
+	}
+}
+
 func TestBuildContext_MarkerCompleteness(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -0,0 +1,151 @@
+// ABOUTME: Named scenario baselines for comparing proposals against a saved plan
+// ABOUTME: Keeps baselines in memory and optionally persists them to disk via the cache
+
+package services
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/markalston/diego-capacity-analyzer/backend/cache"
+	"github.com/markalston/diego-capacity-analyzer/backend/models"
+)
+
+// MaxBaselines caps how many named baselines are kept; overwriting an
+// existing name is always allowed
+const MaxBaselines = 100
+
+// baselinesCacheKey is the cache key used when persisting baselines
+const baselinesCacheKey = "scenario:baselines"
+
+// baselineNamePattern restricts names to characters that are safe in URL paths
+var baselineNamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+var (
+	// ErrBaselineNotFound means no baseline has been saved under the name
+	ErrBaselineNotFound = errors.New("baseline not found")
+	// ErrInvalidBaselineName means the name is empty, too long, or has unsupported characters
+	ErrInvalidBaselineName = errors.New("baseline name must be 1-64 letters, digits, '.', '_', or '-'")
+	// ErrTooManyBaselines means the store is full and the name is new
+	ErrTooManyBaselines = fmt.Errorf("baseline limit of %d reached", MaxBaselines)
+	// ErrBaselineScopeMismatch means the baseline was saved for a different
+	// target cluster (or the whole foundation) than the comparison covers
+	ErrBaselineScopeMismatch = errors.New("baseline scope does not match the comparison")
+)
+
+// BaselineStore keeps named scenario results so proposals can be compared
+// against a previously saved plan, not just the current state
+type BaselineStore struct {
+	mu        sync.RWMutex
+	baselines map[string]models.ScenarioBaseline
+	cache     *cache.Cache
+	path      string // empty = in-memory only
+}
+
+// NewBaselineStore creates a baseline store. When path is non-empty, every
+// save is written there and Restore reloads it after a restart.
+func NewBaselineStore(c *cache.Cache, path string) *BaselineStore {
+	return &BaselineStore{
+		baselines: make(map[string]models.ScenarioBaseline),
+		cache:     c,
+		path:      path,
+	}
+}
+
+// SaveBaseline stores result under name, replacing any existing baseline with
+// that name. targetCluster is the cluster result was scoped to; empty means
+// the whole foundation. The in-memory baseline is kept even if persisting it fails.
+func (s *BaselineStore) SaveBaseline(name, savedBy, targetCluster string, result *models.ScenarioResult) (models.ScenarioBaseline, error) {
+	if !baselineNamePattern.MatchString(name) {
+		return models.ScenarioBaseline{}, ErrInvalidBaselineName
+	}
+	if result == nil {
+		return models.ScenarioBaseline{}, errors.New("baseline result is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.baselines[name]; !exists && len(s.baselines) >= MaxBaselines {
+		return models.ScenarioBaseline{}, ErrTooManyBaselines
+	}
+
+	baseline := models.ScenarioBaseline{
+		Name:          name,
+		SavedAt:       time.Now().UTC(),
+		SavedBy:       savedBy,
+		TargetCluster: targetCluster,
+		Result:        *result,
+	}
+	s.baselines[name] = baseline
+
+	if s.path != "" && s.cache != nil {
+		if err := s.cache.SetPersistent(baselinesCacheKey, maps.Clone(s.baselines), s.path); err != nil {
+			return baseline, fmt.Errorf("baseline saved but not persisted: %w", err)
+		}
+	}
+	return baseline, nil
+}
+
+// Get returns the baseline saved under name
+func (s *BaselineStore) Get(name string) (models.ScenarioBaseline, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	baseline, ok := s.baselines[name]
+	return baseline, ok
+}
+
+// CompareToBaseline computes the delta of proposed relative to the named
+// baseline, using the same delta logic as current-vs-proposed comparison.
+// targetCluster is the scope of proposed; a baseline saved for another scope
+// returns ErrBaselineScopeMismatch, since its delta would mix a cluster with
+// the foundation or with a different cluster.
+func (s *BaselineStore) CompareToBaseline(name, targetCluster string, proposed models.ScenarioResult) (models.BaselineComparison, error) {
+	baseline, ok := s.Get(name)
+	if !ok {
+		return models.BaselineComparison{}, fmt.Errorf("%w: %q", ErrBaselineNotFound, name)
+	}
+	if baseline.TargetCluster != targetCluster {
+		return models.BaselineComparison{}, fmt.Errorf("%w: baseline %q covers %s, the comparison covers %s",
+			ErrBaselineScopeMismatch, name, scopeLabel(baseline.TargetCluster), scopeLabel(targetCluster))
+	}
+	return models.BaselineComparison{
+		Baseline: baseline,
+		Proposed: proposed,
+		Delta:    CalculateDelta(baseline.Result, proposed),
+	}, nil
+}
+
+// scopeLabel describes a target cluster for error messages
+func scopeLabel(targetCluster string) string {
+	if targetCluster == "" {
+		return "the whole foundation"
+	}
+	return fmt.Sprintf("cluster %q", targetCluster)
+}
+
+// Restore loads baselines persisted at the store's path, replacing any held
+// in memory. A missing file returns an error wrapping os.ErrNotExist.
+func (s *BaselineStore) Restore() error {
+	if s.path == "" || s.cache == nil {
+		return nil
+	}
+
+	var baselines map[string]models.ScenarioBaseline
+	if err := s.cache.LoadPersistent(baselinesCacheKey, s.path, &baselines); err != nil {
+		return err
+	}
+	if baselines == nil {
+		baselines = make(map[string]models.ScenarioBaseline)
+	}
+
+	s.mu.Lock()
+	s.baselines = baselines
+	s.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,128 @@
+// ABOUTME: Tests for named scenario baselines
+// ABOUTME: Verifies name validation, capacity limits, baseline deltas, and persistence
+
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/markalston/diego-capacity-analyzer/backend/cache"
+	"github.com/markalston/diego-capacity-analyzer/backend/models"
+)
+
+func TestBaselineStore_SaveAndCompare(t *testing.T) {
+	store := NewBaselineStore(nil, "")
+
+	baselineResult := models.ScenarioResult{CellCount: 100, AppCapacityGB: 3000, UtilizationPct: 70, BlastRadiusPct: 1}
+	saved, err := store.SaveBaseline("q3-plan", "alice", "", &baselineResult)
+	if err != nil {
+		t.Fatalf("SaveBaseline returned error: %v", err)
+	}
+	if saved.Name != "q3-plan" || saved.SavedBy != "alice" || saved.SavedAt.IsZero() {
+		t.Errorf("Unexpected saved baseline: %+v", saved)
+	}
+
+	proposed := models.ScenarioResult{CellCount: 120, AppCapacityGB: 3600, UtilizationPct: 60, BlastRadiusPct: 1}
+	comparison, err := store.CompareToBaseline("q3-plan", "", proposed)
+	if err != nil {
+		t.Fatalf("CompareToBaseline returned error: %v", err)
+	}
+	if comparison.Baseline.Result.CellCount != 100 || comparison.Proposed.CellCount != 120 {
+		t.Errorf("Unexpected comparison results: baseline=%d proposed=%d", comparison.Baseline.Result.CellCount, comparison.Proposed.CellCount)
+	}
+	if want := CalculateDelta(baselineResult, proposed); comparison.Delta != want {
+		t.Errorf("Delta = %+v, want %+v", comparison.Delta, want)
+	}
+	if comparison.Delta.CapacityChangeGB != 600 || comparison.Delta.UtilizationChangePct != -10 {
+		t.Errorf("Expected +600 GB and -10%% utilization, got %+v", comparison.Delta)
+	}
+
+	if _, err := store.CompareToBaseline("missing", "", proposed); !errors.Is(err, ErrBaselineNotFound) {
+		t.Errorf("Expected ErrBaselineNotFound, got %v", err)
+	}
+}
+
+func TestBaselineStore_CompareRequiresSameScope(t *testing.T) {
+	store := NewBaselineStore(nil, "")
+
+	saved, err := store.SaveBaseline("b-plan", "", "cluster-b", &models.ScenarioResult{CellCount: 12})
+	if err != nil {
+		t.Fatalf("SaveBaseline returned error: %v", err)
+	}
+	if saved.TargetCluster != "cluster-b" {
+		t.Errorf("Expected the baseline to record cluster-b, got %q", saved.TargetCluster)
+	}
+
+	proposed := models.ScenarioResult{CellCount: 10}
+	if _, err := store.CompareToBaseline("b-plan", "cluster-b", proposed); err != nil {
+		t.Errorf("Same scope: expected no error, got %v", err)
+	}
+	for _, target := range []string{"", "cluster-a"} {
+		if _, err := store.CompareToBaseline("b-plan", target, proposed); !errors.Is(err, ErrBaselineScopeMismatch) {
+			t.Errorf("CompareToBaseline(target %q): expected ErrBaselineScopeMismatch, got %v", target, err)
+		}
+	}
+}
+
+func TestBaselineStore_InvalidNames(t *testing.T) {
+	store := NewBaselineStore(nil, "")
+	result := &models.ScenarioResult{}
+
+	for _, name := range []string{"", "has space", "slash/name", strings.Repeat("a", 65)} {
+		if _, err := store.SaveBaseline(name, "", "", result); !errors.Is(err, ErrInvalidBaselineName) {
+			t.Errorf("SaveBaseline(%q): expected ErrInvalidBaselineName, got %v", name, err)
+		}
+	}
+}
+
+func TestBaselineStore_Limit(t *testing.T) {
+	store := NewBaselineStore(nil, "")
+	result := &models.ScenarioResult{}
+
+	for i := 0; i < MaxBaselines; i++ {
+		if _, err := store.SaveBaseline(fmt.Sprintf("b%d", i), "", "", result); err != nil {
+			t.Fatalf("SaveBaseline %d returned error: %v", i, err)
+		}
+	}
+	if _, err := store.SaveBaseline("one-too-many", "", "", result); !errors.Is(err, ErrTooManyBaselines) {
+		t.Errorf("Expected ErrTooManyBaselines, got %v", err)
+	}
+
+	// Overwriting an existing name is still allowed at capacity
+	updated := &models.ScenarioResult{CellCount: 42}
+	if _, err := store.SaveBaseline("b0", "", "", updated); err != nil {
+		t.Fatalf("Overwrite at capacity returned error: %v", err)
+	}
+	if baseline, _ := store.Get("b0"); baseline.Result.CellCount != 42 {
+		t.Errorf("Expected overwritten baseline, got %+v", baseline.Result)
+	}
+}
+
+func TestBaselineStore_PersistAndRestore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baselines.json")
+	store := NewBaselineStore(cache.New(time.Minute), path)
+
+	if _, err := store.SaveBaseline("q3-plan", "", "", &models.ScenarioResult{CellCount: 100}); err != nil {
+		t.Fatalf("SaveBaseline returned error: %v", err)
+	}
+
+	restarted := NewBaselineStore(cache.New(time.Minute), path)
+	if err := restarted.Restore(); err != nil {
+		t.Fatalf("Restore returned error: %v", err)
+	}
+	baseline, ok := restarted.Get("q3-plan")
+	if !ok || baseline.Result.CellCount != 100 {
+		t.Errorf("Expected restored baseline with 100 cells, got %+v (found=%v)", baseline, ok)
+	}
+
+	missing := NewBaselineStore(cache.New(time.Minute), filepath.Join(t.TempDir(), "none.json"))
+	if err := missing.Restore(); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected os.ErrNotExist for missing file, got %v", err)
+	}
+}
@@ -4,10 +4,13 @@
 package services
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -16,13 +19,16 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/cloudfoundry/socks5-proxy"
 	"github.com/markalston/diego-capacity-analyzer/backend/models"
+	"github.com/markalston/diego-capacity-analyzer/backend/units"
 )
 
 type BOSHClient struct {
@@ -35,8 +41,43 @@ type BOSHClient struct {
 	token       string
 	tokenExpiry time.Time
 	tokenMutex  sync.RWMutex
+
+	// Deployment name filters (path.Match globs); empty include uses defaultDeploymentInclude
+	deploymentInclude []string
+	deploymentExclude []string
+
+	// Maximum deployments fetched in parallel; zero uses defaultMaxConcurrency
+	maxConcurrency int
+
+	// Overall wait for a BOSH task to finish; zero uses defaultTaskTimeout
+	taskTimeout time.Duration
+
+	// Deployment name to isolation segment name; unmapped deployments use the name heuristic
+	segmentMap map[string]string
+
+	// Instance group names that run Diego cells; empty uses isDiegoCellJob
+	cellJobNames []string
 }
 
+// defaultDeploymentInclude matches CF and isolation segment deployments
+// when BOSH_DEPLOYMENT_INCLUDE is not set
+var defaultDeploymentInclude = []string{"cf-*", "p-isolation-segment*"}
+
+// defaultMaxConcurrency bounds parallel deployment fetches when BOSH_MAX_CONCURRENCY is not set
+const defaultMaxConcurrency = 4
+
+// maxTaskOutputLineSize bounds one NDJSON line of task output. A VM line with
+// full vitals is a few KB, so this only trips on corrupt output.
+const maxTaskOutputLineSize = 1024 * 1024
+
+// BOSH task polling: exponential backoff from taskPollInitialDelay up to
+// taskPollMaxDelay, giving up after the task timeout (BOSH_TASK_TIMEOUT)
+const (
+	defaultTaskTimeout   = 120 * time.Second
+	taskPollInitialDelay = 500 * time.Millisecond
+	taskPollMaxDelay     = 5 * time.Second
+)
+
 func NewBOSHClient(environment, clientID, secret, caCert, deployment string, skipSSLValidation bool) (*BOSHClient, error) {
 	// Normalize environment URL - bosh cli omits protocol and sometimes port
 	if environment != "" {
@@ -101,11 +142,82 @@ func NewBOSHClient(environment, clientID, secret, caCert, deployment string, ski
 	}, nil
 }
 
+// SetMaxConcurrency sets how many deployments are queried in parallel. Values
+// below 1 restore the default of 4.
+func (b *BOSHClient) SetMaxConcurrency(n int) {
+	b.maxConcurrency = n
+}
+
+// SetTaskTimeout sets how long to wait for a BOSH task to finish. A zero or
+// negative duration restores the default of 120s.
+func (b *BOSHClient) SetTaskTimeout(d time.Duration) {
+	b.taskTimeout = d
+}
+
+// SetDeploymentFilters sets glob patterns (path.Match syntax) that select which
+// deployments are scanned for Diego cells. An empty include list keeps the
+// default cf-* and p-isolation-segment* selection. Exclude patterns are applied
+// after include.
+func (b *BOSHClient) SetDeploymentFilters(include, exclude []string) {
+	b.deploymentInclude = include
+	b.deploymentExclude = exclude
+}
+
+// SetSegmentMap sets the isolation segment name reported for the cells of each
+// named deployment. Deployments without an entry keep the default heuristic:
+// "isolated" for p-isolation-segment* deployments and isolated_diego_cell
+// jobs, "default" otherwise.
+func (b *BOSHClient) SetSegmentMap(segments map[string]string) {
+	b.segmentMap = segments
+}
+
+// SetCellJobNames sets the instance group names whose VMs are Diego cells,
+// replacing the built-in diego_cell, compute, and *diego_cell* matching. An
+// empty list restores the built-in matching.
+func (b *BOSHClient) SetCellJobNames(names []string) {
+	b.cellJobNames = names
+}
+
+// isCellJob reports whether VMs of the named instance group are Diego cells
+func (b *BOSHClient) isCellJob(jobName string) bool {
+	return matchesCellJob(b.cellJobNames, jobName)
+}
+
+// matchesCellJob reports whether jobName is one of cellJobNames, the
+// BOSH_CELL_JOB_NAMES list, or with an empty list whether isDiegoCellJob
+// accepts it. Live discovery and the BOSH dump and Ops Manager imports all
+// match instance groups through it.
+func matchesCellJob(cellJobNames []string, jobName string) bool {
+	if len(cellJobNames) == 0 {
+		return isDiegoCellJob(jobName)
+	}
+	return slices.Contains(cellJobNames, jobName)
+}
+
 // SetHTTPClient allows overriding the HTTP client (useful for testing)
 func (b *BOSHClient) SetHTTPClient(client *http.Client) {
 	b.client = client
 }
 
+// Ping checks that the BOSH Director is reachable via the unauthenticated /info endpoint
+func (b *BOSHClient) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", b.environment+"/info", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create info request: %w", err)
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to get BOSH info: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("BOSH info returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
 // getUAAEndpoint discovers the UAA endpoint from the BOSH Director info
 func (b *BOSHClient) getUAAEndpoint() (string, error) {
 	req, err := http.NewRequest("GET", b.environment+"/info", nil)
@@ -297,7 +409,8 @@ func ValidateSSHKeyPath(path string) (string, error) {
 	return absPath, nil
 }
 
-// createSOCKS5DialContextFunc creates a dial function for SSH+SOCKS5 proxy connections.
+// createSOCKS5DialContextFunc creates a dial function for SSH+SOCKS5 proxy connections,
+// used for both BOSH_ALL_PROXY and VSPHERE_ALL_PROXY.
 // Supports format: ssh+socks5://user@host:port?private-key=/path/to/key
 func createSOCKS5DialContextFunc(allProxy string) func(ctx context.Context, network, address string) (net.Conn, error) {
 	// Strip ssh+ prefix if present
@@ -305,13 +418,13 @@ func createSOCKS5DialContextFunc(allProxy string) func(ctx context.Context, netw
 
 	proxyURL, err := url.Parse(allProxy)
 	if err != nil {
-		slog.Error("Failed to parse BOSH_ALL_PROXY URL", "error", err)
+		slog.Error("Failed to parse SOCKS5 proxy URL", "error", err)
 		return nil
 	}
 
 	queryMap, err := url.ParseQuery(proxyURL.RawQuery)
 	if err != nil {
-		slog.Error("Failed to parse BOSH_ALL_PROXY query params", "error", err)
+		slog.Error("Failed to parse SOCKS5 proxy query params", "error", err)
 		return nil
 	}
 
@@ -322,7 +435,7 @@ func createSOCKS5DialContextFunc(allProxy string) func(ctx context.Context, netw
 
 	proxySSHKeyPath := queryMap.Get("private-key")
 	if proxySSHKeyPath == "" {
-		slog.Error("BOSH_ALL_PROXY missing required 'private-key' query param")
+		slog.Error("SOCKS5 proxy URL missing required 'private-key' query param")
 		return nil
 	}
 
@@ -396,37 +509,71 @@ type boshVM struct {
 			System struct {
 				Percent string `json:"percent"`
 			} `json:"system"`
+			Ephemeral struct {
+				Percent string `json:"percent"`
+			} `json:"ephemeral"`
+			Persistent struct {
+				Percent string `json:"percent"`
+			} `json:"persistent"`
 		} `json:"disk"`
 	} `json:"vitals"`
 }
 
-func (b *BOSHClient) GetDiegoCells() ([]models.DiegoCell, error) {
+// GetDiegoCells returns Diego cells from every selected deployment. ctx carries
+// request-scoped values such as the request ID included in the client's log lines.
+func (b *BOSHClient) GetDiegoCells(ctx context.Context) ([]models.DiegoCell, error) {
 	// Authenticate with UAA first
 	if err := b.authenticate(); err != nil {
 		return nil, fmt.Errorf("failed to authenticate with BOSH: %w", err)
 	}
 
 	// Get list of deployments to query
-	deployments, err := b.getDeployments()
+	deployments, err := b.getDeployments(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get deployments: %w", err)
 	}
-	slog.Info("Found deployments to query", "count", len(deployments))
-	slog.Debug("Deployment names", "deployments", deployments)
+	slog.InfoContext(ctx, "Found deployments to query", "count", len(deployments))
+	slog.DebugContext(ctx, "Deployment names", "deployments", deployments)
+
+	// Each deployment runs its own BOSH task, so query them concurrently with a bounded pool
+	concurrency := b.maxConcurrency
+	if concurrency < 1 {
+		concurrency = defaultMaxConcurrency
+	}
+	results := make([][]models.DiegoCell, len(deployments))
+	errs := make([]error, len(deployments))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, deployment := range deployments {
+		wg.Add(1)
+		go func(i int, deployment string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			slog.DebugContext(ctx, "Querying deployment", "deployment", deployment)
+			cells, err := b.getCellsForDeployment(ctx, deployment)
+			if err != nil {
+				slog.WarnContext(ctx, "Failed to get cells for deployment", "deployment", deployment, "error", err)
+				errs[i] = fmt.Errorf("deployment %s: %w", deployment, err)
+				return
+			}
+			slog.DebugContext(ctx, "Found cells in deployment", "deployment", deployment, "count", len(cells))
+			results[i] = cells
+		}(i, deployment)
+	}
+	wg.Wait()
 
+	// Aggregate in deployment order so output is stable regardless of completion order
 	var allCells []models.DiegoCell
-	for _, deployment := range deployments {
-		slog.Debug("Querying deployment", "deployment", deployment)
-		cells, err := b.getCellsForDeployment(deployment)
-		if err != nil {
-			slog.Warn("Failed to get cells for deployment", "deployment", deployment, "error", err)
-			continue
-		}
-		slog.Debug("Found cells in deployment", "deployment", deployment, "count", len(cells))
+	for _, cells := range results {
 		allCells = append(allCells, cells...)
 	}
 
 	if len(allCells) == 0 {
+		if err := errors.Join(errs...); err != nil {
+			return nil, fmt.Errorf("no Diego cells found in any deployment: %w", err)
+		}
 		return nil, fmt.Errorf("no Diego cells found in any deployment")
 	}
 
@@ -434,7 +581,7 @@ func (b *BOSHClient) GetDiegoCells() ([]models.DiegoCell, error) {
 }
 
 // getDeployments returns list of CF and isolation segment deployments
-func (b *BOSHClient) getDeployments() ([]string, error) {
+func (b *BOSHClient) getDeployments(ctx context.Context) ([]string, error) {
 	req, err := http.NewRequest("GET", b.environment+"/deployments", nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -459,25 +606,50 @@ func (b *BOSHClient) getDeployments() ([]string, error) {
 		return nil, fmt.Errorf("failed to parse deployments: %w", err)
 	}
 
-	// Filter for CF and isolation segment deployments
-	var result []string
-	for _, d := range deploymentList {
-		if strings.HasPrefix(d.Name, "cf-") || strings.HasPrefix(d.Name, "p-isolation-segment") {
-			result = append(result, d.Name)
-		}
-	}
-
 	allDeploymentNames := make([]string, len(deploymentList))
 	for i, d := range deploymentList {
 		allDeploymentNames[i] = d.Name
 	}
-	slog.Debug("All deployments from BOSH", "deployments", allDeploymentNames)
+	slog.DebugContext(ctx, "All deployments from BOSH", "deployments", allDeploymentNames)
+
+	// Filter by configured include/exclude patterns (defaults to CF and isolation segment deployments)
+	result, skipped := filterDeployments(allDeploymentNames, b.deploymentInclude, b.deploymentExclude)
+	slog.DebugContext(ctx, "Filtered BOSH deployments", "selected", result, "skipped", skipped)
 
 	return result, nil
 }
 
+// filterDeployments splits deployment names into those selected for scanning
+// and those skipped. A name is selected when it matches any include pattern
+// (defaultDeploymentInclude if include is empty) and no exclude pattern.
+// Patterns use path.Match semantics against the full deployment name.
+func filterDeployments(names, include, exclude []string) (selected, skipped []string) {
+	if len(include) == 0 {
+		include = defaultDeploymentInclude
+	}
+	for _, name := range names {
+		if matchesAnyPattern(name, include) && !matchesAnyPattern(name, exclude) {
+			selected = append(selected, name)
+		} else {
+			skipped = append(skipped, name)
+		}
+	}
+	return selected, skipped
+}
+
+// matchesAnyPattern reports whether name matches any of the glob patterns.
+// Malformed patterns never match; config.Load rejects them at startup.
+func matchesAnyPattern(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, err := path.Match(pattern, name); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
 // getCellsForDeployment fetches Diego cells for a specific deployment
-func (b *BOSHClient) getCellsForDeployment(deployment string) ([]models.DiegoCell, error) {
+func (b *BOSHClient) getCellsForDeployment(ctx context.Context, deployment string) ([]models.DiegoCell, error) {
 	if err := ValidateDeploymentName(deployment); err != nil {
 		return nil, fmt.Errorf("invalid deployment name: %w", err)
 	}
@@ -518,146 +690,207 @@ func (b *BOSHClient) getCellsForDeployment(deployment string) ([]models.DiegoCel
 		return nil, fmt.Errorf("could not determine task ID from BOSH response")
 	}
 
-	// Poll task until done
-	vms, err := b.waitForTaskAndGetOutput(taskID)
+	// Poll task until done, keeping only the Diego cell VMs as the output streams in
+	vms, scanned, err := b.waitForTaskAndGetOutput(taskID, b.isCellJob)
 	if err != nil {
 		return nil, err
 	}
+	if scanned > 0 && len(vms) == 0 {
+		slog.WarnContext(ctx, "No VMs in deployment matched a Diego cell job name; set BOSH_CELL_JOB_NAMES if its cells use another instance group name",
+			"deployment", deployment, "vm_count", scanned, "cell_job_names", b.cellJobNames)
+	}
 
 	// Determine isolation segment from deployment name
 	// p-isolation-segment-* deployments have isolated cells
 	isolationSegment := "default"
 	if strings.HasPrefix(deployment, "p-isolation-segment") {
-		// The segment name is typically configured in the tile
-		// For now, use a generic name based on deployment
+		// The segment name is configured in the tile and is not visible here;
+		// BOSH_SEGMENT_MAP supplies the real name
 		isolationSegment = "isolated"
 	}
 
-	slog.Info("VMs found in deployment", "vm_count", len(vms))
+	slog.InfoContext(ctx, "VMs found in deployment", "deployment", deployment, "vm_count", scanned, "cell_count", len(vms))
 	// Log detailed job names at DEBUG level only
-	if slog.Default().Enabled(context.Background(), slog.LevelDebug) {
+	if slog.Default().Enabled(ctx, slog.LevelDebug) {
 		var jobNames []string
 		for _, vm := range vms {
 			jobNames = append(jobNames, vm.JobName)
 		}
-		slog.Debug("VM details", "deployment", deployment, "job_names", jobNames)
+		slog.DebugContext(ctx, "VM details", "deployment", deployment, "job_names", jobNames)
 	}
 
+	cells := diegoCellsFromVMs(vms, isolationSegment)
+	// An operator-mapped segment name is authoritative, isolated_diego_cell jobs included
+	if segment, ok := b.segmentMap[deployment]; ok {
+		for i := range cells {
+			cells[i].IsolationSegment = segment
+		}
+	}
+	return cells, nil
+}
+
+// diegoCellsFromVMs converts the BOSH VM vitals of Diego cell VMs into
+// DiegoCell metrics. Callers select the cell VMs. Vitals that BOSH omits
+// parse as zero; cells without memory vitals are marked VitalsUnavailable.
+func diegoCellsFromVMs(vms []boshVM, isolationSegment string) []models.DiegoCell {
 	var cells []models.DiegoCell
 	for _, vm := range vms {
-		// Include diego_cell, compute, and any job name containing "diego_cell" (e.g., isolated_diego_cell, isolated_diego_cell_small_cell)
-		if vm.JobName == "diego_cell" || vm.JobName == "compute" || strings.Contains(vm.JobName, "diego_cell") {
-			memoryKB := parseIntOrZero(vm.Vitals.Mem.KB)
-			memoryMB := memoryKB / 1024
-			memPercent := parseIntOrZero(vm.Vitals.Mem.Percent)
-			cpuSys := parseFloatOrZero(vm.Vitals.CPU.Sys)
-
-			// mem.percent from BOSH vitals is VM-level memory usage
-			usedMB := (memoryMB * memPercent) / 100
-
-			// Use deployment-specific isolation segment
-			cellSegment := isolationSegment
-			if vm.JobName == "isolated_diego_cell" {
-				cellSegment = "isolated" // isolated_diego_cell is always in an isolation segment
-			}
-
-			cells = append(cells, models.DiegoCell{
-				ID:               vm.ID,
-				Name:             fmt.Sprintf("%s/%d", vm.JobName, vm.Index),
-				MemoryMB:         memoryMB,
-				AllocatedMB:      usedMB,
-				UsedMB:           usedMB,
-				CPUPercent:       int(cpuSys),
-				IsolationSegment: cellSegment,
-			})
-		}
+		cells = append(cells, diegoCellFromVM(vm, isolationSegment))
 	}
 
-	return cells, nil
+	return cells
+}
+
+// isDiegoCellJob reports whether a BOSH instance group runs Diego cells:
+// diego_cell, compute, and any job name containing "diego_cell" (e.g.,
+// isolated_diego_cell, isolated_diego_cell_small_cell)
+func isDiegoCellJob(jobName string) bool {
+	return jobName == "diego_cell" || jobName == "compute" || strings.Contains(jobName, "diego_cell")
 }
 
-// waitForTaskAndGetOutput polls a BOSH task until done and returns VM data
-func (b *BOSHClient) waitForTaskAndGetOutput(taskID int) ([]boshVM, error) {
+// diegoCellFromVM converts one Diego cell VM's vitals into DiegoCell metrics.
+// BOSH sends empty vitals for VMs whose agent is unresponsive, so a missing
+// mem.percent means usage is unknown rather than zero.
+func diegoCellFromVM(vm boshVM, isolationSegment string) models.DiegoCell {
+	memoryKB := parseIntOrZero(vm.Vitals.Mem.KB)
+	memoryMB := units.KBToMiB(memoryKB)
+	memPercent := parseIntOrZero(vm.Vitals.Mem.Percent)
+	cpuSys := parseFloatOrZero(vm.Vitals.CPU.Sys)
+
+	// mem.percent from BOSH vitals is VM-level memory usage
+	usedMB := (memoryMB * memPercent) / 100
+
+	// Use deployment-specific isolation segment
+	cellSegment := isolationSegment
+	if vm.JobName == "isolated_diego_cell" {
+		cellSegment = "isolated" // isolated_diego_cell is always in an isolation segment
+	}
+
+	return models.DiegoCell{
+		ID:                   vm.ID,
+		Name:                 fmt.Sprintf("%s/%d", vm.JobName, vm.Index),
+		MemoryMB:             memoryMB,
+		AllocatedMB:          usedMB,
+		UsedMB:               usedMB,
+		CPUPercent:           int(cpuSys),
+		DiskPercent:          parseIntOrZero(vm.Vitals.Disk.Persistent.Percent),
+		EphemeralDiskPercent: parseIntOrZero(vm.Vitals.Disk.Ephemeral.Percent),
+		IsolationSegment:     cellSegment,
+		VitalsUnavailable:    strings.TrimSpace(vm.Vitals.Mem.Percent) == "",
+	}
+}
+
+// waitForTaskAndGetOutput polls a BOSH task until done and returns the VMs
+// whose job name passes keep, along with how many VMs the output listed
+func (b *BOSHClient) waitForTaskAndGetOutput(taskID int, keep func(jobName string) bool) ([]boshVM, int, error) {
 	taskURL := fmt.Sprintf("%s/tasks/%d", b.environment, taskID)
 
-	for i := 0; i < 60; i++ { // Max 60 attempts (2 minutes with 2s sleep)
+	timeout := b.taskTimeout
+	if timeout <= 0 {
+		timeout = defaultTaskTimeout
+	}
+	deadline := time.Now().Add(timeout)
+	delay := taskPollInitialDelay
+
+	for {
 		req, err := http.NewRequest("GET", taskURL, nil)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create task request: %w", err)
+			return nil, 0, fmt.Errorf("failed to create task request: %w", err)
 		}
 		req.Header.Set("Authorization", "Bearer "+b.token)
 
 		resp, err := b.client.Do(req)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get task status: %w", err)
+			return nil, 0, fmt.Errorf("failed to get task status: %w", err)
 		}
 
 		var task boshTask
 		if err := json.NewDecoder(resp.Body).Decode(&task); err != nil {
 			resp.Body.Close()
-			return nil, fmt.Errorf("failed to parse task status: %w", err)
+			return nil, 0, fmt.Errorf("failed to parse task status: %w", err)
 		}
 		resp.Body.Close()
 
 		switch task.State {
 		case "done":
 			// Get task output
-			return b.getTaskOutput(taskID)
+			return b.getTaskOutput(taskID, keep)
 		case "error", "cancelled":
-			return nil, fmt.Errorf("BOSH task failed: %s", task.Result)
-		case "processing", "queued":
-			time.Sleep(2 * time.Second)
-		default:
-			time.Sleep(2 * time.Second)
+			return nil, 0, fmt.Errorf("BOSH task failed: %s", task.Result)
 		}
+
+		// Still processing/queued (or an unknown state): back off, but always
+		// poll once more at the deadline in case the task just finished
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil, 0, fmt.Errorf("timeout waiting for BOSH task %d after %s", taskID, timeout)
+		}
+		time.Sleep(min(delay, remaining))
+		delay = nextPollDelay(delay)
 	}
+}
 
-	return nil, fmt.Errorf("timeout waiting for BOSH task %d", taskID)
+// nextPollDelay doubles a task polling delay, capped at taskPollMaxDelay
+func nextPollDelay(delay time.Duration) time.Duration {
+	return min(delay*2, taskPollMaxDelay)
 }
 
-// getTaskOutput retrieves the output from a completed task
-func (b *BOSHClient) getTaskOutput(taskID int) ([]boshVM, error) {
+// getTaskOutput retrieves the output from a completed task, keeping the VMs
+// whose job name passes keep
+func (b *BOSHClient) getTaskOutput(taskID int, keep func(jobName string) bool) ([]boshVM, int, error) {
 	outputURL := fmt.Sprintf("%s/tasks/%d/output?type=result", b.environment, taskID)
 
 	req, err := http.NewRequest("GET", outputURL, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create output request: %w", err)
+		return nil, 0, fmt.Errorf("failed to create output request: %w", err)
 	}
 	req.Header.Set("Authorization", "Bearer "+b.token)
 
 	resp, err := b.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get task output: %w", err)
+		return nil, 0, fmt.Errorf("failed to get task output: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to get task output (status %d): %s", resp.StatusCode, string(body))
+		return nil, 0, fmt.Errorf("failed to get task output (status %d): %s", resp.StatusCode, string(body))
 	}
 
-	// Task output is NDJSON (newline-delimited JSON)
-	var vms []boshVM
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read task output: %w", err)
-	}
+	return parseTaskOutput(resp.Body, keep)
+}
+
+// parseTaskOutput decodes BOSH task output, which is NDJSON (one VM per
+// line), a line at a time so that large deployments are never held in
+// memory whole. VMs whose job name fails keep are dropped as they are read;
+// a nil keep retains every VM. Unparseable lines are logged and skipped.
+// It returns the retained VMs and the number of VMs decoded.
+func parseTaskOutput(r io.Reader, keep func(jobName string) bool) ([]boshVM, int, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxTaskOutputLineSize)
 
-	lines := strings.Split(string(body), "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
+	var vms []boshVM
+	scanned := 0
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
 			continue
 		}
 		var vm boshVM
-		if err := json.Unmarshal([]byte(line), &vm); err != nil {
-			slog.Warn("Failed to parse VM line", "line", line, "error", err)
+		if err := json.Unmarshal(line, &vm); err != nil {
+			slog.Warn("Failed to parse VM line", "line", string(line), "error", err)
 			continue
 		}
-		vms = append(vms, vm)
+		scanned++
+		if keep == nil || keep(vm.JobName) {
+			vms = append(vms, vm)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to read task output: %w", err)
 	}
 
-	return vms, nil
+	return vms, scanned, nil
 }
 
 func parseIntOrZero(s string) int {
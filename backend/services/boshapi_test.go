@@ -1,13 +1,24 @@
 package services
 
 import (
+	"bytes"
+	"context"
 	"crypto/tls"
 	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/markalston/diego-capacity-analyzer/backend/logger"
 )
 
 func TestBOSHClient_GetDiegoCells(t *testing.T) {
@@ -98,7 +109,7 @@ func TestBOSHClient_GetDiegoCells(t *testing.T) {
 		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
 	}
 
-	cells, err := client.GetDiegoCells()
+	cells, err := client.GetDiegoCells(context.Background())
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
@@ -112,6 +123,473 @@ func TestBOSHClient_GetDiegoCells(t *testing.T) {
 	}
 }
 
+// newMultiDeploymentBOSHServer serves a BOSH director with the given deployments.
+// Each deployment's VM task completes immediately with one Diego cell, except
+// deployments listed in failing, whose VM request returns 500. The returned
+// counter tracks the peak number of VM requests in flight at once.
+func newMultiDeploymentBOSHServer(t *testing.T, deployments []string, failing map[string]bool) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var inFlight, peak atomic.Int32
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /info", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"user_authentication": map[string]interface{}{
+				"type":    "uaa",
+				"options": map[string]interface{}{"url": "https://" + r.Host},
+			},
+		})
+	})
+	mux.HandleFunc("POST /oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "test-token", "expires_in": 3600})
+	})
+	mux.HandleFunc("GET /deployments", func(w http.ResponseWriter, r *http.Request) {
+		list := make([]map[string]string, len(deployments))
+		for i, name := range deployments {
+			list[i] = map[string]string{"name": name}
+		}
+		json.NewEncoder(w).Encode(list)
+	})
+	mux.HandleFunc("GET /deployments/{name}/vms", func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+
+		name := r.PathValue("name")
+		if failing[name] {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		for i, d := range deployments {
+			if d == name {
+				json.NewEncoder(w).Encode(map[string]interface{}{"id": i + 1, "state": "queued"})
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("GET /tasks/{id}", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"state": "done"})
+	})
+	mux.HandleFunc("GET /tasks/{id}/output", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"job_name":"diego_cell","index":0,"id":"cell-%s","vitals":{"mem":{"kb":"16777216","percent":"50"}}}`+"\n", r.PathValue("id"))
+	})
+
+	server := httptest.NewTLSServer(mux)
+	t.Cleanup(server.Close)
+	return server, &peak
+}
+
+func newTestBOSHClient(t *testing.T, serverURL string) *BOSHClient {
+	t.Helper()
+	client, err := NewBOSHClient(serverURL, "ops_manager", "secret", "", "", true)
+	if err != nil {
+		t.Fatalf("Failed to create BOSH client: %v", err)
+	}
+	client.client.Transport = &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}
+	client.SetDeploymentFilters([]string{"*"}, nil)
+	return client
+}
+
+func TestBOSHClient_GetDiegoCells_Concurrent(t *testing.T) {
+	deployments := []string{"cf-a", "seg-b", "seg-c", "seg-d", "seg-e", "seg-f"}
+	server, peak := newMultiDeploymentBOSHServer(t, deployments, map[string]bool{"seg-c": true})
+
+	client := newTestBOSHClient(t, server.URL)
+	client.SetMaxConcurrency(2)
+
+	cells, err := client.GetDiegoCells(context.Background())
+	if err != nil {
+		t.Fatalf("Expected partial results despite one failing deployment, got %v", err)
+	}
+	if len(cells) != 5 {
+		t.Fatalf("Expected 5 cells from healthy deployments, got %d", len(cells))
+	}
+	// Results stay in deployment order: cf-a (task 1), seg-b (task 2), seg-d (task 4)...
+	if cells[0].ID != "cell-1" || cells[2].ID != "cell-4" {
+		t.Errorf("Expected cells in deployment order, got %s, %s", cells[0].ID, cells[2].ID)
+	}
+	if got := peak.Load(); got != 2 {
+		t.Errorf("Expected 2 deployments in flight at peak, got %d", got)
+	}
+}
+
+func TestBOSHClient_GetDiegoCells_LogsRequestID(t *testing.T) {
+	server, _ := newMultiDeploymentBOSHServer(t, []string{"cf-a"}, nil)
+	client := newTestBOSHClient(t, server.URL)
+
+	var logBuf bytes.Buffer
+	original := slog.Default()
+	slog.SetDefault(slog.New(logger.NewContextHandler(slog.NewTextHandler(&logBuf, nil))))
+	defer slog.SetDefault(original)
+
+	ctx := logger.WithRequestID(context.Background(), "req-42")
+	if _, err := client.GetDiegoCells(ctx); err != nil {
+		t.Fatalf("GetDiegoCells returned error: %v", err)
+	}
+
+	for _, msg := range []string{"Found deployments to query", "VMs found in deployment"} {
+		found := false
+		for _, line := range strings.Split(logBuf.String(), "\n") {
+			if strings.Contains(line, msg) {
+				found = true
+				if !strings.Contains(line, "request_id=req-42") {
+					t.Errorf("expected request_id on %q log line, got %q", msg, line)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("expected %q log line, got %q", msg, logBuf.String())
+		}
+	}
+}
+
+func TestBOSHClient_GetDiegoCells_SegmentMap(t *testing.T) {
+	deployments := []string{"cf-a", "p-isolation-segment-abc123", "p-isolation-segment-def456"}
+	server, _ := newMultiDeploymentBOSHServer(t, deployments, nil)
+	client := newTestBOSHClient(t, server.URL)
+	client.SetSegmentMap(map[string]string{"p-isolation-segment-abc123": "blue"})
+
+	cells, err := client.GetDiegoCells(context.Background())
+	if err != nil {
+		t.Fatalf("GetDiegoCells returned error: %v", err)
+	}
+	// Mapped deployments report their real name; the others keep the heuristic
+	want := []string{"default", "blue", "isolated"}
+	if len(cells) != len(want) {
+		t.Fatalf("Expected %d cells, got %d", len(want), len(cells))
+	}
+	for i, segment := range want {
+		if cells[i].IsolationSegment != segment {
+			t.Errorf("cells[%d] (%s) segment = %q, want %q", i, deployments[i], cells[i].IsolationSegment, segment)
+		}
+	}
+}
+
+func TestBOSHClient_GetDiegoCells_CellJobNames(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /info", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"user_authentication": map[string]interface{}{
+				"type":    "uaa",
+				"options": map[string]interface{}{"url": "https://" + r.Host},
+			},
+		})
+	})
+	mux.HandleFunc("POST /oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "test-token", "expires_in": 3600})
+	})
+	mux.HandleFunc("GET /deployments", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]map[string]string{{"name": "cf-fork"}})
+	})
+	mux.HandleFunc("GET /deployments/{name}/vms", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": 1, "state": "queued"})
+	})
+	mux.HandleFunc("GET /tasks/{id}", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"state": "done"})
+	})
+	mux.HandleFunc("GET /tasks/{id}/output", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"job_name":"app_cell","index":0,"id":"cell-01","vitals":{"mem":{"kb":"16777216","percent":"50"}}}
+{"job_name":"app_cell","index":1,"id":"cell-02","vitals":{"mem":{"kb":"16777216","percent":"50"}}}
+{"job_name":"diego_cell","index":0,"id":"cell-03","vitals":{"mem":{"kb":"16777216","percent":"50"}}}
+{"job_name":"router","index":0,"id":"router-01"}
+`))
+	})
+	server := httptest.NewTLSServer(mux)
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		jobNames []string
+		wantIDs  []string
+	}{
+		{"built-in names", nil, []string{"cell-03"}},
+		{"custom names replace built-in", []string{"app_cell"}, []string{"cell-01", "cell-02"}},
+		{"custom names extend built-in", []string{"app_cell", "diego_cell"}, []string{"cell-01", "cell-02", "cell-03"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestBOSHClient(t, server.URL)
+			client.SetCellJobNames(tt.jobNames)
+
+			cells, err := client.GetDiegoCells(context.Background())
+			if err != nil {
+				t.Fatalf("GetDiegoCells returned error: %v", err)
+			}
+			var ids []string
+			for _, cell := range cells {
+				ids = append(ids, cell.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Errorf("Expected cells %v, got %v", tt.wantIDs, ids)
+			}
+		})
+	}
+}
+
+func TestBOSHClient_GetDiegoCells_AllDeploymentsFail(t *testing.T) {
+	server, _ := newMultiDeploymentBOSHServer(t, []string{"cf-a", "seg-b"}, map[string]bool{"cf-a": true, "seg-b": true})
+	client := newTestBOSHClient(t, server.URL)
+
+	_, err := client.GetDiegoCells(context.Background())
+	if err == nil {
+		t.Fatal("Expected error when no deployment yields cells")
+	}
+	if !strings.Contains(err.Error(), "no Diego cells found in any deployment") {
+		t.Errorf("Expected no-cells error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "seg-b") {
+		t.Errorf("Expected per-deployment errors to be included, got %v", err)
+	}
+}
+
+func TestNextPollDelay(t *testing.T) {
+	want := []time.Duration{
+		500 * time.Millisecond, time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second,
+	}
+	delay := taskPollInitialDelay
+	for i, w := range want {
+		if delay != w {
+			t.Errorf("delay[%d] = %s, want %s", i, delay, w)
+		}
+		delay = nextPollDelay(delay)
+	}
+}
+
+func TestWaitForTask_PollsUntilDeadline(t *testing.T) {
+	var polls atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /tasks/{id}", func(w http.ResponseWriter, r *http.Request) {
+		polls.Add(1)
+		json.NewEncoder(w).Encode(map[string]interface{}{"state": "processing"})
+	})
+	server := httptest.NewTLSServer(mux)
+	defer server.Close()
+
+	client := newTestBOSHClient(t, server.URL)
+	client.SetTaskTimeout(1200 * time.Millisecond)
+
+	start := time.Now()
+	_, _, err := client.waitForTaskAndGetOutput(7, isDiegoCellJob)
+	elapsed := time.Since(start)
+
+	if err == nil || !strings.Contains(err.Error(), "timeout waiting for BOSH task 7") {
+		t.Fatalf("Expected task timeout error, got %v", err)
+	}
+	// Polls at 0s, 0.5s, then a final poll at the 1.2s deadline instead of sleeping a full 1s
+	if got := polls.Load(); got != 3 {
+		t.Errorf("Expected 3 polls, got %d", got)
+	}
+	if elapsed < 1200*time.Millisecond || elapsed > 2*time.Second {
+		t.Errorf("Expected to give up at the 1.2s deadline, took %s", elapsed)
+	}
+}
+
+func TestWaitForTask_FastTaskReturnsQuickly(t *testing.T) {
+	var polls atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /tasks/{id}", func(w http.ResponseWriter, r *http.Request) {
+		state := "processing"
+		if polls.Add(1) > 1 {
+			state = "done"
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"state": state})
+	})
+	mux.HandleFunc("GET /tasks/{id}/output", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"job_name":"diego_cell","index":0,"id":"cell-01"}` + "\n"))
+	})
+	server := httptest.NewTLSServer(mux)
+	defer server.Close()
+
+	client := newTestBOSHClient(t, server.URL)
+
+	start := time.Now()
+	vms, _, err := client.waitForTaskAndGetOutput(1, isDiegoCellJob)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(vms) != 1 {
+		t.Errorf("Expected 1 VM, got %d", len(vms))
+	}
+	if elapsed := time.Since(start); elapsed > 1500*time.Millisecond {
+		t.Errorf("Expected first backoff of 500ms, took %s", elapsed)
+	}
+}
+
+// syntheticTaskOutput generates NDJSON task output for vms VMs on demand, so
+// the full output never exists in memory. Every tenth VM is a Diego cell, and
+// a blank and an unparseable line follow every ten thousandth.
+type syntheticTaskOutput struct {
+	vms     int
+	next    int
+	pending []byte
+}
+
+func (s *syntheticTaskOutput) Read(p []byte) (int, error) {
+	for len(s.pending) == 0 {
+		if s.next == s.vms {
+			return 0, io.EOF
+		}
+		job := "router"
+		if s.next%10 == 0 {
+			job = "diego_cell"
+		}
+		s.pending = fmt.Appendf(nil, `{"job_name":%q,"index":%d,"id":"vm-%d","vitals":{"mem":{"kb":"16777216","percent":"50"},"cpu":{"sys":"10"}}}`+"\n", job, s.next, s.next)
+		if s.next%10000 == 9999 {
+			s.pending = append(s.pending, "\n{not json\n"...)
+		}
+		s.next++
+	}
+	n := copy(p, s.pending)
+	s.pending = s.pending[n:]
+	return n, nil
+}
+
+func TestParseTaskOutput_StreamsLargeOutput(t *testing.T) {
+	const total = 100000
+	vms, scanned, err := parseTaskOutput(&syntheticTaskOutput{vms: total}, isDiegoCellJob)
+	if err != nil {
+		t.Fatalf("parseTaskOutput failed: %v", err)
+	}
+	if scanned != total {
+		t.Errorf("Expected %d VMs decoded, got %d", total, scanned)
+	}
+	if len(vms) != total/10 {
+		t.Fatalf("Expected %d Diego cells kept, got %d", total/10, len(vms))
+	}
+	for i, vm := range vms {
+		if vm.JobName != "diego_cell" || vm.Index != i*10 {
+			t.Fatalf("vms[%d] = %s/%d, want diego_cell/%d", i, vm.JobName, vm.Index, i*10)
+		}
+	}
+	if vms[1].Vitals.Mem.Percent != "50" {
+		t.Errorf("Expected vitals to be decoded, got %+v", vms[1].Vitals)
+	}
+}
+
+func TestParseTaskOutput_NilKeepRetainsAll(t *testing.T) {
+	output := `{"job_name":"diego_cell","index":0,"id":"cell-01"}
+garbage
+{"job_name":"router","index":0,"id":"router-01"}
+`
+	vms, scanned, err := parseTaskOutput(strings.NewReader(output), nil)
+	if err != nil {
+		t.Fatalf("parseTaskOutput failed: %v", err)
+	}
+	if scanned != 2 || len(vms) != 2 {
+		t.Errorf("Expected 2 VMs decoded and kept, got %d decoded, %d kept", scanned, len(vms))
+	}
+}
+
+func TestParseTaskOutput_LineTooLong(t *testing.T) {
+	output := `{"job_name":"diego_cell","id":"` + strings.Repeat("x", maxTaskOutputLineSize) + `"}`
+	if _, _, err := parseTaskOutput(strings.NewReader(output), nil); err == nil {
+		t.Error("Expected an error for a line over maxTaskOutputLineSize")
+	}
+}
+
+func TestDiegoCellsFromVMs_DiskVitals(t *testing.T) {
+	output := `{"job_name":"diego_cell","index":0,"id":"cell-01","vitals":{"mem":{"kb":"16777216","percent":"50"},"cpu":{"sys":"10"},"disk":{"system":{"percent":"30"},"ephemeral":{"percent":"64"},"persistent":{"percent":"12"}}}}
+{"job_name":"diego_cell","index":1,"id":"cell-02","vitals":{"mem":{"kb":"16777216","percent":"50"},"cpu":{"sys":"10"}}}`
+
+	var vms []boshVM
+	for _, line := range strings.Split(output, "\n") {
+		var vm boshVM
+		if err := json.Unmarshal([]byte(line), &vm); err != nil {
+			t.Fatalf("Failed to parse VM line: %v", err)
+		}
+		vms = append(vms, vm)
+	}
+
+	cells := diegoCellsFromVMs(vms, "default")
+	if len(cells) != 2 {
+		t.Fatalf("Expected 2 cells, got %d", len(cells))
+	}
+	if cells[0].EphemeralDiskPercent != 64 || cells[0].DiskPercent != 12 {
+		t.Errorf("cell-01 disk = ephemeral %d%%, persistent %d%%, want 64%%/12%%",
+			cells[0].EphemeralDiskPercent, cells[0].DiskPercent)
+	}
+	if cells[1].EphemeralDiskPercent != 0 || cells[1].DiskPercent != 0 {
+		t.Errorf("Expected zero disk usage for cell without disk vitals, got ephemeral %d%%, persistent %d%%",
+			cells[1].EphemeralDiskPercent, cells[1].DiskPercent)
+	}
+}
+
+func TestDiegoCellsFromVMs_MissingVitals(t *testing.T) {
+	output := `{"job_name":"diego_cell","index":0,"id":"cell-01","vitals":{"mem":{"kb":"16777216","percent":"0"},"cpu":{"sys":"10"}}}
+{"job_name":"diego_cell","index":1,"id":"cell-02","vitals":{"mem":{"kb":"","percent":""},"cpu":{"sys":""}}}
+{"job_name":"diego_cell","index":2,"id":"cell-03"}`
+
+	var vms []boshVM
+	for _, line := range strings.Split(output, "\n") {
+		var vm boshVM
+		if err := json.Unmarshal([]byte(line), &vm); err != nil {
+			t.Fatalf("Failed to parse VM line: %v", err)
+		}
+		vms = append(vms, vm)
+	}
+
+	cells := diegoCellsFromVMs(vms, "default")
+	if len(cells) != 3 {
+		t.Fatalf("Expected 3 cells, got %d", len(cells))
+	}
+	// A reported 0% is a measurement, not missing vitals
+	if cells[0].VitalsUnavailable {
+		t.Error("cell-01 reports mem.percent and should not be marked VitalsUnavailable")
+	}
+	for _, cell := range cells[1:] {
+		if !cell.VitalsUnavailable {
+			t.Errorf("%s has no memory vitals and should be marked VitalsUnavailable", cell.ID)
+		}
+		if cell.UsedMB != 0 || cell.MemoryMB != 0 {
+			t.Errorf("%s metrics = %d/%d MB, want zero", cell.ID, cell.UsedMB, cell.MemoryMB)
+		}
+	}
+}
+
+func TestFilterDeployments(t *testing.T) {
+	names := []string{"cf-abc123", "p-isolation-segment-xyz", "prod-cf", "seg-payments", "seg-sandbox", "p-mysql"}
+
+	tests := []struct {
+		name     string
+		include  []string
+		exclude  []string
+		expected []string
+	}{
+		{"default include", nil, nil, []string{"cf-abc123", "p-isolation-segment-xyz"}},
+		{"custom include", []string{"prod-cf", "seg-*"}, nil, []string{"prod-cf", "seg-payments", "seg-sandbox"}},
+		{"include with exclude", []string{"prod-cf", "seg-*"}, []string{"*-sandbox"}, []string{"prod-cf", "seg-payments"}},
+		{"exclude with default include", nil, []string{"p-isolation-segment-*"}, []string{"cf-abc123"}},
+		{"full-name match only", []string{"cf"}, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selected, skipped := filterDeployments(names, tt.include, tt.exclude)
+			if len(selected) != len(tt.expected) {
+				t.Fatalf("Expected selected %v, got %v", tt.expected, selected)
+			}
+			for i := range selected {
+				if selected[i] != tt.expected[i] {
+					t.Errorf("Expected selected %v, got %v", tt.expected, selected)
+					break
+				}
+			}
+			if len(selected)+len(skipped) != len(names) {
+				t.Errorf("Expected every deployment to be selected or skipped, got %d + %d", len(selected), len(skipped))
+			}
+		})
+	}
+}
+
 // Security Tests - Issue #70: SSH Private Key Path Traversal Vulnerability
 
 func TestValidateSSHKeyPath_RejectsPathTraversal(t *testing.T) {
@@ -242,3 +720,28 @@ func TestCreateSOCKS5DialContextFunc_RejectsTraversalInProxy(t *testing.T) {
 		t.Error("createSOCKS5DialContextFunc should return nil for path traversal in private-key")
 	}
 }
+
+func TestBOSHClient_Ping(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/info" {
+			t.Errorf("Expected only /info to be requested, got %s", r.URL.Path)
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	client, err := NewBOSHClient(server.URL, "ops_manager", "secret", "", "cf-test", false)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	if err := client.Ping(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	status = http.StatusInternalServerError
+	if err := client.Ping(context.Background()); err == nil || !strings.Contains(err.Error(), "status 500") {
+		t.Errorf("Expected status 500 error, got %v", err)
+	}
+}
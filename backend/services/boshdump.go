@@ -0,0 +1,126 @@
+// ABOUTME: Parser for offline `bosh vms --json` output
+// ABOUTME: Maps the bosh-cli table rows, with optional --vitals columns, to Diego cell metrics
+
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/markalston/diego-capacity-analyzer/backend/models"
+	"github.com/markalston/diego-capacity-analyzer/backend/units"
+)
+
+// ErrNoDiegoCellsInDump is returned when a dump parses but contains no Diego
+// cell instances, e.g. a dump of a deployment without diego_cell jobs
+var ErrNoDiegoCellsInDump = errors.New("no Diego cells found in BOSH VMs dump")
+
+// boshCLIOutput is the envelope bosh-cli writes with --json
+type boshCLIOutput struct {
+	Tables []struct {
+		Rows []boshVMDumpRow `json:"Rows"`
+	} `json:"Tables"`
+}
+
+// boshVMDumpRow holds the columns of a `bosh vms --json` row that map to
+// DiegoCell. Every value is a display string; the vitals columns are present
+// only with --vitals.
+type boshVMDumpRow struct {
+	Instance            string `json:"instance"`             // job_name/instance-id
+	CPUSys              string `json:"cpu_sys"`              // "1.2%"
+	MemoryUsage         string `json:"memory_usage"`         // "45% (7.1 GB)"
+	EphemeralDiskUsage  string `json:"ephemeral_disk_usage"` // "12% (3i%)"
+	PersistentDiskUsage string `json:"persistent_disk_usage"`
+}
+
+// ParseBOSHVMsDump reads the output of `bosh vms --json`, optionally with
+// --vitals, and returns the Diego cells it lists. Without --vitals the cells
+// carry names and IDs but zero memory, CPU, and disk metrics, and are marked
+// VitalsUnavailable. Cells are not in
+// an isolation segment unless their job is isolated_diego_cell, since the dump
+// does not record which deployment each table came from. Rows are Diego cells
+// when their instance group is in cellJobNames, or with an empty list when it
+// matches the built-in diego_cell, compute, and *diego_cell* names.
+func ParseBOSHVMsDump(r io.Reader, cellJobNames []string) ([]models.DiegoCell, error) {
+	var out boshCLIOutput
+	if err := json.NewDecoder(r).Decode(&out); err != nil {
+		return nil, fmt.Errorf("invalid bosh vms JSON: %w", err)
+	}
+	if len(out.Tables) == 0 {
+		return nil, errors.New("invalid bosh vms JSON: no tables (run `bosh vms --json`)")
+	}
+
+	var cells []models.DiegoCell
+	for _, table := range out.Tables {
+		for _, row := range table.Rows {
+			jobName, instanceID, _ := strings.Cut(row.Instance, "/")
+			if !matchesCellJob(cellJobNames, jobName) {
+				continue
+			}
+			cell := diegoCellFromVM(row.toBOSHVM(jobName, instanceID), "default")
+			// The dump has no instance index, so keep bosh-cli's job/id name
+			cell.Name = row.Instance
+			cells = append(cells, cell)
+		}
+	}
+
+	if len(cells) == 0 {
+		return nil, ErrNoDiegoCellsInDump
+	}
+	return cells, nil
+}
+
+// toBOSHVM converts the row's display strings into the vitals shape the
+// Director API returns, so dump and live cells share one conversion
+func (row boshVMDumpRow) toBOSHVM(jobName, instanceID string) boshVM {
+	vm := boshVM{JobName: jobName, ID: instanceID}
+
+	// "45% (7.1 GB)" is percent used and bytes used; total memory is derived
+	memPercent := parseIntOrZero(row.MemoryUsage)
+	if _, used, ok := strings.Cut(row.MemoryUsage, "("); ok && memPercent > 0 {
+		usedBytes := parseByteSize(strings.TrimSuffix(used, ")"))
+		vm.Vitals.Mem.KB = fmt.Sprintf("%d", units.BytesToKiB(usedBytes*100/int64(memPercent)))
+	}
+	// Without --vitals the column is empty and the cell is VitalsUnavailable
+	if strings.TrimSpace(row.MemoryUsage) != "" {
+		vm.Vitals.Mem.Percent = fmt.Sprintf("%d", memPercent)
+	}
+
+	vm.Vitals.CPU.Sys = strings.TrimSuffix(row.CPUSys, "%")
+	vm.Vitals.Disk.Ephemeral.Percent = row.EphemeralDiskUsage
+	vm.Vitals.Disk.Persistent.Percent = row.PersistentDiskUsage
+	return vm
+}
+
+// byteSizeUnits maps bosh-cli size suffixes to bytes. bosh-cli prints SI
+// units ("7.1 GB"); binary suffixes are accepted in case a dump was edited.
+var byteSizeUnits = map[string]float64{
+	"B":   1,
+	"kB":  1e3,
+	"KB":  1e3,
+	"MB":  1e6,
+	"GB":  1e9,
+	"TB":  1e12,
+	"KiB": 1 << 10,
+	"MiB": 1 << 20,
+	"GiB": 1 << 30,
+	"TiB": 1 << 40,
+}
+
+// parseByteSize parses a human-readable size such as "7.1 GB", returning 0
+// when the value or unit is not recognized
+func parseByteSize(s string) int64 {
+	var value float64
+	var unit string
+	if n, _ := fmt.Sscanf(strings.TrimSpace(s), "%f %s", &value, &unit); n != 2 {
+		return 0
+	}
+	multiplier, ok := byteSizeUnits[unit]
+	if !ok {
+		return 0
+	}
+	return int64(value * multiplier)
+}
@@ -0,0 +1,137 @@
+// ABOUTME: Tests for parsing offline `bosh vms --json` output
+// ABOUTME: Covers vitals mapping, dumps without vitals, and malformed input
+
+package services
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseBOSHVMsDump_WithVitals(t *testing.T) {
+	f, err := os.Open("testdata/bosh_vms_vitals.json")
+	if err != nil {
+		t.Fatalf("Failed to open fixture: %v", err)
+	}
+	defer f.Close()
+
+	cells, err := ParseBOSHVMsDump(f, nil)
+	if err != nil {
+		t.Fatalf("ParseBOSHVMsDump failed: %v", err)
+	}
+	if len(cells) != 2 {
+		t.Fatalf("Expected 2 Diego cells (router skipped), got %d", len(cells))
+	}
+
+	cell := cells[0]
+	if cell.Name != "diego_cell/0a1b2c3d-1111-4e5f-8a9b-000000000001" || cell.ID != "0a1b2c3d-1111-4e5f-8a9b-000000000001" {
+		t.Errorf("Unexpected cell identity: name %q, id %q", cell.Name, cell.ID)
+	}
+	// 8.6 GB used at 50% is 17.2 GB total, about 16403 MiB
+	if cell.MemoryMB < 16300 || cell.MemoryMB > 16500 {
+		t.Errorf("MemoryMB = %d, want about 16403", cell.MemoryMB)
+	}
+	if cell.UsedMB != cell.MemoryMB/2 {
+		t.Errorf("UsedMB = %d, want half of %d", cell.UsedMB, cell.MemoryMB)
+	}
+	if cell.CPUPercent != 4 || cell.EphemeralDiskPercent != 64 || cell.DiskPercent != 0 {
+		t.Errorf("Vitals = cpu %d%%, ephemeral %d%%, persistent %d%%, want 4/64/0",
+			cell.CPUPercent, cell.EphemeralDiskPercent, cell.DiskPercent)
+	}
+	if cell.IsolationSegment != "default" {
+		t.Errorf("IsolationSegment = %q, want default", cell.IsolationSegment)
+	}
+
+	if cell.VitalsUnavailable {
+		t.Error("Cell with vitals should not be marked VitalsUnavailable")
+	}
+
+	if cells[1].IsolationSegment != "isolated" {
+		t.Errorf("isolated_diego_cell segment = %q, want isolated", cells[1].IsolationSegment)
+	}
+}
+
+func TestParseBOSHVMsDump_WithoutVitals(t *testing.T) {
+	dump := `{"Tables":[{"Content":"vms","Rows":[
+		{"instance":"diego_cell/aaa","process_state":"running","az":"z1","ips":"10.0.16.21","vm_cid":"vm-1","vm_type":"large","active":"true"},
+		{"instance":"compute/bbb","process_state":"running","az":"z1","ips":"10.0.16.22","vm_cid":"vm-2","vm_type":"large","active":"true"}
+	]}]}`
+
+	cells, err := ParseBOSHVMsDump(strings.NewReader(dump), nil)
+	if err != nil {
+		t.Fatalf("ParseBOSHVMsDump failed: %v", err)
+	}
+	if len(cells) != 2 {
+		t.Fatalf("Expected 2 cells, got %d", len(cells))
+	}
+	for _, cell := range cells {
+		if cell.MemoryMB != 0 || cell.CPUPercent != 0 || cell.EphemeralDiskPercent != 0 {
+			t.Errorf("Expected zero metrics without vitals, got %+v", cell)
+		}
+		if !cell.VitalsUnavailable {
+			t.Errorf("Expected %s to be marked VitalsUnavailable", cell.Name)
+		}
+	}
+}
+
+func TestParseBOSHVMsDump_CellJobNames(t *testing.T) {
+	dump := `{"Tables":[{"Content":"vms","Rows":[
+		{"instance":"diego_cell/aaa","process_state":"running"},
+		{"instance":"app_runner/bbb","process_state":"running"}
+	]}]}`
+
+	// BOSH_CELL_JOB_NAMES replaces the built-in names, as in live discovery
+	cells, err := ParseBOSHVMsDump(strings.NewReader(dump), []string{"app_runner"})
+	if err != nil {
+		t.Fatalf("ParseBOSHVMsDump failed: %v", err)
+	}
+	if len(cells) != 1 || cells[0].Name != "app_runner/bbb" {
+		t.Errorf("Expected only the app_runner cell, got %+v", cells)
+	}
+}
+
+func TestParseBOSHVMsDump_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		dump    string
+		wantErr string
+	}{
+		{"not JSON", "Instance  Process State", "invalid bosh vms JSON"},
+		{"no tables", `{"Lines":["Succeeded"]}`, "no tables"},
+		{"no Diego cells", `{"Tables":[{"Rows":[{"instance":"router/aaa"}]}]}`, ErrNoDiegoCellsInDump.Error()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseBOSHVMsDump(strings.NewReader(tt.dump), nil)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	if _, err := ParseBOSHVMsDump(strings.NewReader(`{"Tables":[{"Rows":[]}]}`), nil); !errors.Is(err, ErrNoDiegoCellsInDump) {
+		t.Errorf("Expected ErrNoDiegoCellsInDump, got %v", err)
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"8.6 GB", 8_600_000_000},
+		{"512 MB", 512_000_000},
+		{"2 GiB", 2 << 30},
+		{"0 B", 0},
+		{"lots", 0},
+		{"3 parsecs", 0},
+	}
+	for _, tt := range tests {
+		if got := parseByteSize(tt.in); got != tt.want {
+			t.Errorf("parseByteSize(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
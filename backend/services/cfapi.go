@@ -17,6 +17,7 @@ import (
 	"time"
 
 	"github.com/markalston/diego-capacity-analyzer/backend/models"
+	"github.com/markalston/diego-capacity-analyzer/backend/units"
 )
 
 type CFClient struct {
@@ -44,6 +45,15 @@ func NewCFClient(apiURL, username, password string, skipSSLValidation bool) *CFC
 	}
 }
 
+// NewCFClientWithToken creates a CF client that calls the API with an existing
+// access token, such as a user's session token, instead of authenticating
+// with username and password
+func NewCFClientWithToken(apiURL, token string, skipSSLValidation bool) *CFClient {
+	c := NewCFClient(apiURL, "", "", skipSSLValidation)
+	c.token = token
+	return c
+}
+
 func (c *CFClient) Authenticate(ctx context.Context) error {
 	// Get UAA URL from CF API info
 	infoReq, err := http.NewRequestWithContext(ctx, "GET", c.apiURL+"/v3/info", nil)
@@ -117,6 +127,24 @@ func (c *CFClient) Authenticate(ctx context.Context) error {
 	return nil
 }
 
+// Ping checks that the CF API is reachable via the unauthenticated /v3/info endpoint
+func (c *CFClient) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.apiURL+"/v3/info", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create CF info request: %w", err)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to get CF info: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("CF info returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
 // doAuthenticatedRequest performs an HTTP request with the CF API token and caller-provided context
 func (c *CFClient) doAuthenticatedRequest(ctx context.Context, method, path string) (*http.Response, error) {
 	if c.token == "" {
@@ -210,8 +238,7 @@ func (c *CFClient) GetApps(ctx context.Context) ([]models.App, error) {
 					}
 					slog.Debug("Log Cache unavailable for app, using requested memory", "app_guid", resource.GUID, "error", err)
 				} else if metrics.MemoryBytesAvg > 0 {
-					// Convert bytes to MB
-					totalActualMB = int(metrics.MemoryBytesAvg / (1024 * 1024))
+					totalActualMB = int(units.BytesToMiB(metrics.MemoryBytesAvg))
 					// Multiply by instances if we got per-instance average
 					if metrics.InstanceCount > 0 && metrics.InstanceCount < totalInstances {
 						totalActualMB = totalActualMB * totalInstances / metrics.InstanceCount
@@ -259,6 +286,122 @@ func (c *CFClient) GetApps(ctx context.Context) ([]models.App, error) {
 	return apps, nil
 }
 
+// cfPageSize is the largest per_page the CF v3 API accepts
+const cfPageSize = 5000
+
+// AppAllocation totals the memory, disk, and instances allocated to started apps
+type AppAllocation struct {
+	AppCount            int
+	Instances           int
+	MemoryMB            int // sum of instances x memory_in_mb across processes
+	DiskMB              int // sum of instances x disk_in_mb across processes
+	MaxInstanceMemoryMB int // largest per-instance memory limit of any running process
+}
+
+// GetAppAllocation sums per-process allocation for started apps using two
+// paginated listings, /v3/apps and /v3/processes, rather than per-app lookups.
+// Processes of stopped apps are excluded since they hold no cell capacity.
+func (c *CFClient) GetAppAllocation(ctx context.Context) (AppAllocation, error) {
+	start := time.Now()
+	var alloc AppAllocation
+
+	started := make(map[string]bool)
+	err := c.forEachPage(ctx, fmt.Sprintf("/v3/apps?states=STARTED&per_page=%d", cfPageSize), func(resources json.RawMessage) error {
+		var apps []struct {
+			GUID string `json:"guid"`
+		}
+		if err := json.Unmarshal(resources, &apps); err != nil {
+			return fmt.Errorf("failed to parse apps response: %w", err)
+		}
+		for _, app := range apps {
+			started[app.GUID] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return AppAllocation{}, err
+	}
+	alloc.AppCount = len(started)
+
+	err = c.forEachPage(ctx, fmt.Sprintf("/v3/processes?per_page=%d", cfPageSize), func(resources json.RawMessage) error {
+		var processes []struct {
+			Instances     int `json:"instances"`
+			MemoryMB      int `json:"memory_in_mb"`
+			DiskMB        int `json:"disk_in_mb"`
+			Relationships struct {
+				App struct {
+					Data struct {
+						GUID string `json:"guid"`
+					} `json:"data"`
+				} `json:"app"`
+			} `json:"relationships"`
+		}
+		if err := json.Unmarshal(resources, &processes); err != nil {
+			return fmt.Errorf("failed to parse processes response: %w", err)
+		}
+		for _, proc := range processes {
+			if !started[proc.Relationships.App.Data.GUID] || proc.Instances == 0 {
+				continue
+			}
+			alloc.Instances += proc.Instances
+			alloc.MemoryMB += proc.Instances * proc.MemoryMB
+			alloc.DiskMB += proc.Instances * proc.DiskMB
+			alloc.MaxInstanceMemoryMB = max(alloc.MaxInstanceMemoryMB, proc.MemoryMB)
+		}
+		return nil
+	})
+	if err != nil {
+		return AppAllocation{}, err
+	}
+
+	slog.InfoContext(ctx, "CF API GetAppAllocation completed",
+		"app_count", alloc.AppCount,
+		"instances", alloc.Instances,
+		"duration_ms", time.Since(start).Milliseconds())
+	return alloc, nil
+}
+
+// forEachPage requests path and every following pagination.next page,
+// passing each page's resources array to fn
+func (c *CFClient) forEachPage(ctx context.Context, path string, fn func(resources json.RawMessage) error) error {
+	for path != "" {
+		resp, err := c.doAuthenticatedRequest(ctx, "GET", path)
+		if err != nil {
+			return err
+		}
+
+		var page struct {
+			Resources  json.RawMessage `json:"resources"`
+			Pagination struct {
+				Next struct {
+					Href string `json:"href"`
+				} `json:"next"`
+			} `json:"pagination"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("failed to parse CF API page %s: %w", path, err)
+		}
+		if len(page.Resources) > 0 {
+			if err := fn(page.Resources); err != nil {
+				return err
+			}
+		}
+
+		path = ""
+		if page.Pagination.Next.Href != "" {
+			// Extract path from full URL
+			parsedURL, err := url.Parse(page.Pagination.Next.Href)
+			if err != nil {
+				return fmt.Errorf("failed to parse next page URL: %w", err)
+			}
+			path = parsedURL.Path + "?" + parsedURL.RawQuery
+		}
+	}
+	return nil
+}
+
 // getAppProcesses fetches process information for an app
 func (c *CFClient) getAppProcesses(ctx context.Context, appGUID string) ([]struct {
 	Type      string `json:"type"`
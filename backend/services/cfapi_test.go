@@ -422,3 +422,91 @@ func TestCFClient_Authenticate_ContextTimeout(t *testing.T) {
 		t.Errorf("Expected context.DeadlineExceeded error, got: %v", err)
 	}
 }
+
+func TestCFClient_Ping(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v3/info" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("Authorization") != "" {
+			t.Error("Expected Ping to be unauthenticated")
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	client := NewCFClient(server.URL, "admin", "secret", true)
+
+	if err := client.Ping(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	status = http.StatusServiceUnavailable
+	err := client.Ping(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "status 503") {
+		t.Errorf("Expected status 503 error, got %v", err)
+	}
+}
+
+func TestCFClient_GetAppAllocation(t *testing.T) {
+	var serverURL string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer session-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case r.URL.Path == "/v3/apps" && r.URL.Query().Get("states") != "STARTED":
+			t.Errorf("Expected apps to be filtered to STARTED, got query %q", r.URL.RawQuery)
+			w.WriteHeader(http.StatusBadRequest)
+		case r.URL.Path == "/v3/apps" && r.URL.Query().Get("page") == "2":
+			w.Write([]byte(`{"resources":[{"guid":"app-2"}],"pagination":{"next":null}}`))
+		case r.URL.Path == "/v3/apps":
+			w.Write([]byte(`{"resources":[{"guid":"app-1"}],"pagination":{"next":{"href":"` + serverURL + `/v3/apps?states=STARTED&page=2"}}}`))
+		case r.URL.Path == "/v3/processes" && r.URL.Query().Get("page") == "2":
+			// app-3 is stopped, so its process is excluded
+			w.Write([]byte(`{"resources":[
+				{"instances":1,"memory_in_mb":4096,"disk_in_mb":2048,"relationships":{"app":{"data":{"guid":"app-2"}}}},
+				{"instances":5,"memory_in_mb":8192,"disk_in_mb":1024,"relationships":{"app":{"data":{"guid":"app-3"}}}}
+			],"pagination":{"next":null}}`))
+		case r.URL.Path == "/v3/processes":
+			w.Write([]byte(`{"resources":[
+				{"instances":3,"memory_in_mb":1024,"disk_in_mb":1024,"relationships":{"app":{"data":{"guid":"app-1"}}}},
+				{"instances":0,"memory_in_mb":2048,"disk_in_mb":1024,"relationships":{"app":{"data":{"guid":"app-1"}}}}
+			],"pagination":{"next":{"href":"` + serverURL + `/v3/processes?page=2"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	serverURL = server.URL
+
+	client := NewCFClientWithToken(server.URL, "session-token", false)
+	alloc, err := client.GetAppAllocation(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	want := AppAllocation{AppCount: 2, Instances: 4, MemoryMB: 3*1024 + 4096, DiskMB: 3*1024 + 2048, MaxInstanceMemoryMB: 4096}
+	if alloc != want {
+		t.Errorf("GetAppAllocation = %+v, want %+v", alloc, want)
+	}
+}
+
+func TestCFClient_GetAppAllocation_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"errors":[{"title":"CF-InvalidAuthToken"}]}`))
+	}))
+	defer server.Close()
+
+	client := NewCFClientWithToken(server.URL, "expired-token", false)
+	_, err := client.GetAppAllocation(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "status 401") {
+		t.Errorf("Expected status 401 error, got %v", err)
+	}
+}
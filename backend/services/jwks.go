@@ -1,10 +1,13 @@
 // ABOUTME: JWKS (JSON Web Key Set) parsing and JWT verification for CF UAA
-// ABOUTME: Converts UAA's JWKS endpoint response into RSA public keys and verifies JWT signatures
+// ABOUTME: Converts UAA's JWKS endpoint response into RSA/EC public keys and verifies JWT signatures
 
 package services
 
 import (
 	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rsa"
 	"crypto/sha256"
 	"crypto/sha512"
@@ -14,6 +17,7 @@ import (
 	"fmt"
 	"hash"
 	"io"
+	"log/slog"
 	"math/big"
 	"net/http"
 	"strings"
@@ -30,10 +34,13 @@ type jwksResponse struct {
 
 // jwkKey represents a single JSON Web Key in the JWKS response
 type jwkKey struct {
-	Kty string `json:"kty"` // Key type (must be "RSA" for our use)
+	Kty string `json:"kty"` // Key type ("RSA" or "EC"; others are skipped)
 	Kid string `json:"kid"` // Key ID
 	N   string `json:"n"`   // RSA modulus (base64url encoded)
 	E   string `json:"e"`   // RSA exponent (base64url encoded)
+	Crv string `json:"crv"` // EC curve name (e.g., "P-256")
+	X   string `json:"x"`   // EC point x coordinate (base64url encoded)
+	Y   string `json:"y"`   // EC point y coordinate (base64url encoded)
 	Alg string `json:"alg"` // Algorithm (e.g., "RS256")
 	Use string `json:"use"` // Key use (e.g., "sig" for signature)
 }
@@ -41,27 +48,51 @@ type jwkKey struct {
 // ErrUnknownKeyID indicates a JWT references a key ID not present in the JWKS key set.
 var ErrUnknownKeyID = errors.New("unknown key ID")
 
-// parseJWKS parses a JWKS JSON response and returns a map of key ID to RSA public key.
-// Non-RSA keys are silently skipped.
-func parseJWKS(data []byte) (map[string]*rsa.PublicKey, error) {
+// JWKSKey is a verification key from a JWKS. Exactly one of the embedded RSA
+// key or EC is set, matching the JWK's kty.
+type JWKSKey struct {
+	*rsa.PublicKey
+	EC *ecdsa.PublicKey
+}
+
+// publicKey returns whichever key is set, as *rsa.PublicKey or *ecdsa.PublicKey.
+func (k *JWKSKey) publicKey() crypto.PublicKey {
+	if k.EC != nil {
+		return k.EC
+	}
+	return k.PublicKey
+}
+
+// parseJWKS parses a JWKS JSON response and returns a map of key ID to public key.
+// Symmetric (oct) and other key types are silently skipped. An EC key that cannot
+// be used (unsupported curve, point off the curve) is skipped with a warning, so
+// one bad key does not take down verification with the others.
+func parseJWKS(data []byte) (map[string]*JWKSKey, error) {
 	var response jwksResponse
 	if err := json.Unmarshal(data, &response); err != nil {
 		return nil, fmt.Errorf("failed to parse JWKS JSON: %w", err)
 	}
 
-	keys := make(map[string]*rsa.PublicKey)
+	keys := make(map[string]*JWKSKey)
 	for _, jwk := range response.Keys {
-		// Skip non-RSA keys
-		if jwk.Kty != "RSA" {
+		switch jwk.Kty {
+		case "RSA":
+			pubKey, err := parseRSAPublicKey(jwk.N, jwk.E)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse RSA key %s: %w", jwk.Kid, err)
+			}
+			keys[jwk.Kid] = &JWKSKey{PublicKey: pubKey}
+		case "EC":
+			pubKey, err := parseECPublicKey(jwk.Crv, jwk.X, jwk.Y)
+			if err != nil {
+				slog.Warn("Skipping unusable EC key in JWKS", "kid", jwk.Kid, "error", err)
+				continue
+			}
+			keys[jwk.Kid] = &JWKSKey{EC: pubKey}
+		default:
+			// Skip symmetric and unknown key types
 			continue
 		}
-
-		pubKey, err := parseRSAPublicKey(jwk.N, jwk.E)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse RSA key %s: %w", jwk.Kid, err)
-		}
-
-		keys[jwk.Kid] = pubKey
 	}
 
 	return keys, nil
@@ -96,6 +127,51 @@ func parseRSAPublicKey(nB64, eB64 string) (*rsa.PublicKey, error) {
 	}, nil
 }
 
+// ecCurves maps JWK curve names to their curves, used for both parsing and validation
+var ecCurves = map[string]struct {
+	curve elliptic.Curve
+	ecdh  ecdh.Curve
+}{
+	"P-256": {elliptic.P256(), ecdh.P256()},
+	"P-384": {elliptic.P384(), ecdh.P384()},
+	"P-521": {elliptic.P521(), ecdh.P521()},
+}
+
+// parseECPublicKey decodes base64url-encoded curve coordinates into an EC public key.
+// The point is rejected unless it lies on the named curve.
+func parseECPublicKey(crv, xB64, yB64 string) (*ecdsa.PublicKey, error) {
+	curves, ok := ecCurves[crv]
+	if !ok {
+		return nil, fmt.Errorf("unsupported curve %q", crv)
+	}
+
+	xBytes, err := base64.RawURLEncoding.DecodeString(xB64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode x coordinate: %w", err)
+	}
+	yBytes, err := base64.RawURLEncoding.DecodeString(yB64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode y coordinate: %w", err)
+	}
+
+	size := (curves.curve.Params().BitSize + 7) / 8
+	if len(xBytes) != size || len(yBytes) != size {
+		return nil, fmt.Errorf("coordinates must be %d bytes for %s", size, crv)
+	}
+
+	// crypto/ecdh validates that the uncompressed point is on the curve
+	point := append([]byte{4}, append(xBytes, yBytes...)...)
+	if _, err := curves.ecdh.NewPublicKey(point); err != nil {
+		return nil, fmt.Errorf("invalid point for %s: %w", crv, err)
+	}
+
+	return &ecdsa.PublicKey{
+		Curve: curves.curve,
+		X:     new(big.Int).SetBytes(xBytes),
+		Y:     new(big.Int).SetBytes(yBytes),
+	}, nil
+}
+
 // JWTClaims contains the extracted claims from a verified JWT
 type JWTClaims struct {
 	Username string
@@ -112,6 +188,8 @@ type jwtHeaderForVerification struct {
 
 // jwtClaimsForVerification represents the claims portion of a JWT for parsing
 type jwtClaimsForVerification struct {
+	Iss      string   `json:"iss"`
+	Aud      audience `json:"aud"`
 	Sub      string   `json:"sub"`
 	UserName string   `json:"user_name"`
 	UserID   string   `json:"user_id"`
@@ -122,22 +200,75 @@ type jwtClaimsForVerification struct {
 	Scope    []string `json:"scope"`
 }
 
-// supportedAlgorithms defines the only allowed signing algorithms (RS256/RS384/RS512)
-// This prevents algorithm confusion attacks where an attacker might try to use
-// symmetric algorithms (HS256) or "none"
+// audience holds the aud claim, which RFC 7519 allows to be a single string or an array
+type audience []string
+
+// UnmarshalJSON accepts both the string and array forms of the aud claim
+func (a *audience) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*a = nil
+		return nil
+	}
+
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return fmt.Errorf("aud must be a string or array of strings: %w", err)
+	}
+	*a = multiple
+	return nil
+}
+
+// contains reports whether the audience includes the given value
+func (a audience) contains(value string) bool {
+	for _, aud := range a {
+		if aud == value {
+			return true
+		}
+	}
+	return false
+}
+
+// JWTValidationOptions configures optional claim checks applied after signature verification.
+// Empty fields disable the corresponding check.
+type JWTValidationOptions struct {
+	ExpectedIssuer   string // exact match against the iss claim
+	ExpectedAudience string // must appear in the aud claim
+}
+
+// supportedAlgorithms defines the only allowed signing algorithms (RS256/RS384/RS512
+// and ES256/ES384/ES512). This prevents algorithm confusion attacks where an attacker
+// might try to use symmetric algorithms (HS256) or "none"
 var supportedAlgorithms = map[string]struct {
 	hash       func() hash.Hash
 	cryptoHash crypto.Hash
+	curve      string // required EC curve; empty for RSA algorithms
 }{
-	"RS256": {sha256.New, crypto.SHA256},
-	"RS384": {sha512.New384, crypto.SHA384},
-	"RS512": {sha512.New, crypto.SHA512},
+	"RS256": {sha256.New, crypto.SHA256, ""},
+	"RS384": {sha512.New384, crypto.SHA384, ""},
+	"RS512": {sha512.New, crypto.SHA512, ""},
+	"ES256": {sha256.New, crypto.SHA256, "P-256"},
+	"ES384": {sha512.New384, crypto.SHA384, "P-384"},
+	"ES512": {sha512.New, crypto.SHA512, "P-521"},
 }
 
-// verifyJWT verifies a JWT signature and extracts claims.
+// verifyJWT verifies a JWT signature and extracts claims without issuer or audience checks.
+func verifyJWT[K crypto.PublicKey](token string, keys map[string]K) (*JWTClaims, error) {
+	return verifyJWTWithOptions(token, keys, JWTValidationOptions{})
+}
+
+// verifyJWTWithOptions verifies a JWT signature and extracts claims, then applies the
+// issuer and audience checks configured in opts.
 // Security: Signature is verified BEFORE checking expiration to prevent timing attacks.
-// Only RS256/RS384/RS512 algorithms are allowed to prevent algorithm confusion attacks.
-func verifyJWT(token string, keys map[string]*rsa.PublicKey) (*JWTClaims, error) {
+// Only RS256/RS384/RS512 and ES256/ES384/ES512 are allowed to prevent algorithm confusion
+// attacks, and the key type must match the algorithm family. keys may hold RSA, EC, or
+// mixed public keys.
+func verifyJWTWithOptions[K crypto.PublicKey](token string, keys map[string]K, opts JWTValidationOptions) (*JWTClaims, error) {
 	// Split token into parts
 	parts := strings.Split(token, ".")
 	if len(parts) != 3 {
@@ -160,7 +291,7 @@ func verifyJWT(token string, keys map[string]*rsa.PublicKey) (*JWTClaims, error)
 	// Validate algorithm (prevent algorithm confusion attacks)
 	algInfo, ok := supportedAlgorithms[header.Alg]
 	if !ok {
-		return nil, fmt.Errorf("unsupported algorithm %q: only RS256, RS384, RS512 are allowed", header.Alg)
+		return nil, fmt.Errorf("unsupported algorithm %q: only RS256, RS384, RS512, ES256, ES384, ES512 are allowed", header.Alg)
 	}
 
 	// Validate kid is present
@@ -186,8 +317,28 @@ func verifyJWT(token string, keys map[string]*rsa.PublicKey) (*JWTClaims, error)
 	hashFunc.Write([]byte(signingInput))
 	hashed := hashFunc.Sum(nil)
 
-	if err := rsa.VerifyPKCS1v15(publicKey, algInfo.cryptoHash, hashed, signature); err != nil {
-		return nil, fmt.Errorf("invalid JWT signature: %w", err)
+	var pub crypto.PublicKey = publicKey
+	if k, ok := pub.(*JWKSKey); ok {
+		pub = k.publicKey()
+	}
+
+	switch key := pub.(type) {
+	case *rsa.PublicKey:
+		if algInfo.curve != "" {
+			return nil, fmt.Errorf("algorithm %s cannot be used with RSA key %q", header.Alg, header.Kid)
+		}
+		if err := rsa.VerifyPKCS1v15(key, algInfo.cryptoHash, hashed, signature); err != nil {
+			return nil, fmt.Errorf("invalid JWT signature: %w", err)
+		}
+	case *ecdsa.PublicKey:
+		if algInfo.curve == "" || key.Curve.Params().Name != algInfo.curve {
+			return nil, fmt.Errorf("algorithm %s cannot be used with EC key %q", header.Alg, header.Kid)
+		}
+		if err := verifyECDSASignature(key, hashed, signature); err != nil {
+			return nil, fmt.Errorf("invalid JWT signature: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported key type %T for key %q", publicKey, header.Kid)
 	}
 
 	// Decode and parse payload (only after signature is verified)
@@ -213,6 +364,14 @@ func verifyJWT(token string, keys map[string]*rsa.PublicKey) (*JWTClaims, error)
 		return nil, fmt.Errorf("token expired (exp: %d, now: %d)", claims.Exp, now)
 	}
 
+	// Reject tokens minted by a different UAA or for a different client
+	if opts.ExpectedIssuer != "" && claims.Iss != opts.ExpectedIssuer {
+		return nil, fmt.Errorf("unexpected issuer %q: expected %q", claims.Iss, opts.ExpectedIssuer)
+	}
+	if opts.ExpectedAudience != "" && !claims.Aud.contains(opts.ExpectedAudience) {
+		return nil, fmt.Errorf("audience mismatch: %q not in token audience %v", opts.ExpectedAudience, []string(claims.Aud))
+	}
+
 	// Extract username and userID
 	// User tokens have user_name and user_id
 	// Client credentials tokens have client_id and sub
@@ -238,20 +397,48 @@ func verifyJWT(token string, keys map[string]*rsa.PublicKey) (*JWTClaims, error)
 	}, nil
 }
 
+// jwksKeyGracePeriod is how long a key that disappears from the UAA key set is
+// still accepted, so tokens signed just before a rotation continue to verify.
+const jwksKeyGracePeriod = 15 * time.Minute
+
+// verifyECDSASignature checks a JWS ECDSA signature, which is the fixed-width R||S
+// concatenation (RFC 7518 section 3.4) rather than ASN.1 DER.
+func verifyECDSASignature(key *ecdsa.PublicKey, hashed, signature []byte) error {
+	size := (key.Curve.Params().BitSize + 7) / 8
+	if len(signature) != 2*size {
+		return fmt.Errorf("ECDSA signature must be %d bytes, got %d", 2*size, len(signature))
+	}
+
+	r := new(big.Int).SetBytes(signature[:size])
+	sig := new(big.Int).SetBytes(signature[size:])
+	if !ecdsa.Verify(key, hashed, r, sig) {
+		return errors.New("ECDSA verification failed")
+	}
+	return nil
+}
+
 // JWKSClient fetches and caches JWKS keys from a UAA server.
 // Uses singleflight to prevent thundering herd when refreshing keys.
 type JWKSClient struct {
-	uaaURL     string
-	httpClient *http.Client
-	keys       map[string]*rsa.PublicKey
-	mu         sync.RWMutex
-	sfGroup    singleflight.Group
+	uaaURL      string
+	httpClient  *http.Client
+	keys        map[string]*JWKSKey
+	retired     map[string]time.Time // kid -> when it was first missing from the key set
+	gracePeriod time.Duration
+	validation  JWTValidationOptions
+	mu          sync.RWMutex
+	sfGroup     singleflight.Group
+	stop        chan struct{}
+	done        chan struct{}
+	closeOnce   sync.Once
 }
 
 // NewJWKSClient creates a new JWKS client and fetches initial keys.
 // If httpClient is nil, a default client with 30s timeout is used.
+// If refreshInterval is positive, a background goroutine reloads the keys on that
+// interval until Close is called; zero disables background refresh.
 // Returns an error if the initial key fetch fails.
-func NewJWKSClient(uaaURL string, httpClient *http.Client) (*JWKSClient, error) {
+func NewJWKSClient(uaaURL string, httpClient *http.Client, refreshInterval time.Duration) (*JWKSClient, error) {
 	if httpClient == nil {
 		httpClient = &http.Client{
 			Timeout: 30 * time.Second,
@@ -259,9 +446,13 @@ func NewJWKSClient(uaaURL string, httpClient *http.Client) (*JWKSClient, error)
 	}
 
 	client := &JWKSClient{
-		uaaURL:     uaaURL,
-		httpClient: httpClient,
-		keys:       make(map[string]*rsa.PublicKey),
+		uaaURL:      uaaURL,
+		httpClient:  httpClient,
+		keys:        make(map[string]*JWKSKey),
+		retired:     make(map[string]time.Time),
+		gracePeriod: jwksKeyGracePeriod,
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
 	}
 
 	// Fetch initial keys
@@ -269,13 +460,54 @@ func NewJWKSClient(uaaURL string, httpClient *http.Client) (*JWKSClient, error)
 		return nil, fmt.Errorf("failed to fetch initial JWKS: %w", err)
 	}
 
+	if refreshInterval > 0 {
+		go client.refreshLoop(refreshInterval)
+	} else {
+		close(client.done)
+	}
+
 	return client, nil
 }
 
-// GetKey returns the RSA public key for the given key ID.
+// refreshLoop proactively reloads the key set so a newly published key is cached
+// before the first token signed with it arrives.
+func (c *JWKSClient) refreshLoop(interval time.Duration) {
+	defer close(c.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			_, err, _ := c.sfGroup.Do("refresh", func() (interface{}, error) {
+				return nil, c.refresh()
+			})
+			if err != nil {
+				slog.Warn("Background JWKS refresh failed, keeping cached keys", "error", err)
+			}
+		}
+	}
+}
+
+// Close stops the background refresh goroutine, if any, and waits for it to exit.
+// It is safe to call more than once.
+func (c *JWKSClient) Close() {
+	if c.stop == nil {
+		return
+	}
+	c.closeOnce.Do(func() {
+		close(c.stop)
+	})
+	<-c.done
+}
+
+// GetKey returns the public key for the given key ID.
 // If the key is not found, it triggers a refresh and tries again.
 // Returns nil if the key is still not found after refresh.
-func (c *JWKSClient) GetKey(kid string) *rsa.PublicKey {
+func (c *JWKSClient) GetKey(kid string) *JWKSKey {
 	// First check with read lock
 	c.mu.RLock()
 	key, ok := c.keys[kid]
@@ -298,7 +530,7 @@ func (c *JWKSClient) GetKey(kid string) *rsa.PublicKey {
 	return key
 }
 
-// refresh fetches the JWKS from the UAA server and updates the keys map.
+// refresh fetches the JWKS from the UAA server and merges it into the keys map.
 func (c *JWKSClient) refresh() error {
 	url := c.uaaURL + "/token_keys"
 
@@ -323,25 +555,71 @@ func (c *JWKSClient) refresh() error {
 	}
 
 	c.mu.Lock()
-	c.keys = keys
+	c.keys = c.mergeKeys(keys, time.Now())
 	c.mu.Unlock()
 
 	return nil
 }
 
+// mergeKeys combines a freshly fetched key set with the cached one. Keys no longer
+// published are kept until gracePeriod has passed since they were first missing.
+// Callers must hold c.mu for writing.
+func (c *JWKSClient) mergeKeys(fetched map[string]*JWKSKey, now time.Time) map[string]*JWKSKey {
+	if c.retired == nil {
+		c.retired = make(map[string]time.Time)
+	}
+
+	merged := make(map[string]*JWKSKey, len(fetched))
+	for kid, key := range fetched {
+		merged[kid] = key
+		delete(c.retired, kid)
+	}
+
+	for kid, key := range c.keys {
+		if _, ok := fetched[kid]; ok {
+			continue
+		}
+		since, ok := c.retired[kid]
+		if !ok {
+			since = now
+			c.retired[kid] = now
+		}
+		if now.Sub(since) < c.gracePeriod {
+			merged[kid] = key
+			continue
+		}
+		delete(c.retired, kid)
+	}
+
+	return merged
+}
+
+// SetValidationOptions configures the issuer and audience checks applied by VerifyAndParse.
+func (c *JWKSClient) SetValidationOptions(opts JWTValidationOptions) {
+	c.mu.Lock()
+	c.validation = opts
+	c.mu.Unlock()
+}
+
 // ClearKeysForTesting clears all cached keys. This is only for testing purposes
 // to force a refresh on the next verification attempt.
 func (c *JWKSClient) ClearKeysForTesting() {
 	c.mu.Lock()
-	c.keys = make(map[string]*rsa.PublicKey)
+	c.keys = make(map[string]*JWKSKey)
+	c.retired = make(map[string]time.Time)
 	c.mu.Unlock()
 }
 
-// SetKeysForTesting sets the cached keys directly. This is only for testing purposes
-// to avoid requiring a mock HTTP server for every test.
+// SetKeysForTesting sets the cached keys directly to the given RSA keys. This is only
+// for testing purposes to avoid requiring a mock HTTP server for every test.
 func (c *JWKSClient) SetKeysForTesting(keys map[string]*rsa.PublicKey) {
+	converted := make(map[string]*JWKSKey, len(keys))
+	for kid, key := range keys {
+		converted[kid] = &JWKSKey{PublicKey: key}
+	}
+
 	c.mu.Lock()
-	c.keys = keys
+	c.keys = converted
 	c.mu.Unlock()
 }
 
@@ -350,7 +628,7 @@ func (c *JWKSClient) SetKeysForTesting(keys map[string]*rsa.PublicKey) {
 func (c *JWKSClient) VerifyAndParse(token string) (*JWTClaims, error) {
 	// Verify with read lock held to avoid map copy overhead
 	c.mu.RLock()
-	claims, err := verifyJWT(token, c.keys)
+	claims, err := verifyJWTWithOptions(token, c.keys, c.validation)
 	c.mu.RUnlock()
 
 	if err != nil {
@@ -362,7 +640,7 @@ func (c *JWKSClient) VerifyAndParse(token string) (*JWTClaims, error) {
 
 			// Retry with fresh keys (read lock held during verification)
 			c.mu.RLock()
-			claims, err = verifyJWT(token, c.keys)
+			claims, err = verifyJWTWithOptions(token, c.keys, c.validation)
 			c.mu.RUnlock()
 
 			return claims, err
@@ -1,10 +1,12 @@
 // ABOUTME: Tests for JWKS parsing and JWT verification functionality
-// ABOUTME: Verifies parsing of CF UAA's JWKS JSON into RSA public keys and JWT signature verification
+// ABOUTME: Verifies parsing of CF UAA's JWKS JSON into RSA/EC public keys and JWT signature verification
 
 package services
 
 import (
 	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha256"
@@ -27,6 +29,12 @@ import (
 	"time"
 )
 
+// Test EC P-256 point from RFC 7517 Appendix A.1 (example EC public key)
+const (
+	testECKeyX = "MKBCTNIcKUSDii11ySs3526iDZ8AiTo7Tu6KPAqv7D4"
+	testECKeyY = "4Etl6SRW2YiLUrN5vfvVHuhp7x8PxltmWWlbbM4IFyM"
+)
+
 // Test key modulus from RFC 7517 Appendix A.1 (example RSA public key)
 const testKeyModulus = "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw"
 
@@ -160,6 +168,84 @@ func TestParseJWKS_SkipsNonRSAKeys(t *testing.T) {
 	}
 }
 
+func TestParseJWKS_ECKeys(t *testing.T) {
+	jwksJSON := `{
+		"keys": [
+			{
+				"kty": "RSA",
+				"kid": "rsa-key",
+				"n": "` + testKeyModulus + `",
+				"e": "AQAB",
+				"alg": "RS256",
+				"use": "sig"
+			},
+			{
+				"kty": "EC",
+				"kid": "ec-key",
+				"crv": "P-256",
+				"x": "` + testECKeyX + `",
+				"y": "` + testECKeyY + `",
+				"alg": "ES256",
+				"use": "sig"
+			}
+		]
+	}`
+
+	keys, err := parseJWKS([]byte(jwksJSON))
+	if err != nil {
+		t.Fatalf("parseJWKS returned error: %v", err)
+	}
+
+	if len(keys) != 2 {
+		t.Fatalf("expected 2 keys (RSA and EC), got %d", len(keys))
+	}
+
+	if key := keys["rsa-key"]; key == nil || key.PublicKey == nil || key.EC != nil {
+		t.Errorf("expected only an RSA key for 'rsa-key', got %+v", key)
+	}
+
+	key := keys["ec-key"]
+	if key == nil || key.EC == nil || key.PublicKey != nil {
+		t.Fatalf("expected only an EC key for 'ec-key', got %+v", key)
+	}
+	if key.EC.Curve != elliptic.P256() {
+		t.Errorf("expected P-256 curve, got %s", key.EC.Curve.Params().Name)
+	}
+}
+
+func TestParseJWKS_SkipsInvalidECKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		crv  string
+		x    string
+		y    string
+	}{
+		{"unsupported curve", "secp256k1", testECKeyX, testECKeyY},
+		{"point not on curve", "P-256", testECKeyX, testECKeyX},
+		{"wrong coordinate size", "P-384", testECKeyX, testECKeyY},
+		{"invalid base64", "P-256", "!!!invalid!!!", testECKeyY},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jwksJSON := `{"keys":[
+				{"kty":"EC","kid":"ec-key","crv":"` + tt.crv + `","x":"` + tt.x + `","y":"` + tt.y + `"},
+				{"kty":"RSA","kid":"rsa-key","n":"` + testKeyModulus + `","e":"AQAB"}
+			]}`
+			keys, err := parseJWKS([]byte(jwksJSON))
+			if err != nil {
+				t.Fatalf("expected the invalid EC key to be skipped, got error: %v", err)
+			}
+			if _, ok := keys["ec-key"]; ok {
+				t.Error("expected the invalid EC key to be skipped")
+			}
+			if _, ok := keys["rsa-key"]; !ok {
+				t.Error("expected the RSA key to survive an invalid EC key")
+			}
+		})
+	}
+}
+
 func TestParseJWKS_InvalidJSON(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -327,6 +413,7 @@ type jwtPayload struct {
 	Nbf      int64  `json:"nbf,omitempty"`
 	Iat      int64  `json:"iat,omitempty"`
 	Iss      string `json:"iss,omitempty"`
+	Aud      any    `json:"aud,omitempty"`
 }
 
 // createTestJWT creates a signed JWT for testing
@@ -790,6 +877,181 @@ func TestVerifyJWT_MissingIdentityClaims(t *testing.T) {
 // JWKSClient Tests
 // -----------------------------------------------------------------------------
 
+func TestVerifyJWTWithOptions_IssuerAndAudience(t *testing.T) {
+	privateKey := loadTestPrivateKey(t)
+	keys := map[string]*rsa.PublicKey{
+		"test-key-1": loadTestPublicKey(t),
+	}
+
+	const issuer = "https://uaa.sys.example.com/oauth/token"
+
+	tests := []struct {
+		name    string
+		iss     string
+		aud     any
+		opts    JWTValidationOptions
+		wantErr string
+	}{
+		{"no expectations accepts any claims", "https://other.example.com/oauth/token", "other", JWTValidationOptions{}, ""},
+		{"matching issuer", issuer, nil, JWTValidationOptions{ExpectedIssuer: issuer}, ""},
+		{"wrong issuer", "https://other.example.com/oauth/token", nil, JWTValidationOptions{ExpectedIssuer: issuer}, "unexpected issuer"},
+		{"missing issuer", "", nil, JWTValidationOptions{ExpectedIssuer: issuer}, "unexpected issuer"},
+		{"audience string matches", issuer, "diego-analyzer", JWTValidationOptions{ExpectedAudience: "diego-analyzer"}, ""},
+		{"audience array contains", issuer, []string{"cf", "diego-analyzer"}, JWTValidationOptions{ExpectedAudience: "diego-analyzer"}, ""},
+		{"audience array missing value", issuer, []string{"cf", "openid"}, JWTValidationOptions{ExpectedAudience: "diego-analyzer"}, "audience mismatch"},
+		{"audience absent", issuer, nil, JWTValidationOptions{ExpectedAudience: "diego-analyzer"}, "audience mismatch"},
+		{"both match", issuer, []string{"diego-analyzer"}, JWTValidationOptions{ExpectedIssuer: issuer, ExpectedAudience: "diego-analyzer"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := createTestJWT(t, privateKey, "test-key-1", "RS256", jwtPayload{
+				UserName: "testuser",
+				UserID:   "user-123",
+				Exp:      time.Now().Add(1 * time.Hour).Unix(),
+				Iss:      tt.iss,
+				Aud:      tt.aud,
+			})
+
+			_, err := verifyJWTWithOptions(token, keys, tt.opts)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// createTestECJWT creates a JWT signed with an EC private key, encoding the
+// signature as fixed-width R||S per RFC 7518
+func createTestECJWT(t *testing.T, privateKey *ecdsa.PrivateKey, kid string, alg string, claims jwtPayload) string {
+	t.Helper()
+
+	headerJSON, err := json.Marshal(jwtHeader{Alg: alg, Typ: "JWT", Kid: kid})
+	if err != nil {
+		t.Fatalf("failed to marshal header: %v", err)
+	}
+	payloadJSON, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatalf("failed to marshal payload: %v", err)
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(payloadJSON)
+
+	var hashFunc hash.Hash
+	switch alg {
+	case "ES256":
+		hashFunc = sha256.New()
+	case "ES384":
+		hashFunc = sha512.New384()
+	case "ES512":
+		hashFunc = sha512.New()
+	default:
+		t.Fatalf("unsupported algorithm for test: %s", alg)
+	}
+	hashFunc.Write([]byte(signingInput))
+
+	r, sig, err := ecdsa.Sign(rand.Reader, privateKey, hashFunc.Sum(nil))
+	if err != nil {
+		t.Fatalf("failed to sign JWT: %v", err)
+	}
+
+	size := (privateKey.Curve.Params().BitSize + 7) / 8
+	signature := make([]byte, 2*size)
+	r.FillBytes(signature[:size])
+	sig.FillBytes(signature[size:])
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func generateTestECKey(t *testing.T, curve elliptic.Curve) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate EC key: %v", err)
+	}
+	return key
+}
+
+func TestVerifyJWT_ECAlgorithms(t *testing.T) {
+	tests := []struct {
+		alg   string
+		curve elliptic.Curve
+	}{
+		{"ES256", elliptic.P256()},
+		{"ES384", elliptic.P384()},
+		{"ES512", elliptic.P521()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.alg, func(t *testing.T) {
+			privateKey := generateTestECKey(t, tt.curve)
+			keys := map[string]crypto.PublicKey{
+				"ec-key": &privateKey.PublicKey,
+			}
+
+			token := createTestECJWT(t, privateKey, "ec-key", tt.alg, jwtPayload{
+				UserName: "testuser",
+				UserID:   "user-123",
+				Exp:      time.Now().Add(1 * time.Hour).Unix(),
+			})
+
+			result, err := verifyJWT(token, keys)
+			if err != nil {
+				t.Fatalf("verifyJWT returned error: %v", err)
+			}
+			if result.Username != "testuser" {
+				t.Errorf("expected username 'testuser', got %q", result.Username)
+			}
+		})
+	}
+}
+
+func TestVerifyJWT_ECRejections(t *testing.T) {
+	ecKey := generateTestECKey(t, elliptic.P256())
+	otherECKey := generateTestECKey(t, elliptic.P256())
+	rsaPrivateKey := loadTestPrivateKey(t)
+	claims := jwtPayload{UserName: "testuser", Exp: time.Now().Add(1 * time.Hour).Unix()}
+
+	keys := map[string]crypto.PublicKey{
+		"ec-key":  &ecKey.PublicKey,
+		"rsa-key": &rsaPrivateKey.PublicKey,
+	}
+
+	esToken := createTestECJWT(t, ecKey, "ec-key", "ES256", claims)
+	parts := strings.Split(esToken, ".")
+	truncated := parts[0] + "." + parts[1] + "." + base64.RawURLEncoding.EncodeToString([]byte("short"))
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr string
+	}{
+		{"ES256 token signed by another key", createTestECJWT(t, otherECKey, "ec-key", "ES256", claims), "invalid JWT signature"},
+		{"ES256 header with RSA key", createTestECJWT(t, ecKey, "rsa-key", "ES256", claims), "cannot be used with RSA key"},
+		{"RS256 header with EC key", createTestJWT(t, rsaPrivateKey, "ec-key", "RS256", claims), "cannot be used with EC key"},
+		{"ES384 header with P-256 key", createTestECJWT(t, ecKey, "ec-key", "ES384", claims), "cannot be used with EC key"},
+		{"truncated signature", truncated, "signature must be 64 bytes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := verifyJWT(tt.token, keys)
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
 // createMockUAAServer creates a mock UAA server that returns JWKS responses
 func createMockUAAServer(t *testing.T, publicKey *rsa.PublicKey, keyID string) *httptest.Server {
 	t.Helper()
@@ -840,7 +1102,7 @@ func TestJWKSClient_FetchKeys(t *testing.T) {
 	server := createMockUAAServer(t, publicKey, "test-key-1")
 	defer server.Close()
 
-	client, err := NewJWKSClient(server.URL, nil)
+	client, err := NewJWKSClient(server.URL, nil, 0)
 	if err != nil {
 		t.Fatalf("NewJWKSClient returned error: %v", err)
 	}
@@ -913,7 +1175,7 @@ func TestJWKSClient_RefreshOnUnknownKey(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client, err := NewJWKSClient(server.URL, nil)
+	client, err := NewJWKSClient(server.URL, nil, 0)
 	if err != nil {
 		t.Fatalf("NewJWKSClient returned error: %v", err)
 	}
@@ -1006,7 +1268,7 @@ func TestJWKSClient_ConcurrentRefresh_ThunderingHerd(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client, err := NewJWKSClient(server.URL, nil)
+	client, err := NewJWKSClient(server.URL, nil, 0)
 	if err != nil {
 		t.Fatalf("NewJWKSClient returned error: %v", err)
 	}
@@ -1044,7 +1306,7 @@ func TestJWKSClient_VerifyAndParse(t *testing.T) {
 	server := createMockUAAServer(t, publicKey, "test-key-1")
 	defer server.Close()
 
-	client, err := NewJWKSClient(server.URL, nil)
+	client, err := NewJWKSClient(server.URL, nil, 0)
 	if err != nil {
 		t.Fatalf("NewJWKSClient returned error: %v", err)
 	}
@@ -1122,7 +1384,7 @@ func TestJWKSClient_VerifyAndParse_RefreshOnUnknownKey(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client, err := NewJWKSClient(server.URL, nil)
+	client, err := NewJWKSClient(server.URL, nil, 0)
 	if err != nil {
 		t.Fatalf("NewJWKSClient returned error: %v", err)
 	}
@@ -1161,6 +1423,29 @@ func TestJWKSClient_VerifyAndParse_RefreshOnUnknownKey(t *testing.T) {
 	}
 }
 
+func TestJWKSClient_VerifyAndParse_ValidationOptions(t *testing.T) {
+	privateKey := loadTestPrivateKey(t)
+	publicKey := loadTestPublicKey(t)
+	server := createMockUAAServer(t, publicKey, "test-key-1")
+	defer server.Close()
+
+	client, err := NewJWKSClient(server.URL, nil, 0)
+	if err != nil {
+		t.Fatalf("NewJWKSClient returned error: %v", err)
+	}
+	client.SetValidationOptions(JWTValidationOptions{ExpectedIssuer: "https://uaa.sys.example.com/oauth/token"})
+
+	token := createTestJWT(t, privateKey, "test-key-1", "RS256", jwtPayload{
+		UserName: "testuser",
+		Exp:      time.Now().Add(1 * time.Hour).Unix(),
+		Iss:      "https://uaa.other.example.com/oauth/token",
+	})
+
+	if _, err := client.VerifyAndParse(token); err == nil || !strings.Contains(err.Error(), "unexpected issuer") {
+		t.Errorf("expected unexpected issuer error, got %v", err)
+	}
+}
+
 func TestNewJWKSClient_InitialFetchFails(t *testing.T) {
 	// Server that returns error
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -1168,7 +1453,7 @@ func TestNewJWKSClient_InitialFetchFails(t *testing.T) {
 	}))
 	defer server.Close()
 
-	_, err := NewJWKSClient(server.URL, nil)
+	_, err := NewJWKSClient(server.URL, nil, 0)
 	if err == nil {
 		t.Fatal("expected error when initial fetch fails")
 	}
@@ -1182,8 +1467,148 @@ func TestNewJWKSClient_InvalidJSON(t *testing.T) {
 	}))
 	defer server.Close()
 
-	_, err := NewJWKSClient(server.URL, nil)
+	_, err := NewJWKSClient(server.URL, nil, 0)
 	if err == nil {
 		t.Fatal("expected error when JWKS response is invalid JSON")
 	}
 }
+
+// newRotatingUAAServer serves the test public key under whichever key IDs are
+// currently held in kids, so tests can simulate UAA publishing and retiring keys.
+func newRotatingUAAServer(t *testing.T, publicKey *rsa.PublicKey, kids *atomic.Value, fetches *int32) *httptest.Server {
+	t.Helper()
+	nB64 := base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+	eB64 := base64.RawURLEncoding.EncodeToString([]byte{1, 0, 1})
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(fetches, 1)
+		var keys []map[string]interface{}
+		for _, kid := range kids.Load().([]string) {
+			keys = append(keys, map[string]interface{}{
+				"kty": "RSA", "kid": kid, "n": nB64, "e": eB64, "alg": "RS256", "use": "sig",
+			})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys}); err != nil {
+			t.Errorf("failed to encode JWKS: %v", err)
+		}
+	}))
+}
+
+func TestJWKSClient_BackgroundRefresh(t *testing.T) {
+	publicKey := loadTestPublicKey(t)
+	if publicKey.E != 65537 {
+		t.Fatalf("test key exponent %d, expected 65537", publicKey.E)
+	}
+
+	var kids atomic.Value
+	kids.Store([]string{"key-1"})
+	var fetches int32
+	server := newRotatingUAAServer(t, publicKey, &kids, &fetches)
+	defer server.Close()
+
+	client, err := NewJWKSClient(server.URL, nil, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("NewJWKSClient returned error: %v", err)
+	}
+	defer client.Close()
+
+	// UAA publishes a new key before signing with it
+	kids.Store([]string{"key-1", "key-2"})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		client.mu.RLock()
+		_, ok := client.keys["key-2"]
+		client.mu.RUnlock()
+		if ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("background refresh did not pick up newly published key")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Close stops the loop: no further fetches after it returns
+	client.Close()
+	client.Close()
+	after := atomic.LoadInt32(&fetches)
+	time.Sleep(50 * time.Millisecond)
+	if got := atomic.LoadInt32(&fetches); got != after {
+		t.Errorf("expected no fetches after Close, got %d more", got-after)
+	}
+}
+
+func TestJWKSClient_RetiredKeyGracePeriod(t *testing.T) {
+	privateKey := loadTestPrivateKey(t)
+	publicKey := loadTestPublicKey(t)
+
+	var kids atomic.Value
+	kids.Store([]string{"old-key"})
+	var fetches int32
+	server := newRotatingUAAServer(t, publicKey, &kids, &fetches)
+	defer server.Close()
+
+	client, err := NewJWKSClient(server.URL, nil, 0)
+	if err != nil {
+		t.Fatalf("NewJWKSClient returned error: %v", err)
+	}
+	defer client.Close()
+
+	token := createTestJWT(t, privateKey, "old-key", "RS256", jwtPayload{
+		UserName: "testuser",
+		UserID:   "user-123",
+		Exp:      time.Now().Add(time.Hour).Unix(),
+	})
+
+	// UAA rotates: the old key is no longer published
+	kids.Store([]string{"new-key"})
+	if err := client.refresh(); err != nil {
+		t.Fatalf("refresh returned error: %v", err)
+	}
+
+	if _, err := client.VerifyAndParse(token); err != nil {
+		t.Fatalf("token signed with retired key should verify within grace period: %v", err)
+	}
+	if client.GetKey("new-key") == nil {
+		t.Error("expected newly published key to be cached")
+	}
+
+	// Once the grace period has passed, the retired key is dropped
+	client.mu.Lock()
+	client.retired["old-key"] = time.Now().Add(-2 * client.gracePeriod)
+	client.mu.Unlock()
+	if err := client.refresh(); err != nil {
+		t.Fatalf("refresh returned error: %v", err)
+	}
+
+	_, err = client.VerifyAndParse(token)
+	if !errors.Is(err, ErrUnknownKeyID) {
+		t.Errorf("expected ErrUnknownKeyID after grace period, got %v", err)
+	}
+}
+
+func TestJWKSClient_CloseWithoutBackgroundRefresh(t *testing.T) {
+	publicKey := loadTestPublicKey(t)
+	server := createMockUAAServer(t, publicKey, "test-key-1")
+	defer server.Close()
+
+	client, err := NewJWKSClient(server.URL, nil, 0)
+	if err != nil {
+		t.Fatalf("NewJWKSClient returned error: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		client.Close()
+		(&JWKSClient{}).Close()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Close blocked with background refresh disabled")
+	}
+}
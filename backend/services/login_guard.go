@@ -0,0 +1,112 @@
+// ABOUTME: Failed login tracking with temporary lockout
+// ABOUTME: Counts failures per username and per client IP in the cache and locks a key out once it hits its limit
+
+package services
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/markalston/diego-capacity-analyzer/backend/cache"
+)
+
+// LoginGuard tracks failed logins and locks out a username or client IP that
+// fails too often. A key's failures are forgotten once the lockout window
+// passes without another failure.
+type LoginGuard struct {
+	cache          *cache.Cache
+	maxPerUsername int
+	maxPerIP       int
+	window         time.Duration
+	mu             sync.Mutex // serializes read-modify-write of counters
+}
+
+// loginFailures is the cached counter for one username or IP
+type loginFailures struct {
+	count       int
+	lockedUntil time.Time
+}
+
+// NewLoginGuard creates a guard that locks a username after maxPerUsername
+// failures and a client IP after maxPerIP failures, for window. A limit of 0
+// disables that check.
+func NewLoginGuard(c *cache.Cache, maxPerUsername, maxPerIP int, window time.Duration) *LoginGuard {
+	return &LoginGuard{
+		cache:          c,
+		maxPerUsername: maxPerUsername,
+		maxPerIP:       maxPerIP,
+		window:         window,
+	}
+}
+
+// Locked reports how long the username or IP remains locked out, or zero
+// when the login may proceed
+func (g *LoginGuard) Locked(username, ip string) time.Duration {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	var remaining time.Duration
+	for _, key := range []string{usernameFailureKey(username), ipFailureKey(ip)} {
+		if f, ok := g.load(key); ok && f.lockedUntil.After(now) {
+			remaining = max(remaining, f.lockedUntil.Sub(now))
+		}
+	}
+	return remaining
+}
+
+// RecordFailure counts a failed login against the username and IP. It
+// returns which of them the failure locked out.
+func (g *LoginGuard) RecordFailure(username, ip string) (usernameLocked, ipLocked bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	usernameLocked = g.increment(usernameFailureKey(username), g.maxPerUsername)
+	ipLocked = g.increment(ipFailureKey(ip), g.maxPerIP)
+	return usernameLocked, ipLocked
+}
+
+// Reset clears the username's failures after a successful login. The IP's
+// failures are left to expire, so logging in to one account does not reset
+// the count for guesses made against others from the same address.
+func (g *LoginGuard) Reset(username string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.cache.Clear(usernameFailureKey(username))
+}
+
+// increment adds a failure to key and reports whether it reached limit
+func (g *LoginGuard) increment(key string, limit int) bool {
+	if limit <= 0 {
+		return false
+	}
+	f, _ := g.load(key)
+	f.count++
+	locked := f.count >= limit
+	if locked {
+		f.count = 0
+		f.lockedUntil = time.Now().Add(g.window)
+	}
+	g.cache.SetExactTTL(key, f, g.window)
+	return locked
+}
+
+func (g *LoginGuard) load(key string) (loginFailures, bool) {
+	val, ok := g.cache.Get(key)
+	if !ok {
+		return loginFailures{}, false
+	}
+	f, ok := val.(loginFailures)
+	return f, ok
+}
+
+// Usernames are case-insensitive in UAA, so case variations share a counter
+func usernameFailureKey(username string) string {
+	return "login_failures:user:" + strings.ToLower(username)
+}
+
+func ipFailureKey(ip string) string {
+	return "login_failures:ip:" + ip
+}
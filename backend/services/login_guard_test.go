@@ -0,0 +1,99 @@
+// ABOUTME: Tests for failed login tracking and lockout
+// ABOUTME: Verifies per-username and per-IP thresholds, lockout expiry, and reset on success
+
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/markalston/diego-capacity-analyzer/backend/cache"
+)
+
+func TestLoginGuard_LocksUsernameAtThreshold(t *testing.T) {
+	g := NewLoginGuard(cache.New(time.Minute), 3, 100, time.Minute)
+
+	for i := 1; i <= 2; i++ {
+		if userLocked, _ := g.RecordFailure("admin", "10.0.0.1"); userLocked {
+			t.Fatalf("Username locked after %d failures, want 3", i)
+		}
+		if g.Locked("admin", "10.0.0.1") != 0 {
+			t.Fatalf("Locked after %d failures, want 3", i)
+		}
+	}
+
+	if userLocked, ipLocked := g.RecordFailure("admin", "10.0.0.1"); !userLocked || ipLocked {
+		t.Fatalf("RecordFailure = (%v, %v), want username locked only", userLocked, ipLocked)
+	}
+
+	// Locked for the username from any address, not for other usernames
+	remaining := g.Locked("ADMIN", "10.0.0.2")
+	if remaining <= 0 || remaining > time.Minute {
+		t.Errorf("Locked(ADMIN) = %v, want within the 1m window", remaining)
+	}
+	if g.Locked("operator", "10.0.0.1") != 0 {
+		t.Error("Other usernames should not be locked")
+	}
+}
+
+func TestLoginGuard_LocksIPAtThreshold(t *testing.T) {
+	g := NewLoginGuard(cache.New(time.Minute), 100, 3, time.Minute)
+
+	for _, user := range []string{"alice", "bob"} {
+		g.RecordFailure(user, "10.0.0.1")
+	}
+	if _, ipLocked := g.RecordFailure("carol", "10.0.0.1"); !ipLocked {
+		t.Fatal("Expected IP to lock after 3 failures across usernames")
+	}
+
+	if g.Locked("dave", "10.0.0.1") == 0 {
+		t.Error("Expected any username from the locked IP to be refused")
+	}
+	if g.Locked("dave", "10.0.0.2") != 0 {
+		t.Error("Other IPs should not be locked")
+	}
+}
+
+func TestLoginGuard_ResetClearsUsernameOnly(t *testing.T) {
+	g := NewLoginGuard(cache.New(time.Minute), 2, 2, time.Minute)
+
+	g.RecordFailure("admin", "10.0.0.1")
+	g.Reset("admin")
+
+	// One more failure: the username restarts at 1, the IP reaches its limit
+	userLocked, ipLocked := g.RecordFailure("admin", "10.0.0.1")
+	if userLocked {
+		t.Error("Reset should have cleared the username's failures")
+	}
+	if !ipLocked {
+		t.Error("Reset should not clear the IP's failures")
+	}
+}
+
+func TestLoginGuard_LockoutExpires(t *testing.T) {
+	g := NewLoginGuard(cache.New(time.Minute), 1, 0, 50*time.Millisecond)
+
+	g.RecordFailure("admin", "10.0.0.1")
+	if g.Locked("admin", "10.0.0.1") == 0 {
+		t.Fatal("Expected lockout after first failure")
+	}
+
+	time.Sleep(80 * time.Millisecond)
+
+	if remaining := g.Locked("admin", "10.0.0.1"); remaining != 0 {
+		t.Errorf("Locked = %v after the window passed, want 0", remaining)
+	}
+}
+
+func TestLoginGuard_ZeroLimitDisablesCheck(t *testing.T) {
+	g := NewLoginGuard(cache.New(time.Minute), 0, 0, time.Minute)
+
+	for i := 0; i < 50; i++ {
+		if userLocked, ipLocked := g.RecordFailure("admin", "10.0.0.1"); userLocked || ipLocked {
+			t.Fatal("Zero limits should never lock")
+		}
+	}
+	if g.Locked("admin", "10.0.0.1") != 0 {
+		t.Error("Zero limits should never lock")
+	}
+}
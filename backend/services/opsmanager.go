@@ -0,0 +1,84 @@
+// ABOUTME: Parser for Ops Manager product resource configuration
+// ABOUTME: Derives Diego cell counts and VM sizing from om staged-config resource-config and VM types
+
+package services
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/markalston/diego-capacity-analyzer/backend/models"
+	"github.com/markalston/diego-capacity-analyzer/backend/units"
+)
+
+// ParseOpsManagerResourceConfig builds a ManualInput with one cluster per Diego
+// cell instance group (those in cellJobNames, or with an empty list
+// diego_cell, isolated_diego_cell, compute) from a tile's staged config and
+// Ops Manager's VM types.
+// productConfig is the output of: om staged-config -p <product>, as JSON.
+// Host fields are left at zero, since the tile does not describe the vSphere
+// hosts; fill them in before submitting to /api/v1/infrastructure/manual.
+func ParseOpsManagerResourceConfig(productConfig map[string]interface{}, vmTypes []models.OpsManagerVMType, cellJobNames []string) (models.ManualInput, error) {
+	input := models.ManualInput{}
+	if name, ok := productConfig["product-name"].(string); ok {
+		input.Name = name
+	}
+
+	resourceConfig, ok := productConfig["resource-config"].(map[string]interface{})
+	if !ok {
+		return input, fmt.Errorf("missing resource-config")
+	}
+
+	typesByName := make(map[string]models.OpsManagerVMType, len(vmTypes))
+	for _, vt := range vmTypes {
+		typesByName[vt.Name] = vt
+	}
+
+	// Map iteration order is random; sort so clusters come out in a stable order
+	jobs := make([]string, 0, len(resourceConfig))
+	for job := range resourceConfig {
+		if matchesCellJob(cellJobNames, job) {
+			jobs = append(jobs, job)
+		}
+	}
+	sort.Strings(jobs)
+
+	for _, job := range jobs {
+		resource, ok := resourceConfig[job].(map[string]interface{})
+		if !ok {
+			return input, fmt.Errorf("resource-config.%s is not an object", job)
+		}
+
+		instances, ok := resource["instances"].(float64)
+		if !ok {
+			return input, fmt.Errorf("resource-config.%s.instances must be a number, got %v (set an explicit instance count instead of automatic)", job, resource["instances"])
+		}
+		if instances <= 0 {
+			continue
+		}
+
+		instanceType, _ := resource["instance_type"].(map[string]interface{})
+		typeName, _ := instanceType["id"].(string)
+		if typeName == "" || typeName == "automatic" {
+			return input, fmt.Errorf("resource-config.%s.instance_type.id must name a VM type, got %q (set an explicit VM type instead of automatic)", job, typeName)
+		}
+		vmType, ok := typesByName[typeName]
+		if !ok {
+			return input, fmt.Errorf("resource-config.%s uses VM type %q, which is not in vm_types", job, typeName)
+		}
+
+		input.Clusters = append(input.Clusters, models.ClusterInput{
+			Name:              job,
+			DiegoCellCount:    int(instances),
+			DiegoCellMemoryGB: units.MBToGiB(vmType.RAM),
+			DiegoCellCPU:      vmType.CPU,
+			DiegoCellDiskGB:   units.MBToGiB(vmType.EphemeralDisk),
+		})
+	}
+
+	if len(input.Clusters) == 0 {
+		return input, fmt.Errorf("no Diego cell instance groups with instances in resource-config")
+	}
+
+	return input, nil
+}
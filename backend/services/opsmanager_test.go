@@ -0,0 +1,116 @@
+// ABOUTME: Tests for Ops Manager resource-config parsing
+// ABOUTME: Verifies cell instance groups, VM type sizing, and errors for automatic or unknown settings
+
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/markalston/diego-capacity-analyzer/backend/models"
+)
+
+var testOpsManagerVMTypes = []models.OpsManagerVMType{
+	{Name: "micro", RAM: 1024, CPU: 1, EphemeralDisk: 8192},
+	{Name: "xlarge.disk", RAM: 32768, CPU: 4, EphemeralDisk: 131072},
+	{Name: "2xlarge.disk", RAM: 65536, CPU: 8, EphemeralDisk: 262144},
+}
+
+func TestParseOpsManagerResourceConfig(t *testing.T) {
+	productConfig := map[string]interface{}{
+		"product-name": "cf",
+		"resource-config": map[string]interface{}{
+			"router": map[string]interface{}{
+				"instances":     float64(3),
+				"instance_type": map[string]interface{}{"id": "micro"},
+			},
+			"isolated_diego_cell": map[string]interface{}{
+				"instances":     float64(4),
+				"instance_type": map[string]interface{}{"id": "2xlarge.disk"},
+			},
+			"diego_cell": map[string]interface{}{
+				"instances":     float64(12),
+				"instance_type": map[string]interface{}{"id": "xlarge.disk"},
+			},
+			"compute": map[string]interface{}{
+				"instances":     float64(0),
+				"instance_type": map[string]interface{}{"id": "automatic"},
+			},
+		},
+	}
+
+	input, err := ParseOpsManagerResourceConfig(productConfig, testOpsManagerVMTypes, nil)
+	if err != nil {
+		t.Fatalf("ParseOpsManagerResourceConfig failed: %v", err)
+	}
+
+	want := models.ManualInput{
+		Name: "cf",
+		Clusters: []models.ClusterInput{
+			{Name: "diego_cell", DiegoCellCount: 12, DiegoCellMemoryGB: 32, DiegoCellCPU: 4, DiegoCellDiskGB: 128},
+			{Name: "isolated_diego_cell", DiegoCellCount: 4, DiegoCellMemoryGB: 64, DiegoCellCPU: 8, DiegoCellDiskGB: 256},
+		},
+	}
+	if !reflect.DeepEqual(input, want) {
+		t.Errorf("ParseOpsManagerResourceConfig() = %+v, want %+v", input, want)
+	}
+}
+
+func TestParseOpsManagerResourceConfig_CellJobNames(t *testing.T) {
+	productConfig := map[string]interface{}{
+		"resource-config": map[string]interface{}{
+			"diego_cell": map[string]interface{}{
+				"instances":     float64(12),
+				"instance_type": map[string]interface{}{"id": "xlarge.disk"},
+			},
+			"app_runner": map[string]interface{}{
+				"instances":     float64(6),
+				"instance_type": map[string]interface{}{"id": "2xlarge.disk"},
+			},
+		},
+	}
+
+	// BOSH_CELL_JOB_NAMES replaces the built-in names, as in live discovery
+	input, err := ParseOpsManagerResourceConfig(productConfig, testOpsManagerVMTypes, []string{"app_runner"})
+	if err != nil {
+		t.Fatalf("ParseOpsManagerResourceConfig failed: %v", err)
+	}
+	if len(input.Clusters) != 1 || input.Clusters[0].Name != "app_runner" || input.Clusters[0].DiegoCellCount != 6 {
+		t.Errorf("Expected only the app_runner instance group, got %+v", input.Clusters)
+	}
+}
+
+func TestParseOpsManagerResourceConfig_Errors(t *testing.T) {
+	cell := func(instances interface{}, vmType string) map[string]interface{} {
+		return map[string]interface{}{
+			"resource-config": map[string]interface{}{
+				"diego_cell": map[string]interface{}{
+					"instances":     instances,
+					"instance_type": map[string]interface{}{"id": vmType},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		config  map[string]interface{}
+		wantErr string
+	}{
+		{"missing resource-config", map[string]interface{}{"product-name": "cf"}, "missing resource-config"},
+		{"automatic instances", cell("automatic", "xlarge.disk"), "instances must be a number"},
+		{"automatic VM type", cell(float64(3), "automatic"), "instance_type.id must name a VM type"},
+		{"unknown VM type", cell(float64(3), "huge"), `VM type "huge"`},
+		{"no cells", cell(float64(0), "xlarge.disk"), "no Diego cell instance groups"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseOpsManagerResourceConfig(tt.config, testOpsManagerVMTypes, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
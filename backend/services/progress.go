@@ -0,0 +1,31 @@
+// ABOUTME: Progress reporting for long-running infrastructure discovery
+// ABOUTME: Lets callers such as the SSE discovery stream follow each step as it happens
+
+package services
+
+// Discovery stages reported through a ProgressFunc
+const (
+	StageConnecting    = "connecting"
+	StageScanningVMs   = "scanning_vms"
+	StageCellsFound    = "cells_found"
+	StageClustersFound = "clusters_found"
+	StageEnriching     = "enriching"
+)
+
+// DiscoveryProgress describes one step of infrastructure discovery
+type DiscoveryProgress struct {
+	Stage   string `json:"stage"`
+	Message string `json:"message"`
+	Count   int    `json:"count,omitempty"` // clusters, VMs, or cells, depending on the stage
+}
+
+// ProgressFunc receives discovery progress as it happens. A nil ProgressFunc
+// discards progress, so callers that do not stream can pass nil.
+type ProgressFunc func(DiscoveryProgress)
+
+// Report sends a progress event if p is set
+func (p ProgressFunc) Report(stage, message string, count int) {
+	if p != nil {
+		p(DiscoveryProgress{Stage: stage, Message: message, Count: count})
+	}
+}
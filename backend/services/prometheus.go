@@ -0,0 +1,222 @@
+// ABOUTME: Prometheus HTTP API client for app usage totals
+// ABOUTME: Runs operator-configured PromQL instant queries for app memory, instances, and disk
+
+package services
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/markalston/diego-capacity-analyzer/backend/units"
+)
+
+// ErrPrometheusTimeout is returned when a query does not finish within the
+// client's query timeout, whether Prometheus or the connection gave up first
+var ErrPrometheusTimeout = errors.New("prometheus query timed out")
+
+// PrometheusQueries are the PromQL expressions that yield the app totals.
+// Memory and disk are in bytes. Each expression should evaluate to one
+// number; a vector of several series is summed.
+type PrometheusQueries struct {
+	MemoryBytes string
+	Instances   string
+	DiskBytes   string // Optional; empty skips the disk total
+}
+
+// PrometheusAppUsage holds the app totals read from Prometheus
+type PrometheusAppUsage struct {
+	MemoryMB    int
+	Instances   int
+	DiskMB      int
+	DiskQueried bool // False when no disk query is configured, so DiskMB is unknown
+}
+
+// PrometheusClient queries the Prometheus HTTP API
+type PrometheusClient struct {
+	baseURL     string
+	bearerToken string
+	username    string
+	password    string
+	timeout     time.Duration
+	client      *http.Client
+}
+
+// NewPrometheusClient creates a client for the Prometheus server at baseURL.
+// Each query is limited to timeout, which is also passed to Prometheus so it
+// stops evaluating a query nobody is waiting for.
+func NewPrometheusClient(baseURL string, timeout time.Duration, skipSSLValidation bool) *PrometheusClient {
+	return &PrometheusClient{
+		baseURL: baseURL,
+		timeout: timeout,
+		client: &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: skipSSLValidation},
+			},
+		},
+	}
+}
+
+// SetBearerToken sends token as Authorization: Bearer on every query
+func (p *PrometheusClient) SetBearerToken(token string) {
+	p.bearerToken = token
+}
+
+// SetBasicAuth sends username and password as HTTP basic auth on every query
+func (p *PrometheusClient) SetBasicAuth(username, password string) {
+	p.username = username
+	p.password = password
+}
+
+// GetAppUsage runs queries and converts the results to the MB and instance
+// counts the infrastructure state is built from
+func (p *PrometheusClient) GetAppUsage(ctx context.Context, queries PrometheusQueries) (*PrometheusAppUsage, error) {
+	memoryBytes, err := p.Query(ctx, queries.MemoryBytes)
+	if err != nil {
+		return nil, fmt.Errorf("app memory query: %w", err)
+	}
+	instances, err := p.Query(ctx, queries.Instances)
+	if err != nil {
+		return nil, fmt.Errorf("app instances query: %w", err)
+	}
+
+	usage := &PrometheusAppUsage{
+		MemoryMB:  units.BytesToMiB(int(math.Round(memoryBytes))),
+		Instances: int(math.Round(instances)),
+	}
+	if queries.DiskBytes != "" {
+		diskBytes, err := p.Query(ctx, queries.DiskBytes)
+		if err != nil {
+			return nil, fmt.Errorf("app disk query: %w", err)
+		}
+		usage.DiskMB = units.BytesToMiB(int(math.Round(diskBytes)))
+		usage.DiskQueried = true
+	}
+	return usage, nil
+}
+
+// prometheusResponse is the envelope of a /api/v1/query response
+type prometheusResponse struct {
+	Status    string `json:"status"`
+	ErrorType string `json:"errorType"`
+	Error     string `json:"error"`
+	Data      struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+// Query runs expr as an instant query and returns its value. Scalar results
+// are returned as is and vector results are summed across series. A query
+// that matches no series is an error rather than zero, since it usually
+// means the expression or exporter is misconfigured.
+func (p *PrometheusClient) Query(ctx context.Context, expr string) (float64, error) {
+	params := url.Values{}
+	params.Set("query", expr)
+	params.Set("timeout", p.timeout.String())
+
+	req, err := http.NewRequestWithContext(ctx, "GET", p.baseURL+"/api/v1/query?"+params.Encode(), nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if p.bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+p.bearerToken)
+	} else if p.username != "" {
+		req.SetBasicAuth(p.username, p.password)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		var netErr net.Error
+		if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+			return 0, fmt.Errorf("%w after %s", ErrPrometheusTimeout, p.timeout)
+		}
+		return 0, fmt.Errorf("failed to query prometheus: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read prometheus response: %w", err)
+	}
+
+	var result prometheusResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return 0, fmt.Errorf("prometheus returned status %d", resp.StatusCode)
+		}
+		return 0, fmt.Errorf("failed to parse prometheus response: %w", err)
+	}
+	if result.Status != "success" {
+		if result.ErrorType == "timeout" {
+			return 0, fmt.Errorf("%w after %s", ErrPrometheusTimeout, p.timeout)
+		}
+		return 0, fmt.Errorf("prometheus returned status %d: %s: %s", resp.StatusCode, result.ErrorType, result.Error)
+	}
+
+	return sumPrometheusResult(result.Data.ResultType, result.Data.Result)
+}
+
+// sumPrometheusResult returns the value of a scalar result or the sum of a
+// vector result. Samples are [<unix time>, "<value>"] pairs.
+func sumPrometheusResult(resultType string, raw json.RawMessage) (float64, error) {
+	switch resultType {
+	case "scalar":
+		var sample []any
+		if err := json.Unmarshal(raw, &sample); err != nil {
+			return 0, fmt.Errorf("failed to parse scalar result: %w", err)
+		}
+		return prometheusSampleValue(sample)
+	case "vector":
+		var series []struct {
+			Value []any `json:"value"`
+		}
+		if err := json.Unmarshal(raw, &series); err != nil {
+			return 0, fmt.Errorf("failed to parse vector result: %w", err)
+		}
+		if len(series) == 0 {
+			return 0, fmt.Errorf("query returned no data")
+		}
+		var total float64
+		for _, s := range series {
+			v, err := prometheusSampleValue(s.Value)
+			if err != nil {
+				return 0, err
+			}
+			total += v
+		}
+		return total, nil
+	default:
+		return 0, fmt.Errorf("unsupported result type %q; the query must return a scalar or an instant vector", resultType)
+	}
+}
+
+// prometheusSampleValue parses the value of a [<unix time>, "<value>"] sample
+func prometheusSampleValue(sample []any) (float64, error) {
+	if len(sample) != 2 {
+		return 0, fmt.Errorf("malformed sample %v", sample)
+	}
+	s, ok := sample[1].(string)
+	if !ok {
+		return 0, fmt.Errorf("malformed sample value %v", sample[1])
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("malformed sample value %q: %w", s, err)
+	}
+	if math.IsNaN(v) || math.IsInf(v, 0) || v < 0 {
+		return 0, fmt.Errorf("query returned %s; expected a non-negative number", s)
+	}
+	return v, nil
+}
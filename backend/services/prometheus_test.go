@@ -0,0 +1,143 @@
+// ABOUTME: Tests for the Prometheus app usage client
+// ABOUTME: Covers result parsing, auth headers, error responses, and query timeouts
+
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// prometheusTestServer answers instant queries with the canned body for each expression
+func prometheusTestServer(t *testing.T, bodies map[string]string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/query" {
+			http.NotFound(w, r)
+			return
+		}
+		body, ok := bodies[r.URL.Query().Get("query")]
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"status":"error","errorType":"bad_data","error":"unknown query"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestPrometheusClient_GetAppUsage(t *testing.T) {
+	server := prometheusTestServer(t, map[string]string{
+		// 10 GiB split across two series, which must be summed
+		"app_memory": `{"status":"success","data":{"resultType":"vector","result":[
+			{"metric":{"org":"a"},"value":[1700000000,"5368709120"]},
+			{"metric":{"org":"b"},"value":[1700000000,"5368709120"]}]}}`,
+		"app_instances": `{"status":"success","data":{"resultType":"scalar","result":[1700000000,"40"]}}`,
+		"app_disk":      `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1700000000,"21474836480"]}]}}`,
+	})
+	client := NewPrometheusClient(server.URL, 5*time.Second, false)
+
+	usage, err := client.GetAppUsage(context.Background(), PrometheusQueries{
+		MemoryBytes: "app_memory",
+		Instances:   "app_instances",
+		DiskBytes:   "app_disk",
+	})
+	if err != nil {
+		t.Fatalf("GetAppUsage failed: %v", err)
+	}
+	if usage.MemoryMB != 10240 || usage.Instances != 40 || usage.DiskMB != 20480 || !usage.DiskQueried {
+		t.Errorf("Unexpected usage: %+v", usage)
+	}
+
+	usage, err = client.GetAppUsage(context.Background(), PrometheusQueries{MemoryBytes: "app_memory", Instances: "app_instances"})
+	if err != nil {
+		t.Fatalf("GetAppUsage without disk query failed: %v", err)
+	}
+	if usage.DiskQueried || usage.DiskMB != 0 {
+		t.Errorf("Expected no disk total without a disk query, got %+v", usage)
+	}
+}
+
+func TestPrometheusClient_AuthHeaders(t *testing.T) {
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		w.Write([]byte(`{"status":"success","data":{"resultType":"scalar","result":[1700000000,"1"]}}`))
+	}))
+	defer server.Close()
+
+	client := NewPrometheusClient(server.URL, 5*time.Second, false)
+	client.SetBearerToken("prom-token")
+	if _, err := client.Query(context.Background(), "up"); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if authorization != "Bearer prom-token" {
+		t.Errorf("Expected bearer token, got %q", authorization)
+	}
+
+	client = NewPrometheusClient(server.URL, 5*time.Second, false)
+	client.SetBasicAuth("reader", "secret")
+	if _, err := client.Query(context.Background(), "up"); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if !strings.HasPrefix(authorization, "Basic ") {
+		t.Errorf("Expected basic auth, got %q", authorization)
+	}
+}
+
+func TestPrometheusClient_QueryErrors(t *testing.T) {
+	server := prometheusTestServer(t, map[string]string{
+		"empty":  `{"status":"success","data":{"resultType":"vector","result":[]}}`,
+		"matrix": `{"status":"success","data":{"resultType":"matrix","result":[]}}`,
+		"nan":    `{"status":"success","data":{"resultType":"scalar","result":[1700000000,"NaN"]}}`,
+		"slow":   `{"status":"error","errorType":"timeout","error":"query timed out in expression evaluation"}`,
+	})
+	client := NewPrometheusClient(server.URL, 5*time.Second, false)
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"empty", "no data"},
+		{"matrix", "unsupported result type"},
+		{"nan", "non-negative number"},
+		{"missing", "bad_data"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := client.Query(context.Background(), tt.query)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	if _, err := client.Query(context.Background(), "slow"); !errors.Is(err, ErrPrometheusTimeout) {
+		t.Errorf("Expected ErrPrometheusTimeout for a Prometheus timeout, got %v", err)
+	}
+}
+
+func TestPrometheusClient_ClientTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("timeout"); got != "50ms" {
+			t.Errorf("Expected the query timeout to be passed to Prometheus, got %q", got)
+		}
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := NewPrometheusClient(server.URL, 50*time.Millisecond, false)
+	if _, err := client.Query(context.Background(), "up"); !errors.Is(err, ErrPrometheusTimeout) {
+		t.Errorf("Expected ErrPrometheusTimeout, got %v", err)
+	}
+}
@@ -6,8 +6,11 @@ package services
 import (
 	"fmt"
 	"math"
+	"sort"
+	"strings"
 
 	"github.com/markalston/diego-capacity-analyzer/backend/models"
+	"github.com/markalston/diego-capacity-analyzer/backend/units"
 )
 
 const (
@@ -50,14 +53,7 @@ func resolveChunkSizeMB(inputChunkMB, stateMaxMB int) int {
 // - Moderate (4-8:1): Monitor CPU Ready time
 // - Aggressive (>8:1): Expect contention, requires active monitoring
 func CPURiskLevel(ratio float64) string {
-	switch {
-	case ratio <= 4:
-		return "conservative"
-	case ratio <= 8:
-		return "moderate"
-	default:
-		return "aggressive"
-	}
+	return models.DefaultThresholds().ScenarioCPURiskLevel(ratio)
 }
 
 // DefaultTPSCurve is the default TPS curve - baseline estimates, user can override
@@ -73,13 +69,42 @@ var DefaultTPSCurve = []models.TPSPt{
 type TPSDataPoint = models.TPSPt
 
 // ScenarioCalculator computes capacity metrics for scenarios
-type ScenarioCalculator struct{}
+type ScenarioCalculator struct {
+	thresholds models.Thresholds // Zero values use the defaults
+}
 
 // NewScenarioCalculator creates a new calculator
 func NewScenarioCalculator() *ScenarioCalculator {
 	return &ScenarioCalculator{}
 }
 
+// SetThresholds replaces the thresholds used for warnings and CPU risk,
+// e.g. with the operator's THRESHOLDS overrides
+func (c *ScenarioCalculator) SetThresholds(t models.Thresholds) {
+	c.thresholds = t
+}
+
+// currentThresholds returns the calculator's thresholds; a nil calculator
+// uses the defaults
+func (c *ScenarioCalculator) currentThresholds() models.Thresholds {
+	if c == nil {
+		return models.DefaultThresholds()
+	}
+	return c.thresholds
+}
+
+// targetVCPURatio returns target, or when it is unset the ratio above which
+// CPU risk becomes moderate (4:1 by default)
+func (c *ScenarioCalculator) targetVCPURatio(target float64) float64 {
+	if target > 0 {
+		return target
+	}
+	if ratio := c.currentThresholds().VCPURatioModerate; ratio > 0 {
+		return ratio
+	}
+	return models.DefaultVCPURatioModerate
+}
+
 // EstimateTPS estimates TPS for a given cell count using the provided curve.
 // If curve is nil/empty, returns 0 and "disabled" (TPS modeling is disabled).
 // The frontend must explicitly enable TPS by providing a curve.
@@ -231,6 +256,11 @@ func CalculateConstraints(totalMemoryGB, hostCount, memoryPerHostGB, haAdmission
 // CalculateCurrent computes metrics for the current configuration.
 // tpsCurve is optional - if nil, TPS modeling is disabled.
 func (c *ScenarioCalculator) CalculateCurrent(state models.InfrastructureState, tpsCurve []models.TPSPt) models.ScenarioResult {
+	return c.calculateCurrent(state, tpsCurve, nil)
+}
+
+// calculateCurrent is CalculateCurrent, recording its steps in trace
+func (c *ScenarioCalculator) calculateCurrent(state models.InfrastructureState, tpsCurve []models.TPSPt, trace *calculationTrace) models.ScenarioResult {
 	// Get cell config from first cluster (assumes uniform cells)
 	var cellMemoryGB, cellCPU, cellDiskGB int
 	for _, cluster := range state.Clusters {
@@ -242,7 +272,7 @@ func (c *ScenarioCalculator) CalculateCurrent(state models.InfrastructureState,
 		}
 	}
 
-	return c.calculateFull(
+	result := c.calculateFull(
 		state.TotalCellCount,
 		cellMemoryGB,
 		cellCPU,
@@ -252,22 +282,106 @@ func (c *ScenarioCalculator) CalculateCurrent(state models.InfrastructureState,
 		state.TotalAppInstances,
 		state.PlatformVMsGB,
 		state.TotalN1MemoryGB,
-		DefaultMemoryOverheadPct,
+		models.OverheadModel{Pct: DefaultMemoryOverheadPct},
 		tpsCurve,
 		0, // hostCount - not available in current state
 		0, // physicalCoresPerHost - not available in current state
 		0, // targetVCPURatio - not available in current state
 		0, // platformVMsCPU - not available in current state
 		resolveChunkSizeMB(0, state.MaxInstanceMemoryMB),
+		trace,
 	)
+
+	// The current state knows each cluster's hosts, so the busiest host is
+	// the one in the cluster with the most cells per host
+	if maxCells := stateMaxCellsPerHost(state); maxCells > 0 {
+		result.MaxCellsPerHost = maxCells
+		result.BlastRadiusPct = hostBlastRadiusPct(state.TotalCellCount, maxCells)
+		trace.record("max_cells_per_host", "most cells on one host of any cluster, ⌈cluster cells ÷ cluster hosts⌉", float64(maxCells), "%d", maxCells)
+		trace.record("blast_radius_pct", "max_cells_per_host ÷ cell_count × 100", result.BlastRadiusPct, "%d ÷ %d × 100", maxCells, state.TotalCellCount)
+	}
+	return result
+}
+
+// stateMaxCellsPerHost returns the most cells on any one host, assuming cells
+// are spread evenly across the hosts of their cluster. 0 when no cluster with
+// cells reports a host count.
+func stateMaxCellsPerHost(state models.InfrastructureState) int {
+	maxCells := 0
+	for _, cluster := range state.Clusters {
+		if cluster.HostCount > 0 && cluster.DiegoCellCount > 0 {
+			maxCells = max(maxCells, ceilDiv(cluster.DiegoCellCount, cluster.HostCount))
+		}
+	}
+	return maxCells
+}
+
+// hostBlastRadiusPct returns the % of app instances lost when the host running
+// cellsOnHost of cellCount cells fails. App instances are assumed to be spread
+// evenly across cells.
+func hostBlastRadiusPct(cellCount, cellsOnHost int) float64 {
+	if cellCount <= 0 {
+		return 0
+	}
+	return float64(min(cellsOnHost, cellCount)) / float64(cellCount) * 100
 }
 
-// CalculateProposed computes metrics for a proposed scenario
+// ceilDiv returns a / b rounded up, for positive b
+func ceilDiv(a, b int) int {
+	return (a + b - 1) / b
+}
+
+// CalculateProposed computes metrics for a proposed scenario. When
+// input.TargetCluster names a cluster, the proposal replaces that cluster's
+// cells and is measured against its capacity alone.
 func (c *ScenarioCalculator) CalculateProposed(state models.InfrastructureState, input models.ScenarioInput) models.ScenarioResult {
-	// Get overhead percentage (default to 7% if not specified)
-	overheadPct := input.OverheadPct
-	if overheadPct == 0 {
-		overheadPct = DefaultMemoryOverheadPct
+	return c.calculateProposed(scopeToTargetCluster(state, input), input, nil)
+}
+
+// scopeToTargetCluster returns state narrowed to input.TargetCluster, or state
+// unchanged when no target is set or no cluster has that name
+func scopeToTargetCluster(state models.InfrastructureState, input models.ScenarioInput) models.InfrastructureState {
+	if input.TargetCluster == "" {
+		return state
+	}
+	if scoped, ok := state.ForCluster(input.TargetCluster); ok {
+		return scoped
+	}
+	return state
+}
+
+// unchangedClusters summarizes the clusters of state other than target
+func unchangedClusters(state models.InfrastructureState, target string) []models.ClusterSummary {
+	var unchanged []models.ClusterSummary
+	for _, summary := range state.ClusterList().Clusters {
+		if summary.Name != target {
+			unchanged = append(unchanged, summary)
+		}
+	}
+	return unchanged
+}
+
+// ValidateTargetCluster checks that input.TargetCluster, if set, names a
+// cluster of state
+func ValidateTargetCluster(state models.InfrastructureState, input models.ScenarioInput) error {
+	if input.TargetCluster == "" {
+		return nil
+	}
+	if _, ok := state.ForCluster(input.TargetCluster); !ok {
+		return fmt.Errorf("target_cluster %q not found in infrastructure", input.TargetCluster)
+	}
+	return nil
+}
+
+// calculateProposed is CalculateProposed, recording its steps in trace
+func (c *ScenarioCalculator) calculateProposed(state models.InfrastructureState, input models.ScenarioInput, trace *calculationTrace) models.ScenarioResult {
+	// Use the overhead model if given, otherwise the overhead percentage
+	// (default to 7% if not specified)
+	overhead := models.OverheadModel{Pct: input.OverheadPct}
+	if input.OverheadModel != nil {
+		overhead = *input.OverheadModel
+	} else if overhead.Pct == 0 {
+		overhead.Pct = DefaultMemoryOverheadPct
 	}
 
 	// Calculate app memory/disk including additional app if specified
@@ -279,9 +393,28 @@ func (c *ScenarioCalculator) CalculateProposed(state models.InfrastructureState,
 		totalAppMemoryGB += input.AdditionalApp.Instances * input.AdditionalApp.MemoryGB
 		totalAppDiskGB += input.AdditionalApp.Instances * input.AdditionalApp.DiskGB
 		totalAppInstances += input.AdditionalApp.Instances
-	}
-
-	return c.calculateFull(
+		trace.record("total_app_memory_gb", "current app memory + additional instances × memory_gb", float64(totalAppMemoryGB),
+			"%d + %d × %d", state.TotalAppMemoryGB, input.AdditionalApp.Instances, input.AdditionalApp.MemoryGB)
+		trace.record("total_app_instances", "current instances + additional instances", float64(totalAppInstances),
+			"%d + %d", state.TotalAppInstances, input.AdditionalApp.Instances)
+	}
+
+	// Removing hosts shrinks N-1 capacity by that many average hosts, so the
+	// N-1 utilization reported below becomes N-1-k utilization
+	n1MemoryGB := state.TotalN1MemoryGB
+	hostsRemoved := 0
+	cellsToEvacuate := 0
+	if input.HostsToRemove > 0 && state.TotalHostCount > 0 {
+		hostsRemoved = min(input.HostsToRemove, state.TotalHostCount)
+		avgHostMemoryGB := state.TotalMemoryGB / state.TotalHostCount
+		n1MemoryGB = max(n1MemoryGB-hostsRemoved*avgHostMemoryGB, 0)
+		trace.record("n1_memory_gb", "current N-1 memory − hosts_removed × average host memory", float64(n1MemoryGB),
+			"%d − %d × %d", state.TotalN1MemoryGB, hostsRemoved, avgHostMemoryGB)
+		// Cells are assumed to be spread evenly, so round up to whole cells
+		cellsToEvacuate = (hostsRemoved*input.ProposedCellCount + state.TotalHostCount - 1) / state.TotalHostCount
+	}
+
+	result := c.calculateFull(
 		input.ProposedCellCount,
 		input.ProposedCellMemoryGB,
 		input.ProposedCellCPU,
@@ -290,15 +423,74 @@ func (c *ScenarioCalculator) CalculateProposed(state models.InfrastructureState,
 		totalAppDiskGB,
 		totalAppInstances,
 		state.PlatformVMsGB,
-		state.TotalN1MemoryGB,
-		overheadPct,
+		n1MemoryGB,
+		overhead,
 		input.TPSCurve,
-		input.HostCount,
+		remainingHostCount(input),
 		input.PhysicalCoresPerHost,
 		float64(input.TargetVCPURatio),
 		input.PlatformVMsCPU,
 		resolveChunkSizeMB(input.ChunkSizeMB, state.MaxInstanceMemoryMB),
+		trace,
 	)
+	result.HostsRemoved = hostsRemoved
+	result.CellsToEvacuate = cellsToEvacuate
+	return result
+}
+
+// remainingHostCount returns the proposed host count after HostsToRemove
+// hosts are taken out
+func remainingHostCount(input models.ScenarioInput) int {
+	return max(input.HostCount-max(input.HostsToRemove, 0), 0)
+}
+
+// ValidateHostRemoval checks that removing input.HostsToRemove hosts still
+// leaves N-1 capacity to place cells on. With a target cluster, the hosts
+// come out of that cluster.
+func ValidateHostRemoval(state models.InfrastructureState, input models.ScenarioInput) error {
+	state = scopeToTargetCluster(state, input)
+	if input.HostsToRemove < 0 {
+		return fmt.Errorf("hosts_to_remove must not be negative, got %d", input.HostsToRemove)
+	}
+	if input.HostsToRemove == 0 {
+		return nil
+	}
+	if state.TotalHostCount == 0 {
+		return fmt.Errorf("hosts_to_remove requires infrastructure with a host count")
+	}
+	avgHostMemoryGB := state.TotalMemoryGB / state.TotalHostCount
+	if state.TotalN1MemoryGB-input.HostsToRemove*avgHostMemoryGB <= 0 {
+		return fmt.Errorf("hosts_to_remove %d leaves no N-1 capacity across %d hosts", input.HostsToRemove, state.TotalHostCount)
+	}
+	return nil
+}
+
+// ValidateOverheadModel checks that input.OverheadModel, if given, has no
+// negative terms and leaves each proposed cell some memory for apps
+func ValidateOverheadModel(state models.InfrastructureState, input models.ScenarioInput) error {
+	state = scopeToTargetCluster(state, input)
+	m := input.OverheadModel
+	if m == nil {
+		return nil
+	}
+	if m.FixedGB < 0 || m.PerInstanceMB < 0 || m.Pct < 0 {
+		return fmt.Errorf("overhead_model values must not be negative")
+	}
+	if m.Pct >= 100 {
+		return fmt.Errorf("overhead_model pct must be less than 100, got %g", m.Pct)
+	}
+	if input.ProposedCellCount <= 0 || input.ProposedCellMemoryGB <= 0 {
+		return nil
+	}
+	instances := state.TotalAppInstances
+	if input.AdditionalApp != nil {
+		instances += input.AdditionalApp.Instances
+	}
+	instancesPerCell := float64(instances) / float64(input.ProposedCellCount)
+	if overheadGB := m.CellOverheadGB(input.ProposedCellMemoryGB, instancesPerCell); overheadGB >= input.ProposedCellMemoryGB {
+		return fmt.Errorf("overhead_model leaves no app capacity: %d GB overhead on %d GB cells", overheadGB, input.ProposedCellMemoryGB)
+	}
+	return nil
 }
 
 // calculateFull performs the core metric calculations with all features
@@ -312,40 +504,56 @@ func (c *ScenarioCalculator) calculateFull(
 	totalAppInstances int,
 	platformVMsGB int,
 	n1MemoryGB int,
-	overheadPct float64,
+	overhead models.OverheadModel,
 	tpsCurve []models.TPSPt,
-	hostCount int, // for CPU ratio calculation
+	hostCount int, // for CPU ratio and blast radius calculation
 	physicalCoresPerHost int, // for CPU ratio calculation
 	targetVCPURatio float64, // for max cells by CPU calculation (0 = default 4:1)
 	platformVMsCPU int, // for max cells by CPU calculation
 	chunkSizeMB int, // chunk size for free chunks calculation
+	trace *calculationTrace, // records each step; nil to skip
 ) models.ScenarioResult {
-	// Memory overhead as percentage
-	memoryOverhead := int(float64(cellMemoryGB) * (overheadPct / 100))
-	appCapacityGB := cellCount * (cellMemoryGB - memoryOverhead)
+	// Instances per cell
+	var instancesPerCell float64
+	if cellCount > 0 {
+		instancesPerCell = float64(totalAppInstances) / float64(cellCount)
+		trace.record("instances_per_cell", "total_app_instances ÷ cell_count", instancesPerCell, "%d ÷ %d", totalAppInstances, cellCount)
+	}
+
+	// Memory overhead per cell: fixed + per-instance + percentage of cell memory
+	memoryOverhead := overhead.CellOverheadGB(cellMemoryGB, instancesPerCell)
+	usablePerCellGB := cellMemoryGB - memoryOverhead
+	appCapacityGB := cellCount * usablePerCellGB
+	trace.record("memory_overhead_gb", "fixed_gb + cell_memory_gb × pct ÷ 100 + per_instance_mb × instances_per_cell ÷ 1024, truncated", float64(memoryOverhead),
+		"%g + %d × %g ÷ 100 + %g × %.2f ÷ 1024", overhead.FixedGB, cellMemoryGB, overhead.Pct, overhead.PerInstanceMB, instancesPerCell)
+	trace.record("usable_memory_per_cell_gb", "cell_memory_gb − memory_overhead_gb", float64(usablePerCellGB), "%d − %d", cellMemoryGB, memoryOverhead)
+	trace.record("app_capacity_gb", "cell_count × usable_memory_per_cell_gb", float64(appCapacityGB), "%d × %d", cellCount, usablePerCellGB)
 
 	// Disk overhead (0.01% - negligible but included for completeness)
 	diskOverhead := int(float64(cellDiskGB) * (DefaultDiskOverheadPct / 100))
 	diskCapacityGB := 0
 	if cellDiskGB > 0 {
 		diskCapacityGB = cellCount * (cellDiskGB - diskOverhead)
+		trace.record("disk_capacity_gb", "cell_count × (cell_disk_gb − disk overhead)", float64(diskCapacityGB), "%d × (%d − %d)", cellCount, cellDiskGB, diskOverhead)
 	}
 
 	// Memory utilization
 	var utilizationPct float64
 	if appCapacityGB > 0 {
 		utilizationPct = float64(totalAppMemoryGB) / float64(appCapacityGB) * 100
+		trace.record("utilization_pct", "total_app_memory_gb ÷ app_capacity_gb × 100", utilizationPct, "%d ÷ %d × 100", totalAppMemoryGB, appCapacityGB)
 	}
 
 	// Disk utilization
 	var diskUtilizationPct float64
 	if diskCapacityGB > 0 {
 		diskUtilizationPct = float64(totalAppDiskGB) / float64(diskCapacityGB) * 100
+		trace.record("disk_utilization_pct", "total_app_disk_gb ÷ disk_capacity_gb × 100", diskUtilizationPct, "%d ÷ %d × 100", totalAppDiskGB, diskCapacityGB)
 	}
 
 	// Free chunks: (capacity - used) / chunkSize
 	// Convert GB to MB for precision
-	freeMemoryMB := (appCapacityGB - totalAppMemoryGB) * 1024
+	freeMemoryMB := units.GBToMiB(appCapacityGB - totalAppMemoryGB)
 	freeChunks := 0
 	if chunkSizeMB > 0 {
 		freeChunks = freeMemoryMB / chunkSizeMB
@@ -353,52 +561,81 @@ func (c *ScenarioCalculator) calculateFull(
 	if freeChunks < 0 {
 		freeChunks = 0
 	}
+	trace.record("free_memory_mb", "(app_capacity_gb − total_app_memory_gb) × 1024", float64(freeMemoryMB), "(%d − %d) × 1024", appCapacityGB, totalAppMemoryGB)
+	trace.record("free_chunks", "free_memory_mb ÷ chunk_size_mb, rounded down, at least 0", float64(freeChunks), "%d ÷ %d", freeMemoryMB, chunkSizeMB)
 
-	// Instances per cell
-	var instancesPerCell float64
+	// Largest single chunk: the free memory left on an average cell, since an
+	// app instance must fit on one cell rather than across the pooled free memory
+	maxSingleChunkGB := 0
 	if cellCount > 0 {
-		instancesPerCell = float64(totalAppInstances) / float64(cellCount)
+		avgUsedPerCellGB := float64(totalAppMemoryGB) / float64(cellCount)
+		maxSingleChunkGB = max(int(float64(cellMemoryGB-memoryOverhead)-avgUsedPerCellGB), 0)
+		trace.record("max_single_chunk_gb", "usable_memory_per_cell_gb − total_app_memory_gb ÷ cell_count, truncated, at least 0", float64(maxSingleChunkGB),
+			"%d − %d ÷ %d", usablePerCellGB, totalAppMemoryGB, cellCount)
 	}
 
 	// Fault impact (rounded)
 	faultImpact := int(math.Round(instancesPerCell))
+	trace.record("fault_impact", "round(instances_per_cell)", float64(faultImpact), "round(%.2f)", instancesPerCell)
 
 	// N-1 utilization: (cellMemory + platformVMs) / n1Memory × 100
 	totalCellMemoryGB := cellCount * cellMemoryGB
+	trace.record("total_cell_memory_gb", "cell_count × cell_memory_gb", float64(totalCellMemoryGB), "%d × %d", cellCount, cellMemoryGB)
 	var n1UtilizationPct float64
 	if n1MemoryGB > 0 {
 		n1UtilizationPct = float64(totalCellMemoryGB+platformVMsGB) / float64(n1MemoryGB) * 100
+		trace.record("n1_utilization_pct", "(total_cell_memory_gb + platform_vms_gb) ÷ n1_memory_gb × 100", n1UtilizationPct,
+			"(%d + %d) ÷ %d × 100", totalCellMemoryGB, platformVMsGB, n1MemoryGB)
 	}
 
 	// TPS estimation
 	estimatedTPS, tpsStatus := EstimateTPS(cellCount, tpsCurve)
+	if len(tpsCurve) > 0 {
+		trace.record("estimated_tps", "tps_curve interpolated at cell_count", float64(estimatedTPS), "tps_curve(%d)", cellCount)
+	}
 
-	// Blast radius: % of capacity lost per single cell failure
+	// Blast radius: % of app instances lost per single host failure, with
+	// cells spread evenly across hosts. Without a host count, each cell is
+	// treated as its own failure domain.
 	var blastRadiusPct float64
+	var maxCellsPerHost int
 	if cellCount > 0 {
 		blastRadiusPct = 100.0 / float64(cellCount)
+		if hostCount > 0 {
+			maxCellsPerHost = ceilDiv(cellCount, hostCount)
+			blastRadiusPct = hostBlastRadiusPct(cellCount, maxCellsPerHost)
+			trace.record("max_cells_per_host", "⌈cell_count ÷ host_count⌉", float64(maxCellsPerHost), "⌈%d ÷ %d⌉", cellCount, hostCount)
+			trace.record("blast_radius_pct", "max_cells_per_host ÷ cell_count × 100", blastRadiusPct, "%d ÷ %d × 100", maxCellsPerHost, cellCount)
+		} else {
+			trace.record("blast_radius_pct", "100 ÷ cell_count", blastRadiusPct, "100 ÷ %d", cellCount)
+		}
 	}
 
 	// CPU ratio calculations (only when host CPU config provided)
 	var totalVCPUs, totalPCPUs int
-	var vcpuRatio float64
+	var vcpuRatio, targetRatio float64
 	var cpuRiskLevel string
 	var maxCellsByCPU, cpuHeadroomCells int
+	var exceedsCPUCapacity bool
 
 	if hostCount > 0 && physicalCoresPerHost > 0 {
 		totalVCPUs = cellCount * cellCPU
 		totalPCPUs = hostCount * physicalCoresPerHost
 		vcpuRatio = float64(totalVCPUs) / float64(totalPCPUs)
-		cpuRiskLevel = CPURiskLevel(vcpuRatio)
+		cpuRiskLevel = c.currentThresholds().ScenarioCPURiskLevel(vcpuRatio)
+		trace.record("total_vcpus", "cell_count × cell_cpu", float64(totalVCPUs), "%d × %d", cellCount, cellCPU)
+		trace.record("total_pcpus", "host_count × physical_cores_per_host", float64(totalPCPUs), "%d × %d", hostCount, physicalCoresPerHost)
+		trace.record("vcpu_ratio", "total_vcpus ÷ total_pcpus", vcpuRatio, "%d ÷ %d", totalVCPUs, totalPCPUs)
 
 		// Calculate max cells by CPU and headroom
+		targetRatio = c.targetVCPURatio(targetVCPURatio)
 		if cellCPU > 0 {
-			targetRatio := targetVCPURatio
-			if targetRatio == 0 {
-				targetRatio = 4.0 // default 4:1 ratio
-			}
 			maxCellsByCPU = CalculateMaxCellsByCPU(targetRatio, totalPCPUs, cellCPU, platformVMsCPU)
 			cpuHeadroomCells = maxCellsByCPU - cellCount // Can be negative if over target
+			exceedsCPUCapacity = cpuHeadroomCells < 0
+			trace.record("max_cells_by_cpu", "(target_vcpu_ratio × total_pcpus − platform_vms_cpu) ÷ cell_cpu, rounded down, at least 0", float64(maxCellsByCPU),
+				"(%g × %d − %d) ÷ %d", targetRatio, totalPCPUs, platformVMsCPU, cellCPU)
+			trace.record("cpu_headroom_cells", "max_cells_by_cpu − cell_count", float64(cpuHeadroomCells), "%d − %d", maxCellsByCPU, cellCount)
 		}
 	}
 
@@ -413,18 +650,22 @@ func (c *ScenarioCalculator) calculateFull(
 		DiskUtilizationPct: diskUtilizationPct,
 		FreeChunks:         freeChunks,
 		ChunkSizeMB:        chunkSizeMB,
+		MaxSingleChunkGB:   maxSingleChunkGB,
 		N1UtilizationPct:   n1UtilizationPct,
 		FaultImpact:        faultImpact,
 		InstancesPerCell:   instancesPerCell,
 		EstimatedTPS:       estimatedTPS,
 		TPSStatus:          tpsStatus,
 		BlastRadiusPct:     blastRadiusPct,
+		MaxCellsPerHost:    maxCellsPerHost,
 		TotalVCPUs:         totalVCPUs,
 		TotalPCPUs:         totalPCPUs,
 		VCPURatio:          vcpuRatio,
 		CPURiskLevel:       cpuRiskLevel,
 		MaxCellsByCPU:      maxCellsByCPU,
 		CPUHeadroomCells:   cpuHeadroomCells,
+		TargetVCPURatio:    targetRatio,
+		ExceedsCPUCapacity: exceedsCPUCapacity,
 	}
 }
 
@@ -466,7 +707,27 @@ func isResourceSelected(selectedResources []string, resource string) bool {
 	return false
 }
 
-// GenerateWarnings produces warnings based on proposed scenario.
+// DefaultWarningThresholds returns the thresholds used by scenario comparison.
+func DefaultWarningThresholds() models.WarningThresholds {
+	return models.DefaultThresholds().WarningThresholds
+}
+
+// ResolveWarningThresholds fills zero-valued thresholds from the defaults.
+// When only a critical limit is supplied, the warning limit keeps the same
+// spacing the defaults use: N-1 warns 10 points below critical and free chunks
+// warn at twice the critical count.
+func ResolveWarningThresholds(t models.WarningThresholds) models.WarningThresholds {
+	return t.ResolveFrom(DefaultWarningThresholds())
+}
+
+// GenerateWarnings produces warnings based on proposed scenario using the
+// calculator's thresholds. See GenerateThresholdWarnings for parameter details.
+func (c *ScenarioCalculator) GenerateWarnings(current, proposed models.ScenarioResult, constraints *models.ConstraintAnalysis, ctx *WarningsContext) []models.ScenarioWarning {
+	return GenerateThresholdWarnings(current, proposed, constraints, ctx, c.currentThresholds().WarningThresholds)
+}
+
+// GenerateThresholdWarnings produces warnings for a scenario result against the
+// given thresholds (zero values use defaults).
 // The constraints parameter is optional - if provided, the warning messages
 // will reflect whether HA Admission Control or N-1 is the limiting factor.
 // The ctx parameter is optional - if provided, warnings will include change
@@ -475,7 +736,65 @@ func isResourceSelected(selectedResources []string, resource string) bool {
 // - CPU warnings only shown when "cpu" is selected
 // - Disk warnings only shown when "disk" is selected
 // - Memory/capacity warnings only shown when "memory" is selected
-func (c *ScenarioCalculator) GenerateWarnings(current, proposed models.ScenarioResult, constraints *models.ConstraintAnalysis, ctx *WarningsContext) []models.ScenarioWarning {
+// The result is ordered critical-first with repeated messages removed, and at
+// most maxScenarioWarnings long (see organizeWarnings).
+func GenerateThresholdWarnings(current, proposed models.ScenarioResult, constraints *models.ConstraintAnalysis, ctx *WarningsContext, thresholds models.WarningThresholds) []models.ScenarioWarning {
+	return organizeWarnings(thresholdWarnings(current, proposed, constraints, ctx, thresholds))
+}
+
+// maxScenarioWarnings caps the warnings returned for one scenario; any past
+// it are folded into a single WarningMoreWarnings summary
+const maxScenarioWarnings = 8
+
+// warningSeverityRank orders severities most severe first; unknown
+// severities sort after info
+var warningSeverityRank = map[string]int{"critical": 0, "warning": 1, "info": 2}
+
+// organizeWarnings orders warnings critical, then warning, then info, keeping
+// generation order within a severity. A repeated message keeps only its most
+// severe occurrence, and past maxScenarioWarnings the remainder is replaced by
+// one info warning counting them.
+func organizeWarnings(warnings []models.ScenarioWarning) []models.ScenarioWarning {
+	if len(warnings) == 0 {
+		return warnings
+	}
+
+	rank := func(severity string) int {
+		if r, ok := warningSeverityRank[severity]; ok {
+			return r
+		}
+		return len(warningSeverityRank)
+	}
+	sort.SliceStable(warnings, func(i, j int) bool {
+		return rank(warnings[i].Severity) < rank(warnings[j].Severity)
+	})
+
+	seen := make(map[string]bool, len(warnings))
+	organized := make([]models.ScenarioWarning, 0, len(warnings))
+	for _, w := range warnings {
+		if seen[w.Message] {
+			continue
+		}
+		seen[w.Message] = true
+		organized = append(organized, w)
+	}
+
+	if len(organized) <= maxScenarioWarnings {
+		return organized
+	}
+	hidden := len(organized) - (maxScenarioWarnings - 1)
+	organized = append(organized[:maxScenarioWarnings-1], models.ScenarioWarning{
+		Severity: "info",
+		Code:     models.WarningMoreWarnings,
+		Message:  fmt.Sprintf("+%d more warnings", hidden),
+	})
+	return organized
+}
+
+// thresholdWarnings generates the warnings GenerateThresholdWarnings
+// organizes, in check order
+func thresholdWarnings(current, proposed models.ScenarioResult, constraints *models.ConstraintAnalysis, ctx *WarningsContext, thresholds models.WarningThresholds) []models.ScenarioWarning {
+	t := ResolveWarningThresholds(thresholds)
 	var warnings []models.ScenarioWarning
 
 	// Get selected resources from context (nil means all resources)
@@ -490,15 +809,15 @@ func (c *ScenarioCalculator) GenerateWarnings(current, proposed models.ScenarioR
 	// Capacity utilization warnings - message depends on limiting constraint
 	// Only shown when memory is selected
 	if isResourceSelected(selectedResources, "memory") {
-		if proposed.N1UtilizationPct > 85 {
-			var message string
+		if proposed.N1UtilizationPct > t.N1CriticalPct {
+			code, message := models.WarningN1Exceeded, "Exceeds N-1 capacity safety margin"
 			if isHALimiting {
+				code = models.WarningHAAdmissionExceeded
 				message = fmt.Sprintf("Exceeds HA Admission Control capacity limit (%s)", constraints.LimitingLabel)
-			} else {
-				message = "Exceeds N-1 capacity safety margin"
 			}
 			warning := models.ScenarioWarning{
 				Severity: "critical",
+				Code:     code,
 				Message:  message,
 			}
 			// Add context if available
@@ -507,15 +826,15 @@ func (c *ScenarioCalculator) GenerateWarnings(current, proposed models.ScenarioR
 				warning.Fixes = CalculateCapacityFix(ctx.State, ctx.Input, constraints)
 			}
 			warnings = append(warnings, warning)
-		} else if proposed.N1UtilizationPct > 75 {
-			var message string
+		} else if proposed.N1UtilizationPct > t.N1WarningPct {
+			code, message := models.WarningN1Approaching, "Approaching N-1 capacity limits"
 			if isHALimiting {
+				code = models.WarningHAAdmissionApproaching
 				message = fmt.Sprintf("Approaching HA Admission Control capacity limit (%s)", constraints.LimitingLabel)
-			} else {
-				message = "Approaching N-1 capacity limits"
 			}
 			warning := models.ScenarioWarning{
 				Severity: "warning",
+				Code:     code,
 				Message:  message,
 			}
 			// Add context if available
@@ -527,33 +846,71 @@ func (c *ScenarioCalculator) GenerateWarnings(current, proposed models.ScenarioR
 		}
 	}
 
+	// Host removal warning: the remaining hosts cannot hold the proposed cells
+	// (only when memory is selected)
+	if isResourceSelected(selectedResources, "memory") && proposed.HostsRemoved > 0 && proposed.N1UtilizationPct > 100 {
+		warning := models.ScenarioWarning{
+			Severity: "critical",
+			Code:     models.WarningHostRemovalOverflow,
+			Message: fmt.Sprintf(
+				"Removing %d host(s) pushes N-1 utilization to %.0f%% - %d cell(s) to evacuate will not fit",
+				proposed.HostsRemoved, proposed.N1UtilizationPct, proposed.CellsToEvacuate,
+			),
+		}
+		if ctx != nil {
+			warning.Change = &models.ConfigChange{
+				Field:       "hosts_to_remove",
+				ProposedVal: proposed.HostsRemoved,
+				Delta:       proposed.HostsRemoved,
+			}
+		}
+		warnings = append(warnings, warning)
+	}
+
 	// Free chunks warnings (only when memory is selected)
-	// Thresholds: < 10 = critical (< 40GB staging capacity)
-	//             < 20 = warning (< 80GB staging capacity)
+	// Default thresholds: < 10 = critical (< 40GB staging capacity)
+	//                     < 20 = warning (< 80GB staging capacity)
 	if isResourceSelected(selectedResources, "memory") {
-		if proposed.FreeChunks < 10 {
+		if proposed.FreeChunks < t.FreeChunksCritical {
 			warnings = append(warnings, models.ScenarioWarning{
 				Severity: "critical",
+				Code:     models.WarningLowFreeChunks,
 				Message:  "Critical: Low staging capacity",
 			})
-		} else if proposed.FreeChunks < 20 {
+		} else if proposed.FreeChunks < t.FreeChunksWarning {
 			warnings = append(warnings, models.ScenarioWarning{
 				Severity: "warning",
+				Code:     models.WarningLowFreeChunks,
 				Message:  "Low staging capacity",
 			})
 		}
 	}
 
+	// Largest single chunk warning: free chunks can look healthy while no one
+	// cell has room for a large app instance (only when memory is selected)
+	if isResourceSelected(selectedResources, "memory") && proposed.CellMemoryGB > 0 && proposed.MaxSingleChunkGB < t.MinSingleChunkGB {
+		warnings = append(warnings, models.ScenarioWarning{
+			Severity: "warning",
+			Code:     models.WarningSmallSingleChunk,
+			Message: fmt.Sprintf(
+				"Largest stageable app is %d GB per cell, below the %d GB minimum",
+				proposed.MaxSingleChunkGB, t.MinSingleChunkGB,
+			),
+		})
+	}
+
 	// Cell utilization warnings (only when memory is selected)
 	if isResourceSelected(selectedResources, "memory") {
-		if proposed.UtilizationPct > 90 {
+		if proposed.UtilizationPct > t.UtilizationCriticalPct {
 			warnings = append(warnings, models.ScenarioWarning{
 				Severity: "critical",
+				Code:     models.WarningCellUtilization,
 				Message:  "Cell utilization critically high",
 			})
-		} else if proposed.UtilizationPct > 80 {
+		} else if proposed.UtilizationPct > t.UtilizationWarningPct {
 			warnings = append(warnings, models.ScenarioWarning{
 				Severity: "warning",
+				Code:     models.WarningCellUtilization,
 				Message:  "Cell utilization elevated",
 			})
 		}
@@ -561,14 +918,16 @@ func (c *ScenarioCalculator) GenerateWarnings(current, proposed models.ScenarioR
 
 	// Disk utilization warnings (only when disk analysis is selected)
 	if isResourceSelected(selectedResources, "disk") {
-		if proposed.DiskUtilizationPct > 90 {
+		if proposed.DiskUtilizationPct > t.DiskCriticalPct {
 			warnings = append(warnings, models.ScenarioWarning{
 				Severity: "critical",
+				Code:     models.WarningDiskUtilization,
 				Message:  "Disk utilization critically high",
 			})
-		} else if proposed.DiskUtilizationPct > 80 {
+		} else if proposed.DiskUtilizationPct > t.DiskWarningPct {
 			warnings = append(warnings, models.ScenarioWarning{
 				Severity: "warning",
+				Code:     models.WarningDiskUtilization,
 				Message:  "Disk utilization elevated",
 			})
 		}
@@ -579,27 +938,47 @@ func (c *ScenarioCalculator) GenerateWarnings(current, proposed models.ScenarioR
 	case "critical":
 		warnings = append(warnings, models.ScenarioWarning{
 			Severity: "critical",
+			Code:     models.WarningTPSDegraded,
 			Message:  fmt.Sprintf("Cell count (%d) causes severe scheduling degradation (~%d TPS)", proposed.CellCount, proposed.EstimatedTPS),
 		})
 	case "degraded":
 		warnings = append(warnings, models.ScenarioWarning{
 			Severity: "warning",
+			Code:     models.WarningTPSDegraded,
 			Message:  fmt.Sprintf("Cell count (%d) may cause scheduling latency (~%d TPS)", proposed.CellCount, proposed.EstimatedTPS),
 		})
 	}
 
-	// Blast radius warning: warn when single cell failure impact is high
-	// Thresholds: >20% is critical (5 or fewer cells), >10% is warning (10 or fewer cells)
+	// Blast radius warning: warn when single host (or, without hosts, single
+	// cell) failure impact is high
+	// Default host thresholds: >40% is critical (2 or fewer hosts), >30% is warning (3 hosts)
+	// Default cell thresholds: >20% is critical (5 or fewer cells), >10% is warning (10 or fewer cells)
 	// Only shown when memory is selected (blast radius is a memory capacity metric)
 	if isResourceSelected(selectedResources, "memory") {
-		if proposed.BlastRadiusPct > 20 {
+		if proposed.MaxCellsPerHost > 0 {
+			if proposed.BlastRadiusPct > t.HostFailureCriticalPct {
+				warnings = append(warnings, models.ScenarioWarning{
+					Severity: "critical",
+					Code:     models.WarningHostFailureImpact,
+					Message:  fmt.Sprintf("High host failure impact: single host loss (%d cells) affects %.0f%% of app instances", proposed.MaxCellsPerHost, proposed.BlastRadiusPct),
+				})
+			} else if proposed.BlastRadiusPct > t.HostFailureWarningPct {
+				warnings = append(warnings, models.ScenarioWarning{
+					Severity: "warning",
+					Code:     models.WarningHostFailureImpact,
+					Message:  fmt.Sprintf("Elevated host failure impact: single host loss (%d cells) affects %.0f%% of app instances", proposed.MaxCellsPerHost, proposed.BlastRadiusPct),
+				})
+			}
+		} else if proposed.BlastRadiusPct > t.BlastRadiusCriticalPct {
 			warnings = append(warnings, models.ScenarioWarning{
 				Severity: "critical",
+				Code:     models.WarningCellFailureImpact,
 				Message:  fmt.Sprintf("High cell failure impact: single cell loss affects %.0f%% of capacity", proposed.BlastRadiusPct),
 			})
-		} else if proposed.BlastRadiusPct > 10 {
+		} else if proposed.BlastRadiusPct > t.BlastRadiusWarningPct {
 			warnings = append(warnings, models.ScenarioWarning{
 				Severity: "warning",
+				Code:     models.WarningCellFailureImpact,
 				Message:  fmt.Sprintf("Elevated cell failure impact: single cell loss affects %.0f%% of capacity", proposed.BlastRadiusPct),
 			})
 		}
@@ -607,19 +986,36 @@ func (c *ScenarioCalculator) GenerateWarnings(current, proposed models.ScenarioR
 
 	// vCPU:pCPU ratio warnings (only when CPU analysis enabled AND cpu resource selected)
 	if proposed.TotalPCPUs > 0 && isResourceSelected(selectedResources, "cpu") {
-		targetRatio := 4.0 // Default target
+		targetRatio := proposed.TargetVCPURatio
 		if ctx != nil && ctx.Input.TargetVCPURatio > 0 {
 			targetRatio = float64(ctx.Input.TargetVCPURatio)
 		}
+		if targetRatio == 0 {
+			targetRatio = models.DefaultVCPURatioModerate
+		}
 
-		// Warning when ratio exceeds target
-		if proposed.VCPURatio > targetRatio {
+		// Warning when ratio exceeds target, or when the cells alone fit the
+		// target but not once platform VM vCPUs are counted
+		var code, message string
+		switch {
+		case proposed.VCPURatio > targetRatio:
+			code = models.WarningVCPURatioExceeded
+			message = fmt.Sprintf(
+				"vCPU:pCPU ratio %.1f:1 exceeds target %.0f:1 - expect CPU contention under load",
+				proposed.VCPURatio, targetRatio,
+			)
+		case proposed.ExceedsCPUCapacity:
+			code = models.WarningCPUCapacityExceeded
+			message = fmt.Sprintf(
+				"%d cells exceed the %d cells CPU supports at %.0f:1 once platform VM vCPUs are counted - expect CPU contention under load",
+				proposed.CellCount, proposed.MaxCellsByCPU, targetRatio,
+			)
+		}
+		if message != "" {
 			warning := models.ScenarioWarning{
 				Severity: "warning",
-				Message: fmt.Sprintf(
-					"vCPU:pCPU ratio %.1f:1 exceeds target %.0f:1 - expect CPU contention under load",
-					proposed.VCPURatio, targetRatio,
-				),
+				Code:     code,
+				Message:  message,
 			}
 			if ctx != nil {
 				warning.Change = findRelevantChange(ctx.Changes, "cell_count", "cell_cpu")
@@ -632,6 +1028,7 @@ func (c *ScenarioCalculator) GenerateWarnings(current, proposed models.ScenarioR
 		if proposed.CPURiskLevel == "aggressive" {
 			warnings = append(warnings, models.ScenarioWarning{
 				Severity: "critical",
+				Code:     models.WarningVCPURatioAggressive,
 				Message: fmt.Sprintf(
 					"vCPU:pCPU ratio %.1f:1 is aggressive - monitor CPU Ready time (>5%% indicates problems)",
 					proposed.VCPURatio,
@@ -643,23 +1040,56 @@ func (c *ScenarioCalculator) GenerateWarnings(current, proposed models.ScenarioR
 	return warnings
 }
 
-// Compare computes full comparison between current and proposed scenarios
+// Compare computes full comparison between current and proposed scenarios.
+// When input.BaselineInput is set, its proposed result stands in for the
+// current side, and changes are detected against it rather than the state.
+// When input.TargetCluster names a cluster, both sides describe that cluster
+// alone, and the other clusters are listed as unchanged.
 func (c *ScenarioCalculator) Compare(state models.InfrastructureState, input models.ScenarioInput) models.ScenarioComparison {
+	return c.compare(state, input, false)
+}
+
+// CompareExplained is Compare with a CalculationTrace of the intermediate
+// values behind the current and proposed results
+func (c *ScenarioCalculator) CompareExplained(state models.InfrastructureState, input models.ScenarioInput) models.ScenarioComparison {
+	return c.compare(state, input, true)
+}
+
+// compare builds the comparison, recording calculation steps when explain is set
+func (c *ScenarioCalculator) compare(state models.InfrastructureState, input models.ScenarioInput, explain bool) models.ScenarioComparison {
+	foundation := state
+	_, scoped := state.ForCluster(input.TargetCluster)
+	state = scopeToTargetCluster(state, input)
+
+	var currentTrace, proposedTrace *calculationTrace
+	if explain {
+		currentTrace, proposedTrace = &calculationTrace{}, &calculationTrace{}
+	}
+
 	// Use same TPS curve for both current and proposed (if provided)
-	current := c.CalculateCurrent(state, input.TPSCurve)
-	proposed := c.CalculateProposed(state, input)
+	var current models.ScenarioResult
+	if input.BaselineInput != nil {
+		baseline := *input.BaselineInput
+		if !baseline.EnableTPS() {
+			baseline.TPSCurve = input.TPSCurve
+		}
+		current = c.calculateProposed(state, baseline, currentTrace)
+	} else {
+		current = c.calculateCurrent(state, input.TPSCurve, currentTrace)
+	}
+	proposed := c.calculateProposed(state, input, proposedTrace)
 
 	// Calculate constraint analysis FIRST if host config is provided
 	// This is needed before generating warnings so we know which constraint is limiting
 	var constraints *models.ConstraintAnalysis
-	if input.HostCount > 0 && input.MemoryPerHostGB > 0 {
-		totalMemoryGB := input.HostCount * input.MemoryPerHostGB
+	if hostCount := remainingHostCount(input); hostCount > 0 && input.MemoryPerHostGB > 0 {
+		totalMemoryGB := hostCount * input.MemoryPerHostGB
 		// Used memory: proposed cell memory + platform VMs
 		usedMemoryGB := proposed.CellCount*proposed.CellMemoryGB + state.PlatformVMsGB
 
 		constraints = CalculateConstraints(
 			totalMemoryGB,
-			input.HostCount,
+			hostCount,
 			input.MemoryPerHostGB,
 			input.HAAdmissionPct,
 			usedMemoryGB,
@@ -668,6 +1098,10 @@ func (c *ScenarioCalculator) Compare(state models.InfrastructureState, input mod
 
 	// Detect what changed between current and proposed
 	changes := DetectChanges(state, input)
+	if input.BaselineInput != nil {
+		b := input.BaselineInput
+		changes = detectCellChanges(b.ProposedCellCount, b.ProposedCellMemoryGB, b.ProposedCellCPU, b.ProposedCellDiskGB, input)
+	}
 
 	// Build context for actionable warnings
 	ctx := &WarningsContext{
@@ -676,13 +1110,15 @@ func (c *ScenarioCalculator) Compare(state models.InfrastructureState, input mod
 		Changes: changes,
 	}
 
-	// Generate warnings - pass constraints and context for actionable messages
-	warnings := c.GenerateWarnings(current, proposed, constraints, ctx)
+	// Generate warnings - pass constraints and context for actionable messages.
+	// They are organized once the scenario-level warnings below are added.
+	warnings := thresholdWarnings(current, proposed, constraints, ctx, c.currentThresholds().WarningThresholds)
 
 	// Add warning if HA% is insufficient for N-1 protection (only when memory is selected)
 	if constraints != nil && constraints.InsufficientHAWarning && isResourceSelected(input.SelectedResources, "memory") {
 		warnings = append(warnings, models.ScenarioWarning{
 			Severity: "warning",
+			Code:     models.WarningHAInsufficientForN1,
 			Message: fmt.Sprintf(
 				"HA Admission Control (%d%%) may be insufficient for N-1 host failure protection. Consider increasing to at least %.0f%%.",
 				input.HAAdmissionPct,
@@ -691,7 +1127,67 @@ func (c *ScenarioCalculator) Compare(state models.InfrastructureState, input mod
 		})
 	}
 
-	// Calculate delta
+	if w := haAdmissionMismatchWarning(state, input); w != nil {
+		warnings = append(warnings, *w)
+	}
+	warnings = organizeWarnings(warnings)
+
+	comparison := models.ScenarioComparison{
+		Current:     current,
+		Proposed:    proposed,
+		Warnings:    warnings,
+		Constraints: constraints,
+		Delta:       CalculateDelta(current, proposed),
+	}
+	if scoped {
+		comparison.TargetCluster = input.TargetCluster
+		comparison.UnchangedClusters = unchangedClusters(foundation, input.TargetCluster)
+	}
+	if explain {
+		comparison.CalculationTrace = &models.CalculationTrace{
+			Current:  currentTrace.Steps(),
+			Proposed: proposedTrace.Steps(),
+		}
+	}
+	return comparison
+}
+
+// haAdmissionMismatchWarning flags a scenario HA admission control percentage
+// that differs from what vSphere reports for the targeted clusters. Clusters
+// with no discovered percentage (HA off or a non-percentage policy) are skipped.
+func haAdmissionMismatchWarning(state models.InfrastructureState, input models.ScenarioInput) *models.ScenarioWarning {
+	if state.Source != "vsphere" || input.HAAdmissionPct <= 0 {
+		return nil
+	}
+
+	var mismatched []string
+	for _, cluster := range state.Clusters {
+		if input.TargetCluster != "" && cluster.Name != input.TargetCluster {
+			continue
+		}
+		discovered := cluster.HAAdmissionControlPercentage
+		if discovered > 0 && discovered != input.HAAdmissionPct {
+			mismatched = append(mismatched, fmt.Sprintf("%s (%d%%)", cluster.Name, discovered))
+		}
+	}
+	if len(mismatched) == 0 {
+		return nil
+	}
+
+	return &models.ScenarioWarning{
+		Severity: "warning",
+		Code:     models.WarningHAAdmissionMismatch,
+		Message: fmt.Sprintf(
+			"HA Admission Control is set to %d%% in this scenario, but vSphere reports a different reservation for %s. Constraint analysis uses the scenario value.",
+			input.HAAdmissionPct,
+			strings.Join(mismatched, ", "),
+		),
+	}
+}
+
+// CalculateDelta computes how proposed differs from a reference result, which
+// is either the current state or a saved baseline
+func CalculateDelta(current, proposed models.ScenarioResult) models.ScenarioDelta {
 	capacityChange := proposed.AppCapacityGB - current.AppCapacityGB
 	diskCapacityChange := proposed.DiskCapacityGB - current.DiskCapacityGB
 	utilizationChange := proposed.UtilizationPct - current.UtilizationPct
@@ -700,7 +1196,7 @@ func (c *ScenarioCalculator) Compare(state models.InfrastructureState, input mod
 	// CPU ratio change
 	vcpuRatioChange := proposed.VCPURatio - current.VCPURatio
 
-	// ResilienceChange based on blast radius: what % of capacity is at risk per cell failure
+	// ResilienceChange based on blast radius: what % of capacity is at risk per host (or cell) failure
 	// "low" = ≤5% blast radius (20+ cells), very resilient
 	// "moderate" = 5-15% blast radius (7-20 cells), acceptable for most workloads
 	// "high" = >15% blast radius (< 7 cells), concerning for production
@@ -714,19 +1210,13 @@ func (c *ScenarioCalculator) Compare(state models.InfrastructureState, input mod
 		resilienceChange = "high"
 	}
 
-	return models.ScenarioComparison{
-		Current:     current,
-		Proposed:    proposed,
-		Warnings:    warnings,
-		Constraints: constraints,
-		Delta: models.ScenarioDelta{
-			CapacityChangeGB:         capacityChange,
-			DiskCapacityChangeGB:     diskCapacityChange,
-			UtilizationChangePct:     utilizationChange,
-			DiskUtilizationChangePct: diskUtilizationChange,
-			ResilienceChange:         resilienceChange,
-			VCPURatioChange:          vcpuRatioChange,
-		},
+	return models.ScenarioDelta{
+		CapacityChangeGB:         capacityChange,
+		DiskCapacityChangeGB:     diskCapacityChange,
+		UtilizationChangePct:     utilizationChange,
+		DiskUtilizationChangePct: diskUtilizationChange,
+		ResilienceChange:         resilienceChange,
+		VCPURatioChange:          vcpuRatioChange,
 	}
 }
 
@@ -734,8 +1224,6 @@ func (c *ScenarioCalculator) Compare(state models.InfrastructureState, input mod
 // the current state and the proposed input. Returns a slice of ConfigChange
 // describing each modification with its delta and percentage change.
 func DetectChanges(state models.InfrastructureState, input models.ScenarioInput) []models.ConfigChange {
-	var changes []models.ConfigChange
-
 	// Get current cell config from first cluster (assumes uniform cells)
 	var currentCellMemory, currentCellCPU, currentCellDisk int
 	for _, cluster := range state.Clusters {
@@ -746,7 +1234,13 @@ func DetectChanges(state models.InfrastructureState, input models.ScenarioInput)
 			break
 		}
 	}
-	currentCellCount := state.TotalCellCount
+	return detectCellChanges(state.TotalCellCount, currentCellMemory, currentCellCPU, currentCellDisk, input)
+}
+
+// detectCellChanges lists how input's proposed cells differ from the given
+// current cell count and size. Current values of 0 are treated as unknown.
+func detectCellChanges(currentCellCount, currentCellMemory, currentCellCPU, currentCellDisk int, input models.ScenarioInput) []models.ConfigChange {
+	var changes []models.ConfigChange
 
 	// Detect cell count change
 	if input.ProposedCellCount != currentCellCount && currentCellCount > 0 {
@@ -815,9 +1309,9 @@ func CalculateCPURatioFix(state models.InfrastructureState, input models.Scenari
 	}
 
 	// Fix 1: Reduce cell count to achieve target ratio
-	// targetRatio = (cells * cellCPU) / totalPCPUs
-	// cells = (targetRatio * totalPCPUs) / cellCPU
-	targetCells := int(targetRatio * float64(totalPCPUs) / float64(input.ProposedCellCPU))
+	// targetRatio = (cells * cellCPU + platformVMsCPU) / totalPCPUs
+	// cells = (targetRatio * totalPCPUs - platformVMsCPU) / cellCPU
+	targetCells := CalculateMaxCellsByCPU(targetRatio, totalPCPUs, input.ProposedCellCPU, input.PlatformVMsCPU)
 	if targetCells > 0 && targetCells < input.ProposedCellCount {
 		fixes = append(fixes, models.FixSuggestion{
 			Description: fmt.Sprintf("Reduce to %d cells to achieve %.0f:1 ratio", targetCells, targetRatio),
@@ -827,9 +1321,9 @@ func CalculateCPURatioFix(state models.InfrastructureState, input models.Scenari
 	}
 
 	// Fix 2: Reduce vCPU per cell
-	// targetRatio = (cells * cellCPU) / totalPCPUs
-	// cellCPU = (targetRatio * totalPCPUs) / cells
-	targetCellCPU := int(targetRatio * float64(totalPCPUs) / float64(input.ProposedCellCount))
+	// targetRatio = (cells * cellCPU + platformVMsCPU) / totalPCPUs
+	// cellCPU = (targetRatio * totalPCPUs - platformVMsCPU) / cells
+	targetCellCPU := int((targetRatio*float64(totalPCPUs) - float64(input.PlatformVMsCPU)) / float64(input.ProposedCellCount))
 	if targetCellCPU > 0 && targetCellCPU < input.ProposedCellCPU {
 		fixes = append(fixes, models.FixSuggestion{
 			Description: fmt.Sprintf("Reduce cell vCPU to %d to achieve %.0f:1 ratio", targetCellCPU, targetRatio),
@@ -927,3 +1421,46 @@ func CalculateMaxCellsByCPU(targetRatio float64, totalPCPUs, cellCPU, platformVM
 
 	return int(availableForCells) / cellCPU
 }
+
+// CalculateMaxCells returns the maximum number of cells of the given size that
+// keep N-1 memory utilization (cell memory plus platform VMs) at or below
+// targetN1Pct. The CPU-limited maximum at the default 4:1 vCPU:pCPU target is
+// reported separately, along with whichever resource binds first.
+func (c *ScenarioCalculator) CalculateMaxCells(state models.InfrastructureState, cellMemoryGB, cellCPU int, targetN1Pct float64) models.MaxCellsResult {
+	targetVCPURatio := c.targetVCPURatio(0)
+
+	result := models.MaxCellsResult{
+		CellMemoryGB:     cellMemoryGB,
+		CellCPU:          cellCPU,
+		TargetN1Pct:      targetN1Pct,
+		TargetVCPURatio:  targetVCPURatio,
+		CurrentCellCount: state.TotalCellCount,
+	}
+
+	// N-1 utilization = (cells × cellMemory + platformVMs) / n1Memory × 100
+	// cells = (target% × n1Memory - platformVMs) / cellMemory
+	if cellMemoryGB > 0 && state.TotalN1MemoryGB > 0 {
+		availableForCells := targetN1Pct/100*float64(state.TotalN1MemoryGB) - float64(state.PlatformVMsGB)
+		if availableForCells > 0 {
+			result.MaxCellsByMemory = int(availableForCells) / cellMemoryGB
+		}
+	}
+
+	result.MaxCellsByCPU = CalculateMaxCellsByCPU(targetVCPURatio, state.TotalCPUCores, cellCPU, 0)
+	cpuAvailable := cellCPU > 0 && state.TotalCPUCores > 0
+
+	result.MaxCells = result.MaxCellsByMemory
+	result.LimitingResource = "memory"
+	if cpuAvailable {
+		switch {
+		case result.MaxCellsByCPU < result.MaxCellsByMemory:
+			result.MaxCells = result.MaxCellsByCPU
+			result.LimitingResource = "cpu"
+		case result.MaxCellsByCPU == result.MaxCellsByMemory:
+			result.LimitingResource = "balanced"
+		}
+	}
+	result.AdditionalCells = result.MaxCells - state.TotalCellCount
+
+	return result
+}
@@ -173,6 +173,103 @@ func TestGenerateWarnings_LowFreeChunks(t *testing.T) {
 	}
 }
 
+func TestResolveWarningThresholds(t *testing.T) {
+	defaults := DefaultWarningThresholds()
+	if got := ResolveWarningThresholds(models.WarningThresholds{}); got != defaults {
+		t.Errorf("Expected zero thresholds to resolve to defaults, got %+v", got)
+	}
+
+	got := ResolveWarningThresholds(models.WarningThresholds{N1CriticalPct: 90, FreeChunksCritical: 400})
+	if got.N1WarningPct != 80 {
+		t.Errorf("Expected N-1 warning derived as 80, got %.1f", got.N1WarningPct)
+	}
+	if got.FreeChunksWarning != 800 {
+		t.Errorf("Expected free chunks warning derived as 800, got %d", got.FreeChunksWarning)
+	}
+	if got.UtilizationCriticalPct != defaults.UtilizationCriticalPct {
+		t.Errorf("Expected unset utilization threshold to keep default, got %.1f", got.UtilizationCriticalPct)
+	}
+}
+
+func TestGenerateThresholdWarnings_CustomThresholds(t *testing.T) {
+	result := models.ScenarioResult{
+		N1UtilizationPct: 70,
+		FreeChunks:       500,
+		CellCount:        100,
+	}
+
+	// Defaults: 70% N-1 and 500 free chunks pass
+	if warnings := GenerateThresholdWarnings(result, result, nil, nil, models.WarningThresholds{}); len(warnings) != 0 {
+		t.Fatalf("Expected no warnings with default thresholds, got %+v", warnings)
+	}
+
+	tests := []struct {
+		name       string
+		thresholds models.WarningThresholds
+		severity   string
+		message    string
+	}{
+		{"N-1 critical", models.WarningThresholds{N1CriticalPct: 65}, "critical", "Exceeds N-1 capacity safety margin"},
+		{"N-1 warning", models.WarningThresholds{N1CriticalPct: 75}, "warning", "Approaching N-1 capacity limits"},
+		{"Free chunks critical", models.WarningThresholds{FreeChunksCritical: 600}, "critical", "Critical: Low staging capacity"},
+		{"Free chunks warning", models.WarningThresholds{FreeChunksCritical: 400}, "warning", "Low staging capacity"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings := GenerateThresholdWarnings(result, result, nil, nil, tt.thresholds)
+			found := false
+			for _, w := range warnings {
+				if w.Severity == tt.severity && w.Message == tt.message {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("Expected %s warning %q, got %+v", tt.severity, tt.message, warnings)
+			}
+		})
+	}
+}
+
+func TestScenarioCalculator_SetThresholds(t *testing.T) {
+	result := models.ScenarioResult{
+		N1UtilizationPct: 70,
+		FreeChunks:       300,
+		CellCount:        100,
+	}
+
+	calc := NewScenarioCalculator()
+	if warnings := calc.GenerateWarnings(result, result, nil, nil); len(warnings) != 0 {
+		t.Fatalf("Expected no warnings with default thresholds, got %+v", warnings)
+	}
+
+	calc.SetThresholds(models.Thresholds{
+		WarningThresholds: models.WarningThresholds{FreeChunksCritical: 400},
+		VCPURatioModerate: 2,
+	})
+	found := false
+	for _, w := range calc.GenerateWarnings(result, result, nil, nil) {
+		if w.Severity == "critical" && w.Message == "Critical: Low staging capacity" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Expected critical free chunks warning against the configured threshold")
+	}
+
+	// 10 cells x 4 vCPU on 16 pCPU is 2.5:1, moderate once the tier drops to 2:1
+	proposed := calc.CalculateProposed(models.InfrastructureState{}, models.ScenarioInput{
+		ProposedCellMemoryGB: 32,
+		ProposedCellCPU:      4,
+		ProposedCellCount:    10,
+		HostCount:            1,
+		PhysicalCoresPerHost: 16,
+	})
+	if proposed.CPURiskLevel != "moderate" {
+		t.Errorf("CPURiskLevel = %s, want moderate", proposed.CPURiskLevel)
+	}
+}
+
 func TestGenerateWarnings_BlastRadius(t *testing.T) {
 	// Test that blast radius warnings fire based on ABSOLUTE impact, not relative change
 	tests := []struct {
@@ -380,6 +477,72 @@ func TestPercentageOverhead(t *testing.T) {
 	}
 }
 
+func TestOverheadModel(t *testing.T) {
+	// 100 cells running 5000 instances = 50 instances per cell
+	state := models.InfrastructureState{
+		TotalCellCount:    100,
+		TotalAppMemoryGB:  2000,
+		TotalAppInstances: 5000,
+		Clusters: []models.ClusterState{
+			{DiegoCellCount: 100, DiegoCellMemoryGB: 64, DiegoCellCPU: 8},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		overheadPct  float64
+		model        *models.OverheadModel
+		wantCapacity int
+	}{
+		// 64 × 7% = 4.48 → 4 GB overhead
+		{"default percentage", 0, nil, 100 * 60},
+		// 64 × 10% = 6.4 → 6 GB overhead
+		{"percentage only", 10, nil, 100 * 58},
+		// 2 + 64 × 2% (1.28) + 50 × 30 MB (1.46) = 4.74 → 4 GB overhead
+		{"fixed, per-instance, and percentage", 0, &models.OverheadModel{FixedGB: 2, PerInstanceMB: 30, Pct: 2}, 100 * 60},
+		// 1 + 50 × 100 MB (4.88) = 5.88 → 5 GB overhead; pct is not defaulted
+		{"per-instance dominated", 0, &models.OverheadModel{FixedGB: 1, PerInstanceMB: 100}, 100 * 59},
+		// The model takes precedence over overhead_pct
+		{"model overrides percentage", 50, &models.OverheadModel{FixedGB: 3}, 100 * 61},
+	}
+
+	calc := NewScenarioCalculator()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := models.ScenarioInput{
+				ProposedCellMemoryGB: 64,
+				ProposedCellCPU:      8,
+				ProposedCellCount:    100,
+				OverheadPct:          tt.overheadPct,
+				OverheadModel:        tt.model,
+			}
+			result := calc.CalculateProposed(state, input)
+			if result.AppCapacityGB != tt.wantCapacity {
+				t.Errorf("Expected AppCapacityGB %d, got %d", tt.wantCapacity, result.AppCapacityGB)
+			}
+		})
+	}
+}
+
+func TestOverheadModel_ScalesWithInstancesPerCell(t *testing.T) {
+	model := &models.OverheadModel{FixedGB: 1, PerInstanceMB: 64}
+	calc := NewScenarioCalculator()
+
+	capacity := func(instances int) int {
+		state := models.InfrastructureState{TotalAppInstances: instances}
+		input := models.ScenarioInput{ProposedCellMemoryGB: 32, ProposedCellCount: 10, OverheadModel: model}
+		return calc.CalculateProposed(state, input).AppCapacityGB
+	}
+
+	// 16 instances per cell × 64 MB = 1 GB; 160 per cell = 10 GB
+	if got := capacity(160); got != 10*(32-2) {
+		t.Errorf("Sparse fleet: expected AppCapacityGB %d, got %d", 10*(32-2), got)
+	}
+	if got := capacity(1600); got != 10*(32-11) {
+		t.Errorf("Dense fleet: expected AppCapacityGB %d, got %d", 10*(32-11), got)
+	}
+}
+
 func TestTPSEstimation(t *testing.T) {
 	// Test TPS estimation using default curve
 	tests := []struct {
@@ -662,6 +825,100 @@ func TestBlastRadiusPct(t *testing.T) {
 	}
 }
 
+func TestBlastRadiusPct_HostDistribution(t *testing.T) {
+	calc := NewScenarioCalculator()
+
+	t.Run("current uses the busiest cluster's cells per host", func(t *testing.T) {
+		// cluster-a: 40 cells on 8 hosts (5/host); cluster-b: 20 cells on 2 hosts (10/host)
+		state := models.InfrastructureState{
+			TotalCellCount:    60,
+			TotalAppInstances: 600,
+			Clusters: []models.ClusterState{
+				{HostCount: 8, DiegoCellCount: 40, DiegoCellMemoryGB: 32, DiegoCellCPU: 4},
+				{HostCount: 2, DiegoCellCount: 20, DiegoCellMemoryGB: 32, DiegoCellCPU: 4},
+			},
+		}
+		result := calc.CalculateCurrent(state, nil)
+		if result.MaxCellsPerHost != 10 {
+			t.Errorf("Expected MaxCellsPerHost 10, got %d", result.MaxCellsPerHost)
+		}
+		if result.BlastRadiusPct < 16.6 || result.BlastRadiusPct > 16.7 {
+			t.Errorf("Expected BlastRadiusPct ~16.67 (10 of 60 cells), got %.2f", result.BlastRadiusPct)
+		}
+	})
+
+	tests := []struct {
+		name          string
+		cellCount     int
+		hostCount     int
+		wantPerHost   int
+		wantRadiusPct float64
+	}{
+		{"even spread", 100, 10, 10, 10},
+		{"uneven spread rounds up", 10, 4, 3, 30},
+		{"more hosts than cells", 4, 8, 1, 25},
+		{"host count unknown falls back to per cell", 50, 0, 0, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := models.ScenarioInput{
+				ProposedCellMemoryGB: 32,
+				ProposedCellCPU:      4,
+				ProposedCellCount:    tt.cellCount,
+				HostCount:            tt.hostCount,
+			}
+			result := calc.CalculateProposed(models.InfrastructureState{}, input)
+			if result.MaxCellsPerHost != tt.wantPerHost {
+				t.Errorf("Expected MaxCellsPerHost %d, got %d", tt.wantPerHost, result.MaxCellsPerHost)
+			}
+			if result.BlastRadiusPct != tt.wantRadiusPct {
+				t.Errorf("Expected BlastRadiusPct %.1f, got %.1f", tt.wantRadiusPct, result.BlastRadiusPct)
+			}
+		})
+	}
+}
+
+func TestGenerateThresholdWarnings_HostFailure(t *testing.T) {
+	tests := []struct {
+		name         string
+		radiusPct    float64
+		thresholds   models.WarningThresholds
+		wantSeverity string
+	}{
+		{"four hosts pass defaults", 25, models.WarningThresholds{}, ""},
+		{"three hosts warn", 34, models.WarningThresholds{}, "warning"},
+		{"two hosts are critical", 50, models.WarningThresholds{}, "critical"},
+		{"custom warning threshold", 25, models.WarningThresholds{HostFailureWarningPct: 20}, "warning"},
+		// Host blast radius is judged by the host thresholds, not the cell ones
+		{"cell thresholds ignored", 25, models.WarningThresholds{BlastRadiusCriticalPct: 5}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := models.ScenarioResult{
+				CellCount:        40,
+				FreeChunks:       500,
+				MaxSingleChunkGB: 16,
+				BlastRadiusPct:   tt.radiusPct,
+				MaxCellsPerHost:  10,
+			}
+			severity := ""
+			for _, w := range GenerateThresholdWarnings(result, result, nil, nil, tt.thresholds) {
+				if contains(w.Message, "host failure impact") {
+					severity = w.Severity
+				}
+				if contains(w.Message, "cell failure impact") {
+					t.Errorf("Unexpected cell failure warning: %s", w.Message)
+				}
+			}
+			if severity != tt.wantSeverity {
+				t.Errorf("Expected host failure severity %q, got %q", tt.wantSeverity, severity)
+			}
+		})
+	}
+}
+
 func TestResilienceWarning_LargeFoundation_NoWarning(t *testing.T) {
 	// 500 → 250 cells is a 50% reduction, but blast radius only goes from 0.2% → 0.4%
 	// This should NOT trigger a resilience warning - it's still very safe
@@ -1631,6 +1888,113 @@ func TestCPUHeadroomCells(t *testing.T) {
 	}
 }
 
+func TestCalculateProposed_ExceedsCPUCapacity(t *testing.T) {
+	tests := []struct {
+		name           string
+		cellCount      int
+		targetRatio    int
+		platformVMsCPU int
+		wantTarget     float64
+		wantMaxCells   int
+		wantExceeds    bool
+	}{
+		// 3 hosts x 32 pCPU = 96 pCPU, 4 vCPU cells
+		{"within default target", 90, 0, 0, 4, 96, false},
+		{"over default target", 100, 0, 0, 4, 96, true},
+		{"over explicit target", 60, 2, 0, 2, 48, true},
+		{"over target once platform VMs count", 95, 4, 24, 4, 90, true},
+		{"platform VMs alone exceed target", 1, 1, 100, 1, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewScenarioCalculator().CalculateProposed(models.InfrastructureState{}, models.ScenarioInput{
+				ProposedCellCount:    tt.cellCount,
+				ProposedCellCPU:      4,
+				ProposedCellMemoryGB: 32,
+				HostCount:            3,
+				PhysicalCoresPerHost: 32,
+				TargetVCPURatio:      tt.targetRatio,
+				PlatformVMsCPU:       tt.platformVMsCPU,
+			})
+
+			if result.TotalPCPUs != 96 {
+				t.Errorf("TotalPCPUs = %d, want 96", result.TotalPCPUs)
+			}
+			if result.TotalVCPUs != tt.cellCount*4 {
+				t.Errorf("TotalVCPUs = %d, want %d", result.TotalVCPUs, tt.cellCount*4)
+			}
+			if want := float64(tt.cellCount*4) / 96; result.VCPURatio != want {
+				t.Errorf("VCPURatio = %f, want %f", result.VCPURatio, want)
+			}
+			if result.TargetVCPURatio != tt.wantTarget {
+				t.Errorf("TargetVCPURatio = %g, want %g", result.TargetVCPURatio, tt.wantTarget)
+			}
+			if result.MaxCellsByCPU != tt.wantMaxCells {
+				t.Errorf("MaxCellsByCPU = %d, want %d", result.MaxCellsByCPU, tt.wantMaxCells)
+			}
+			if result.ExceedsCPUCapacity != tt.wantExceeds {
+				t.Errorf("ExceedsCPUCapacity = %v, want %v", result.ExceedsCPUCapacity, tt.wantExceeds)
+			}
+		})
+	}
+}
+
+func TestCalculateProposed_TargetVCPURatioFromThresholds(t *testing.T) {
+	calc := NewScenarioCalculator()
+	calc.SetThresholds(models.Thresholds{VCPURatioModerate: 2, VCPURatioAggressive: 8})
+
+	// 3 hosts x 32 pCPU at 2:1 = 192 vCPU = 48 cells of 4 vCPU
+	result := calc.CalculateProposed(models.InfrastructureState{}, models.ScenarioInput{
+		ProposedCellCount:    50,
+		ProposedCellCPU:      4,
+		ProposedCellMemoryGB: 32,
+		HostCount:            3,
+		PhysicalCoresPerHost: 32,
+	})
+
+	if result.TargetVCPURatio != 2 {
+		t.Errorf("TargetVCPURatio = %g, want 2", result.TargetVCPURatio)
+	}
+	if result.MaxCellsByCPU != 48 || !result.ExceedsCPUCapacity {
+		t.Errorf("MaxCellsByCPU = %d, ExceedsCPUCapacity = %v, want 48 and true", result.MaxCellsByCPU, result.ExceedsCPUCapacity)
+	}
+}
+
+func TestGenerateWarnings_CPUCapacityExceededByPlatformVMs(t *testing.T) {
+	calc := NewScenarioCalculator()
+	state := models.InfrastructureState{}
+	input := models.ScenarioInput{
+		ProposedCellCount:    95,
+		ProposedCellCPU:      4,
+		ProposedCellMemoryGB: 32,
+		HostCount:            3,
+		PhysicalCoresPerHost: 32,
+		TargetVCPURatio:      4,
+		PlatformVMsCPU:       24,
+	}
+
+	// 95 x 4 = 380 vCPU is under 4:1 on 96 pCPU, but 24 platform vCPUs push it over
+	proposed := calc.CalculateProposed(state, input)
+	warnings := calc.GenerateWarnings(models.ScenarioResult{}, proposed, nil, &WarningsContext{State: state, Input: input})
+
+	var found *models.ScenarioWarning
+	for i, w := range warnings {
+		if strings.Contains(w.Message, "95 cells exceed the 90 cells CPU supports at 4:1") {
+			found = &warnings[i]
+		}
+		if strings.Contains(w.Message, "exceeds target") {
+			t.Errorf("Unexpected ratio warning: %s", w.Message)
+		}
+	}
+	if found == nil {
+		t.Fatalf("Expected CPU capacity warning, got %+v", warnings)
+	}
+	if len(found.Fixes) == 0 || found.Fixes[0].Field != "cell_count" || found.Fixes[0].Value != 90 {
+		t.Errorf("Expected fix reducing to 90 cells, got %+v", found.Fixes)
+	}
+}
+
 func TestCalculateMaxCellsByCPU(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -1692,6 +2056,69 @@ func TestCalculateMaxCellsByCPU(t *testing.T) {
 	}
 }
 
+func TestCalculateMaxCells(t *testing.T) {
+	tests := []struct {
+		name         string
+		state        models.InfrastructureState
+		cellMemoryGB int
+		cellCPU      int
+		target       float64
+		wantMemory   int
+		wantCPU      int
+		wantMax      int
+		wantLimiting string
+	}{
+		{
+			name: "memory limited",
+			// (0.85 × 3072 - 200) / 64 = 2411.2 / 64 = 37
+			state:        models.InfrastructureState{TotalN1MemoryGB: 3072, PlatformVMsGB: 200, TotalCPUCores: 256, TotalCellCount: 20},
+			cellMemoryGB: 64, cellCPU: 8, target: 85,
+			wantMemory: 37, wantCPU: 128, wantMax: 37, wantLimiting: "memory",
+		},
+		{
+			name: "cpu limited",
+			// CPU: 4 × 32 / 8 = 16
+			state:        models.InfrastructureState{TotalN1MemoryGB: 3072, TotalCPUCores: 32, TotalCellCount: 10},
+			cellMemoryGB: 64, cellCPU: 8, target: 85,
+			wantMemory: 40, wantCPU: 16, wantMax: 16, wantLimiting: "cpu",
+		},
+		{
+			name:         "no CPU data falls back to memory",
+			state:        models.InfrastructureState{TotalN1MemoryGB: 1000},
+			cellMemoryGB: 50, cellCPU: 8, target: 50,
+			wantMemory: 10, wantCPU: 0, wantMax: 10, wantLimiting: "memory",
+		},
+		{
+			name:         "platform VMs exceed target",
+			state:        models.InfrastructureState{TotalN1MemoryGB: 1000, PlatformVMsGB: 900},
+			cellMemoryGB: 64, cellCPU: 8, target: 85,
+			wantMemory: 0, wantCPU: 0, wantMax: 0, wantLimiting: "memory",
+		},
+	}
+
+	calc := NewScenarioCalculator()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := calc.CalculateMaxCells(tt.state, tt.cellMemoryGB, tt.cellCPU, tt.target)
+			if got.MaxCellsByMemory != tt.wantMemory {
+				t.Errorf("MaxCellsByMemory = %d, want %d", got.MaxCellsByMemory, tt.wantMemory)
+			}
+			if got.MaxCellsByCPU != tt.wantCPU {
+				t.Errorf("MaxCellsByCPU = %d, want %d", got.MaxCellsByCPU, tt.wantCPU)
+			}
+			if got.MaxCells != tt.wantMax {
+				t.Errorf("MaxCells = %d, want %d", got.MaxCells, tt.wantMax)
+			}
+			if got.LimitingResource != tt.wantLimiting {
+				t.Errorf("LimitingResource = %q, want %q", got.LimitingResource, tt.wantLimiting)
+			}
+			if got.AdditionalCells != tt.wantMax-tt.state.TotalCellCount {
+				t.Errorf("AdditionalCells = %d, want %d", got.AdditionalCells, tt.wantMax-tt.state.TotalCellCount)
+			}
+		})
+	}
+}
+
 // ============================================================================
 // SELECTED RESOURCES FILTER TESTS
 // ============================================================================
@@ -2109,3 +2536,540 @@ func TestCompare_HAInsufficientWarning_FilteredWhenMemoryNotSelected(t *testing.
 		}
 	}
 }
+
+func TestCompare_HAAdmissionMismatchWarning(t *testing.T) {
+	state := models.InfrastructureState{
+		Source:         "vsphere",
+		TotalCellCount: 20,
+		Clusters: []models.ClusterState{
+			{Name: "cluster-a", HAAdmissionControlPercentage: 25, DiegoCellCount: 10, DiegoCellMemoryGB: 32, DiegoCellCPU: 4},
+			{Name: "cluster-b", HAAdmissionControlPercentage: 0, DiegoCellCount: 10, DiegoCellMemoryGB: 32, DiegoCellCPU: 4},
+		},
+	}
+	input := models.ScenarioInput{
+		ProposedCellMemoryGB: 32,
+		ProposedCellCPU:      4,
+		ProposedCellCount:    20,
+		SelectedResources:    []string{"memory"},
+	}
+
+	tests := []struct {
+		name     string
+		source   string
+		haPct    int
+		target   string
+		wantWarn bool
+	}{
+		{"matches discovered value", "vsphere", 25, "", false},
+		{"differs from discovered value", "vsphere", 10, "", true},
+		{"differs only on a cluster without a discovered value", "vsphere", 10, "cluster-b", false},
+		{"manual source is never checked", "manual", 10, "", false},
+		{"no scenario value", "vsphere", 0, "", false},
+	}
+
+	calc := NewScenarioCalculator()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := state
+			s.Source = tt.source
+			in := input
+			in.HAAdmissionPct = tt.haPct
+			in.TargetCluster = tt.target
+
+			var found *models.ScenarioWarning
+			for _, w := range calc.Compare(s, in).Warnings {
+				if strings.Contains(w.Message, "vSphere reports") {
+					found = &w
+					break
+				}
+			}
+			if tt.wantWarn != (found != nil) {
+				t.Fatalf("mismatch warning present = %v, want %v", found != nil, tt.wantWarn)
+			}
+			if found != nil && !strings.Contains(found.Message, "cluster-a (25%)") {
+				t.Errorf("Expected warning to name cluster-a (25%%), got %q", found.Message)
+			}
+		})
+	}
+}
+
+func TestCalculateProposed_HostsToRemove(t *testing.T) {
+	// 10 hosts × 512 GB, one cluster: N-1 = 9 × 512 = 4608 GB
+	state := models.InfrastructureState{
+		TotalMemoryGB:     5120,
+		TotalN1MemoryGB:   4608,
+		TotalHostCount:    10,
+		TotalCellCount:    100,
+		PlatformVMsGB:     200,
+		TotalAppMemoryGB:  2000,
+		TotalAppInstances: 1000,
+		Clusters: []models.ClusterState{
+			{DiegoCellCount: 100, DiegoCellMemoryGB: 32, DiegoCellCPU: 4},
+		},
+	}
+
+	input := models.ScenarioInput{
+		ProposedCellMemoryGB: 32,
+		ProposedCellCPU:      4,
+		ProposedCellCount:    100,
+		HostCount:            10,
+		PhysicalCoresPerHost: 32,
+		HostsToRemove:        2,
+	}
+
+	calc := NewScenarioCalculator()
+	result := calc.CalculateProposed(state, input)
+
+	if result.HostsRemoved != 2 {
+		t.Errorf("Expected HostsRemoved 2, got %d", result.HostsRemoved)
+	}
+	// 2 of 10 hosts carry 20 of 100 cells
+	if result.CellsToEvacuate != 20 {
+		t.Errorf("Expected CellsToEvacuate 20, got %d", result.CellsToEvacuate)
+	}
+	// N-1-2: (3200 + 200) / (4608 - 1024) × 100 = 94.9%
+	if result.N1UtilizationPct < 94.8 || result.N1UtilizationPct > 95.0 {
+		t.Errorf("Expected N1UtilizationPct ~94.9%%, got %.1f%%", result.N1UtilizationPct)
+	}
+	// pCPUs come from the 8 remaining hosts
+	if result.TotalPCPUs != 8*32 {
+		t.Errorf("Expected TotalPCPUs %d, got %d", 8*32, result.TotalPCPUs)
+	}
+
+	input.HostsToRemove = 0
+	baseline := calc.CalculateProposed(state, input)
+	if baseline.HostsRemoved != 0 || baseline.CellsToEvacuate != 0 {
+		t.Errorf("Expected no host removal metrics, got removed=%d evacuate=%d", baseline.HostsRemoved, baseline.CellsToEvacuate)
+	}
+}
+
+func TestGenerateWarnings_HostRemovalOverCapacity(t *testing.T) {
+	calc := NewScenarioCalculator()
+
+	proposed := models.ScenarioResult{
+		CellCount:        100,
+		N1UtilizationPct: 112,
+		FreeChunks:       100,
+		HostsRemoved:     3,
+		CellsToEvacuate:  30,
+	}
+	warnings := calc.GenerateWarnings(models.ScenarioResult{}, proposed, nil, nil)
+
+	found := false
+	for _, w := range warnings {
+		if w.Severity == "critical" && strings.HasPrefix(w.Message, "Removing 3 host(s)") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected critical host removal warning, got %+v", warnings)
+	}
+
+	// Same utilization without host removal only raises the generic N-1 warning
+	proposed.HostsRemoved = 0
+	for _, w := range calc.GenerateWarnings(models.ScenarioResult{}, proposed, nil, nil) {
+		if strings.HasPrefix(w.Message, "Removing") {
+			t.Errorf("Unexpected host removal warning without removed hosts: %s", w.Message)
+		}
+	}
+}
+
+func TestValidateHostRemoval(t *testing.T) {
+	state := models.InfrastructureState{
+		TotalMemoryGB:   2048,
+		TotalN1MemoryGB: 1536,
+		TotalHostCount:  4,
+	}
+
+	tests := []struct {
+		name    string
+		state   models.InfrastructureState
+		remove  int
+		wantErr bool
+	}{
+		{"none", state, 0, false},
+		{"leaves capacity", state, 2, false},
+		{"removes all spare hosts", state, 3, true},
+		{"negative", state, -1, true},
+		{"no hosts in state", models.InfrastructureState{}, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateHostRemoval(tt.state, models.ScenarioInput{HostsToRemove: tt.remove})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateHostRemoval() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateOverheadModel(t *testing.T) {
+	state := models.InfrastructureState{TotalAppInstances: 3200}
+
+	tests := []struct {
+		name    string
+		model   *models.OverheadModel
+		wantErr bool
+	}{
+		{"no model", nil, false},
+		{"valid model", &models.OverheadModel{FixedGB: 2, PerInstanceMB: 30, Pct: 2}, false},
+		{"negative fixed", &models.OverheadModel{FixedGB: -1}, true},
+		{"negative per-instance", &models.OverheadModel{PerInstanceMB: -30}, true},
+		{"negative pct", &models.OverheadModel{Pct: -5}, true},
+		{"pct of 100", &models.OverheadModel{Pct: 100}, true},
+		// 100 instances per cell × 320 MB = 31.25 GB, plus 1 GB fixed
+		{"consumes whole cell", &models.OverheadModel{FixedGB: 1, PerInstanceMB: 320}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := models.ScenarioInput{ProposedCellMemoryGB: 32, ProposedCellCount: 32, OverheadModel: tt.model}
+			err := ValidateOverheadModel(state, input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateOverheadModel() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMaxSingleChunkGB(t *testing.T) {
+	state := models.InfrastructureState{
+		TotalN1MemoryGB:  4096,
+		TotalCellCount:   10,
+		TotalAppMemoryGB: 250,
+		Clusters: []models.ClusterState{
+			{DiegoCellCount: 10, DiegoCellMemoryGB: 32, DiegoCellCPU: 4},
+		},
+	}
+
+	calc := NewScenarioCalculator()
+
+	// 32 GB cell - 2 GB overhead (7%) - 25 GB average usage = 5 GB
+	current := calc.CalculateCurrent(state, nil)
+	if current.MaxSingleChunkGB != 5 {
+		t.Errorf("Expected current MaxSingleChunkGB 5, got %d", current.MaxSingleChunkGB)
+	}
+
+	// Larger cells carry more headroom per cell: 64 - 4 - 25 = 35 GB
+	proposed := calc.CalculateProposed(state, models.ScenarioInput{
+		ProposedCellMemoryGB: 64,
+		ProposedCellCPU:      8,
+		ProposedCellCount:    10,
+	})
+	if proposed.MaxSingleChunkGB != 35 {
+		t.Errorf("Expected proposed MaxSingleChunkGB 35, got %d", proposed.MaxSingleChunkGB)
+	}
+
+	// Overcommitted cells floor at zero
+	state.TotalAppMemoryGB = 400
+	if got := calc.CalculateCurrent(state, nil).MaxSingleChunkGB; got != 0 {
+		t.Errorf("Expected MaxSingleChunkGB 0 when cells are full, got %d", got)
+	}
+}
+
+func TestGenerateThresholdWarnings_MinSingleChunk(t *testing.T) {
+	result := models.ScenarioResult{
+		CellCount:        10,
+		CellMemoryGB:     32,
+		N1UtilizationPct: 50,
+		FreeChunks:       500,
+		MaxSingleChunkGB: 5,
+	}
+
+	hasChunkWarning := func(warnings []models.ScenarioWarning) bool {
+		for _, w := range warnings {
+			if strings.HasPrefix(w.Message, "Largest stageable app") {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Default minimum is 4 GB, so 5 GB passes
+	if hasChunkWarning(GenerateThresholdWarnings(result, result, nil, nil, models.WarningThresholds{})) {
+		t.Error("Expected no single chunk warning with the default 4 GB minimum")
+	}
+
+	warnings := GenerateThresholdWarnings(result, result, nil, nil, models.WarningThresholds{MinSingleChunkGB: 8})
+	if !hasChunkWarning(warnings) {
+		t.Errorf("Expected single chunk warning with an 8 GB minimum, got %+v", warnings)
+	}
+
+	// Not shown when memory is not selected
+	ctx := &WarningsContext{Input: models.ScenarioInput{SelectedResources: []string{"cpu"}}}
+	if hasChunkWarning(GenerateThresholdWarnings(result, result, nil, ctx, models.WarningThresholds{MinSingleChunkGB: 8})) {
+		t.Error("Expected no single chunk warning when memory is not selected")
+	}
+}
+
+func TestGenerateWarnings_CriticalFirstWithCodes(t *testing.T) {
+	proposed := models.ScenarioResult{
+		N1UtilizationPct:   80, // warning
+		FreeChunks:         5,  // critical
+		UtilizationPct:     85, // warning
+		DiskUtilizationPct: 95, // critical
+		CellCount:          100,
+	}
+
+	calc := NewScenarioCalculator()
+	warnings := calc.GenerateWarnings(models.ScenarioResult{}, proposed, nil, nil)
+
+	want := []struct{ severity, code string }{
+		{"critical", models.WarningLowFreeChunks},
+		{"critical", models.WarningDiskUtilization},
+		{"warning", models.WarningN1Approaching},
+		{"warning", models.WarningCellUtilization},
+	}
+	if len(warnings) != len(want) {
+		t.Fatalf("Expected %d warnings, got %+v", len(want), warnings)
+	}
+	for i, w := range want {
+		if warnings[i].Severity != w.severity || warnings[i].Code != w.code {
+			t.Errorf("warnings[%d] = %s/%s, want %s/%s", i, warnings[i].Severity, warnings[i].Code, w.severity, w.code)
+		}
+	}
+}
+
+func TestOrganizeWarnings_DeduplicatesAndCaps(t *testing.T) {
+	var warnings []models.ScenarioWarning
+	for i := 0; i < 10; i++ {
+		warnings = append(warnings, models.ScenarioWarning{Severity: "warning", Code: "w", Message: fmt.Sprintf("warning %d", i)})
+	}
+	warnings = append(warnings,
+		models.ScenarioWarning{Severity: "info", Code: "i", Message: "warning 3"},
+		models.ScenarioWarning{Severity: "critical", Code: "c", Message: "critical"},
+	)
+
+	organized := organizeWarnings(warnings)
+
+	if len(organized) != maxScenarioWarnings {
+		t.Fatalf("Expected %d warnings, got %d: %+v", maxScenarioWarnings, len(organized), organized)
+	}
+	if organized[0].Code != "c" {
+		t.Errorf("Expected critical warning first, got %+v", organized[0])
+	}
+	for _, w := range organized {
+		if w.Code == "i" {
+			t.Errorf("Expected repeated message dropped, got %+v", w)
+		}
+	}
+	// 11 distinct messages: 7 shown, 4 summarized
+	last := organized[len(organized)-1]
+	if last.Code != models.WarningMoreWarnings || last.Severity != "info" || last.Message != "+4 more warnings" {
+		t.Errorf("Expected +4 more summary, got %+v", last)
+	}
+}
+
+func TestOrganizeWarnings_UnderCapUnchanged(t *testing.T) {
+	warnings := []models.ScenarioWarning{
+		{Severity: "warning", Code: "a", Message: "a"},
+		{Severity: "warning", Code: "b", Message: "b"},
+	}
+	organized := organizeWarnings(warnings)
+	if len(organized) != 2 || organized[0].Code != "a" || organized[1].Code != "b" {
+		t.Errorf("Expected generation order kept within a severity, got %+v", organized)
+	}
+}
+
+func TestCompare_BaselineInputReplacesCurrent(t *testing.T) {
+	state := models.InfrastructureState{
+		TotalN1MemoryGB:   26624,
+		TotalCellCount:    470,
+		TotalAppMemoryGB:  10500,
+		TotalAppInstances: 7500,
+		Clusters: []models.ClusterState{
+			{DiegoCellCount: 470, DiegoCellMemoryGB: 32, DiegoCellCPU: 4},
+		},
+	}
+	optionA := models.ScenarioInput{ProposedCellMemoryGB: 64, ProposedCellCPU: 8, ProposedCellCount: 250}
+	optionB := models.ScenarioInput{ProposedCellMemoryGB: 64, ProposedCellCPU: 8, ProposedCellCount: 300, BaselineInput: &optionA}
+
+	calc := NewScenarioCalculator()
+	comparison := calc.Compare(state, optionB)
+	wantA := calc.CalculateProposed(state, optionA)
+
+	if comparison.Current.CellCount != 250 || comparison.Current.AppCapacityGB != wantA.AppCapacityGB {
+		t.Errorf("Expected option A as current (250 cells, %d GB), got %d cells, %d GB",
+			wantA.AppCapacityGB, comparison.Current.CellCount, comparison.Current.AppCapacityGB)
+	}
+	if comparison.Proposed.CellCount != 300 {
+		t.Errorf("Expected Proposed.CellCount 300, got %d", comparison.Proposed.CellCount)
+	}
+	wantDelta := comparison.Proposed.AppCapacityGB - wantA.AppCapacityGB
+	if comparison.Delta.CapacityChangeGB != wantDelta {
+		t.Errorf("Expected capacity change %d between the options, got %d", wantDelta, comparison.Delta.CapacityChangeGB)
+	}
+
+	// Without a baseline input the live state is still the current side
+	optionB.BaselineInput = nil
+	if got := calc.Compare(state, optionB).Current.CellCount; got != 470 {
+		t.Errorf("Expected current from state (470 cells) without baseline_input, got %d", got)
+	}
+}
+
+func TestDetectCellChanges_AgainstBaselineInput(t *testing.T) {
+	input := models.ScenarioInput{ProposedCellMemoryGB: 64, ProposedCellCPU: 8, ProposedCellCount: 300}
+
+	changes := detectCellChanges(250, 64, 8, 0, input)
+
+	if len(changes) != 1 || changes[0].Field != "cell_count" || changes[0].PreviousVal != 250 || changes[0].Delta != 50 {
+		t.Errorf("Expected one cell_count change from 250 by 50, got %+v", changes)
+	}
+}
+
+func TestCompareExplained_TraceMatchesResults(t *testing.T) {
+	state := models.InfrastructureState{
+		TotalN1MemoryGB:   3072,
+		TotalCellCount:    40,
+		TotalAppMemoryGB:  400,
+		TotalAppInstances: 2000,
+		Clusters: []models.ClusterState{
+			{HostCount: 4, DiegoCellCount: 40, DiegoCellMemoryGB: 32, DiegoCellCPU: 4},
+		},
+	}
+	input := models.ScenarioInput{
+		ProposedCellMemoryGB: 64,
+		ProposedCellCPU:      8,
+		ProposedCellCount:    24,
+		HostCount:            4,
+		PhysicalCoresPerHost: 32,
+	}
+
+	calc := NewScenarioCalculator()
+	if calc.Compare(state, input).CalculationTrace != nil {
+		t.Error("Expected no calculation trace from Compare")
+	}
+
+	comparison := calc.CompareExplained(state, input)
+	if comparison.CalculationTrace == nil {
+		t.Fatal("Expected a calculation trace from CompareExplained")
+	}
+
+	steps := func(trace []models.CalculationStep) map[string]models.CalculationStep {
+		byKey := make(map[string]models.CalculationStep)
+		for _, s := range trace {
+			if s.Formula == "" || s.Expression == "" {
+				t.Errorf("Expected formula and expression for %q, got %+v", s.Key, s)
+			}
+			byKey[s.Key] = s
+		}
+		return byKey
+	}
+	for side, tt := range map[string]struct {
+		result models.ScenarioResult
+		trace  []models.CalculationStep
+	}{
+		"current":  {comparison.Current, comparison.CalculationTrace.Current},
+		"proposed": {comparison.Proposed, comparison.CalculationTrace.Proposed},
+	} {
+		byKey := steps(tt.trace)
+		for key, want := range map[string]float64{
+			"app_capacity_gb":     float64(tt.result.AppCapacityGB),
+			"utilization_pct":     tt.result.UtilizationPct,
+			"free_chunks":         float64(tt.result.FreeChunks),
+			"max_single_chunk_gb": float64(tt.result.MaxSingleChunkGB),
+			"fault_impact":        float64(tt.result.FaultImpact),
+			"n1_utilization_pct":  tt.result.N1UtilizationPct,
+			"blast_radius_pct":    tt.result.BlastRadiusPct,
+		} {
+			step, ok := byKey[key]
+			if !ok {
+				t.Errorf("%s: expected a %q step", side, key)
+				continue
+			}
+			if step.Value != want {
+				t.Errorf("%s: expected %q = %v, got %v", side, key, want, step.Value)
+			}
+		}
+	}
+
+	proposed := steps(comparison.CalculationTrace.Proposed)
+	if got := proposed["usable_memory_per_cell_gb"]; got.Value != 60 || got.Expression != "64 − 4" {
+		t.Errorf("Expected usable memory per cell 64 − 4 = 60, got %+v", got)
+	}
+	if got := proposed["vcpu_ratio"]; got.Value != comparison.Proposed.VCPURatio {
+		t.Errorf("Expected vcpu_ratio %v, got %+v", comparison.Proposed.VCPURatio, got)
+	}
+}
+
+func TestCompare_TargetCluster(t *testing.T) {
+	input := models.ManualInput{
+		Clusters: []models.ClusterInput{
+			{Name: "cluster-a", HostCount: 4, MemoryGBPerHost: 1024, CPUThreadsPerHost: 64, DiegoCellCount: 40, DiegoCellMemoryGB: 32, DiegoCellCPU: 4},
+			{Name: "cluster-b", HostCount: 4, MemoryGBPerHost: 1024, CPUThreadsPerHost: 64, DiegoCellCount: 10, DiegoCellMemoryGB: 32, DiegoCellCPU: 4},
+		},
+		PlatformVMsGB:     200,
+		TotalAppMemoryGB:  800,
+		TotalAppInstances: 500,
+	}
+	state := input.ToInfrastructureState()
+	calc := NewScenarioCalculator()
+
+	scenario := models.ScenarioInput{
+		ProposedCellMemoryGB: 32,
+		ProposedCellCPU:      4,
+		ProposedCellCount:    48,
+		TargetCluster:        "cluster-a",
+	}
+	comparison := calc.Compare(state, scenario)
+
+	if comparison.Current.CellCount != 40 || comparison.Proposed.CellCount != 48 {
+		t.Errorf("Expected cluster-a's 40 cells going to 48, got %d -> %d", comparison.Current.CellCount, comparison.Proposed.CellCount)
+	}
+	// cluster-a alone: N-1 = 3 × 1024 GB; platform VMs take half of 200 GB
+	wantN1 := float64(48*32+100) / 3072 * 100
+	if math.Abs(comparison.Proposed.N1UtilizationPct-wantN1) > 0.01 {
+		t.Errorf("Expected proposed N-1 utilization %.2f%%, got %.2f%%", wantN1, comparison.Proposed.N1UtilizationPct)
+	}
+	// 400 of the 500 instances run on cluster-a's 40 cells
+	if comparison.Current.InstancesPerCell != 10 {
+		t.Errorf("Expected 10 instances per current cell, got %.2f", comparison.Current.InstancesPerCell)
+	}
+	if comparison.TargetCluster != "cluster-a" {
+		t.Errorf("Expected target cluster cluster-a, got %q", comparison.TargetCluster)
+	}
+	if len(comparison.UnchangedClusters) != 1 || comparison.UnchangedClusters[0].Name != "cluster-b" || comparison.UnchangedClusters[0].DiegoCellCount != 10 {
+		t.Errorf("Expected cluster-b reported as unchanged, got %+v", comparison.UnchangedClusters)
+	}
+
+	// Without a target the whole foundation is one pool
+	scenario.TargetCluster = ""
+	foundation := calc.Compare(state, scenario)
+	if foundation.Current.CellCount != 50 || foundation.TargetCluster != "" || foundation.UnchangedClusters != nil {
+		t.Errorf("Expected a foundation-wide comparison, got %d cells, target %q, unchanged %+v",
+			foundation.Current.CellCount, foundation.TargetCluster, foundation.UnchangedClusters)
+	}
+}
+
+func TestCalculateProposed_TargetCluster(t *testing.T) {
+	input := models.ManualInput{
+		Clusters: []models.ClusterInput{
+			{Name: "cluster-a", HostCount: 4, MemoryGBPerHost: 1024, DiegoCellCount: 40, DiegoCellMemoryGB: 32, DiegoCellCPU: 4},
+			{Name: "cluster-b", HostCount: 4, MemoryGBPerHost: 1024, DiegoCellCount: 10, DiegoCellMemoryGB: 32, DiegoCellCPU: 4},
+		},
+		TotalAppMemoryGB: 800,
+	}
+	state := input.ToInfrastructureState()
+
+	scenario := models.ScenarioInput{ProposedCellMemoryGB: 32, ProposedCellCPU: 4, ProposedCellCount: 40, TargetCluster: "cluster-a"}
+	result := NewScenarioCalculator().CalculateProposed(state, scenario)
+	if want := float64(40*32) / 3072 * 100; math.Abs(result.N1UtilizationPct-want) > 0.01 {
+		t.Errorf("Expected N-1 utilization of cluster-a alone (%.2f%%), got %.2f%%", want, result.N1UtilizationPct)
+	}
+}
+
+func TestValidateTargetCluster(t *testing.T) {
+	state := models.InfrastructureState{Clusters: []models.ClusterState{{Name: "cluster-a"}}}
+
+	if err := ValidateTargetCluster(state, models.ScenarioInput{}); err != nil {
+		t.Errorf("Expected no target to be valid, got %v", err)
+	}
+	if err := ValidateTargetCluster(state, models.ScenarioInput{TargetCluster: "cluster-a"}); err != nil {
+		t.Errorf("Expected cluster-a to be valid, got %v", err)
+	}
+	err := ValidateTargetCluster(state, models.ScenarioInput{TargetCluster: "cluster-z"})
+	if err == nil || !strings.Contains(err.Error(), `"cluster-z" not found`) {
+		t.Errorf("Expected an unknown cluster error, got %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+// ABOUTME: Records the intermediate arithmetic of a scenario calculation
+// ABOUTME: Lets ?explain=true comparisons show how each result was derived
+
+package services
+
+import (
+	"fmt"
+
+	"github.com/markalston/diego-capacity-analyzer/backend/models"
+)
+
+// calculationTrace collects the steps of one scenario calculation. Methods on
+// a nil trace do nothing, so calculations that are not explained pass nil.
+type calculationTrace struct {
+	steps []models.CalculationStep
+}
+
+// record adds the step key computed by formula. expression and args format
+// the formula with the numbers used. Recording a key again replaces the
+// earlier step, for values that are refined after the core calculation.
+func (t *calculationTrace) record(key, formula string, value float64, expression string, args ...any) {
+	if t == nil {
+		return
+	}
+	step := models.CalculationStep{
+		Key:        key,
+		Formula:    formula,
+		Expression: fmt.Sprintf(expression, args...),
+		Value:      value,
+	}
+	for i := range t.steps {
+		if t.steps[i].Key == key {
+			t.steps[i] = step
+			return
+		}
+	}
+	t.steps = append(t.steps, step)
+}
+
+// Steps returns the recorded steps in calculation order
+func (t *calculationTrace) Steps() []models.CalculationStep {
+	if t == nil {
+		return nil
+	}
+	return t.steps
+}
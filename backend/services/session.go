@@ -1,31 +1,79 @@
 // ABOUTME: Session management service for BFF OAuth pattern
-// ABOUTME: Stores and retrieves auth sessions using cache backend
+// ABOUTME: Stores and retrieves auth sessions through a pluggable SessionStore
 
 package services
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
 	"time"
 
-	"github.com/markalston/diego-capacity-analyzer/backend/cache"
 	"github.com/markalston/diego-capacity-analyzer/backend/models"
 )
 
+// ErrSessionExpired means a session's tokens could not be renewed and the user
+// must log in again
+var ErrSessionExpired = errors.New("session expired")
+
+// ErrRefreshTokenReused means a refresh token that had already been rotated
+// out was redeemed again, a sign it was copied. The session is revoked.
+var ErrRefreshTokenReused = errors.New("superseded refresh token reused")
+
+// refreshReuseGrace is how long after a rotation the refresh token it replaced
+// is still taken for a concurrent renewal by another replica, not a replay
+const refreshReuseGrace = 30 * time.Second
+
+// RefreshedTokens holds the result of a refresh_token grant
+type RefreshedTokens struct {
+	AccessToken  string
+	RefreshToken string
+	Scopes       []string
+	Expiry       time.Time
+}
+
+// TokenRefresher exchanges a refresh token for new tokens with the identity provider
+type TokenRefresher func(refreshToken string) (RefreshedTokens, error)
+
 // SessionService manages server-side authentication sessions
 type SessionService struct {
-	cache *cache.Cache
+	store       SessionStore
+	refresher   TokenRefresher
+	refreshMu   sync.Mutex    // serializes renewals within this instance; RotateTokens guards against other replicas
+	idleTimeout time.Duration // end sessions unused this long (0 = never)
+	maxLifetime time.Duration // end sessions this old, however often refreshed (0 = never)
 }
 
-// NewSessionService creates a new session service
-func NewSessionService(c *cache.Cache) *SessionService {
-	return &SessionService{cache: c}
+// NewSessionService creates a new session service backed by store
+func NewSessionService(store SessionStore) *SessionService {
+	return &SessionService{store: store}
 }
 
-// Create generates a new session and stores it in the cache
+// lastSeenInterval limits how often a session's activity time is written
+const lastSeenInterval = time.Minute
+
+// SetTimeouts sets how long a session may go unused and how long it may live
+// in total, independent of token refresh. Zero disables either limit.
+func (s *SessionService) SetTimeouts(idleTimeout, maxLifetime time.Duration) {
+	s.idleTimeout = idleTimeout
+	s.maxLifetime = maxLifetime
+}
+
+// Create generates a new session and stores it
 // Returns the cryptographically secure session ID
 func (s *SessionService) Create(username, userID, accessToken, refreshToken string, scopes []string, tokenExpiry time.Time) (string, error) {
+	return s.CreateWithUserAgent(username, userID, accessToken, refreshToken, scopes, tokenExpiry, "")
+}
+
+// CreateWithUserAgent is Create for a login from a client identifying itself
+// as userAgent, shown in the session list
+func (s *SessionService) CreateWithUserAgent(username, userID, accessToken, refreshToken string, scopes []string, tokenExpiry time.Time, userAgent string) (string, error) {
 	// Generate 32 bytes of cryptographically secure random data for session ID
 	sessionIDBytes := make([]byte, 32)
 	if _, err := rand.Read(sessionIDBytes); err != nil {
@@ -40,6 +88,7 @@ func (s *SessionService) Create(username, userID, accessToken, refreshToken stri
 	}
 	csrfToken := base64.URLEncoding.EncodeToString(csrfBytes)
 
+	now := time.Now()
 	session := &models.Session{
 		ID:           sessionID,
 		Username:     username,
@@ -49,37 +98,135 @@ func (s *SessionService) Create(username, userID, accessToken, refreshToken stri
 		RefreshToken: refreshToken,
 		CSRFToken:    csrfToken,
 		TokenExpiry:  tokenExpiry,
-		CreatedAt:    time.Now(),
+		CreatedAt:    now,
+
+		UserAgent: userAgent,
+		LastSeen:  now,
 	}
 
 	// Store session with TTL matching token expiry (plus buffer for refresh)
-	ttl := time.Until(tokenExpiry) + 10*time.Minute
-	if ttl < time.Minute {
-		ttl = time.Minute
+	if err := s.store.Save(session, s.ttlFor(session)); err != nil {
+		return "", err
 	}
-	s.cache.SetWithTTL(sessionKey(sessionID), session, ttl)
 
 	return sessionID, nil
 }
 
-// Get retrieves a session by ID
+// Get retrieves a session by ID and records it as used. A session past its
+// idle timeout or maximum lifetime is deleted and ErrSessionExpired returned.
 func (s *SessionService) Get(sessionID string) (*models.Session, error) {
-	val, ok := s.cache.Get(sessionKey(sessionID))
-	if !ok {
-		return nil, errors.New("session not found")
+	session, err := s.store.Load(sessionID)
+	if err != nil {
+		return nil, err
 	}
 
-	session, ok := val.(*models.Session)
-	if !ok {
-		return nil, errors.New("invalid session data")
+	if reason := s.expiryReason(session, time.Now()); reason != "" {
+		slog.Info("Session ended", "reason", reason, "username", session.Username)
+		s.Delete(sessionID)
+		return nil, fmt.Errorf("%w: %s", ErrSessionExpired, reason)
 	}
 
+	s.touch(session)
 	return session, nil
 }
 
-// Delete removes a session from the cache
+// Delete removes a session from the store
 func (s *SessionService) Delete(sessionID string) {
-	s.cache.Clear(sessionKey(sessionID))
+	if err := s.store.Delete(sessionID); err != nil {
+		slog.Warn("failed to delete session", "error", err)
+	}
+}
+
+// touch records that the session was just used. Writes are skipped while the
+// recorded time is under a minute old (or half the idle timeout, if shorter),
+// so busy sessions cost one store write a minute.
+func (s *SessionService) touch(session *models.Session) {
+	interval := lastSeenInterval
+	if s.idleTimeout > 0 {
+		interval = min(interval, s.idleTimeout/2)
+	}
+
+	now := time.Now()
+	if now.Sub(session.LastSeen) < interval {
+		return
+	}
+	if err := s.store.Touch(session.ID, now, s.ttlFor(session)); err != nil {
+		slog.Debug("failed to record session activity", "error", err)
+	}
+}
+
+// expiryReason reports why the session has ended at now, or "" if it has not
+func (s *SessionService) expiryReason(session *models.Session, now time.Time) string {
+	if s.maxLifetime > 0 && now.Sub(session.CreatedAt) > s.maxLifetime {
+		return "maximum lifetime reached"
+	}
+	lastSeen := session.LastSeen
+	if lastSeen.IsZero() {
+		lastSeen = session.CreatedAt
+	}
+	if s.idleTimeout > 0 && now.Sub(lastSeen) > s.idleTimeout {
+		return "idle timeout"
+	}
+	return ""
+}
+
+// ListForUser returns the user's active sessions, most recently used first.
+// Sessions past their idle timeout or maximum lifetime are deleted, not listed.
+func (s *SessionService) ListForUser(userID string) ([]*models.Session, error) {
+	stored, err := s.store.ListByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	sessions := make([]*models.Session, 0, len(stored))
+	for _, session := range stored {
+		if s.expiryReason(session, now) != "" {
+			s.Delete(session.ID)
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeen.After(sessions[j].LastSeen)
+	})
+	return sessions, nil
+}
+
+// RevokeByHandle deletes the user's session with the given SessionHandle.
+// It returns ErrSessionNotFound if the user has no such session, so one user
+// cannot revoke another's.
+func (s *SessionService) RevokeByHandle(userID, handle string) error {
+	sessions, err := s.store.ListByUser(userID)
+	if err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		if SessionHandle(session.ID) == handle {
+			s.Delete(session.ID)
+			return nil
+		}
+	}
+	return ErrSessionNotFound
+}
+
+// RevokeAllForUser deletes every session the user has and returns how many
+func (s *SessionService) RevokeAllForUser(userID string) (int, error) {
+	sessions, err := s.store.ListByUser(userID)
+	if err != nil {
+		return 0, err
+	}
+	for _, session := range sessions {
+		s.Delete(session.ID)
+	}
+	return len(sessions), nil
+}
+
+// SessionHandle returns the public identifier for a session: a hash of the
+// session ID, which can name the session without granting access to it
+func SessionHandle(sessionID string) string {
+	sum := sha256.Sum256([]byte("session-handle:" + sessionID))
+	return hex.EncodeToString(sum[:16])
 }
 
 // NeedsRefresh checks if the session's token is near expiry
@@ -88,6 +235,123 @@ func (s *SessionService) NeedsRefresh(session *models.Session) bool {
 	return time.Until(session.TokenExpiry) <= 5*time.Minute
 }
 
+// SetTokenRefresher sets the function RefreshIfNeeded uses to renew tokens
+func (s *SessionService) SetTokenRefresher(fn TokenRefresher) {
+	s.refresher = fn
+}
+
+// RefreshIfNeeded returns the session, first renewing its tokens when they are
+// within the refresh window. The bool reports whether this call renewed them.
+// When another replica sharing the store renewed them first, the session it
+// stored is returned instead. A failed renewal returns ErrSessionExpired,
+// wrapping ErrRefreshTokenReused when the session was revoked for redeeming a
+// superseded token; the caller should end the session. Without a refresher
+// configured the session is returned unchanged.
+func (s *SessionService) RefreshIfNeeded(sessionID string) (*models.Session, bool, error) {
+	session, err := s.Get(sessionID)
+	if err != nil {
+		return nil, false, err
+	}
+	if !s.NeedsRefresh(session) || s.refresher == nil {
+		return session, false, nil
+	}
+
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+
+	// Another request may have renewed the tokens while we waited for the lock
+	session, err = s.Get(sessionID)
+	if err != nil {
+		return nil, false, err
+	}
+	if !s.NeedsRefresh(session) {
+		return session, false, nil
+	}
+
+	redeemed := session.RefreshToken
+	tokens, err := s.refresher(redeemed)
+	if err != nil {
+		// A replica that redeemed the same token first leaves it rejected by
+		// the identity provider but the session already renewed
+		current, renewed, supersededErr := s.renewedConcurrently(sessionID, redeemed)
+		if supersededErr != nil {
+			return nil, false, supersededErr
+		}
+		if renewed {
+			return current, false, nil
+		}
+		return nil, false, fmt.Errorf("%w: %v", ErrSessionExpired, err)
+	}
+
+	rotated, err := s.RotateTokens(sessionID, redeemed, tokens)
+	if errors.Is(err, ErrSessionExpired) {
+		return nil, false, err
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: %v", ErrSessionExpired, err)
+	}
+
+	session, err = s.Get(sessionID)
+	if err != nil {
+		return nil, false, err
+	}
+	return session, rotated, nil
+}
+
+// RotateTokens stores tokens obtained by redeeming redeemedRefreshToken, as
+// long as that is still the session's refresh token. The check and the write
+// are one store operation, so of several replicas renewing at once only the
+// first stores its tokens; the rest get false and should reload the session.
+// A token superseded by anything other than a rotation in the last
+// refreshReuseGrace is a replay: the session is revoked and ErrSessionExpired,
+// wrapping ErrRefreshTokenReused, returned.
+func (s *SessionService) RotateTokens(sessionID, redeemedRefreshToken string, tokens RefreshedTokens) (bool, error) {
+	session, err := s.Get(sessionID)
+	if err != nil {
+		return false, err
+	}
+
+	updated := *session
+	updated.AccessToken = tokens.AccessToken
+	updated.RefreshToken = tokens.RefreshToken
+	updated.Scopes = tokens.Scopes
+	updated.TokenExpiry = tokens.Expiry
+	updated.PreviousRefreshToken = redeemedRefreshToken
+	updated.RefreshRotatedAt = time.Now()
+
+	rotated, err := s.store.SaveIfRefreshToken(&updated, redeemedRefreshToken, s.ttlFor(&updated))
+	if err != nil || rotated {
+		return rotated, err
+	}
+	if _, _, err := s.renewedConcurrently(sessionID, redeemedRefreshToken); err != nil {
+		return false, err
+	}
+	slog.Debug("Session tokens already renewed by another instance", "username", session.Username)
+	return false, nil
+}
+
+// renewedConcurrently reloads the session to see how its refresh token moved
+// on from redeemed. It returns the session and true when another replica
+// rotated redeemed out within refreshReuseGrace, and false while redeemed is
+// still current. Any other change means a superseded token was replayed, so
+// the session is revoked.
+func (s *SessionService) renewedConcurrently(sessionID, redeemed string) (*models.Session, bool, error) {
+	session, err := s.Get(sessionID)
+	if err != nil {
+		return nil, false, err
+	}
+	if session.RefreshToken == redeemed {
+		return session, false, nil
+	}
+	if session.PreviousRefreshToken == redeemed && time.Since(session.RefreshRotatedAt) <= refreshReuseGrace {
+		return session, true, nil
+	}
+
+	slog.Warn("Superseded refresh token reused, revoking session", "username", session.Username)
+	s.Delete(sessionID)
+	return nil, false, fmt.Errorf("%w: %w", ErrSessionExpired, ErrRefreshTokenReused)
+}
+
 // UpdateTokens updates the tokens and scopes for an existing session
 func (s *SessionService) UpdateTokens(sessionID, accessToken, refreshToken string, scopes []string, tokenExpiry time.Time) error {
 	session, err := s.Get(sessionID)
@@ -95,19 +359,15 @@ func (s *SessionService) UpdateTokens(sessionID, accessToken, refreshToken strin
 		return err
 	}
 
-	session.AccessToken = accessToken
-	session.RefreshToken = refreshToken
-	session.Scopes = scopes
-	session.TokenExpiry = tokenExpiry
-
-	// Update cache with new TTL
-	ttl := time.Until(tokenExpiry) + 10*time.Minute
-	if ttl < time.Minute {
-		ttl = time.Minute
-	}
-	s.cache.SetWithTTL(sessionKey(sessionID), session, ttl)
+	// Store an updated copy so requests still holding the old session never see a partial write
+	updated := *session
+	updated.AccessToken = accessToken
+	updated.RefreshToken = refreshToken
+	updated.Scopes = scopes
+	updated.TokenExpiry = tokenExpiry
 
-	return nil
+	// Store with the TTL of the new token expiry
+	return s.store.Save(&updated, s.ttlFor(&updated))
 }
 
 // GetCSRFToken returns the CSRF token for a session
@@ -119,7 +379,20 @@ func (s *SessionService) GetCSRFToken(sessionID string) (string, error) {
 	return session.CSRFToken, nil
 }
 
-// sessionKey returns the cache key for a session ID
+// ttlFor keeps a session for the life of its token plus a buffer for refresh,
+// but never past its maximum lifetime
+func (s *SessionService) ttlFor(session *models.Session) time.Duration {
+	ttl := time.Until(session.TokenExpiry) + 10*time.Minute
+	if ttl < time.Minute {
+		ttl = time.Minute
+	}
+	if s.maxLifetime > 0 {
+		ttl = min(ttl, max(time.Until(session.CreatedAt.Add(s.maxLifetime)), time.Second))
+	}
+	return ttl
+}
+
+// sessionKey returns the store key for a session ID
 func sessionKey(sessionID string) string {
 	return "session:" + sessionID
 }
@@ -0,0 +1,357 @@
+// ABOUTME: Session storage backends for the session service
+// ABOUTME: In-memory cache store for single instances, Redis store for replicas behind a load balancer
+
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/markalston/diego-capacity-analyzer/backend/cache"
+	"github.com/markalston/diego-capacity-analyzer/backend/models"
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrSessionNotFound means no live session exists for the ID
+var ErrSessionNotFound = errors.New("session not found")
+
+// redisOpTimeout bounds each Redis call so a stalled Redis fails the request
+// instead of hanging it
+const redisOpTimeout = 5 * time.Second
+
+// SessionStore persists sessions for SessionService. Load returns
+// ErrSessionNotFound for missing or expired sessions. Touch records activity
+// apart from the session itself, so it never overwrites a concurrent token
+// rotation; Load reports the latest recorded time as LastSeen.
+// SaveIfRefreshToken saves only while the stored session still holds
+// expectedRefreshToken, checking and writing atomically, and reports whether
+// it saved.
+type SessionStore interface {
+	Save(session *models.Session, ttl time.Duration) error
+	SaveIfRefreshToken(session *models.Session, expectedRefreshToken string, ttl time.Duration) (bool, error)
+	Load(sessionID string) (*models.Session, error)
+	Delete(sessionID string) error
+	ListByUser(userID string) ([]*models.Session, error)
+	Touch(sessionID string, at time.Time, ttl time.Duration) error
+}
+
+// CacheSessionStore keeps sessions in the in-memory cache. Sessions are only
+// visible to the backend instance that created them.
+type CacheSessionStore struct {
+	cache  *cache.Cache
+	mu     sync.Mutex
+	byUser map[string]map[string]struct{} // user ID -> session IDs, pruned when listed
+}
+
+// NewCacheSessionStore creates a session store backed by c
+func NewCacheSessionStore(c *cache.Cache) *CacheSessionStore {
+	return &CacheSessionStore{cache: c, byUser: make(map[string]map[string]struct{})}
+}
+
+// Save stores session until ttl elapses
+func (s *CacheSessionStore) Save(session *models.Session, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.save(session, ttl)
+	return nil
+}
+
+// SaveIfRefreshToken stores session if the stored one still holds
+// expectedRefreshToken
+func (s *CacheSessionStore) SaveIfRefreshToken(session *models.Session, expectedRefreshToken string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, err := s.Load(session.ID)
+	if err != nil {
+		return false, err
+	}
+	if current.RefreshToken != expectedRefreshToken {
+		return false, nil
+	}
+	s.save(session, ttl)
+	return true, nil
+}
+
+// save stores and indexes session; the caller holds mu
+func (s *CacheSessionStore) save(session *models.Session, ttl time.Duration) {
+	s.cache.SetExactTTL(sessionKey(session.ID), session, ttl)
+	if s.byUser[session.UserID] == nil {
+		s.byUser[session.UserID] = make(map[string]struct{})
+	}
+	s.byUser[session.UserID][session.ID] = struct{}{}
+}
+
+// Load returns the stored session
+func (s *CacheSessionStore) Load(sessionID string) (*models.Session, error) {
+	val, ok := s.cache.Get(sessionKey(sessionID))
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+
+	session, ok := val.(*models.Session)
+	if !ok {
+		return nil, errors.New("invalid session data")
+	}
+
+	// Return a copy with the latest activity rather than mutating the cached session
+	if seen, ok := s.cache.Get(sessionSeenKey(sessionID)); ok {
+		if at, ok := seen.(time.Time); ok && at.After(session.LastSeen) {
+			withSeen := *session
+			withSeen.LastSeen = at
+			return &withSeen, nil
+		}
+	}
+
+	return session, nil
+}
+
+// Delete removes the session
+func (s *CacheSessionStore) Delete(sessionID string) error {
+	s.cache.Clear(sessionKey(sessionID))
+	s.cache.Clear(sessionSeenKey(sessionID))
+	return nil
+}
+
+// ListByUser returns the user's live sessions
+func (s *CacheSessionStore) ListByUser(userID string) ([]*models.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var sessions []*models.Session
+	for id := range s.byUser[userID] {
+		session, err := s.Load(id)
+		if err != nil {
+			delete(s.byUser[userID], id)
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+	if len(s.byUser[userID]) == 0 {
+		delete(s.byUser, userID)
+	}
+	return sessions, nil
+}
+
+// Touch records activity on the session at the given time
+func (s *CacheSessionStore) Touch(sessionID string, at time.Time, ttl time.Duration) error {
+	s.cache.SetExactTTL(sessionSeenKey(sessionID), at, ttl)
+	return nil
+}
+
+// RedisSessionStore keeps sessions in Redis so every backend replica sees
+// the same sessions. Redis expires each key with the session's TTL.
+type RedisSessionStore struct {
+	client *redis.Client
+}
+
+// storedSession is the Redis encoding of a session. models.Session hides its
+// tokens from JSON so they never reach the browser; this type keeps them.
+type storedSession struct {
+	ID           string    `json:"id"`
+	Username     string    `json:"username"`
+	UserID       string    `json:"user_id"`
+	Scopes       []string  `json:"scopes"`
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	CSRFToken    string    `json:"csrf_token"`
+	TokenExpiry  time.Time `json:"token_expiry"`
+	CreatedAt    time.Time `json:"created_at"`
+
+	UserAgent string    `json:"user_agent"`
+	LastSeen  time.Time `json:"last_seen"`
+
+	PreviousRefreshToken string    `json:"previous_refresh_token,omitempty"`
+	RefreshRotatedAt     time.Time `json:"refresh_rotated_at"`
+}
+
+// NewRedisSessionStore connects to the Redis at redisURL
+// (redis://[user:password@]host:port[/db], or rediss:// for TLS) and checks
+// that it is reachable.
+func NewRedisSessionStore(redisURL string) (*RedisSessionStore, error) {
+	opts, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid REDIS_URL: %w", err)
+	}
+	client := redis.NewClient(opts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("connecting to Redis at %s: %w", opts.Addr, err)
+	}
+
+	return &RedisSessionStore{client: client}, nil
+}
+
+// Save stores session until ttl elapses and indexes it under its user. The
+// index has no expiry; ListByUser drops sessions that have expired.
+func (s *RedisSessionStore) Save(session *models.Session, ttl time.Duration) error {
+	data, err := json.Marshal(storedSession(*session))
+	if err != nil {
+		return fmt.Errorf("encoding session: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+	defer cancel()
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, sessionKey(session.ID), data, ttl)
+		pipe.SAdd(ctx, userSessionsKey(session.UserID), session.ID)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("saving session: %w", err)
+	}
+	return nil
+}
+
+// SaveIfRefreshToken stores session if the stored one still holds
+// expectedRefreshToken. The session key is watched across the check, so a
+// concurrent write from another replica aborts this one.
+func (s *RedisSessionStore) SaveIfRefreshToken(session *models.Session, expectedRefreshToken string, ttl time.Duration) (bool, error) {
+	data, err := json.Marshal(storedSession(*session))
+	if err != nil {
+		return false, fmt.Errorf("encoding session: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+	defer cancel()
+
+	key := sessionKey(session.ID)
+	saved := false
+	err = s.client.Watch(ctx, func(tx *redis.Tx) error {
+		current, err := tx.Get(ctx, key).Result()
+		if errors.Is(err, redis.Nil) {
+			return ErrSessionNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		var stored storedSession
+		if err := json.Unmarshal([]byte(current), &stored); err != nil {
+			return errors.New("invalid session data")
+		}
+		if stored.RefreshToken != expectedRefreshToken {
+			return nil
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, data, ttl)
+			pipe.SAdd(ctx, userSessionsKey(session.UserID), session.ID)
+			return nil
+		})
+		saved = err == nil
+		return err
+	}, key)
+
+	switch {
+	case errors.Is(err, redis.TxFailedErr):
+		return false, nil
+	case errors.Is(err, ErrSessionNotFound):
+		return false, err
+	case err != nil:
+		return false, fmt.Errorf("saving session: %w", err)
+	}
+	return saved, nil
+}
+
+// Load returns the stored session
+func (s *RedisSessionStore) Load(sessionID string) (*models.Session, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+	defer cancel()
+
+	vals, err := s.client.MGet(ctx, sessionKey(sessionID), sessionSeenKey(sessionID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("loading session: %w", err)
+	}
+	data, ok := vals[0].(string)
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+
+	var stored storedSession
+	if err := json.Unmarshal([]byte(data), &stored); err != nil {
+		return nil, errors.New("invalid session data")
+	}
+	session := models.Session(stored)
+
+	if seen, ok := vals[1].(string); ok {
+		if at, err := time.Parse(time.RFC3339Nano, seen); err == nil && at.After(session.LastSeen) {
+			session.LastSeen = at
+		}
+	}
+	return &session, nil
+}
+
+// Delete removes the session
+func (s *RedisSessionStore) Delete(sessionID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+	defer cancel()
+	if err := s.client.Del(ctx, sessionKey(sessionID), sessionSeenKey(sessionID)).Err(); err != nil {
+		return fmt.Errorf("deleting session: %w", err)
+	}
+	return nil
+}
+
+// ListByUser returns the user's live sessions
+func (s *RedisSessionStore) ListByUser(userID string) ([]*models.Session, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+	defer cancel()
+
+	ids, err := s.client.SMembers(ctx, userSessionsKey(userID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("listing sessions: %w", err)
+	}
+
+	var sessions []*models.Session
+	var expired []interface{}
+	for _, id := range ids {
+		session, err := s.Load(id)
+		if errors.Is(err, ErrSessionNotFound) {
+			expired = append(expired, id)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	if len(expired) > 0 {
+		if err := s.client.SRem(ctx, userSessionsKey(userID), expired...).Err(); err != nil {
+			return nil, fmt.Errorf("pruning session index: %w", err)
+		}
+	}
+	return sessions, nil
+}
+
+// Touch records activity on the session at the given time
+func (s *RedisSessionStore) Touch(sessionID string, at time.Time, ttl time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+	defer cancel()
+	if err := s.client.Set(ctx, sessionSeenKey(sessionID), at.Format(time.RFC3339Nano), ttl).Err(); err != nil {
+		return fmt.Errorf("recording session activity: %w", err)
+	}
+	return nil
+}
+
+// Close releases the Redis connection pool
+func (s *RedisSessionStore) Close() error {
+	return s.client.Close()
+}
+
+// sessionSeenKey returns the store key for a session's last activity time
+func sessionSeenKey(sessionID string) string {
+	return "session_seen:" + sessionID
+}
+
+// userSessionsKey returns the store key for the set of a user's session IDs
+func userSessionsKey(userID string) string {
+	return "user_sessions:" + userID
+}
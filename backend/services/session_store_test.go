@@ -0,0 +1,251 @@
+// ABOUTME: Tests for session storage backends
+// ABOUTME: Verifies Redis serialization round-trips and expiry against an in-process Redis
+
+package services
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/markalston/diego-capacity-analyzer/backend/cache"
+	"github.com/markalston/diego-capacity-analyzer/backend/models"
+)
+
+func newTestRedisStore(t *testing.T) (*RedisSessionStore, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	store, err := NewRedisSessionStore("redis://" + mr.Addr())
+	if err != nil {
+		t.Fatalf("NewRedisSessionStore failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store, mr
+}
+
+func TestRedisSessionStore_RoundTrip(t *testing.T) {
+	store, _ := newTestRedisStore(t)
+
+	session := &models.Session{
+		ID:           "session-abc",
+		Username:     "admin",
+		UserID:       "user-123",
+		Scopes:       []string{"cloud_controller.read", "cloud_controller.admin"},
+		AccessToken:  "access-token",
+		RefreshToken: "refresh-token",
+		CSRFToken:    "csrf-token",
+		TokenExpiry:  time.Now().Add(time.Hour).Truncate(time.Second).UTC(),
+		CreatedAt:    time.Now().Truncate(time.Second).UTC(),
+
+		PreviousRefreshToken: "refresh-token-0",
+		RefreshRotatedAt:     time.Now().Truncate(time.Second).UTC(),
+	}
+
+	if err := store.Save(session, time.Hour); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	got, err := store.Load(session.ID)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, session) {
+		t.Errorf("Load returned %+v, want %+v", got, session)
+	}
+}
+
+func TestRedisSessionStore_NotFound(t *testing.T) {
+	store, _ := newTestRedisStore(t)
+
+	_, err := store.Load("missing")
+	if !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("Expected ErrSessionNotFound, got %v", err)
+	}
+}
+
+func TestRedisSessionStore_Expiry(t *testing.T) {
+	store, mr := newTestRedisStore(t)
+
+	if err := store.Save(&models.Session{ID: "short"}, time.Minute); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if ttl := mr.TTL(sessionKey("short")); ttl != time.Minute {
+		t.Errorf("Expected Redis TTL 1m, got %v", ttl)
+	}
+
+	mr.FastForward(2 * time.Minute)
+
+	if _, err := store.Load("short"); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("Expected ErrSessionNotFound after expiry, got %v", err)
+	}
+}
+
+func TestRedisSessionStore_Delete(t *testing.T) {
+	store, _ := newTestRedisStore(t)
+
+	if err := store.Save(&models.Session{ID: "gone"}, time.Minute); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if err := store.Delete("gone"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := store.Load("gone"); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("Expected ErrSessionNotFound after delete, got %v", err)
+	}
+}
+
+func TestRedisSessionStore_InvalidData(t *testing.T) {
+	store, mr := newTestRedisStore(t)
+
+	if err := mr.Set(sessionKey("corrupt"), "not json"); err != nil {
+		t.Fatalf("miniredis Set failed: %v", err)
+	}
+	if _, err := store.Load("corrupt"); err == nil || !strings.Contains(err.Error(), "invalid session data") {
+		t.Errorf("Expected invalid session data error, got %v", err)
+	}
+}
+
+func TestNewRedisSessionStore_Errors(t *testing.T) {
+	if _, err := NewRedisSessionStore("http://localhost:6379"); err == nil || !strings.Contains(err.Error(), "REDIS_URL") {
+		t.Errorf("Expected invalid REDIS_URL error, got %v", err)
+	}
+
+	mr := miniredis.RunT(t)
+	addr := mr.Addr()
+	mr.Close()
+	if _, err := NewRedisSessionStore("redis://" + addr); err == nil {
+		t.Error("Expected connection error for unreachable Redis, got nil")
+	}
+}
+
+// Sessions created on one replica must be usable from another, tokens included
+func TestSessionService_SharedRedisStore(t *testing.T) {
+	store, _ := newTestRedisStore(t)
+	replicaA := NewSessionService(store)
+	replicaB := NewSessionService(store)
+
+	expiry := time.Now().Add(time.Hour)
+	sessionID, err := replicaA.Create("admin", "user-123", "access", "refresh", []string{"openid"}, expiry)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	session, err := replicaB.Get(sessionID)
+	if err != nil {
+		t.Fatalf("Get from second replica failed: %v", err)
+	}
+	if session.AccessToken != "access" || session.RefreshToken != "refresh" || session.CSRFToken == "" {
+		t.Errorf("Tokens did not survive the round trip: %+v", session)
+	}
+	if !session.TokenExpiry.Equal(expiry) {
+		t.Errorf("TokenExpiry = %v, want %v", session.TokenExpiry, expiry)
+	}
+
+	if err := replicaB.UpdateTokens(sessionID, "access-2", "refresh-2", []string{"openid", "roles"}, expiry.Add(time.Hour)); err != nil {
+		t.Fatalf("UpdateTokens failed: %v", err)
+	}
+	session, err = replicaA.Get(sessionID)
+	if err != nil {
+		t.Fatalf("Get after update failed: %v", err)
+	}
+	if session.AccessToken != "access-2" || !reflect.DeepEqual(session.Scopes, []string{"openid", "roles"}) {
+		t.Errorf("Update not visible on first replica: %+v", session)
+	}
+
+	replicaA.Delete(sessionID)
+	if _, err := replicaB.Get(sessionID); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("Expected ErrSessionNotFound after delete, got %v", err)
+	}
+}
+
+func TestRedisSessionStore_ListByUserAndTouch(t *testing.T) {
+	store, mr := newTestRedisStore(t)
+
+	for _, s := range []*models.Session{
+		{ID: "a1", UserID: "user-a"},
+		{ID: "a2", UserID: "user-a"},
+		{ID: "b1", UserID: "user-b"},
+	} {
+		if err := store.Save(s, time.Minute); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+	if err := store.Save(&models.Session{ID: "a3", UserID: "user-a"}, 10*time.Second); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	seen := time.Now().Add(time.Hour).Truncate(time.Millisecond).UTC()
+	if err := store.Touch("a1", seen, time.Minute); err != nil {
+		t.Fatalf("Touch failed: %v", err)
+	}
+
+	// a3 expires and is pruned from the index on the next listing
+	mr.FastForward(30 * time.Second)
+
+	sessions, err := store.ListByUser("user-a")
+	if err != nil {
+		t.Fatalf("ListByUser failed: %v", err)
+	}
+	byID := map[string]*models.Session{}
+	for _, s := range sessions {
+		byID[s.ID] = s
+	}
+	if len(byID) != 2 || byID["a1"] == nil || byID["a2"] == nil {
+		t.Fatalf("Expected a1 and a2, got %v", byID)
+	}
+	if !byID["a1"].LastSeen.Equal(seen) {
+		t.Errorf("a1 LastSeen = %v, want %v", byID["a1"].LastSeen, seen)
+	}
+	if members, _ := mr.Members(userSessionsKey("user-a")); len(members) != 2 {
+		t.Errorf("Expected expired session pruned from index, got %v", members)
+	}
+}
+
+func TestCacheSessionStore_NotFound(t *testing.T) {
+	store := NewCacheSessionStore(cache.New(time.Minute))
+
+	if _, err := store.Load("missing"); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("Expected ErrSessionNotFound, got %v", err)
+	}
+}
+
+func TestSessionStore_SaveIfRefreshToken(t *testing.T) {
+	redisStore, _ := newTestRedisStore(t)
+	stores := map[string]SessionStore{
+		"cache": NewCacheSessionStore(cache.New(time.Minute)),
+		"redis": redisStore,
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			if err := store.Save(&models.Session{ID: "s1", UserID: "u1", RefreshToken: "refresh-1"}, time.Minute); err != nil {
+				t.Fatalf("Save failed: %v", err)
+			}
+
+			saved, err := store.SaveIfRefreshToken(&models.Session{ID: "s1", UserID: "u1", RefreshToken: "refresh-2"}, "refresh-1", time.Minute)
+			if err != nil || !saved {
+				t.Fatalf("SaveIfRefreshToken = %v, %v; want true, nil", saved, err)
+			}
+
+			// refresh-1 is no longer stored, so a second renewal from it must not overwrite
+			saved, err = store.SaveIfRefreshToken(&models.Session{ID: "s1", UserID: "u1", RefreshToken: "refresh-3"}, "refresh-1", time.Minute)
+			if err != nil || saved {
+				t.Fatalf("SaveIfRefreshToken = %v, %v; want false, nil", saved, err)
+			}
+			got, err := store.Load("s1")
+			if err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+			if got.RefreshToken != "refresh-2" {
+				t.Errorf("Expected refresh-2 to be kept, got %q", got.RefreshToken)
+			}
+
+			if _, err := store.SaveIfRefreshToken(&models.Session{ID: "missing"}, "", time.Minute); !errors.Is(err, ErrSessionNotFound) {
+				t.Errorf("Expected ErrSessionNotFound, got %v", err)
+			}
+		})
+	}
+}
@@ -5,7 +5,10 @@ package services
 
 import (
 	"encoding/base64"
+	"errors"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -15,7 +18,7 @@ import (
 
 func TestNewSessionService(t *testing.T) {
 	c := cache.New(5 * time.Minute)
-	svc := NewSessionService(c)
+	svc := NewSessionService(NewCacheSessionStore(c))
 
 	if svc == nil {
 		t.Fatal("NewSessionService returned nil")
@@ -24,7 +27,7 @@ func TestNewSessionService(t *testing.T) {
 
 func TestSessionService_Create(t *testing.T) {
 	c := cache.New(5 * time.Minute)
-	svc := NewSessionService(c)
+	svc := NewSessionService(NewCacheSessionStore(c))
 
 	expiry := time.Now().Add(time.Hour)
 	sessionID, err := svc.Create("testuser", "user-123", "access-token", "refresh-token", nil, expiry)
@@ -50,7 +53,7 @@ func TestSessionService_Create(t *testing.T) {
 
 func TestSessionService_Create_UniqueIDs(t *testing.T) {
 	c := cache.New(5 * time.Minute)
-	svc := NewSessionService(c)
+	svc := NewSessionService(NewCacheSessionStore(c))
 
 	expiry := time.Now().Add(time.Hour)
 	ids := make(map[string]bool)
@@ -70,7 +73,7 @@ func TestSessionService_Create_UniqueIDs(t *testing.T) {
 
 func TestSessionService_Get(t *testing.T) {
 	c := cache.New(5 * time.Minute)
-	svc := NewSessionService(c)
+	svc := NewSessionService(NewCacheSessionStore(c))
 
 	expiry := time.Now().Add(time.Hour)
 	sessionID, err := svc.Create("testuser", "user-123", "access-token", "refresh-token", nil, expiry)
@@ -106,7 +109,7 @@ func TestSessionService_Get(t *testing.T) {
 
 func TestSessionService_Get_NotFound(t *testing.T) {
 	c := cache.New(5 * time.Minute)
-	svc := NewSessionService(c)
+	svc := NewSessionService(NewCacheSessionStore(c))
 
 	session, err := svc.Get("nonexistent-session-id")
 	if err == nil {
@@ -122,7 +125,7 @@ func TestSessionService_Get_NotFound(t *testing.T) {
 
 func TestSessionService_Delete(t *testing.T) {
 	c := cache.New(5 * time.Minute)
-	svc := NewSessionService(c)
+	svc := NewSessionService(NewCacheSessionStore(c))
 
 	expiry := time.Now().Add(time.Hour)
 	sessionID, err := svc.Create("testuser", "user-123", "access", "refresh", nil, expiry)
@@ -148,7 +151,7 @@ func TestSessionService_Delete(t *testing.T) {
 
 func TestSessionService_NeedsRefresh(t *testing.T) {
 	c := cache.New(5 * time.Minute)
-	svc := NewSessionService(c)
+	svc := NewSessionService(NewCacheSessionStore(c))
 
 	tests := []struct {
 		name        string
@@ -176,9 +179,419 @@ func TestSessionService_NeedsRefresh(t *testing.T) {
 	}
 }
 
+func TestSessionService_RefreshIfNeeded(t *testing.T) {
+	newTokens := RefreshedTokens{
+		AccessToken:  "new-access",
+		RefreshToken: "new-refresh",
+		Scopes:       []string{"cloud_controller.read"},
+		Expiry:       time.Now().Add(time.Hour),
+	}
+
+	t.Run("fresh token is left alone", func(t *testing.T) {
+		svc := NewSessionService(NewCacheSessionStore(cache.New(5 * time.Minute)))
+		svc.SetTokenRefresher(func(string) (RefreshedTokens, error) {
+			t.Error("refresher should not be called for a fresh token")
+			return RefreshedTokens{}, nil
+		})
+		id, _ := svc.Create("user", "uid", "access", "refresh", nil, time.Now().Add(time.Hour))
+
+		session, refreshed, err := svc.RefreshIfNeeded(id)
+		if err != nil || refreshed || session.AccessToken != "access" {
+			t.Errorf("Expected unchanged session, got refreshed=%v token=%q err=%v", refreshed, session.AccessToken, err)
+		}
+	})
+
+	t.Run("token near expiry is renewed", func(t *testing.T) {
+		svc := NewSessionService(NewCacheSessionStore(cache.New(5 * time.Minute)))
+		var gotRefreshToken string
+		svc.SetTokenRefresher(func(refreshToken string) (RefreshedTokens, error) {
+			gotRefreshToken = refreshToken
+			return newTokens, nil
+		})
+		id, _ := svc.Create("user", "uid", "access", "refresh", nil, time.Now().Add(2*time.Minute))
+
+		session, refreshed, err := svc.RefreshIfNeeded(id)
+		if err != nil {
+			t.Fatalf("RefreshIfNeeded failed: %v", err)
+		}
+		if !refreshed || session.AccessToken != "new-access" || session.RefreshToken != "new-refresh" {
+			t.Errorf("Expected renewed tokens, got refreshed=%v access=%q refresh=%q", refreshed, session.AccessToken, session.RefreshToken)
+		}
+		if gotRefreshToken != "refresh" {
+			t.Errorf("Refresher got refresh token %q, want %q", gotRefreshToken, "refresh")
+		}
+	})
+
+	t.Run("failed renewal reports session expired", func(t *testing.T) {
+		svc := NewSessionService(NewCacheSessionStore(cache.New(5 * time.Minute)))
+		svc.SetTokenRefresher(func(string) (RefreshedTokens, error) {
+			return RefreshedTokens{}, errors.New("invalid_grant")
+		})
+		id, _ := svc.Create("user", "uid", "access", "refresh", nil, time.Now().Add(2*time.Minute))
+
+		_, _, err := svc.RefreshIfNeeded(id)
+		if !errors.Is(err, ErrSessionExpired) {
+			t.Errorf("Expected ErrSessionExpired, got %v", err)
+		}
+	})
+
+	t.Run("missing session is not reported as expired", func(t *testing.T) {
+		svc := NewSessionService(NewCacheSessionStore(cache.New(5 * time.Minute)))
+		_, _, err := svc.RefreshIfNeeded("no-such-session")
+		if err == nil || errors.Is(err, ErrSessionExpired) {
+			t.Errorf("Expected session not found error, got %v", err)
+		}
+	})
+
+	t.Run("concurrent callers renew once", func(t *testing.T) {
+		svc := NewSessionService(NewCacheSessionStore(cache.New(5 * time.Minute)))
+		var calls atomic.Int32
+		svc.SetTokenRefresher(func(string) (RefreshedTokens, error) {
+			calls.Add(1)
+			time.Sleep(10 * time.Millisecond)
+			return newTokens, nil
+		})
+		id, _ := svc.Create("user", "uid", "access", "refresh", nil, time.Now().Add(2*time.Minute))
+
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, _, err := svc.RefreshIfNeeded(id); err != nil {
+					t.Errorf("RefreshIfNeeded failed: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if got := calls.Load(); got != 1 {
+			t.Errorf("Expected a single renewal, got %d", got)
+		}
+	})
+}
+
+func TestSessionService_RotateTokens(t *testing.T) {
+	rotated := RefreshedTokens{
+		AccessToken:  "access-2",
+		RefreshToken: "refresh-2",
+		Expiry:       time.Now().Add(time.Hour),
+	}
+
+	t.Run("current token rotates", func(t *testing.T) {
+		svc := NewSessionService(NewCacheSessionStore(cache.New(5 * time.Minute)))
+		id, _ := svc.Create("user", "uid", "access-1", "refresh-1", nil, time.Now().Add(time.Hour))
+
+		ok, err := svc.RotateTokens(id, "refresh-1", rotated)
+		if err != nil || !ok {
+			t.Fatalf("RotateTokens = %v, %v; want true, nil", ok, err)
+		}
+		session, err := svc.Get(id)
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if session.AccessToken != "access-2" || session.RefreshToken != "refresh-2" {
+			t.Errorf("Expected rotated tokens, got %q / %q", session.AccessToken, session.RefreshToken)
+		}
+	})
+
+	t.Run("superseded token leaves newer tokens in place", func(t *testing.T) {
+		svc := NewSessionService(NewCacheSessionStore(cache.New(5 * time.Minute)))
+		id, _ := svc.Create("user", "uid", "access-1", "refresh-1", nil, time.Now().Add(time.Hour))
+		if _, err := svc.RotateTokens(id, "refresh-1", rotated); err != nil {
+			t.Fatalf("RotateTokens failed: %v", err)
+		}
+
+		// refresh-1 was rotated out above, so a late renewal from it loses
+		ok, err := svc.RotateTokens(id, "refresh-1", RefreshedTokens{AccessToken: "access-3", RefreshToken: "refresh-3"})
+		if err != nil || ok {
+			t.Fatalf("RotateTokens = %v, %v; want false, nil", ok, err)
+		}
+		session, err := svc.Get(id)
+		if err != nil {
+			t.Fatalf("Expected session to survive, got %v", err)
+		}
+		if session.RefreshToken != "refresh-2" {
+			t.Errorf("Expected refresh-2 to be kept, got %q", session.RefreshToken)
+		}
+	})
+
+	t.Run("replayed token revokes the session", func(t *testing.T) {
+		svc := NewSessionService(NewCacheSessionStore(cache.New(5 * time.Minute)))
+		id, _ := svc.Create("user", "uid", "access-1", "refresh-1", nil, time.Now().Add(time.Hour))
+		if _, err := svc.RotateTokens(id, "refresh-1", rotated); err != nil {
+			t.Fatalf("RotateTokens failed: %v", err)
+		}
+		if _, err := svc.RotateTokens(id, "refresh-2", RefreshedTokens{AccessToken: "access-3", RefreshToken: "refresh-3"}); err != nil {
+			t.Fatalf("RotateTokens failed: %v", err)
+		}
+
+		// refresh-1 was replaced two rotations ago, so no replica can still be renewing with it
+		ok, err := svc.RotateTokens(id, "refresh-1", RefreshedTokens{AccessToken: "access-x", RefreshToken: "refresh-x"})
+		if ok || !errors.Is(err, ErrSessionExpired) || !errors.Is(err, ErrRefreshTokenReused) {
+			t.Fatalf("RotateTokens = %v, %v; want false, ErrRefreshTokenReused", ok, err)
+		}
+		if _, err := svc.Get(id); !errors.Is(err, ErrSessionNotFound) {
+			t.Errorf("Expected the session to be revoked, got %v", err)
+		}
+	})
+
+	t.Run("token replayed after the grace window revokes the session", func(t *testing.T) {
+		store := NewCacheSessionStore(cache.New(5 * time.Minute))
+		svc := NewSessionService(store)
+		id, _ := svc.Create("user", "uid", "access-1", "refresh-1", nil, time.Now().Add(time.Hour))
+		if _, err := svc.RotateTokens(id, "refresh-1", rotated); err != nil {
+			t.Fatalf("RotateTokens failed: %v", err)
+		}
+		session, _ := store.Load(id)
+		aged := *session
+		aged.RefreshRotatedAt = time.Now().Add(-refreshReuseGrace - time.Second)
+		if err := store.Save(&aged, time.Hour); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+
+		if _, err := svc.RotateTokens(id, "refresh-1", RefreshedTokens{AccessToken: "access-x", RefreshToken: "refresh-x"}); !errors.Is(err, ErrRefreshTokenReused) {
+			t.Fatalf("Expected ErrRefreshTokenReused, got %v", err)
+		}
+		if _, err := svc.Get(id); !errors.Is(err, ErrSessionNotFound) {
+			t.Errorf("Expected the session to be revoked, got %v", err)
+		}
+	})
+}
+
+// Two replicas share a session store and renew the same session at once; the
+// one that stores second must adopt the first's tokens, not end the session
+func TestSessionService_RefreshIfNeeded_ConcurrentReplicas(t *testing.T) {
+	newReplicas := func(t *testing.T) (*SessionService, *SessionService, string) {
+		t.Helper()
+		store := NewCacheSessionStore(cache.New(5 * time.Minute))
+		replicaA := NewSessionService(store)
+		replicaB := NewSessionService(store)
+		id, _ := replicaA.Create("user", "uid", "access-1", "refresh-1", nil, time.Now().Add(2*time.Minute))
+		return replicaA, replicaB, id
+	}
+	rotateOnA := func(t *testing.T, replicaA *SessionService, id string) {
+		t.Helper()
+		if _, err := replicaA.RotateTokens(id, "refresh-1", RefreshedTokens{
+			AccessToken:  "access-a",
+			RefreshToken: "refresh-a",
+			Expiry:       time.Now().Add(time.Hour),
+		}); err != nil {
+			t.Errorf("RotateTokens on replica A failed: %v", err)
+		}
+	}
+
+	t.Run("losing the store race reloads", func(t *testing.T) {
+		replicaA, replicaB, id := newReplicas(t)
+		replicaB.SetTokenRefresher(func(refreshToken string) (RefreshedTokens, error) {
+			// Replica A stores its renewal while B's is in flight
+			rotateOnA(t, replicaA, id)
+			return RefreshedTokens{AccessToken: "access-b", RefreshToken: "refresh-b", Expiry: time.Now().Add(time.Hour)}, nil
+		})
+
+		session, refreshed, err := replicaB.RefreshIfNeeded(id)
+		if err != nil {
+			t.Fatalf("RefreshIfNeeded failed: %v", err)
+		}
+		if refreshed {
+			t.Error("Expected refreshed=false when another replica renewed first")
+		}
+		if session.AccessToken != "access-a" || session.RefreshToken != "refresh-a" {
+			t.Errorf("Expected replica A's tokens, got %q / %q", session.AccessToken, session.RefreshToken)
+		}
+	})
+
+	t.Run("identity provider rejecting the spent token reloads", func(t *testing.T) {
+		replicaA, replicaB, id := newReplicas(t)
+		replicaB.SetTokenRefresher(func(refreshToken string) (RefreshedTokens, error) {
+			rotateOnA(t, replicaA, id)
+			return RefreshedTokens{}, errors.New("invalid_grant")
+		})
+
+		session, refreshed, err := replicaB.RefreshIfNeeded(id)
+		if err != nil {
+			t.Fatalf("RefreshIfNeeded failed: %v", err)
+		}
+		if refreshed || session.RefreshToken != "refresh-a" {
+			t.Errorf("Expected replica A's session unrefreshed by B, got %v / %q", refreshed, session.RefreshToken)
+		}
+	})
+
+	t.Run("rejected current token still expires", func(t *testing.T) {
+		_, replicaB, id := newReplicas(t)
+		replicaB.SetTokenRefresher(func(refreshToken string) (RefreshedTokens, error) {
+			return RefreshedTokens{}, errors.New("invalid_grant")
+		})
+
+		if _, _, err := replicaB.RefreshIfNeeded(id); !errors.Is(err, ErrSessionExpired) {
+			t.Fatalf("Expected ErrSessionExpired, got %v", err)
+		}
+	})
+}
+
+func TestSessionService_ListAndRevoke(t *testing.T) {
+	svc := NewSessionService(NewCacheSessionStore(cache.New(5 * time.Minute)))
+	expiry := time.Now().Add(time.Hour)
+	first, _ := svc.CreateWithUserAgent("alice", "user-1", "a", "r", nil, expiry, "Firefox")
+	second, _ := svc.CreateWithUserAgent("alice", "user-1", "a", "r", nil, expiry, "Safari")
+	other, _ := svc.Create("bob", "user-2", "a", "r", nil, expiry)
+
+	sessions, err := svc.ListForUser("user-1")
+	if err != nil {
+		t.Fatalf("ListForUser failed: %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("Expected 2 sessions, got %d", len(sessions))
+	}
+
+	// Deleted sessions drop out of the list
+	svc.Delete(first)
+	sessions, _ = svc.ListForUser("user-1")
+	if len(sessions) != 1 || sessions[0].ID != second || sessions[0].UserAgent != "Safari" {
+		t.Errorf("Expected only the Safari session, got %+v", sessions)
+	}
+
+	if err := svc.RevokeByHandle("user-1", SessionHandle(other)); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("Expected ErrSessionNotFound revoking another user's session, got %v", err)
+	}
+	if err := svc.RevokeByHandle("user-1", SessionHandle(second)); err != nil {
+		t.Errorf("RevokeByHandle failed: %v", err)
+	}
+
+	revoked, err := svc.RevokeAllForUser("user-2")
+	if err != nil || revoked != 1 {
+		t.Errorf("RevokeAllForUser = %d, %v; want 1, nil", revoked, err)
+	}
+	if _, err := svc.Get(other); err == nil {
+		t.Error("Expected user-2 session revoked")
+	}
+}
+
+func TestSessionService_Touch(t *testing.T) {
+	svc := NewSessionService(NewCacheSessionStore(cache.New(5 * time.Minute)))
+	id, _ := svc.Create("alice", "user-1", "a", "r", nil, time.Now().Add(time.Hour))
+	session, _ := svc.Get(id)
+	created := session.LastSeen
+
+	// A fresh session is not rewritten
+	svc.touch(session)
+	if session, _ = svc.Get(id); !session.LastSeen.Equal(created) {
+		t.Errorf("Expected LastSeen unchanged within a minute, got %v", session.LastSeen)
+	}
+
+	stale := *session
+	stale.LastSeen = time.Now().Add(-2 * lastSeenInterval)
+	svc.touch(&stale)
+	if session, _ = svc.Get(id); !session.LastSeen.After(created) {
+		t.Errorf("Expected LastSeen advanced, got %v (created %v)", session.LastSeen, created)
+	}
+	if session.AccessToken != "a" {
+		t.Error("Touch must not change the session's tokens")
+	}
+}
+
+func TestSessionService_IdleTimeout(t *testing.T) {
+	now := time.Now()
+
+	t.Run("idle session is ended and deleted", func(t *testing.T) {
+		store := NewCacheSessionStore(cache.New(5 * time.Minute))
+		svc := NewSessionService(store)
+		svc.SetTimeouts(30*time.Minute, 0)
+		store.Save(&models.Session{
+			ID:          "idle",
+			CreatedAt:   now.Add(-2 * time.Hour),
+			LastSeen:    now.Add(-31 * time.Minute),
+			TokenExpiry: now.Add(time.Hour),
+		}, time.Hour)
+
+		if _, err := svc.Get("idle"); !errors.Is(err, ErrSessionExpired) {
+			t.Fatalf("Expected ErrSessionExpired, got %v", err)
+		}
+		if _, err := store.Load("idle"); !errors.Is(err, ErrSessionNotFound) {
+			t.Errorf("Expected idle session deleted, got %v", err)
+		}
+	})
+
+	t.Run("recently used session is kept", func(t *testing.T) {
+		store := NewCacheSessionStore(cache.New(5 * time.Minute))
+		svc := NewSessionService(store)
+		svc.SetTimeouts(30*time.Minute, 0)
+		store.Save(&models.Session{
+			ID:          "active",
+			CreatedAt:   now.Add(-2 * time.Hour),
+			LastSeen:    now.Add(-5 * time.Minute),
+			TokenExpiry: now.Add(time.Hour),
+		}, time.Hour)
+
+		if _, err := svc.Get("active"); err != nil {
+			t.Errorf("Expected active session, got %v", err)
+		}
+	})
+
+	t.Run("use keeps a session alive past the timeout", func(t *testing.T) {
+		svc := NewSessionService(NewCacheSessionStore(cache.New(5 * time.Minute)))
+		svc.SetTimeouts(200*time.Millisecond, 0)
+		id, _ := svc.Create("alice", "user-1", "a", "r", nil, time.Now().Add(time.Hour))
+
+		for i := 0; i < 2; i++ {
+			time.Sleep(150 * time.Millisecond)
+			if _, err := svc.Get(id); err != nil {
+				t.Fatalf("Get %d failed: %v", i+1, err)
+			}
+		}
+
+		time.Sleep(300 * time.Millisecond)
+		if _, err := svc.Get(id); !errors.Is(err, ErrSessionExpired) {
+			t.Errorf("Expected ErrSessionExpired after going idle, got %v", err)
+		}
+	})
+}
+
+func TestSessionService_MaxLifetime(t *testing.T) {
+	now := time.Now()
+	store := NewCacheSessionStore(cache.New(5 * time.Minute))
+	svc := NewSessionService(store)
+	svc.SetTimeouts(0, 12*time.Hour)
+	svc.SetTokenRefresher(func(string) (RefreshedTokens, error) {
+		t.Error("refresher must not extend a session past its maximum lifetime")
+		return RefreshedTokens{}, nil
+	})
+
+	// Fresh tokens and recent use do not keep an old session alive
+	store.Save(&models.Session{
+		ID:          "old",
+		CreatedAt:   now.Add(-13 * time.Hour),
+		LastSeen:    now,
+		TokenExpiry: now.Add(2 * time.Minute),
+	}, time.Hour)
+
+	if _, _, err := svc.RefreshIfNeeded("old"); !errors.Is(err, ErrSessionExpired) {
+		t.Fatalf("Expected ErrSessionExpired, got %v", err)
+	}
+	if _, err := store.Load("old"); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("Expected old session deleted, got %v", err)
+	}
+
+	// Stored TTL stops at the lifetime limit even when the token lasts longer
+	young := &models.Session{CreatedAt: now.Add(-11 * time.Hour), TokenExpiry: now.Add(2 * time.Hour)}
+	if ttl := svc.ttlFor(young); ttl > time.Hour || ttl <= 59*time.Minute {
+		t.Errorf("ttlFor = %v, want about 1h remaining lifetime", ttl)
+	}
+}
+
+func TestSessionHandle(t *testing.T) {
+	handle := SessionHandle("session-id")
+	if handle == "session-id" || strings.Contains(handle, "session-id") {
+		t.Error("Handle must not reveal the session ID")
+	}
+	if SessionHandle("session-id") != handle || SessionHandle("other-id") == handle {
+		t.Error("Handle must be stable per session and distinct between sessions")
+	}
+}
+
 func TestSessionService_UpdateTokens(t *testing.T) {
 	c := cache.New(5 * time.Minute)
-	svc := NewSessionService(c)
+	svc := NewSessionService(NewCacheSessionStore(c))
 
 	expiry := time.Now().Add(time.Hour)
 	originalScopes := []string{"openid", "diego-analyzer.operator"}
@@ -215,7 +628,7 @@ func TestSessionService_UpdateTokens(t *testing.T) {
 
 func TestSessionService_UpdateTokens_PromotesToOperator(t *testing.T) {
 	c := cache.New(5 * time.Minute)
-	svc := NewSessionService(c)
+	svc := NewSessionService(NewCacheSessionStore(c))
 
 	expiry := time.Now().Add(time.Hour)
 	sessionID, err := svc.Create("testuser", "user-123", "access", "refresh", []string{"diego-analyzer.viewer"}, expiry)
@@ -240,7 +653,7 @@ func TestSessionService_UpdateTokens_PromotesToOperator(t *testing.T) {
 
 func TestSessionService_UpdateTokens_ScopesBecomesNil(t *testing.T) {
 	c := cache.New(5 * time.Minute)
-	svc := NewSessionService(c)
+	svc := NewSessionService(NewCacheSessionStore(c))
 
 	expiry := time.Now().Add(time.Hour)
 	sessionID, err := svc.Create("testuser", "user-123", "access", "refresh", []string{"diego-analyzer.operator"}, expiry)
@@ -262,7 +675,7 @@ func TestSessionService_UpdateTokens_ScopesBecomesNil(t *testing.T) {
 
 func TestSessionService_UpdateTokens_PreservesSessionFields(t *testing.T) {
 	c := cache.New(5 * time.Minute)
-	svc := NewSessionService(c)
+	svc := NewSessionService(NewCacheSessionStore(c))
 
 	expiry := time.Now().Add(time.Hour)
 	sessionID, err := svc.Create("testuser", "user-123", "access", "refresh", []string{"diego-analyzer.operator"}, expiry)
@@ -297,7 +710,7 @@ func TestSessionService_UpdateTokens_PreservesSessionFields(t *testing.T) {
 
 func TestSessionService_UpdateTokens_NotFound(t *testing.T) {
 	c := cache.New(5 * time.Minute)
-	svc := NewSessionService(c)
+	svc := NewSessionService(NewCacheSessionStore(c))
 
 	err := svc.UpdateTokens("nonexistent", "access", "refresh", nil, time.Now().Add(time.Hour))
 	if err == nil {
@@ -310,7 +723,7 @@ func TestSessionService_UpdateTokens_NotFound(t *testing.T) {
 
 func TestSessionService_SessionIDNotPredictable(t *testing.T) {
 	c := cache.New(5 * time.Minute)
-	svc := NewSessionService(c)
+	svc := NewSessionService(NewCacheSessionStore(c))
 
 	expiry := time.Now().Add(time.Hour)
 
@@ -326,7 +739,7 @@ func TestSessionService_SessionIDNotPredictable(t *testing.T) {
 
 func TestSessionService_ConcurrentAccess(t *testing.T) {
 	c := cache.New(5 * time.Minute)
-	svc := NewSessionService(c)
+	svc := NewSessionService(NewCacheSessionStore(c))
 
 	expiry := time.Now().Add(time.Hour)
 
@@ -356,7 +769,7 @@ func TestSessionService_ConcurrentAccess(t *testing.T) {
 
 func TestSessionService_Create_GeneratesCSRFToken(t *testing.T) {
 	c := cache.New(5 * time.Minute)
-	svc := NewSessionService(c)
+	svc := NewSessionService(NewCacheSessionStore(c))
 
 	sessionID, err := svc.Create("testuser", "user-123", "access-token", "refresh-token", nil, time.Now().Add(time.Hour))
 	if err != nil {
@@ -380,7 +793,7 @@ func TestSessionService_Create_GeneratesCSRFToken(t *testing.T) {
 
 func TestSessionService_Create_UniqueCSRFTokens(t *testing.T) {
 	c := cache.New(5 * time.Minute)
-	svc := NewSessionService(c)
+	svc := NewSessionService(NewCacheSessionStore(c))
 
 	expiry := time.Now().Add(time.Hour)
 	tokens := make(map[string]bool)
@@ -404,7 +817,7 @@ func TestSessionService_Create_UniqueCSRFTokens(t *testing.T) {
 
 func TestSessionService_GetCSRFToken(t *testing.T) {
 	c := cache.New(5 * time.Minute)
-	svc := NewSessionService(c)
+	svc := NewSessionService(NewCacheSessionStore(c))
 
 	sessionID, err := svc.Create("testuser", "user-123", "access-token", "refresh-token", nil, time.Now().Add(time.Hour))
 	if err != nil {
@@ -429,7 +842,7 @@ func TestSessionService_GetCSRFToken(t *testing.T) {
 
 func TestSessionService_GetCSRFToken_InvalidSession(t *testing.T) {
 	c := cache.New(5 * time.Minute)
-	svc := NewSessionService(c)
+	svc := NewSessionService(NewCacheSessionStore(c))
 
 	_, err := svc.GetCSRFToken("nonexistent-session")
 	if err == nil {
@@ -0,0 +1,89 @@
+// ABOUTME: Dry-run capacity sweep over a range of proposed cell counts
+// ABOUTME: Evaluates the scenario calculator at each step so planners can see where limits hit
+
+package services
+
+import (
+	"fmt"
+
+	"github.com/markalston/diego-capacity-analyzer/backend/models"
+)
+
+// MaxSweepPoints caps how many cell counts a single sweep may evaluate
+const MaxSweepPoints = 500
+
+// MaxSweepCells caps the largest cell count a sweep may evaluate, far beyond
+// any real foundation, so range arithmetic cannot overflow
+const MaxSweepCells = 100000
+
+// ValidateSweepRange checks that minCells..maxCells by step is a usable,
+// bounded range of cell counts
+func ValidateSweepRange(minCells, maxCells, step int) error {
+	if minCells <= 0 {
+		return fmt.Errorf("min must be a positive integer, got %d", minCells)
+	}
+	if maxCells < minCells {
+		return fmt.Errorf("max (%d) must not be less than min (%d)", maxCells, minCells)
+	}
+	if maxCells > MaxSweepCells {
+		return fmt.Errorf("max must be at most %d, got %d", MaxSweepCells, maxCells)
+	}
+	if step <= 0 {
+		return fmt.Errorf("step must be a positive integer, got %d", step)
+	}
+	if maxCells > minCells && step > maxCells-minCells {
+		return fmt.Errorf("step (%d) must not exceed max - min (%d)", step, maxCells-minCells)
+	}
+	if points := (maxCells-minCells)/step + 1; points > MaxSweepPoints {
+		return fmt.Errorf("sweep would evaluate %d cell counts, more than the limit of %d", points, MaxSweepPoints)
+	}
+	return nil
+}
+
+// SweepBaseInput returns a scenario input that keeps the current cell shape
+// and host layout, so a sweep varies only the cell count
+func SweepBaseInput(state models.InfrastructureState) models.ScenarioInput {
+	var input models.ScenarioInput
+	for _, cluster := range state.Clusters {
+		if cluster.DiegoCellMemoryGB > 0 {
+			input.ProposedCellMemoryGB = cluster.DiegoCellMemoryGB
+			input.ProposedCellCPU = cluster.DiegoCellCPU
+			input.ProposedCellDiskGB = cluster.DiegoCellDiskGB
+			break
+		}
+	}
+	input.ProposedCellCount = state.TotalCellCount
+	input.HostCount = state.TotalHostCount
+	if state.TotalHostCount > 0 {
+		input.MemoryPerHostGB = state.TotalMemoryGB / state.TotalHostCount
+		input.PhysicalCoresPerHost = state.TotalCPUCores / state.TotalHostCount
+	}
+	return input
+}
+
+// CalculateSweep evaluates baseInput at every cell count from minCells to
+// maxCells in increments of step, always including maxCells. TPS is
+// estimated at each point, using DefaultTPSCurve when baseInput has no curve,
+// so the results show where scheduling starts to degrade.
+func (c *ScenarioCalculator) CalculateSweep(state models.InfrastructureState, baseInput models.ScenarioInput, minCells, maxCells, step int) []models.ScenarioResult {
+	if ValidateSweepRange(minCells, maxCells, step) != nil {
+		return nil
+	}
+
+	input := baseInput
+	if !input.EnableTPS() {
+		input.TPSCurve = DefaultTPSCurve
+	}
+
+	points := (maxCells-minCells)/step + 1
+	results := make([]models.ScenarioResult, 0, points+1)
+	for i := 0; i < points; i++ {
+		input.ProposedCellCount = minCells + i*step
+		results = append(results, c.CalculateProposed(state, input))
+	}
+	if (maxCells-minCells)%step != 0 {
+		input.ProposedCellCount = maxCells
+		results = append(results, c.CalculateProposed(state, input))
+	}
+	return results
+}
@@ -0,0 +1,92 @@
+// ABOUTME: Tests for the dry-run cell count sweep
+// ABOUTME: Covers range validation, step handling, and per-point TPS estimation
+
+package services
+
+import (
+	"math"
+	"testing"
+
+	"github.com/markalston/diego-capacity-analyzer/backend/models"
+)
+
+func TestValidateSweepRange(t *testing.T) {
+	tests := []struct {
+		name           string
+		min, max, step int
+		wantErr        bool
+	}{
+		{"valid range", 10, 100, 5, false},
+		{"single point", 10, 10, 1, false},
+		{"zero min", 0, 100, 5, true},
+		{"max below min", 50, 10, 5, true},
+		{"zero step", 10, 100, 0, true},
+		{"too many points", 1, MaxSweepPoints + 1, 1, true},
+		{"max above cap", 1, MaxSweepCells + 1, MaxSweepCells, true},
+		{"step wider than range", 10, 100, 91, true},
+		{"step equal to range", 10, 100, 90, false},
+		// Previously passed validation, then the loop counter overflowed and never ended
+		{"overflowing range", 1, math.MaxInt, math.MaxInt / 2, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSweepRange(tt.min, tt.max, tt.step)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateSweepRange(%d, %d, %d) error = %v, wantErr %v", tt.min, tt.max, tt.step, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCalculateSweep(t *testing.T) {
+	// 10 hosts × 512 GB, one cluster: N-1 = 9 × 512 = 4608 GB
+	state := models.InfrastructureState{
+		TotalMemoryGB:     5120,
+		TotalN1MemoryGB:   4608,
+		TotalHostCount:    10,
+		TotalCPUCores:     320,
+		TotalCellCount:    100,
+		PlatformVMsGB:     200,
+		TotalAppMemoryGB:  2000,
+		TotalAppInstances: 1000,
+		Clusters: []models.ClusterState{
+			{DiegoCellCount: 100, DiegoCellMemoryGB: 32, DiegoCellCPU: 4},
+		},
+	}
+
+	calc := NewScenarioCalculator()
+	results := calc.CalculateSweep(state, SweepBaseInput(state), 10, 100, 20)
+
+	// 10, 30, 50, 70, 90, then max (100) is always included
+	wantCells := []int{10, 30, 50, 70, 90, 100}
+	if len(results) != len(wantCells) {
+		t.Fatalf("Expected %d results, got %d", len(wantCells), len(results))
+	}
+	for i, r := range results {
+		if r.CellCount != wantCells[i] {
+			t.Errorf("results[%d]: expected %d cells, got %d", i, wantCells[i], r.CellCount)
+		}
+		if r.CellMemoryGB != 32 {
+			t.Errorf("results[%d]: expected cell memory from current state (32 GB), got %d", i, r.CellMemoryGB)
+		}
+		if r.TPSStatus == "disabled" || r.EstimatedTPS == 0 {
+			t.Errorf("results[%d]: expected TPS estimated with the default curve, got %d (%s)", i, r.EstimatedTPS, r.TPSStatus)
+		}
+	}
+
+	// More cells means higher N-1 utilization
+	for i := 1; i < len(results); i++ {
+		if results[i].N1UtilizationPct <= results[i-1].N1UtilizationPct {
+			t.Errorf("Expected N-1 utilization to rise with cell count, got %.1f%% then %.1f%%",
+				results[i-1].N1UtilizationPct, results[i].N1UtilizationPct)
+		}
+	}
+
+	if got := calc.CalculateSweep(state, SweepBaseInput(state), 10, 5, 1); got != nil {
+		t.Errorf("Expected nil for an invalid range, got %d results", len(got))
+	}
+	if got := calc.CalculateSweep(state, SweepBaseInput(state), 1, math.MaxInt, math.MaxInt/2); got != nil {
+		t.Errorf("Expected nil for an overflowing range, got %d results", len(got))
+	}
+}
@@ -5,16 +5,27 @@ package services
 
 import (
 	"context"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/url"
+	"os"
+	"regexp"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/markalston/diego-capacity-analyzer/backend/models"
+	"github.com/markalston/diego-capacity-analyzer/backend/units"
 	"github.com/vmware/govmomi"
 	"github.com/vmware/govmomi/find"
 	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/vim25"
 	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/soap"
 	"github.com/vmware/govmomi/vim25/types"
 )
 
@@ -25,14 +36,104 @@ type VSphereCredentials struct {
 	Password   string
 	Datacenter string
 	Insecure   bool
+	CACert     string // PEM CA bundle for vCenter (empty = system roots)
+	AllProxy   string // SSH+SOCKS5 proxy URL in BOSH_ALL_PROXY format (empty = direct)
 }
 
-// VSphereClient wraps govmomi client for infrastructure discovery
+// ErrNoDiegoCells is returned when a discovery finds no Diego cell VMs,
+// usually because the cell detection settings or the datacenter are wrong
+var ErrNoDiegoCells = errors.New("no Diego cells discovered; check VSPHERE_CELL_NAME_PATTERNS and datacenter")
+
+// vspherePingTTL is how long Ping reuses its last probe result when no session
+// is open, so frequent readiness checks do not log in to vCenter every time
+const vspherePingTTL = 30 * time.Second
+
+// VSphereClient wraps govmomi client for infrastructure discovery.
+// Concurrent callers share one vCenter session: each Connect takes a reference
+// and the matching Disconnect logs out once the last reference is released.
 type VSphereClient struct {
-	creds      VSphereCredentials
+	creds        VSphereCredentials
+	cellDetector *cellDetector // nil = built-in name heuristics
+
+	mu         sync.Mutex // guards refs and the connection fields below
+	refs       int
 	client     *govmomi.Client
 	finder     *find.Finder
 	datacenter *object.Datacenter
+
+	pingMu  sync.Mutex // serializes probes and guards the cached result
+	pingAt  time.Time
+	pingErr error
+}
+
+// cellDetector decides whether a VM is a Diego cell using operator-supplied
+// regexes and/or a specific BOSH custom attribute carrying the job name
+type cellDetector struct {
+	patterns   []*regexp.Regexp
+	customAttr string
+}
+
+// SetCellDetection replaces the built-in Diego cell heuristics. Patterns are
+// regexes matched against the job name from customAttr when set, otherwise
+// against the VM name. With only customAttr set, that attribute's value is
+// checked with the built-in job name heuristics. Calling with no patterns and
+// no attribute restores the default heuristics.
+func (v *VSphereClient) SetCellDetection(patterns []string, customAttr string) error {
+	if len(patterns) == 0 && customAttr == "" {
+		v.cellDetector = nil
+		return nil
+	}
+
+	detector := &cellDetector{customAttr: customAttr}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid cell name pattern %q: %w", pattern, err)
+		}
+		detector.patterns = append(detector.patterns, re)
+	}
+	v.cellDetector = detector
+	return nil
+}
+
+// isDiegoCell reports whether a VM with the given name and custom attributes
+// (attribute name to value) is a Diego cell
+func (d *cellDetector) isDiegoCell(vmName string, attrs map[string]string) bool {
+	subject := vmName
+	if d.customAttr != "" {
+		value, ok := attrs[d.customAttr]
+		if !ok {
+			return false
+		}
+		subject = value
+	}
+
+	if len(d.patterns) == 0 {
+		return isDiegoCellJobName(subject)
+	}
+	for _, re := range d.patterns {
+		if re.MatchString(subject) {
+			return true
+		}
+	}
+	return false
+}
+
+// isDiegoCellJobName applies the built-in heuristics to a BOSH job name
+func isDiegoCellJobName(value string) bool {
+	val := strings.ToLower(value)
+	return strings.Contains(val, "diego_cell") || strings.Contains(val, "diego-cell") ||
+		strings.HasPrefix(val, "compute") || strings.HasPrefix(val, "diego") ||
+		strings.Contains(val, "isolated_diego_cell")
+}
+
+// isDiegoCellVMName applies the built-in heuristics to a VM name
+func isDiegoCellVMName(vmName string) bool {
+	name := strings.ToLower(vmName)
+	return strings.Contains(name, "diego_cell") ||
+		strings.Contains(name, "diego-cell") ||
+		strings.HasPrefix(name, "compute") ||
+		strings.HasPrefix(name, "diego")
 }
 
 // NewVSphereClient creates a new vSphere client
@@ -42,8 +143,16 @@ func NewVSphereClient(creds VSphereCredentials) *VSphereClient {
 	}
 }
 
-// Connect establishes connection to vCenter
+// Connect establishes connection to vCenter, or takes a reference on the
+// session already open. Every successful Connect must be paired with Disconnect.
 func (v *VSphereClient) Connect(ctx context.Context) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.client != nil {
+		v.refs++
+		return nil
+	}
+
 	host := v.creds.Host
 	if !strings.HasPrefix(host, "https://") && !strings.HasPrefix(host, "http://") {
 		host = "https://" + host
@@ -55,53 +164,76 @@ func (v *VSphereClient) Connect(ctx context.Context) error {
 	}
 	u.User = url.UserPassword(v.creds.Username, v.creds.Password)
 
-	client, err := govmomi.NewClient(ctx, u, v.creds.Insecure)
+	rootCAs, err := vsphereCertPool(v.creds.CACert)
+	if err != nil {
+		return err
+	}
+
+	client, err := v.newGovmomiClient(ctx, u, rootCAs)
 	if err != nil {
 		// Provide more specific error messages
 		errStr := err.Error()
+		// Certificate errors first: their message includes host:port, as do proxy dial errors
+		if strings.Contains(errStr, "certificate") || strings.Contains(errStr, "x509") {
+			return fmt.Errorf("SSL certificate error connecting to %s - set VSPHERE_CA_CERT to the vCenter CA, or VSPHERE_INSECURE=true", v.creds.Host)
+		}
 		if strings.Contains(errStr, "connection refused") {
 			return fmt.Errorf("connection refused to vCenter at %s - verify the host is reachable", v.creds.Host)
 		}
 		if strings.Contains(errStr, "no such host") {
 			return fmt.Errorf("cannot resolve vCenter hostname '%s' - verify DNS", v.creds.Host)
 		}
-		if strings.Contains(errStr, "401") || strings.Contains(errStr, "Cannot complete login") {
+		// Match the full status text: a bare "401" also matches ports in host:port
+		if strings.Contains(errStr, "401 Unauthorized") || strings.Contains(errStr, "Cannot complete login") {
 			return fmt.Errorf("authentication failed - verify username and password")
 		}
 		if strings.Contains(errStr, "context deadline exceeded") || strings.Contains(errStr, "timeout") {
 			return fmt.Errorf("connection timeout to vCenter at %s - check network connectivity", v.creds.Host)
 		}
-		if strings.Contains(errStr, "certificate") || strings.Contains(errStr, "x509") {
-			return fmt.Errorf("SSL certificate error connecting to %s - try setting VSPHERE_INSECURE=true", v.creds.Host)
-		}
 		return fmt.Errorf("failed to connect to vCenter at %s: %w", v.creds.Host, err)
 	}
 
-	v.client = client
-	v.finder = find.NewFinder(client.Client, true)
+	finder := find.NewFinder(client.Client, true)
 
 	// Set datacenter
-	dc, err := v.finder.Datacenter(ctx, v.creds.Datacenter)
+	dc, err := finder.Datacenter(ctx, v.creds.Datacenter)
 	if err != nil {
+		// Log out rather than keep a session no caller holds a reference to
+		_ = client.Logout(ctx)
 		if strings.Contains(err.Error(), "not found") {
 			return fmt.Errorf("datacenter '%s' not found - verify the datacenter name", v.creds.Datacenter)
 		}
 		return fmt.Errorf("error accessing datacenter '%s': %w", v.creds.Datacenter, err)
 	}
+	finder.SetDatacenter(dc)
+	v.client = client
+	v.finder = finder
 	v.datacenter = dc
-	v.finder.SetDatacenter(dc)
+	v.refs = 1
 
-	slog.Info("vSphere connected successfully")
-	slog.Debug("vSphere connection details", "host", v.creds.Host, "datacenter", v.creds.Datacenter)
+	slog.InfoContext(ctx, "vSphere connected successfully")
+	slog.DebugContext(ctx, "vSphere connection details", "host", v.creds.Host, "datacenter", v.creds.Datacenter)
 	return nil
 }
 
-// Disconnect closes the vCenter connection
+// Disconnect releases a reference taken by Connect and closes the vCenter
+// connection when it was the last one. The client is cleared even if logout
+// fails, since govmomi still reports a logged-out client as valid.
 func (v *VSphereClient) Disconnect(ctx context.Context) error {
-	if v.client != nil {
-		return v.client.Logout(ctx)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.client == nil {
+		return nil
 	}
-	return nil
+	if v.refs--; v.refs > 0 {
+		return nil
+	}
+	err := v.client.Logout(ctx)
+	v.refs = 0
+	v.client = nil
+	v.finder = nil
+	v.datacenter = nil
+	return err
 }
 
 // ClusterInfo holds cluster inventory data
@@ -112,6 +244,19 @@ type ClusterInfo struct {
 	TotalCPUThreads int32
 	DiegoCellCount  int
 	DiegoCells      []VMInfo
+	// Memory reserved by a percentage-based HA admission control policy
+	// (0 when HA or admission control is off, or another policy is in use)
+	HAAdmissionControlPercentage int
+	// Shared, accessible datastores mounted by the cluster
+	Datastores []DatastoreInfo
+}
+
+// DatastoreInfo holds datastore capacity data
+type DatastoreInfo struct {
+	Name       string
+	Type       string
+	CapacityGB int64
+	FreeGB     int64
 }
 
 // HostInfo holds ESXi host data
@@ -135,19 +280,51 @@ type VMInfo struct {
 	IsDiegoCell  bool
 	CellMemoryGB int
 	CellCPU      int
+	// Non-root resource pool holding the VM (Diego cells only; nil when the
+	// VM sits in its cluster's or host's root pool)
+	ResourcePool *ResourcePoolInfo
+}
+
+// ResourcePoolInfo holds a resource pool's memory allocation
+type ResourcePoolInfo struct {
+	ID                  string // managed object reference value, unique per vCenter
+	Name                string
+	MemoryReservationMB int64
+	MemoryLimitMB       int64 // effective limit including ancestor pools, -1 = unlimited
+}
+
+// Limited reports whether the pool, or a pool above it, caps memory
+func (p *ResourcePoolInfo) Limited() bool {
+	return p != nil && p.MemoryLimitMB >= 0
 }
 
 // GetClusters retrieves all compute clusters in the datacenter
 func (v *VSphereClient) GetClusters(ctx context.Context) ([]ClusterInfo, error) {
+	// Fetch every VM once up front rather than re-reading the inventory per cluster
+	cells, err := v.getAllDiegoCells(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("getting Diego cells: %w", err)
+	}
+	return v.getClusters(ctx, cells)
+}
+
+// getClusters retrieves all compute clusters, assigning the given Diego cells
+// to the cluster they run in
+func (v *VSphereClient) getClusters(ctx context.Context, cells []VMInfo) ([]ClusterInfo, error) {
 	clusters, err := v.finder.ClusterComputeResourceList(ctx, "*")
 	if err != nil {
 		return nil, fmt.Errorf("listing clusters: %w", err)
 	}
 
+	datastores, err := v.getDatastores(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting datastores: %w", err)
+	}
+
 	result := make([]ClusterInfo, 0, len(clusters))
 
 	for _, cluster := range clusters {
-		info, err := v.getClusterInfo(ctx, cluster)
+		info, err := v.getClusterInfo(ctx, cluster, cells, datastores)
 		if err != nil {
 			return nil, fmt.Errorf("getting cluster %s info: %w", cluster.Name(), err)
 		}
@@ -157,18 +334,22 @@ func (v *VSphereClient) GetClusters(ctx context.Context) ([]ClusterInfo, error)
 	return result, nil
 }
 
-// getClusterInfo retrieves detailed info for a single cluster
-func (v *VSphereClient) getClusterInfo(ctx context.Context, cluster *object.ClusterComputeResource) (ClusterInfo, error) {
+// getClusterInfo retrieves detailed info for a single cluster, taking its
+// Diego cells and datastores from the prefetched datacenter-wide lists
+func (v *VSphereClient) getClusterInfo(ctx context.Context, cluster *object.ClusterComputeResource, allCells []VMInfo, datastores map[types.ManagedObjectReference]DatastoreInfo) (ClusterInfo, error) {
 	info := ClusterInfo{
 		Name: cluster.Name(),
 	}
 
 	// Get cluster properties
 	var clusterMo mo.ClusterComputeResource
-	err := cluster.Properties(ctx, cluster.Reference(), []string{"host"}, &clusterMo)
+	err := cluster.Properties(ctx, cluster.Reference(), []string{"host", "datastore", "configurationEx"}, &clusterMo)
 	if err != nil {
 		return info, fmt.Errorf("getting cluster properties: %w", err)
 	}
+	if cfg, ok := clusterMo.ConfigurationEx.(*types.ClusterConfigInfoEx); ok {
+		info.HAAdmissionControlPercentage = haAdmissionControlPercent(cfg.DasConfig)
+	}
 
 	// Get hosts
 	for _, hostRef := range clusterMo.Host {
@@ -182,17 +363,82 @@ func (v *VSphereClient) getClusterInfo(ctx context.Context, cluster *object.Clus
 		info.TotalCPUThreads += hostInfo.CPUThreads
 	}
 
-	// Get Diego cells in this cluster
-	cells, err := v.getDiegoCellsInCluster(ctx, cluster)
-	if err != nil {
-		return info, fmt.Errorf("getting Diego cells: %w", err)
+	// Attached datastores; local and inaccessible ones are absent from datastores
+	for _, ref := range clusterMo.Datastore {
+		if ds, ok := datastores[ref]; ok {
+			info.Datastores = append(info.Datastores, ds)
+		}
 	}
+
+	// Get Diego cells in this cluster
+	cells := diegoCellsInCluster(allCells, info.Name)
 	info.DiegoCells = cells
 	info.DiegoCellCount = len(cells)
 
 	return info, nil
 }
 
+// getDatastores lists every datastore in the datacenter and returns the shared,
+// accessible ones keyed by reference. Local datastores (mounted by a single
+// host) and inaccessible ones are skipped, since neither offers headroom a
+// Diego cell can be placed on.
+func (v *VSphereClient) getDatastores(ctx context.Context) (map[types.ManagedObjectReference]DatastoreInfo, error) {
+	list, err := v.finder.DatastoreList(ctx, "*")
+	if err != nil {
+		if _, ok := err.(*find.NotFoundError); ok {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("listing datastores: %w", err)
+	}
+
+	refs := make([]types.ManagedObjectReference, len(list))
+	for i, ds := range list {
+		refs[i] = ds.Reference()
+	}
+	var dsMos []mo.Datastore
+	if err := property.DefaultCollector(v.client.Client).Retrieve(ctx, refs, []string{"summary"}, &dsMos); err != nil {
+		return nil, fmt.Errorf("retrieving datastore properties: %w", err)
+	}
+
+	result := make(map[types.ManagedObjectReference]DatastoreInfo, len(dsMos))
+	for _, dsMo := range dsMos {
+		summary := dsMo.Summary
+		switch {
+		case !summary.Accessible:
+			slog.DebugContext(ctx, "Skipping inaccessible datastore", "datastore", summary.Name)
+			continue
+		case summary.MultipleHostAccess == nil || !*summary.MultipleHostAccess:
+			slog.DebugContext(ctx, "Skipping local datastore", "datastore", summary.Name)
+			continue
+		}
+		result[dsMo.Reference()] = DatastoreInfo{
+			Name:       summary.Name,
+			Type:       summary.Type,
+			CapacityGB: units.BytesToGiB(summary.Capacity),
+			FreeGB:     units.BytesToGiB(summary.FreeSpace),
+		}
+	}
+	return result, nil
+}
+
+// haAdmissionControlPercent returns the memory percentage reserved by the
+// cluster's HA admission control. Only the percentage-based (failover
+// resources) policy maps onto a reservation; slot and dedicated failover host
+// policies, or HA / admission control being off, yield 0.
+func haAdmissionControlPercent(das types.ClusterDasConfigInfo) int {
+	if das.Enabled == nil || !*das.Enabled {
+		return 0
+	}
+	if das.AdmissionControlEnabled != nil && !*das.AdmissionControlEnabled {
+		return 0
+	}
+	policy, ok := das.AdmissionControlPolicy.(*types.ClusterFailoverResourcesAdmissionControlPolicy)
+	if !ok {
+		return 0
+	}
+	return int(policy.MemoryFailoverResourcesPercent)
+}
+
 // getHostInfo retrieves host hardware summary
 func (v *VSphereClient) getHostInfo(ctx context.Context, host *object.HostSystem, clusterName string) (HostInfo, error) {
 	var hostMo mo.HostSystem
@@ -203,7 +449,7 @@ func (v *VSphereClient) getHostInfo(ctx context.Context, host *object.HostSystem
 
 	info := HostInfo{
 		Name:        host.Name(),
-		MemoryMB:    hostMo.Summary.Hardware.MemorySize / (1024 * 1024),
+		MemoryMB:    units.BytesToMiB(hostMo.Summary.Hardware.MemorySize),
 		CPUThreads:    int32(hostMo.Summary.Hardware.NumCpuThreads), // Logical processors (includes hyperthreading)
 		InCluster:   clusterName,
 		PowerState:  string(hostMo.Runtime.PowerState),
@@ -213,39 +459,209 @@ func (v *VSphereClient) getHostInfo(ctx context.Context, host *object.HostSystem
 	return info, nil
 }
 
-// getDiegoCellsInCluster finds Diego cell VMs in a cluster
-func (v *VSphereClient) getDiegoCellsInCluster(ctx context.Context, cluster *object.ClusterComputeResource) ([]VMInfo, error) {
-	// List all VMs in the datacenter
-	vms, err := v.finder.VirtualMachineList(ctx, "*")
-	if err != nil {
-		return nil, fmt.Errorf("listing VMs: %w", err)
-	}
-
+// diegoCellsInCluster filters VMs down to the Diego cells placed in a cluster
+func diegoCellsInCluster(vms []VMInfo, clusterName string) []VMInfo {
 	var cells []VMInfo
 	for _, vm := range vms {
-		vmInfo, err := v.getVMInfo(ctx, vm)
-		if err != nil {
+		if vm.Cluster == clusterName && vm.IsDiegoCell {
+			cells = append(cells, vm)
+		}
+	}
+	return cells
+}
+
+// vmProperties are the VirtualMachine properties needed to build a VMInfo
+var vmProperties = []string{"name", "config", "runtime", "resourcePool", "customValue", "availableField"}
+
+// getVMInfos retrieves configuration for a set of VMs using a fixed number of
+// property collector calls: one for the VMs, one for their hosts, one for the
+// hosts' clusters, and one per level of resource pool nesting above Diego
+// cells. Results keep the order of vms; VMs missing from the property
+// collector result are skipped.
+func (v *VSphereClient) getVMInfos(ctx context.Context, vms []*object.VirtualMachine) ([]VMInfo, error) {
+	if len(vms) == 0 {
+		return nil, nil
+	}
+
+	pc := property.DefaultCollector(v.client.Client)
+
+	refs := make([]types.ManagedObjectReference, len(vms))
+	for i, vm := range vms {
+		refs[i] = vm.Reference()
+	}
+	var vmMos []mo.VirtualMachine
+	if err := pc.Retrieve(ctx, refs, vmProperties, &vmMos); err != nil {
+		return nil, fmt.Errorf("retrieving VM properties: %w", err)
+	}
+
+	// Prefetch host names and host -> cluster placement for every referenced host
+	hostRefs := uniqueRefs(len(vmMos), func(i int) *types.ManagedObjectReference {
+		return vmMos[i].Runtime.Host
+	})
+	hostNames := make(map[types.ManagedObjectReference]string, len(hostRefs))
+	hostClusters := make(map[types.ManagedObjectReference]string, len(hostRefs))
+	rootPools := make(map[types.ManagedObjectReference]bool)
+	if len(hostRefs) > 0 {
+		var hostMos []mo.HostSystem
+		if err := pc.Retrieve(ctx, hostRefs, []string{"name", "parent"}, &hostMos); err != nil {
+			return nil, fmt.Errorf("retrieving host properties: %w", err)
+		}
+
+		clusterRefs := uniqueRefs(len(hostMos), func(i int) *types.ManagedObjectReference {
+			if parent := hostMos[i].Parent; parent != nil && parent.Type == "ClusterComputeResource" {
+				return parent
+			}
+			return nil
+		})
+		clusterNames := make(map[types.ManagedObjectReference]string, len(clusterRefs))
+		if len(clusterRefs) > 0 {
+			var clusterMos []mo.ClusterComputeResource
+			if err := pc.Retrieve(ctx, clusterRefs, []string{"name", "resourcePool"}, &clusterMos); err != nil {
+				return nil, fmt.Errorf("retrieving cluster properties: %w", err)
+			}
+			for _, c := range clusterMos {
+				clusterNames[c.Reference()] = c.Name
+				if c.ResourcePool != nil {
+					rootPools[*c.ResourcePool] = true
+				}
+			}
+		}
+
+		for _, h := range hostMos {
+			hostNames[h.Reference()] = h.Name
+			if h.Parent != nil {
+				if name, ok := clusterNames[*h.Parent]; ok {
+					hostClusters[h.Reference()] = name
+				}
+			}
+		}
+	}
+
+	byRef := make(map[types.ManagedObjectReference]mo.VirtualMachine, len(vmMos))
+	for _, vmMo := range vmMos {
+		byRef[vmMo.Reference()] = vmMo
+	}
+
+	result := make([]VMInfo, 0, len(vms))
+	cellPools := make(map[int]types.ManagedObjectReference)
+	for _, ref := range refs {
+		vmMo, ok := byRef[ref]
+		if !ok {
 			continue // Skip VMs we can't read
 		}
+		info := v.buildVMInfo(vmMo)
+		if vmMo.Runtime.Host != nil {
+			info.Host = hostNames[*vmMo.Runtime.Host]
+			info.Cluster = hostClusters[*vmMo.Runtime.Host]
+		}
+		// Pools are reported per cluster; cells in a cluster's root pool need no lookup
+		if info.IsDiegoCell && info.Cluster != "" && vmMo.ResourcePool != nil && !rootPools[*vmMo.ResourcePool] {
+			cellPools[len(result)] = *vmMo.ResourcePool
+		}
+		result = append(result, info)
+	}
 
-		// Filter to Diego cells in this cluster
-		if vmInfo.Cluster == cluster.Name() && vmInfo.IsDiegoCell {
-			cells = append(cells, vmInfo)
+	if len(cellPools) > 0 {
+		poolRefs := make([]types.ManagedObjectReference, 0, len(cellPools))
+		for _, ref := range cellPools {
+			poolRefs = append(poolRefs, ref)
+		}
+		pools, err := getResourcePools(ctx, pc, poolRefs, rootPools)
+		if err != nil {
+			return nil, err
+		}
+		for i, ref := range cellPools {
+			if pool, ok := pools[ref]; ok {
+				result[i].ResourcePool = &pool
+			}
 		}
 	}
-	return cells, nil
+	return result, nil
 }
 
-// getVMInfo retrieves VM configuration
-func (v *VSphereClient) getVMInfo(ctx context.Context, vm *object.VirtualMachine) (VMInfo, error) {
-	var vmMo mo.VirtualMachine
-	err := vm.Properties(ctx, vm.Reference(), []string{"config", "runtime", "summary", "customValue"}, &vmMo)
-	if err != nil {
-		return VMInfo{}, err
+// getResourcePools retrieves the pools in refs and every ancestor pool, one
+// property collector call per nesting level, and returns the non-root pools
+// with their effective memory limits. A root pool (whose parent is a cluster
+// or standalone host) only mirrors host capacity, so it is left out; pools
+// already known to be roots are not fetched at all.
+func getResourcePools(ctx context.Context, pc *property.Collector, refs []types.ManagedObjectReference, roots map[types.ManagedObjectReference]bool) (map[types.ManagedObjectReference]ResourcePoolInfo, error) {
+	fetched := make(map[types.ManagedObjectReference]mo.ResourcePool)
+	pending := uniqueRefs(len(refs), func(i int) *types.ManagedObjectReference { return &refs[i] })
+	for len(pending) > 0 {
+		var poolMos []mo.ResourcePool
+		if err := pc.Retrieve(ctx, pending, []string{"name", "parent", "config"}, &poolMos); err != nil {
+			return nil, fmt.Errorf("retrieving resource pool properties: %w", err)
+		}
+		pending = nil
+		for _, p := range poolMos {
+			fetched[p.Reference()] = p
+			if p.Parent != nil && p.Parent.Type == "ResourcePool" && !roots[*p.Parent] {
+				if _, seen := fetched[*p.Parent]; !seen {
+					pending = append(pending, *p.Parent)
+				}
+			}
+		}
+	}
+
+	isRoot := func(p mo.ResourcePool) bool {
+		return p.Parent == nil || p.Parent.Type != "ResourcePool"
 	}
 
+	// effectiveLimit is the tightest memory limit on the path up to the root pool
+	var effectiveLimit func(ref types.ManagedObjectReference) int64
+	effectiveLimit = func(ref types.ManagedObjectReference) int64 {
+		p, ok := fetched[ref]
+		if !ok || isRoot(p) {
+			return -1
+		}
+		limit := int64(-1)
+		if l := p.Config.MemoryAllocation.Limit; l != nil {
+			limit = *l
+		}
+		if parent := effectiveLimit(*p.Parent); parent >= 0 && (limit < 0 || parent < limit) {
+			limit = parent
+		}
+		return limit
+	}
+
+	result := make(map[types.ManagedObjectReference]ResourcePoolInfo, len(fetched))
+	for ref, p := range fetched {
+		if isRoot(p) {
+			continue
+		}
+		info := ResourcePoolInfo{
+			ID:            ref.Value,
+			Name:          p.Name,
+			MemoryLimitMB: effectiveLimit(ref),
+		}
+		if r := p.Config.MemoryAllocation.Reservation; r != nil {
+			info.MemoryReservationMB = *r
+		}
+		result[ref] = info
+	}
+	return result, nil
+}
+
+// uniqueRefs collects the distinct non-nil references returned by ref for indexes 0..n-1
+func uniqueRefs(n int, ref func(i int) *types.ManagedObjectReference) []types.ManagedObjectReference {
+	seen := make(map[types.ManagedObjectReference]bool, n)
+	var refs []types.ManagedObjectReference
+	for i := 0; i < n; i++ {
+		r := ref(i)
+		if r == nil || seen[*r] {
+			continue
+		}
+		seen[*r] = true
+		refs = append(refs, *r)
+	}
+	return refs
+}
+
+// buildVMInfo derives VM sizing and Diego cell detection from retrieved
+// properties. Host and cluster placement is filled in by the caller.
+func (v *VSphereClient) buildVMInfo(vmMo mo.VirtualMachine) VMInfo {
 	info := VMInfo{
-		Name:       vm.Name(),
+		Name:       vmMo.Name,
 		PowerState: string(vmMo.Runtime.PowerState),
 	}
 
@@ -254,73 +670,84 @@ func (v *VSphereClient) getVMInfo(ctx context.Context, vm *object.VirtualMachine
 		info.NumCPU = vmMo.Config.Hardware.NumCPU
 	}
 
-	// Check custom attributes for BOSH job name
-	// BOSH sets custom attributes like "job", "id", "deployment"
-	for _, cv := range vmMo.CustomValue {
-		if field, ok := cv.(*types.CustomFieldStringValue); ok {
-			// Check if value looks like a diego cell job name
-			val := strings.ToLower(field.Value)
-			if strings.Contains(val, "diego_cell") || strings.Contains(val, "diego-cell") ||
-				strings.HasPrefix(val, "compute") || strings.HasPrefix(val, "diego") ||
-				strings.Contains(val, "isolated_diego_cell") {
+	if v.cellDetector != nil {
+		info.IsDiegoCell = v.cellDetector.isDiegoCell(vmMo.Name, customAttributes(vmMo))
+	} else {
+		// Check custom attributes for BOSH job name
+		// BOSH sets custom attributes like "job", "id", "deployment"
+		for _, cv := range vmMo.CustomValue {
+			if field, ok := cv.(*types.CustomFieldStringValue); ok && isDiegoCellJobName(field.Value) {
 				info.IsDiegoCell = true
 				break
 			}
 		}
-	}
 
-	// Fallback to name-based detection if no custom attributes matched
-	if !info.IsDiegoCell {
-		name := strings.ToLower(vm.Name())
-		info.IsDiegoCell = strings.Contains(name, "diego_cell") ||
-			strings.Contains(name, "diego-cell") ||
-			strings.HasPrefix(name, "compute") ||
-			strings.HasPrefix(name, "diego")
+		// Fallback to name-based detection if no custom attributes matched
+		if !info.IsDiegoCell {
+			info.IsDiegoCell = isDiegoCellVMName(vmMo.Name)
+		}
 	}
 
 	if info.IsDiegoCell {
-		info.CellMemoryGB = int(info.MemoryMB / 1024)
+		info.CellMemoryGB = int(units.MBToGiB(info.MemoryMB))
 		info.CellCPU = int(info.NumCPU)
 	}
 
-	// Get host and cluster
-	if vmMo.Runtime.Host != nil {
-		host := object.NewHostSystem(v.client.Client, *vmMo.Runtime.Host)
-		info.Host = host.Name()
+	return info
+}
+
+// customAttributes maps custom attribute names to their string values for a VM
+func customAttributes(vmMo mo.VirtualMachine) map[string]string {
+	names := make(map[int32]string, len(vmMo.AvailableField))
+	for _, def := range vmMo.AvailableField {
+		names[def.Key] = def.Name
+	}
 
-		// Find cluster for this host
-		var hostMo mo.HostSystem
-		if err := host.Properties(ctx, host.Reference(), []string{"parent"}, &hostMo); err == nil {
-			if hostMo.Parent != nil && hostMo.Parent.Type == "ClusterComputeResource" {
-				cluster := object.NewClusterComputeResource(v.client.Client, *hostMo.Parent)
-				info.Cluster = cluster.Name()
+	attrs := make(map[string]string, len(vmMo.CustomValue))
+	for _, cv := range vmMo.CustomValue {
+		if field, ok := cv.(*types.CustomFieldStringValue); ok {
+			if name, found := names[field.Key]; found {
+				attrs[name] = field.Value
 			}
 		}
 	}
-
-	return info, nil
+	return attrs
 }
 
 // GetInfrastructureState builds InfrastructureState from vSphere data
 // Uses the same calculation logic as ManualInput.ToInfrastructureState() for consistency
-func (v *VSphereClient) GetInfrastructureState(ctx context.Context) (models.InfrastructureState, error) {
-	// Get all clusters for host/memory info
-	clusters, err := v.GetClusters(ctx)
+// progress, which may be nil, is told as VMs are scanned and cells and clusters found
+func (v *VSphereClient) GetInfrastructureState(ctx context.Context, progress ProgressFunc) (models.InfrastructureState, error) {
+	// Find all Diego cells across entire datacenter
+	allCells, err := v.getAllDiegoCells(ctx, progress)
 	if err != nil {
-		return models.InfrastructureState{}, fmt.Errorf("getting clusters: %w", err)
+		return models.InfrastructureState{}, fmt.Errorf("getting Diego cells: %w", err)
 	}
+	// Without cells every per-cell figure downstream would divide by zero
+	if len(allCells) == 0 {
+		return models.InfrastructureState{}, fmt.Errorf("%w (datacenter %q)", ErrNoDiegoCells, v.creds.Datacenter)
+	}
+	progress.Report(StageCellsFound, fmt.Sprintf("found %d Diego cells", len(allCells)), len(allCells))
 
-	// Find all Diego cells across entire datacenter
-	allCells, err := v.getAllDiegoCells(ctx)
+	// Get all clusters for host/memory info
+	clusters, err := v.getClusters(ctx, allCells)
 	if err != nil {
-		return models.InfrastructureState{}, fmt.Errorf("getting Diego cells: %w", err)
+		return models.InfrastructureState{}, fmt.Errorf("getting clusters: %w", err)
 	}
+	progress.Report(StageClustersFound, fmt.Sprintf("found %d clusters", len(clusters)), len(clusters))
+
+	slog.InfoContext(ctx, "vSphere Diego cell discovery complete", "cell_count", len(allCells))
 
-	slog.Info("vSphere Diego cell discovery complete", "cell_count", len(allCells))
+	return buildInfrastructureState(v.creds.Datacenter, clusters, allCells), nil
+}
 
+// buildInfrastructureState aggregates discovered clusters and Diego cells into
+// an InfrastructureState. Only powered-on hosts outside maintenance mode count
+// toward capacity; the rest are reported per cluster as offline or maintenance.
+func buildInfrastructureState(datacenter string, clusters []ClusterInfo, allCells []VMInfo) models.InfrastructureState {
 	// Build ManualInput from vSphere data to leverage existing calculation logic
 	manualInput := models.ManualInput{
-		Name:     v.creds.Datacenter,
+		Name:     datacenter,
 		Clusters: make([]models.ClusterInput, 0, len(clusters)),
 	}
 
@@ -343,7 +770,7 @@ func (v *VSphereClient) GetInfrastructureState(ctx context.Context) (models.Infr
 	}
 
 	if totalHosts > 0 {
-		avgMemoryPerHost = int(totalMemoryMB / int64(totalHosts) / 1024) // Convert to GB
+		avgMemoryPerHost = int(units.MBToGiB(totalMemoryMB / int64(totalHosts)))
 		avgCPUPerHost = int(totalCPUThreads) / totalHosts
 	}
 
@@ -381,27 +808,28 @@ func (v *VSphereClient) GetInfrastructureState(ctx context.Context) (models.Infr
 			continue
 		}
 
-		memoryPerHost := int(clusterMemoryMB / int64(clusterHosts) / 1024) // GB
+		memoryPerHost := int(units.MBToGiB(clusterMemoryMB / int64(clusterHosts)))
 		cpuPerHost := int(clusterCPUThreads) / clusterHosts
 
 		// Use first cell's size (assuming uniform within cluster)
 		cellMemoryGB := cells[0].CellMemoryGB
 		cellCPU := cells[0].CellCPU
 		if cellMemoryGB == 0 {
-			cellMemoryGB = int(cells[0].MemoryMB / 1024)
+			cellMemoryGB = int(units.MBToGiB(cells[0].MemoryMB))
 		}
 		if cellCPU == 0 {
 			cellCPU = int(cells[0].NumCPU)
 		}
 
 		clusterInput := models.ClusterInput{
-			Name:              c.Name,
-			HostCount:         clusterHosts,
-			MemoryGBPerHost:   memoryPerHost,
-			CPUThreadsPerHost: cpuPerHost,
-			DiegoCellCount:    len(cells),
-			DiegoCellMemoryGB: cellMemoryGB,
-			DiegoCellCPU:      cellCPU,
+			Name:                         c.Name,
+			HostCount:                    clusterHosts,
+			MemoryGBPerHost:              memoryPerHost,
+			CPUThreadsPerHost:            cpuPerHost,
+			HAAdmissionControlPercentage: c.HAAdmissionControlPercentage,
+			DiegoCellCount:               len(cells),
+			DiegoCellMemoryGB:            cellMemoryGB,
+			DiegoCellCPU:                 cellCPU,
 		}
 
 		manualInput.Clusters = append(manualInput.Clusters, clusterInput)
@@ -413,7 +841,7 @@ func (v *VSphereClient) GetInfrastructureState(ctx context.Context) (models.Infr
 		cellMemoryGB := defaultCells[0].CellMemoryGB
 		cellCPU := defaultCells[0].CellCPU
 		if cellMemoryGB == 0 {
-			cellMemoryGB = int(defaultCells[0].MemoryMB / 1024)
+			cellMemoryGB = int(units.MBToGiB(defaultCells[0].MemoryMB))
 		}
 		if cellCPU == 0 {
 			cellCPU = int(defaultCells[0].NumCPU)
@@ -435,26 +863,156 @@ func (v *VSphereClient) GetInfrastructureState(ctx context.Context) (models.Infr
 	state := manualInput.ToInfrastructureState()
 	state.Source = "vsphere" // Override source
 
-	return state, nil
+	// Attach hosts excluded from capacity so temporarily unavailable capacity stays visible
+	var allHosts []HostInfo
+	unavailableByCluster := make(map[string]hostAvailability, len(clusters))
+	for _, c := range clusters {
+		allHosts = append(allHosts, c.Hosts...)
+		unavailableByCluster[c.Name] = summarizeUnavailableHosts(c.Hosts)
+	}
+	if _, exists := unavailableByCluster["unassigned"]; !exists {
+		unavailableByCluster["unassigned"] = summarizeUnavailableHosts(allHosts)
+	}
+	for i := range state.Clusters {
+		unavailableByCluster[state.Clusters[i].Name].apply(&state.Clusters[i])
+	}
+
+	applyDatastoreCapacity(&state, clusters, cellsByCluster)
+	for i := range state.Clusters {
+		name := state.Clusters[i].Name
+		if name == "unassigned" {
+			name = "default"
+		}
+		applyResourcePools(&state.Clusters[i], cellsByCluster[name])
+	}
+
+	return state
+}
+
+// applyResourcePools totals memory reservations and limits of the resource
+// pools holding a cluster's Diego cells, and warns when a pool's limit is
+// below the memory configured on the cells inside it
+func applyResourcePools(cs *models.ClusterState, cells []VMInfo) {
+	pools := make(map[string]*ResourcePoolInfo)
+	cellMemoryMB := make(map[string]int64)
+	for _, cell := range cells {
+		if cell.ResourcePool == nil {
+			continue
+		}
+		pools[cell.ResourcePool.ID] = cell.ResourcePool
+		cellMemoryMB[cell.ResourcePool.ID] += int64(cell.MemoryMB)
+		if cell.ResourcePool.Limited() {
+			cs.CellsInLimitedPools++
+		}
+	}
+
+	for id, pool := range pools {
+		cs.ResourcePoolReservationGB += int(units.MBToGiB(pool.MemoryReservationMB))
+		if !pool.Limited() {
+			continue
+		}
+		cs.ResourcePoolLimitGB += int(units.MBToGiB(pool.MemoryLimitMB))
+		if pool.MemoryLimitMB < cellMemoryMB[id] {
+			slog.Warn("Diego cells are in a resource pool whose memory limit is below their configured memory",
+				"cluster", cs.Name,
+				"resource_pool", pool.Name,
+				"limit_mb", pool.MemoryLimitMB,
+				"cell_memory_mb", cellMemoryMB[id])
+		} else {
+			slog.Info("Diego cells are in a memory-limited resource pool",
+				"cluster", cs.Name,
+				"resource_pool", pool.Name,
+				"limit_mb", pool.MemoryLimitMB)
+		}
+	}
+}
+
+// applyDatastoreCapacity totals the datastores attached to clusters running
+// Diego cells, counting a datastore shared by several clusters once
+func applyDatastoreCapacity(state *models.InfrastructureState, clusters []ClusterInfo, cellsByCluster map[string][]VMInfo) {
+	counted := make(map[string]bool)
+	for _, c := range clusters {
+		if len(cellsByCluster[c.Name]) == 0 {
+			continue
+		}
+		for _, ds := range c.Datastores {
+			if counted[ds.Name] {
+				continue
+			}
+			counted[ds.Name] = true
+			state.TotalDatastoreCapacityGB += int(ds.CapacityGB)
+			state.TotalDatastoreFreeGB += int(ds.FreeGB)
+		}
+	}
+	slog.Info("vSphere datastore capacity counted",
+		"datastores", len(counted),
+		"capacity_gb", state.TotalDatastoreCapacityGB,
+		"free_gb", state.TotalDatastoreFreeGB)
+}
+
+// hostAvailability tallies hosts that are excluded from capacity
+type hostAvailability struct {
+	offlineHosts          int
+	offlineMemoryMB       int64
+	offlineCPUThreads     int32
+	maintenanceHosts      int
+	maintenanceMemoryMB   int64
+	maintenanceCPUThreads int32
+}
+
+// summarizeUnavailableHosts counts hosts in maintenance mode and hosts that are
+// not powered on. A powered-off host in maintenance counts as maintenance only.
+func summarizeUnavailableHosts(hosts []HostInfo) hostAvailability {
+	var a hostAvailability
+	for _, h := range hosts {
+		switch {
+		case h.Maintenance:
+			a.maintenanceHosts++
+			a.maintenanceMemoryMB += h.MemoryMB
+			a.maintenanceCPUThreads += h.CPUThreads
+		case h.PowerState != "poweredOn":
+			a.offlineHosts++
+			a.offlineMemoryMB += h.MemoryMB
+			a.offlineCPUThreads += h.CPUThreads
+		}
+	}
+	return a
+}
+
+// apply copies the tallies onto a cluster's state
+func (a hostAvailability) apply(cs *models.ClusterState) {
+	cs.OfflineHostCount = a.offlineHosts
+	cs.OfflineMemoryGB = int(units.MBToGiB(a.offlineMemoryMB))
+	cs.OfflineCPUThreads = int(a.offlineCPUThreads)
+	cs.MaintenanceHostCount = a.maintenanceHosts
+	cs.MaintenanceMemoryGB = int(units.MBToGiB(a.maintenanceMemoryMB))
+	cs.MaintenanceCPUThreads = int(a.maintenanceCPUThreads)
 }
 
 // getAllDiegoCells finds all Diego cell VMs in the datacenter
-func (v *VSphereClient) getAllDiegoCells(ctx context.Context) ([]VMInfo, error) {
+func (v *VSphereClient) getAllDiegoCells(ctx context.Context, progress ProgressFunc) ([]VMInfo, error) {
 	vms, err := v.finder.VirtualMachineList(ctx, "*")
 	if err != nil {
 		return nil, fmt.Errorf("listing VMs: %w", err)
 	}
+	progress.Report(StageScanningVMs, fmt.Sprintf("scanning %d VMs", len(vms)), len(vms))
+
+	infos, err := v.getVMInfos(ctx, vms)
+	if err != nil {
+		return nil, err
+	}
 
 	var cells []VMInfo
-	for _, vm := range vms {
-		vmInfo, err := v.getVMInfo(ctx, vm)
-		if err != nil {
-			continue
-		}
+	for _, vmInfo := range infos {
 		if vmInfo.IsDiegoCell {
 			cells = append(cells, vmInfo)
 		}
 	}
+	slog.InfoContext(ctx, "vSphere VM scan complete",
+		"datacenter", v.creds.Datacenter,
+		"vms_scanned", len(infos),
+		"cells_matched", len(cells),
+		"custom_cell_detection", v.cellDetector != nil)
 
 	return cells, nil
 }
@@ -488,28 +1046,125 @@ func ParseOpsManagerCredentials(iaasConfig map[string]interface{}) (VSphereCrede
 		return creds, fmt.Errorf("missing datacenter")
 	}
 
-	// Default to insecure for lab environments
-	creds.Insecure = true
+	// Verify against the director's vCenter CA when one is configured;
+	// otherwise default to insecure for lab environments
+	if ca, ok := iaasConfig["vcenter_ca_certificate"].(string); ok && ca != "" {
+		creds.CACert = ca
+	} else {
+		creds.Insecure = true
+	}
 
 	return creds, nil
 }
 
-// VSphereClientFromEnv creates a client from environment variables
-func VSphereClientFromEnv(host, user, pass, datacenter string) *VSphereClient {
+// VSphereClientFromEnv creates a client from environment variables. Certificates
+// are verified against caCert, or the system roots when it is empty, unless
+// insecure is set; a caCert that cannot be parsed is an error.
+func VSphereClientFromEnv(host, user, pass, datacenter, caCert string, insecure bool) (*VSphereClient, error) {
+	if _, err := vsphereCertPool(caCert); err != nil {
+		return nil, err
+	}
 	return NewVSphereClient(VSphereCredentials{
 		Host:       host,
 		Username:   user,
 		Password:   pass,
 		Datacenter: datacenter,
-		Insecure:   true,
-	})
+		Insecure:   insecure,
+		CACert:     caCert,
+		AllProxy:   os.Getenv("VSPHERE_ALL_PROXY"),
+	}), nil
+}
+
+// vsphereCertPool parses caCert into a certificate pool, returning nil when
+// caCert is empty so the system roots are used
+func vsphereCertPool(caCert string) (*x509.CertPool, error) {
+	if caCert == "" {
+		return nil, nil
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM([]byte(caCert)) {
+		return nil, fmt.Errorf("VSPHERE_CA_CERT is malformed - certificate could not be parsed")
+	}
+	return pool, nil
+}
+
+// newGovmomiClient logs in to vCenter at u, dialing through the SSH+SOCKS5
+// proxy when one is configured. It is govmomi.NewClient with the proxy dialer
+// and CA pool installed on the SOAP transport before the first request. A
+// configured CA takes precedence over Insecure, as it does for BOSH.
+func (v *VSphereClient) newGovmomiClient(ctx context.Context, u *url.URL, rootCAs *x509.CertPool) (*govmomi.Client, error) {
+	insecure := v.creds.Insecure && rootCAs == nil
+	soapClient := soap.NewClient(u, insecure)
+	if rootCAs != nil {
+		soapClient.DefaultTransport().TLSClientConfig.RootCAs = rootCAs
+	}
+	if v.creds.AllProxy != "" {
+		dialContextFunc := createSOCKS5DialContextFunc(v.creds.AllProxy)
+		if dialContextFunc == nil {
+			return nil, fmt.Errorf("invalid VSPHERE_ALL_PROXY - expected ssh+socks5://user@host:port?private-key=/path/to/key")
+		}
+		// govmomi dials TLS itself, bypassing DialContext; clearing its
+		// DialTLSContext lets the transport run the handshake over the proxy
+		transport := soapClient.DefaultTransport()
+		transport.DialContext = dialContextFunc
+		transport.DialTLSContext = nil
+	}
+
+	vimClient, err := vim25.NewClient(ctx, soapClient)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &govmomi.Client{
+		Client:         vimClient,
+		SessionManager: session.NewManager(vimClient),
+	}
+	if err := client.Login(ctx, u.User); err != nil {
+		return nil, err
+	}
+	return client, nil
 }
 
 // IsConnected returns true if client has an active connection
 func (v *VSphereClient) IsConnected() bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
 	return v.client != nil && v.client.Valid()
 }
 
+// Ping verifies vCenter is reachable and accepts the configured credentials.
+// A valid existing session is reused; otherwise a short-lived session is opened
+// on a separate client and logged out, so this client's connection is untouched.
+// That probe's result, success or failure, is reused for vspherePingTTL.
+func (v *VSphereClient) Ping(ctx context.Context) error {
+	if v.IsConnected() {
+		return nil
+	}
+
+	v.pingMu.Lock()
+	defer v.pingMu.Unlock()
+	if !v.pingAt.IsZero() && time.Since(v.pingAt) < vspherePingTTL {
+		return v.pingErr
+	}
+
+	v.pingErr = v.probe(ctx)
+	v.pingAt = time.Now()
+	return v.pingErr
+}
+
+// probe logs in to vCenter on a separate client and logs out again
+func (v *VSphereClient) probe(ctx context.Context) error {
+	probe := NewVSphereClient(v.creds)
+	defer probe.Disconnect(ctx)
+	if err := probe.Connect(ctx); err != nil {
+		return err
+	}
+	if !probe.IsConnected() {
+		return fmt.Errorf("vCenter session is not valid")
+	}
+	return nil
+}
+
 // GetClusterNames returns just the cluster names (useful for dropdowns)
 func (v *VSphereClient) GetClusterNames(ctx context.Context) ([]string, error) {
 	clusters, err := v.finder.ClusterComputeResourceList(ctx, "*")
@@ -535,16 +1190,7 @@ func (v *VSphereClient) FilterVMsByPattern(ctx context.Context, pattern string)
 		return nil, err
 	}
 
-	result := make([]VMInfo, 0, len(vms))
-	for _, vm := range vms {
-		info, err := v.getVMInfo(ctx, vm)
-		if err != nil {
-			continue
-		}
-		result = append(result, info)
-	}
-
-	return result, nil
+	return v.getVMInfos(ctx, vms)
 }
 
 // Ensure types is used (govmomi requires it for ManagedObjectReference handling)
@@ -1,10 +1,27 @@
 // ABOUTME: Unit tests for vSphere service
-// ABOUTME: Tests credential parsing and infrastructure state conversion
+// ABOUTME: Tests credential parsing, cell detection, and batched inventory retrieval against vcsim
 
 package services
 
 import (
+	"context"
+	"crypto/tls"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
 )
 
 func TestParseOpsManagerCredentials(t *testing.T) {
@@ -31,6 +48,24 @@ func TestParseOpsManagerCredentials(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "with vCenter CA",
+			config: map[string]interface{}{
+				"vcenter_host":           "vcenter.example.com",
+				"vcenter_username":       "admin@vsphere.local",
+				"vcenter_password":       "secret123",
+				"datacenter":             "DC1",
+				"vcenter_ca_certificate": "-----BEGIN CERTIFICATE-----",
+			},
+			want: VSphereCredentials{
+				Host:       "vcenter.example.com",
+				Username:   "admin@vsphere.local",
+				Password:   "secret123",
+				Datacenter: "DC1",
+				CACert:     "-----BEGIN CERTIFICATE-----",
+			},
+			wantErr: false,
+		},
 		{
 			name: "missing host",
 			config: map[string]interface{}{
@@ -98,12 +133,17 @@ func TestParseOpsManagerCredentials(t *testing.T) {
 }
 
 func TestVSphereClientFromEnv(t *testing.T) {
-	client := VSphereClientFromEnv(
+	client, err := VSphereClientFromEnv(
 		"vcenter.example.com",
 		"admin@vsphere.local",
 		"secret123",
 		"DC1",
+		"",
+		false,
 	)
+	if err != nil {
+		t.Fatalf("VSphereClientFromEnv failed: %v", err)
+	}
 
 	if client == nil {
 		t.Fatal("Expected non-nil client")
@@ -120,8 +160,138 @@ func TestVSphereClientFromEnv(t *testing.T) {
 	if client.creds.Datacenter != "DC1" {
 		t.Errorf("Datacenter = %v, want DC1", client.creds.Datacenter)
 	}
-	if !client.creds.Insecure {
-		t.Error("Expected Insecure to be true")
+	if client.creds.Insecure {
+		t.Error("Expected Insecure to be false unless requested")
+	}
+}
+
+func TestVSphereClientFromEnv_MalformedCACert(t *testing.T) {
+	for _, insecure := range []bool{false, true} {
+		_, err := VSphereClientFromEnv("vcenter.example.com", "admin", "secret", "DC1", "not a certificate", insecure)
+		if err == nil || !strings.Contains(err.Error(), "VSPHERE_CA_CERT is malformed") {
+			t.Errorf("insecure=%v: error = %v, want malformed CA error", insecure, err)
+		}
+	}
+}
+
+func TestVSphereClientFromEnv_AllProxy(t *testing.T) {
+	proxyURL := "ssh+socks5://ubuntu@opsman.example.com:22?private-key=/tmp/key"
+	t.Setenv("VSPHERE_ALL_PROXY", proxyURL)
+
+	client, err := VSphereClientFromEnv("vcenter.example.com", "admin@vsphere.local", "secret123", "DC1", "", false)
+	if err != nil {
+		t.Fatalf("VSphereClientFromEnv failed: %v", err)
+	}
+	if client.creds.AllProxy != proxyURL {
+		t.Errorf("AllProxy = %q, want %q", client.creds.AllProxy, proxyURL)
+	}
+}
+
+// newSimulatorServer starts a default vcsim inventory over TLS and returns
+// credentials for it that skip certificate verification
+func newSimulatorServer(t *testing.T) (*simulator.Server, VSphereCredentials) {
+	t.Helper()
+
+	model := simulator.VPX()
+	if err := model.Create(); err != nil {
+		t.Fatalf("creating simulator model: %v", err)
+	}
+	// vCenter is always HTTPS, which govmomi dials separately from plain HTTP
+	model.Service.TLS = new(tls.Config)
+	server := model.Service.NewServer()
+	t.Cleanup(func() {
+		server.Close()
+		model.Remove()
+	})
+
+	password, _ := server.URL.User.Password()
+	return server, VSphereCredentials{
+		Host:       server.URL.Scheme + "://" + server.URL.Host,
+		Username:   server.URL.User.Username(),
+		Password:   password,
+		Datacenter: "DC0",
+		Insecure:   true,
+	}
+}
+
+func TestConnect_CACert(t *testing.T) {
+	server, creds := newSimulatorServer(t)
+	simulatorCA := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+
+	tests := []struct {
+		name     string
+		caCert   string
+		insecure bool
+		wantErr  string
+	}{
+		{"verified with CA", simulatorCA, false, ""},
+		{"CA takes precedence over insecure", simulatorCA, true, ""},
+		{"explicitly insecure", "", true, ""},
+		{"system roots reject the simulator", "", false, "SSL certificate error"},
+		{"malformed CA", "not a certificate", true, "VSPHERE_CA_CERT is malformed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := creds
+			c.CACert = tt.caCert
+			c.Insecure = tt.insecure
+			client := NewVSphereClient(c)
+			defer client.Disconnect(context.Background())
+
+			err := client.Connect(context.Background())
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Connect failed: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Connect error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConnect_AllProxy(t *testing.T) {
+	_, creds := newSimulatorServer(t)
+
+	keyDir := t.TempDir()
+	keyPath := filepath.Join(keyDir, "id_rsa")
+	if err := os.WriteFile(keyPath, []byte("not a key"), 0600); err != nil {
+		t.Fatalf("writing key: %v", err)
+	}
+	t.Setenv("BOSH_SSH_KEY_ALLOWED_DIRS", keyDir)
+
+	tests := []struct {
+		name     string
+		allProxy string
+		wantErr  string
+	}{
+		{"direct", "", ""},
+		{"missing private key", "ssh+socks5://ubuntu@127.0.0.1:1", "invalid VSPHERE_ALL_PROXY"},
+		// The simulator is reachable directly, so failing proves the dial went through the proxy
+		{"dials through proxy", "ssh+socks5://ubuntu@127.0.0.1:1?private-key=" + keyPath, "SOCKS5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := creds
+			c.AllProxy = tt.allProxy
+			client := NewVSphereClient(c)
+			defer client.Disconnect(context.Background())
+
+			err := client.Connect(context.Background())
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Connect failed: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Connect error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
 	}
 }
 
@@ -206,6 +376,76 @@ func containsDiegoCellPattern(name string) bool {
 	return false
 }
 
+func TestBuiltinCellHeuristics(t *testing.T) {
+	// Documents current behavior, including the compute-broker false positive
+	// that VSPHERE_CELL_NAME_PATTERNS exists to avoid
+	tests := []struct {
+		name    string
+		isDiego bool
+	}{
+		{"diego_cell/abc123", true},
+		{"compute-broker", true},
+		{"router/abc123", false},
+	}
+
+	for _, tt := range tests {
+		if got := isDiegoCellVMName(tt.name); got != tt.isDiego {
+			t.Errorf("isDiegoCellVMName(%q) = %v, want %v", tt.name, got, tt.isDiego)
+		}
+	}
+}
+
+func TestSetCellDetection(t *testing.T) {
+	tests := []struct {
+		name       string
+		patterns   []string
+		customAttr string
+		vmName     string
+		attrs      map[string]string
+		isDiego    bool
+	}{
+		{"pattern matches VM name", []string{`^vm-cell-\d+$`}, "", "vm-cell-07", nil, true},
+		{"pattern rejects broker", []string{`^diego_cell`}, "", "compute-broker", nil, false},
+		{"pattern matches custom attribute", []string{`^(isolated_)?diego_cell$`}, "job", "vm-1a2b", map[string]string{"job": "isolated_diego_cell"}, true},
+		{"custom attribute ignores VM name", []string{`^diego_cell$`}, "job", "diego_cell-0", map[string]string{"job": "router"}, false},
+		{"custom attribute missing", nil, "job", "diego_cell-0", map[string]string{"deployment": "cf"}, false},
+		{"custom attribute with built-in job heuristics", nil, "job", "vm-1a2b", map[string]string{"job": "diego_cell"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewVSphereClient(VSphereCredentials{})
+			if err := client.SetCellDetection(tt.patterns, tt.customAttr); err != nil {
+				t.Fatalf("SetCellDetection failed: %v", err)
+			}
+			if got := client.cellDetector.isDiegoCell(tt.vmName, tt.attrs); got != tt.isDiego {
+				t.Errorf("isDiegoCell(%q, %v) = %v, want %v", tt.vmName, tt.attrs, got, tt.isDiego)
+			}
+		})
+	}
+}
+
+func TestSetCellDetection_InvalidPattern(t *testing.T) {
+	client := NewVSphereClient(VSphereCredentials{})
+	err := client.SetCellDetection([]string{"diego_cell(["}, "")
+	if err == nil {
+		t.Fatal("Expected error for invalid regex")
+	}
+	if !strings.Contains(err.Error(), "diego_cell([") {
+		t.Errorf("Expected error to name the pattern, got %v", err)
+	}
+}
+
+func TestSetCellDetection_EmptyUsesHeuristics(t *testing.T) {
+	client := NewVSphereClient(VSphereCredentials{})
+	if err := client.SetCellDetection(nil, ""); err != nil {
+		t.Fatalf("SetCellDetection failed: %v", err)
+	}
+	if client.cellDetector != nil {
+		t.Error("Expected nil detector when nothing is configured")
+	}
+}
+
 func TestClusterInfoHostAggregation(t *testing.T) {
 	// Test that ClusterInfo correctly aggregates host data
 	info := ClusterInfo{
@@ -235,3 +475,549 @@ func TestClusterInfoHostAggregation(t *testing.T) {
 		t.Errorf("Total cores = %d, want %d", totalCores, expectedCores)
 	}
 }
+
+func TestBuildInfrastructureState_UnavailableHosts(t *testing.T) {
+	clusters := []ClusterInfo{
+		{
+			Name: "cluster-a",
+			Hosts: []HostInfo{
+				{Name: "esx01", MemoryMB: 524288, CPUThreads: 64, PowerState: "poweredOn"},
+				{Name: "esx02", MemoryMB: 524288, CPUThreads: 64, PowerState: "poweredOn"},
+				{Name: "esx03", MemoryMB: 524288, CPUThreads: 64, PowerState: "poweredOn", Maintenance: true},
+				{Name: "esx04", MemoryMB: 524288, CPUThreads: 64, PowerState: "poweredOff", Maintenance: true},
+				{Name: "esx05", MemoryMB: 262144, CPUThreads: 32, PowerState: "poweredOff"},
+				{Name: "esx06", MemoryMB: 262144, CPUThreads: 32, PowerState: "standBy"},
+			},
+		},
+	}
+	cells := []VMInfo{
+		{Name: "diego_cell/0", Cluster: "cluster-a", IsDiegoCell: true, CellMemoryGB: 32, CellCPU: 4},
+		{Name: "diego_cell/1", Cluster: "cluster-a", IsDiegoCell: true, CellMemoryGB: 32, CellCPU: 4},
+	}
+
+	state := buildInfrastructureState("DC0", clusters, cells)
+	if len(state.Clusters) != 1 {
+		t.Fatalf("Expected 1 cluster, got %d", len(state.Clusters))
+	}
+	cs := state.Clusters[0]
+
+	// Capacity math still only counts available hosts
+	if cs.HostCount != 2 || cs.MemoryGB != 1024 {
+		t.Errorf("Expected 2 available hosts with 1024 GB, got %d hosts with %d GB", cs.HostCount, cs.MemoryGB)
+	}
+
+	if cs.MaintenanceHostCount != 2 || cs.MaintenanceMemoryGB != 1024 || cs.MaintenanceCPUThreads != 128 {
+		t.Errorf("Maintenance = %d hosts/%d GB/%d threads, want 2/1024/128",
+			cs.MaintenanceHostCount, cs.MaintenanceMemoryGB, cs.MaintenanceCPUThreads)
+	}
+	if cs.OfflineHostCount != 2 || cs.OfflineMemoryGB != 512 || cs.OfflineCPUThreads != 64 {
+		t.Errorf("Offline = %d hosts/%d GB/%d threads, want 2/512/64",
+			cs.OfflineHostCount, cs.OfflineMemoryGB, cs.OfflineCPUThreads)
+	}
+}
+
+func TestBuildInfrastructureState_HAAdmissionControl(t *testing.T) {
+	hosts := []HostInfo{
+		{Name: "esx01", MemoryMB: 524288, CPUThreads: 64, PowerState: "poweredOn"},
+		{Name: "esx02", MemoryMB: 524288, CPUThreads: 64, PowerState: "poweredOn"},
+		{Name: "esx03", MemoryMB: 524288, CPUThreads: 64, PowerState: "poweredOn"},
+		{Name: "esx04", MemoryMB: 524288, CPUThreads: 64, PowerState: "poweredOn"},
+	}
+	clusters := []ClusterInfo{{Name: "cluster-a", Hosts: hosts, HAAdmissionControlPercentage: 25}}
+	cells := []VMInfo{{Name: "diego_cell/0", Cluster: "cluster-a", IsDiegoCell: true, CellMemoryGB: 32, CellCPU: 4}}
+
+	state := buildInfrastructureState("DC0", clusters, cells)
+	if len(state.Clusters) != 1 {
+		t.Fatalf("Expected 1 cluster, got %d", len(state.Clusters))
+	}
+	cs := state.Clusters[0]
+	if cs.HAAdmissionControlPercentage != 25 {
+		t.Errorf("HAAdmissionControlPercentage = %d, want 25", cs.HAAdmissionControlPercentage)
+	}
+	// 25% of 4 x 512 GB is held back for HA
+	if cs.HAUsableMemoryGB != 1536 {
+		t.Errorf("HAUsableMemoryGB = %d, want 1536", cs.HAUsableMemoryGB)
+	}
+}
+
+func TestBuildInfrastructureState_DatastoreCapacity(t *testing.T) {
+	hosts := []HostInfo{{Name: "esx01", MemoryMB: 524288, CPUThreads: 64, PowerState: "poweredOn"}}
+	shared := DatastoreInfo{Name: "vsan-shared", CapacityGB: 10000, FreeGB: 4000}
+	clusters := []ClusterInfo{
+		{Name: "cluster-a", Hosts: hosts, Datastores: []DatastoreInfo{shared, {Name: "nfs-a", CapacityGB: 2000, FreeGB: 500}}},
+		{Name: "cluster-b", Hosts: hosts, Datastores: []DatastoreInfo{shared}},
+		{Name: "mgmt", Hosts: hosts, Datastores: []DatastoreInfo{{Name: "mgmt-ds", CapacityGB: 5000, FreeGB: 5000}}},
+	}
+	cells := []VMInfo{
+		{Name: "diego_cell/0", Cluster: "cluster-a", IsDiegoCell: true, CellMemoryGB: 32, CellCPU: 4},
+		{Name: "diego_cell/1", Cluster: "cluster-b", IsDiegoCell: true, CellMemoryGB: 32, CellCPU: 4},
+	}
+
+	state := buildInfrastructureState("DC0", clusters, cells)
+
+	// The shared datastore counts once; the cell-less mgmt cluster is ignored
+	if state.TotalDatastoreCapacityGB != 12000 || state.TotalDatastoreFreeGB != 4500 {
+		t.Errorf("Datastores = %d GB capacity / %d GB free, want 12000/4500",
+			state.TotalDatastoreCapacityGB, state.TotalDatastoreFreeGB)
+	}
+}
+
+func TestBuildInfrastructureState_ResourcePools(t *testing.T) {
+	hosts := []HostInfo{{Name: "esx01", MemoryMB: 524288, CPUThreads: 64, PowerState: "poweredOn"}}
+	clusters := []ClusterInfo{{Name: "cluster-a", Hosts: hosts}}
+	limited := &ResourcePoolInfo{ID: "resgroup-1", Name: "diego", MemoryReservationMB: 65536, MemoryLimitMB: 131072}
+	reserved := &ResourcePoolInfo{ID: "resgroup-2", Name: "diego-iso", MemoryReservationMB: 32768, MemoryLimitMB: -1}
+	cells := []VMInfo{
+		{Name: "diego_cell/0", Cluster: "cluster-a", IsDiegoCell: true, MemoryMB: 32768, CellMemoryGB: 32, CellCPU: 4, ResourcePool: limited},
+		{Name: "diego_cell/1", Cluster: "cluster-a", IsDiegoCell: true, MemoryMB: 32768, CellMemoryGB: 32, CellCPU: 4, ResourcePool: limited},
+		{Name: "iso_cell/0", Cluster: "cluster-a", IsDiegoCell: true, MemoryMB: 32768, CellMemoryGB: 32, CellCPU: 4, ResourcePool: reserved},
+		{Name: "root_cell/0", Cluster: "cluster-a", IsDiegoCell: true, MemoryMB: 32768, CellMemoryGB: 32, CellCPU: 4},
+	}
+
+	state := buildInfrastructureState("DC0", clusters, cells)
+
+	cs := state.Clusters[0]
+	// Each pool counts once however many cells it holds
+	if cs.ResourcePoolReservationGB != 96 {
+		t.Errorf("ResourcePoolReservationGB = %d, want 96", cs.ResourcePoolReservationGB)
+	}
+	if cs.ResourcePoolLimitGB != 128 {
+		t.Errorf("ResourcePoolLimitGB = %d, want 128", cs.ResourcePoolLimitGB)
+	}
+	if cs.CellsInLimitedPools != 2 {
+		t.Errorf("CellsInLimitedPools = %d, want 2", cs.CellsInLimitedPools)
+	}
+}
+
+// countingRoundTripper counts SOAP calls made against vCenter
+type countingRoundTripper struct {
+	soap.RoundTripper
+	calls atomic.Int64
+}
+
+func (c *countingRoundTripper) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
+	c.calls.Add(1)
+	return c.RoundTripper.RoundTrip(ctx, req, res)
+}
+
+// newSimulatedVSphereClient connects a VSphereClient to an in-process vcsim
+// inventory with the given number of VMs per host and per cluster
+func newSimulatedVSphereClient(tb testing.TB, machines int) (*VSphereClient, *countingRoundTripper) {
+	tb.Helper()
+	return newSimulatedVSphereClientWith(tb, machines, nil)
+}
+
+// newSimulatedVSphereClientWith is newSimulatedVSphereClient with a hook to
+// adjust the created inventory before the client connects
+func newSimulatedVSphereClientWith(tb testing.TB, machines int, setup func(*simulator.Model)) (*VSphereClient, *countingRoundTripper) {
+	tb.Helper()
+
+	model := simulator.VPX()
+	model.Machine = machines
+	return newSimulatedVSphereClientFor(tb, model, setup)
+}
+
+// newSimulatedVSphereClientFor connects a VSphereClient to the inventory
+// described by model, for tests that need more than the default VPX layout
+func newSimulatedVSphereClientFor(tb testing.TB, model *simulator.Model, setup func(*simulator.Model)) (*VSphereClient, *countingRoundTripper) {
+	tb.Helper()
+
+	if err := model.Create(); err != nil {
+		tb.Fatalf("creating simulator model: %v", err)
+	}
+	if setup != nil {
+		setup(model)
+	}
+	server := model.Service.NewServer()
+	tb.Cleanup(func() {
+		server.Close()
+		model.Remove()
+	})
+
+	password, _ := server.URL.User.Password()
+	client := NewVSphereClient(VSphereCredentials{
+		Host:       server.URL.Scheme + "://" + server.URL.Host,
+		Username:   server.URL.User.Username(),
+		Password:   password,
+		Datacenter: "DC0",
+		Insecure:   true,
+	})
+	if err := client.Connect(context.Background()); err != nil {
+		tb.Fatalf("connecting to simulator: %v", err)
+	}
+
+	counter := &countingRoundTripper{RoundTripper: client.client.Client.RoundTripper}
+	client.client.Client.RoundTripper = counter
+	return client, counter
+}
+
+// perVMInfos looks up each VM individually, one property fetch per VM plus
+// host and cluster lookups, for comparison with the batched retrieval
+func perVMInfos(ctx context.Context, v *VSphereClient, vms []*object.VirtualMachine) ([]VMInfo, error) {
+	result := make([]VMInfo, 0, len(vms))
+	for _, vm := range vms {
+		var vmMo mo.VirtualMachine
+		if err := vm.Properties(ctx, vm.Reference(), []string{"config", "runtime", "customValue", "availableField"}, &vmMo); err != nil {
+			return nil, err
+		}
+		vmMo.Name = vm.Name()
+		info := v.buildVMInfo(vmMo)
+
+		if vmMo.Runtime.Host != nil {
+			host := object.NewHostSystem(v.client.Client, *vmMo.Runtime.Host)
+			info.Host, _ = host.ObjectName(ctx)
+
+			var hostMo mo.HostSystem
+			if err := host.Properties(ctx, host.Reference(), []string{"parent"}, &hostMo); err == nil {
+				if hostMo.Parent != nil && hostMo.Parent.Type == "ClusterComputeResource" {
+					cluster := object.NewClusterComputeResource(v.client.Client, *hostMo.Parent)
+					info.Cluster, _ = cluster.ObjectName(ctx)
+				}
+			}
+		}
+		result = append(result, info)
+	}
+	return result, nil
+}
+
+func TestGetVMInfos_MatchesPerVMLookup(t *testing.T) {
+	client, counter := newSimulatedVSphereClient(t, 3)
+	if err := client.SetCellDetection([]string{`_VM[02]$`}, ""); err != nil {
+		t.Fatalf("SetCellDetection failed: %v", err)
+	}
+	ctx := context.Background()
+
+	vms, err := client.finder.VirtualMachineList(ctx, "*")
+	if err != nil {
+		t.Fatalf("listing VMs: %v", err)
+	}
+
+	want, err := perVMInfos(ctx, client, vms)
+	if err != nil {
+		t.Fatalf("per-VM lookup failed: %v", err)
+	}
+
+	counter.calls.Store(0)
+	got, err := client.getVMInfos(ctx, vms)
+	if err != nil {
+		t.Fatalf("getVMInfos failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("batched VMInfo differs from per-VM lookup\ngot:  %+v\nwant: %+v", got, want)
+	}
+	if calls := counter.calls.Load(); calls > 3 {
+		t.Errorf("Expected at most 3 API calls for %d VMs, got %d", len(vms), calls)
+	}
+
+	var clustered, cells int
+	for _, vm := range got {
+		if vm.Cluster != "" {
+			clustered++
+		}
+		if vm.IsDiegoCell {
+			cells++
+		}
+	}
+	if clustered == 0 || clustered == len(got) {
+		t.Errorf("Expected a mix of clustered and standalone VMs, got %d of %d clustered", clustered, len(got))
+	}
+	if cells == 0 {
+		t.Error("Expected some VMs to be detected as Diego cells")
+	}
+}
+
+func TestGetClusters_AssignsCellsFromSingleFetch(t *testing.T) {
+	client, _ := newSimulatedVSphereClient(t, 2)
+	if err := client.SetCellDetection([]string{`_VM\d+$`}, ""); err != nil {
+		t.Fatalf("SetCellDetection failed: %v", err)
+	}
+
+	clusters, err := client.GetClusters(context.Background())
+	if err != nil {
+		t.Fatalf("GetClusters failed: %v", err)
+	}
+	if len(clusters) != 1 {
+		t.Fatalf("Expected 1 cluster, got %d", len(clusters))
+	}
+	if clusters[0].DiegoCellCount != 2 {
+		t.Errorf("Expected 2 Diego cells in %s, got %d", clusters[0].Name, clusters[0].DiegoCellCount)
+	}
+	for _, cell := range clusters[0].DiegoCells {
+		if cell.Cluster != clusters[0].Name || cell.Host == "" {
+			t.Errorf("Unexpected cell placement: %+v", cell)
+		}
+	}
+}
+
+func TestHAAdmissionControlPercent(t *testing.T) {
+	on, off := types.NewBool(true), types.NewBool(false)
+	percentage := &types.ClusterFailoverResourcesAdmissionControlPolicy{
+		CpuFailoverResourcesPercent:    30,
+		MemoryFailoverResourcesPercent: 25,
+	}
+
+	tests := []struct {
+		name string
+		das  types.ClusterDasConfigInfo
+		want int
+	}{
+		{"percentage policy", types.ClusterDasConfigInfo{Enabled: on, AdmissionControlEnabled: on, AdmissionControlPolicy: percentage}, 25},
+		{"admission control unset defaults on", types.ClusterDasConfigInfo{Enabled: on, AdmissionControlPolicy: percentage}, 25},
+		{"HA disabled", types.ClusterDasConfigInfo{Enabled: off, AdmissionControlEnabled: on, AdmissionControlPolicy: percentage}, 0},
+		{"HA unset", types.ClusterDasConfigInfo{AdmissionControlPolicy: percentage}, 0},
+		{"admission control disabled", types.ClusterDasConfigInfo{Enabled: on, AdmissionControlEnabled: off, AdmissionControlPolicy: percentage}, 0},
+		{"slot policy", types.ClusterDasConfigInfo{Enabled: on, AdmissionControlPolicy: &types.ClusterFailoverLevelAdmissionControlPolicy{FailoverLevel: 1}}, 0},
+		{"no policy", types.ClusterDasConfigInfo{Enabled: on}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := haAdmissionControlPercent(tt.das); got != tt.want {
+				t.Errorf("haAdmissionControlPercent() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetClusters_ReadsHAAdmissionControl(t *testing.T) {
+	// vcsim's ReconfigureComputeResource ignores admission control policies,
+	// so set the policy on the simulated cluster directly
+	client, _ := newSimulatedVSphereClientWith(t, 1, func(model *simulator.Model) {
+		cluster := model.Map().Any("ClusterComputeResource").(*simulator.ClusterComputeResource)
+		cfg := cluster.ConfigurationEx.(*types.ClusterConfigInfoEx)
+		cfg.DasConfig.Enabled = types.NewBool(true)
+		cfg.DasConfig.AdmissionControlEnabled = types.NewBool(true)
+		cfg.DasConfig.AdmissionControlPolicy = &types.ClusterFailoverResourcesAdmissionControlPolicy{
+			CpuFailoverResourcesPercent:    33,
+			MemoryFailoverResourcesPercent: 33,
+		}
+	})
+
+	clusters, err := client.GetClusters(context.Background())
+	if err != nil {
+		t.Fatalf("GetClusters failed: %v", err)
+	}
+	if len(clusters) != 1 {
+		t.Fatalf("Expected 1 cluster, got %d", len(clusters))
+	}
+	if clusters[0].HAAdmissionControlPercentage != 33 {
+		t.Errorf("HAAdmissionControlPercentage = %d, want 33", clusters[0].HAAdmissionControlPercentage)
+	}
+}
+
+func TestGetClusters_CountsSharedDatastores(t *testing.T) {
+	const gb = 1024 * 1024 * 1024
+
+	tests := []struct {
+		name       string
+		accessible bool
+		shared     bool
+		wantCount  int
+	}{
+		{"shared and accessible", true, true, 1},
+		{"local", true, false, 0},
+		{"inaccessible", false, true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := newSimulatedVSphereClientWith(t, 1, func(model *simulator.Model) {
+				for _, obj := range model.Map().All("Datastore") {
+					ds := obj.(*simulator.Datastore)
+					ds.Summary.Capacity = 1000 * gb
+					ds.Summary.FreeSpace = 400 * gb
+					ds.Summary.Accessible = tt.accessible
+					ds.Summary.MultipleHostAccess = types.NewBool(tt.shared)
+				}
+			})
+
+			clusters, err := client.GetClusters(context.Background())
+			if err != nil {
+				t.Fatalf("GetClusters failed: %v", err)
+			}
+			if len(clusters) != 1 {
+				t.Fatalf("Expected 1 cluster, got %d", len(clusters))
+			}
+			if got := len(clusters[0].Datastores); got != tt.wantCount {
+				t.Fatalf("Expected %d counted datastores, got %d", tt.wantCount, got)
+			}
+			for _, ds := range clusters[0].Datastores {
+				if ds.CapacityGB != 1000 || ds.FreeGB != 400 {
+					t.Errorf("Datastore %s = %d/%d GB, want 1000/400", ds.Name, ds.CapacityGB, ds.FreeGB)
+				}
+			}
+		})
+	}
+}
+
+func TestGetAllDiegoCells_ReadsResourcePools(t *testing.T) {
+	model := simulator.VPX()
+	model.Machine = 1
+	model.Pool = 1
+
+	client, _ := newSimulatedVSphereClientFor(t, model, func(model *simulator.Model) {
+		for _, obj := range model.Map().All("ResourcePool") {
+			pool, ok := obj.(*simulator.ResourcePool)
+			if !ok || pool.Name != "DC0_C0_RP1" {
+				continue
+			}
+			pool.Config.MemoryAllocation.Reservation = types.NewInt64(8192)
+			pool.Config.MemoryAllocation.Limit = types.NewInt64(16384)
+		}
+	})
+	if err := client.SetCellDetection([]string{`^DC0_C0_RP1_VM\d+$`}, ""); err != nil {
+		t.Fatalf("SetCellDetection failed: %v", err)
+	}
+
+	cells, err := client.getAllDiegoCells(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("getAllDiegoCells failed: %v", err)
+	}
+	if len(cells) == 0 {
+		t.Fatal("Expected cells in the child resource pool")
+	}
+	for _, cell := range cells {
+		pool := cell.ResourcePool
+		if pool == nil {
+			t.Fatalf("Cell %s has no resource pool", cell.Name)
+		}
+		if pool.Name != "DC0_C0_RP1" || pool.MemoryReservationMB != 8192 || pool.MemoryLimitMB != 16384 {
+			t.Errorf("Cell %s pool = %+v, want DC0_C0_RP1 with 8192 MB reserved and a 16384 MB limit", cell.Name, *pool)
+		}
+	}
+}
+
+func BenchmarkVMInfoRetrieval(b *testing.B) {
+	client, counter := newSimulatedVSphereClient(b, 10)
+	ctx := context.Background()
+
+	vms, err := client.finder.VirtualMachineList(ctx, "*")
+	if err != nil {
+		b.Fatalf("listing VMs: %v", err)
+	}
+
+	benchmarks := []struct {
+		name  string
+		fetch func() ([]VMInfo, error)
+	}{
+		{"per_vm", func() ([]VMInfo, error) { return perVMInfos(ctx, client, vms) }},
+		{"batched", func() ([]VMInfo, error) { return client.getVMInfos(ctx, vms) }},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			counter.calls.Store(0)
+			for i := 0; i < b.N; i++ {
+				if _, err := bm.fetch(); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(counter.calls.Load())/float64(b.N), "calls/op")
+			b.ReportMetric(float64(len(vms)), "vms")
+		})
+	}
+}
+
+func TestVSphereClient_Ping(t *testing.T) {
+	connected, counter := newSimulatedVSphereClient(t, 1)
+
+	before := counter.calls.Load()
+	if err := connected.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping on connected client: %v", err)
+	}
+	if calls := counter.calls.Load() - before; calls != 0 {
+		t.Errorf("Expected a valid session to be reused without round trips, got %d", calls)
+	}
+
+	if err := connected.Disconnect(context.Background()); err != nil {
+		t.Fatalf("Disconnect: %v", err)
+	}
+	if connected.IsConnected() {
+		t.Error("Expected client to report disconnected after logout")
+	}
+
+	disconnected := NewVSphereClient(connected.creds)
+	if err := disconnected.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping on disconnected client: %v", err)
+	}
+	if disconnected.IsConnected() {
+		t.Error("Expected Ping to leave the client's own connection untouched")
+	}
+
+	badCreds := connected.creds
+	badCreds.Datacenter = "missing-dc"
+	if err := NewVSphereClient(badCreds).Ping(context.Background()); err == nil {
+		t.Error("Expected error for unknown datacenter")
+	}
+}
+
+func TestVSphereClient_PingCachesResult(t *testing.T) {
+	connected, _ := newSimulatedVSphereClient(t, 1)
+
+	creds := connected.creds
+	creds.Datacenter = "missing-dc"
+	client := NewVSphereClient(creds)
+	if err := client.Ping(context.Background()); err == nil {
+		t.Fatal("Expected error for unknown datacenter")
+	}
+
+	// Fixing the datacenter does not show until the cached failure expires
+	client.creds.Datacenter = "DC0"
+	if err := client.Ping(context.Background()); err == nil {
+		t.Error("Expected the cached failure within the TTL")
+	}
+
+	client.pingAt = time.Now().Add(-vspherePingTTL)
+	if err := client.Ping(context.Background()); err != nil {
+		t.Errorf("Expected a fresh probe after the TTL, got %v", err)
+	}
+}
+
+func TestVSphereClient_SharedSession(t *testing.T) {
+	client, _ := newSimulatedVSphereClient(t, 2)
+	ctx := context.Background()
+
+	// The helper holds the first reference; concurrent discoveries share it
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := client.Connect(ctx); err != nil {
+				t.Errorf("Connect: %v", err)
+				return
+			}
+			defer client.Disconnect(ctx)
+			if _, err := client.GetClusters(ctx); err != nil {
+				t.Errorf("GetClusters: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if !client.IsConnected() {
+		t.Fatal("Expected the session to stay open while a reference is held")
+	}
+	if err := client.Disconnect(ctx); err != nil {
+		t.Fatalf("Disconnect: %v", err)
+	}
+	if client.IsConnected() {
+		t.Error("Expected the last Disconnect to log out")
+	}
+}
+
+func TestGetInfrastructureState_NoDiegoCells(t *testing.T) {
+	client, _ := newSimulatedVSphereClient(t, 2)
+	if err := client.SetCellDetection([]string{`^no_such_cell$`}, ""); err != nil {
+		t.Fatalf("SetCellDetection failed: %v", err)
+	}
+
+	_, err := client.GetInfrastructureState(context.Background(), nil)
+	if !errors.Is(err, ErrNoDiegoCells) {
+		t.Fatalf("Expected ErrNoDiegoCells, got %v", err)
+	}
+	if !strings.Contains(err.Error(), `datacenter "DC0"`) {
+		t.Errorf("Expected the datacenter in the error, got %q", err.Error())
+	}
+}
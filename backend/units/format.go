@@ -0,0 +1,46 @@
+// ABOUTME: Human-readable rendering of GiB sizes for API responses
+// ABOUTME: Produces "512 GB" or "10.5 TB" strings with comma thousands separators
+
+package units
+
+import (
+	"strconv"
+	"strings"
+)
+
+// FormatGB renders a GiB size for people: below 1024 GB as whole GB, from
+// there as TB with one decimal place, dropping a trailing ".0". Labels follow
+// the model fields, so "TB" is a TiB. Examples: 512 GB, 10.5 TB, 1,024 TB.
+func FormatGB[T Integer](gb T) string {
+	if gb >= KiB || gb <= -KiB {
+		return groupThousands(strconv.FormatFloat(float64(gb)/KiB, 'f', 1, 64)) + " TB"
+	}
+	return groupThousands(strconv.FormatInt(int64(gb), 10)) + " GB"
+}
+
+// groupThousands inserts commas into the integer part of a decimal string
+// and drops a zero fractional part
+func groupThousands(s string) string {
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	whole, frac, _ := strings.Cut(s, ".")
+	if strings.Trim(frac, "0") == "" {
+		frac = ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString(sign)
+	for i, r := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteRune(r)
+	}
+	if frac != "" {
+		sb.WriteByte('.')
+		sb.WriteString(frac)
+	}
+	return sb.String()
+}
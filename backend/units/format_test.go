@@ -0,0 +1,26 @@
+// ABOUTME: Tests for human-readable GiB size rendering
+// ABOUTME: Pins the GB to TB switch, decimal trimming, and thousands separators
+
+package units
+
+import "testing"
+
+func TestFormatGB(t *testing.T) {
+	tests := []struct {
+		gb   int
+		want string
+	}{
+		{0, "0 GB"},
+		{512, "512 GB"},
+		{1023, "1,023 GB"},
+		{1024, "1 TB"},
+		{10752, "10.5 TB"},
+		{1 << 20, "1,024 TB"},
+		{-1536, "-1.5 TB"},
+	}
+	for _, tt := range tests {
+		if got := FormatGB(tt.gb); got != tt.want {
+			t.Errorf("FormatGB(%d) = %q, want %q", tt.gb, got, tt.want)
+		}
+	}
+}
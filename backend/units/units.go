@@ -0,0 +1,64 @@
+// ABOUTME: Memory and disk unit conversions shared by the vSphere, BOSH, and CF collectors
+// ABOUTME: All units are binary: the "GB" and "MB" in field names mean GiB and MiB
+
+// Package units converts between the byte, KB, MB, and GB values reported by
+// vSphere, BOSH, and the CF APIs. The analyzer uses binary units throughout:
+// a KB is 1024 bytes, an MB is 1024 KB, and a GB is 1024 MB, so "GB" in a
+// model field is a GiB. Conversions truncate unless the name says Rounded.
+package units
+
+// Binary unit multipliers
+const (
+	KiB = 1024
+	MiB = 1024 * KiB
+	GiB = 1024 * MiB
+)
+
+// Integer is the set of integer types the conversions accept, so callers keep
+// the width their source API reports (vSphere int64, BOSH and CF int)
+type Integer interface {
+	~int | ~int32 | ~int64
+}
+
+// BytesToKiB converts bytes to whole KiB, truncating
+func BytesToKiB[T Integer](bytes T) T {
+	return bytes / KiB
+}
+
+// BytesToMiB converts bytes to whole MiB, truncating
+func BytesToMiB[T Integer](bytes T) T {
+	return bytes / MiB
+}
+
+// BytesToGiB converts bytes to whole GiB, truncating
+func BytesToGiB[T Integer](bytes T) T {
+	return bytes / GiB
+}
+
+// KBToMiB converts KiB to whole MiB, truncating
+func KBToMiB[T Integer](kb T) T {
+	return kb / KiB
+}
+
+// KBToGiB converts KiB to whole GiB, truncating
+func KBToGiB[T Integer](kb T) T {
+	return kb / MiB
+}
+
+// MBToGiB converts MiB to whole GiB, truncating. Use it for capacity, where
+// reporting a partial GiB as available would overstate what fits.
+func MBToGiB[T Integer](mb T) T {
+	return mb / KiB
+}
+
+// MBToGiBRounded converts MiB to GiB, rounding to the nearest GiB. Use it for
+// summed demand, such as total app memory, where truncation would drop up to
+// a GiB per sum.
+func MBToGiBRounded[T Integer](mb T) T {
+	return (mb + KiB/2) / KiB
+}
+
+// GBToMiB converts GiB to MiB
+func GBToMiB[T Integer](gb T) T {
+	return gb * KiB
+}
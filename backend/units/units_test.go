@@ -0,0 +1,95 @@
+// ABOUTME: Tests for binary unit conversions
+// ABOUTME: Pins truncation and rounding so collectors agree on GiB boundaries
+
+package units
+
+import "testing"
+
+func TestBytesConversions(t *testing.T) {
+	tests := []struct {
+		name    string
+		bytes   int64
+		wantKiB int64
+		wantMiB int64
+		wantGiB int64
+	}{
+		{"zero", 0, 0, 0, 0},
+		{"exactly 1 GiB", 1 << 30, 1 << 20, 1024, 1},
+		{"1 TiB host", 1 << 40, 1 << 30, 1 << 20, 1024},
+		{"one byte short of 2 GiB truncates", 2<<30 - 1, 2<<20 - 1, 2047, 1},
+		// 500 decimal GB is 465.66 GiB; the analyzer reports binary units
+		{"decimal 500 GB datastore", 500_000_000_000, 488281250, 476837, 465},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BytesToKiB(tt.bytes); got != tt.wantKiB {
+				t.Errorf("BytesToKiB(%d) = %d, want %d", tt.bytes, got, tt.wantKiB)
+			}
+			if got := BytesToMiB(tt.bytes); got != tt.wantMiB {
+				t.Errorf("BytesToMiB(%d) = %d, want %d", tt.bytes, got, tt.wantMiB)
+			}
+			if got := BytesToGiB(tt.bytes); got != tt.wantGiB {
+				t.Errorf("BytesToGiB(%d) = %d, want %d", tt.bytes, got, tt.wantGiB)
+			}
+		})
+	}
+}
+
+func TestKBConversions(t *testing.T) {
+	tests := []struct {
+		name    string
+		kb      int
+		wantMiB int
+		wantGiB int
+	}{
+		{"zero", 0, 0, 0},
+		{"32 GiB VM", 32 * 1024 * 1024, 32 * 1024, 32},
+		// BOSH vitals report what the guest sees, a little under the VM size
+		{"guest-visible 32 GiB VM", 32_899_184, 32128, 31},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := KBToMiB(tt.kb); got != tt.wantMiB {
+				t.Errorf("KBToMiB(%d) = %d, want %d", tt.kb, got, tt.wantMiB)
+			}
+			if got := KBToGiB(tt.kb); got != tt.wantGiB {
+				t.Errorf("KBToGiB(%d) = %d, want %d", tt.kb, got, tt.wantGiB)
+			}
+		})
+	}
+}
+
+func TestMBToGiB(t *testing.T) {
+	tests := []struct {
+		mb          int
+		wantTrunc   int
+		wantRounded int
+	}{
+		{0, 0, 0},
+		{511, 0, 0},
+		{512, 0, 1},
+		{1024, 1, 1},
+		{1535, 1, 1},
+		{1536, 1, 2},
+		{32768, 32, 32},
+	}
+
+	for _, tt := range tests {
+		if got := MBToGiB(tt.mb); got != tt.wantTrunc {
+			t.Errorf("MBToGiB(%d) = %d, want %d", tt.mb, got, tt.wantTrunc)
+		}
+		if got := MBToGiBRounded(tt.mb); got != tt.wantRounded {
+			t.Errorf("MBToGiBRounded(%d) = %d, want %d", tt.mb, got, tt.wantRounded)
+		}
+	}
+}
+
+func TestGBToMiB_RoundTrips(t *testing.T) {
+	for _, gb := range []int64{0, 1, 32, 1024} {
+		if got := MBToGiB(GBToMiB(gb)); got != gb {
+			t.Errorf("MBToGiB(GBToMiB(%d)) = %d", gb, got)
+		}
+	}
+}
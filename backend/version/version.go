@@ -0,0 +1,13 @@
+// ABOUTME: Build metadata for the backend binary
+// ABOUTME: Values are injected at build time with -ldflags -X
+
+package version
+
+// Build metadata, overridden at build time, e.g.
+//
+//	go build -ldflags "-X github.com/markalston/diego-capacity-analyzer/backend/version.Version=v1.2.0"
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildDate = "unknown"
+)
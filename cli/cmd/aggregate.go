@@ -0,0 +1,164 @@
+// ABOUTME: Aggregate command for diego-capacity CLI
+// ABOUTME: Combines infrastructure state from several foundation backends into one capacity view
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/aggregate"
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/client"
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/format"
+	"github.com/spf13/cobra"
+)
+
+var aggregateBackends string
+
+var aggregateCmd = &cobra.Command{
+	Use:   "aggregate",
+	Short: "Combine capacity across several foundations",
+	Long: `Fetch infrastructure state from several backends, one per foundation, and
+show the combined capacity with a per-foundation breakdown and a bottleneck
+analysis of the whole platform.
+
+Each backend is queried with GET /api/v1/infrastructure and
+GET /api/v1/bottleneck, so it must have vSphere configured. The combined
+state is analyzed by the first backend that responded, with
+POST /api/v1/bottleneck and its GET /api/v1/thresholds, so the combined
+bottleneck and CPU risk follow that backend's threshold settings. The
+--token, --ca-cert, and --retries settings apply to every backend. A backend
+that cannot be reached is reported and left out of the combined view.
+
+Exit codes:
+  0 - All backends aggregated
+  1 - Some backends failed, or the combined analysis failed; the rest is shown
+  2 - Error (no backend reachable, invalid flags)
+
+Example:
+  diego-capacity aggregate --backends https://east.example.com,https://west.example.com`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+
+		exitCode := runAggregate(ctx, os.Stdout, os.Stderr, aggregateBackends, func(url string) (*client.Client, error) {
+			return newClientForURL(url)
+		})
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(aggregateCmd)
+	aggregateCmd.Flags().StringVar(&aggregateBackends, "backends", "", "Comma-separated backend URLs, one per foundation (required)")
+}
+
+// runAggregate fetches every backend, writes the combined view, and returns exit code
+func runAggregate(ctx context.Context, stdout, stderr io.Writer, backends string, newClient func(url string) (*client.Client, error)) int {
+	urls := parseBackendList(backends)
+	if len(urls) == 0 {
+		fmt.Fprintln(stderr, "Error: --backends is required (comma-separated backend URLs)")
+		return 2
+	}
+
+	result := aggregate.Combine(aggregate.Fetch(ctx, urls, newClient))
+	for _, f := range result.Foundations {
+		if f.Error != "" {
+			fmt.Fprintf(stderr, "Warning: %s: %s\n", f.URL, f.Error)
+		}
+	}
+	if result.Failed == len(urls) {
+		fmt.Fprintln(stderr, "Error: no backend returned infrastructure state")
+		return 2
+	}
+	analyzeErr := aggregate.Analyze(ctx, &result, newClient)
+	if analyzeErr != nil {
+		fmt.Fprintf(stderr, "Warning: combined bottleneck analysis failed: %v\n", analyzeErr)
+	}
+
+	if IsJSONOutput() {
+		data, _ := json.MarshalIndent(result, "", "  ")
+		fmt.Fprintln(stdout, string(data))
+	} else {
+		fmt.Fprintln(stdout, formatAggregateHuman(result))
+	}
+
+	if result.Failed > 0 || analyzeErr != nil {
+		return 1
+	}
+	return 0
+}
+
+// parseBackendList splits a comma-separated URL list, dropping blanks and
+// trailing slashes
+func parseBackendList(backends string) []string {
+	var urls []string
+	for _, u := range strings.Split(backends, ",") {
+		u = strings.TrimRight(strings.TrimSpace(u), "/")
+		if u != "" {
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
+
+// formatAggregateHuman formats the combined view for human readability
+func formatAggregateHuman(result aggregate.Result) string {
+	var sb strings.Builder
+	combined := result.Combined
+
+	fmt.Fprintf(&sb, "Foundations:    %d of %d\n", len(result.Foundations)-result.Failed, len(result.Foundations))
+	for _, f := range result.Foundations {
+		if f.Error != "" {
+			fmt.Fprintf(&sb, "  %-24s unavailable\n", f.URL)
+			continue
+		}
+		name := f.Name
+		if name == "" {
+			name = f.URL
+		}
+		fmt.Fprintf(&sb, "  %-24s %3d hosts %5d cells %9s cells  %s %.0f%%\n",
+			name, f.Hosts, f.Cells, format.GBInt(f.CellMemoryGB), f.ConstrainingResource, f.ConstrainingPercent)
+	}
+
+	fmt.Fprintf(&sb, `
+Combined
+Clusters:       %d
+Hosts:          %d
+Diego Cells:    %d
+Cell Memory:    %s
+App Memory:     %s
+App Instances:  %s
+`,
+		len(combined.Clusters),
+		combined.TotalHostCount,
+		combined.TotalCellCount,
+		format.GBInt(combined.TotalCellMemoryGB),
+		format.GBInt(combined.TotalAppMemoryGB),
+		format.Int(combined.TotalAppInstances))
+
+	if len(result.Bottleneck.Resources) > 0 {
+		sb.WriteString("\nBottleneck\n")
+		for _, r := range result.Bottleneck.Resources {
+			marker := " "
+			if r.IsConstraining {
+				marker = "*"
+			}
+			fmt.Fprintf(&sb, "%s %-12s %5.1f%%  (%d / %d %s)\n", marker, r.Name, r.UsedPercent, r.UsedCapacity, r.TotalCapacity, r.Unit)
+		}
+		sb.WriteString(result.Bottleneck.Summary)
+		if result.AnalyzedBy != "" {
+			fmt.Fprintf(&sb, "\n(analyzed by %s with its thresholds)", result.AnalyzedBy)
+		}
+	}
+
+	return strings.TrimRight(sb.String(), "\n")
+}
@@ -0,0 +1,146 @@
+// ABOUTME: Tests for the aggregate command
+// ABOUTME: Verifies combined output, partial-failure reporting, and exit codes
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/aggregate"
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/client"
+)
+
+// foundationServer serves state and a memory-only bottleneck analysis of it,
+// for both the stored state and a submitted one
+func foundationServer(t *testing.T, state client.InfrastructureState) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/infrastructure":
+			json.NewEncoder(w).Encode(state)
+		case "/api/v1/thresholds":
+			json.NewEncoder(w).Encode(client.DefaultThresholds())
+		case "/api/v1/bottleneck":
+			used, total := state.TotalAppMemoryGB, state.TotalCellMemoryGB
+			if r.Method == http.MethodPost {
+				var input client.ManualInput
+				json.NewDecoder(r.Body).Decode(&input)
+				used, total = input.TotalAppMemoryGB, 0
+				for _, c := range input.Clusters {
+					total += c.DiegoCellCount * c.DiegoCellMemoryGB
+				}
+			}
+			pct := float64(used) / float64(total) * 100
+			json.NewEncoder(w).Encode(client.BottleneckAnalysis{
+				Resources:            []client.ResourceUtilization{{Name: "Memory", UsedPercent: pct, UsedCapacity: used, TotalCapacity: total, Unit: "GB", IsConstraining: true}},
+				ConstrainingResource: "Memory",
+				Summary:              fmt.Sprintf("Memory is your constraint at %.1f%% utilization.", pct),
+			})
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func testClientFactory(url string) (*client.Client, error) {
+	return client.New(url, client.WithRetries(0)), nil
+}
+
+func TestAggregateCommand_CombinesBackends(t *testing.T) {
+	east := foundationServer(t, client.InfrastructureState{
+		Name: "east", TotalHostCount: 4, TotalCellCount: 10, TotalCellMemoryGB: 1000, TotalAppMemoryGB: 500,
+		Clusters: []client.ClusterState{{Name: "c1", HostCount: 4, DiegoCellCount: 10, DiegoCellMemoryGB: 100}},
+	})
+	west := foundationServer(t, client.InfrastructureState{
+		Name: "west", TotalHostCount: 6, TotalCellCount: 20, TotalCellMemoryGB: 1000, TotalAppMemoryGB: 300,
+		Clusters: []client.ClusterState{{Name: "c1", HostCount: 6, DiegoCellCount: 20, DiegoCellMemoryGB: 50}},
+	})
+
+	var stdout, stderr bytes.Buffer
+	code := runAggregate(context.Background(), &stdout, &stderr, east.URL+", "+west.URL+"/", testClientFactory)
+
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d (stderr %q)", code, stderr.String())
+	}
+	out := stdout.String()
+	for _, want := range []string{"Foundations:    2 of 2", "east", "west", "Hosts:          10", "Diego Cells:    30", "Memory is your constraint at 40.0%", "analyzed by " + east.URL} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestAggregateCommand_PartialFailure(t *testing.T) {
+	east := foundationServer(t, client.InfrastructureState{
+		Name: "east", TotalHostCount: 4, TotalCellMemoryGB: 1000, TotalAppMemoryGB: 500,
+		Clusters: []client.ClusterState{{Name: "c1", HostCount: 4, DiegoCellCount: 10, DiegoCellMemoryGB: 100}},
+	})
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	downURL := down.URL
+	down.Close()
+
+	jsonOutput = true
+	defer func() { jsonOutput = false }()
+
+	var stdout, stderr bytes.Buffer
+	code := runAggregate(context.Background(), &stdout, &stderr, east.URL+","+downURL, testClientFactory)
+
+	if code != 1 {
+		t.Errorf("expected exit code 1 for partial failure, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "Warning: "+downURL) {
+		t.Errorf("expected a warning for the unreachable backend, got %q", stderr.String())
+	}
+
+	var result aggregate.Result
+	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		t.Fatalf("expected JSON output: %v\n%s", err, stdout.String())
+	}
+	if result.Failed != 1 || result.Combined.TotalHostCount != 4 {
+		t.Errorf("expected east aggregated and one failure, got %+v", result)
+	}
+	if result.Foundations[1].Error == "" {
+		t.Error("expected the unreachable backend's error in the breakdown")
+	}
+	if result.AnalyzedBy != east.URL || result.Bottleneck.ConstrainingResource != "Memory" {
+		t.Errorf("expected east to analyze the combined state, got %q / %+v", result.AnalyzedBy, result.Bottleneck)
+	}
+}
+
+func TestAggregateCommand_Errors(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	downURL := down.URL
+	down.Close()
+
+	tests := []struct {
+		name     string
+		backends string
+		wantErr  string
+	}{
+		{"no backends", " , ", "--backends is required"},
+		{"all backends down", downURL, "no backend returned infrastructure state"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := runAggregate(context.Background(), &stdout, &stderr, tt.backends, testClientFactory)
+			if code != 2 {
+				t.Errorf("expected exit code 2, got %d", code)
+			}
+			if !strings.Contains(stderr.String(), tt.wantErr) {
+				t.Errorf("expected stderr to contain %q, got %q", tt.wantErr, stderr.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+// ABOUTME: Analyze command for diego-capacity CLI
+// ABOUTME: Loads an infrastructure file and prints state plus bottleneck analysis as JSON or CSV
+
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/client"
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/report"
+	"github.com/spf13/cobra"
+)
+
+var (
+	analyzeInput     string
+	analyzeFormat    string
+	analyzeThreshold float64
+	analyzeRedact    bool
+)
+
+var analyzeCmd = &cobra.Command{
+	Use:   "analyze",
+	Short: "Analyze an infrastructure file",
+	Long: `Load infrastructure from a JSON file, compute its state and bottleneck
+analysis via the backend, and print the result in a machine-readable format.
+The input is not stored, so the backend's current infrastructure is unchanged.
+
+The input file uses the manual input format (clusters with memory_gb_per_host).
+CSV output has one row per cluster. With --redact, foundation and cluster
+names are replaced with pseudonyms (foundation-1, cluster-1, ...) so the
+output can be shared without naming the environment.
+
+Exit codes:
+  0 - Constraining resource within threshold
+  1 - Constraining resource exceeds threshold
+  2 - Error (connectivity, invalid input)
+
+Example:
+  diego-capacity analyze --input infra.json --format csv --threshold 85
+  diego-capacity analyze --input infra.json --redact`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+
+		c, err := NewClient()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+		exitCode := runAnalyze(ctx, c, os.Stdout, os.Stderr, analyzeInput, analyzeFormat, analyzeThreshold, analyzeRedact)
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(analyzeCmd)
+	analyzeCmd.Flags().StringVar(&analyzeInput, "input", "", "Path to infrastructure JSON file (required)")
+	analyzeCmd.Flags().StringVar(&analyzeFormat, "format", "json", "Output format: json or csv")
+	analyzeCmd.Flags().Float64Var(&analyzeThreshold, "threshold", 90, "Maximum utilization percentage for the constraining resource")
+	analyzeCmd.Flags().BoolVar(&analyzeRedact, "redact", false, "Replace foundation and cluster names with pseudonyms (cluster-1, ...)")
+}
+
+// analyzeResult is the JSON output shape for the analyze command
+type analyzeResult struct {
+	Infrastructure *client.InfrastructureState `json:"infrastructure"`
+	Bottleneck     *client.BottleneckAnalysis  `json:"bottleneck"`
+	Threshold      float64                     `json:"threshold"`
+	Passed         bool                        `json:"passed"`
+}
+
+// runAnalyze loads the input file, queries the backend, writes the result, and returns exit code.
+// With redact, names are replaced in the output only; the backend is sent the real input.
+func runAnalyze(ctx context.Context, c *client.Client, stdout, stderr io.Writer, inputPath, format string, threshold float64, redact bool) int {
+	if format != "json" && format != "csv" {
+		fmt.Fprintf(stderr, "Error: --format must be json or csv, got %q\n", format)
+		return 2
+	}
+	if threshold < 0 || threshold > 100 {
+		fmt.Fprintln(stderr, "Error: --threshold must be between 0 and 100")
+		return 2
+	}
+
+	input, err := loadManualInput(inputPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	// Both endpoints are stateless, so analyzing a file never replaces the
+	// infrastructure the backend is serving to other clients
+	check, err := c.CheckThresholds(ctx, input, client.WarningThresholds{})
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 2
+	}
+	infra := &check.Infrastructure
+
+	analysis, err := c.AnalyzeBottleneck(ctx, input)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	if redact {
+		redactor := report.NewRedactor(infra)
+		infra = redactor.InfrastructureState(infra)
+		analysis = redactor.Bottleneck(analysis)
+	}
+
+	constraining := constrainingResource(analysis)
+	passed := constraining == nil || constraining.UsedPercent <= threshold
+
+	switch format {
+	case "csv":
+		err = writeAnalyzeCSV(stdout, infra, constraining)
+	default:
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(analyzeResult{
+			Infrastructure: infra,
+			Bottleneck:     analysis,
+			Threshold:      threshold,
+			Passed:         passed,
+		})
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: failed to write output: %v\n", err)
+		return 2
+	}
+
+	if !passed {
+		fmt.Fprintf(stderr, "%s utilization %.1f%% exceeds threshold %.0f%%\n",
+			constraining.Name, constraining.UsedPercent, threshold)
+		return 1
+	}
+	return 0
+}
+
+// loadManualInput reads and parses a manual input JSON file
+func loadManualInput(path string) (*client.ManualInput, error) {
+	if path == "" {
+		return nil, fmt.Errorf("--input is required")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input file: %w", err)
+	}
+
+	var input client.ManualInput
+	if err := json.Unmarshal(data, &input); err != nil {
+		return nil, fmt.Errorf("invalid JSON in %s: %w", path, err)
+	}
+	if input.Format == client.FormatInfrastructureState {
+		return nil, fmt.Errorf("input file %s has format %q; analyze expects manual input", path, input.Format)
+	}
+	if len(input.Clusters) == 0 {
+		return nil, fmt.Errorf("input file %s has no clusters", path)
+	}
+
+	return &input, nil
+}
+
+// constrainingResource returns the resource flagged as constraining, or nil if none
+func constrainingResource(analysis *client.BottleneckAnalysis) *client.ResourceUtilization {
+	for i := range analysis.Resources {
+		if analysis.Resources[i].IsConstraining {
+			return &analysis.Resources[i]
+		}
+	}
+	return nil
+}
+
+// analyzeCSVHeader lists the per-cluster CSV columns
+var analyzeCSVHeader = []string{
+	"cluster",
+	"host_count",
+	"memory_gb",
+	"cpu_cores",
+	"diego_cell_count",
+	"diego_cell_memory_gb",
+	"diego_cell_cpu",
+	"diego_cell_disk_gb",
+	"n1_memory_gb",
+	"total_cell_memory_gb",
+	"vcpu_ratio",
+	"ha_host_failures_survived",
+	"ha_status",
+	"constraining_resource",
+	"constraining_used_percent",
+}
+
+// writeAnalyzeCSV flattens the infrastructure state into one CSV row per cluster.
+// Bottleneck columns are foundation-wide and repeat on every row.
+func writeAnalyzeCSV(w io.Writer, infra *client.InfrastructureState, constraining *client.ResourceUtilization) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(analyzeCSVHeader); err != nil {
+		return err
+	}
+
+	constrainingName := ""
+	constrainingPct := ""
+	if constraining != nil {
+		constrainingName = constraining.Name
+		constrainingPct = strconv.FormatFloat(constraining.UsedPercent, 'f', 1, 64)
+	}
+
+	for _, cl := range infra.Clusters {
+		row := []string{
+			cl.Name,
+			strconv.Itoa(cl.HostCount),
+			strconv.Itoa(cl.MemoryGB),
+			strconv.Itoa(cl.CPUCores),
+			strconv.Itoa(cl.DiegoCellCount),
+			strconv.Itoa(cl.DiegoCellMemoryGB),
+			strconv.Itoa(cl.DiegoCellCPU),
+			strconv.Itoa(cl.DiegoCellDiskGB),
+			strconv.Itoa(cl.N1MemoryGB),
+			strconv.Itoa(cl.TotalCellMemoryGB),
+			strconv.FormatFloat(cl.VCPURatio, 'f', 2, 64),
+			strconv.Itoa(cl.HAHostFailuresSurvived),
+			cl.HAStatus,
+			constrainingName,
+			constrainingPct,
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,208 @@
+// ABOUTME: Tests for the analyze command
+// ABOUTME: Verifies JSON/CSV output and threshold exit codes
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/client"
+)
+
+// newAnalyzeServer returns a backend stub serving the stateless check and bottleneck endpoints
+func newAnalyzeServer(t *testing.T, memoryPct float64) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/check":
+			json.NewEncoder(w).Encode(client.CheckResponse{
+				Status: "passed",
+				Infrastructure: client.InfrastructureState{
+					Name: "test",
+					Clusters: []client.ClusterState{
+						{Name: "cluster-a", HostCount: 4, DiegoCellCount: 10, HAStatus: "ok"},
+						{Name: "cluster-b", HostCount: 3, DiegoCellCount: 6, HAStatus: "at-risk"},
+					},
+				},
+			})
+		case "/api/v1/bottleneck":
+			json.NewEncoder(w).Encode(client.BottleneckAnalysis{
+				Resources: []client.ResourceUtilization{
+					{Name: "Memory", UsedPercent: memoryPct, IsConstraining: true},
+					{Name: "Disk", UsedPercent: 20},
+				},
+				ConstrainingResource: "Memory",
+			})
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func writeInputFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "infra.json")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write input file: %v", err)
+	}
+	return path
+}
+
+const analyzeInputJSON = `{"name":"test","clusters":[{"name":"cluster-a","host_count":4,"memory_gb_per_host":512}]}`
+
+func TestAnalyzeCommand_JSON(t *testing.T) {
+	server := newAnalyzeServer(t, 70)
+	defer server.Close()
+
+	var stdout, stderr bytes.Buffer
+	code := runAnalyze(context.Background(), client.New(server.URL), &stdout, &stderr, writeInputFile(t, analyzeInputJSON), "json", 90, false)
+
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+
+	var result analyzeResult
+	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if !result.Passed {
+		t.Error("expected passed=true")
+	}
+	if result.Bottleneck.ConstrainingResource != "Memory" {
+		t.Errorf("expected constraining resource Memory, got %s", result.Bottleneck.ConstrainingResource)
+	}
+	if len(result.Infrastructure.Clusters) != 2 {
+		t.Errorf("expected 2 clusters, got %d", len(result.Infrastructure.Clusters))
+	}
+}
+
+func TestAnalyzeCommand_CSV(t *testing.T) {
+	server := newAnalyzeServer(t, 70)
+	defer server.Close()
+
+	var stdout, stderr bytes.Buffer
+	code := runAnalyze(context.Background(), client.New(server.URL), &stdout, &stderr, writeInputFile(t, analyzeInputJSON), "csv", 90, false)
+
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+
+	records, err := csv.NewReader(&stdout).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not valid CSV: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("expected header + 2 cluster rows, got %d rows", len(records))
+	}
+	if records[0][0] != "cluster" {
+		t.Errorf("expected header to start with 'cluster', got %q", records[0][0])
+	}
+	if records[1][0] != "cluster-a" || records[2][0] != "cluster-b" {
+		t.Errorf("unexpected cluster rows: %v", records[1:])
+	}
+	last := len(records[1]) - 1
+	if records[1][last-1] != "Memory" || records[1][last] != "70.0" {
+		t.Errorf("expected constraining columns Memory/70.0, got %v", records[1][last-1:])
+	}
+}
+
+func TestAnalyzeCommand_Redact(t *testing.T) {
+	server := newAnalyzeServer(t, 70)
+	defer server.Close()
+
+	for _, format := range []string{"json", "csv"} {
+		t.Run(format, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := runAnalyze(context.Background(), client.New(server.URL), &stdout, &stderr, writeInputFile(t, analyzeInputJSON), format, 90, true)
+			if code != 0 {
+				t.Fatalf("expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+			}
+
+			out := stdout.String()
+			if strings.Contains(out, "cluster-a") || strings.Contains(out, "cluster-b") || strings.Contains(out, `"test"`) {
+				t.Errorf("expected no foundation or cluster names, got:\n%s", out)
+			}
+			if !strings.Contains(out, "cluster-1") || !strings.Contains(out, "cluster-2") {
+				t.Errorf("expected cluster-1 and cluster-2, got:\n%s", out)
+			}
+		})
+	}
+}
+
+func TestAnalyzeCommand_ThresholdExceeded(t *testing.T) {
+	server := newAnalyzeServer(t, 92)
+	defer server.Close()
+
+	var stdout, stderr bytes.Buffer
+	code := runAnalyze(context.Background(), client.New(server.URL), &stdout, &stderr, writeInputFile(t, analyzeInputJSON), "json", 85, false)
+
+	if code != 1 {
+		t.Errorf("expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "exceeds threshold") {
+		t.Errorf("expected threshold message on stderr, got %q", stderr.String())
+	}
+	if stdout.Len() == 0 {
+		t.Error("expected JSON output even when threshold exceeded")
+	}
+}
+
+func TestAnalyzeCommand_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		format  string
+		wantErr string
+	}{
+		{"missing input flag", "", "json", "--input is required"},
+		{"invalid format", "x.json", "xml", "--format"},
+		{"missing file", "/nonexistent/infra.json", "json", "failed to read"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := runAnalyze(context.Background(), client.New("http://localhost:99999"), &stdout, &stderr, tt.input, tt.format, 90, false)
+			if code != 2 {
+				t.Errorf("expected exit code 2, got %d", code)
+			}
+			if !strings.Contains(stderr.String(), tt.wantErr) {
+				t.Errorf("expected stderr to contain %q, got %q", tt.wantErr, stderr.String())
+			}
+		})
+	}
+}
+
+func TestAnalyzeCommand_NoClusters(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := runAnalyze(context.Background(), client.New("http://localhost:99999"), &stdout, &stderr, writeInputFile(t, `{"name":"empty"}`), "json", 90, false)
+	if code != 2 {
+		t.Errorf("expected exit code 2, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "no clusters") {
+		t.Errorf("expected no clusters error, got %q", stderr.String())
+	}
+}
+
+func TestAnalyzeCommand_RejectsInfrastructureStateFormat(t *testing.T) {
+	input := `{"format":"infrastructure_state","name":"state","clusters":[{"name":"c1","memory_gb_per_host":512}]}`
+	var stdout, stderr bytes.Buffer
+	code := runAnalyze(context.Background(), client.New("http://localhost:99999"), &stdout, &stderr, writeInputFile(t, input), "json", 90, false)
+	if code != 2 {
+		t.Errorf("expected exit code 2, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "expects manual input") {
+		t.Errorf("expected format error, got %q", stderr.String())
+	}
+}
@@ -19,6 +19,10 @@ import (
 var (
 	n1Threshold     int
 	memoryThreshold int
+	checkInput      string
+	maxN1           float64
+	minFreeChunks   int
+	minSingleChunk  int
 )
 
 var checkCmd = &cobra.Command{
@@ -29,12 +33,51 @@ var checkCmd = &cobra.Command{
 Exit codes:
   0 - All checks passed
   1 - One or more thresholds exceeded
-  2 - Error (connectivity, no data, invalid input)`,
+  2 - Error (connectivity, no data, invalid input)
+
+With --input, the infrastructure file is evaluated statelessly against the
+same warning thresholds used by scenario comparison. --max-n1 and
+--min-free-chunks set the critical limits; the warning limits sit 10 points
+below max N-1 and at twice the minimum free chunks. --min-single-chunk sets
+the smallest app (GB) that must still fit on an average cell. Flags left
+unset use the backend's thresholds (GET /api/v1/thresholds), which may be
+overridden by the backend's THRESHOLDS setting. Failed checks are listed on
+stderr.
+
+Exit codes with --input:
+  0 - All checks passed
+  1 - Warning-level threshold crossed
+  2 - Critical threshold crossed
+  3 - Error (connectivity, invalid input)
+
+Example:
+  diego-capacity check --input infra.json --max-n1 85 --min-free-chunks 400`,
 	Run: func(cmd *cobra.Command, args []string) {
 		ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 		defer cancel()
 
-		exitCode := runCheck(ctx, os.Stdout)
+		var exitCode int
+		if checkInput != "" {
+			c, err := NewClient()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(2)
+			}
+			// Only explicit flags are sent; zero values defer to the backend's thresholds
+			var thresholds client.WarningThresholds
+			if cmd.Flags().Changed("max-n1") {
+				thresholds.N1CriticalPct = maxN1
+			}
+			if cmd.Flags().Changed("min-free-chunks") {
+				thresholds.FreeChunksCritical = minFreeChunks
+			}
+			if cmd.Flags().Changed("min-single-chunk") {
+				thresholds.MinSingleChunkGB = minSingleChunk
+			}
+			exitCode = runCheckInput(ctx, c, os.Stdout, os.Stderr, checkInput, thresholds)
+		} else {
+			exitCode = runCheck(ctx, os.Stdout)
+		}
 		if exitCode != 0 {
 			os.Exit(exitCode)
 		}
@@ -43,8 +86,13 @@ Exit codes:
 
 func init() {
 	rootCmd.AddCommand(checkCmd)
+	defaults := client.DefaultWarningThresholds()
 	checkCmd.Flags().IntVar(&n1Threshold, "n1-threshold", 85, "N-1 capacity threshold percentage")
 	checkCmd.Flags().IntVar(&memoryThreshold, "memory-threshold", 90, "Memory utilization threshold percentage")
+	checkCmd.Flags().StringVar(&checkInput, "input", "", "Path to infrastructure JSON file to check without loading it")
+	checkCmd.Flags().Float64Var(&maxN1, "max-n1", defaults.N1CriticalPct, "Critical N-1 utilization percentage (with --input)")
+	checkCmd.Flags().IntVar(&minFreeChunks, "min-free-chunks", defaults.FreeChunksCritical, "Critical minimum free 4GB chunks (with --input)")
+	checkCmd.Flags().IntVar(&minSingleChunk, "min-single-chunk", defaults.MinSingleChunkGB, "Warning minimum GB stageable on an average cell (with --input)")
 }
 
 // checkResult represents the result of a single threshold check
@@ -63,8 +111,11 @@ func runCheck(ctx context.Context, w io.Writer) int {
 		return 2
 	}
 
-	url := GetAPIURL()
-	c := client.New(url)
+	c, err := NewClient()
+	if err != nil {
+		fmt.Fprintf(w, "Error: %v\n", err)
+		return 2
+	}
 
 	resp, err := c.InfrastructureStatus(ctx)
 	if err != nil {
@@ -193,3 +244,102 @@ func formatCheckJSON(results []checkResult) string {
 	data, _ := json.MarshalIndent(output, "", "  ")
 	return string(data)
 }
+
+// Exit codes for check --input, ordered by severity
+const (
+	checkExitPassed   = 0
+	checkExitWarning  = 1
+	checkExitCritical = 2
+	checkExitError    = 3
+)
+
+// runCheckInput evaluates an infrastructure file against warning thresholds via the
+// backend, prints failed checks to stderr, and returns exit code
+func runCheckInput(ctx context.Context, c *client.Client, stdout, stderr io.Writer, inputPath string, thresholds client.WarningThresholds) int {
+	if err := validateCheckThresholds(thresholds); err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return checkExitError
+	}
+
+	input, err := loadManualInput(inputPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return checkExitError
+	}
+
+	result, err := c.CheckThresholds(ctx, input, thresholds)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return checkExitError
+	}
+
+	if IsJSONOutput() {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(result); err != nil {
+			fmt.Fprintf(stderr, "Error: failed to write output: %v\n", err)
+			return checkExitError
+		}
+	} else {
+		fmt.Fprintln(stdout, formatThresholdCheckHuman(result))
+	}
+
+	if len(result.Warnings) > 0 {
+		fmt.Fprintln(stderr, formatFailedChecks(result))
+	}
+
+	switch result.Status {
+	case "critical":
+		return checkExitCritical
+	case "warning":
+		return checkExitWarning
+	}
+	return checkExitPassed
+}
+
+// validateCheckThresholds rejects out-of-range --max-n1 and --min-free-chunks values
+func validateCheckThresholds(thresholds client.WarningThresholds) error {
+	if thresholds.N1CriticalPct < 0 || thresholds.N1CriticalPct > 100 {
+		return fmt.Errorf("--max-n1 must be between 0 and 100")
+	}
+	if thresholds.FreeChunksCritical < 0 {
+		return fmt.Errorf("--min-free-chunks must not be negative")
+	}
+	return nil
+}
+
+// formatThresholdCheckHuman summarizes the key metrics against their effective limits
+func formatThresholdCheckHuman(result *client.CheckResponse) string {
+	t := result.Thresholds
+	output := fmt.Sprintf("N-1 utilization: %.0f%% (warning > %.0f%%, critical > %.0f%%)\n",
+		result.Current.N1UtilizationPct, t.N1WarningPct, t.N1CriticalPct)
+	output += fmt.Sprintf("Free chunks:     %d (warning < %d, critical < %d)\n",
+		result.Current.FreeChunks, t.FreeChunksWarning, t.FreeChunksCritical)
+	output += fmt.Sprintf("Largest app:     %d GB per cell (warning < %d GB)\n",
+		result.Current.MaxSingleChunkGB, t.MinSingleChunkGB)
+
+	switch result.Status {
+	case "critical":
+		output += "\nCRITICAL: critical threshold crossed"
+	case "warning":
+		output += "\nWARNING: warning threshold crossed"
+	default:
+		output += "\nPASSED: All checks within thresholds"
+	}
+	return output
+}
+
+// formatFailedChecks lists each failed check with its severity
+func formatFailedChecks(result *client.CheckResponse) string {
+	var output string
+	critical := 0
+	for _, w := range result.Warnings {
+		if w.Severity == "critical" {
+			critical++
+		}
+		output += fmt.Sprintf("✗ [%s] %s\n", w.Severity, w.Message)
+	}
+	output += fmt.Sprintf("%d check(s) failed (%d critical, %d warning)",
+		len(result.Warnings), critical, len(result.Warnings)-critical)
+	return output
+}
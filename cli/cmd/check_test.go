@@ -9,6 +9,8 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/markalston/diego-capacity-analyzer/cli/internal/client"
@@ -198,3 +200,102 @@ func TestValidateThresholds(t *testing.T) {
 		}
 	}
 }
+
+// newCheckInputServer returns a backend stub answering POST /api/v1/check with the given status
+func newCheckInputServer(t *testing.T, status string, warnings []client.ScenarioWarning) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/check" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var req client.CheckRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		if req.Thresholds.N1CriticalPct != 85 || req.Thresholds.FreeChunksCritical != 400 {
+			t.Errorf("unexpected thresholds: %+v", req.Thresholds)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(client.CheckResponse{
+			Status:     status,
+			Current:    client.ScenarioResult{N1UtilizationPct: 60, FreeChunks: 500},
+			Thresholds: client.WarningThresholds{N1WarningPct: 75, N1CriticalPct: 85, FreeChunksWarning: 800, FreeChunksCritical: 400},
+			Warnings:   warnings,
+		})
+	}))
+}
+
+func TestCheckInput_ExitCodes(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   string
+		warnings []client.ScenarioWarning
+		expected int
+	}{
+		{"passed", "passed", nil, 0},
+		{"warning", "warning", []client.ScenarioWarning{{Severity: "warning", Message: "Low staging capacity"}}, 1},
+		{"critical", "critical", []client.ScenarioWarning{
+			{Severity: "warning", Message: "Approaching N-1 capacity limits"},
+			{Severity: "critical", Message: "Critical: Low staging capacity"},
+		}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newCheckInputServer(t, tt.status, tt.warnings)
+			defer server.Close()
+
+			path := writeInputFile(t, analyzeInputJSON)
+			var stdout, stderr bytes.Buffer
+			exitCode := runCheckInput(context.Background(), client.New(server.URL), &stdout, &stderr, path,
+				client.WarningThresholds{N1CriticalPct: 85, FreeChunksCritical: 400})
+
+			if exitCode != tt.expected {
+				t.Errorf("expected exit code %d, got %d (stderr: %s)", tt.expected, exitCode, stderr.String())
+			}
+			for _, w := range tt.warnings {
+				if !strings.Contains(stderr.String(), w.Message) {
+					t.Errorf("expected stderr to name failed check %q, got %q", w.Message, stderr.String())
+				}
+			}
+			if len(tt.warnings) == 0 && stderr.Len() != 0 {
+				t.Errorf("expected empty stderr when all checks pass, got %q", stderr.String())
+			}
+			if !strings.Contains(stdout.String(), "Free chunks") {
+				t.Errorf("expected metric summary on stdout, got %q", stdout.String())
+			}
+		})
+	}
+}
+
+func TestCheckInput_Errors(t *testing.T) {
+	c := client.New("http://127.0.0.1:1")
+	valid := client.WarningThresholds{N1CriticalPct: 85, FreeChunksCritical: 400}
+
+	tests := []struct {
+		name       string
+		path       string
+		thresholds client.WarningThresholds
+	}{
+		{"missing file", filepath.Join(t.TempDir(), "missing.json"), valid},
+		{"invalid max-n1", writeInputFile(t, analyzeInputJSON), client.WarningThresholds{N1CriticalPct: 150}},
+		{"negative min-free-chunks", writeInputFile(t, analyzeInputJSON), client.WarningThresholds{FreeChunksCritical: -1}},
+		{"backend unreachable", writeInputFile(t, analyzeInputJSON), valid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			exitCode := runCheckInput(context.Background(), c, &stdout, &stderr, tt.path, tt.thresholds)
+			if exitCode != 3 {
+				t.Errorf("expected exit code 3, got %d", exitCode)
+			}
+			if !strings.Contains(stderr.String(), "Error") {
+				t.Errorf("expected error on stderr, got %q", stderr.String())
+			}
+		})
+	}
+}
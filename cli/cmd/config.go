@@ -0,0 +1,161 @@
+// ABOUTME: Config command for diego-capacity CLI
+// ABOUTME: Prints the effective CLI configuration with secrets redacted, for troubleshooting
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/tui/recentfiles"
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/tui/samples"
+)
+
+const redacted = "<redacted>"
+
+// configEnvVars are the environment variables the CLI reads, beyond any other
+// DIEGO_* variables that happen to be set
+var configEnvVars = []string{
+	"DIEGO_CAPACITY_API_URL",
+	"DIEGO_CAPACITY_API_TOKEN",
+	"DIEGO_CAPACITY_CA_CERT",
+	"DIEGO_SAMPLES_PATH",
+	"DIEGO_NERD_FONTS",
+	"NERD_FONTS",
+	"XDG_CONFIG_HOME",
+	"HTTPS_PROXY",
+	"HTTP_PROXY",
+	"NO_PROXY",
+}
+
+// secretMarkers flag environment variable names whose values are never printed
+var secretMarkers = []string{"TOKEN", "SECRET", "PASSWORD", "KEY"}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Show the effective CLI configuration",
+	Long: `Show the resolved backend URL, connection settings, samples and config
+directories, and which environment variables are set.
+
+Secret values are redacted, so the output is safe to paste into an issue.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runConfig(cmd.OutOrStdout())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+}
+
+// configInfo is the JSON shape of the config command output
+type configInfo struct {
+	Backend    string            `json:"backend"`
+	TokenSet   bool              `json:"token_set"`
+	CACert     string            `json:"ca_cert"`
+	Retries    int               `json:"retries"`
+	SamplesDir string            `json:"samples_dir"`
+	ConfigDir  string            `json:"config_dir"`
+	Env        map[string]string `json:"env"`
+}
+
+// runConfig prints the effective configuration
+func runConfig(w io.Writer) {
+	cwd, _ := os.Getwd()
+	info := configInfo{
+		Backend:    GetAPIURL(),
+		TokenSet:   GetAPIToken() != "",
+		CACert:     GetCACertPath(),
+		Retries:    retries,
+		SamplesDir: samples.FindSamplesDir(cwd),
+		ConfigDir:  recentfiles.DefaultConfigDir(),
+		Env:        setEnvVars(os.Environ()),
+	}
+
+	if IsJSONOutput() {
+		data, _ := json.MarshalIndent(info, "", "  ")
+		fmt.Fprintln(w, string(data))
+		return
+	}
+	fmt.Fprintln(w, formatConfigHuman(info))
+}
+
+// setEnvVars returns the known and DIEGO_* variables present in environ,
+// with secret-like values redacted
+func setEnvVars(environ []string) map[string]string {
+	known := make(map[string]bool, len(configEnvVars))
+	for _, name := range configEnvVars {
+		known[name] = true
+	}
+
+	vars := map[string]string{}
+	for _, kv := range environ {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok || (!known[name] && !strings.HasPrefix(name, "DIEGO_")) {
+			continue
+		}
+		vars[name] = redactEnvValue(name, value)
+	}
+	return vars
+}
+
+// redactEnvValue hides the value of secret-like variables and any password
+// embedded in a URL value, such as a proxy with credentials
+func redactEnvValue(name, value string) string {
+	upper := strings.ToUpper(name)
+	for _, marker := range secretMarkers {
+		if strings.Contains(upper, marker) {
+			return redacted
+		}
+	}
+	if u, err := url.Parse(value); err == nil && u.User != nil {
+		u.User = url.UserPassword(u.User.Username(), "xxxxx")
+		return u.String()
+	}
+	return value
+}
+
+// formatConfigHuman formats configuration for human readability
+func formatConfigHuman(info configInfo) string {
+	token := "not set"
+	if info.TokenSet {
+		token = "set"
+	}
+	caCert := info.CACert
+	if caCert == "" {
+		caCert = "system roots"
+	}
+	samplesDir := info.SamplesDir
+	if samplesDir == "" {
+		samplesDir = "not found"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `Backend:      %s
+Token:        %s
+CA Cert:      %s
+Retries:      %d
+Samples Dir:  %s
+Config Dir:   %s
+Environment:`, info.Backend, token, caCert, info.Retries, samplesDir, info.ConfigDir)
+
+	if len(info.Env) == 0 {
+		b.WriteString(" none set")
+		return b.String()
+	}
+	names := make([]string, 0, len(info.Env))
+	for name := range info.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&b, "\n  %s=%s", name, info.Env[name])
+	}
+	return b.String()
+}
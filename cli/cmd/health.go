@@ -38,7 +38,11 @@ func init() {
 // runHealth executes the health check and returns exit code
 func runHealth(ctx context.Context, w io.Writer) int {
 	url := GetAPIURL()
-	c := client.New(url)
+	c, err := NewClient()
+	if err != nil {
+		fmt.Fprintf(w, "Error: %v\n", err)
+		return 2
+	}
 
 	resp, err := c.Health(ctx)
 	if err != nil {
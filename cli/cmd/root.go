@@ -5,6 +5,10 @@ package cmd
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
 	"os"
 
 	"github.com/spf13/cobra"
@@ -12,11 +16,17 @@ import (
 
 	"github.com/markalston/diego-capacity-analyzer/cli/internal/client"
 	"github.com/markalston/diego-capacity-analyzer/cli/internal/tui"
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/tui/styles"
 )
 
 var (
 	apiURL     string
+	apiToken   string
+	caCertPath string
+	retries    int
 	jsonOutput bool
+	themeName  string
+	redact     bool
 )
 
 const defaultAPIURL = "http://localhost:8080"
@@ -32,21 +42,32 @@ scenario planning. Use subcommands (health, status, check) for non-interactive
 access or add --json for machine-readable output.
 
 Environment Variables:
-  DIEGO_CAPACITY_API_URL  Backend API URL (default: http://localhost:8080)`,
+  DIEGO_CAPACITY_API_URL    Backend API URL (default: http://localhost:8080)
+  DIEGO_CAPACITY_API_TOKEN  Bearer token for backends running with AUTH_MODE=required
+  DIEGO_CAPACITY_CA_CERT    PEM file of extra CA certificates to trust
+  HTTPS_PROXY, NO_PROXY     Proxy settings, honored by the HTTP client
+  NO_COLOR                  Use the mono TUI theme unless --theme is given`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := styles.SetTheme(styles.ResolveTheme(themeName)); err != nil {
+			return err
+		}
+
 		// If not a TTY or --json flag, show help
 		if !term.IsTerminal(int(os.Stdout.Fd())) || jsonOutput {
 			return cmd.Help()
 		}
 
 		// Launch TUI
-		c := client.New(GetAPIURL())
+		c, err := NewClient()
+		if err != nil {
+			return err
+		}
 
 		// Check if vSphere is configured by calling status endpoint
 		status, err := c.InfrastructureStatus(context.Background())
 		vsphereConfigured := err == nil && status.VSphereConfigured
 
-		return tui.Run(c, vsphereConfigured)
+		return tui.Run(c, vsphereConfigured, redact)
 	},
 }
 
@@ -57,7 +78,12 @@ func Execute() error {
 
 func init() {
 	rootCmd.PersistentFlags().StringVar(&apiURL, "api-url", "", "Backend API URL (overrides DIEGO_CAPACITY_API_URL)")
+	rootCmd.PersistentFlags().StringVar(&apiToken, "token", "", "Backend API bearer token (overrides DIEGO_CAPACITY_API_TOKEN)")
+	rootCmd.PersistentFlags().StringVar(&caCertPath, "ca-cert", "", "PEM file of CA certificates to trust (overrides DIEGO_CAPACITY_CA_CERT)")
+	rootCmd.PersistentFlags().IntVar(&retries, "retries", client.DefaultRetries, "Retries on connection errors and 502/503/504 responses (0 disables)")
 	rootCmd.PersistentFlags().BoolVar(&jsonOutput, "json", false, "Output JSON instead of human-readable text")
+	rootCmd.Flags().BoolVar(&redact, "redact", false, "Replace foundation and cluster names with pseudonyms (cluster-1, ...) in TUI exports")
+	rootCmd.Flags().StringVar(&themeName, "theme", "", "TUI color theme: default, colorblind, or mono (default \"default\", or \"mono\" when NO_COLOR is set)")
 }
 
 // GetAPIURL returns the API URL from flag, env, or default (in priority order)
@@ -71,6 +97,72 @@ func GetAPIURL() string {
 	return defaultAPIURL
 }
 
+// GetAPIToken returns the API token from flag or env (in priority order)
+func GetAPIToken() string {
+	if apiToken != "" {
+		return apiToken
+	}
+	return os.Getenv("DIEGO_CAPACITY_API_TOKEN")
+}
+
+// GetCACertPath returns the CA certificate path from flag or env (in priority order)
+func GetCACertPath() string {
+	if caCertPath != "" {
+		return caCertPath
+	}
+	return os.Getenv("DIEGO_CAPACITY_CA_CERT")
+}
+
+// NewClient creates an API client from the URL, token, CA certificate, and
+// retry settings. Extra options are applied last, overriding those settings.
+func NewClient(extra ...client.Option) (*client.Client, error) {
+	return newClientForURL(GetAPIURL(), extra...)
+}
+
+// newClientForURL creates an API client for baseURL with the token, CA
+// certificate, and retry settings NewClient uses
+func newClientForURL(baseURL string, extra ...client.Option) (*client.Client, error) {
+	opts := []client.Option{client.WithRetries(retries)}
+	if token := GetAPIToken(); token != "" {
+		opts = append(opts, client.WithToken(token))
+	}
+	if path := GetCACertPath(); path != "" {
+		hc, err := httpClientWithCA(path)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, client.WithHTTPClient(hc))
+	}
+	return client.New(baseURL, append(opts, extra...)...), nil
+}
+
+// httpClientWithCA returns an HTTP client that trusts the certificates in the
+// PEM file at path in addition to the system roots
+func httpClientWithCA(path string) (*http.Client, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading CA certificate: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no PEM certificates found in %s", path)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
+		RootCAs:    pool,
+		MinVersion: tls.VersionTLS12,
+	}
+	return &http.Client{
+		Timeout:   client.DefaultTimeout,
+		Transport: transport,
+	}, nil
+}
+
 // IsJSONOutput returns whether JSON output is requested
 func IsJSONOutput() bool {
 	return jsonOutput
@@ -4,7 +4,12 @@
 package cmd
 
 import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -49,3 +54,63 @@ func TestJSONOutput(t *testing.T) {
 		t.Error("expected IsJSONOutput to return true")
 	}
 }
+
+func TestGetAPIToken_FlagOverridesEnv(t *testing.T) {
+	os.Setenv("DIEGO_CAPACITY_API_TOKEN", "env-token")
+	defer os.Unsetenv("DIEGO_CAPACITY_API_TOKEN")
+	apiToken = ""
+
+	if got := GetAPIToken(); got != "env-token" {
+		t.Errorf("expected env-token, got %q", got)
+	}
+
+	apiToken = "flag-token"
+	defer func() { apiToken = "" }()
+	if got := GetAPIToken(); got != "flag-token" {
+		t.Errorf("expected flag to override env, got %q", got)
+	}
+}
+
+func TestNewClient_CACert(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "Bearer tls-token" {
+			t.Errorf("expected Bearer header, got %q", auth)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"cf_api": "ok"}`))
+	}))
+	defer server.Close()
+
+	certPath := filepath.Join(t.TempDir(), "ca.pem")
+	pemData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(certPath, pemData, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	apiURL = server.URL
+	apiToken = "tls-token"
+	caCertPath = certPath
+	defer func() { apiURL, apiToken, caCertPath = "", "", "" }()
+
+	c, err := NewClient()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := c.Health(context.Background()); err != nil {
+		t.Errorf("expected the custom CA to be trusted, got %v", err)
+	}
+}
+
+func TestNewClient_InvalidCACert(t *testing.T) {
+	dir := t.TempDir()
+	notPEM := filepath.Join(dir, "bad.pem")
+	os.WriteFile(notPEM, []byte("not a certificate"), 0600)
+
+	for _, path := range []string{notPEM, filepath.Join(dir, "missing.pem")} {
+		caCertPath = path
+		if _, err := NewClient(); err == nil {
+			t.Errorf("expected an error for CA cert %s", path)
+		}
+	}
+	caCertPath = ""
+}
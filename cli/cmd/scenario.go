@@ -43,7 +43,10 @@ Example:
 			cancel()
 		}()
 
-		c := client.New(GetAPIURL())
+		c, err := NewClient()
+		if err != nil {
+			return err
+		}
 		return runScenarioCompare(ctx, c, os.Stdout, cellMemoryGB, cellCPU, cellDiskGB, cellCount, IsJSONOutput())
 	},
 }
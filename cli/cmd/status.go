@@ -37,8 +37,11 @@ func init() {
 
 // runStatus executes the status check and returns exit code
 func runStatus(ctx context.Context, w io.Writer) int {
-	url := GetAPIURL()
-	c := client.New(url)
+	c, err := NewClient()
+	if err != nil {
+		fmt.Fprintf(w, "Error: %v\n", err)
+		return 2
+	}
 
 	resp, err := c.InfrastructureStatus(ctx)
 	if err != nil {
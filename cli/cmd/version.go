@@ -0,0 +1,101 @@
+// ABOUTME: Version command for diego-capacity CLI
+// ABOUTME: Prints build metadata and, when reachable, the backend's version
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"time"
+
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/client"
+	"github.com/spf13/cobra"
+)
+
+// Build metadata, overridden at build time, e.g.
+//
+//	go build -ldflags "-X github.com/markalston/diego-capacity-analyzer/cli/cmd.version=v1.2.0"
+var (
+	version   = "dev"
+	commit    = "unknown"
+	buildDate = "unknown"
+)
+
+// backendVersionTimeout keeps an unreachable backend from delaying the output
+const backendVersionTimeout = 3 * time.Second
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Show CLI and backend versions",
+	Long: `Show the CLI version, git commit, build date, and Go version.
+
+If the backend is reachable, its version is reported as well.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runVersion(cmd.Context(), cmd.OutOrStdout())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(versionCmd)
+}
+
+// versionInfo is the JSON shape of the version command output
+type versionInfo struct {
+	Version        string `json:"version"`
+	Commit         string `json:"commit"`
+	BuildDate      string `json:"build_date"`
+	GoVersion      string `json:"go_version"`
+	Platform       string `json:"platform"`
+	Backend        string `json:"backend"`
+	BackendVersion string `json:"backend_version,omitempty"`
+}
+
+// runVersion prints build metadata, looking up the backend version without
+// retries so an unreachable backend only costs a short timeout
+func runVersion(ctx context.Context, w io.Writer) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	info := versionInfo{
+		Version:   version,
+		Commit:    commit,
+		BuildDate: buildDate,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		Backend:   GetAPIURL(),
+	}
+
+	if c, err := NewClient(client.WithRetries(0)); err == nil {
+		ctx, cancel := context.WithTimeout(ctx, backendVersionTimeout)
+		defer cancel()
+		if resp, err := c.Health(ctx); err == nil {
+			info.BackendVersion = resp.Version
+			if info.BackendVersion == "" {
+				info.BackendVersion = "unknown"
+			}
+		}
+	}
+
+	if IsJSONOutput() {
+		data, _ := json.MarshalIndent(info, "", "  ")
+		fmt.Fprintln(w, string(data))
+		return
+	}
+	fmt.Fprintln(w, formatVersionHuman(info))
+}
+
+// formatVersionHuman formats version info for human readability
+func formatVersionHuman(info versionInfo) string {
+	backend := "not reachable"
+	if info.BackendVersion != "" {
+		backend = info.BackendVersion
+	}
+	return fmt.Sprintf(`diego-capacity %s
+Commit:     %s
+Built:      %s
+Go:         %s %s
+Backend:    %s (%s)`, info.Version, info.Commit, info.BuildDate, info.GoVersion, info.Platform, backend, info.Backend)
+}
@@ -0,0 +1,81 @@
+// ABOUTME: Tests for the version command
+// ABOUTME: Verifies build metadata output and backend version lookup
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestVersionCommand_WithBackend(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"cf_api": "ok", "version": "v2.0.0"}`))
+	}))
+	defer server.Close()
+
+	apiURL = server.URL
+	version, commit, buildDate = "v1.2.3", "abc1234", "2026-10-01T00:00:00Z"
+	defer func() {
+		apiURL = ""
+		version, commit, buildDate = "dev", "unknown", "unknown"
+	}()
+
+	var buf bytes.Buffer
+	runVersion(context.Background(), &buf)
+
+	output := buf.String()
+	for _, want := range []string{"diego-capacity v1.2.3", "abc1234", "2026-10-01T00:00:00Z", runtime.Version(), "v2.0.0 (" + server.URL + ")"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, output)
+		}
+	}
+}
+
+func TestVersionCommand_BackendUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	apiURL = server.URL
+	server.Close()
+	defer func() { apiURL = "" }()
+
+	var buf bytes.Buffer
+	runVersion(context.Background(), &buf)
+
+	if !strings.Contains(buf.String(), "Backend:    not reachable") {
+		t.Errorf("expected unreachable backend, got:\n%s", buf.String())
+	}
+}
+
+func TestVersionCommand_JSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"cf_api": "ok"}`))
+	}))
+	defer server.Close()
+
+	apiURL = server.URL
+	jsonOutput = true
+	defer func() { apiURL, jsonOutput = "", false }()
+
+	var buf bytes.Buffer
+	runVersion(context.Background(), &buf)
+
+	var info versionInfo
+	if err := json.Unmarshal(buf.Bytes(), &info); err != nil {
+		t.Fatalf("expected valid JSON: %v\n%s", err, buf.String())
+	}
+	if info.Version != "dev" || info.GoVersion != runtime.Version() {
+		t.Errorf("unexpected version info: %+v", info)
+	}
+	// A backend older than the version field is reachable but unversioned
+	if info.BackendVersion != "unknown" {
+		t.Errorf("expected backend version unknown, got %q", info.BackendVersion)
+	}
+}
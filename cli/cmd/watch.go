@@ -0,0 +1,177 @@
+// ABOUTME: Watch command for diego-capacity CLI
+// ABOUTME: Re-checks an infrastructure file on an interval and posts status changes to a webhook
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/client"
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/notify"
+	"github.com/spf13/cobra"
+)
+
+var (
+	watchInput          string
+	watchWebhook        string
+	watchInterval       time.Duration
+	watchFormat         string
+	watchMaxN1          float64
+	watchMinFreeChunks  int
+	watchMinSingleChunk int
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Monitor capacity and notify a webhook on threshold breaches",
+	Long: `Re-evaluate an infrastructure file every --interval with the same warning
+thresholds as check --input, and POST to --webhook when the status changes.
+
+A notification is sent when the platform enters warning or critical, when it
+moves between them, and when it recovers. Ticks that leave the status
+unchanged send nothing. A failed delivery is retried on the next tick. The
+input file is re-read on every tick, so it can be regenerated in place.
+
+--format json posts the alert as JSON; --format slack posts a Slack incoming
+webhook message. Threshold flags behave as in check --input. The command runs
+until interrupted.
+
+Exit codes:
+  0 - Interrupted
+  2 - Error (invalid flags, no backend configured)
+
+Example:
+  diego-capacity watch --input infra.json --webhook https://hooks.slack.com/services/... --format slack --interval 15m`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+
+		c, err := NewClient()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+		// Only explicit flags are sent; zero values defer to the backend's thresholds
+		var thresholds client.WarningThresholds
+		if cmd.Flags().Changed("max-n1") {
+			thresholds.N1CriticalPct = watchMaxN1
+		}
+		if cmd.Flags().Changed("min-free-chunks") {
+			thresholds.FreeChunksCritical = watchMinFreeChunks
+		}
+		if cmd.Flags().Changed("min-single-chunk") {
+			thresholds.MinSingleChunkGB = watchMinSingleChunk
+		}
+		exitCode := runWatch(ctx, c, os.Stdout, os.Stderr, watchOptions{
+			inputPath:  watchInput,
+			webhookURL: watchWebhook,
+			interval:   watchInterval,
+			format:     watchFormat,
+			thresholds: thresholds,
+		})
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+	defaults := client.DefaultWarningThresholds()
+	watchCmd.Flags().StringVar(&watchInput, "input", "", "Path to infrastructure JSON file (required)")
+	watchCmd.Flags().StringVar(&watchWebhook, "webhook", "", "URL to POST notifications to (required)")
+	watchCmd.Flags().DurationVar(&watchInterval, "interval", 15*time.Minute, "Time between checks")
+	watchCmd.Flags().StringVar(&watchFormat, "format", notify.FormatJSON, "Webhook payload format: json or slack")
+	watchCmd.Flags().Float64Var(&watchMaxN1, "max-n1", defaults.N1CriticalPct, "Critical N-1 utilization percentage")
+	watchCmd.Flags().IntVar(&watchMinFreeChunks, "min-free-chunks", defaults.FreeChunksCritical, "Critical minimum free 4GB chunks")
+	watchCmd.Flags().IntVar(&watchMinSingleChunk, "min-single-chunk", defaults.MinSingleChunkGB, "Warning minimum GB stageable on an average cell")
+}
+
+// watchOptions configures a watch loop
+type watchOptions struct {
+	inputPath  string
+	webhookURL string
+	interval   time.Duration
+	format     string
+	thresholds client.WarningThresholds
+}
+
+// runWatch checks immediately and then every interval until ctx is done, and returns exit code
+func runWatch(ctx context.Context, c *client.Client, stdout, stderr io.Writer, opts watchOptions) int {
+	if opts.inputPath == "" {
+		fmt.Fprintln(stderr, "Error: --input is required")
+		return 2
+	}
+	if opts.webhookURL == "" {
+		fmt.Fprintln(stderr, "Error: --webhook is required")
+		return 2
+	}
+	if opts.interval <= 0 {
+		fmt.Fprintln(stderr, "Error: --interval must be positive")
+		return 2
+	}
+	if opts.format != notify.FormatJSON && opts.format != notify.FormatSlack {
+		fmt.Fprintf(stderr, "Error: --format must be json or slack, got %q\n", opts.format)
+		return 2
+	}
+	if err := validateCheckThresholds(opts.thresholds); err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	var tracker notify.Tracker
+	ticker := time.NewTicker(opts.interval)
+	defer ticker.Stop()
+	for {
+		watchTick(ctx, c, stdout, stderr, opts, &tracker)
+		select {
+		case <-ctx.Done():
+			return 0
+		case <-ticker.C:
+		}
+	}
+}
+
+// watchTick runs one check and notifies the webhook if the status changed.
+// Errors are reported on stderr and leave the tracker untouched, so the
+// watch keeps running and a missed notification is retried.
+func watchTick(ctx context.Context, c *client.Client, stdout, stderr io.Writer, opts watchOptions, tracker *notify.Tracker) {
+	now := time.Now().Format(time.RFC3339)
+
+	input, err := loadManualInput(opts.inputPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s Error: %v\n", now, err)
+		return
+	}
+	check, err := c.CheckThresholds(ctx, input, opts.thresholds)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s Error: %v\n", now, err)
+		return
+	}
+	// The constraining resource only enriches the alert; the status comes from the check
+	analysis, err := c.AnalyzeBottleneck(ctx, input)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s Warning: bottleneck analysis failed: %v\n", now, err)
+		analysis = nil
+	}
+
+	fmt.Fprintf(stdout, "%s %s (%d warning(s))\n", now, check.Status, len(check.Warnings))
+	if !tracker.ShouldNotify(check.Status) {
+		return
+	}
+
+	alert := notify.NewAlert(input.Name, check, analysis)
+	alert.PreviousStatus = tracker.Previous()
+	if err := notify.NotifyWebhook(ctx, opts.webhookURL, alert, opts.format); err != nil {
+		fmt.Fprintf(stderr, "%s Error: %v\n", now, err)
+		return
+	}
+	tracker.Delivered(check.Status)
+	fmt.Fprintf(stdout, "%s notified webhook: %s\n", now, check.Status)
+}
@@ -0,0 +1,165 @@
+// ABOUTME: Tests for the watch command
+// ABOUTME: Verifies status-change notifications, retries, and flag validation
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/client"
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/notify"
+)
+
+// newWatchServer returns a backend stub whose check status follows statuses, one per request
+func newWatchServer(t *testing.T, statuses []string) *httptest.Server {
+	t.Helper()
+	next := 0
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/check":
+			status := statuses[next]
+			next++
+			json.NewEncoder(w).Encode(client.CheckResponse{Status: status})
+		case "/api/v1/bottleneck":
+			json.NewEncoder(w).Encode(client.BottleneckAnalysis{
+				ConstrainingResource: "memory",
+				Resources:            []client.ResourceUtilization{{Name: "memory", UsedPercent: 91, IsConstraining: true}},
+			})
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestWatchTick_NotifiesOnStatusChange(t *testing.T) {
+	backend := newWatchServer(t, []string{"passed", "warning", "warning", "critical", "passed"})
+	defer backend.Close()
+
+	var alerts []notify.Alert
+	failNext := false
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failNext {
+			failNext = false
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var alert notify.Alert
+		if err := json.NewDecoder(r.Body).Decode(&alert); err != nil {
+			t.Errorf("failed to decode alert: %v", err)
+		}
+		alerts = append(alerts, alert)
+	}))
+	defer webhook.Close()
+
+	opts := watchOptions{inputPath: writeInputFile(t, analyzeInputJSON), webhookURL: webhook.URL, interval: time.Minute, format: notify.FormatJSON}
+	c := client.New(backend.URL)
+	var tracker notify.Tracker
+	var stdout, stderr bytes.Buffer
+
+	watchTick(context.Background(), c, &stdout, &stderr, opts, &tracker) // passed: nothing to report
+	watchTick(context.Background(), c, &stdout, &stderr, opts, &tracker) // warning: notified
+	failNext = true
+	watchTick(context.Background(), c, &stdout, &stderr, opts, &tracker) // warning again: unchanged, so the failure waits for critical
+	watchTick(context.Background(), c, &stdout, &stderr, opts, &tracker) // critical: delivery fails
+	watchTick(context.Background(), c, &stdout, &stderr, opts, &tracker) // passed: recovery notified
+
+	if len(alerts) != 2 {
+		t.Fatalf("expected 2 alerts, got %d: %+v (stderr: %s)", len(alerts), alerts, stderr.String())
+	}
+	if alerts[0].Status != "warning" || alerts[0].PreviousStatus != "" || alerts[0].ConstrainingResource != "memory" || alerts[0].Environment != "test" {
+		t.Errorf("unexpected first alert: %+v", alerts[0])
+	}
+	if alerts[1].Status != "passed" || alerts[1].PreviousStatus != "warning" {
+		t.Errorf("expected recovery from warning, got %+v", alerts[1])
+	}
+	if !strings.Contains(stderr.String(), "503") {
+		t.Errorf("expected failed delivery on stderr, got %q", stderr.String())
+	}
+}
+
+func TestWatchTick_RetriesFailedDelivery(t *testing.T) {
+	backend := newWatchServer(t, []string{"critical", "critical"})
+	defer backend.Close()
+
+	deliveries := 0
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deliveries++
+		if deliveries == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer webhook.Close()
+
+	opts := watchOptions{inputPath: writeInputFile(t, analyzeInputJSON), webhookURL: webhook.URL, interval: time.Minute, format: notify.FormatSlack}
+	var tracker notify.Tracker
+	var stdout, stderr bytes.Buffer
+	watchTick(context.Background(), client.New(backend.URL), &stdout, &stderr, opts, &tracker)
+	watchTick(context.Background(), client.New(backend.URL), &stdout, &stderr, opts, &tracker)
+
+	if deliveries != 2 || tracker.Previous() != "critical" {
+		t.Errorf("expected the failed delivery to be retried, got %d deliveries, last %q", deliveries, tracker.Previous())
+	}
+}
+
+func TestWatchTick_KeepsRunningOnErrors(t *testing.T) {
+	opts := watchOptions{inputPath: writeInputFile(t, analyzeInputJSON), webhookURL: "http://127.0.0.1:1", interval: time.Minute, format: notify.FormatJSON}
+	var tracker notify.Tracker
+	var stdout, stderr bytes.Buffer
+	watchTick(context.Background(), client.New("http://127.0.0.1:1"), &stdout, &stderr, opts, &tracker)
+
+	if !strings.Contains(stderr.String(), "Error") || tracker.Previous() != "" {
+		t.Errorf("expected the error reported and no status recorded, got stderr %q", stderr.String())
+	}
+}
+
+func TestRunWatch_StopsOnCancel(t *testing.T) {
+	backend := newWatchServer(t, []string{"passed"})
+	defer backend.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	opts := watchOptions{inputPath: writeInputFile(t, analyzeInputJSON), webhookURL: "http://example.invalid", interval: time.Hour, format: notify.FormatJSON}
+	var stdout, stderr bytes.Buffer
+	if exitCode := runWatch(ctx, client.New(backend.URL), &stdout, &stderr, opts); exitCode != 0 {
+		t.Errorf("expected exit code 0 after cancel, got %d (stderr: %s)", exitCode, stderr.String())
+	}
+}
+
+func TestRunWatch_InvalidFlags(t *testing.T) {
+	valid := watchOptions{inputPath: "infra.json", webhookURL: "http://example.invalid", interval: time.Minute, format: notify.FormatJSON}
+
+	tests := []struct {
+		name   string
+		modify func(*watchOptions)
+		want   string
+	}{
+		{"missing input", func(o *watchOptions) { o.inputPath = "" }, "--input"},
+		{"missing webhook", func(o *watchOptions) { o.webhookURL = "" }, "--webhook"},
+		{"zero interval", func(o *watchOptions) { o.interval = 0 }, "--interval"},
+		{"unknown format", func(o *watchOptions) { o.format = "xml" }, "--format"},
+		{"invalid max-n1", func(o *watchOptions) { o.thresholds.N1CriticalPct = 150 }, "--max-n1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := valid
+			tt.modify(&opts)
+			var stdout, stderr bytes.Buffer
+			if exitCode := runWatch(context.Background(), client.New("http://127.0.0.1:1"), &stdout, &stderr, opts); exitCode != 2 {
+				t.Errorf("expected exit code 2, got %d", exitCode)
+			}
+			if !strings.Contains(stderr.String(), tt.want) {
+				t.Errorf("expected stderr to mention %s, got %q", tt.want, stderr.String())
+			}
+		})
+	}
+}
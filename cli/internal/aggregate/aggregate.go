@@ -0,0 +1,214 @@
+// ABOUTME: Multi-foundation aggregation of infrastructure state from several backends
+// ABOUTME: Sums per-foundation states into one combined view and has a backend rank its bottleneck resources
+
+package aggregate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/client"
+)
+
+// Foundation is one backend's infrastructure state and its bottleneck
+// analysis, or the error fetching them
+type Foundation struct {
+	URL        string
+	State      *client.InfrastructureState
+	Bottleneck *client.BottleneckAnalysis
+	Err        error
+}
+
+// FoundationSummary is one foundation's row in the per-foundation breakdown
+type FoundationSummary struct {
+	URL                  string  `json:"url"`
+	Name                 string  `json:"name,omitempty"`
+	Source               string  `json:"source,omitempty"`
+	Clusters             int     `json:"clusters"`
+	Hosts                int     `json:"hosts"`
+	Cells                int     `json:"cells"`
+	CellMemoryGB         int     `json:"cell_memory_gb"`
+	AppMemoryGB          int     `json:"app_memory_gb"`
+	ConstrainingResource string  `json:"constraining_resource,omitempty"`
+	ConstrainingPercent  float64 `json:"constraining_percent,omitempty"`
+	Error                string  `json:"error,omitempty"`
+}
+
+// Result is the combined view across foundations. Failed foundations appear
+// in Foundations with Error set and are left out of Combined. Bottleneck and
+// Combined.CPURiskLevel are set by Analyze, using the thresholds of the
+// backend named by AnalyzedBy.
+type Result struct {
+	Foundations []FoundationSummary        `json:"foundations"`
+	Combined    client.InfrastructureState `json:"combined"`
+	Bottleneck  client.BottleneckAnalysis  `json:"bottleneck"`
+	AnalyzedBy  string                     `json:"analyzed_by,omitempty"`
+	Failed      int                        `json:"failed"`
+}
+
+// Fetch gets the infrastructure state and its bottleneck analysis from every
+// backend concurrently. Results keep the order of urls; a backend that fails
+// has Err set.
+func Fetch(ctx context.Context, urls []string, newClient func(url string) (*client.Client, error)) []Foundation {
+	foundations := make([]Foundation, len(urls))
+	var wg sync.WaitGroup
+	for i, u := range urls {
+		foundations[i].URL = u
+		wg.Add(1)
+		go func(f *Foundation) {
+			defer wg.Done()
+			c, err := newClient(f.URL)
+			if err != nil {
+				f.Err = err
+				return
+			}
+			if f.State, f.Err = c.GetInfrastructure(ctx); f.Err != nil {
+				return
+			}
+			if f.Bottleneck, f.Err = c.GetBottleneck(ctx); f.Err != nil {
+				f.State = nil
+			}
+		}(&foundations[i])
+	}
+	wg.Wait()
+	return foundations
+}
+
+// Combine sums the states of the foundations that were fetched into one
+// InfrastructureState, prefixing cluster names with their foundation. Each
+// foundation's constraining resource comes from its own backend's analysis.
+func Combine(foundations []Foundation) Result {
+	result := Result{
+		Foundations: make([]FoundationSummary, 0, len(foundations)),
+		Combined:    client.InfrastructureState{Source: "aggregate"},
+	}
+	combined := &result.Combined
+
+	var hostMemoryWeighted, hostCPUWeighted float64
+	var ok int
+	for _, f := range foundations {
+		if f.Err != nil || f.State == nil {
+			msg := "no infrastructure state returned"
+			if f.Err != nil {
+				msg = f.Err.Error()
+			}
+			result.Foundations = append(result.Foundations, FoundationSummary{URL: f.URL, Error: msg})
+			result.Failed++
+			continue
+		}
+
+		s := f.State
+		label := foundationLabel(f)
+		summary := FoundationSummary{
+			URL:          f.URL,
+			Name:         s.Name,
+			Source:       s.Source,
+			Clusters:     len(s.Clusters),
+			Hosts:        s.TotalHostCount,
+			Cells:        s.TotalCellCount,
+			CellMemoryGB: s.TotalCellMemoryGB,
+			AppMemoryGB:  s.TotalAppMemoryGB,
+		}
+		if f.Bottleneck != nil && len(f.Bottleneck.Resources) > 0 {
+			summary.ConstrainingResource = f.Bottleneck.ConstrainingResource
+			summary.ConstrainingPercent = f.Bottleneck.Resources[0].UsedPercent
+		}
+		result.Foundations = append(result.Foundations, summary)
+
+		for _, cluster := range s.Clusters {
+			cluster.Name = label + "/" + cluster.Name
+			combined.Clusters = append(combined.Clusters, cluster)
+		}
+		combined.TotalMemoryGB += s.TotalMemoryGB
+		combined.TotalN1MemoryGB += s.TotalN1MemoryGB
+		combined.TotalHAUsableMemoryGB += s.TotalHAUsableMemoryGB
+		combined.TotalCellMemoryGB += s.TotalCellMemoryGB
+		combined.TotalHostCount += s.TotalHostCount
+		combined.TotalCellCount += s.TotalCellCount
+		combined.TotalCPUCores += s.TotalCPUCores
+		combined.TotalVCPUs += s.TotalVCPUs
+		combined.PlatformVMsGB += s.PlatformVMsGB
+		combined.TotalAppMemoryGB += s.TotalAppMemoryGB
+		combined.TotalAppDiskGB += s.TotalAppDiskGB
+		combined.TotalAppInstances += s.TotalAppInstances
+		combined.MaxInstanceMemoryMB = max(combined.MaxInstanceMemoryMB, s.MaxInstanceMemoryMB)
+
+		// The combined view is only as resilient as its weakest foundation
+		if ok == 0 || s.HAMinHostFailuresSurvived < combined.HAMinHostFailuresSurvived {
+			combined.HAMinHostFailuresSurvived = s.HAMinHostFailuresSurvived
+		}
+		if s.HAStatus != "" && combined.HAStatus != "at-risk" {
+			combined.HAStatus = s.HAStatus
+		}
+		hostMemoryWeighted += s.HostMemoryUtilizationPercent * float64(s.TotalMemoryGB)
+		hostCPUWeighted += s.HostCPUUtilizationPercent * float64(s.TotalCPUCores)
+		ok++
+	}
+
+	combined.Name = fmt.Sprintf("%d foundations", ok)
+	if ok == 1 {
+		combined.Name = "1 foundation"
+	}
+	if combined.TotalMemoryGB > 0 {
+		combined.HostMemoryUtilizationPercent = hostMemoryWeighted / float64(combined.TotalMemoryGB)
+	}
+	if combined.TotalCPUCores > 0 {
+		combined.HostCPUUtilizationPercent = hostCPUWeighted / float64(combined.TotalCPUCores)
+		combined.VCPURatio = float64(combined.TotalVCPUs) / float64(combined.TotalCPUCores)
+	}
+
+	return result
+}
+
+// Analyze has the first foundation that was fetched rank the combined state's
+// resources with POST /api/v1/bottleneck, and classifies its CPU risk with
+// that backend's thresholds, so the combined view follows the same
+// CPU_RATIO_* and THRESHOLDS settings as the backend analyzing it
+func Analyze(ctx context.Context, result *Result, newClient func(url string) (*client.Client, error)) error {
+	var backend string
+	for _, f := range result.Foundations {
+		if f.Error == "" {
+			backend = f.URL
+			break
+		}
+	}
+	if backend == "" {
+		return errors.New("no backend to analyze the combined state")
+	}
+
+	c, err := newClient(backend)
+	if err != nil {
+		return err
+	}
+	thresholds, err := c.GetThresholds(ctx)
+	if err != nil {
+		return fmt.Errorf("getting thresholds from %s: %w", backend, err)
+	}
+	input := result.Combined.ToManualInput()
+	analysis, err := c.AnalyzeBottleneck(ctx, &input)
+	if err != nil {
+		return fmt.Errorf("analyzing combined state with %s: %w", backend, err)
+	}
+
+	if result.Combined.TotalCPUCores > 0 {
+		result.Combined.CPURiskLevel = thresholds.CPURiskLevel(result.Combined.VCPURatio)
+	}
+	result.Bottleneck = *analysis
+	result.AnalyzedBy = backend
+	return nil
+}
+
+// foundationLabel names a foundation for cluster prefixes: its state name,
+// or the backend host when the state is unnamed
+func foundationLabel(f Foundation) string {
+	if f.State.Name != "" {
+		return f.State.Name
+	}
+	if u, err := url.Parse(f.URL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return f.URL
+}
@@ -0,0 +1,239 @@
+// ABOUTME: Tests for multi-foundation aggregation
+// ABOUTME: Validates summed totals, partial failures, cluster prefixes, and backend analysis of the combined state
+
+package aggregate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/client"
+)
+
+func eastState() *client.InfrastructureState {
+	return &client.InfrastructureState{
+		Name:                         "east",
+		Source:                       "vsphere",
+		Clusters:                     []client.ClusterState{{Name: "c1", DiegoCellCount: 10, DiegoCellDiskGB: 100}},
+		TotalMemoryGB:                2000,
+		TotalCellMemoryGB:            1000,
+		TotalHostCount:               4,
+		TotalCellCount:               10,
+		TotalCPUCores:                100,
+		TotalVCPUs:                   200,
+		TotalAppMemoryGB:             600,
+		TotalAppDiskGB:               200,
+		TotalAppInstances:            300,
+		HAMinHostFailuresSurvived:    2,
+		HAStatus:                     "ok",
+		HostMemoryUtilizationPercent: 50,
+	}
+}
+
+func westState() *client.InfrastructureState {
+	return &client.InfrastructureState{
+		Name:                         "west",
+		Source:                       "vsphere",
+		Clusters:                     []client.ClusterState{{Name: "c1", DiegoCellCount: 20, DiegoCellDiskGB: 100}},
+		TotalMemoryGB:                2000,
+		TotalCellMemoryGB:            1000,
+		TotalHostCount:               6,
+		TotalCellCount:               20,
+		TotalCPUCores:                100,
+		TotalVCPUs:                   100,
+		TotalAppMemoryGB:             200,
+		TotalAppDiskGB:               400,
+		TotalAppInstances:            100,
+		MaxInstanceMemoryMB:          4096,
+		HAMinHostFailuresSurvived:    0,
+		HAStatus:                     "at-risk",
+		HostMemoryUtilizationPercent: 30,
+	}
+}
+
+func eastBottleneck() *client.BottleneckAnalysis {
+	return &client.BottleneckAnalysis{
+		Resources:            []client.ResourceUtilization{{Name: "Memory", UsedPercent: 60, IsConstraining: true}},
+		ConstrainingResource: "Memory",
+	}
+}
+
+func TestCombine_SumsFoundations(t *testing.T) {
+	result := Combine([]Foundation{
+		{URL: "https://east", State: eastState(), Bottleneck: eastBottleneck()},
+		{URL: "https://west", State: westState()},
+	})
+
+	c := result.Combined
+	if c.Name != "2 foundations" || c.Source != "aggregate" {
+		t.Errorf("Combined name/source = %q/%q", c.Name, c.Source)
+	}
+	if c.TotalHostCount != 10 || c.TotalCellCount != 30 || c.TotalCellMemoryGB != 2000 || c.TotalAppMemoryGB != 800 {
+		t.Errorf("Unexpected totals: %+v", c)
+	}
+	if c.TotalAppInstances != 400 || c.TotalVCPUs != 300 || c.TotalCPUCores != 200 {
+		t.Errorf("Unexpected totals: %+v", c)
+	}
+	if c.MaxInstanceMemoryMB != 4096 {
+		t.Errorf("MaxInstanceMemoryMB = %d, want the largest foundation's 4096", c.MaxInstanceMemoryMB)
+	}
+	if c.VCPURatio != 1.5 || c.CPURiskLevel != "" {
+		t.Errorf("VCPURatio = %.2f (%q), want 1.5 with risk left to Analyze", c.VCPURatio, c.CPURiskLevel)
+	}
+	if c.HostMemoryUtilizationPercent != 40 {
+		t.Errorf("HostMemoryUtilizationPercent = %.1f, want 40 (memory-weighted)", c.HostMemoryUtilizationPercent)
+	}
+	if c.HAMinHostFailuresSurvived != 0 || c.HAStatus != "at-risk" {
+		t.Errorf("HA = %d/%s, want the weakest foundation's 0/at-risk", c.HAMinHostFailuresSurvived, c.HAStatus)
+	}
+
+	if len(c.Clusters) != 2 || c.Clusters[0].Name != "east/c1" || c.Clusters[1].Name != "west/c1" {
+		t.Errorf("Expected clusters prefixed by foundation, got %+v", c.Clusters)
+	}
+
+	if len(result.Foundations) != 2 || result.Failed != 0 {
+		t.Fatalf("Expected 2 foundations and no failures, got %+v", result)
+	}
+	if f := result.Foundations[0]; f.ConstrainingResource != "Memory" || f.ConstrainingPercent != 60 {
+		t.Errorf("east constraining = %s %.0f%%, want its backend's Memory 60%%", f.ConstrainingResource, f.ConstrainingPercent)
+	}
+	if f := result.Foundations[1]; f.ConstrainingResource != "" {
+		t.Errorf("west constraining = %q, want empty without an analysis", f.ConstrainingResource)
+	}
+}
+
+// analysisServer is a backend that reports CPU tiers of 1 and 2 and returns
+// analysis for POST /api/v1/bottleneck, recording the submitted input
+func analysisServer(t *testing.T, got *client.ManualInput) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/thresholds":
+			w.Write([]byte(`{"vcpu_ratio_moderate":1,"vcpu_ratio_aggressive":2}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/bottleneck":
+			if err := json.NewDecoder(r.Body).Decode(got); err != nil {
+				t.Errorf("failed to decode input: %v", err)
+			}
+			json.NewEncoder(w).Encode(client.BottleneckAnalysis{
+				Resources:            []client.ResourceUtilization{{Name: "CPU", UsedPercent: 75, IsConstraining: true}},
+				ConstrainingResource: "CPU",
+				Summary:              "CPU is your constraint",
+			})
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestAnalyze_UsesFirstFetchedBackend(t *testing.T) {
+	var submitted client.ManualInput
+	backend := analysisServer(t, &submitted)
+
+	result := Combine([]Foundation{
+		{URL: "https://down", Err: errors.New("cannot connect")},
+		{URL: backend.URL, State: eastState()},
+		{URL: "https://west", State: westState()},
+	})
+	var asked []string
+	err := Analyze(context.Background(), &result, func(url string) (*client.Client, error) {
+		asked = append(asked, url)
+		return client.New(url, client.WithRetries(0)), nil
+	})
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+
+	if len(asked) != 1 || asked[0] != backend.URL || result.AnalyzedBy != backend.URL {
+		t.Errorf("Expected the first fetched backend to analyze, asked %v, analyzed by %q", asked, result.AnalyzedBy)
+	}
+	if len(submitted.Clusters) != 2 || submitted.Clusters[0].Name != "east/c1" || submitted.TotalAppMemoryGB != 800 {
+		t.Errorf("Expected the combined state to be submitted, got %+v", submitted)
+	}
+	if result.Bottleneck.ConstrainingResource != "CPU" || result.Bottleneck.Summary != "CPU is your constraint" {
+		t.Errorf("Expected the backend's analysis, got %+v", result.Bottleneck)
+	}
+	// 1.5:1 is above the backend's moderate tier of 1
+	if result.Combined.CPURiskLevel != "medium" {
+		t.Errorf("CPURiskLevel = %q, want medium from the backend's thresholds", result.Combined.CPURiskLevel)
+	}
+}
+
+func TestAnalyze_NoBackend(t *testing.T) {
+	result := Combine([]Foundation{{URL: "https://down", Err: errors.New("cannot connect")}})
+	if err := Analyze(context.Background(), &result, func(url string) (*client.Client, error) {
+		t.Errorf("unexpected client for %s", url)
+		return client.New(url), nil
+	}); err == nil {
+		t.Error("Expected an error with no fetched backend")
+	}
+}
+
+func TestCombine_PartialFailure(t *testing.T) {
+	result := Combine([]Foundation{
+		{URL: "https://east", State: eastState()},
+		{URL: "https://down", Err: errors.New("cannot connect")},
+	})
+
+	if result.Failed != 1 || result.Combined.Name != "1 foundation" {
+		t.Errorf("Failed = %d, name = %q", result.Failed, result.Combined.Name)
+	}
+	if result.Combined.TotalHostCount != 4 {
+		t.Errorf("Expected only east in combined view, got %d hosts", result.Combined.TotalHostCount)
+	}
+	if f := result.Foundations[1]; f.URL != "https://down" || f.Error != "cannot connect" {
+		t.Errorf("Unexpected failed foundation %+v", f)
+	}
+}
+
+func TestCombine_UnnamedFoundationUsesHost(t *testing.T) {
+	state := eastState()
+	state.Name = ""
+
+	result := Combine([]Foundation{{URL: "https://east.example.com:8443", State: state}})
+
+	if got := result.Combined.Clusters[0].Name; got != "east.example.com:8443/c1" {
+		t.Errorf("Cluster name = %q, want host-prefixed", got)
+	}
+}
+
+func TestFetch_KeepsOrderAndReportsErrors(t *testing.T) {
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/v1/bottleneck" {
+			json.NewEncoder(w).Encode(eastBottleneck())
+			return
+		}
+		json.NewEncoder(w).Encode(eastState())
+	}))
+	defer ok.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{"error": "vSphere not configured"})
+	}))
+	defer failing.Close()
+
+	foundations := Fetch(context.Background(), []string{failing.URL, ok.URL}, func(url string) (*client.Client, error) {
+		return client.New(url, client.WithRetries(0)), nil
+	})
+
+	if len(foundations) != 2 || foundations[0].URL != failing.URL || foundations[1].URL != ok.URL {
+		t.Fatalf("Expected results in input order, got %+v", foundations)
+	}
+	if foundations[0].Err == nil {
+		t.Error("Expected an error from the failing backend")
+	}
+	if foundations[1].Err != nil || foundations[1].State == nil || foundations[1].State.Name != "east" {
+		t.Errorf("Expected east state, got %+v", foundations[1])
+	}
+	if b := foundations[1].Bottleneck; b == nil || b.ConstrainingResource != "Memory" {
+		t.Errorf("Expected east's bottleneck analysis, got %+v", b)
+	}
+}
@@ -9,23 +9,129 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 )
 
+// DefaultTimeout is the request timeout of the client's default HTTP client
+const DefaultTimeout = 30 * time.Second
+
+// Retry defaults for transient failures
+const (
+	DefaultRetries      = 3
+	defaultRetryBackoff = 250 * time.Millisecond
+	maxRetryBackoff     = 4 * time.Second
+)
+
 // Client is the API client for Diego Capacity Analyzer backend
 type Client struct {
-	baseURL    string
-	httpClient *http.Client
+	baseURL      string
+	token        string
+	httpClient   *http.Client
+	retries      int           // Retries after the first attempt on transient failures
+	retryBackoff time.Duration // Delay before the first retry, doubled for each one after
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithToken sends token as a Bearer token on every request, for backends
+// running with AUTH_MODE=required.
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithHTTPClient replaces the default HTTP client, e.g. to trust a custom CA.
+// A nil client keeps the default.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		if hc != nil {
+			c.httpClient = hc
+		}
+	}
+}
+
+// WithRetries sets how many times a request is retried after a connection
+// error or a 502/503/504 response. Zero disables retries.
+func WithRetries(n int) Option {
+	return func(c *Client) {
+		c.retries = max(n, 0)
+	}
+}
+
+// WithRetryBackoff sets the delay before the first retry. Each later retry
+// waits twice as long as the one before, up to a few seconds.
+func WithRetryBackoff(d time.Duration) Option {
+	return func(c *Client) {
+		c.retryBackoff = d
+	}
 }
 
 // New creates a new API client with the given base URL
-func New(baseURL string) *Client {
-	return &Client{
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
 		baseURL: baseURL,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout: DefaultTimeout,
 		},
+		retries:      DefaultRetries,
+		retryBackoff: defaultRetryBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// do sends req with the client's credentials attached, retrying with
+// exponential backoff on connection errors and 502/503/504 responses. Other
+// responses, including every 4xx, are returned as-is. Retries stop as soon
+// as the request's context is done.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	ctx := req.Context()
+	backoff := c.retryBackoff
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if attempt >= c.retries || ctx.Err() != nil || !isTransient(resp, err) {
+			return resp, err
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxRetryBackoff)
+	}
+}
+
+// isTransient reports whether a request outcome is worth retrying
+func isTransient(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
 	}
+	return false
 }
 
 // HealthResponse represents the /api/v1/health endpoint response
@@ -33,6 +139,7 @@ type HealthResponse struct {
 	CFAPI       string      `json:"cf_api"`
 	BOSHAPI     string      `json:"bosh_api"`
 	CacheStatus CacheStatus `json:"cache_status"`
+	Version     string      `json:"version,omitempty"`
 }
 
 // CacheStatus represents cache state in health response
@@ -62,9 +169,16 @@ type InfrastructureStatus struct {
 
 // ErrorResponse represents an API error
 type ErrorResponse struct {
-	Error   string `json:"error"`
-	Details string `json:"details,omitempty"`
-	Code    int    `json:"code"`
+	Error   string       `json:"error"`
+	Details string       `json:"details,omitempty"`
+	Code    int          `json:"code"`
+	Errors  []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError describes one invalid field reported by the backend
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
 }
 
 // ClusterState represents computed metrics for a single cluster
@@ -112,8 +226,10 @@ type InfrastructureState struct {
 	TotalAppMemoryGB             int            `json:"total_app_memory_gb"`
 	TotalAppDiskGB               int            `json:"total_app_disk_gb"`
 	TotalAppInstances            int            `json:"total_app_instances"`
+	MaxInstanceMemoryMB          int            `json:"max_instance_memory_mb"`
 	Timestamp                    string         `json:"timestamp"`
 	Cached                       bool           `json:"cached"`
+	AppDataTimestamp             string         `json:"app_data_timestamp,omitempty"` // When app totals were read from CF; empty when they came with the input
 }
 
 // ClusterInput represents user-provided cluster configuration
@@ -131,12 +247,108 @@ type ClusterInput struct {
 
 // ManualInput represents user-provided infrastructure data
 type ManualInput struct {
-	Name              string         `json:"name"`
-	Clusters          []ClusterInput `json:"clusters"`
-	PlatformVMsGB     int            `json:"platform_vms_gb"`
-	TotalAppMemoryGB  int            `json:"total_app_memory_gb"`
-	TotalAppDiskGB    int            `json:"total_app_disk_gb"`
-	TotalAppInstances int            `json:"total_app_instances"`
+	Format              string         `json:"format,omitempty"` // FormatManualInput or empty
+	Name                string         `json:"name"`
+	Clusters            []ClusterInput `json:"clusters"`
+	PlatformVMsGB       int            `json:"platform_vms_gb"`
+	TotalAppMemoryGB    int            `json:"total_app_memory_gb"`
+	TotalAppDiskGB      int            `json:"total_app_disk_gb"`
+	TotalAppInstances   int            `json:"total_app_instances"`
+	MaxInstanceMemoryMB int            `json:"max_instance_memory_mb"`
+}
+
+// ToManualInput recovers the raw cluster inputs and app totals behind a
+// computed state, mirroring the backend's InfrastructureState.ToManualInput,
+// so a discovered environment can be saved, edited, and loaded back
+func (s *InfrastructureState) ToManualInput() ManualInput {
+	mi := ManualInput{
+		Format:              FormatManualInput,
+		Name:                s.Name,
+		Clusters:            make([]ClusterInput, len(s.Clusters)),
+		PlatformVMsGB:       s.PlatformVMsGB,
+		TotalAppMemoryGB:    s.TotalAppMemoryGB,
+		TotalAppDiskGB:      s.TotalAppDiskGB,
+		TotalAppInstances:   s.TotalAppInstances,
+		MaxInstanceMemoryMB: s.MaxInstanceMemoryMB,
+	}
+
+	for i, c := range s.Clusters {
+		memoryPerHost := c.MemoryGBPerHost
+		threadsPerHost := c.CPUThreadsPerHost
+		// Older states carry only cluster totals
+		if c.HostCount > 0 {
+			if memoryPerHost == 0 {
+				memoryPerHost = c.MemoryGB / c.HostCount
+			}
+			if threadsPerHost == 0 {
+				threadsPerHost = c.CPUCores / c.HostCount
+			}
+		}
+
+		mi.Clusters[i] = ClusterInput{
+			Name:                         c.Name,
+			HostCount:                    c.HostCount,
+			MemoryGBPerHost:              memoryPerHost,
+			CPUThreadsPerHost:            threadsPerHost,
+			HAAdmissionControlPercentage: c.HAAdmissionControlPercentage,
+			DiegoCellCount:               c.DiegoCellCount,
+			DiegoCellMemoryGB:            c.DiegoCellMemoryGB,
+			DiegoCellCPU:                 c.DiegoCellCPU,
+			DiegoCellDiskGB:              c.DiegoCellDiskGB,
+		}
+	}
+
+	return mi
+}
+
+// Values of the optional top-level "format" field, which names a file's
+// format explicitly instead of leaving it to be inferred
+const (
+	FormatManualInput         = "manual_input"
+	FormatInfrastructureState = "infrastructure_state"
+)
+
+// DetectFormat returns FormatManualInput or FormatInfrastructureState for a
+// JSON file. An explicit top-level "format" field decides; without one, the
+// format is inferred from the clusters (see IsManualInputFormat).
+func DetectFormat(data []byte) (string, error) {
+	var doc struct {
+		Format   string                       `json:"format"`
+		Clusters []map[string]json.RawMessage `json:"clusters"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return "", fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	switch doc.Format {
+	case FormatManualInput, FormatInfrastructureState:
+		return doc.Format, nil
+	case "":
+	default:
+		return "", fmt.Errorf("unknown format %q (want %q or %q)", doc.Format, FormatManualInput, FormatInfrastructureState)
+	}
+
+	// Both formats' clusters may carry memory_gb_per_host, so the discriminator
+	// is memory_gb: the backend's InfrastructureState schema
+	// (GET /api/v1/schema/infrastructure-state) requires it on every cluster,
+	// and the ManualInput schema does not define it.
+	if len(doc.Clusters) == 0 {
+		return FormatInfrastructureState, nil
+	}
+	for _, cluster := range doc.Clusters {
+		if _, hasMemoryGB := cluster["memory_gb"]; hasMemoryGB {
+			return FormatInfrastructureState, nil
+		}
+	}
+	return FormatManualInput, nil
+}
+
+// IsManualInputFormat detects if JSON is ManualInput format rather than a
+// pre-computed InfrastructureState. Invalid JSON and unknown formats are not
+// ManualInput.
+func IsManualInputFormat(data []byte) bool {
+	format, err := DetectFormat(data)
+	return err == nil && format == FormatManualInput
 }
 
 // Health calls the /api/v1/health endpoint
@@ -146,7 +358,7 @@ func (c *Client) Health(ctx context.Context) (*HealthResponse, error) {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		if ctx.Err() == context.Canceled {
 			return nil, fmt.Errorf("request canceled")
@@ -181,7 +393,7 @@ func (c *Client) InfrastructureStatus(ctx context.Context) (*InfrastructureStatu
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		if ctx.Err() == context.Canceled {
 			return nil, fmt.Errorf("request canceled")
@@ -209,14 +421,24 @@ func (c *Client) InfrastructureStatus(ctx context.Context) (*InfrastructureStatu
 	return &status, nil
 }
 
-// GetInfrastructure calls GET /api/v1/infrastructure
+// GetInfrastructure calls GET /api/v1/infrastructure, which may return a cached discovery
 func (c *Client) GetInfrastructure(ctx context.Context) (*InfrastructureState, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/api/v1/infrastructure", nil)
+	return c.getInfrastructure(ctx, "/api/v1/infrastructure")
+}
+
+// RefreshInfrastructure calls GET /api/v1/infrastructure?force=true to bypass
+// the backend cache and re-run vSphere discovery
+func (c *Client) RefreshInfrastructure(ctx context.Context) (*InfrastructureState, error) {
+	return c.getInfrastructure(ctx, "/api/v1/infrastructure?force=true")
+}
+
+func (c *Client) getInfrastructure(ctx context.Context, path string) (*InfrastructureState, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, c.handleRequestError(ctx, err)
 	}
@@ -247,7 +469,7 @@ func (c *Client) SetManualInfrastructure(ctx context.Context, input *ManualInput
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, c.handleRequestError(ctx, err)
 	}
@@ -265,6 +487,257 @@ func (c *Client) SetManualInfrastructure(ctx context.Context, input *ManualInput
 	return &infra, nil
 }
 
+// ResourceUtilization represents the utilization of a single resource type
+type ResourceUtilization struct {
+	Name           string  `json:"name"`
+	UsedPercent    float64 `json:"used_percent"`
+	TotalCapacity  int     `json:"total_capacity"`
+	UsedCapacity   int     `json:"used_capacity"`
+	Unit           string  `json:"unit"`
+	IsConstraining bool    `json:"is_constraining"`
+}
+
+// BottleneckAnalysis represents the multi-resource bottleneck analysis result
+type BottleneckAnalysis struct {
+	Resources            []ResourceUtilization `json:"resources"`
+	ConstrainingResource string                `json:"constraining_resource"`
+	Summary              string                `json:"summary"`
+}
+
+// GetBottleneck calls GET /api/v1/bottleneck to rank the resources of the
+// infrastructure state the backend holds
+func (c *Client) GetBottleneck(ctx context.Context) (*BottleneckAnalysis, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/api/v1/bottleneck", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, c.handleRequestError(ctx, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleErrorResponse(resp)
+	}
+
+	var analysis BottleneckAnalysis
+	if err := json.NewDecoder(resp.Body).Decode(&analysis); err != nil {
+		return nil, fmt.Errorf("invalid response from backend: %w", err)
+	}
+
+	return &analysis, nil
+}
+
+// AnalyzeBottleneck calls POST /api/v1/bottleneck (stateless, does not store input)
+func (c *Client) AnalyzeBottleneck(ctx context.Context, input *ManualInput) (*BottleneckAnalysis, error) {
+	body, err := json.Marshal(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal input: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/v1/bottleneck", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, c.handleRequestError(ctx, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleErrorResponse(resp)
+	}
+
+	var analysis BottleneckAnalysis
+	if err := json.NewDecoder(resp.Body).Decode(&analysis); err != nil {
+		return nil, fmt.Errorf("invalid response from backend: %w", err)
+	}
+
+	return &analysis, nil
+}
+
+// WarningThresholds sets the limits used by the backend when generating warnings.
+// Zero values use the backend defaults.
+type WarningThresholds struct {
+	N1WarningPct           float64 `json:"n1_warning_pct,omitempty"`
+	N1CriticalPct          float64 `json:"n1_critical_pct,omitempty"`
+	FreeChunksWarning      int     `json:"free_chunks_warning,omitempty"`
+	FreeChunksCritical     int     `json:"free_chunks_critical,omitempty"`
+	UtilizationWarningPct  float64 `json:"utilization_warning_pct,omitempty"`
+	UtilizationCriticalPct float64 `json:"utilization_critical_pct,omitempty"`
+	DiskWarningPct         float64 `json:"disk_warning_pct,omitempty"`
+	DiskCriticalPct        float64 `json:"disk_critical_pct,omitempty"`
+	BlastRadiusWarningPct  float64 `json:"blast_radius_warning_pct,omitempty"`
+	BlastRadiusCriticalPct float64 `json:"blast_radius_critical_pct,omitempty"`
+	HostFailureWarningPct  float64 `json:"host_failure_warning_pct,omitempty"`
+	HostFailureCriticalPct float64 `json:"host_failure_critical_pct,omitempty"`
+	MinSingleChunkGB       int     `json:"min_single_chunk_gb,omitempty"`
+}
+
+// DefaultWarningThresholds returns the backend's built-in limits, matching
+// models.DefaultThresholds. Prefer GetThresholds, which reflects the backend's
+// THRESHOLDS overrides; this is the fallback when it is unavailable.
+func DefaultWarningThresholds() WarningThresholds {
+	return WarningThresholds{
+		N1WarningPct:           75,
+		N1CriticalPct:          85,
+		FreeChunksWarning:      20,
+		FreeChunksCritical:     10,
+		UtilizationWarningPct:  80,
+		UtilizationCriticalPct: 90,
+		DiskWarningPct:         80,
+		DiskCriticalPct:        90,
+		BlastRadiusWarningPct:  10,
+		BlastRadiusCriticalPct: 20,
+		HostFailureWarningPct:  30,
+		HostFailureCriticalPct: 40,
+		MinSingleChunkGB:       4,
+	}
+}
+
+// Thresholds is every capacity threshold the backend applies, as returned by
+// GET /api/v1/thresholds
+type Thresholds struct {
+	WarningThresholds
+	VCPURatioModerate   float64 `json:"vcpu_ratio_moderate"`   // CPU risk is medium above this vCPU:pCPU ratio
+	VCPURatioAggressive float64 `json:"vcpu_ratio_aggressive"` // CPU risk is high above this vCPU:pCPU ratio
+}
+
+// DefaultThresholds returns the backend's built-in thresholds
+func DefaultThresholds() Thresholds {
+	return Thresholds{
+		WarningThresholds:   DefaultWarningThresholds(),
+		VCPURatioModerate:   4,
+		VCPURatioAggressive: 8,
+	}
+}
+
+// CPURiskLevel classifies a vCPU:pCPU ratio as "low", "medium", or "high"
+// against these tiers, as the backend sets cpu_risk_level
+func (t Thresholds) CPURiskLevel(ratio float64) string {
+	switch {
+	case ratio <= t.VCPURatioModerate:
+		return "low"
+	case ratio <= t.VCPURatioAggressive:
+		return "medium"
+	default:
+		return "high"
+	}
+}
+
+// GetThresholds calls GET /api/v1/thresholds for the backend's effective
+// capacity thresholds
+func (c *Client) GetThresholds(ctx context.Context) (*Thresholds, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/api/v1/thresholds", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, c.handleRequestError(ctx, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleErrorResponse(resp)
+	}
+
+	var thresholds Thresholds
+	if err := json.NewDecoder(resp.Body).Decode(&thresholds); err != nil {
+		return nil, fmt.Errorf("invalid response from backend: %w", err)
+	}
+
+	return &thresholds, nil
+}
+
+// MetricDefinition explains one computed metric and its formula
+type MetricDefinition struct {
+	Key         string `json:"key"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Formula     string `json:"formula"`
+}
+
+// GetMetricGlossary calls GET /api/v1/metrics/glossary for the definitions of
+// the metrics shown on the dashboard and comparison screens
+func (c *Client) GetMetricGlossary(ctx context.Context) ([]MetricDefinition, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/api/v1/metrics/glossary", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, c.handleRequestError(ctx, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleErrorResponse(resp)
+	}
+
+	var glossary struct {
+		Metrics []MetricDefinition `json:"metrics"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&glossary); err != nil {
+		return nil, fmt.Errorf("invalid response from backend: %w", err)
+	}
+
+	return glossary.Metrics, nil
+}
+
+// CheckRequest is the request body for POST /api/v1/check
+type CheckRequest struct {
+	Input      *ManualInput      `json:"input"`
+	Thresholds WarningThresholds `json:"thresholds"`
+}
+
+// CheckResponse represents the threshold check result
+type CheckResponse struct {
+	Status         string              `json:"status"`
+	Current        ScenarioResult      `json:"current"`
+	Thresholds     WarningThresholds   `json:"thresholds"`
+	Warnings       []ScenarioWarning   `json:"warnings"`
+	Infrastructure InfrastructureState `json:"infrastructure"`
+}
+
+// CheckThresholds calls POST /api/v1/check (stateless, does not store input)
+func (c *Client) CheckThresholds(ctx context.Context, input *ManualInput, thresholds WarningThresholds) (*CheckResponse, error) {
+	body, err := json.Marshal(CheckRequest{Input: input, Thresholds: thresholds})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal input: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/v1/check", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, c.handleRequestError(ctx, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleErrorResponse(resp)
+	}
+
+	var result CheckResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("invalid response from backend: %w", err)
+	}
+
+	return &result, nil
+}
+
 // handleRequestError converts context errors to user-friendly messages
 func (c *Client) handleRequestError(ctx context.Context, err error) error {
 	if ctx.Err() == context.Canceled {
@@ -282,6 +755,13 @@ func (c *Client) handleErrorResponse(resp *http.Response) error {
 	if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil {
 		return fmt.Errorf("backend returned status %d", resp.StatusCode)
 	}
+	if len(errResp.Errors) > 0 {
+		fields := make([]string, len(errResp.Errors))
+		for i, fe := range errResp.Errors {
+			fields[i] = fe.Field + " " + fe.Message
+		}
+		return fmt.Errorf("backend error: %s: %s", errResp.Error, strings.Join(fields, "; "))
+	}
 	return fmt.Errorf("backend error: %s", errResp.Error)
 }
 
@@ -300,6 +780,7 @@ type ScenarioInput struct {
 	PhysicalCoresPerHost int      `json:"physical_cores_per_host"`
 	TargetVCPURatio      int      `json:"target_vcpu_ratio"`
 	PlatformVMsCPU       int      `json:"platform_vms_cpu"`
+	HostsToRemove        int      `json:"hosts_to_remove,omitempty"`
 }
 
 // ScenarioResult represents computed metrics for a scenario
@@ -311,6 +792,7 @@ type ScenarioResult struct {
 	AppCapacityGB    int     `json:"app_capacity_gb"`
 	UtilizationPct   float64 `json:"utilization_pct"`
 	FreeChunks       int     `json:"free_chunks"`
+	MaxSingleChunkGB int     `json:"max_single_chunk_gb"`
 	N1UtilizationPct float64 `json:"n1_utilization_pct"`
 	FaultImpact      int     `json:"fault_impact"`
 	BlastRadiusPct   float64 `json:"blast_radius_pct"`
@@ -318,6 +800,10 @@ type ScenarioResult struct {
 	TotalPCPUs       int     `json:"total_pcpus"`
 	VCPURatio        float64 `json:"vcpu_ratio"`
 	CPURiskLevel     string  `json:"cpu_risk_level"`
+	HostsRemoved     int     `json:"hosts_removed"`
+	CellsToEvacuate  int     `json:"cells_to_evacuate"`
+	EstimatedTPS     int     `json:"estimated_tps"`
+	TPSStatus        string  `json:"tps_status"` // "optimal", "degraded", "critical"
 }
 
 // ScenarioDelta represents changes between current and proposed
@@ -331,15 +817,69 @@ type ScenarioDelta struct {
 // ScenarioWarning represents a tradeoff warning
 type ScenarioWarning struct {
 	Severity string `json:"severity"`
+	Code     string `json:"code"` // Stable check identifier, e.g. "n1_exceeded"
 	Message  string `json:"message"`
 }
 
 // ScenarioComparison represents full comparison response
 type ScenarioComparison struct {
-	Current  ScenarioResult    `json:"current"`
-	Proposed ScenarioResult    `json:"proposed"`
-	Delta    ScenarioDelta     `json:"delta"`
-	Warnings []ScenarioWarning `json:"warnings"`
+	Current         ScenarioResult    `json:"current"`
+	Proposed        ScenarioResult    `json:"proposed"`
+	Delta           ScenarioDelta     `json:"delta"`
+	Warnings        []ScenarioWarning `json:"warnings"`
+	Recommendations []Recommendation  `json:"recommendations,omitempty"`
+}
+
+// Recommendation represents an upgrade path suggested by the backend
+type Recommendation struct {
+	Type          string         `json:"type"`
+	Priority      int            `json:"priority"`
+	Title         string         `json:"title"`
+	Description   string         `json:"description"`
+	Impact        string         `json:"impact"`
+	EstimatedCost *EstimatedCost `json:"estimated_cost,omitempty"`
+}
+
+// EstimatedCost is the relative cost of acting on a recommendation
+type EstimatedCost struct {
+	HostCountDelta int    `json:"host_count_delta"`
+	MemoryGBDelta  int    `json:"memory_gb_delta"`
+	Tier           string `json:"tier"`
+}
+
+// ScenarioSweep holds scenario results for a range of proposed cell counts
+type ScenarioSweep struct {
+	MinCells int              `json:"min_cells"`
+	MaxCells int              `json:"max_cells"`
+	Step     int              `json:"step"`
+	Results  []ScenarioResult `json:"results"`
+}
+
+// GetScenarioSweep calls GET /api/v1/scenario/sweep, evaluating the current
+// infrastructure at every cell count from minCells to maxCells by step
+func (c *Client) GetScenarioSweep(ctx context.Context, minCells, maxCells, step int) (*ScenarioSweep, error) {
+	endpoint := fmt.Sprintf("%s/api/v1/scenario/sweep?min=%d&max=%d&step=%d", c.baseURL, minCells, maxCells, step)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, c.handleRequestError(ctx, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleErrorResponse(resp)
+	}
+
+	var sweep ScenarioSweep
+	if err := json.NewDecoder(resp.Body).Decode(&sweep); err != nil {
+		return nil, fmt.Errorf("invalid response from backend: %w", err)
+	}
+
+	return &sweep, nil
 }
 
 // SetInfrastructureState calls POST /api/v1/infrastructure/state
@@ -355,7 +895,7 @@ func (c *Client) SetInfrastructureState(ctx context.Context, state *Infrastructu
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, c.handleRequestError(ctx, err)
 	}
@@ -386,7 +926,7 @@ func (c *Client) CompareScenario(ctx context.Context, input *ScenarioInput) (*Sc
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, c.handleRequestError(ctx, err)
 	}
@@ -8,6 +8,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 )
@@ -208,6 +209,29 @@ func TestGetInfrastructure(t *testing.T) {
 	}
 }
 
+func TestRefreshInfrastructure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/infrastructure" {
+			t.Errorf("expected path /api/v1/infrastructure, got %s", r.URL.Path)
+		}
+		if r.URL.Query().Get("force") != "true" {
+			t.Errorf("expected force=true, got query %q", r.URL.RawQuery)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(InfrastructureState{Source: "vsphere", TotalHostCount: 4})
+	}))
+	defer server.Close()
+
+	c := New(server.URL)
+	infra, err := c.RefreshInfrastructure(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if infra.TotalHostCount != 4 {
+		t.Errorf("expected 4 hosts, got %d", infra.TotalHostCount)
+	}
+}
+
 func TestSetManualInfrastructure(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path != "/api/v1/infrastructure/manual" {
@@ -256,6 +280,260 @@ func TestSetManualInfrastructure(t *testing.T) {
 	}
 }
 
+func TestSetManualInfrastructure_MultipleClusters(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var input ManualInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		if len(input.Clusters) != 2 {
+			t.Fatalf("expected 2 clusters in request, got %d", len(input.Clusters))
+		}
+
+		state := InfrastructureState{Source: "manual", Name: input.Name}
+		for _, c := range input.Clusters {
+			state.Clusters = append(state.Clusters, ClusterState{Name: c.Name, HostCount: c.HostCount})
+			state.TotalHostCount += c.HostCount
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(state)
+	}))
+	defer server.Close()
+
+	c := New(server.URL)
+	input := &ManualInput{
+		Name: "Multi",
+		Clusters: []ClusterInput{
+			{Name: "cluster-01", HostCount: 4, MemoryGBPerHost: 512, DiegoCellCount: 10, DiegoCellMemoryGB: 64, DiegoCellCPU: 8},
+			{Name: "cluster-02", HostCount: 6, MemoryGBPerHost: 512, DiegoCellCount: 12, DiegoCellMemoryGB: 64, DiegoCellCPU: 8},
+		},
+	}
+
+	infra, err := c.SetManualInfrastructure(context.Background(), input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(infra.Clusters) != 2 {
+		t.Fatalf("expected 2 clusters, got %d", len(infra.Clusters))
+	}
+	if infra.Clusters[1].Name != "cluster-02" {
+		t.Errorf("expected second cluster cluster-02, got %s", infra.Clusters[1].Name)
+	}
+	if infra.TotalHostCount != 10 {
+		t.Errorf("expected TotalHostCount 10, got %d", infra.TotalHostCount)
+	}
+}
+
+func TestSetManualInfrastructure_ValidationErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{
+			Error: "Invalid infrastructure input",
+			Code:  http.StatusBadRequest,
+			Errors: []FieldError{
+				{Field: "clusters[0].host_count", Message: "must be greater than 0"},
+				{Field: "clusters[0].diego_cell_memory_gb", Message: "must be greater than 0"},
+			},
+		})
+	}))
+	defer server.Close()
+
+	c := New(server.URL)
+	_, err := c.SetManualInfrastructure(context.Background(), &ManualInput{Name: "Bad"})
+	if err == nil {
+		t.Fatal("expected validation error, got nil")
+	}
+	want := "backend error: Invalid infrastructure input: clusters[0].host_count must be greater than 0; clusters[0].diego_cell_memory_gb must be greater than 0"
+	if err.Error() != want {
+		t.Errorf("expected %q, got %q", want, err.Error())
+	}
+}
+
+func TestGetBottleneck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/bottleneck" {
+			t.Errorf("expected path /api/v1/bottleneck, got %s", r.URL.Path)
+		}
+		if r.Method != http.MethodGet {
+			t.Errorf("expected GET, got %s", r.Method)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"resources":[{"name":"CPU","used_percent":71.5,"is_constraining":true}],"constraining_resource":"CPU"}`))
+	}))
+	defer server.Close()
+
+	c := New(server.URL)
+	analysis, err := c.GetBottleneck(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if analysis.ConstrainingResource != "CPU" || len(analysis.Resources) != 1 || analysis.Resources[0].UsedPercent != 71.5 {
+		t.Errorf("unexpected analysis: %+v", analysis)
+	}
+}
+
+func TestAnalyzeBottleneck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/bottleneck" {
+			t.Errorf("expected path /api/v1/bottleneck, got %s", r.URL.Path)
+		}
+		if r.Method != http.MethodPost {
+			t.Errorf("expected POST, got %s", r.Method)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(BottleneckAnalysis{
+			Resources: []ResourceUtilization{
+				{Name: "Memory", UsedPercent: 82.5, IsConstraining: true},
+			},
+			ConstrainingResource: "Memory",
+		})
+	}))
+	defer server.Close()
+
+	c := New(server.URL)
+	analysis, err := c.AnalyzeBottleneck(context.Background(), &ManualInput{Name: "Test Infra"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if analysis.ConstrainingResource != "Memory" {
+		t.Errorf("expected constraining resource Memory, got %s", analysis.ConstrainingResource)
+	}
+	if len(analysis.Resources) != 1 || analysis.Resources[0].UsedPercent != 82.5 {
+		t.Errorf("unexpected resources: %+v", analysis.Resources)
+	}
+}
+
+func TestCheckThresholds(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/check" {
+			t.Errorf("expected path /api/v1/check, got %s", r.URL.Path)
+		}
+		if r.Method != http.MethodPost {
+			t.Errorf("expected POST, got %s", r.Method)
+		}
+
+		var req CheckRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		if req.Input == nil || req.Input.Name != "Test Infra" {
+			t.Errorf("expected input name 'Test Infra', got %+v", req.Input)
+		}
+		if req.Thresholds.FreeChunksCritical != 400 {
+			t.Errorf("expected free_chunks_critical 400, got %d", req.Thresholds.FreeChunksCritical)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(CheckResponse{
+			Status:   "critical",
+			Warnings: []ScenarioWarning{{Severity: "critical", Message: "Critical: Low staging capacity"}},
+		})
+	}))
+	defer server.Close()
+
+	c := New(server.URL)
+	result, err := c.CheckThresholds(context.Background(), &ManualInput{Name: "Test Infra"}, WarningThresholds{FreeChunksCritical: 400})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Status != "critical" {
+		t.Errorf("expected status critical, got %s", result.Status)
+	}
+	if len(result.Warnings) != 1 {
+		t.Errorf("expected 1 warning, got %d", len(result.Warnings))
+	}
+}
+
+func TestGetThresholds(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/thresholds" {
+			t.Errorf("expected path /api/v1/thresholds, got %s", r.URL.Path)
+		}
+		if r.Method != http.MethodGet {
+			t.Errorf("expected GET, got %s", r.Method)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"n1_warning_pct":70,"n1_critical_pct":80,"free_chunks_warning":400,"free_chunks_critical":200,"vcpu_ratio_moderate":3,"vcpu_ratio_aggressive":6}`))
+	}))
+	defer server.Close()
+
+	c := New(server.URL)
+	thresholds, err := c.GetThresholds(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if thresholds.N1CriticalPct != 80 || thresholds.FreeChunksCritical != 200 {
+		t.Errorf("expected N-1 critical 80 and free chunks critical 200, got %+v", thresholds.WarningThresholds)
+	}
+	if thresholds.VCPURatioModerate != 3 || thresholds.VCPURatioAggressive != 6 {
+		t.Errorf("expected vCPU tiers 3/6, got %v/%v", thresholds.VCPURatioModerate, thresholds.VCPURatioAggressive)
+	}
+}
+
+func TestThresholds_CPURiskLevel(t *testing.T) {
+	thresholds := Thresholds{VCPURatioModerate: 3, VCPURatioAggressive: 6}
+	for ratio, want := range map[float64]string{2: "low", 3: "low", 4: "medium", 6: "medium", 6.5: "high"} {
+		if got := thresholds.CPURiskLevel(ratio); got != want {
+			t.Errorf("CPURiskLevel(%v) = %q, want %q", ratio, got, want)
+		}
+	}
+}
+
+func TestGetScenarioSweep(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/scenario/sweep" {
+			t.Errorf("expected path /api/v1/scenario/sweep, got %s", r.URL.Path)
+		}
+		if got := r.URL.RawQuery; got != "min=1&max=21&step=10" {
+			t.Errorf("expected min, max, and step in the query, got %q", got)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"min_cells":1,"max_cells":21,"step":10,"results":[{"cell_count":1,"estimated_tps":284,"tps_status":"critical"},{"cell_count":11,"estimated_tps":1922,"tps_status":"optimal"},{"cell_count":21,"estimated_tps":1866,"tps_status":"optimal"}]}`))
+	}))
+	defer server.Close()
+
+	c := New(server.URL)
+	sweep, err := c.GetScenarioSweep(context.Background(), 1, 21, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sweep.Results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(sweep.Results))
+	}
+	if r := sweep.Results[1]; r.CellCount != 11 || r.EstimatedTPS != 1922 || r.TPSStatus != "optimal" {
+		t.Errorf("expected 11 cells at 1922 TPS (optimal), got %+v", r)
+	}
+}
+
+func TestGetMetricGlossary(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/metrics/glossary" {
+			t.Errorf("expected path /api/v1/metrics/glossary, got %s", r.URL.Path)
+		}
+		if r.Method != http.MethodGet {
+			t.Errorf("expected GET, got %s", r.Method)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"metrics":[{"key":"fault_impact","name":"Fault impact","description":"Instances lost per cell","formula":"app instances ÷ cells, rounded"}]}`))
+	}))
+	defer server.Close()
+
+	c := New(server.URL)
+	glossary, err := c.GetMetricGlossary(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(glossary) != 1 || glossary[0].Key != "fault_impact" || glossary[0].Formula != "app instances ÷ cells, rounded" {
+		t.Errorf("unexpected glossary: %+v", glossary)
+	}
+}
+
 func TestSetInfrastructureState(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path != "/api/v1/infrastructure/state" {
@@ -357,3 +635,381 @@ func TestCompareScenario(t *testing.T) {
 		t.Errorf("expected proposed cell count 15, got %d", result.Proposed.CellCount)
 	}
 }
+
+func TestIsManualInputFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		json     string
+		expected bool
+	}{
+		{
+			name: "ManualInput format",
+			json: `{
+				"name": "Test",
+				"clusters": [{"name": "c1", "host_count": 4, "memory_gb_per_host": 256}]
+			}`,
+			expected: true,
+		},
+		{
+			name: "InfrastructureState format",
+			json: `{
+				"name": "Test",
+				"clusters": [{"name": "c1", "host_count": 4, "memory_gb": 1024}],
+				"total_host_count": 4
+			}`,
+			expected: false,
+		},
+		{
+			name: "InfrastructureState exported by the backend",
+			json: `{
+				"name": "Test",
+				"clusters": [{"name": "c1", "host_count": 4, "memory_gb": 1024, "memory_gb_per_host": 256}],
+				"total_host_count": 4
+			}`,
+			expected: false,
+		},
+		{
+			name: "ManualInput with $schema and extra keys",
+			json: `{
+				"$schema": "http://localhost:8080/api/v1/schema/manual-input",
+				"name": "Test",
+				"description": "lab foundation",
+				"clusters": [{"name": "c1", "host_count": 4, "memory_gb_per_host": 256}]
+			}`,
+			expected: true,
+		},
+		{
+			name:     "Empty clusters",
+			json:     `{"name": "Test", "clusters": []}`,
+			expected: false,
+		},
+		{
+			name:     "Invalid JSON",
+			json:     `{invalid}`,
+			expected: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result := IsManualInputFormat([]byte(tc.json))
+			if result != tc.expected {
+				t.Errorf("IsManualInputFormat() = %v, want %v", result, tc.expected)
+			}
+		})
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "explicit manual_input wins over state-shaped clusters",
+			json: `{"format": "manual_input", "clusters": [{"name": "c1", "memory_gb": 1024}]}`,
+			want: FormatManualInput,
+		},
+		{
+			name: "explicit infrastructure_state wins over manual-shaped clusters",
+			json: `{"format": "infrastructure_state", "clusters": [{"name": "c1", "memory_gb_per_host": 256}]}`,
+			want: FormatInfrastructureState,
+		},
+		{
+			name: "heuristic manual input",
+			json: `{"clusters": [{"name": "c1", "memory_gb_per_host": 256}]}`,
+			want: FormatManualInput,
+		},
+		{
+			name: "heuristic infrastructure state",
+			json: `{"clusters": [{"name": "c1", "memory_gb": 1024}]}`,
+			want: FormatInfrastructureState,
+		},
+		{
+			name:    "unknown format",
+			json:    `{"format": "csv", "clusters": [{"name": "c1", "memory_gb_per_host": 256}]}`,
+			wantErr: "unknown format",
+		},
+		{
+			name:    "invalid JSON",
+			json:    `{invalid}`,
+			wantErr: "invalid JSON",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := DetectFormat([]byte(tc.json))
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("DetectFormat() error = %v, want it to contain %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DetectFormat() error = %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("DetectFormat() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestWithToken_SendsBearerHeader(t *testing.T) {
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/health":
+			json.NewEncoder(w).Encode(HealthResponse{CFAPI: "ok"})
+		default:
+			json.NewEncoder(w).Encode(InfrastructureState{Source: "manual"})
+		}
+	}))
+	defer server.Close()
+
+	c := New(server.URL, WithToken("secret-token"))
+	if _, err := c.Health(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := c.SetManualInfrastructure(context.Background(), &ManualInput{Name: "x"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for i, header := range got {
+		if header != "Bearer secret-token" {
+			t.Errorf("request %d: expected Bearer header, got %q", i, header)
+		}
+	}
+}
+
+func TestNew_NoTokenOmitsAuthHeader(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "" {
+			t.Errorf("expected no Authorization header, got %q", auth)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(HealthResponse{CFAPI: "ok"})
+	}))
+	defer server.Close()
+
+	if _, err := New(server.URL).Health(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestWithHTTPClient(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(HealthResponse{CFAPI: "ok"})
+	}))
+	defer server.Close()
+
+	// The default client does not trust the test server's certificate
+	if _, err := New(server.URL, WithRetries(0)).Health(context.Background()); err == nil {
+		t.Error("expected TLS verification to fail with the default client")
+	}
+
+	c := New(server.URL, WithHTTPClient(server.Client()))
+	if _, err := c.Health(context.Background()); err != nil {
+		t.Errorf("unexpected error with custom HTTP client: %v", err)
+	}
+
+	// A nil client keeps the default
+	if New(server.URL, WithHTTPClient(nil)).httpClient == nil {
+		t.Error("expected nil HTTP client to be ignored")
+	}
+}
+
+func TestRetry_TransientStatusThenSuccess(t *testing.T) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		// The request body must be resent on every attempt
+		var input ManualInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.Name != "Retry" {
+			t.Errorf("attempt %d: expected request body, got %+v (%v)", attempts, input, err)
+		}
+		if attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(InfrastructureState{Source: "manual", Name: input.Name})
+	}))
+	defer server.Close()
+
+	c := New(server.URL, WithRetryBackoff(time.Millisecond))
+	infra, err := c.SetManualInfrastructure(context.Background(), &ManualInput{Name: "Retry"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", attempts)
+	}
+	if infra.Name != "Retry" {
+		t.Errorf("expected name Retry, got %s", infra.Name)
+	}
+}
+
+func TestRetry_GivesUpAfterRetries(t *testing.T) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	c := New(server.URL, WithRetries(2), WithRetryBackoff(time.Millisecond))
+	if _, err := c.Health(context.Background()); err == nil {
+		t.Error("expected an error after retries are exhausted")
+	}
+	if attempts != 3 {
+		t.Errorf("expected 1 attempt plus 2 retries, got %d", attempts)
+	}
+}
+
+func TestRetry_NeverRetriesClientErrors(t *testing.T) {
+	for _, status := range []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusInternalServerError} {
+		var attempts int
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			w.WriteHeader(status)
+		}))
+
+		c := New(server.URL, WithRetryBackoff(time.Millisecond))
+		c.Health(context.Background())
+		server.Close()
+
+		if attempts != 1 {
+			t.Errorf("status %d: expected 1 attempt, got %d", status, attempts)
+		}
+	}
+}
+
+func TestRetry_ConnectionError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := server.URL
+	server.Close()
+
+	start := time.Now()
+	c := New(url, WithRetries(2), WithRetryBackoff(10*time.Millisecond))
+	if _, err := c.Health(context.Background()); err == nil {
+		t.Fatal("expected a connection error")
+	}
+	// Two backoffs of 10ms and 20ms
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("expected connection errors to be retried, returned after %s", elapsed)
+	}
+}
+
+func TestRetry_StopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var attempts int
+	hit := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+		hit <- struct{}{}
+	}))
+	defer server.Close()
+
+	// An hour-long backoff only ends early if cancellation is respected
+	c := New(server.URL, WithRetryBackoff(time.Hour))
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.Health(ctx)
+		done <- err
+	}()
+
+	<-hit
+	cancel()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("expected an error after cancellation")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("retry did not stop when the context was cancelled")
+	}
+	if attempts != 1 {
+		t.Errorf("expected 1 attempt, got %d", attempts)
+	}
+}
+
+func TestInfrastructureStateToManualInput(t *testing.T) {
+	state := InfrastructureState{
+		Name: "discovered",
+		Clusters: []ClusterState{
+			{Name: "c1", HostCount: 4, MemoryGB: 2048, CPUCores: 256, MemoryGBPerHost: 512, CPUThreadsPerHost: 64,
+				HAAdmissionControlPercentage: 25, DiegoCellCount: 20, DiegoCellMemoryGB: 64, DiegoCellCPU: 8, DiegoCellDiskGB: 200},
+			{Name: "legacy", HostCount: 2, MemoryGB: 1024, CPUCores: 96, DiegoCellCount: 4, DiegoCellMemoryGB: 32},
+		},
+		PlatformVMsGB:     300,
+		TotalAppMemoryGB:  900,
+		TotalAppDiskGB:    1500,
+		TotalAppInstances: 400,
+	}
+
+	input := state.ToManualInput()
+
+	want := ClusterInput{Name: "c1", HostCount: 4, MemoryGBPerHost: 512, CPUThreadsPerHost: 64,
+		HAAdmissionControlPercentage: 25, DiegoCellCount: 20, DiegoCellMemoryGB: 64, DiegoCellCPU: 8, DiegoCellDiskGB: 200}
+	if input.Clusters[0] != want {
+		t.Errorf("expected %+v, got %+v", want, input.Clusters[0])
+	}
+	if c := input.Clusters[1]; c.MemoryGBPerHost != 512 || c.CPUThreadsPerHost != 48 {
+		t.Errorf("expected per-host figures derived from totals, got %d GB and %d threads", c.MemoryGBPerHost, c.CPUThreadsPerHost)
+	}
+	if input.PlatformVMsGB != 300 || input.TotalAppMemoryGB != 900 || input.TotalAppDiskGB != 1500 || input.TotalAppInstances != 400 {
+		t.Errorf("expected app totals carried over, got %+v", input)
+	}
+
+	data, err := json.Marshal(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format, err := DetectFormat(data); err != nil || format != FormatManualInput {
+		t.Errorf("expected exported file detected as %q, got %q (err %v)", FormatManualInput, format, err)
+	}
+}
+
+// A state decoded from the backend exports a manual input that carries the
+// largest instance size, so re-importing it keeps the same chunk size
+func TestInfrastructureStateToManualInput_RoundTrip(t *testing.T) {
+	backendJSON := `{"name":"discovered","clusters":[{"name":"c1","host_count":4,"memory_gb_per_host":512,"cpu_threads_per_host":64,"diego_cell_count":20,"diego_cell_memory_gb":64}],"total_app_memory_gb":900,"total_app_instances":400,"max_instance_memory_mb":6144}`
+
+	var state InfrastructureState
+	if err := json.Unmarshal([]byte(backendJSON), &state); err != nil {
+		t.Fatal(err)
+	}
+	if state.MaxInstanceMemoryMB != 6144 {
+		t.Fatalf("expected max_instance_memory_mb decoded from state, got %d", state.MaxInstanceMemoryMB)
+	}
+
+	data, err := json.Marshal(state.ToManualInput())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	if fields["max_instance_memory_mb"] != float64(6144) {
+		t.Errorf("expected max_instance_memory_mb 6144 in the export, got %v", fields["max_instance_memory_mb"])
+	}
+
+	var input ManualInput
+	if err := json.Unmarshal(data, &input); err != nil {
+		t.Fatal(err)
+	}
+	if input.MaxInstanceMemoryMB != 6144 || input.TotalAppInstances != 400 || len(input.Clusters) != 1 {
+		t.Errorf("expected the export to load back unchanged, got %+v", input)
+	}
+}
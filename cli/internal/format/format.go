@@ -0,0 +1,91 @@
+// ABOUTME: Human-readable formatting of memory sizes, counts, and ages for CLI output
+// ABOUTME: Renders GB values as "512 GB" or "10.5 TB" with thousands separators
+
+package format
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// gbPerTB matches the backend's binary units, where 1 TB is 1024 GB
+const gbPerTB = 1024
+
+// GB renders a size in GB, switching to TB with one decimal place at
+// 1024 GB: 512 GB, 10.5 TB, 2 TB
+func GB(gb float64) string {
+	if gb >= gbPerTB || gb <= -gbPerTB {
+		return Decimal(gb/gbPerTB, 1) + " TB"
+	}
+	return Decimal(gb, 0) + " GB"
+}
+
+// GBInt is GB for integer sizes
+func GBInt(gb int) string {
+	return GB(float64(gb))
+}
+
+// SignedGB is GB with a leading + for positive sizes, for capacity deltas
+func SignedGB(gb int) string {
+	if gb > 0 {
+		return "+" + GBInt(gb)
+	}
+	return GBInt(gb)
+}
+
+// Int renders n with comma thousands separators: 1234567 becomes 1,234,567
+func Int(n int) string {
+	return Decimal(float64(n), 0)
+}
+
+// Decimal renders v with the given number of decimal places and comma
+// thousands separators, dropping a fractional part that rounds to zero
+func Decimal(v float64, places int) string {
+	s := strconv.FormatFloat(v, 'f', places, 64)
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if strings.Trim(frac, "0") == "" {
+		frac = ""
+	}
+	if whole == "0" && frac == "" {
+		sign = ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString(sign)
+	for i, r := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteRune(r)
+	}
+	if frac != "" {
+		sb.WriteByte('.')
+		sb.WriteString(frac)
+	}
+	return sb.String()
+}
+
+// Age renders how long ago something happened: "just now" under 5 seconds,
+// then "42s ago", "3m ago", or "2h ago"
+func Age(d time.Duration) string {
+	if d < time.Minute {
+		secs := int(d.Seconds())
+		if secs < 5 {
+			return "just now"
+		}
+		return fmt.Sprintf("%ds ago", secs)
+	}
+
+	if d < time.Hour {
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	}
+
+	return fmt.Sprintf("%dh ago", int(d.Hours()))
+}
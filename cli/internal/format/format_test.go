@@ -0,0 +1,81 @@
+// ABOUTME: Tests for human-readable memory size, count, and age formatting
+// ABOUTME: Covers GB/TB switching, thousands separators, signed deltas, and ages
+
+package format
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGB(t *testing.T) {
+	tests := []struct {
+		gb   float64
+		want string
+	}{
+		{0, "0 GB"},
+		{512, "512 GB"},
+		{1023.4, "1,023 GB"},
+		{1024, "1 TB"},
+		{10752, "10.5 TB"},
+		{1048576, "1,024 TB"},
+		{-2048, "-2 TB"},
+		{-0.2, "0 GB"},
+	}
+	for _, tt := range tests {
+		if got := GB(tt.gb); got != tt.want {
+			t.Errorf("GB(%v) = %q, want %q", tt.gb, got, tt.want)
+		}
+	}
+}
+
+func TestSignedGB(t *testing.T) {
+	tests := []struct {
+		gb   int
+		want string
+	}{
+		{300, "+300 GB"},
+		{0, "0 GB"},
+		{-1536, "-1.5 TB"},
+	}
+	for _, tt := range tests {
+		if got := SignedGB(tt.gb); got != tt.want {
+			t.Errorf("SignedGB(%d) = %q, want %q", tt.gb, got, tt.want)
+		}
+	}
+}
+
+func TestInt(t *testing.T) {
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{0, "0"},
+		{999, "999"},
+		{1000, "1,000"},
+		{1234567, "1,234,567"},
+		{-45000, "-45,000"},
+	}
+	for _, tt := range tests {
+		if got := Int(tt.n); got != tt.want {
+			t.Errorf("Int(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestAge(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{2 * time.Second, "just now"},
+		{42 * time.Second, "42s ago"},
+		{3*time.Minute + 20*time.Second, "3m ago"},
+		{2*time.Hour + 5*time.Minute, "2h ago"},
+	}
+	for _, tt := range tests {
+		if got := Age(tt.d); got != tt.want {
+			t.Errorf("Age(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+// ABOUTME: Webhook notifications for capacity threshold breaches
+// ABOUTME: Posts check results as JSON or Slack messages, once per status change
+
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/client"
+)
+
+// Payload formats accepted by NotifyWebhook
+const (
+	FormatJSON  = "json"
+	FormatSlack = "slack"
+)
+
+// webhookClient bounds each delivery so a hung endpoint cannot stall the watch loop
+var webhookClient = &http.Client{Timeout: 10 * time.Second}
+
+// Alert describes a change in capacity status worth notifying about
+type Alert struct {
+	Environment          string                   `json:"environment"`
+	Status               string                   `json:"status"`          // passed, warning, or critical
+	PreviousStatus       string                   `json:"previous_status"` // Empty on the first notification
+	ConstrainingResource string                   `json:"constraining_resource,omitempty"`
+	ConstrainingPct      float64                  `json:"constraining_pct,omitempty"`
+	N1UtilizationPct     float64                  `json:"n1_utilization_pct"`
+	FreeChunks           int                      `json:"free_chunks"`
+	MaxSingleChunkGB     int                      `json:"max_single_chunk_gb"`
+	Thresholds           client.WarningThresholds `json:"thresholds"`
+	Warnings             []client.ScenarioWarning `json:"warnings"`
+	Time                 time.Time                `json:"time"`
+}
+
+// NewAlert builds an alert from a threshold check and, when available, the
+// bottleneck analysis naming the constraining resource
+func NewAlert(environment string, check *client.CheckResponse, analysis *client.BottleneckAnalysis) Alert {
+	alert := Alert{
+		Environment:      environment,
+		Status:           check.Status,
+		N1UtilizationPct: check.Current.N1UtilizationPct,
+		FreeChunks:       check.Current.FreeChunks,
+		MaxSingleChunkGB: check.Current.MaxSingleChunkGB,
+		Thresholds:       check.Thresholds,
+		Warnings:         check.Warnings,
+		Time:             time.Now().UTC(),
+	}
+	if alert.Warnings == nil {
+		alert.Warnings = []client.ScenarioWarning{}
+	}
+	if analysis != nil {
+		for _, r := range analysis.Resources {
+			if r.IsConstraining {
+				alert.ConstrainingResource = r.Name
+				alert.ConstrainingPct = r.UsedPercent
+				break
+			}
+		}
+		if alert.ConstrainingResource == "" {
+			alert.ConstrainingResource = analysis.ConstrainingResource
+		}
+	}
+	return alert
+}
+
+// Tracker de-duplicates alerts across repeated checks. It reports a status
+// only when it differs from the last one delivered, so a platform that stays
+// critical notifies once, and again when it recovers or changes severity.
+type Tracker struct {
+	last string
+}
+
+// Previous returns the last delivered status, or empty before any delivery
+func (t *Tracker) Previous() string {
+	return t.last
+}
+
+// ShouldNotify reports whether status is a change worth delivering. A
+// passing status is not announced until an earlier breach was delivered.
+func (t *Tracker) ShouldNotify(status string) bool {
+	if status == t.last {
+		return false
+	}
+	return t.last != "" || status == "warning" || status == "critical"
+}
+
+// Delivered records status as the last one sent. Callers skip this when
+// delivery fails so the next check retries.
+func (t *Tracker) Delivered(status string) {
+	t.last = status
+}
+
+// NotifyWebhook POSTs alert to url as JSON, or as a Slack incoming webhook
+// message when format is FormatSlack. Non-2xx responses are errors.
+func NotifyWebhook(ctx context.Context, url string, alert Alert, format string) error {
+	var payload any
+	switch format {
+	case FormatJSON, "":
+		payload = alert
+	case FormatSlack:
+		payload = map[string]string{"text": SlackText(alert)}
+	default:
+		return fmt.Errorf("unknown webhook format %q (want json or slack)", format)
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook returned HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// SlackText renders alert as a Slack mrkdwn message
+func SlackText(alert Alert) string {
+	var b strings.Builder
+
+	env := alert.Environment
+	if env == "" {
+		env = "Diego"
+	}
+	switch alert.Status {
+	case "critical":
+		fmt.Fprintf(&b, ":red_circle: *CRITICAL*: %s capacity crossed a critical threshold", env)
+	case "warning":
+		fmt.Fprintf(&b, ":warning: *WARNING*: %s capacity crossed a warning threshold", env)
+	default:
+		fmt.Fprintf(&b, ":white_check_mark: *RECOVERED*: %s capacity is within thresholds", env)
+	}
+	if alert.PreviousStatus != "" {
+		fmt.Fprintf(&b, " (was %s)", alert.PreviousStatus)
+	}
+	b.WriteString("\n")
+
+	if alert.ConstrainingResource != "" {
+		fmt.Fprintf(&b, "Constraining resource: %s at %.1f%%\n", alert.ConstrainingResource, alert.ConstrainingPct)
+	}
+	fmt.Fprintf(&b, "N-1 utilization: %.0f%% · Free chunks: %d · Largest app: %d GB\n",
+		alert.N1UtilizationPct, alert.FreeChunks, alert.MaxSingleChunkGB)
+	for _, w := range alert.Warnings {
+		fmt.Fprintf(&b, "• [%s] %s\n", w.Severity, w.Message)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
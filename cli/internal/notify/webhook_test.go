@@ -0,0 +1,128 @@
+// ABOUTME: Tests for webhook notifications of threshold breaches
+// ABOUTME: Covers JSON and Slack payloads, delivery errors, and de-duplication
+
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/client"
+)
+
+func testAlert() Alert {
+	check := &client.CheckResponse{
+		Status:   "critical",
+		Current:  client.ScenarioResult{N1UtilizationPct: 92, FreeChunks: 120, MaxSingleChunkGB: 6},
+		Warnings: []client.ScenarioWarning{{Severity: "critical", Code: "n1_exceeded", Message: "Exceeds N-1 capacity safety margin"}},
+	}
+	analysis := &client.BottleneckAnalysis{
+		ConstrainingResource: "memory",
+		Resources: []client.ResourceUtilization{
+			{Name: "cpu", UsedPercent: 40},
+			{Name: "memory", UsedPercent: 88.5, IsConstraining: true},
+		},
+	}
+	return NewAlert("prod", check, analysis)
+}
+
+func TestNewAlert(t *testing.T) {
+	alert := testAlert()
+	if alert.ConstrainingResource != "memory" || alert.ConstrainingPct != 88.5 {
+		t.Errorf("expected memory at 88.5%%, got %s at %.1f%%", alert.ConstrainingResource, alert.ConstrainingPct)
+	}
+	if alert.Status != "critical" || alert.N1UtilizationPct != 92 || len(alert.Warnings) != 1 {
+		t.Errorf("expected check results copied, got %+v", alert)
+	}
+
+	passed := NewAlert("prod", &client.CheckResponse{Status: "passed"}, nil)
+	if passed.Warnings == nil || passed.ConstrainingResource != "" {
+		t.Errorf("expected empty warnings and no constraining resource, got %+v", passed)
+	}
+}
+
+func TestNotifyWebhook_Formats(t *testing.T) {
+	tests := []struct {
+		format string
+		check  func(t *testing.T, body map[string]any)
+	}{
+		{FormatJSON, func(t *testing.T, body map[string]any) {
+			if body["status"] != "critical" || body["constraining_resource"] != "memory" {
+				t.Errorf("expected alert fields in JSON payload, got %v", body)
+			}
+		}},
+		{FormatSlack, func(t *testing.T, body map[string]any) {
+			text, _ := body["text"].(string)
+			for _, want := range []string{"CRITICAL", "prod", "memory at 88.5%", "Exceeds N-1 capacity safety margin"} {
+				if !strings.Contains(text, want) {
+					t.Errorf("expected Slack text to contain %q, got %q", want, text)
+				}
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var body map[string]any
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+					t.Errorf("expected JSON POST, got %s %s", r.Method, r.Header.Get("Content-Type"))
+				}
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Errorf("failed to decode payload: %v", err)
+				}
+			}))
+			defer server.Close()
+
+			if err := NotifyWebhook(context.Background(), server.URL, testAlert(), tt.format); err != nil {
+				t.Fatalf("NotifyWebhook failed: %v", err)
+			}
+			tt.check(t, body)
+		})
+	}
+}
+
+func TestNotifyWebhook_Errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid_token", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	err := NotifyWebhook(context.Background(), server.URL, testAlert(), FormatSlack)
+	if err == nil || !strings.Contains(err.Error(), "403") || !strings.Contains(err.Error(), "invalid_token") {
+		t.Errorf("expected HTTP 403 error with body, got %v", err)
+	}
+
+	if err := NotifyWebhook(context.Background(), server.URL, testAlert(), "xml"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func TestTracker_DeduplicatesStatus(t *testing.T) {
+	var tracker Tracker
+	steps := []struct {
+		status string
+		notify bool
+	}{
+		{"passed", false},
+		{"warning", true},
+		{"warning", false},
+		{"critical", true},
+		{"critical", false},
+		{"passed", true},
+		{"passed", false},
+	}
+
+	for i, s := range steps {
+		if got := tracker.ShouldNotify(s.status); got != s.notify {
+			t.Errorf("step %d (%s): expected notify=%v, got %v", i, s.status, s.notify, got)
+		}
+		if s.notify {
+			tracker.Delivered(s.status)
+		}
+	}
+}
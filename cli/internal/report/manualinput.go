@@ -0,0 +1,29 @@
+// ABOUTME: Manual input export of a computed infrastructure state
+// ABOUTME: Writes the raw cluster inputs behind a discovery so they can be edited and re-imported
+
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/client"
+)
+
+// ManualInputFilename returns the manual input export filename for the given time
+func ManualInputFilename(t time.Time) string {
+	return "manual-input-" + t.Format(filenameLayout) + ".json"
+}
+
+// WriteManualInput writes state as an indented ManualInput JSON file into
+// dir and returns the path written. Like WriteMarkdown, it never overwrites.
+func WriteManualInput(dir string, state *client.InfrastructureState, now time.Time) (string, error) {
+	data, err := json.MarshalIndent(state.ToManualInput(), "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode manual input: %w", err)
+	}
+	base := strings.TrimSuffix(ManualInputFilename(now), ".json")
+	return writeNew(dir, base, ".json", "manual input", append(data, '\n'))
+}
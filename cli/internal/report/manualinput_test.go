@@ -0,0 +1,58 @@
+// ABOUTME: Tests for manual input export of infrastructure state
+// ABOUTME: Validates the written JSON loads back as manual input without clobbering
+
+package report
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/client"
+)
+
+func TestWriteManualInput(t *testing.T) {
+	dir := t.TempDir()
+	ts := time.Date(2026, 3, 5, 14, 7, 9, 0, time.UTC)
+	state := &client.InfrastructureState{
+		Name:   "vcenter.example.com",
+		Source: "vsphere",
+		Clusters: []client.ClusterState{
+			{Name: "c1", HostCount: 4, MemoryGB: 2048, MemoryGBPerHost: 512, CPUThreadsPerHost: 64, DiegoCellCount: 20, DiegoCellMemoryGB: 64},
+		},
+		TotalAppMemoryGB:    900,
+		MaxInstanceMemoryMB: 4096,
+	}
+
+	first, err := WriteManualInput(dir, state, ts)
+	if err != nil {
+		t.Fatalf("first write failed: %v", err)
+	}
+	if filepath.Base(first) != "manual-input-20260305-140709.json" {
+		t.Errorf("unexpected filename %s", filepath.Base(first))
+	}
+	second, err := WriteManualInput(dir, state, ts)
+	if err != nil {
+		t.Fatalf("second write failed: %v", err)
+	}
+	if filepath.Base(second) != "manual-input-20260305-140709-1.json" {
+		t.Errorf("unexpected collision filename %s", filepath.Base(second))
+	}
+
+	data, err := os.ReadFile(first)
+	if err != nil {
+		t.Fatalf("failed to read export: %v", err)
+	}
+	if format, err := client.DetectFormat(data); err != nil || format != client.FormatManualInput {
+		t.Errorf("expected export detected as %q, got %q (err %v)", client.FormatManualInput, format, err)
+	}
+	var input client.ManualInput
+	if err := json.Unmarshal(data, &input); err != nil {
+		t.Fatalf("failed to parse export: %v", err)
+	}
+	if len(input.Clusters) != 1 || input.Clusters[0].MemoryGBPerHost != 512 || input.TotalAppMemoryGB != 900 || input.MaxInstanceMemoryMB != 4096 {
+		t.Errorf("unexpected exported input %+v", input)
+	}
+}
@@ -0,0 +1,142 @@
+// ABOUTME: Markdown report rendering for scenario comparison results
+// ABOUTME: Produces shareable current vs proposed tables independent of the TUI
+
+package report
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/client"
+)
+
+// filenameLayout includes seconds so two exports in the same minute get distinct names
+const filenameLayout = "20060102-150405"
+
+// RenderMarkdown renders a scenario comparison as a Markdown report
+func RenderMarkdown(c *client.ScenarioComparison) string {
+	if c == nil {
+		return "# Scenario Comparison\n\nNo comparison data.\n"
+	}
+
+	var sb strings.Builder
+	sb.WriteString("# Scenario Comparison\n\n")
+
+	// Current vs proposed side by side
+	sb.WriteString("## Current vs Proposed\n\n")
+	sb.WriteString("| Metric | Current | Proposed |\n")
+	sb.WriteString("| --- | --- | --- |\n")
+	cur, prop := c.Current, c.Proposed
+	writeRow(&sb, "Cells", fmt.Sprintf("%d", cur.CellCount), fmt.Sprintf("%d", prop.CellCount))
+	writeRow(&sb, "Cell size", cellSize(cur), cellSize(prop))
+	writeRow(&sb, "App capacity", fmt.Sprintf("%d GB", cur.AppCapacityGB), fmt.Sprintf("%d GB", prop.AppCapacityGB))
+	writeRow(&sb, "Memory utilization", fmt.Sprintf("%.1f%%", cur.UtilizationPct), fmt.Sprintf("%.1f%%", prop.UtilizationPct))
+	writeRow(&sb, "N-1 utilization", fmt.Sprintf("%.1f%%", cur.N1UtilizationPct), fmt.Sprintf("%.1f%%", prop.N1UtilizationPct))
+	writeRow(&sb, "Free chunks", fmt.Sprintf("%d", cur.FreeChunks), fmt.Sprintf("%d", prop.FreeChunks))
+	writeRow(&sb, "Fault impact", fmt.Sprintf("%d apps/cell", cur.FaultImpact), fmt.Sprintf("%d apps/cell", prop.FaultImpact))
+	writeRow(&sb, "Blast radius", fmt.Sprintf("%.1f%%", cur.BlastRadiusPct), fmt.Sprintf("%.1f%%", prop.BlastRadiusPct))
+	if cur.VCPURatio > 0 || prop.VCPURatio > 0 {
+		writeRow(&sb, "vCPU:pCPU ratio", ratio(cur), ratio(prop))
+	}
+	if prop.HostsRemoved > 0 {
+		writeRow(&sb, "Hosts removed", "0", fmt.Sprintf("%d", prop.HostsRemoved))
+		writeRow(&sb, "Cells to evacuate", "0", fmt.Sprintf("%d", prop.CellsToEvacuate))
+	}
+	sb.WriteString("\n")
+
+	// Delta summary
+	sb.WriteString("## Delta\n\n")
+	sb.WriteString("| Change | Value |\n")
+	sb.WriteString("| --- | --- |\n")
+	d := c.Delta
+	fmt.Fprintf(&sb, "| Capacity | %+d GB |\n", d.CapacityChangeGB)
+	fmt.Fprintf(&sb, "| Utilization | %+.1f%% |\n", d.UtilizationChangePct)
+	if d.ResilienceChange != "" {
+		fmt.Fprintf(&sb, "| Resilience | %s |\n", escapeCell(d.ResilienceChange))
+	}
+	if d.VCPURatioChange != 0 {
+		fmt.Fprintf(&sb, "| vCPU ratio | %+.1f |\n", d.VCPURatioChange)
+	}
+	sb.WriteString("\n")
+
+	// Warnings
+	sb.WriteString("## Warnings\n\n")
+	if len(c.Warnings) == 0 {
+		sb.WriteString("No warnings.\n")
+	} else {
+		sb.WriteString("| Severity | Message |\n")
+		sb.WriteString("| --- | --- |\n")
+		for _, w := range c.Warnings {
+			fmt.Fprintf(&sb, "| %s | %s |\n", escapeCell(w.Severity), escapeCell(w.Message))
+		}
+	}
+
+	return sb.String()
+}
+
+// Filename returns the report filename for the given time
+func Filename(t time.Time) string {
+	return "scenario-comparison-" + t.Format(filenameLayout) + ".md"
+}
+
+// WriteMarkdown writes the rendered report into dir and returns the path written.
+// Existing files are never overwritten; a numeric suffix is added on collision.
+func WriteMarkdown(dir string, c *client.ScenarioComparison, now time.Time) (string, error) {
+	content := []byte(RenderMarkdown(c))
+	return writeNew(dir, strings.TrimSuffix(Filename(now), ".md"), ".md", "report", content)
+}
+
+// writeNew writes content to base+ext in dir, adding a numeric suffix to
+// base rather than overwriting an existing file. what names the file in errors.
+func writeNew(dir, base, ext, what string, content []byte) (string, error) {
+	for i := 0; i < 100; i++ {
+		name := base + ext
+		if i > 0 {
+			name = fmt.Sprintf("%s-%d%s", base, i, ext)
+		}
+		path := filepath.Join(dir, name)
+
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to create %s: %w", what, err)
+		}
+		if _, err := f.Write(content); err != nil {
+			f.Close()
+			return "", fmt.Errorf("failed to write %s: %w", what, err)
+		}
+		if err := f.Close(); err != nil {
+			return "", fmt.Errorf("failed to write %s: %w", what, err)
+		}
+		return path, nil
+	}
+
+	return "", fmt.Errorf("failed to create %s: too many files named %s", what, base)
+}
+
+func writeRow(sb *strings.Builder, metric, current, proposed string) {
+	fmt.Fprintf(sb, "| %s | %s | %s |\n", metric, current, proposed)
+}
+
+func cellSize(r client.ScenarioResult) string {
+	return fmt.Sprintf("%d vCPU / %d GB / %d GB disk", r.CellCPU, r.CellMemoryGB, r.CellDiskGB)
+}
+
+func ratio(r client.ScenarioResult) string {
+	if r.CPURiskLevel == "" {
+		return fmt.Sprintf("%.1f:1", r.VCPURatio)
+	}
+	return fmt.Sprintf("%.1f:1 (%s)", r.VCPURatio, r.CPURiskLevel)
+}
+
+// escapeCell keeps table cells on one line and prevents pipes from splitting columns
+func escapeCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", " ")
+}
@@ -0,0 +1,118 @@
+// ABOUTME: Tests for Markdown comparison report rendering
+// ABOUTME: Validates table content and collision-safe file writing
+
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/client"
+)
+
+func testComparison() *client.ScenarioComparison {
+	return &client.ScenarioComparison{
+		Current: client.ScenarioResult{
+			CellCount:      10,
+			CellMemoryGB:   32,
+			CellCPU:        4,
+			AppCapacityGB:  300,
+			UtilizationPct: 75.0,
+			FreeChunks:     20,
+		},
+		Proposed: client.ScenarioResult{
+			CellCount:      20,
+			CellMemoryGB:   32,
+			CellCPU:        4,
+			AppCapacityGB:  600,
+			UtilizationPct: 37.5,
+			FreeChunks:     95,
+		},
+		Delta: client.ScenarioDelta{
+			CapacityChangeGB:     300,
+			UtilizationChangePct: -37.5,
+			ResilienceChange:     "improved",
+		},
+		Warnings: []client.ScenarioWarning{
+			{Severity: "warning", Message: "Cell count | doubled"},
+		},
+	}
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	md := RenderMarkdown(testComparison())
+
+	for _, want := range []string{
+		"# Scenario Comparison",
+		"## Current vs Proposed",
+		"| Cells | 10 | 20 |",
+		"| Memory utilization | 75.0% | 37.5% |",
+		"| Free chunks | 20 | 95 |",
+		"## Delta",
+		"| Capacity | +300 GB |",
+		"| Utilization | -37.5% |",
+		"| Resilience | improved |",
+		"## Warnings",
+		`| warning | Cell count \| doubled |`,
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("expected report to contain %q, got:\n%s", want, md)
+		}
+	}
+}
+
+func TestRenderMarkdownNoWarnings(t *testing.T) {
+	c := testComparison()
+	c.Warnings = nil
+
+	md := RenderMarkdown(c)
+	if !strings.Contains(md, "No warnings.") {
+		t.Error("expected 'No warnings.' when there are no warnings")
+	}
+}
+
+func TestRenderMarkdownNil(t *testing.T) {
+	md := RenderMarkdown(nil)
+	if !strings.Contains(md, "No comparison data") {
+		t.Error("expected nil comparison to render placeholder")
+	}
+}
+
+func TestFilename(t *testing.T) {
+	ts := time.Date(2026, 3, 5, 14, 7, 9, 0, time.UTC)
+	if got := Filename(ts); got != "scenario-comparison-20260305-140709.md" {
+		t.Errorf("unexpected filename %q", got)
+	}
+}
+
+func TestWriteMarkdownDoesNotClobber(t *testing.T) {
+	dir := t.TempDir()
+	ts := time.Date(2026, 3, 5, 14, 7, 9, 0, time.UTC)
+
+	first, err := WriteMarkdown(dir, testComparison(), ts)
+	if err != nil {
+		t.Fatalf("first write failed: %v", err)
+	}
+	second, err := WriteMarkdown(dir, testComparison(), ts)
+	if err != nil {
+		t.Fatalf("second write failed: %v", err)
+	}
+
+	if first == second {
+		t.Fatalf("expected distinct paths, both were %s", first)
+	}
+	if filepath.Base(second) != "scenario-comparison-20260305-140709-1.md" {
+		t.Errorf("unexpected collision filename %s", filepath.Base(second))
+	}
+
+	data, err := os.ReadFile(first)
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+	if !strings.Contains(string(data), "# Scenario Comparison") {
+		t.Error("expected written report to contain heading")
+	}
+}
@@ -0,0 +1,150 @@
+// ABOUTME: Name redaction for exports shared outside the team
+// ABOUTME: Swaps foundation and cluster names for stable pseudonyms such as cluster-1, keeping all numbers
+
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/client"
+)
+
+// Redactor maps the foundation and cluster names of one infrastructure state
+// to pseudonyms, like the backend's redact=true. Clusters are numbered in
+// state order, so a report and a manual input exported from the same state
+// use the same pseudonyms.
+type Redactor struct {
+	names   map[string]string
+	ordered []string // Names longest first, so a name containing another is matched whole
+}
+
+// NewRedactor returns a Redactor for the names in state. A nil state
+// redacts nothing.
+func NewRedactor(state *client.InfrastructureState) *Redactor {
+	r := &Redactor{names: make(map[string]string)}
+	if state != nil {
+		if state.Name != "" {
+			r.names[state.Name] = "foundation-1"
+		}
+		n := 0
+		for _, c := range state.Clusters {
+			if _, seen := r.names[c.Name]; c.Name == "" || seen {
+				continue
+			}
+			n++
+			r.names[c.Name] = fmt.Sprintf("cluster-%d", n)
+		}
+	}
+
+	r.ordered = make([]string, 0, len(r.names))
+	for name := range r.names {
+		r.ordered = append(r.ordered, name)
+	}
+	sort.Slice(r.ordered, func(i, j int) bool {
+		if len(r.ordered[i]) != len(r.ordered[j]) {
+			return len(r.ordered[i]) > len(r.ordered[j])
+		}
+		return r.ordered[i] < r.ordered[j]
+	})
+	return r
+}
+
+// Name returns the pseudonym of a foundation or cluster name, or name itself
+// when the state did not contain it
+func (r *Redactor) Name(name string) string {
+	if alias, ok := r.names[name]; ok {
+		return alias
+	}
+	return name
+}
+
+// Text replaces every foundation and cluster name in s, for free-form
+// messages such as scenario warnings. A name is only replaced where it stands
+// on its own, so a short name such as "prod" leaves "production" alone.
+func (r *Redactor) Text(s string) string {
+	if len(r.ordered) == 0 {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		if name, ok := r.nameAt(s, i); ok {
+			b.WriteString(r.names[name])
+			i += len(name)
+			continue
+		}
+		b.WriteByte(s[i])
+		i++
+	}
+	return b.String()
+}
+
+// nameAt returns the longest name that starts at s[i] and is not part of a
+// longer word
+func (r *Redactor) nameAt(s string, i int) (string, bool) {
+	if i > 0 && isNameByte(s[i-1]) {
+		return "", false
+	}
+	for _, name := range r.ordered {
+		end := i + len(name)
+		if strings.HasPrefix(s[i:], name) && (end == len(s) || !isNameByte(s[end])) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// isNameByte reports whether b can continue a word in a name. Hyphens and
+// underscores count, since cluster names such as "prod-pci" use them.
+func isNameByte(b byte) bool {
+	return b == '-' || b == '_' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= 0x80
+}
+
+// InfrastructureState returns a copy of state with its names redacted
+func (r *Redactor) InfrastructureState(state *client.InfrastructureState) *client.InfrastructureState {
+	if state == nil {
+		return nil
+	}
+	redacted := *state
+	redacted.Name = r.Name(state.Name)
+	redacted.Clusters = make([]client.ClusterState, len(state.Clusters))
+	for i, c := range state.Clusters {
+		c.Name = r.Name(c.Name)
+		redacted.Clusters[i] = c
+	}
+	return &redacted
+}
+
+// Bottleneck returns a copy of analysis with names redacted from its summary
+func (r *Redactor) Bottleneck(analysis *client.BottleneckAnalysis) *client.BottleneckAnalysis {
+	if analysis == nil {
+		return nil
+	}
+	redacted := *analysis
+	redacted.Summary = r.Text(analysis.Summary)
+	return &redacted
+}
+
+// Comparison returns a copy of c with names redacted from its warnings and
+// recommendations, the only parts of a comparison that can carry them
+func (r *Redactor) Comparison(c *client.ScenarioComparison) *client.ScenarioComparison {
+	if c == nil {
+		return nil
+	}
+	redacted := *c
+	redacted.Warnings = make([]client.ScenarioWarning, len(c.Warnings))
+	for i, w := range c.Warnings {
+		w.Message = r.Text(w.Message)
+		redacted.Warnings[i] = w
+	}
+	redacted.Recommendations = make([]client.Recommendation, len(c.Recommendations))
+	for i, rec := range c.Recommendations {
+		rec.Title = r.Text(rec.Title)
+		rec.Description = r.Text(rec.Description)
+		rec.Impact = r.Text(rec.Impact)
+		redacted.Recommendations[i] = rec
+	}
+	redacted.Delta.ResilienceChange = r.Text(c.Delta.ResilienceChange)
+	return &redacted
+}
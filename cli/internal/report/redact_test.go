@@ -0,0 +1,89 @@
+// ABOUTME: Tests for export name redaction
+// ABOUTME: Validates pseudonyms follow state order and reach warning text without touching numbers
+
+package report
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/client"
+)
+
+func redactTestState() *client.InfrastructureState {
+	return &client.InfrastructureState{
+		Name: "vcenter.corp.example",
+		Clusters: []client.ClusterState{
+			{Name: "prod", HostCount: 4, DiegoCellCount: 20},
+			{Name: "prod-pci", HostCount: 2, DiegoCellCount: 8},
+		},
+		TotalCellCount: 28,
+	}
+}
+
+func TestRedactor_InfrastructureState(t *testing.T) {
+	state := redactTestState()
+	redacted := NewRedactor(state).InfrastructureState(state)
+
+	if redacted.Name != "foundation-1" {
+		t.Errorf("expected foundation-1, got %q", redacted.Name)
+	}
+	if redacted.Clusters[0].Name != "cluster-1" || redacted.Clusters[1].Name != "cluster-2" {
+		t.Errorf("expected cluster-1 and cluster-2 in state order, got %q and %q", redacted.Clusters[0].Name, redacted.Clusters[1].Name)
+	}
+	if redacted.TotalCellCount != 28 || redacted.Clusters[1].DiegoCellCount != 8 {
+		t.Errorf("expected numbers to be kept, got %+v", redacted)
+	}
+	if state.Clusters[0].Name != "prod" {
+		t.Errorf("expected the original state to be unchanged, got %q", state.Clusters[0].Name)
+	}
+}
+
+func TestRedactor_Comparison(t *testing.T) {
+	r := NewRedactor(redactTestState())
+	c := &client.ScenarioComparison{
+		Proposed: client.ScenarioResult{CellCount: 30},
+		Warnings: []client.ScenarioWarning{
+			{Severity: "warning", Message: "HA admission control differs: prod (25%), prod-pci (50%)"},
+		},
+	}
+
+	redacted := r.Comparison(c)
+	want := "HA admission control differs: cluster-1 (25%), cluster-2 (50%)"
+	if redacted.Warnings[0].Message != want {
+		t.Errorf("expected %q, got %q", want, redacted.Warnings[0].Message)
+	}
+	if redacted.Proposed.CellCount != 30 {
+		t.Errorf("expected numbers to be kept, got %d cells", redacted.Proposed.CellCount)
+	}
+	if strings.Contains(RenderMarkdown(redacted), "prod") {
+		t.Error("expected the rendered report to carry no cluster names")
+	}
+	if c.Warnings[0].Message == want {
+		t.Error("expected the original comparison to be unchanged")
+	}
+}
+
+func TestRedactor_TextMatchesWholeNames(t *testing.T) {
+	r := NewRedactor(&client.InfrastructureState{
+		Name:     "c1",
+		Clusters: []client.ClusterState{{Name: "prod"}, {Name: "prod-pci"}},
+	})
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"prod is at 80%", "cluster-1 is at 80%"},
+		{"prod-pci and prod differ.", "cluster-2 and cluster-1 differ."},
+		{"HA differs: prod (25%), prod-pci (50%)", "HA differs: cluster-1 (25%), cluster-2 (50%)"},
+		{"production and prod-east are not clusters", "production and prod-east are not clusters"},
+		{"c1 hosts 1c1 and c10", "foundation-1 hosts 1c1 and c10"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := r.Text(tt.in); got != tt.want {
+			t.Errorf("Text(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
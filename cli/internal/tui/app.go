@@ -6,6 +6,7 @@ package tui
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -16,11 +17,14 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/markalston/diego-capacity-analyzer/cli/internal/client"
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/format"
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/report"
 	"github.com/markalston/diego-capacity-analyzer/cli/internal/tui/comparison"
 	"github.com/markalston/diego-capacity-analyzer/cli/internal/tui/dashboard"
 	"github.com/markalston/diego-capacity-analyzer/cli/internal/tui/debuglog"
 	"github.com/markalston/diego-capacity-analyzer/cli/internal/tui/filepicker"
 	"github.com/markalston/diego-capacity-analyzer/cli/internal/tui/icons"
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/tui/manualentry"
 	"github.com/markalston/diego-capacity-analyzer/cli/internal/tui/menu"
 	"github.com/markalston/diego-capacity-analyzer/cli/internal/tui/recentfiles"
 	"github.com/markalston/diego-capacity-analyzer/cli/internal/tui/samples"
@@ -37,6 +41,7 @@ const (
 	ScreenDashboard
 	ScreenComparison
 	ScreenWizard
+	ScreenManualEntry
 )
 
 // Layout constants
@@ -45,6 +50,14 @@ const (
 	panelOverhead    = 2  // Border only (1 left + 1 right) - lipgloss Width() includes padding in content area
 )
 
+// loadTimeout bounds how long the dashboard waits on the backend before
+// giving up and returning to the menu
+const loadTimeout = 30 * time.Second
+
+// cellCountDebounce is how long the comparison screen waits after the last
+// +/- press before re-running the scenario, so holding a key sends one request
+const cellCountDebounce = 300 * time.Millisecond
+
 // infraLoadedMsg is sent when infrastructure data is loaded
 type infraLoadedMsg struct {
 	infra *client.InfrastructureState
@@ -55,6 +68,33 @@ type infraLoadedMsg struct {
 type scenarioComparedMsg struct {
 	result *client.ScenarioComparison
 	err    error
+	seq    int // compareSeq when the request was issued
+}
+
+// cellCountSettledMsg is sent when no +/- press has followed the one that
+// scheduled it within cellCountDebounce
+type cellCountSettledMsg struct {
+	seq int
+}
+
+// thresholdsLoadedMsg is sent when the backend's capacity thresholds arrive
+type thresholdsLoadedMsg struct {
+	thresholds *client.Thresholds
+	err        error
+}
+
+// tpsSweepLoadedMsg is sent when the backend's TPS estimates for the
+// dashboard chart arrive
+type tpsSweepLoadedMsg struct {
+	cells   int // TotalCellCount the sweep was requested for
+	results []client.ScenarioResult
+	err     error
+}
+
+// glossaryLoadedMsg is sent when the backend's metric definitions arrive
+type glossaryLoadedMsg struct {
+	glossary []client.MetricDefinition
+	err      error
 }
 
 // fileLoadedMsg is sent when a JSON file is loaded
@@ -68,6 +108,13 @@ type infraPostedMsg struct {
 	err error
 }
 
+// reportExportedMsg is sent when a comparison report or manual input export
+// has been written
+type reportExportedMsg struct {
+	path string
+	err  error
+}
+
 // App is the root model for the TUI
 type App struct {
 	client            *client.Client
@@ -85,11 +132,30 @@ type App struct {
 	lastUpdate        time.Time
 	infraName         string // Name of the infrastructure source for header
 	loading           bool   // Whether we're in a loading state
+	statusMessage     string // Transient footer status (e.g., exported report path)
+	redactExports     bool   // Replace foundation and cluster names with pseudonyms in exports
+
+	// Backend capacity thresholds for status colors and the legend; nil until fetched
+	thresholds *client.Thresholds
+
+	// Metric definitions for the ? help panels; nil until fetched
+	glossary []client.MetricDefinition
+
+	// Last scenario compared, re-issued with a new cell count by +/-
+	lastScenario *client.ScenarioInput
+	pendingCells int // Cell count chosen with +/- but not yet compared
+	sliderSeq    int // Incremented per +/- press; only the latest press settles
+	compareSeq   int // Incremented per comparison; stale results are dropped
+
+	// Loading state for the in-flight backend call
+	loadDeadline time.Time
+	cancelLoad   context.CancelFunc
 
 	// Child models
 	menu         *menu.Menu
 	filePicker   *filepicker.FilePicker
 	wizardScreen *wizard.Wizard
+	manualEntry  *manualentry.ManualEntry
 	spinner      spinner.Model
 
 	// Recent files manager
@@ -129,7 +195,7 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		a.width = msg.Width
 		a.height = msg.Height
 		if a.dashboard != nil {
-			a.dashboard.SetSize(a.dashboardWidth(), a.contentHeight())
+			a.dashboard.SetSize(a.dashboardWidth(), a.dashboardHeight())
 		}
 		if a.compView != nil {
 			a.compView.SetSize(a.comparisonWidth())
@@ -145,6 +211,10 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			a.wizardScreen.SetWidth(a.width - 1)
 			return a.updateWizard(msg)
 		}
+		if a.manualEntry != nil {
+			a.manualEntry.SetWidth(a.width - 1)
+			return a.updateManualEntry(msg)
+		}
 		return a, nil
 
 	case spinner.TickMsg:
@@ -161,6 +231,13 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return a, tea.Quit
 		}
 
+		// Esc abandons a backend call that is taking too long
+		if a.loading && msg.String() == "esc" {
+			a.stopLoading()
+			a.returnToMenu("Loading cancelled")
+			return a, nil
+		}
+
 		// Route to current screen
 		switch a.screen {
 		case ScreenMenu:
@@ -173,6 +250,8 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return a.updateComparison(msg)
 		case ScreenWizard:
 			return a.updateWizard(msg)
+		case ScreenManualEntry:
+			return a.updateManualEntry(msg)
 		}
 
 	case menu.DataSourceSelectedMsg:
@@ -184,6 +263,12 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case filepicker.FileSelectedMsg:
 		return a.handleFileSelected(msg)
 
+	case filepicker.PreviewLoadedMsg:
+		if a.filePicker != nil {
+			a.filePicker.Update(msg)
+		}
+		return a, nil
+
 	case filepicker.CancelledMsg:
 		// Go back to menu
 		a.screen = ScreenMenu
@@ -201,35 +286,136 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		a.wizardScreen = nil
 		return a, nil
 
+	case manualentry.SubmittedMsg:
+		// Form finished, have the backend compute the infrastructure state
+		a.manualEntry = nil
+		a.screen = ScreenDashboard
+		ctx := a.startLoading()
+		return a, tea.Batch(a.spinner.Tick, a.computeManualInfrastructure(ctx, msg.Input))
+
+	case manualentry.CancelledMsg:
+		// Go back to menu
+		a.screen = ScreenMenu
+		a.manualEntry = nil
+		return a, nil
+
 	case fileLoadedMsg:
 		return a.handleFileLoaded(msg)
 
 	case infraLoadedMsg:
-		a.loading = false
+		if errors.Is(msg.err, context.Canceled) {
+			// Result of a call the user already cancelled
+			return a, nil
+		}
+		a.stopLoading()
+		if errors.Is(msg.err, context.DeadlineExceeded) {
+			a.returnToMenu(fmt.Sprintf("Backend did not respond within %s", loadTimeout))
+			return a, nil
+		}
 		if msg.err != nil {
 			a.err = msg.err
 			return a, nil
 		}
+		// Backends that predate the check hand back a discovery with no cells,
+		// which would render as a dashboard of zeros
+		if msg.infra.Source == "vsphere" && msg.infra.TotalCellCount == 0 {
+			a.err = errors.New("no Diego cells discovered; check VSPHERE_CELL_NAME_PATTERNS and datacenter")
+			return a, nil
+		}
 		a.infra = msg.infra
 		a.lastUpdate = time.Now()
 		a.infraName = a.deriveInfraName()
-		a.dashboard = dashboard.New(a.infra, a.dashboardWidth(), a.contentHeight())
+		a.dashboard = a.newDashboard()
 		a.screen = ScreenDashboard
+		return a, tea.Batch(a.loadThresholds(), a.loadTPSSweep())
+
+	case thresholdsLoadedMsg:
+		if msg.err != nil {
+			// The panels and legend keep the built-in defaults
+			debuglog.Error("loading thresholds", msg.err)
+			return a, nil
+		}
+		a.thresholds = msg.thresholds
+		if a.dashboard != nil {
+			a.dashboard.SetThresholds(*a.thresholds)
+		}
+		if a.compView != nil {
+			a.compView.SetThresholds(*a.thresholds)
+		}
+		return a, nil
+
+	case tpsSweepLoadedMsg:
+		// Drop a sweep for infrastructure that has since been replaced
+		if a.dashboard == nil || a.infra == nil || a.infra.TotalCellCount != msg.cells {
+			return a, nil
+		}
+		if msg.err != nil {
+			// The chart says the estimates are unavailable
+			debuglog.Error("loading TPS sweep", msg.err)
+		}
+		a.dashboard.SetTPSSweep(msg.results)
+		return a, nil
+
+	case glossaryLoadedMsg:
+		if msg.err != nil {
+			// The help panels say the definitions are unavailable
+			debuglog.Error("loading metric glossary", msg.err)
+			return a, nil
+		}
+		a.glossary = msg.glossary
+		if a.dashboard != nil {
+			a.dashboard.SetGlossary(a.glossary)
+		}
+		if a.compView != nil {
+			a.compView.SetGlossary(a.glossary)
+		}
+		return a, nil
+
+	case reportExportedMsg:
+		if msg.err != nil {
+			debuglog.Error("exporting file", msg.err)
+			a.statusMessage = "Export failed: " + msg.err.Error()
+			return a, nil
+		}
+		// Exports are written to the working directory, so the base name is a usable relative path
+		a.statusMessage = "Saved " + filepath.Base(msg.path)
 		return a, nil
 
 	case infraPostedMsg:
 		// Backend post completed (success or failure doesn't block UI)
 		// The infrastructure is already loaded locally
-		return a, nil
+		if msg.err != nil {
+			if a.dashboard != nil {
+				a.dashboard.SetTPSSweep(nil)
+			}
+			return a, nil
+		}
+		// The sweep runs against the backend's stored state, so it waits for the post
+		return a, a.loadTPSSweep()
+
+	case cellCountSettledMsg:
+		if msg.seq != a.sliderSeq || a.lastScenario == nil || a.screen != ScreenComparison {
+			return a, nil
+		}
+		input := *a.lastScenario
+		input.ProposedCellCount = a.pendingCells
+		return a, a.compareScenario(&input)
 
 	case scenarioComparedMsg:
+		if msg.seq != a.compareSeq {
+			// A newer comparison has been requested since this one
+			return a, nil
+		}
 		if msg.err != nil {
 			a.err = msg.err
 			return a, nil
 		}
 		a.comparison = msg.result
-		a.compView = comparison.New(a.comparison, a.comparisonWidth())
+		a.compView = a.newComparison()
 		a.screen = ScreenComparison
+		if a.lastScenario != nil && a.pendingCells == a.lastScenario.ProposedCellCount {
+			a.statusMessage = ""
+		}
 		return a, nil
 
 	default:
@@ -237,6 +423,9 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if a.screen == ScreenWizard && a.wizardScreen != nil {
 			return a.updateWizard(msg)
 		}
+		if a.screen == ScreenManualEntry && a.manualEntry != nil {
+			return a.updateManualEntry(msg)
+		}
 	}
 
 	return a, nil
@@ -269,18 +458,36 @@ func (a *App) updateDashboard(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "q":
 		return a, tea.Quit
 	case "r":
-		return a, a.loadInfrastructure()
+		a.statusMessage = ""
+		ctx := a.startLoading()
+		return a, tea.Batch(a.spinner.Tick, a.refreshInfrastructure(ctx))
 	case "w":
 		if a.infra != nil {
+			a.statusMessage = ""
 			return a, a.runWizard()
 		}
+	case "e":
+		if a.infra != nil {
+			return a, a.exportManualInput()
+		}
+	case "?":
+		if a.dashboard != nil {
+			a.dashboard.ToggleLegend()
+		}
+		return a, a.loadGlossary()
 	case "b":
 		// Go back to menu
 		a.screen = ScreenMenu
 		a.dashboard = nil
 		a.infra = nil
 		a.err = nil
+		a.statusMessage = ""
 		return a, nil
+	default:
+		// Movement keys scroll dashboard content taller than the pane
+		if a.dashboard != nil {
+			return a, a.dashboard.HandleScroll(msg)
+		}
 	}
 	return a, nil
 }
@@ -293,11 +500,27 @@ func (a *App) updateComparison(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		a.screen = ScreenDashboard
 		a.comparison = nil
 		a.compView = nil
+		a.lastScenario = nil
+		a.statusMessage = ""
 		return a, nil
+	case "+", "=":
+		return a, a.adjustCellCount(1)
+	case "-":
+		return a, a.adjustCellCount(-1)
 	case "w":
 		if a.infra != nil {
+			a.statusMessage = ""
 			return a, a.runWizard()
 		}
+	case "e":
+		if a.comparison != nil {
+			return a, a.exportReport()
+		}
+	case "?":
+		if a.compView != nil {
+			a.compView.ToggleHelp()
+		}
+		return a, a.loadGlossary()
 	}
 	return a, nil
 }
@@ -313,14 +536,25 @@ func (a *App) updateWizard(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return a, cmd
 }
 
+func (a *App) updateManualEntry(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if a.manualEntry == nil {
+		return a, nil
+	}
+	model, cmd := a.manualEntry.Update(msg)
+	if m, ok := model.(*manualentry.ManualEntry); ok {
+		a.manualEntry = m
+	}
+	return a, cmd
+}
+
 func (a *App) handleDataSourceSelected(msg menu.DataSourceSelectedMsg) (tea.Model, tea.Cmd) {
 	a.dataSource = msg.Source
 
 	switch msg.Source {
 	case menu.SourceVSphere:
 		a.screen = ScreenDashboard
-		a.loading = true
-		return a, tea.Batch(a.spinner.Tick, a.loadInfrastructure())
+		ctx := a.startLoading()
+		return a, tea.Batch(a.spinner.Tick, a.loadInfrastructure(ctx))
 
 	case menu.SourceJSON:
 		// Initialize file picker with recent files and samples
@@ -336,13 +570,13 @@ func (a *App) handleDataSourceSelected(msg menu.DataSourceSelectedMsg) (tea.Mode
 		}
 		a.filePicker = filepicker.New(recentList, sampleFiles)
 		a.screen = ScreenFilePicker
-		return a, nil
+		return a, a.filePicker.Init()
 
 	case menu.SourceManual:
-		// Manual input goes directly to dashboard (will implement manual input later)
-		a.screen = ScreenDashboard
-		a.loading = true
-		return a, tea.Batch(a.spinner.Tick, a.loadInfrastructure())
+		a.manualEntry = manualentry.New()
+		a.manualEntry.SetWidth(a.width - 1)
+		a.screen = ScreenManualEntry
+		return a, a.manualEntry.Init()
 	}
 
 	return a, nil
@@ -359,9 +593,17 @@ func (a *App) handleFileSelected(msg filepicker.FileSelectedMsg) (tea.Model, tea
 }
 
 func (a *App) handleFileLoaded(msg fileLoadedMsg) (tea.Model, tea.Cmd) {
-	// Try to detect the JSON format - ManualInput vs InfrastructureState
-	// ManualInput has clusters[].memory_gb_per_host, InfrastructureState has clusters[].memory_gb
-	if isManualInputFormat(msg.data) {
+	// Detect the JSON format - ManualInput vs InfrastructureState. An explicit
+	// "format" field wins; otherwise InfrastructureState has clusters[].memory_gb
+	format, err := client.DetectFormat(msg.data)
+	if err != nil {
+		a.err = err
+		if a.filePicker != nil {
+			a.filePicker.SetError(err.Error())
+		}
+		return a, nil
+	}
+	if format == client.FormatManualInput {
 		// Parse as ManualInput and send to backend for computation
 		var input client.ManualInput
 		if err := json.Unmarshal(msg.data, &input); err != nil {
@@ -375,10 +617,10 @@ func (a *App) handleFileLoaded(msg fileLoadedMsg) (tea.Model, tea.Cmd) {
 		// Transition to dashboard with loading state
 		a.screen = ScreenDashboard
 		a.filePicker = nil
-		a.loading = true
+		ctx := a.startLoading()
 
 		// Call backend to compute infrastructure state
-		return a, tea.Batch(a.spinner.Tick, a.computeManualInfrastructure(&input))
+		return a, tea.Batch(a.spinner.Tick, a.computeManualInfrastructure(ctx, &input))
 	}
 
 	// Parse as InfrastructureState (pre-computed format)
@@ -395,46 +637,93 @@ func (a *App) handleFileLoaded(msg fileLoadedMsg) (tea.Model, tea.Cmd) {
 	a.infra = &infra
 	a.lastUpdate = time.Now()
 	a.infraName = a.deriveInfraName()
-	a.dashboard = dashboard.New(a.infra, a.dashboardWidth(), a.contentHeight())
+	a.dashboard = a.newDashboard()
 	a.screen = ScreenDashboard
 	a.filePicker = nil
 
 	// POST infrastructure state to backend so scenario comparison works
-	return a, a.postInfrastructureState(&infra)
+	return a, tea.Batch(a.postInfrastructureState(&infra), a.loadThresholds())
 }
 
-// isManualInputFormat detects if JSON is ManualInput format (has memory_gb_per_host)
-func isManualInputFormat(data []byte) bool {
-	// Quick check: ManualInput has "memory_gb_per_host", InfrastructureState has "memory_gb"
-	// but NOT "memory_gb_per_host"
-	var raw map[string]interface{}
-	if err := json.Unmarshal(data, &raw); err != nil {
-		return false
+// computeManualInfrastructure calls the backend to compute infrastructure from manual input
+func (a *App) computeManualInfrastructure(ctx context.Context, input *client.ManualInput) tea.Cmd {
+	return func() tea.Msg {
+		infra, err := a.client.SetManualInfrastructure(ctx, input)
+		return loadResult(ctx, infra, err)
 	}
+}
 
-	clusters, ok := raw["clusters"].([]interface{})
-	if !ok || len(clusters) == 0 {
-		return false
+// newDashboard builds the dashboard for the current infrastructure, showing
+// the backend's thresholds once they have been fetched
+func (a *App) newDashboard() *dashboard.Dashboard {
+	d := dashboard.New(a.infra, a.dashboardWidth(), a.dashboardHeight())
+	if a.thresholds != nil {
+		d.SetThresholds(*a.thresholds)
+	}
+	if a.glossary != nil {
+		d.SetGlossary(a.glossary)
 	}
+	return d
+}
+
+// newComparison builds the view for the latest comparison result, keeping
+// the help overlay open across +/- re-comparisons
+func (a *App) newComparison() *comparison.Comparison {
+	c := comparison.New(a.comparison, a.comparisonWidth())
+	c.SetGlossary(a.glossary)
+	if a.thresholds != nil {
+		c.SetThresholds(*a.thresholds)
+	}
+	if a.compView != nil && a.compView.HelpVisible() {
+		c.ToggleHelp()
+	}
+	return c
+}
 
-	firstCluster, ok := clusters[0].(map[string]interface{})
-	if !ok {
-		return false
+// loadThresholds fetches the backend's capacity thresholds once per session,
+// so the panels and legend match what the backend applies
+func (a *App) loadThresholds() tea.Cmd {
+	if a.thresholds != nil || a.client == nil {
+		return nil
 	}
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), loadTimeout)
+		defer cancel()
+		thresholds, err := a.client.GetThresholds(ctx)
+		return thresholdsLoadedMsg{thresholds: thresholds, err: err}
+	}
+}
 
-	// ManualInput format has memory_gb_per_host
-	_, hasPerHost := firstCluster["memory_gb_per_host"]
-	return hasPerHost
+// loadGlossary fetches the metric definitions once per session, when help is
+// first opened, so the panels explain metrics the way the backend computes them
+func (a *App) loadGlossary() tea.Cmd {
+	if a.glossary != nil || a.client == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), loadTimeout)
+		defer cancel()
+		glossary, err := a.client.GetMetricGlossary(ctx)
+		return glossaryLoadedMsg{glossary: glossary, err: err}
+	}
 }
 
-// computeManualInfrastructure calls the backend to compute infrastructure from manual input
-func (a *App) computeManualInfrastructure(input *client.ManualInput) tea.Cmd {
+// loadTPSSweep fetches the backend's TPS estimates for the dashboard chart,
+// which reflect any TPS_CURVE the operator configured
+func (a *App) loadTPSSweep() tea.Cmd {
+	if a.dashboard == nil || a.client == nil || a.infra == nil || a.infra.TotalCellCount == 0 {
+		return nil
+	}
+	cells := a.infra.TotalCellCount
+	minCells, maxCells, step := a.dashboard.TPSSweepRange()
 	return func() tea.Msg {
-		infra, err := a.client.SetManualInfrastructure(context.Background(), input)
+		ctx, cancel := context.WithTimeout(context.Background(), loadTimeout)
+		defer cancel()
+		sweep, err := a.client.GetScenarioSweep(ctx, minCells, maxCells, step)
 		if err != nil {
-			return infraLoadedMsg{err: err}
+			return tpsSweepLoadedMsg{cells: cells, err: err}
 		}
-		return infraLoadedMsg{infra: infra}
+		return tpsSweepLoadedMsg{cells: cells, results: sweep.Results}
 	}
 }
 
@@ -466,6 +755,8 @@ func (a *App) View() string {
 		content = a.viewComparison()
 	case ScreenWizard:
 		content = a.viewWizard()
+	case ScreenManualEntry:
+		content = a.viewManualEntry()
 	default:
 		content = a.viewMenu()
 	}
@@ -546,7 +837,9 @@ func (a *App) viewDashboard() string {
 	leftPane := ""
 	if a.loading {
 		// Show animated loading spinner
-		loadingContent := fmt.Sprintf("\n\n   %s Loading infrastructure data...\n\n", a.spinner.View())
+		remaining := max(time.Until(a.loadDeadline).Round(time.Second), 0)
+		loadingContent := fmt.Sprintf("\n\n   %s Loading infrastructure data... %s\n\n   %s\n",
+			a.spinner.View(), remaining, lipgloss.NewStyle().Foreground(styles.Muted).Render("Press Esc to cancel"))
 		leftPane = styles.Panel.Width(a.dashboardWidth()).Height(paneHeight).Render(loadingContent)
 	} else if a.dashboard != nil {
 		leftPane = styles.ActivePanel.Width(a.dashboardWidth()).Height(paneHeight).Render(a.dashboard.View())
@@ -575,6 +868,14 @@ func (a *App) viewWizard() string {
 	return ""
 }
 
+// viewManualEntry renders the manual infrastructure entry form
+func (a *App) viewManualEntry() string {
+	if a.manualEntry != nil {
+		return a.manualEntry.View()
+	}
+	return ""
+}
+
 // viewComparison renders the dashboard with comparison results
 func (a *App) viewComparison() string {
 	if a.err != nil {
@@ -669,6 +970,16 @@ func (a *App) contentHeight() int {
 	return height
 }
 
+// dashboardHeight returns the lines visible inside the dashboard pane: the
+// pane height used by viewDashboard minus its vertical padding
+func (a *App) dashboardHeight() int {
+	paneHeight := a.contentHeight() - 4
+	if paneHeight < 10 {
+		paneHeight = 10
+	}
+	return paneHeight - 2
+}
+
 // deriveInfraName extracts a display name for the infrastructure source
 func (a *App) deriveInfraName() string {
 	switch a.dataSource {
@@ -749,11 +1060,24 @@ func (a *App) renderFooter() string {
 	case ScreenFilePicker:
 		shortcuts = []string{"↑↓ Navigate", "Enter Select", "b Back", "q Quit"}
 	case ScreenDashboard:
-		shortcuts = []string{"r Refresh", "w Wizard", "b Back", "q Quit"}
+		shortcuts = []string{"r Refresh", "w Wizard", "e Export", "? Legend", "b Back", "q Quit"}
+		if a.loading {
+			shortcuts = []string{"Esc Cancel", "q Quit"}
+		}
+		if a.dashboard != nil && a.dashboard.ScrollIndicator() != "" {
+			shortcuts = append([]string{"↑↓ Scroll"}, shortcuts...)
+		}
 	case ScreenComparison:
-		shortcuts = []string{"w New scenario", "b Back", "q Quit"}
+		shortcuts = []string{"+/- Cells", "w New scenario", "e Export", "? Metrics", "b Back", "q Quit"}
 	case ScreenWizard:
 		shortcuts = []string{"↑↓ Select", "Enter Confirm", "Esc Cancel"}
+		if a.wizardScreen != nil && a.wizardScreen.Reviewing() {
+			shortcuts = []string{"Enter Run scenario", "b Back", "Esc Cancel"}
+		} else if a.wizardScreen != nil && a.wizardScreen.CanGoBack() {
+			shortcuts = []string{"↑↓ Select", "Enter Confirm", "b Back", "Esc Cancel"}
+		}
+	case ScreenManualEntry:
+		shortcuts = []string{"Tab Next", "Enter Confirm", "Esc Cancel"}
 	}
 
 	// Build styled shortcuts and plain text versions for width calculation
@@ -773,14 +1097,23 @@ func (a *App) renderFooter() string {
 	leftStyled := " " + strings.Join(styledShortcuts, "  ") + " "
 	leftPlain := " " + strings.Join(plainShortcuts, "  ") + " "
 
-	// Right side status (last update time)
+	// Right side status (export result on comparison and dashboard screens, otherwise last update time)
 	rightStyled := ""
 	rightPlain := ""
-	if !a.lastUpdate.IsZero() && a.screen != ScreenMenu && a.screen != ScreenFilePicker && a.screen != ScreenWizard {
+	if a.statusMessage != "" && (a.screen == ScreenComparison || a.screen == ScreenDashboard) {
+		rightStyled = " " + statusStyle.Render(a.statusMessage) + " "
+		rightPlain = " " + a.statusMessage + " "
+	} else if !a.lastUpdate.IsZero() && a.screen != ScreenMenu && a.screen != ScreenFilePicker && a.screen != ScreenWizard && a.screen != ScreenManualEntry {
 		elapsed := a.formatTimeSince(a.lastUpdate)
 		rightStyled = " " + statusStyle.Render("Updated "+elapsed) + " "
 		rightPlain = " Updated " + elapsed + " "
 	}
+	if a.screen == ScreenDashboard && a.dashboard != nil {
+		if indicator := a.dashboard.ScrollIndicator(); indicator != "" {
+			rightStyled = " " + statusStyle.Render(indicator) + rightStyled
+			rightPlain = " " + indicator + rightPlain
+		}
+	}
 
 	// Calculate fill width using lipgloss.Width for proper Unicode handling
 	// Total width - corners(2) - left content - right content
@@ -799,29 +1132,7 @@ func (a *App) renderFooter() string {
 
 // formatTimeSince formats a duration since the given time in human-readable form
 func (a *App) formatTimeSince(t time.Time) string {
-	d := time.Since(t)
-
-	if d < time.Minute {
-		secs := int(d.Seconds())
-		if secs < 5 {
-			return "just now"
-		}
-		return fmt.Sprintf("%ds ago", secs)
-	}
-
-	if d < time.Hour {
-		mins := int(d.Minutes())
-		if mins == 1 {
-			return "1m ago"
-		}
-		return fmt.Sprintf("%dm ago", mins)
-	}
-
-	hours := int(d.Hours())
-	if hours == 1 {
-		return "1h ago"
-	}
-	return fmt.Sprintf("%dh ago", hours)
+	return format.Age(time.Since(t))
 }
 
 // wrapWithFrame wraps content with header and footer, filling full terminal height
@@ -867,10 +1178,60 @@ func (a *App) wrapWithFrame(content string) string {
 }
 
 // loadInfrastructure creates a command to fetch infrastructure data
-func (a *App) loadInfrastructure() tea.Cmd {
+func (a *App) loadInfrastructure(ctx context.Context) tea.Cmd {
+	return func() tea.Msg {
+		infra, err := a.client.GetInfrastructure(ctx)
+		return loadResult(ctx, infra, err)
+	}
+}
+
+// refreshInfrastructure creates a command that forces the backend to
+// re-discover infrastructure instead of serving its cached copy
+func (a *App) refreshInfrastructure(ctx context.Context) tea.Cmd {
 	return func() tea.Msg {
-		infra, err := a.client.GetInfrastructure(context.Background())
-		return infraLoadedMsg{infra: infra, err: err}
+		infra, err := a.client.RefreshInfrastructure(ctx)
+		return loadResult(ctx, infra, err)
+	}
+}
+
+// loadResult builds the infraLoadedMsg for a finished call, reporting the
+// context's error when it was cancelled or timed out so the App can tell
+// those apart from backend failures
+func loadResult(ctx context.Context, infra *client.InfrastructureState, err error) infraLoadedMsg {
+	if err != nil && ctx.Err() != nil {
+		return infraLoadedMsg{err: ctx.Err()}
+	}
+	return infraLoadedMsg{infra: infra, err: err}
+}
+
+// startLoading enters the loading state and returns a context for the
+// backend call, cancelled by Esc or after loadTimeout
+func (a *App) startLoading() context.Context {
+	a.stopLoading()
+	ctx, cancel := context.WithTimeout(context.Background(), loadTimeout)
+	a.cancelLoad = cancel
+	a.loadDeadline = time.Now().Add(loadTimeout)
+	a.loading = true
+	return ctx
+}
+
+// stopLoading leaves the loading state, cancelling any in-flight call
+func (a *App) stopLoading() {
+	if a.cancelLoad != nil {
+		a.cancelLoad()
+		a.cancelLoad = nil
+	}
+	a.loading = false
+}
+
+// returnToMenu goes back to the data source menu showing notice
+func (a *App) returnToMenu(notice string) {
+	a.screen = ScreenMenu
+	a.dashboard = nil
+	a.infra = nil
+	a.err = nil
+	if a.menu != nil {
+		a.menu.SetNotice(notice)
 	}
 }
 
@@ -882,20 +1243,78 @@ func (a *App) runWizard() tea.Cmd {
 	return a.wizardScreen.Init()
 }
 
-// compareScenario calls the backend to compare the scenario
+// compareScenario calls the backend to compare the scenario, remembering the
+// input so +/- on the comparison screen can re-run it with another cell count
 func (a *App) compareScenario(input *client.ScenarioInput) tea.Cmd {
+	a.lastScenario = input
+	a.pendingCells = input.ProposedCellCount
+	a.compareSeq++
+	seq := a.compareSeq
 	return func() tea.Msg {
 		result, err := a.client.CompareScenario(context.Background(), input)
-		return scenarioComparedMsg{result: result, err: err}
+		return scenarioComparedMsg{result: result, err: err, seq: seq}
+	}
+}
+
+// adjustCellCount moves the proposed cell count by delta and schedules a
+// re-comparison once presses stop for cellCountDebounce
+func (a *App) adjustCellCount(delta int) tea.Cmd {
+	if a.lastScenario == nil {
+		return nil
+	}
+	a.pendingCells += delta
+	if a.pendingCells < 1 {
+		a.pendingCells = 1
+	}
+	a.statusMessage = fmt.Sprintf("Proposed cells: %d", a.pendingCells)
+	a.sliderSeq++
+	seq := a.sliderSeq
+	return tea.Tick(cellCountDebounce, func(time.Time) tea.Msg {
+		return cellCountSettledMsg{seq: seq}
+	})
+}
+
+// exportReport writes the current comparison to a Markdown file in the working directory
+func (a *App) exportReport() tea.Cmd {
+	comparison := a.comparison
+	if a.redactExports {
+		comparison = report.NewRedactor(a.infra).Comparison(comparison)
+	}
+	return func() tea.Msg {
+		dir, err := os.Getwd()
+		if err != nil {
+			return reportExportedMsg{err: err}
+		}
+		path, err := report.WriteMarkdown(dir, comparison, time.Now())
+		return reportExportedMsg{path: path, err: err}
+	}
+}
+
+// exportManualInput writes the current infrastructure as a ManualInput JSON
+// file in the working directory, for editing and loading back as a JSON file
+func (a *App) exportManualInput() tea.Cmd {
+	infra := a.infra
+	if a.redactExports {
+		infra = report.NewRedactor(infra).InfrastructureState(infra)
+	}
+	return func() tea.Msg {
+		dir, err := os.Getwd()
+		if err != nil {
+			return reportExportedMsg{err: err}
+		}
+		path, err := report.WriteManualInput(dir, infra, time.Now())
+		return reportExportedMsg{path: path, err: err}
 	}
 }
 
-// Run starts the TUI
-func Run(apiClient *client.Client, vsphereConfigured bool) error {
+// Run starts the TUI. With redactExports, files exported from it name the
+// foundation and clusters by pseudonyms such as cluster-1.
+func Run(apiClient *client.Client, vsphereConfigured, redactExports bool) error {
 	// Find repository base path for sample files
 	repoBasePath := findRepoBasePath()
 
 	app := New(apiClient, vsphereConfigured, repoBasePath)
+	app.redactExports = redactExports
 
 	p := tea.NewProgram(
 		app,
@@ -4,10 +4,20 @@
 package tui
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/markalston/diego-capacity-analyzer/cli/internal/client"
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/tui/manualentry"
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/tui/menu"
 )
 
 func TestAppInitialState(t *testing.T) {
@@ -66,6 +76,29 @@ func TestAppInfraLoadedMsg(t *testing.T) {
 	}
 }
 
+func TestAppInfraLoadedMsg_NoDiegoCells(t *testing.T) {
+	c := client.New("http://localhost:8080")
+	app := New(c, true, "")
+	app.width = 100
+	app.height = 40
+
+	// A vSphere discovery that matched no cells must not render as a dashboard
+	app.Update(menu.DataSourceSelectedMsg{Source: menu.SourceVSphere})
+	infra := &client.InfrastructureState{Name: "DC0", Source: "vsphere", TotalHostCount: 4}
+	updatedApp, _ := app.Update(infraLoadedMsg{infra: infra})
+
+	result := updatedApp.(*App)
+	if result.dashboard != nil || result.infra != nil {
+		t.Error("expected no dashboard for a discovery without cells")
+	}
+	if result.err == nil || !strings.Contains(result.err.Error(), "no Diego cells discovered") {
+		t.Errorf("expected the no-cells error, got %v", result.err)
+	}
+	if view := result.View(); !strings.Contains(view, "VSPHERE_CELL_NAME_PATTERNS") {
+		t.Errorf("expected the error in the view, got:\n%s", view)
+	}
+}
+
 func TestAppScenarioComparedMsg(t *testing.T) {
 	c := client.New("http://localhost:8080")
 	app := New(c, false, "")
@@ -94,6 +127,307 @@ func TestAppScenarioComparedMsg(t *testing.T) {
 	}
 }
 
+func TestAppExportManualInput(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	c := client.New("http://localhost:8080")
+	app := New(c, false, "")
+	app.width = 120
+	app.height = 40
+	app.screen = ScreenDashboard
+	app.infra = &client.InfrastructureState{
+		Name: "vcenter",
+		Clusters: []client.ClusterState{
+			{Name: "c1", HostCount: 4, MemoryGB: 2048, MemoryGBPerHost: 512, DiegoCellCount: 10, DiegoCellMemoryGB: 64},
+		},
+	}
+
+	_, cmd := app.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("e")})
+	if cmd == nil {
+		t.Fatal("expected export command from 'e' key on dashboard")
+	}
+
+	exported, ok := cmd().(reportExportedMsg)
+	if !ok {
+		t.Fatal("expected reportExportedMsg")
+	}
+	if exported.err != nil {
+		t.Fatalf("export failed: %v", exported.err)
+	}
+	data, err := os.ReadFile(exported.path)
+	if err != nil {
+		t.Fatalf("expected manual input file at %s: %v", exported.path, err)
+	}
+	if format, _ := client.DetectFormat(data); format != client.FormatManualInput {
+		t.Errorf("expected exported file in %s format, got %q", client.FormatManualInput, format)
+	}
+
+	app.Update(exported)
+	if !strings.Contains(app.renderFooter(), "Saved "+filepath.Base(exported.path)) {
+		t.Error("expected dashboard footer to show saved export")
+	}
+}
+
+func TestAppExportManualInput_Redacted(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	app := New(client.New("http://localhost:8080"), false, "")
+	app.redactExports = true
+	app.screen = ScreenDashboard
+	app.infra = &client.InfrastructureState{
+		Name: "vcenter.corp.example",
+		Clusters: []client.ClusterState{
+			{Name: "pci-prod", HostCount: 4, MemoryGB: 2048, MemoryGBPerHost: 512, DiegoCellCount: 10, DiegoCellMemoryGB: 64},
+		},
+	}
+
+	_, cmd := app.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("e")})
+	exported, ok := cmd().(reportExportedMsg)
+	if !ok || exported.err != nil {
+		t.Fatalf("expected a successful export, got %+v", exported)
+	}
+	data, err := os.ReadFile(exported.path)
+	if err != nil {
+		t.Fatalf("expected manual input file at %s: %v", exported.path, err)
+	}
+	var input client.ManualInput
+	if err := json.Unmarshal(data, &input); err != nil {
+		t.Fatalf("expected manual input JSON: %v", err)
+	}
+	if input.Name != "foundation-1" || input.Clusters[0].Name != "cluster-1" || input.Clusters[0].DiegoCellCount != 10 {
+		t.Errorf("expected pseudonyms with numbers kept, got %+v", input)
+	}
+	if app.infra.Clusters[0].Name != "pci-prod" {
+		t.Errorf("expected the loaded state to keep its names, got %q", app.infra.Clusters[0].Name)
+	}
+}
+
+func TestAppExportReport(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	c := client.New("http://localhost:8080")
+	app := New(c, false, "")
+	app.width = 120
+	app.height = 40
+	app.screen = ScreenComparison
+	app.comparison = &client.ScenarioComparison{
+		Current:  client.ScenarioResult{CellCount: 10},
+		Proposed: client.ScenarioResult{CellCount: 12},
+	}
+
+	_, cmd := app.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("e")})
+	if cmd == nil {
+		t.Fatal("expected export command from 'e' key")
+	}
+
+	msg := cmd()
+	exported, ok := msg.(reportExportedMsg)
+	if !ok {
+		t.Fatalf("expected reportExportedMsg, got %T", msg)
+	}
+	if exported.err != nil {
+		t.Fatalf("export failed: %v", exported.err)
+	}
+	if _, err := os.Stat(exported.path); err != nil {
+		t.Errorf("expected report file at %s: %v", exported.path, err)
+	}
+
+	app.Update(exported)
+	if !strings.Contains(app.statusMessage, filepath.Base(exported.path)) {
+		t.Errorf("expected status message to contain report path, got %q", app.statusMessage)
+	}
+	if !strings.Contains(app.renderFooter(), "Saved") {
+		t.Error("expected footer to show saved report status")
+	}
+}
+
+func TestAppDashboardLegendToggle(t *testing.T) {
+	app := New(nil, false, "")
+	app.Update(tea.WindowSizeMsg{Width: 120, Height: 60})
+	app.Update(infraLoadedMsg{infra: &client.InfrastructureState{Name: "test", TotalHostCount: 4, HAStatus: "ok"}})
+
+	if !strings.Contains(app.renderFooter(), "Legend") {
+		t.Error("expected footer to offer the legend")
+	}
+	app.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("?")})
+	if !app.dashboard.LegendVisible() {
+		t.Fatal("expected '?' to expand the legend")
+	}
+	if !strings.Contains(app.View(), "Scenario warnings") {
+		t.Error("expected the dashboard to show the legend")
+	}
+}
+
+func TestAppLoadsBackendThresholds(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/thresholds" {
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+		thresholds := client.DefaultThresholds()
+		thresholds.N1CriticalPct = 80
+		json.NewEncoder(w).Encode(thresholds)
+	}))
+	defer server.Close()
+
+	app := New(client.New(server.URL, client.WithRetries(0)), false, "")
+	app.Update(tea.WindowSizeMsg{Width: 120, Height: 60})
+	_, cmd := app.Update(infraLoadedMsg{infra: &client.InfrastructureState{Name: "test", TotalHostCount: 4, HAStatus: "ok"}})
+	if cmd == nil {
+		t.Fatal("expected loading infrastructure to fetch thresholds")
+	}
+	app.Update(cmd())
+
+	if app.thresholds == nil || app.thresholds.N1CriticalPct != 80 {
+		t.Fatalf("expected backend thresholds to be stored, got %+v", app.thresholds)
+	}
+	app.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("?")})
+	if !strings.Contains(app.View(), "above 75% / 80%") {
+		t.Error("expected the legend to show the backend's N-1 critical threshold")
+	}
+
+	// Fetched once per session
+	if _, cmd := app.Update(infraLoadedMsg{infra: app.infra}); cmd != nil {
+		t.Error("expected thresholds not to be fetched again")
+	}
+}
+
+func TestAppComparisonMetricGlossary(t *testing.T) {
+	var glossaryRequests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/metrics/glossary":
+			glossaryRequests++
+			w.Write([]byte(`{"metrics":[{"key":"free_chunks","name":"Free chunks","description":"Instances that still fit.","formula":"free memory ÷ chunk size"}]}`))
+		default:
+			json.NewEncoder(w).Encode(client.ScenarioComparison{
+				Current:  client.ScenarioResult{CellCount: 10},
+				Proposed: client.ScenarioResult{CellCount: 12},
+			})
+		}
+	}))
+	defer server.Close()
+
+	app := New(client.New(server.URL, client.WithRetries(0)), false, "")
+	app.width = 120
+	app.height = 60
+	app.Update(app.compareScenario(&client.ScenarioInput{ProposedCellMemoryGB: 64, ProposedCellCount: 12})())
+	if !strings.Contains(app.renderFooter(), "? Metrics") {
+		t.Error("expected comparison footer to offer the metric definitions")
+	}
+
+	_, cmd := app.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("?")})
+	if cmd == nil {
+		t.Fatal("expected '?' to fetch the metric glossary")
+	}
+	app.Update(cmd())
+	if !app.compView.HelpVisible() {
+		t.Fatal("expected '?' to open the help overlay")
+	}
+	if !strings.Contains(app.View(), "free memory ÷ chunk size") {
+		t.Error("expected the overlay to show the backend's formula")
+	}
+
+	// The overlay stays open across re-comparisons, and the glossary is fetched once
+	app.Update(app.compareScenario(&client.ScenarioInput{ProposedCellMemoryGB: 64, ProposedCellCount: 13})())
+	if !app.compView.HelpVisible() {
+		t.Error("expected the overlay to stay open after a re-comparison")
+	}
+	app.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("?")})
+	if _, cmd := app.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("?")}); cmd != nil {
+		t.Error("expected the glossary not to be fetched again")
+	}
+	if glossaryRequests != 1 {
+		t.Errorf("expected one glossary request, got %d", glossaryRequests)
+	}
+}
+
+func TestAppCellCountSlider(t *testing.T) {
+	var requested []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var input client.ScenarioInput
+		json.NewDecoder(r.Body).Decode(&input)
+		requested = append(requested, input.ProposedCellCount)
+		json.NewEncoder(w).Encode(client.ScenarioComparison{
+			Current:  client.ScenarioResult{CellCount: 10},
+			Proposed: client.ScenarioResult{CellCount: input.ProposedCellCount},
+		})
+	}))
+	defer server.Close()
+
+	app := New(client.New(server.URL, client.WithRetries(0)), false, "")
+	app.width = 120
+	app.height = 40
+	app.Update(app.compareScenario(&client.ScenarioInput{ProposedCellMemoryGB: 64, ProposedCellCount: 12})())
+	if app.screen != ScreenComparison {
+		t.Fatalf("expected comparison screen, got %d", app.screen)
+	}
+
+	plus := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("+")}
+	for i := 0; i < 3; i++ {
+		if _, cmd := app.Update(plus); cmd == nil {
+			t.Fatal("expected '+' to schedule a re-comparison")
+		}
+	}
+	if app.pendingCells != 15 {
+		t.Errorf("expected 15 pending cells, got %d", app.pendingCells)
+	}
+	if !strings.Contains(app.renderFooter(), "Proposed cells: 15") {
+		t.Error("expected footer to show the pending cell count")
+	}
+
+	// Only the last press in a burst re-runs the scenario
+	if _, cmd := app.Update(cellCountSettledMsg{seq: app.sliderSeq - 1}); cmd != nil {
+		t.Error("expected an earlier press to be debounced")
+	}
+	_, cmd := app.Update(cellCountSettledMsg{seq: app.sliderSeq})
+	if cmd == nil {
+		t.Fatal("expected the last press to re-run the scenario")
+	}
+	app.Update(cmd())
+
+	if len(requested) != 2 || requested[1] != 15 {
+		t.Errorf("expected one re-comparison with 15 cells, got requests %v", requested)
+	}
+	if app.screen != ScreenComparison || app.comparison.Proposed.CellCount != 15 {
+		t.Errorf("expected comparison updated in place with 15 cells, got screen %d", app.screen)
+	}
+	if app.lastScenario.ProposedCellMemoryGB != 64 {
+		t.Error("expected the rest of the scenario to be kept")
+	}
+	if app.statusMessage != "" {
+		t.Errorf("expected status cleared once results arrive, got %q", app.statusMessage)
+	}
+}
+
+func TestAppCellCountSlider_Bounds(t *testing.T) {
+	app := New(nil, false, "")
+	app.screen = ScreenComparison
+
+	if _, cmd := app.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("+")}); cmd != nil {
+		t.Error("expected '+' to do nothing without a previous scenario")
+	}
+
+	app.lastScenario = &client.ScenarioInput{ProposedCellCount: 1}
+	app.pendingCells = 1
+	app.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("-")})
+	if app.pendingCells != 1 {
+		t.Errorf("expected cell count to stay at 1, got %d", app.pendingCells)
+	}
+}
+
+func TestAppScenarioComparedMsg_Stale(t *testing.T) {
+	app := New(nil, false, "")
+	app.width = 100
+	app.height = 40
+	app.screen = ScreenComparison
+	app.compareSeq = 2
+
+	app.Update(scenarioComparedMsg{result: &client.ScenarioComparison{}, seq: 1})
+	if app.comparison != nil {
+		t.Error("expected a superseded comparison result to be dropped")
+	}
+}
+
 func TestAppViewReturnsContent(t *testing.T) {
 	c := client.New("http://localhost:8080")
 	app := New(c, false, "")
@@ -138,47 +472,155 @@ func TestAppVSphereConfigured(t *testing.T) {
 	}
 }
 
-func TestIsManualInputFormat(t *testing.T) {
-	tests := []struct {
-		name     string
-		json     string
-		expected bool
-	}{
-		{
-			name: "ManualInput format",
-			json: `{
-				"name": "Test",
-				"clusters": [{"name": "c1", "host_count": 4, "memory_gb_per_host": 256}]
-			}`,
-			expected: true,
-		},
-		{
-			name: "InfrastructureState format",
-			json: `{
-				"name": "Test",
-				"clusters": [{"name": "c1", "host_count": 4, "memory_gb": 1024}],
-				"total_host_count": 4
-			}`,
-			expected: false,
-		},
-		{
-			name:     "Empty clusters",
-			json:     `{"name": "Test", "clusters": []}`,
-			expected: false,
-		},
-		{
-			name:     "Invalid JSON",
-			json:     `{invalid}`,
-			expected: false,
-		},
+func TestAppDashboardScroll(t *testing.T) {
+	app := New(nil, false, "")
+	model, _ := app.Update(tea.WindowSizeMsg{Width: 120, Height: 24})
+	app = model.(*App)
+
+	infra := &client.InfrastructureState{
+		Name:           "Multi-cluster",
+		TotalMemoryGB:  8192,
+		TotalHostCount: 16,
+		TotalCellCount: 120,
+		HAStatus:       "ok",
 	}
+	model, _ = app.Update(infraLoadedMsg{infra: infra})
+	app = model.(*App)
 
-	for _, tc := range tests {
-		t.Run(tc.name, func(t *testing.T) {
-			result := isManualInputFormat([]byte(tc.json))
-			if result != tc.expected {
-				t.Errorf("isManualInputFormat() = %v, want %v", result, tc.expected)
-			}
-		})
+	view := app.View()
+	if lines := strings.Count(view, "\n") + 1; lines > 24 {
+		t.Errorf("expected the dashboard to scroll within a 24 line terminal, got %d lines", lines)
+	}
+	if !strings.Contains(view, "↕ 0%") {
+		t.Error("expected scroll indicator in footer for overflowing dashboard")
+	}
+	if !strings.Contains(view, "Scroll") {
+		t.Error("expected scroll shortcut in footer")
+	}
+
+	model, _ = app.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'j'}})
+	app = model.(*App)
+	if strings.Contains(app.View(), "↕ 0%") {
+		t.Error("expected j to scroll the dashboard down")
+	}
+
+	// b still goes back rather than paging up
+	model, _ = app.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'b'}})
+	app = model.(*App)
+	if app.screen != ScreenMenu {
+		t.Errorf("expected b to return to the menu, got screen %d", app.screen)
+	}
+}
+
+func TestAppManualEntryFlow(t *testing.T) {
+	c := client.New("http://localhost:8080")
+	app := New(c, false, "")
+	app.width = 120
+	app.height = 40
+
+	app.Update(menu.DataSourceSelectedMsg{Source: menu.SourceManual})
+	if app.screen != ScreenManualEntry {
+		t.Fatalf("expected ScreenManualEntry, got %d", app.screen)
+	}
+	if app.manualEntry == nil {
+		t.Fatal("expected manual entry form to be initialized")
+	}
+	if !strings.Contains(app.View(), "Manual Infrastructure Input") {
+		t.Error("expected view to show the manual entry form")
+	}
+
+	app.Update(manualentry.CancelledMsg{})
+	if app.screen != ScreenMenu {
+		t.Errorf("expected cancel to return to ScreenMenu, got %d", app.screen)
+	}
+	if app.manualEntry != nil {
+		t.Error("expected manual entry form to be cleared on cancel")
+	}
+
+	app.Update(menu.DataSourceSelectedMsg{Source: menu.SourceManual})
+	_, cmd := app.Update(manualentry.SubmittedMsg{Input: app.manualEntry.Input()})
+	if app.screen != ScreenDashboard {
+		t.Errorf("expected submit to switch to ScreenDashboard, got %d", app.screen)
+	}
+	if !app.loading {
+		t.Error("expected loading state while the backend computes infrastructure")
+	}
+	if cmd == nil {
+		t.Error("expected a command to compute the manual infrastructure")
+	}
+}
+
+func TestAppLoadingCancel(t *testing.T) {
+	c := client.New("http://localhost:8080")
+	app := New(c, true, "")
+	app.width = 120
+	app.height = 40
+
+	app.Update(menu.DataSourceSelectedMsg{Source: menu.SourceVSphere})
+	if !app.loading || app.cancelLoad == nil {
+		t.Fatal("expected a cancellable loading state")
+	}
+	if !strings.Contains(app.View(), "Press Esc to cancel") {
+		t.Error("expected cancel hint while loading")
+	}
+
+	app.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if app.loading {
+		t.Error("expected Esc to stop loading")
+	}
+	if app.screen != ScreenMenu {
+		t.Errorf("expected Esc to return to ScreenMenu, got %d", app.screen)
+	}
+	if !strings.Contains(app.View(), "Loading cancelled") {
+		t.Error("expected the menu to explain the load was cancelled")
+	}
+
+	// The cancelled call's result arrives later and is ignored
+	app.Update(infraLoadedMsg{err: context.Canceled})
+	if app.screen != ScreenMenu || app.err != nil {
+		t.Errorf("expected cancelled result to be ignored, screen %d err %v", app.screen, app.err)
+	}
+}
+
+func TestAppLoadingTimeout(t *testing.T) {
+	c := client.New("http://localhost:8080")
+	app := New(c, true, "")
+
+	app.Update(menu.DataSourceSelectedMsg{Source: menu.SourceVSphere})
+	app.Update(infraLoadedMsg{err: context.DeadlineExceeded})
+
+	if app.loading {
+		t.Error("expected timeout to stop loading")
+	}
+	if app.screen != ScreenMenu {
+		t.Errorf("expected timeout to return to ScreenMenu, got %d", app.screen)
+	}
+	if !strings.Contains(app.View(), "Backend did not respond within 30s") {
+		t.Error("expected the menu to explain the timeout")
+	}
+}
+
+func TestLoadInfrastructureCancelledContext(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	app := New(client.New(server.URL), true, "")
+	ctx, cancel := context.WithCancel(context.Background())
+	cmd := app.loadInfrastructure(ctx)
+	cancel()
+
+	msg, ok := cmd().(infraLoadedMsg)
+	if !ok {
+		t.Fatal("expected infraLoadedMsg")
+	}
+	if !errors.Is(msg.err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", msg.err)
 	}
 }
@@ -9,6 +9,7 @@ import (
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/markalston/diego-capacity-analyzer/cli/internal/client"
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/format"
 	"github.com/markalston/diego-capacity-analyzer/cli/internal/tui/icons"
 	"github.com/markalston/diego-capacity-analyzer/cli/internal/tui/styles"
 	"github.com/markalston/diego-capacity-analyzer/cli/internal/tui/widgets"
@@ -16,23 +17,49 @@ import (
 
 // Comparison displays scenario comparison results
 type Comparison struct {
-	result *client.ScenarioComparison
-	width  int
+	result     *client.ScenarioComparison
+	width      int
+	glossary   []client.MetricDefinition // Metric definitions for the help overlay
+	showHelp   bool                      // Whether the help overlay replaces the results
+	thresholds client.Thresholds         // Boundaries for utilization bar colors
 }
 
 // New creates a new comparison view
 func New(result *client.ScenarioComparison, width int) *Comparison {
 	return &Comparison{
-		result: result,
-		width:  width,
+		result:     result,
+		width:      width,
+		thresholds: client.DefaultThresholds(),
 	}
 }
 
+// SetThresholds replaces the built-in thresholds the utilization bars use
+// with the backend's, from GET /api/v1/thresholds
+func (c *Comparison) SetThresholds(t client.Thresholds) {
+	c.thresholds = t
+}
+
 // SetSize updates the view dimensions for terminal resize
 func (c *Comparison) SetSize(width int) {
 	c.width = width
 }
 
+// SetGlossary sets the metric definitions shown by the help overlay, from
+// GET /api/v1/metrics/glossary
+func (c *Comparison) SetGlossary(defs []client.MetricDefinition) {
+	c.glossary = defs
+}
+
+// ToggleHelp shows or hides the metric definitions in place of the results
+func (c *Comparison) ToggleHelp() {
+	c.showHelp = !c.showHelp
+}
+
+// HelpVisible reports whether the help overlay is shown
+func (c *Comparison) HelpVisible() bool {
+	return c.showHelp
+}
+
 // View renders the comparison
 func (c *Comparison) View() string {
 	if c.result == nil {
@@ -52,6 +79,11 @@ func (c *Comparison) View() string {
 		contentWidth = 40
 	}
 
+	if c.showHelp {
+		sb.WriteString(c.buildPanel("Metrics", icons.Info, widgets.Glossary(c.glossary, contentWidth-6), contentWidth-2))
+		return lipgloss.NewStyle().Width(c.width).Render(sb.String())
+	}
+
 	// For side-by-side panels: each panel = (contentWidth - 2) / 2
 	colWidth := (contentWidth - 2) / 2
 
@@ -82,6 +114,14 @@ func (c *Comparison) View() string {
 		sb.WriteString(warningsPanel)
 	}
 
+	// Recommendations panel
+	if len(c.result.Recommendations) > 0 {
+		if len(c.result.Warnings) > 0 {
+			sb.WriteString("\n\n")
+		}
+		sb.WriteString(c.renderRecommendationsPanel(contentWidth - 2))
+	}
+
 	return lipgloss.NewStyle().Width(c.width).Render(sb.String())
 }
 
@@ -90,8 +130,8 @@ func (c *Comparison) renderScenarioPanel(title string, icon icons.Icon, s *clien
 
 	// Cell info
 	sb.WriteString(fmt.Sprintf("Cells:     %d\n", s.CellCount))
-	sb.WriteString(fmt.Sprintf("Memory:    %d GB each\n", s.CellMemoryGB))
-	sb.WriteString(fmt.Sprintf("Total:     %d GB\n", s.AppCapacityGB))
+	sb.WriteString(fmt.Sprintf("Memory:    %s each\n", format.GBInt(s.CellMemoryGB)))
+	sb.WriteString(fmt.Sprintf("Total:     %s\n", format.GBInt(s.AppCapacityGB)))
 	sb.WriteString("\n")
 
 	// Utilization with progress bar
@@ -103,7 +143,7 @@ func (c *Comparison) renderScenarioPanel(title string, icon icons.Icon, s *clien
 		barWidth = 10 // minimum bar width
 	}
 
-	barConfig := widgets.DefaultProgressBarConfig()
+	barConfig := widgets.DefaultProgressBarConfig(c.thresholds.UtilizationWarningPct, c.thresholds.UtilizationCriticalPct)
 	barConfig.Width = barWidth
 	barConfig.ShowZones = false // Disable zones for compact display
 	bar := widgets.ProgressBarWithLabel(s.UtilizationPct, barConfig, true)
@@ -144,7 +184,7 @@ func (c *Comparison) renderImpactPanel(width int) string {
 
 	capacityStyle := lipgloss.NewStyle().Foreground(capacityColor).Bold(true)
 	sb.WriteString(fmt.Sprintf("Capacity:      %s\n",
-		capacityStyle.Render(fmt.Sprintf("%s%d GB (%s%.0f%%)", capacityPrefix, capacityChange, capacityPrefix, capacityPct))))
+		capacityStyle.Render(fmt.Sprintf("%s (%s%.0f%%)", format.SignedGB(capacityChange), capacityPrefix, capacityPct))))
 
 	// Utilization change (inverted - decrease is good)
 	utilChange := delta.UtilizationChangePct
@@ -186,6 +226,19 @@ func (c *Comparison) renderImpactPanel(width int) string {
 	sb.WriteString(fmt.Sprintf("Headroom:      %s",
 		headroomStyle.Render(fmt.Sprintf("%+.1f%% available", headroomChange))))
 
+	// Host removal (only when the scenario takes hosts out)
+	if proposed := c.result.Proposed; proposed.HostsRemoved > 0 {
+		removalColor := styles.DeltaNeutral
+		if proposed.N1UtilizationPct > 100 {
+			removalColor = styles.DeltaNegative
+		}
+		removalStyle := lipgloss.NewStyle().Foreground(removalColor).Bold(true)
+		sb.WriteString(fmt.Sprintf("\nHost removal:  %d host(s), %d cells to evacuate  %s",
+			proposed.HostsRemoved,
+			proposed.CellsToEvacuate,
+			removalStyle.Render(fmt.Sprintf("(N-1 at %.1f%%)", proposed.N1UtilizationPct))))
+	}
+
 	return c.buildPanel("Impact Summary", icons.TrendUp, sb.String(), width)
 }
 
@@ -211,16 +264,7 @@ func (c *Comparison) renderWarningsPanel(width int) string {
 		wrappedLines := wrapText(message, textWidth)
 
 		// Determine the color for this warning based on status
-		var statusColor lipgloss.Color
-		switch status {
-		case widgets.StatusCritical:
-			statusColor = widgets.BadgeCritBg
-		case widgets.StatusWarning:
-			statusColor = widgets.BadgeWarnBg
-		default:
-			statusColor = widgets.BadgeNeutralBg
-		}
-		textStyle := lipgloss.NewStyle().Foreground(statusColor)
+		textStyle := lipgloss.NewStyle().Foreground(widgets.StatusColor(status))
 
 		for j, line := range wrappedLines {
 			if j == 0 {
@@ -238,6 +282,47 @@ func (c *Comparison) renderWarningsPanel(width int) string {
 	return c.buildPanel("Warnings", icons.Warning, sb.String(), width)
 }
 
+func (c *Comparison) renderRecommendationsPanel(width int) string {
+	var sb strings.Builder
+
+	textWidth := width - 8
+	if textWidth < 20 {
+		textWidth = 20
+	}
+
+	titleStyle := lipgloss.NewStyle().Bold(true)
+	mutedStyle := lipgloss.NewStyle().Foreground(styles.Muted)
+
+	for i, rec := range c.result.Recommendations {
+		sb.WriteString(fmt.Sprintf("%d. %s", rec.Priority, titleStyle.Render(rec.Title)))
+		if rec.EstimatedCost != nil && rec.EstimatedCost.Tier != "" {
+			sb.WriteString("  " + costBadge(rec.EstimatedCost.Tier))
+		}
+		for _, line := range wrapText(rec.Description, textWidth-3) {
+			sb.WriteString("\n   " + mutedStyle.Render(line))
+		}
+		if i < len(c.result.Recommendations)-1 {
+			sb.WriteString("\n")
+		}
+	}
+
+	return c.buildPanel("Recommendations", icons.Info, sb.String(), width)
+}
+
+// costBadge renders a recommendation cost tier, from green (low) to red (high)
+func costBadge(tier string) string {
+	var status widgets.StatusLevel
+	switch tier {
+	case "low":
+		status = widgets.StatusOK
+	case "medium":
+		status = widgets.StatusWarning
+	default:
+		status = widgets.StatusCritical
+	}
+	return widgets.Badge(tier+" cost", status)
+}
+
 // wrapText wraps text to fit within the specified width
 func wrapText(text string, width int) []string {
 	if width <= 0 {
@@ -87,6 +87,74 @@ func TestComparisonViewWithWarnings(t *testing.T) {
 	}
 }
 
+func TestComparisonViewHelpOverlay(t *testing.T) {
+	result := &client.ScenarioComparison{
+		Current:  client.ScenarioResult{CellCount: 10, CellMemoryGB: 64},
+		Proposed: client.ScenarioResult{CellCount: 12, CellMemoryGB: 64},
+		Warnings: []client.ScenarioWarning{{Severity: "warning", Message: "High utilization risk"}},
+	}
+
+	c := New(result, 80)
+	c.SetGlossary([]client.MetricDefinition{
+		{Key: "blast_radius_pct", Name: "Blast radius", Description: "Share of instances lost when the busiest host fails.", Formula: "⌈cells ÷ hosts⌉ ÷ cells × 100"},
+	})
+	if c.HelpVisible() || strings.Contains(c.View(), "Blast radius") {
+		t.Error("expected help hidden by default")
+	}
+
+	c.ToggleHelp()
+	view := c.View()
+	for _, want := range []string{"Metrics", "Blast radius", "= ⌈cells ÷ hosts⌉ ÷ cells × 100"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected help overlay to contain %q\nView:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "High utilization risk") {
+		t.Error("expected help overlay to replace the results")
+	}
+
+	c.ToggleHelp()
+	if !strings.Contains(c.View(), "High utilization risk") {
+		t.Error("expected second toggle to restore the results")
+	}
+}
+
+func TestComparisonViewWithRecommendations(t *testing.T) {
+	result := &client.ScenarioComparison{
+		Current:  client.ScenarioResult{CellCount: 10, CellMemoryGB: 64},
+		Proposed: client.ScenarioResult{CellCount: 10, CellMemoryGB: 64},
+		Recommendations: []client.Recommendation{
+			{
+				Priority:      1,
+				Title:         "Add Diego Cells",
+				Description:   "Add 4 cells to bring utilization below 80%",
+				EstimatedCost: &client.EstimatedCost{Tier: "medium"},
+			},
+			{
+				Priority:    2,
+				Title:       "Resize Diego Cells",
+				Description: "Resize cells to 128 GB",
+			},
+		},
+	}
+
+	c := New(result, 100)
+	view := c.View()
+
+	if !strings.Contains(view, "Recommendations") {
+		t.Error("expected view to contain 'Recommendations' section")
+	}
+	if !strings.Contains(view, "Add Diego Cells") {
+		t.Error("expected view to contain recommendation title")
+	}
+	if !strings.Contains(view, "medium cost") {
+		t.Error("expected view to show the cost tier next to the recommendation")
+	}
+	if strings.Count(view, " cost") != 1 {
+		t.Error("expected no cost tier for a recommendation without an estimate")
+	}
+}
+
 func TestComparisonViewWithVCPURatio(t *testing.T) {
 	result := &client.ScenarioComparison{
 		Current: client.ScenarioResult{
@@ -145,3 +213,30 @@ func TestComparisonViewNegativeCapacityChange(t *testing.T) {
 		t.Error("expected view to contain negative capacity change")
 	}
 }
+
+func TestComparisonViewLargeCapacityInTB(t *testing.T) {
+	result := &client.ScenarioComparison{
+		Current: client.ScenarioResult{
+			CellCount:     200,
+			CellMemoryGB:  64,
+			AppCapacityGB: 10752,
+		},
+		Proposed: client.ScenarioResult{
+			CellCount:     250,
+			CellMemoryGB:  64,
+			AppCapacityGB: 13312,
+		},
+		Delta: client.ScenarioDelta{
+			CapacityChangeGB: 2560,
+		},
+	}
+
+	c := New(result, 100)
+	view := c.View()
+
+	for _, want := range []string{"10.5 TB", "13 TB", "+2.5 TB", "64 GB each"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected view to contain %q", want)
+		}
+	}
+}
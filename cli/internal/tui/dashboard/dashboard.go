@@ -6,12 +6,17 @@ package dashboard
 import (
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
 
 	"github.com/markalston/diego-capacity-analyzer/cli/internal/client"
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/format"
 	"github.com/markalston/diego-capacity-analyzer/cli/internal/tui/icons"
 	"github.com/markalston/diego-capacity-analyzer/cli/internal/tui/styles"
 	"github.com/markalston/diego-capacity-analyzer/cli/internal/tui/widgets"
@@ -24,9 +29,27 @@ type Dashboard struct {
 	height        int
 	historyMemory []float64 // Historical memory values for sparkline
 	historyCPU    []float64 // Historical CPU ratio values for sparkline
+	viewport      viewport.Model
+	showLegend    bool                      // Whether the status legend panel is expanded
+	thresholds    client.Thresholds         // Boundaries for status colors and the legend
+	glossary      []client.MetricDefinition // Metric definitions shown with the legend
+	tpsSweep      []client.ScenarioResult   // Backend TPS estimates across cell counts
+	tpsLoaded     bool                      // Whether the TPS sweep request has finished
 }
 
-// New creates a new dashboard with infrastructure data
+// scrollKeyMap limits viewport scrolling to keys the dashboard doesn't already
+// use (the viewport defaults bind b and f, which clash with Back)
+var scrollKeyMap = viewport.KeyMap{
+	Up:           key.NewBinding(key.WithKeys("up", "k")),
+	Down:         key.NewBinding(key.WithKeys("down", "j")),
+	PageUp:       key.NewBinding(key.WithKeys("pgup")),
+	PageDown:     key.NewBinding(key.WithKeys("pgdown")),
+	HalfPageUp:   key.NewBinding(key.WithKeys("ctrl+u")),
+	HalfPageDown: key.NewBinding(key.WithKeys("ctrl+d")),
+}
+
+// New creates a new dashboard with infrastructure data. height is the number
+// of lines visible at once; taller content scrolls.
 func New(infra *client.InfrastructureState, width, height int) *Dashboard {
 	d := &Dashboard{
 		infra:         infra,
@@ -34,10 +57,14 @@ func New(infra *client.InfrastructureState, width, height int) *Dashboard {
 		height:        height,
 		historyMemory: make([]float64, 0, 8),
 		historyCPU:    make([]float64, 0, 8),
+		viewport:      viewport.New(width, height),
+		thresholds:    client.DefaultThresholds(),
 	}
+	d.viewport.KeyMap = scrollKeyMap
 	if infra != nil {
 		d.recordHistory(infra)
 	}
+	d.refreshContent()
 	return d
 }
 
@@ -47,6 +74,7 @@ func (d *Dashboard) Update(infra *client.InfrastructureState) {
 	if infra != nil {
 		d.recordHistory(infra)
 	}
+	d.refreshContent()
 }
 
 // recordHistory adds current values to history for sparklines
@@ -66,14 +94,71 @@ func (d *Dashboard) recordHistory(infra *client.InfrastructureState) {
 func (d *Dashboard) SetSize(width, height int) {
 	d.width = width
 	d.height = height
+	d.viewport.Width = width
+	d.viewport.Height = height
+	d.refreshContent()
+}
+
+// HandleScroll scrolls the dashboard for movement keys (j/k, arrows, page
+// up/down, ctrl+u/ctrl+d); other keys are ignored
+func (d *Dashboard) HandleScroll(msg tea.KeyMsg) tea.Cmd {
+	var cmd tea.Cmd
+	d.viewport, cmd = d.viewport.Update(msg)
+	return cmd
+}
+
+// ToggleLegend expands or collapses the status legend below the panels
+func (d *Dashboard) ToggleLegend() {
+	d.showLegend = !d.showLegend
+	d.refreshContent()
+}
+
+// SetThresholds replaces the built-in thresholds the panels and legend use
+// with the backend's, from GET /api/v1/thresholds
+func (d *Dashboard) SetThresholds(t client.Thresholds) {
+	d.thresholds = t
+	d.refreshContent()
+}
+
+// SetGlossary sets the metric definitions shown below the legend, from
+// GET /api/v1/metrics/glossary
+func (d *Dashboard) SetGlossary(defs []client.MetricDefinition) {
+	d.glossary = defs
+	d.refreshContent()
 }
 
-// View renders the dashboard
+// LegendVisible reports whether the status legend is expanded
+func (d *Dashboard) LegendVisible() bool {
+	return d.showLegend
+}
+
+// ScrollIndicator reports how far the dashboard is scrolled, e.g. "↕ 40%",
+// or "" when all of the content fits
+func (d *Dashboard) ScrollIndicator() string {
+	if d.infra == nil || d.viewport.TotalLineCount() <= d.viewport.VisibleLineCount() {
+		return ""
+	}
+	return fmt.Sprintf("↕ %.0f%%", d.viewport.ScrollPercent()*100)
+}
+
+// View renders the visible part of the dashboard
 func (d *Dashboard) View() string {
 	if d.infra == nil {
 		return styles.Panel.Width(d.width).Render("Loading infrastructure data...")
 	}
+	return d.viewport.View()
+}
+
+// refreshContent re-renders the full dashboard into the scrollable viewport
+func (d *Dashboard) refreshContent() {
+	if d.infra == nil {
+		return
+	}
+	d.viewport.SetContent(d.render())
+}
 
+// render builds the full, unscrolled dashboard content
+func (d *Dashboard) render() string {
 	var sb strings.Builder
 
 	// Title with infrastructure name
@@ -84,7 +169,12 @@ func (d *Dashboard) View() string {
 	sb.WriteString(titleStyle.Render(fmt.Sprintf("%s Current Infrastructure", titleIcon)))
 	sb.WriteString("\n")
 	sb.WriteString(subtitleStyle.Render(d.infra.Name))
-	sb.WriteString("\n\n")
+	sb.WriteString("\n")
+	if ages := d.dataAges(); ages != "" {
+		sb.WriteString(subtitleStyle.Render(ages))
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n")
 
 	// Row 1: Key metrics in compact blocks
 	row1 := d.renderMetricsRow()
@@ -95,12 +185,33 @@ func (d *Dashboard) View() string {
 	row2 := d.renderCapacityRow()
 	sb.WriteString(row2)
 
+	if d.showLegend {
+		sb.WriteString("\n\n")
+		sb.WriteString(d.renderLegend())
+		sb.WriteString("\n\n")
+		sb.WriteString(d.renderGlossary())
+	}
+
 	// Only constrain width - let height flow naturally so header/footer aren't pushed off
 	return lipgloss.NewStyle().
 		Width(d.width).
 		Render(sb.String())
 }
 
+// dataAges reports how old the infrastructure and app data are. App totals
+// read from CF refresh on their own schedule, so their age is shown apart
+// from the infrastructure's; totals that came with the input have none.
+func (d *Dashboard) dataAges() string {
+	var ages []string
+	if t, err := time.Parse(time.RFC3339, d.infra.Timestamp); err == nil {
+		ages = append(ages, "Infrastructure updated "+format.Age(time.Since(t)))
+	}
+	if t, err := time.Parse(time.RFC3339, d.infra.AppDataTimestamp); err == nil {
+		ages = append(ages, "App data updated "+format.Age(time.Since(t)))
+	}
+	return strings.Join(ages, " · ")
+}
+
 // renderMetricsRow renders the top row of metric blocks
 func (d *Dashboard) renderMetricsRow() string {
 	// Calculate block width based on available space
@@ -115,7 +226,7 @@ func (d *Dashboard) renderMetricsRow() string {
 		blockWidth = 24 // maximum for aesthetic
 	}
 
-	config := widgets.DefaultMetricBlockConfig()
+	config := widgets.DefaultMetricBlockConfig(d.thresholds.UtilizationWarningPct, d.thresholds.UtilizationCriticalPct)
 	config.Width = blockWidth
 
 	// Memory block with bar
@@ -124,7 +235,7 @@ func (d *Dashboard) renderMetricsRow() string {
 		icons.Memory,
 		"Memory",
 		d.infra.HostMemoryUtilizationPercent,
-		fmt.Sprintf("%.0f/%d GB", usedMemoryGB, d.infra.TotalMemoryGB),
+		format.GB(usedMemoryGB)+"/"+format.GBInt(d.infra.TotalMemoryGB),
 		config,
 	)
 
@@ -137,9 +248,10 @@ func (d *Dashboard) renderMetricsRow() string {
 	cpuSubtitle := cases.Title(language.English).String(riskLabel)
 	cpuValue := fmt.Sprintf("%.1f:1", d.infra.VCPURatio)
 	cpuConfig := config
-	if riskLabel == "moderate" {
+	switch riskLabel {
+	case "medium", "moderate":
 		cpuConfig.ValueColor = styles.Warning
-	} else if riskLabel == "aggressive" {
+	case "high", "aggressive":
 		cpuConfig.ValueColor = styles.Danger
 	}
 
@@ -189,15 +301,7 @@ func (d *Dashboard) renderMetricsRow() string {
 
 // renderCapacityRow renders the bottom row with capacity and HA panels
 func (d *Dashboard) renderCapacityRow() string {
-	// Account for the fact that the outer ActivePanel style adds borders and padding
-	// Available content width is roughly d.width - 6 (2 border + 4 padding)
-	contentWidth := d.width - 6
-	if contentWidth < 40 {
-		contentWidth = 40 // minimum for any reasonable layout
-	}
-
-	// Use full content width for each panel, stacked vertically
-	panelWidth := contentWidth - 2 // leave margin
+	panelWidth := d.panelWidth()
 
 	// N-1 Capacity panel
 	capacityPanel := d.renderCapacityPanel(panelWidth)
@@ -206,7 +310,27 @@ func (d *Dashboard) renderCapacityRow() string {
 	haPanel := d.renderHAPanel(panelWidth)
 
 	// Stack vertically for better fit
-	return lipgloss.JoinVertical(lipgloss.Left, capacityPanel, haPanel)
+	if d.infra.TotalCellCount == 0 {
+		return lipgloss.JoinVertical(lipgloss.Left, capacityPanel, haPanel)
+	}
+
+	// Scheduler TPS curve with the current cell count marked
+	tpsPanel := d.renderTPSPanel(panelWidth)
+
+	return lipgloss.JoinVertical(lipgloss.Left, capacityPanel, haPanel, tpsPanel)
+}
+
+// panelWidth is the width of the full-width panels stacked below the metrics
+func (d *Dashboard) panelWidth() int {
+	// Account for the fact that the outer ActivePanel style adds borders and padding
+	// Available content width is roughly d.width - 6 (2 border + 4 padding)
+	contentWidth := d.width - 6
+	if contentWidth < 40 {
+		contentWidth = 40 // minimum for any reasonable layout
+	}
+
+	// Use full content width for each panel, stacked vertically
+	return contentWidth - 2 // leave margin
 }
 
 // renderCapacityPanel renders the N-1 capacity information
@@ -215,13 +339,13 @@ func (d *Dashboard) renderCapacityPanel(width int) string {
 
 	// Utilization with status
 	util := d.infra.HostMemoryUtilizationPercent
-	status := widgets.StatusFromPercent(util, 80, 95)
+	status := widgets.StatusFromPercent(util, d.thresholds.UtilizationWarningPct, d.thresholds.UtilizationCriticalPct)
 	statusIcon := widgets.StatusIcon(status)
 
 	sb.WriteString(fmt.Sprintf("Utilization: %.1f%% %s\n", util, statusIcon))
 
 	// Progress bar with zones
-	barConfig := widgets.DefaultProgressBarConfig()
+	barConfig := widgets.DefaultProgressBarConfig(d.thresholds.UtilizationWarningPct, d.thresholds.UtilizationCriticalPct)
 	barConfig.Width = width - 6
 	barConfig.ShowZones = true
 	bar := widgets.ProgressBarWithLabel(util, barConfig, false)
@@ -233,7 +357,7 @@ func (d *Dashboard) renderCapacityPanel(width int) string {
 	usedGB := float64(d.infra.TotalMemoryGB) * (util / 100)
 	availableGB := float64(d.infra.TotalMemoryGB) - usedGB
 	headroomStyle := lipgloss.NewStyle().Foreground(styles.Muted)
-	sb.WriteString(headroomStyle.Render(fmt.Sprintf("Headroom: %.0f%% (%.0f GB available)", headroom, availableGB)))
+	sb.WriteString(headroomStyle.Render(fmt.Sprintf("Headroom: %.0f%% (%s available)", headroom, format.GB(availableGB))))
 
 	// Build panel with border
 	titleStyle := lipgloss.NewStyle().Foreground(styles.Primary)
@@ -288,6 +412,65 @@ func (d *Dashboard) renderHAPanel(width int) string {
 	return panel
 }
 
+// renderLegend explains the status colors and the thresholds behind them.
+// Values come from the same thresholds the panels are drawn with, so the
+// legend matches what is drawn.
+func (d *Dashboard) renderLegend() string {
+	var sb strings.Builder
+	muted := lipgloss.NewStyle().Foreground(styles.Muted)
+	heading := lipgloss.NewStyle().Foreground(styles.Text).Bold(true)
+	t := d.thresholds
+
+	sb.WriteString(heading.Render("Memory and N-1 utilization"))
+	sb.WriteString("\n")
+	sb.WriteString(widgets.StatusText(fmt.Sprintf("OK        below %.0f%%", t.UtilizationWarningPct), widgets.StatusOK))
+	sb.WriteString("\n")
+	sb.WriteString(widgets.StatusText(fmt.Sprintf("Warning   %.0f%% or more", t.UtilizationWarningPct), widgets.StatusWarning))
+	sb.WriteString("\n")
+	sb.WriteString(widgets.StatusText(fmt.Sprintf("Critical  %.0f%% or more", t.UtilizationCriticalPct), widgets.StatusCritical))
+	sb.WriteString("\n\n")
+
+	sb.WriteString(heading.Render("CPU ratio (vCPU:pCPU)"))
+	sb.WriteString("\n")
+	sb.WriteString(widgets.StatusText(fmt.Sprintf("Low       up to %g:1", t.VCPURatioModerate), widgets.StatusOK))
+	sb.WriteString("\n")
+	sb.WriteString(widgets.StatusText(fmt.Sprintf("Medium    above %g:1", t.VCPURatioModerate), widgets.StatusWarning))
+	sb.WriteString("\n")
+	sb.WriteString(widgets.StatusText(fmt.Sprintf("High      above %g:1", t.VCPURatioAggressive), widgets.StatusCritical))
+	sb.WriteString("\n\n")
+
+	sb.WriteString(heading.Render("HA status"))
+	sb.WriteString("\n")
+	sb.WriteString(widgets.StatusText("Survives at least one host failure", widgets.StatusOK))
+	sb.WriteString("\n")
+	sb.WriteString(widgets.StatusText("Cannot survive a host failure", widgets.StatusCritical))
+	sb.WriteString("\n\n")
+
+	sb.WriteString(heading.Render("Scenario warnings (warning / critical)"))
+	sb.WriteString("\n")
+	sb.WriteString(muted.Render(fmt.Sprintf("N-1 utilization   above %.0f%% / %.0f%%", t.N1WarningPct, t.N1CriticalPct)))
+	sb.WriteString("\n")
+	sb.WriteString(muted.Render(fmt.Sprintf("Cell utilization  above %.0f%% / %.0f%%", t.UtilizationWarningPct, t.UtilizationCriticalPct)))
+	sb.WriteString("\n")
+	sb.WriteString(muted.Render(fmt.Sprintf("Disk utilization  above %.0f%% / %.0f%%", t.DiskWarningPct, t.DiskCriticalPct)))
+	sb.WriteString("\n")
+	sb.WriteString(muted.Render(fmt.Sprintf("Free 4GB chunks   below %d / %d", t.FreeChunksWarning, t.FreeChunksCritical)))
+	sb.WriteString("\n")
+	sb.WriteString(muted.Render(fmt.Sprintf("Blast radius      above %.0f%% / %.0f%%", t.BlastRadiusWarningPct, t.BlastRadiusCriticalPct)))
+
+	titleStyle := lipgloss.NewStyle().Foreground(styles.Primary)
+	title := fmt.Sprintf("%s Legend", icons.Info.String())
+	return d.buildPanel(titleStyle.Render(title), sb.String(), d.panelWidth()-4)
+}
+
+// renderGlossary defines each metric and the formula behind it
+func (d *Dashboard) renderGlossary() string {
+	innerWidth := d.panelWidth() - 4
+	titleStyle := lipgloss.NewStyle().Foreground(styles.Primary)
+	title := fmt.Sprintf("%s Metrics", icons.Info.String())
+	return d.buildPanel(titleStyle.Render(title), widgets.Glossary(d.glossary, innerWidth), innerWidth)
+}
+
 // buildPanel creates a bordered panel with title
 func (d *Dashboard) buildPanel(title, content string, innerWidth int) string {
 	borderStyle := lipgloss.NewStyle().Foreground(styles.Muted)
@@ -6,8 +6,12 @@ package dashboard
 import (
 	"strings"
 	"testing"
+	"time"
 
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/markalston/diego-capacity-analyzer/cli/internal/client"
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/tui/icons"
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/tui/widgets"
 )
 
 func TestDashboardView(t *testing.T) {
@@ -227,3 +231,274 @@ func TestDashboardHistoryTracking(t *testing.T) {
 		t.Errorf("expected 8 history entries (capped), got %d", len(d.historyMemory))
 	}
 }
+
+func TestTPSSweepRange(t *testing.T) {
+	tests := []struct {
+		cells, wantMin, wantMax, wantStep int
+	}{
+		{10, 1, 20, 1},
+		{120, 2, 180, 2},
+		{1000, 8, 1500, 16},
+	}
+	for _, tt := range tests {
+		d := New(&client.InfrastructureState{TotalCellCount: tt.cells}, 100, 40)
+		minCells, maxCells, step := d.TPSSweepRange()
+		if minCells != tt.wantMin || maxCells != tt.wantMax || step != tt.wantStep {
+			t.Errorf("%d cells: expected %d..%d by %d, got %d..%d by %d",
+				tt.cells, tt.wantMin, tt.wantMax, tt.wantStep, minCells, maxCells, step)
+		}
+		// The current count must be one of the swept points
+		if (tt.cells-minCells)%step != 0 {
+			t.Errorf("%d cells: not on the sweep grid %d..%d by %d", tt.cells, minCells, maxCells, step)
+		}
+	}
+}
+
+func TestRenderTPSChart(t *testing.T) {
+	points := []tpsPoint{{Cells: 0, TPS: 0}, {Cells: 50, TPS: 1000}, {Cells: 100, TPS: 0}}
+
+	chart := renderTPSChart(points, 50, 11, 3)
+	lines := strings.Split(chart, "\n")
+
+	// height rows of curve plus one marker row
+	if len(lines) != 4 {
+		t.Fatalf("expected 4 lines, got %d:\n%s", len(lines), chart)
+	}
+	for i, line := range lines[:3] {
+		if w := len([]rune(line)); w != 11 {
+			t.Errorf("row %d: expected width 11, got %d", i, w)
+		}
+	}
+
+	// The peak sits in the middle column and fills every row
+	for i, line := range lines[:3] {
+		if []rune(line)[5] != '█' {
+			t.Errorf("row %d: expected full block at the peak column, got %q", i, line)
+		}
+	}
+	// The ends of the curve are at zero TPS
+	if []rune(lines[2])[0] != ' ' || []rune(lines[2])[10] != ' ' {
+		t.Errorf("expected empty columns at zero TPS, got %q", lines[2])
+	}
+
+	// The marker points at the current cell count
+	if lines[3] != "     ▲" {
+		t.Errorf("expected marker under column 5, got %q", lines[3])
+	}
+
+	if renderTPSChart(nil, 10, 20, 4) != "" {
+		t.Error("expected empty chart for no points")
+	}
+}
+
+func TestDashboardTPSPanel(t *testing.T) {
+	infra := &client.InfrastructureState{
+		Name:           "test",
+		TotalHostCount: 8,
+		TotalCellCount: 120,
+		HAStatus:       "ok",
+	}
+
+	d := New(infra, 100, 40)
+	if view := d.View(); !strings.Contains(view, "Loading TPS estimates") {
+		t.Errorf("expected a loading message before the sweep arrives\nView:\n%s", view)
+	}
+
+	// Estimates come from the backend sweep, including its status for the current count
+	d.SetTPSSweep([]client.ScenarioResult{
+		{CellCount: 2, EstimatedTPS: 1964, TPSStatus: "optimal"},
+		{CellCount: 60, EstimatedTPS: 1600, TPSStatus: "optimal"},
+		{CellCount: 120, EstimatedTPS: 1155, TPSStatus: "degraded"},
+		{CellCount: 180, EstimatedTPS: 500, TPSStatus: "critical"},
+	})
+	view := d.View()
+	for _, expected := range []string{"Scheduler TPS", "120 cells: ~1155 TPS (degraded)", "2 to 180 cells", "▲"} {
+		if !strings.Contains(view, expected) {
+			t.Errorf("expected view to contain %q\nView:\n%s", expected, view)
+		}
+	}
+
+	d.SetTPSSweep(nil)
+	if view := d.View(); !strings.Contains(view, "TPS estimates unavailable") {
+		t.Errorf("expected an unavailable message when the sweep fails\nView:\n%s", view)
+	}
+
+	// Without TPS_CURVE the backend estimates nothing; that is not a critical TPS
+	d.SetTPSSweep([]client.ScenarioResult{
+		{CellCount: 2, TPSStatus: "disabled"},
+		{CellCount: 120, TPSStatus: "disabled"},
+		{CellCount: 180, TPSStatus: "disabled"},
+	})
+	view = d.View()
+	if !strings.Contains(view, "TPS estimates disabled – set TPS_CURVE") {
+		t.Errorf("expected a disabled message without a TPS curve\nView:\n%s", view)
+	}
+	for _, unexpected := range []string{"~0 TPS", "▲"} {
+		if strings.Contains(view, unexpected) {
+			t.Errorf("expected no chart or %q label without a TPS curve\nView:\n%s", unexpected, view)
+		}
+	}
+	if got := tpsStatusLevel("disabled"); got == widgets.StatusCritical {
+		t.Error("expected disabled TPS not to be reported as critical")
+	}
+
+	infra.TotalCellCount = 0
+	if view := New(infra, 100, 40).View(); strings.Contains(view, "Scheduler TPS") {
+		t.Error("expected no TPS panel without cells")
+	}
+}
+
+func TestDashboardScrolling(t *testing.T) {
+	infra := &client.InfrastructureState{
+		Name:           "test",
+		TotalHostCount: 8,
+		TotalCellCount: 120,
+		HAStatus:       "ok",
+	}
+
+	d := New(infra, 80, 10)
+	if lines := strings.Count(d.View(), "\n") + 1; lines != 10 {
+		t.Errorf("expected view clipped to 10 lines, got %d", lines)
+	}
+	if !strings.Contains(d.View(), "Current Infrastructure") {
+		t.Error("expected view to start at the top")
+	}
+	if got := d.ScrollIndicator(); got != "↕ 0%" {
+		t.Errorf("expected indicator at 0%%, got %q", got)
+	}
+
+	d.HandleScroll(tea.KeyMsg{Type: tea.KeyPgDown})
+	d.HandleScroll(tea.KeyMsg{Type: tea.KeyPgDown})
+	d.HandleScroll(tea.KeyMsg{Type: tea.KeyPgDown})
+	d.HandleScroll(tea.KeyMsg{Type: tea.KeyPgDown})
+	if got := d.ScrollIndicator(); got != "↕ 100%" {
+		t.Errorf("expected indicator at 100%% after paging down, got %q", got)
+	}
+	if !strings.Contains(d.View(), "Scheduler TPS") && !strings.Contains(d.View(), "1 to 210 cells") {
+		t.Error("expected the bottom of the dashboard after paging down")
+	}
+
+	d.HandleScroll(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'k'}})
+	if got := d.ScrollIndicator(); got == "↕ 100%" {
+		t.Error("expected k to scroll up")
+	}
+
+	// Everything fits in a tall pane, so there is nothing to scroll
+	if got := New(infra, 80, 200).ScrollIndicator(); got != "" {
+		t.Errorf("expected no indicator when content fits, got %q", got)
+	}
+}
+
+func TestDashboardLegend(t *testing.T) {
+	infra := &client.InfrastructureState{
+		Name:           "test",
+		TotalHostCount: 8,
+		HAStatus:       "ok",
+	}
+
+	d := New(infra, 100, 200)
+	if d.LegendVisible() || strings.Contains(d.View(), "Legend") {
+		t.Error("expected legend collapsed by default")
+	}
+
+	d.ToggleLegend()
+	view := d.View()
+	for _, want := range []string{
+		"Legend",
+		"below 80%",
+		"Critical  90% or more",
+		"High      above 8:1",
+		"N-1 utilization   above 75% / 85%",
+		"Free 4GB chunks   below 20 / 10",
+	} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected legend to contain %q\nView:\n%s", want, view)
+		}
+	}
+
+	d.ToggleLegend()
+	if d.LegendVisible() || strings.Contains(d.View(), "Legend") {
+		t.Error("expected second toggle to collapse the legend")
+	}
+}
+
+func TestDashboardLegend_BackendThresholds(t *testing.T) {
+	d := New(&client.InfrastructureState{Name: "test", TotalHostCount: 8, HAStatus: "ok"}, 100, 200)
+	d.ToggleLegend()
+
+	thresholds := client.DefaultThresholds()
+	thresholds.FreeChunksWarning = 400
+	thresholds.FreeChunksCritical = 200
+	thresholds.VCPURatioAggressive = 6
+	d.SetThresholds(thresholds)
+
+	view := d.View()
+	for _, want := range []string{"Free 4GB chunks   below 400 / 200", "High      above 6:1"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected legend to contain %q\nView:\n%s", want, view)
+		}
+	}
+}
+
+func TestDashboardUtilization_BackendThresholds(t *testing.T) {
+	d := New(&client.InfrastructureState{Name: "test", TotalHostCount: 8, TotalMemoryGB: 1000, HostMemoryUtilizationPercent: 92, HAStatus: "ok"}, 100, 200)
+	d.ToggleLegend()
+
+	// The backend default critical limit is 90%, so 92% is critical
+	view := d.View()
+	if !strings.Contains(view, "Utilization: 92.0% "+icons.Critical.String()) || !strings.Contains(view, "Critical  90% or more") {
+		t.Errorf("expected 92%% to be critical at the default 90%% limit\nView:\n%s", view)
+	}
+
+	thresholds := client.DefaultThresholds()
+	thresholds.UtilizationWarningPct = 85
+	thresholds.UtilizationCriticalPct = 95
+	d.SetThresholds(thresholds)
+
+	view = d.View()
+	for _, want := range []string{"Utilization: 92.0% " + icons.Warning.String(), "Warning   85% or more", "Critical  95% or more"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q with backend limits of 85/95%%\nView:\n%s", want, view)
+		}
+	}
+}
+
+func TestDashboardLegend_MetricGlossary(t *testing.T) {
+	d := New(&client.InfrastructureState{Name: "test", TotalHostCount: 8, HAStatus: "ok"}, 100, 200)
+	d.ToggleLegend()
+	if !strings.Contains(d.View(), "Metric definitions have not loaded") {
+		t.Error("expected the metrics panel to say definitions are not loaded yet")
+	}
+
+	d.SetGlossary([]client.MetricDefinition{
+		{Key: "fault_impact", Name: "Fault impact", Description: "Instances restarted when a cell fails.", Formula: "app instances ÷ cells, rounded"},
+	})
+	view := d.View()
+	for _, want := range []string{"Metrics", "Fault impact", "Instances restarted when a cell fails.", "= app instances ÷ cells, rounded"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected metrics panel to contain %q\nView:\n%s", want, view)
+		}
+	}
+}
+
+func TestDashboardDataAges(t *testing.T) {
+	now := time.Now()
+	infra := &client.InfrastructureState{
+		Name:             "prod",
+		Timestamp:        now.Add(-4*time.Minute - 10*time.Second).Format(time.RFC3339),
+		AppDataTimestamp: now.Add(-30 * time.Second).Format(time.RFC3339),
+		Clusters:         []client.ClusterState{{Name: "cluster-01"}},
+	}
+
+	view := New(infra, 120, 40).View()
+	if !strings.Contains(view, "Infrastructure updated 4m ago") || !strings.Contains(view, "App data updated 30s ago") {
+		t.Errorf("Expected separate infrastructure and app data ages, got:\n%s", view)
+	}
+
+	// App totals from manual input have no age of their own
+	infra.AppDataTimestamp = ""
+	view = New(infra, 120, 40).View()
+	if !strings.Contains(view, "Infrastructure updated 4m ago") || strings.Contains(view, "App data updated") {
+		t.Errorf("Expected only the infrastructure age, got:\n%s", view)
+	}
+}
@@ -0,0 +1,190 @@
+// ABOUTME: TPS curve chart for the dashboard, showing scheduling throughput by cell count
+// ABOUTME: Plots the backend's per-cell-count TPS estimates with block characters
+
+package dashboard
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/client"
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/tui/icons"
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/tui/styles"
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/tui/widgets"
+)
+
+// tpsChartHeight is the number of rows used to draw the TPS curve
+const tpsChartHeight = 4
+
+// tpsSweepPoints is roughly how many cell counts the TPS sweep asks the
+// backend to evaluate, enough to fill a wide chart
+const tpsSweepPoints = 100
+
+// tpsPoint is one (cell count, TPS) sample on the scheduling throughput curve
+type tpsPoint struct {
+	Cells int
+	TPS   int
+}
+
+// TPSSweepRange returns the cell count range to request from
+// GET /api/v1/scenario/sweep for the TPS chart: one cell to half again past
+// the current count, stepped so the current count is one of the points.
+func (d *Dashboard) TPSSweepRange() (minCells, maxCells, step int) {
+	cells := max(d.infra.TotalCellCount, 1)
+	maxCells = max(cells*3/2, 20)
+	step = max(1, (maxCells-1+tpsSweepPoints-2)/(tpsSweepPoints-1))
+	minCells = 1 + (cells-1)%step
+	return minCells, maxCells, step
+}
+
+// SetTPSSweep sets the backend's scenario results for the TPS chart, from
+// GET /api/v1/scenario/sweep. nil marks the sweep as unavailable.
+func (d *Dashboard) SetTPSSweep(results []client.ScenarioResult) {
+	d.tpsSweep = results
+	d.tpsLoaded = true
+	d.refreshContent()
+}
+
+// tpsStatusLevel maps the backend's TPS status to a display level. "disabled"
+// means no TPS curve was applied, which says nothing about the foundation.
+func tpsStatusLevel(status string) widgets.StatusLevel {
+	switch status {
+	case "optimal":
+		return widgets.StatusOK
+	case "degraded":
+		return widgets.StatusWarning
+	case "disabled":
+		return widgets.StatusNeutral
+	default:
+		return widgets.StatusCritical
+	}
+}
+
+// tpsDisabled reports whether the sweep carries no TPS estimates: the backend
+// only estimates TPS with a TPS_CURVE configured, and otherwise reports every
+// point as "disabled" with 0 TPS
+func tpsDisabled(results []client.ScenarioResult) bool {
+	for _, r := range results {
+		if r.TPSStatus != "disabled" && r.EstimatedTPS > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// nearestTPS returns the TPS of the point (sorted by cell count) closest to cells
+func nearestTPS(points []tpsPoint, cells int) int {
+	i := sort.Search(len(points), func(i int) bool { return points[i].Cells >= cells })
+	switch {
+	case i == len(points):
+		return points[len(points)-1].TPS
+	case i > 0 && cells-points[i-1].Cells < points[i].Cells-cells:
+		return points[i-1].TPS
+	default:
+		return points[i].TPS
+	}
+}
+
+// renderTPSChart draws points (sorted by cell count) as a width × height block
+// chart scaled to the highest TPS, with a marker row pointing at currentCells.
+// It is a pure function of its arguments so the layout can be tested directly.
+func renderTPSChart(points []tpsPoint, currentCells, width, height int) string {
+	if len(points) == 0 || width <= 0 || height <= 0 {
+		return ""
+	}
+
+	first, last := points[0].Cells, points[len(points)-1].Cells
+	peak := 0
+	for _, pt := range points {
+		peak = max(peak, pt.TPS)
+	}
+
+	// Column i shows the point nearest its share of the points' cell range
+	levels := make([]int, width)
+	for i := range levels {
+		cells := first
+		if width > 1 {
+			cells = first + i*(last-first)/(width-1)
+		}
+		if peak > 0 {
+			levels[i] = nearestTPS(points, cells) * height * 8 / peak
+		}
+	}
+
+	markerCol := 0
+	if last > first && width > 1 {
+		markerCol = (min(max(currentCells, first), last) - first) * (width - 1) / (last - first)
+	}
+
+	curveStyle := lipgloss.NewStyle().Foreground(styles.Primary)
+	markerStyle := lipgloss.NewStyle().Foreground(styles.Warning)
+
+	rows := make([]string, 0, height+1)
+	for r := height - 1; r >= 0; r-- {
+		var row strings.Builder
+		for i, level := range levels {
+			ch := " "
+			switch filled := level - r*8; {
+			case filled >= 8:
+				ch = "█"
+			case filled > 0:
+				ch = string(widgets.SparklineBlocks[filled-1])
+			}
+			if i == markerCol {
+				row.WriteString(markerStyle.Render(ch))
+			} else {
+				row.WriteString(curveStyle.Render(ch))
+			}
+		}
+		rows = append(rows, row.String())
+	}
+	rows = append(rows, strings.Repeat(" ", markerCol)+markerStyle.Render("▲"))
+
+	return strings.Join(rows, "\n")
+}
+
+// renderTPSPanel renders the backend's TPS estimates from one cell to past the
+// current count, marking where the foundation sits on the curve
+func (d *Dashboard) renderTPSPanel(width int) string {
+	innerWidth := width - 4
+	chartWidth := max(innerWidth, 10)
+	axisStyle := lipgloss.NewStyle().Foreground(styles.Muted)
+
+	var sb strings.Builder
+	switch {
+	case !d.tpsLoaded:
+		sb.WriteString(axisStyle.Render("Loading TPS estimates..."))
+	case len(d.tpsSweep) == 0:
+		sb.WriteString(axisStyle.Render("TPS estimates unavailable"))
+	case tpsDisabled(d.tpsSweep):
+		sb.WriteString(axisStyle.Render("TPS estimates disabled – set TPS_CURVE"))
+	default:
+		cells := d.infra.TotalCellCount
+		points := make([]tpsPoint, len(d.tpsSweep))
+		var current *client.ScenarioResult
+		for i := range d.tpsSweep {
+			r := &d.tpsSweep[i]
+			points[i] = tpsPoint{Cells: r.CellCount, TPS: r.EstimatedTPS}
+			if r.CellCount == cells {
+				current = r
+			}
+		}
+
+		sb.WriteString(renderTPSChart(points, cells, chartWidth, tpsChartHeight))
+		sb.WriteString("\n")
+		if current != nil {
+			label := fmt.Sprintf("%d cells: ~%d TPS (%s)", cells, current.EstimatedTPS, current.TPSStatus)
+			sb.WriteString(widgets.StatusText(label, tpsStatusLevel(current.TPSStatus)))
+			sb.WriteString("\n")
+		}
+		sb.WriteString(axisStyle.Render(fmt.Sprintf("%d to %d cells", points[0].Cells, points[len(points)-1].Cells)))
+	}
+
+	titleStyle := lipgloss.NewStyle().Foreground(styles.Primary)
+	title := fmt.Sprintf("%s Scheduler TPS", icons.Chart.String())
+
+	return d.buildPanel(titleStyle.Render(title), sb.String(), innerWidth)
+}
@@ -48,6 +48,7 @@ type FilePicker struct {
 	err         string
 	width       int
 	height      int
+	previews    map[string]Preview // Previews already read, by path
 }
 
 // Styles using theme colors
@@ -74,12 +75,13 @@ func New(recentFiles []string, sampleFiles []samples.SampleFile) *FilePicker {
 		cursor:      0,
 		state:       stateList,
 		textInput:   ti,
+		previews:    make(map[string]Preview),
 	}
 }
 
 // Init implements tea.Model
 func (fp *FilePicker) Init() tea.Cmd {
-	return nil
+	return fp.previewHighlighted()
 }
 
 // Update implements tea.Model
@@ -90,18 +92,29 @@ func (fp *FilePicker) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		fp.height = msg.Height
 		return fp, nil
 
+	case PreviewLoadedMsg:
+		fp.previews[msg.Path] = msg.Preview
+		return fp, nil
+
 	case tea.KeyMsg:
 		// Clear error on any key press
 		fp.err = ""
 
+		var model tea.Model
+		var cmd tea.Cmd
 		switch fp.state {
 		case stateList:
-			return fp.updateList(msg)
+			model, cmd = fp.updateList(msg)
 		case stateInput:
 			return fp.updateInput(msg)
 		case stateSamples:
-			return fp.updateSamples(msg)
+			model, cmd = fp.updateSamples(msg)
+		}
+		// Moving the cursor may highlight a file not yet previewed
+		if cmd == nil {
+			cmd = fp.previewHighlighted()
 		}
+		return model, cmd
 	}
 
 	return fp, nil
@@ -391,6 +404,12 @@ func (fp *FilePicker) viewList() string {
 		b.WriteString(cursor + style.Render("Load sample file...") + "\n")
 	}
 
+	if preview := fp.viewPreview(); preview != "" {
+		b.WriteString("\n")
+		b.WriteString(preview)
+		b.WriteString("\n")
+	}
+
 	// Error message
 	if fp.err != "" {
 		b.WriteString("\n")
@@ -444,6 +463,12 @@ func (fp *FilePicker) viewSamples() string {
 	}
 	b.WriteString(cursor + style.Render("[back]") + "\n")
 
+	if preview := fp.viewPreview(); preview != "" {
+		b.WriteString("\n")
+		b.WriteString(preview)
+		b.WriteString("\n")
+	}
+
 	if fp.err != "" {
 		b.WriteString("\n")
 		b.WriteString(errorStyle.Render("Error: " + fp.err))
@@ -6,6 +6,7 @@ package filepicker
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -238,3 +239,115 @@ func TestExpandPath(t *testing.T) {
 		})
 	}
 }
+
+func TestReadPreview(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	t.Run("ManualInput", func(t *testing.T) {
+		path := write("manual.json", `{
+			"name": "Lab",
+			"clusters": [
+				{"name": "c1", "host_count": 4, "memory_gb_per_host": 512},
+				{"name": "c2", "host_count": 6, "memory_gb_per_host": 512}
+			]
+		}`)
+		p := readPreview(path)
+		if p.Err != "" {
+			t.Fatalf("unexpected error: %s", p.Err)
+		}
+		if p.Name != "Lab" || p.Format != formatManualInput || p.Clusters != 2 || p.Hosts != 10 {
+			t.Errorf("unexpected preview: %+v", p)
+		}
+	})
+
+	t.Run("InfrastructureState", func(t *testing.T) {
+		path := write("state.json", `{
+			"name": "Prod",
+			"clusters": [{"name": "c1", "host_count": 8, "memory_gb": 4096}],
+			"total_host_count": 8
+		}`)
+		p := readPreview(path)
+		if p.Name != "Prod" || p.Format != formatInfrastructureState || p.Clusters != 1 || p.Hosts != 8 {
+			t.Errorf("unexpected preview: %+v", p)
+		}
+	})
+
+	t.Run("invalid JSON", func(t *testing.T) {
+		p := readPreview(write("bad.json", `{invalid`))
+		if p.Err == "" {
+			t.Error("expected an error for invalid JSON")
+		}
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		big := `{"name": "` + strings.Repeat("x", maxPreviewSize) + `"}`
+		p := readPreview(write("big.json", big))
+		if !strings.Contains(p.Err, "too large") {
+			t.Errorf("expected too large error, got %q", p.Err)
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		p := readPreview(filepath.Join(dir, "missing.json"))
+		if p.Err == "" {
+			t.Error("expected an error for a missing file")
+		}
+	})
+}
+
+func TestPreviewOnHighlight(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.json")
+	second := filepath.Join(dir, "second.json")
+	os.WriteFile(first, []byte(`{"name": "First", "clusters": [{"host_count": 3, "memory_gb_per_host": 256}]}`), 0644)
+	os.WriteFile(second, []byte(`{"name": "Second", "clusters": [], "total_host_count": 12}`), 0644)
+
+	fp := New([]string{first, second}, nil)
+
+	// Init previews the first highlighted file
+	cmd := fp.Init()
+	if cmd == nil {
+		t.Fatal("expected Init to load a preview")
+	}
+	if !strings.Contains(fp.View(), "Loading...") {
+		t.Error("expected loading placeholder before the preview arrives")
+	}
+	fp.Update(cmd())
+	view := fp.View()
+	if !strings.Contains(view, "First") || !strings.Contains(view, formatManualInput) {
+		t.Errorf("expected first file preview, got:\n%s", view)
+	}
+
+	// Moving down previews the next file
+	_, cmd = fp.Update(tea.KeyMsg{Type: tea.KeyDown})
+	if cmd == nil {
+		t.Fatal("expected a preview load after moving the cursor")
+	}
+	msg, ok := cmd().(PreviewLoadedMsg)
+	if !ok {
+		t.Fatal("expected PreviewLoadedMsg")
+	}
+	if msg.Path != second || msg.Preview.Hosts != 12 {
+		t.Errorf("unexpected preview message: %+v", msg)
+	}
+	fp.Update(msg)
+
+	// Moving back up uses the cached preview
+	_, cmd = fp.Update(tea.KeyMsg{Type: tea.KeyUp})
+	if cmd != nil {
+		t.Error("expected cached preview to be reused")
+	}
+
+	// No preview on the "Enter path..." action
+	fp.cursor = 2
+	if fp.viewPreview() != "" {
+		t.Error("expected no preview when an action is highlighted")
+	}
+}
@@ -0,0 +1,178 @@
+// ABOUTME: Infrastructure summary preview for the highlighted file in the picker
+// ABOUTME: Reads a bounded prefix of the file in the background and detects its format
+
+package filepicker
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/client"
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/tui/styles"
+)
+
+// maxPreviewSize is the most of a file read for its preview (1MB)
+const maxPreviewSize = 1024 * 1024
+
+// Detected file formats
+const (
+	formatManualInput         = "ManualInput"
+	formatInfrastructureState = "InfrastructureState"
+)
+
+// Preview summarizes a JSON file without loading it
+type Preview struct {
+	Name     string
+	Format   string
+	Clusters int
+	Hosts    int
+	Err      string
+}
+
+// PreviewLoadedMsg is sent when a highlighted file's preview has been read
+type PreviewLoadedMsg struct {
+	Path    string
+	Preview Preview
+}
+
+// previewDoc holds the fields shared by both file formats that the preview needs
+type previewDoc struct {
+	Name           string `json:"name"`
+	TotalHostCount int    `json:"total_host_count"`
+	Clusters       []struct {
+		HostCount int `json:"host_count"`
+	} `json:"clusters"`
+}
+
+// readPreview summarizes the file at path, reading at most maxPreviewSize bytes
+func readPreview(path string) Preview {
+	cleanPath, err := validatePath(expandPath(path))
+	if err != nil {
+		return Preview{Err: err.Error()}
+	}
+
+	f, err := os.Open(cleanPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Preview{Err: "File not found"}
+		}
+		return Preview{Err: "Cannot open file"}
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxPreviewSize+1))
+	if err != nil {
+		return Preview{Err: "Error reading file: " + err.Error()}
+	}
+	if len(data) > maxPreviewSize {
+		return Preview{Err: fmt.Sprintf("Larger than %d MB, too large to preview", maxPreviewSize/(1024*1024))}
+	}
+
+	var doc previewDoc
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return Preview{Err: "Not valid JSON"}
+	}
+
+	p := Preview{
+		Name:     doc.Name,
+		Format:   formatInfrastructureState,
+		Clusters: len(doc.Clusters),
+		Hosts:    doc.TotalHostCount,
+	}
+	if client.IsManualInputFormat(data) {
+		p.Format = formatManualInput
+	}
+	if p.Hosts == 0 {
+		for _, c := range doc.Clusters {
+			p.Hosts += c.HostCount
+		}
+	}
+	return p
+}
+
+// loadPreview reads the preview for path off the UI goroutine
+func loadPreview(path string) tea.Cmd {
+	return func() tea.Msg {
+		return PreviewLoadedMsg{Path: path, Preview: readPreview(path)}
+	}
+}
+
+// highlightedPath returns the file under the cursor, or "" when the cursor is
+// on an action such as "Enter path..." or [back]
+func (fp *FilePicker) highlightedPath() string {
+	switch fp.state {
+	case stateList:
+		if fp.cursor < len(fp.recentFiles) {
+			return fp.recentFiles[fp.cursor]
+		}
+	case stateSamples:
+		if fp.cursor < len(fp.samples) {
+			return fp.samples[fp.cursor].Path
+		}
+	}
+	return ""
+}
+
+// previewHighlighted starts loading the highlighted file's preview unless it
+// is already cached
+func (fp *FilePicker) previewHighlighted() tea.Cmd {
+	path := fp.highlightedPath()
+	if path == "" {
+		return nil
+	}
+	if _, ok := fp.previews[path]; ok {
+		return nil
+	}
+	return loadPreview(path)
+}
+
+// viewPreview renders the preview pane for the highlighted file
+func (fp *FilePicker) viewPreview() string {
+	path := fp.highlightedPath()
+	if path == "" {
+		return ""
+	}
+
+	labelStyle := lipgloss.NewStyle().Foreground(styles.Muted)
+	paneStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(styles.Muted).
+		Padding(0, 1)
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Preview"))
+	b.WriteString("\n")
+
+	p, ok := fp.previews[path]
+	switch {
+	case !ok:
+		b.WriteString(helpStyle.Render("Loading..."))
+	case p.Err != "":
+		b.WriteString(errorStyle.Render(p.Err))
+	default:
+		name := p.Name
+		if name == "" {
+			name = "(unnamed)"
+		}
+		rows := [][2]string{
+			{"Name", name},
+			{"Format", p.Format},
+			{"Clusters", fmt.Sprintf("%d", p.Clusters)},
+			{"Hosts", fmt.Sprintf("%d", p.Hosts)},
+		}
+		for i, row := range rows {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			b.WriteString(labelStyle.Render(fmt.Sprintf("%-10s", row[0])))
+			b.WriteString(normalStyle.Render(row[1]))
+		}
+	}
+
+	return paneStyle.Render(b.String())
+}
@@ -0,0 +1,424 @@
+// ABOUTME: Manual infrastructure entry form as a bubbletea model
+// ABOUTME: Collects clusters' hosts and cells plus app totals into a client.ManualInput
+
+package manualentry
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/client"
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/tui/icons"
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/tui/styles"
+)
+
+// SubmittedMsg is sent when the form is completed
+type SubmittedMsg struct {
+	Input *client.ManualInput
+}
+
+// CancelledMsg is sent when the user cancels manual entry
+type CancelledMsg struct{}
+
+// step is the stage of the entry flow currently shown
+type step int
+
+const (
+	stepCluster step = iota // Entering one cluster's hosts and cells
+	stepTotals              // Entering foundation name and app totals
+	stepReview              // Reviewing clusters before submitting
+	stepRemove              // Choosing a cluster to remove
+)
+
+// Review actions
+const (
+	actionSubmit = "submit"
+	actionAdd    = "add"
+	actionRemove = "remove"
+)
+
+// ManualEntry collects infrastructure details for one or more clusters
+type ManualEntry struct {
+	form  *huh.Form
+	step  step
+	width int
+
+	// Clusters entered so far
+	clusters []client.ClusterInput
+	// Whether the totals step has been completed (skipped when adding clusters later)
+	totalsDone bool
+
+	// Form field values (strings for huh)
+	name          string
+	clusterName   string
+	hostCount     string
+	memoryPerHost string
+	cpuPerHost    string
+	haAdmission   string
+	cellCount     string
+	cellMemory    string
+	cellCPU       string
+	cellDisk      string
+	platformVMs   string
+	appMemory     string
+	appDisk       string
+	appInstances  string
+	action        string
+	removeIndex   int
+}
+
+// New creates a manual entry form pre-filled with a small foundation
+func New() *ManualEntry {
+	m := &ManualEntry{
+		name:          "Manual Input",
+		clusterName:   "cluster-01",
+		hostCount:     "4",
+		memoryPerHost: "512",
+		cpuPerHost:    "64",
+		haAdmission:   "25",
+		cellCount:     "10",
+		cellMemory:    "64",
+		cellCPU:       "8",
+		cellDisk:      "200",
+		platformVMs:   "0",
+		appMemory:     "0",
+		appDisk:       "0",
+		appInstances:  "0",
+	}
+	m.form = m.createClusterForm()
+	return m
+}
+
+func (m *ManualEntry) createClusterForm() *huh.Form {
+	title := fmt.Sprintf("Cluster %d", len(m.clusters)+1)
+	return huh.NewForm(
+		huh.NewGroup(
+			huh.NewInput().
+				Title("Cluster name").
+				CharLimit(64).
+				Value(&m.clusterName).
+				Validate(m.validateClusterName),
+			huh.NewInput().
+				Title("ESXi hosts").
+				CharLimit(4).
+				Value(&m.hostCount).
+				Validate(validatePositiveInt),
+			huh.NewInput().
+				Title("Memory per host (GB)").
+				CharLimit(6).
+				Value(&m.memoryPerHost).
+				Validate(validatePositiveInt),
+			huh.NewInput().
+				Title("CPU threads per host").
+				CharLimit(4).
+				Value(&m.cpuPerHost).
+				Validate(validateNonNegativeInt),
+			huh.NewInput().
+				Title("HA admission control (%)").
+				CharLimit(3).
+				Value(&m.haAdmission).
+				Validate(validatePercentage),
+		).Title(title).
+			Description("Hosts in the vSphere cluster running Diego cells (Enter moves to the next field)"),
+		huh.NewGroup(
+			huh.NewInput().
+				Title("Diego cell count").
+				CharLimit(5).
+				Value(&m.cellCount).
+				Validate(validatePositiveInt),
+			huh.NewInput().
+				Title("Memory per cell (GB)").
+				CharLimit(4).
+				Value(&m.cellMemory).
+				Validate(validatePositiveInt),
+			huh.NewInput().
+				Title("vCPUs per cell").
+				CharLimit(3).
+				Value(&m.cellCPU).
+				Validate(validatePositiveInt),
+			huh.NewInput().
+				Title("Disk per cell (GB)").
+				CharLimit(5).
+				Value(&m.cellDisk).
+				Validate(validateNonNegativeInt),
+		).Title(title+": Diego Cells").
+			Description("Size and number of Diego cell VMs in this cluster"),
+	).WithTheme(styles.FormTheme())
+}
+
+func (m *ManualEntry) createTotalsForm() *huh.Form {
+	return huh.NewForm(
+		huh.NewGroup(
+			huh.NewInput().
+				Title("Foundation name").
+				CharLimit(64).
+				Value(&m.name).
+				Validate(validateRequired),
+			huh.NewInput().
+				Title("Platform VM memory (GB)").
+				Description("Memory used by non-Diego VMs (BOSH, routers, brains)").
+				CharLimit(6).
+				Value(&m.platformVMs).
+				Validate(validateNonNegativeInt),
+			huh.NewInput().
+				Title("Total app memory (GB)").
+				CharLimit(7).
+				Value(&m.appMemory).
+				Validate(validateNonNegativeInt),
+			huh.NewInput().
+				Title("Total app disk (GB)").
+				CharLimit(7).
+				Value(&m.appDisk).
+				Validate(validateNonNegativeInt),
+			huh.NewInput().
+				Title("Total app instances").
+				CharLimit(7).
+				Value(&m.appInstances).
+				Validate(validateNonNegativeInt),
+		).Title("Platform & Apps").
+			Description("Workload totals across all clusters, from Healthwatch or the CF CLI (0 if unknown)"),
+	).WithTheme(styles.FormTheme())
+}
+
+func (m *ManualEntry) createReviewForm() *huh.Form {
+	options := []huh.Option[string]{
+		huh.NewOption("Submit", actionSubmit),
+		huh.NewOption("Add another cluster", actionAdd),
+	}
+	if len(m.clusters) > 1 {
+		options = append(options, huh.NewOption("Remove a cluster", actionRemove))
+	}
+	m.action = actionSubmit
+
+	return huh.NewForm(
+		huh.NewGroup(
+			huh.NewNote().
+				Title("Review").
+				Description(m.reviewSummary()),
+			huh.NewSelect[string]().
+				Title("Next").
+				Description("Use ↑/↓ to select, Enter to confirm").
+				Options(options...).
+				Value(&m.action),
+		),
+	).WithTheme(styles.FormTheme())
+}
+
+func (m *ManualEntry) createRemoveForm() *huh.Form {
+	options := make([]huh.Option[int], len(m.clusters))
+	for i, c := range m.clusters {
+		options[i] = huh.NewOption(c.Name, i)
+	}
+	m.removeIndex = len(m.clusters) - 1
+
+	return huh.NewForm(
+		huh.NewGroup(
+			huh.NewSelect[int]().
+				Title("Remove cluster").
+				Description("Use ↑/↓ to select, Enter to remove").
+				Options(options...).
+				Value(&m.removeIndex),
+		),
+	).WithTheme(styles.FormTheme())
+}
+
+// reviewSummary lists every entered cluster and the foundation totals
+func (m *ManualEntry) reviewSummary() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s: %d cluster(s)\n\n", strings.TrimSpace(m.name), len(m.clusters))
+	for _, c := range m.clusters {
+		fmt.Fprintf(&sb, "%s: %d hosts x %d GB, %d cells x %d GB / %d vCPU, HA %d%%\n",
+			c.Name, c.HostCount, c.MemoryGBPerHost, c.DiegoCellCount, c.DiegoCellMemoryGB, c.DiegoCellCPU, c.HAAdmissionControlPercentage)
+	}
+	fmt.Fprintf(&sb, "\nPlatform VMs %d GB, apps %d GB memory / %d GB disk / %d instances",
+		atoi(m.platformVMs), atoi(m.appMemory), atoi(m.appDisk), atoi(m.appInstances))
+	return sb.String()
+}
+
+// Init implements tea.Model
+func (m *ManualEntry) Init() tea.Cmd {
+	return m.form.Init()
+}
+
+// Update implements tea.Model
+func (m *ManualEntry) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+	case tea.KeyMsg:
+		if msg.String() == "esc" {
+			return m, func() tea.Msg { return CancelledMsg{} }
+		}
+	}
+
+	form, cmd := m.form.Update(msg)
+	if f, ok := form.(*huh.Form); ok {
+		m.form = f
+	}
+
+	if m.form.State == huh.StateCompleted {
+		return m.advanceStep()
+	}
+
+	return m, cmd
+}
+
+func (m *ManualEntry) advanceStep() (tea.Model, tea.Cmd) {
+	switch m.step {
+	case stepCluster:
+		m.commitCluster()
+		if m.totalsDone {
+			m.step = stepReview
+			m.form = m.createReviewForm()
+		} else {
+			m.step = stepTotals
+			m.form = m.createTotalsForm()
+		}
+		return m, m.form.Init()
+
+	case stepTotals:
+		m.totalsDone = true
+		m.step = stepReview
+		m.form = m.createReviewForm()
+		return m, m.form.Init()
+
+	case stepReview:
+		switch m.action {
+		case actionAdd:
+			m.startCluster()
+			m.step = stepCluster
+			m.form = m.createClusterForm()
+		case actionRemove:
+			m.step = stepRemove
+			m.form = m.createRemoveForm()
+		default:
+			input := m.Input()
+			return m, func() tea.Msg { return SubmittedMsg{Input: input} }
+		}
+		return m, m.form.Init()
+
+	case stepRemove:
+		m.removeCluster(m.removeIndex)
+		m.step = stepReview
+		m.form = m.createReviewForm()
+		return m, m.form.Init()
+	}
+
+	return m, nil
+}
+
+// commitCluster appends the cluster currently in the form fields
+func (m *ManualEntry) commitCluster() {
+	m.clusters = append(m.clusters, client.ClusterInput{
+		Name:                         strings.TrimSpace(m.clusterName),
+		HostCount:                    atoi(m.hostCount),
+		MemoryGBPerHost:              atoi(m.memoryPerHost),
+		CPUThreadsPerHost:            atoi(m.cpuPerHost),
+		HAAdmissionControlPercentage: atoi(m.haAdmission),
+		DiegoCellCount:               atoi(m.cellCount),
+		DiegoCellMemoryGB:            atoi(m.cellMemory),
+		DiegoCellCPU:                 atoi(m.cellCPU),
+		DiegoCellDiskGB:              atoi(m.cellDisk),
+	})
+}
+
+// startCluster prepares the form fields for another cluster. Sizing carries
+// over from the previous cluster since clusters in a foundation usually match.
+func (m *ManualEntry) startCluster() {
+	m.clusterName = fmt.Sprintf("cluster-%02d", len(m.clusters)+1)
+}
+
+// removeCluster drops the cluster at index i, keeping at least one
+func (m *ManualEntry) removeCluster(i int) {
+	if i < 0 || i >= len(m.clusters) || len(m.clusters) <= 1 {
+		return
+	}
+	m.clusters = append(m.clusters[:i], m.clusters[i+1:]...)
+}
+
+// validateClusterName requires a name not already used by an entered cluster
+func (m *ManualEntry) validateClusterName(s string) error {
+	if err := validateRequired(s); err != nil {
+		return err
+	}
+	for _, c := range m.clusters {
+		if c.Name == strings.TrimSpace(s) {
+			return fmt.Errorf("cluster %q already entered", c.Name)
+		}
+	}
+	return nil
+}
+
+// SetWidth sets the form width for proper rendering
+func (m *ManualEntry) SetWidth(width int) {
+	m.width = width
+}
+
+// View implements tea.Model
+func (m *ManualEntry) View() string {
+	var sb strings.Builder
+
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(styles.Primary)
+	sb.WriteString(titleStyle.Render(icons.Settings.String() + " Manual Infrastructure Input"))
+	sb.WriteString("\n\n")
+	if m.width > 0 {
+		m.form = m.form.WithWidth(m.width)
+	}
+	sb.WriteString(m.form.View())
+
+	return sb.String()
+}
+
+// Input returns the entered clusters and totals as a ManualInput
+func (m *ManualEntry) Input() *client.ManualInput {
+	clusters := make([]client.ClusterInput, len(m.clusters))
+	copy(clusters, m.clusters)
+	return &client.ManualInput{
+		Name:              strings.TrimSpace(m.name),
+		Clusters:          clusters,
+		PlatformVMsGB:     atoi(m.platformVMs),
+		TotalAppMemoryGB:  atoi(m.appMemory),
+		TotalAppDiskGB:    atoi(m.appDisk),
+		TotalAppInstances: atoi(m.appInstances),
+	}
+}
+
+func atoi(s string) int {
+	v, _ := strconv.Atoi(strings.TrimSpace(s))
+	return v
+}
+
+func validateRequired(s string) error {
+	if strings.TrimSpace(s) == "" {
+		return fmt.Errorf("is required")
+	}
+	return nil
+}
+
+func validatePositiveInt(s string) error {
+	v, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || v <= 0 {
+		return fmt.Errorf("must be a positive number")
+	}
+	return nil
+}
+
+func validateNonNegativeInt(s string) error {
+	v, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || v < 0 {
+		return fmt.Errorf("must be zero or a positive number")
+	}
+	return nil
+}
+
+func validatePercentage(s string) error {
+	v, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || v < 0 || v > 100 {
+		return fmt.Errorf("must be between 0 and 100")
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+// ABOUTME: Tests for the manual infrastructure entry form
+// ABOUTME: Validates defaults, field validation, and conversion to ManualInput
+
+package manualentry
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestManualEntryDefaultCluster(t *testing.T) {
+	m := New()
+	m.commitCluster()
+
+	input := m.Input()
+
+	if input.Name != "Manual Input" {
+		t.Errorf("expected name 'Manual Input', got %q", input.Name)
+	}
+	if len(input.Clusters) != 1 {
+		t.Fatalf("expected 1 cluster, got %d", len(input.Clusters))
+	}
+	c := input.Clusters[0]
+	if c.Name != "cluster-01" {
+		t.Errorf("expected cluster name cluster-01, got %q", c.Name)
+	}
+	if c.HostCount != 4 || c.MemoryGBPerHost != 512 || c.CPUThreadsPerHost != 64 {
+		t.Errorf("unexpected host defaults: %+v", c)
+	}
+	if c.HAAdmissionControlPercentage != 25 {
+		t.Errorf("expected HA 25%%, got %d", c.HAAdmissionControlPercentage)
+	}
+	if c.DiegoCellCount != 10 || c.DiegoCellMemoryGB != 64 || c.DiegoCellCPU != 8 || c.DiegoCellDiskGB != 200 {
+		t.Errorf("unexpected cell defaults: %+v", c)
+	}
+}
+
+func TestManualEntryInputParsesFields(t *testing.T) {
+	m := New()
+	m.name = " Lab "
+	m.clusterName = "prod-az1"
+	m.hostCount = "8"
+	m.memoryPerHost = "1024"
+	m.cellCount = " 40 "
+	m.platformVMs = "300"
+	m.appMemory = "1800"
+	m.appDisk = "2400"
+	m.appInstances = "950"
+	m.commitCluster()
+
+	input := m.Input()
+
+	if input.Name != "Lab" {
+		t.Errorf("expected trimmed name 'Lab', got %q", input.Name)
+	}
+	c := input.Clusters[0]
+	if c.Name != "prod-az1" || c.HostCount != 8 || c.MemoryGBPerHost != 1024 || c.DiegoCellCount != 40 {
+		t.Errorf("unexpected cluster: %+v", c)
+	}
+	if input.PlatformVMsGB != 300 || input.TotalAppMemoryGB != 1800 || input.TotalAppDiskGB != 2400 || input.TotalAppInstances != 950 {
+		t.Errorf("unexpected totals: %+v", input)
+	}
+}
+
+func TestManualEntryMultipleClusters(t *testing.T) {
+	m := New()
+
+	// First cluster, then totals, then review
+	m.advanceStep()
+	if m.step != stepTotals {
+		t.Fatalf("expected totals step after first cluster, got %d", m.step)
+	}
+	m.advanceStep()
+	if m.step != stepReview {
+		t.Fatalf("expected review step after totals, got %d", m.step)
+	}
+
+	// Add a second cluster; sizing carries over and totals are not asked again
+	m.action = actionAdd
+	m.advanceStep()
+	if m.step != stepCluster {
+		t.Fatalf("expected cluster step after add, got %d", m.step)
+	}
+	if m.clusterName != "cluster-02" {
+		t.Errorf("expected next cluster name cluster-02, got %q", m.clusterName)
+	}
+	m.hostCount = "6"
+	m.advanceStep()
+	if m.step != stepReview {
+		t.Fatalf("expected review step after second cluster, got %d", m.step)
+	}
+
+	// Add and then remove a third cluster
+	m.action = actionAdd
+	m.advanceStep()
+	m.advanceStep()
+	if len(m.clusters) != 3 {
+		t.Fatalf("expected 3 clusters, got %d", len(m.clusters))
+	}
+	m.action = actionRemove
+	m.advanceStep()
+	if m.step != stepRemove {
+		t.Fatalf("expected remove step, got %d", m.step)
+	}
+	m.removeIndex = 2
+	m.advanceStep()
+	if m.step != stepReview {
+		t.Fatalf("expected review step after removal, got %d", m.step)
+	}
+
+	summary := m.reviewSummary()
+	if !strings.Contains(summary, "cluster-01") || !strings.Contains(summary, "cluster-02") || strings.Contains(summary, "cluster-03") {
+		t.Errorf("expected review to list cluster-01 and cluster-02 only, got:\n%s", summary)
+	}
+
+	m.action = actionSubmit
+	_, cmd := m.advanceStep()
+	if cmd == nil {
+		t.Fatal("expected a command on submit")
+	}
+	msg, ok := cmd().(SubmittedMsg)
+	if !ok {
+		t.Fatal("expected SubmittedMsg on submit")
+	}
+	if len(msg.Input.Clusters) != 2 {
+		t.Fatalf("expected 2 clusters submitted, got %d", len(msg.Input.Clusters))
+	}
+	if msg.Input.Clusters[0].HostCount != 4 || msg.Input.Clusters[1].HostCount != 6 {
+		t.Errorf("unexpected host counts: %d, %d", msg.Input.Clusters[0].HostCount, msg.Input.Clusters[1].HostCount)
+	}
+}
+
+func TestManualEntryRemoveKeepsOneCluster(t *testing.T) {
+	m := New()
+	m.commitCluster()
+
+	m.removeCluster(0)
+
+	if len(m.clusters) != 1 {
+		t.Errorf("expected the only cluster to be kept, got %d clusters", len(m.clusters))
+	}
+}
+
+func TestManualEntryRejectsDuplicateClusterName(t *testing.T) {
+	m := New()
+	m.commitCluster()
+
+	if err := m.validateClusterName("cluster-01"); err == nil {
+		t.Error("expected an error for a duplicate cluster name")
+	}
+	if err := m.validateClusterName("cluster-02"); err != nil {
+		t.Errorf("expected cluster-02 to be accepted, got %v", err)
+	}
+}
+
+func TestManualEntryValidators(t *testing.T) {
+	tests := []struct {
+		name     string
+		validate func(string) error
+		value    string
+		wantErr  bool
+	}{
+		{"required ok", validateRequired, "cluster", false},
+		{"required blank", validateRequired, "  ", true},
+		{"positive ok", validatePositiveInt, "4", false},
+		{"positive zero", validatePositiveInt, "0", true},
+		{"positive text", validatePositiveInt, "four", true},
+		{"non-negative zero", validateNonNegativeInt, "0", false},
+		{"non-negative negative", validateNonNegativeInt, "-1", true},
+		{"percentage ok", validatePercentage, "100", false},
+		{"percentage over", validatePercentage, "101", true},
+		{"percentage negative", validatePercentage, "-5", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.validate(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validate(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestManualEntryEscCancels(t *testing.T) {
+	m := New()
+	m.Init()
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if cmd == nil {
+		t.Fatal("expected a command on Esc")
+	}
+	if _, ok := cmd().(CancelledMsg); !ok {
+		t.Error("expected CancelledMsg on Esc")
+	}
+}
+
+func TestManualEntryView(t *testing.T) {
+	m := New()
+	m.SetWidth(80)
+
+	if m.View() == "" {
+		t.Error("expected non-empty view")
+	}
+}
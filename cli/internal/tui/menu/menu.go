@@ -39,6 +39,7 @@ type Menu struct {
 	options []option
 	cursor  int
 	err     string
+	notice  string // Informational message, e.g. why the user was returned here
 	width   int
 	height  int
 }
@@ -50,6 +51,7 @@ var (
 	normalStyle   = lipgloss.NewStyle().Foreground(styles.Text)
 	disabledStyle = lipgloss.NewStyle().Foreground(styles.Muted)
 	errorStyle    = lipgloss.NewStyle().Foreground(styles.Danger)
+	noticeStyle   = lipgloss.NewStyle().Foreground(styles.Warning)
 )
 
 // New creates a new data source menu
@@ -78,8 +80,9 @@ func (m *Menu) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case tea.KeyMsg:
-		// Clear error on any key press
+		// Clear error and notice on any key press
 		m.err = ""
+		m.notice = ""
 
 		switch msg.String() {
 		case "up", "k":
@@ -113,6 +116,11 @@ func (m *Menu) selectOption() (tea.Model, tea.Cmd) {
 	}
 }
 
+// SetNotice sets an informational message shown until the next key press
+func (m *Menu) SetNotice(msg string) {
+	m.notice = msg
+}
+
 // View implements tea.Model
 func (m *Menu) View() string {
 	var b strings.Builder
@@ -144,6 +152,9 @@ func (m *Menu) View() string {
 	if m.err != "" {
 		b.WriteString("\n")
 		b.WriteString(errorStyle.Render("Error: " + m.err))
+	} else if m.notice != "" {
+		b.WriteString("\n")
+		b.WriteString(noticeStyle.Render(m.notice))
 	}
 
 	// Footer frame now has keyboard shortcuts, so we don't need them here
@@ -0,0 +1,106 @@
+// ABOUTME: huh form theme shared by the wizard and other TUI forms
+// ABOUTME: Maps the frontend React palette onto huh field, select, and button styles
+
+package styles
+
+import (
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// FormTheme returns the huh theme shared by TUI forms in the active theme's colors
+func FormTheme() *huh.Theme {
+	t := huh.ThemeBase()
+
+	// Colors from the active theme (default matches frontend React theme)
+	cyan := Primary     // Cyan-500 - primary
+	cyanLight := Accent // Cyan-400 - accents
+	blue := Info        // Blue-500 - info
+	gray := Muted       // Gray-400 - muted
+	grayLight := Text   // Gray-200 - text
+	red := Danger       // Red-400 - errors
+	slate := Surface    // Slate-700 - borders
+	white := current.OnStatus
+
+	// Group styles (section headers)
+	t.Group.Title = lipgloss.NewStyle().
+		Foreground(cyan).
+		Bold(true).
+		MarginBottom(1)
+	t.Group.Description = lipgloss.NewStyle().
+		Foreground(gray).
+		MarginBottom(1)
+
+	// Focused field styles
+	t.Focused.Base = lipgloss.NewStyle().
+		PaddingLeft(1).
+		BorderStyle(lipgloss.ThickBorder()).
+		BorderLeft(true).
+		BorderForeground(cyan)
+	t.Focused.Title = lipgloss.NewStyle().
+		Foreground(cyanLight).
+		Bold(true)
+	t.Focused.Description = lipgloss.NewStyle().
+		Foreground(gray)
+	t.Focused.ErrorIndicator = lipgloss.NewStyle().
+		Foreground(red).
+		SetString(" *")
+	t.Focused.ErrorMessage = lipgloss.NewStyle().
+		Foreground(red)
+
+	// Select field styles
+	t.Focused.SelectSelector = lipgloss.NewStyle().
+		Foreground(cyan).
+		SetString("> ")
+	t.Focused.Option = lipgloss.NewStyle().
+		Foreground(grayLight)
+	t.Focused.SelectedOption = lipgloss.NewStyle().
+		Foreground(cyan).
+		Bold(true)
+	t.Focused.NextIndicator = lipgloss.NewStyle().
+		Foreground(cyan).
+		MarginLeft(1).
+		SetString("→")
+	t.Focused.PrevIndicator = lipgloss.NewStyle().
+		Foreground(cyan).
+		MarginRight(1).
+		SetString("←")
+
+	// Text input styles
+	t.Focused.TextInput.Cursor = lipgloss.NewStyle().
+		Foreground(cyan)
+	t.Focused.TextInput.Placeholder = lipgloss.NewStyle().
+		Foreground(gray)
+	t.Focused.TextInput.Prompt = lipgloss.NewStyle().
+		Foreground(cyan)
+	t.Focused.TextInput.Text = lipgloss.NewStyle().
+		Foreground(grayLight)
+
+	// Button styles
+	t.Focused.FocusedButton = lipgloss.NewStyle().
+		Foreground(white).
+		Background(blue).
+		Padding(0, 2).
+		MarginRight(1)
+	t.Focused.BlurredButton = lipgloss.NewStyle().
+		Foreground(gray).
+		Background(slate).
+		Padding(0, 2).
+		MarginRight(1)
+
+	// Blurred field styles (inherit from focused with muted colors)
+	t.Blurred = t.Focused
+	t.Blurred.Base = lipgloss.NewStyle().
+		PaddingLeft(1).
+		BorderStyle(lipgloss.HiddenBorder()).
+		BorderLeft(true)
+	t.Blurred.Title = lipgloss.NewStyle().
+		Foreground(gray)
+	t.Blurred.SelectSelector = lipgloss.NewStyle().
+		Foreground(gray).
+		SetString("  ")
+	t.Blurred.Option = lipgloss.NewStyle().
+		Foreground(gray)
+
+	return t
+}
@@ -23,6 +23,21 @@ var (
 	DeltaNeutral  = lipgloss.Color("#9CA3AF") // Gray-400 - no change
 	Info          = lipgloss.Color("#3B82F6") // Blue-500 - informational
 
+	// Shared styles, rebuilt from the palette whenever the theme changes
+	Title, Subtitle                         lipgloss.Style
+	StatusOK, StatusWarning, StatusCritical lipgloss.Style
+	Panel, ActivePanel, Help                lipgloss.Style
+	HeaderStyle, FooterStyle                lipgloss.Style
+	KeyStyle, ValueStyle                    lipgloss.Style
+	DeltaPositiveStyle, DeltaNegativeStyle  lipgloss.Style
+)
+
+func init() {
+	buildStyles()
+}
+
+// buildStyles derives the shared styles from the current palette
+func buildStyles() {
 	// Base styles
 	Title = lipgloss.NewStyle().
 		Bold(true).
@@ -30,21 +45,21 @@ var (
 		MarginBottom(1)
 
 	Subtitle = lipgloss.NewStyle().
-			Foreground(Muted).
-			MarginBottom(1)
+		Foreground(Muted).
+		MarginBottom(1)
 
 	// Status indicators
 	StatusOK = lipgloss.NewStyle().
-			Foreground(Secondary).
-			Bold(true)
+		Foreground(Secondary).
+		Bold(true)
 
 	StatusWarning = lipgloss.NewStyle().
-			Foreground(Warning).
-			Bold(true)
+		Foreground(Warning).
+		Bold(true)
 
 	StatusCritical = lipgloss.NewStyle().
-			Foreground(Danger).
-			Bold(true)
+		Foreground(Danger).
+		Bold(true)
 
 	// Panels
 	Panel = lipgloss.NewStyle().
@@ -53,9 +68,9 @@ var (
 		Padding(1, 2)
 
 	ActivePanel = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(Primary).
-			Padding(1, 2)
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(Primary).
+		Padding(1, 2)
 
 	// Help text
 	Help = lipgloss.NewStyle().
@@ -64,7 +79,7 @@ var (
 
 	// Frame styles for header/footer
 	HeaderStyle = lipgloss.NewStyle().
-			Border(lipgloss.Border{
+		Border(lipgloss.Border{
 			Top:         "─",
 			Bottom:      "",
 			Left:        "╭",
@@ -78,7 +93,7 @@ var (
 		Padding(0, 1)
 
 	FooterStyle = lipgloss.NewStyle().
-			Border(lipgloss.Border{
+		Border(lipgloss.Border{
 			Top:         "",
 			Bottom:      "─",
 			Left:        "╰",
@@ -93,23 +108,23 @@ var (
 
 	// Key style for keyboard shortcuts
 	KeyStyle = lipgloss.NewStyle().
-			Foreground(Accent).
-			Bold(true)
+		Foreground(Accent).
+		Bold(true)
 
 	// Value style for emphasized data
 	ValueStyle = lipgloss.NewStyle().
-			Foreground(Text).
-			Bold(true)
+		Foreground(Text).
+		Bold(true)
 
 	// Delta styles for change indicators
 	DeltaPositiveStyle = lipgloss.NewStyle().
-				Foreground(DeltaPositive).
-				Bold(true)
+		Foreground(DeltaPositive).
+		Bold(true)
 
 	DeltaNegativeStyle = lipgloss.NewStyle().
-				Foreground(DeltaNegative).
-				Bold(true)
-)
+		Foreground(DeltaNegative).
+		Bold(true)
+}
 
 // ProgressBar returns a styled progress bar string (matches frontend blue progress bars)
 func ProgressBar(percent float64, width int) string {
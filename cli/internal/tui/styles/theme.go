@@ -0,0 +1,148 @@
+// ABOUTME: Selectable TUI color themes: default, color-blind safe, and monochrome
+// ABOUTME: Swaps the shared palette and rebuilds styles so components follow the active theme
+
+package styles
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// Theme names accepted by SetTheme and the --theme flag
+const (
+	ThemeDefault    = "default"
+	ThemeColorblind = "colorblind"
+	ThemeMono       = "mono"
+)
+
+// Theme is a palette for the shared colors plus how status is cued
+type Theme struct {
+	Name string
+
+	Primary, Secondary, Warning, Danger, Muted, Text, BgDark lipgloss.Color
+	Accent, Surface, Info                                    lipgloss.Color
+	DeltaPositive, DeltaNegative, DeltaNeutral               lipgloss.Color
+
+	OnStatus  lipgloss.Color // Badge text on OK, critical, info, and neutral backgrounds
+	OnWarning lipgloss.Color // Badge text on warning backgrounds
+
+	// StatusCues prefixes status badges with ✓, !, or ✗ so status does not
+	// depend on telling colors apart
+	StatusCues bool
+
+	// Monochrome renders without color or text attributes
+	Monochrome bool
+}
+
+var themes = map[string]Theme{
+	// Okabe-Ito palette: blue for healthy, orange and vermillion for trouble,
+	// which stay distinct under the common forms of color blindness
+	ThemeColorblind: {
+		Name:          ThemeColorblind,
+		Primary:       lipgloss.Color("#56B4E9"), // Sky blue
+		Secondary:     lipgloss.Color("#0072B2"), // Blue - success/positive
+		Warning:       lipgloss.Color("#E69F00"), // Orange
+		Danger:        lipgloss.Color("#D55E00"), // Vermillion
+		Muted:         lipgloss.Color("#9CA3AF"),
+		Text:          lipgloss.Color("#E5E7EB"),
+		BgDark:        lipgloss.Color("#1E293B"),
+		Accent:        lipgloss.Color("#F0E442"), // Yellow
+		Surface:       lipgloss.Color("#334155"),
+		Info:          lipgloss.Color("#56B4E9"),
+		DeltaPositive: lipgloss.Color("#0072B2"),
+		DeltaNegative: lipgloss.Color("#E69F00"),
+		DeltaNeutral:  lipgloss.Color("#9CA3AF"),
+		OnStatus:      lipgloss.Color("#FFFFFF"),
+		OnWarning:     lipgloss.Color("#000000"),
+		StatusCues:    true,
+	},
+	// Colors are left empty; the ASCII color profile drops them anyway
+	ThemeMono: {
+		Name:       ThemeMono,
+		StatusCues: true,
+		Monochrome: true,
+	},
+}
+
+var (
+	current Theme
+
+	// Color profile in effect before the mono theme replaced it
+	savedProfile termenv.Profile
+	profileSaved bool
+)
+
+func init() {
+	// The palette in styles.go is the default theme
+	themes[ThemeDefault] = Theme{
+		Name:          ThemeDefault,
+		Primary:       Primary,
+		Secondary:     Secondary,
+		Warning:       Warning,
+		Danger:        Danger,
+		Muted:         Muted,
+		Text:          Text,
+		BgDark:        BgDark,
+		Accent:        Accent,
+		Surface:       Surface,
+		Info:          Info,
+		DeltaPositive: DeltaPositive,
+		DeltaNegative: DeltaNegative,
+		DeltaNeutral:  DeltaNeutral,
+		OnStatus:      lipgloss.Color("#FFFFFF"),
+		OnWarning:     lipgloss.Color("#000000"),
+	}
+	current = themes[ThemeDefault]
+}
+
+// ThemeNames lists the themes SetTheme accepts
+func ThemeNames() []string {
+	return []string{ThemeDefault, ThemeColorblind, ThemeMono}
+}
+
+// ResolveTheme returns the theme to use for the --theme flag value name:
+// name itself when set, otherwise mono when NO_COLOR is set and default if not
+func ResolveTheme(name string) string {
+	if name != "" {
+		return name
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return ThemeMono
+	}
+	return ThemeDefault
+}
+
+// Current returns the active theme
+func Current() Theme {
+	return current
+}
+
+// SetTheme makes the named theme active. Call it before the TUI starts;
+// styles built earlier keep the previous colors.
+func SetTheme(name string) error {
+	t, ok := themes[name]
+	if !ok {
+		return fmt.Errorf("unknown theme %q (valid: default, colorblind, mono)", name)
+	}
+
+	current = t
+	Primary, Secondary, Warning, Danger = t.Primary, t.Secondary, t.Warning, t.Danger
+	Muted, Text, BgDark = t.Muted, t.Text, t.BgDark
+	Accent, Surface, Info = t.Accent, t.Surface, t.Info
+	DeltaPositive, DeltaNegative, DeltaNeutral = t.DeltaPositive, t.DeltaNegative, t.DeltaNeutral
+	buildStyles()
+
+	switch {
+	case t.Monochrome && !profileSaved:
+		savedProfile = lipgloss.ColorProfile()
+		profileSaved = true
+		lipgloss.SetColorProfile(termenv.Ascii)
+	case !t.Monochrome && profileSaved:
+		lipgloss.SetColorProfile(savedProfile)
+		profileSaved = false
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+// ABOUTME: Tests for TUI theme selection
+// ABOUTME: Verifies palette swaps, NO_COLOR handling, and the mono color profile
+
+package styles
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+func useTheme(t *testing.T, name string) {
+	t.Helper()
+	if err := SetTheme(name); err != nil {
+		t.Fatalf("SetTheme(%q): %v", name, err)
+	}
+	t.Cleanup(func() { SetTheme(ThemeDefault) })
+}
+
+func TestSetTheme_SwapsPalette(t *testing.T) {
+	defaultPrimary := Primary
+	useTheme(t, ThemeColorblind)
+
+	if Primary == defaultPrimary {
+		t.Error("expected colorblind theme to replace the primary color")
+	}
+	if Current().Name != ThemeColorblind || !Current().StatusCues {
+		t.Errorf("expected colorblind theme with status cues, got %+v", Current())
+	}
+	if got := StatusOK.GetForeground(); got != Secondary {
+		t.Errorf("expected StatusOK rebuilt with the new palette, got %v", got)
+	}
+
+	if err := SetTheme(ThemeDefault); err != nil {
+		t.Fatal(err)
+	}
+	if Primary != defaultPrimary {
+		t.Errorf("expected default theme to restore primary %v, got %v", defaultPrimary, Primary)
+	}
+}
+
+func TestSetTheme_Unknown(t *testing.T) {
+	if err := SetTheme("neon"); err == nil {
+		t.Error("expected error for unknown theme")
+	}
+	if Current().Name != ThemeDefault {
+		t.Errorf("expected theme unchanged after error, got %q", Current().Name)
+	}
+}
+
+func TestSetTheme_MonoColorProfile(t *testing.T) {
+	previous := lipgloss.ColorProfile()
+	lipgloss.SetColorProfile(termenv.TrueColor)
+	defer lipgloss.SetColorProfile(previous)
+
+	useTheme(t, ThemeMono)
+	if lipgloss.ColorProfile() != termenv.Ascii {
+		t.Error("expected mono theme to switch to the ASCII color profile")
+	}
+	if out := StatusCritical.Render("CRIT"); out != "CRIT" {
+		t.Errorf("expected mono output without escape codes, got %q", out)
+	}
+
+	SetTheme(ThemeDefault)
+	if lipgloss.ColorProfile() != termenv.TrueColor {
+		t.Error("expected leaving mono to restore the previous color profile")
+	}
+}
+
+func TestResolveTheme(t *testing.T) {
+	tests := []struct {
+		name    string
+		flag    string
+		noColor string
+		want    string
+	}{
+		{"default", "", "", ThemeDefault},
+		{"NO_COLOR selects mono", "", "1", ThemeMono},
+		{"flag overrides NO_COLOR", ThemeColorblind, "1", ThemeColorblind},
+		{"flag without NO_COLOR", ThemeMono, "", ThemeMono},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("NO_COLOR", tt.noColor)
+			if got := ResolveTheme(tt.flag); got != tt.want {
+				t.Errorf("ResolveTheme(%q) = %q, want %q", tt.flag, got, tt.want)
+			}
+		})
+	}
+}
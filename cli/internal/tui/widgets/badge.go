@@ -1,5 +1,5 @@
 // ABOUTME: Status badge widgets for quick visual status indication
-// ABOUTME: Provides colored inline badges and status indicators
+// ABOUTME: Provides colored inline badges and status indicators in the active theme
 
 package widgets
 
@@ -8,6 +8,7 @@ import (
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/markalston/diego-capacity-analyzer/cli/internal/tui/icons"
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/tui/styles"
 )
 
 // StatusLevel represents the severity of a status
@@ -21,39 +22,53 @@ const (
 	StatusNeutral
 )
 
-// Badge colors (matches frontend React theme)
-var (
-	BadgeOKBg       = lipgloss.Color("#34D399") // Emerald-400 - success
-	BadgeOKFg       = lipgloss.Color("#FFFFFF")
-	BadgeWarnBg     = lipgloss.Color("#FBBF24") // Amber-400 - warning
-	BadgeWarnFg     = lipgloss.Color("#000000")
-	BadgeCritBg     = lipgloss.Color("#F87171") // Red-400 - critical
-	BadgeCritFg     = lipgloss.Color("#FFFFFF")
-	BadgeInfoBg     = lipgloss.Color("#3B82F6") // Blue-500 - info
-	BadgeInfoFg     = lipgloss.Color("#FFFFFF")
-	BadgeNeutralBg  = lipgloss.Color("#9CA3AF") // Gray-400 - neutral
-	BadgeNeutralFg  = lipgloss.Color("#FFFFFF")
-)
-
-// Badge renders a colored status badge
-func Badge(text string, level StatusLevel) string {
-	var bg, fg lipgloss.Color
-
+// StatusColor returns the color for level in the active theme. Every status
+// helper goes through it, so a new theme needs no component changes.
+func StatusColor(level StatusLevel) lipgloss.Color {
+	t := styles.Current()
 	switch level {
 	case StatusOK:
-		bg, fg = BadgeOKBg, BadgeOKFg
+		return t.Secondary
 	case StatusWarning:
-		bg, fg = BadgeWarnBg, BadgeWarnFg
+		return t.Warning
 	case StatusCritical:
-		bg, fg = BadgeCritBg, BadgeCritFg
+		return t.Danger
 	case StatusInfo:
-		bg, fg = BadgeInfoBg, BadgeInfoFg
+		return t.Info
+	default:
+		return t.Muted
+	}
+}
+
+// statusCue returns the shape that marks level when the theme adds status cues
+func statusCue(level StatusLevel) string {
+	if !styles.Current().StatusCues {
+		return ""
+	}
+	switch level {
+	case StatusOK:
+		return "✓"
+	case StatusWarning:
+		return "!"
+	case StatusCritical:
+		return "✗"
 	default:
-		bg, fg = BadgeNeutralBg, BadgeNeutralFg
+		return ""
+	}
+}
+
+// Badge renders a colored status badge
+func Badge(text string, level StatusLevel) string {
+	fg := styles.Current().OnStatus
+	if level == StatusWarning {
+		fg = styles.Current().OnWarning
+	}
+	if cue := statusCue(level); cue != "" {
+		text = cue + " " + text
 	}
 
 	style := lipgloss.NewStyle().
-		Background(bg).
+		Background(StatusColor(level)).
 		Foreground(fg).
 		Padding(0, 1).
 		Bold(true)
@@ -90,45 +105,40 @@ func StatusFromPercent(percent, warnThreshold, critThreshold float64) StatusLeve
 
 // StatusIcon returns the appropriate icon for a status level
 func StatusIcon(level StatusLevel) string {
+	style := lipgloss.NewStyle().Foreground(StatusColor(level))
 	switch level {
 	case StatusOK:
-		return lipgloss.NewStyle().Foreground(BadgeOKBg).Render(icons.CheckOK.String())
+		return style.Render(icons.CheckOK.String())
 	case StatusWarning:
-		return lipgloss.NewStyle().Foreground(BadgeWarnBg).Render(icons.Warning.String())
+		return style.Render(icons.Warning.String())
 	case StatusCritical:
-		return lipgloss.NewStyle().Foreground(BadgeCritBg).Render(icons.Critical.String())
+		return style.Render(icons.Critical.String())
 	case StatusInfo:
-		return lipgloss.NewStyle().Foreground(BadgeInfoBg).Render(icons.Info.String())
+		return style.Render(icons.Info.String())
 	default:
-		return lipgloss.NewStyle().Foreground(BadgeNeutralBg).Render("•")
+		return style.Render("•")
 	}
 }
 
 // StatusText returns styled status text with icon
 func StatusText(text string, level StatusLevel) string {
 	var iconStr string
-	var color lipgloss.Color
 
 	switch level {
 	case StatusOK:
 		iconStr = icons.CheckOK.String()
-		color = BadgeOKBg
 	case StatusWarning:
 		iconStr = icons.Warning.String()
-		color = BadgeWarnBg
 	case StatusCritical:
 		iconStr = icons.Critical.String()
-		color = BadgeCritBg
 	case StatusInfo:
 		iconStr = icons.Info.String()
-		color = BadgeInfoBg
 	default:
 		iconStr = "•"
-		color = BadgeNeutralBg
 	}
 
 	// Style icon and text together (matching how buildPanel renders titles)
-	textStyle := lipgloss.NewStyle().Foreground(color)
+	textStyle := lipgloss.NewStyle().Foreground(StatusColor(level))
 	return textStyle.Render(fmt.Sprintf("%s %s", iconStr, text))
 }
 
@@ -162,11 +172,11 @@ func DeltaBadge(delta float64, unit string, invertColors bool) string {
 // TrendIndicator returns an arrow icon for trend direction
 func TrendIndicator(current, previous float64) string {
 	if current > previous {
-		return lipgloss.NewStyle().Foreground(BadgeWarnBg).Render(icons.TrendUp.String())
+		return lipgloss.NewStyle().Foreground(StatusColor(StatusWarning)).Render(icons.TrendUp.String())
 	} else if current < previous {
-		return lipgloss.NewStyle().Foreground(BadgeOKBg).Render(icons.TrendDown.String())
+		return lipgloss.NewStyle().Foreground(StatusColor(StatusOK)).Render(icons.TrendDown.String())
 	}
-	return lipgloss.NewStyle().Foreground(BadgeNeutralBg).Render("→")
+	return lipgloss.NewStyle().Foreground(StatusColor(StatusNeutral)).Render("→")
 }
 
 // RiskBadge renders a risk level badge for CPU ratios
@@ -0,0 +1,58 @@
+// ABOUTME: Tests for status badge widgets
+// ABOUTME: Verifies badges follow the active theme's colors and status cues
+
+package widgets
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/tui/styles"
+)
+
+func TestStatusBadge_Cues(t *testing.T) {
+	tests := []struct {
+		theme string
+		level StatusLevel
+		want  string
+	}{
+		{styles.ThemeDefault, StatusCritical, "CRIT"},
+		{styles.ThemeColorblind, StatusOK, "✓ OK"},
+		{styles.ThemeColorblind, StatusWarning, "! WARN"},
+		{styles.ThemeMono, StatusCritical, "✗ CRIT"},
+		{styles.ThemeMono, StatusNeutral, "--"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.theme+"/"+tt.want, func(t *testing.T) {
+			if err := styles.SetTheme(tt.theme); err != nil {
+				t.Fatal(err)
+			}
+			defer styles.SetTheme(styles.ThemeDefault)
+
+			got := StatusBadge(tt.level)
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("expected badge to contain %q, got %q", tt.want, got)
+			}
+			if tt.theme == styles.ThemeDefault && strings.ContainsAny(got, "✓!✗") {
+				t.Errorf("expected no status cue in the default theme, got %q", got)
+			}
+		})
+	}
+}
+
+func TestStatusColor_FollowsTheme(t *testing.T) {
+	defaultOK := StatusColor(StatusOK)
+
+	if err := styles.SetTheme(styles.ThemeColorblind); err != nil {
+		t.Fatal(err)
+	}
+	defer styles.SetTheme(styles.ThemeDefault)
+
+	if got := StatusColor(StatusOK); got == defaultOK || got != styles.Current().Secondary {
+		t.Errorf("expected OK color from the colorblind palette, got %v", got)
+	}
+	if StatusColor(StatusWarning) == StatusColor(StatusCritical) {
+		t.Error("expected warning and critical to stay distinct")
+	}
+}
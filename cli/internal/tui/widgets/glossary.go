@@ -0,0 +1,36 @@
+// ABOUTME: Metric glossary widget listing what each computed metric means
+// ABOUTME: Renders the backend's definitions so every screen explains metrics the same way
+
+package widgets
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/client"
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/tui/styles"
+)
+
+// Glossary renders each metric's name, description, and formula wrapped to
+// width. Definitions come from GET /api/v1/metrics/glossary; until they
+// load, it says so rather than showing a stale copy.
+func Glossary(defs []client.MetricDefinition, width int) string {
+	muted := lipgloss.NewStyle().Foreground(styles.Muted)
+	if len(defs) == 0 {
+		return muted.Width(width).Render("Metric definitions have not loaded from the backend.")
+	}
+
+	heading := lipgloss.NewStyle().Foreground(styles.Text).Bold(true)
+	text := lipgloss.NewStyle().Foreground(styles.Text).Width(width)
+	formula := muted.Width(width)
+
+	blocks := make([]string, 0, len(defs))
+	for _, d := range defs {
+		blocks = append(blocks, strings.Join([]string{
+			heading.Render(d.Name),
+			text.Render(d.Description),
+			formula.Render("= " + d.Formula),
+		}, "\n"))
+	}
+	return strings.Join(blocks, "\n\n")
+}
@@ -9,23 +9,29 @@ import (
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/markalston/diego-capacity-analyzer/cli/internal/tui/icons"
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/tui/styles"
 )
 
 // MetricBlockConfig holds configuration for a metric block
 type MetricBlockConfig struct {
-	Width       int
-	BorderColor lipgloss.Color
-	TitleColor  lipgloss.Color
-	ValueColor  lipgloss.Color
+	Width         int
+	BorderColor   lipgloss.Color
+	TitleColor    lipgloss.Color
+	ValueColor    lipgloss.Color
+	WarnThreshold float64 // Percentage at which MetricBlockWithBar turns to warning
+	CritThreshold float64 // Percentage at which MetricBlockWithBar turns to critical
 }
 
-// DefaultMetricBlockConfig returns sensible defaults (matches frontend React theme)
-func DefaultMetricBlockConfig() MetricBlockConfig {
+// DefaultMetricBlockConfig returns sensible defaults in the active theme,
+// with bars turning to warning and critical at the given percentages
+func DefaultMetricBlockConfig(warnPct, critPct float64) MetricBlockConfig {
 	return MetricBlockConfig{
-		Width:       22,
-		BorderColor: lipgloss.Color("#9CA3AF"), // Gray-400 - muted
-		TitleColor:  lipgloss.Color("#06B6D4"), // Cyan-500 - primary accent
-		ValueColor:  lipgloss.Color("#E5E7EB"), // Gray-200 - primary text
+		Width:         22,
+		BorderColor:   styles.Muted,   // Gray-400 - muted
+		TitleColor:    styles.Primary, // Cyan-500 - primary accent
+		ValueColor:    styles.Text,    // Gray-200 - primary text
+		WarnThreshold: warnPct,
+		CritThreshold: critPct,
 	}
 }
 
@@ -62,7 +68,7 @@ func MetricBlock(icon icons.Icon, title string, value string, subtitle string, c
 	valueLine := "│  " + styledValue + strings.Repeat(" ", valuePadding) + "│"
 
 	// Subtitle line
-	subtitleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#9CA3AF"))
+	subtitleStyle := lipgloss.NewStyle().Foreground(styles.Muted)
 	styledSubtitle := subtitleStyle.Render(subtitle)
 	subtitleWidth := lipgloss.Width(styledSubtitle)
 	subtitlePadding := max(0, innerWidth-subtitleWidth)
@@ -101,18 +107,18 @@ func MetricBlockWithBar(icon icons.Icon, title string, percent float64, details
 	fillWidth := max(0, innerWidth-titleWidth-1)
 	topBorder := "┌─ " + styledTitle + " " + strings.Repeat("─", fillWidth) + "┐"
 
-	// Value line with percentage (colors match frontend React theme)
+	// Value line with percentage
 	var statusColor lipgloss.Color
 	var statusIcon string
 
-	if percent >= 95 {
-		statusColor = lipgloss.Color("#F87171") // Red-400 - critical
+	if percent >= config.CritThreshold {
+		statusColor = StatusColor(StatusCritical)
 		statusIcon = "✗"
-	} else if percent >= 80 {
-		statusColor = lipgloss.Color("#FBBF24") // Amber-400 - warning
+	} else if percent >= config.WarnThreshold {
+		statusColor = StatusColor(StatusWarning)
 		statusIcon = "⚠"
 	} else {
-		statusColor = lipgloss.Color("#3B82F6") // Blue-500 - normal
+		statusColor = StatusColor(StatusInfo) // Blue, matching progress bars
 		statusIcon = "✓"
 	}
 
@@ -131,7 +137,7 @@ func MetricBlockWithBar(icon icons.Icon, title string, percent float64, details
 	barLine := "│  " + bar + strings.Repeat(" ", barPadding) + "│"
 
 	// Details line
-	detailStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#9CA3AF"))
+	detailStyle := lipgloss.NewStyle().Foreground(styles.Muted)
 	truncatedDetails := truncate(details, innerWidth)
 	styledDetails := detailStyle.Render(truncatedDetails)
 	detailsWidth := lipgloss.Width(styledDetails)
@@ -171,7 +177,7 @@ func MetricBlockWithSparkline(icon icons.Icon, title string, value string, spark
 	// Value + sparkline
 	valueStyle := lipgloss.NewStyle().Foreground(config.ValueColor).Bold(true)
 	styledValue := valueStyle.Render(value)
-	spark := Sparkline(sparkData, sparkWidth, lipgloss.Color("#06B6D4"))
+	spark := Sparkline(sparkData, sparkWidth, styles.Primary)
 
 	valueWithSpark := styledValue + "  " + spark
 	contentWidth := lipgloss.Width(valueWithSpark)
@@ -179,7 +185,7 @@ func MetricBlockWithSparkline(icon icons.Icon, title string, value string, spark
 	valueLine := "│  " + valueWithSpark + strings.Repeat(" ", padding) + "│"
 
 	// Subtitle
-	subtitleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#9CA3AF"))
+	subtitleStyle := lipgloss.NewStyle().Foreground(styles.Muted)
 	truncatedSubtitle := truncate(subtitle, innerWidth)
 	styledSubtitle := subtitleStyle.Render(truncatedSubtitle)
 	subtitleWidth := lipgloss.Width(styledSubtitle)
@@ -8,13 +8,14 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/markalston/diego-capacity-analyzer/cli/internal/tui/styles"
 )
 
 // ProgressBarConfig holds configuration for the progress bar
 type ProgressBarConfig struct {
 	Width         int
-	WarnThreshold float64 // Percentage where warning zone starts (default 80)
-	CritThreshold float64 // Percentage where critical zone starts (default 95)
+	WarnThreshold float64 // Percentage where warning zone starts
+	CritThreshold float64 // Percentage where critical zone starts
 	OKColor       lipgloss.Color
 	WarnColor     lipgloss.Color
 	CritColor     lipgloss.Color
@@ -22,16 +23,17 @@ type ProgressBarConfig struct {
 	ShowZones     bool // Show threshold markers in the bar
 }
 
-// DefaultProgressBarConfig returns sensible defaults (colors match frontend React theme)
-func DefaultProgressBarConfig() ProgressBarConfig {
+// DefaultProgressBarConfig returns sensible defaults in the active theme's
+// colors, with zones starting at the given warning and critical percentages
+func DefaultProgressBarConfig(warnPct, critPct float64) ProgressBarConfig {
 	return ProgressBarConfig{
 		Width:         20,
-		WarnThreshold: 80,
-		CritThreshold: 95,
-		OKColor:       lipgloss.Color("#3B82F6"), // Blue-500 - matches frontend progress bars
-		WarnColor:     lipgloss.Color("#FBBF24"), // Amber-400 - warnings
-		CritColor:     lipgloss.Color("#F87171"), // Red-400 - critical
-		EmptyColor:    lipgloss.Color("#334155"), // Slate-700 - empty portion
+		WarnThreshold: warnPct,
+		CritThreshold: critPct,
+		OKColor:       styles.Info,    // Blue-500 - matches frontend progress bars
+		WarnColor:     styles.Warning, // Amber-400 - warnings
+		CritColor:     styles.Danger,  // Red-400 - critical
+		EmptyColor:    styles.Surface, // Slate-700 - empty portion
 		ShowZones:     true,
 	}
 }
@@ -33,112 +33,21 @@ type Wizard struct {
 	width int
 
 	// Form field values (strings for huh)
-	cellMemory  string
-	cellCPU     string
-	cellDisk    string
-	cellCount   string
-	overhead    string
-	haAdmission string
+	targetCluster string // empty = all clusters
+	cellMemory    string
+	cellCPU       string
+	cellDisk      string
+	cellCount     string
+	overhead      string
+	haAdmission   string
+	hostsRemove   string
 }
 
 // Step names for progress indicator
-var stepNames = []string{"Cell Sizing", "Cell Count", "Overhead & HA"}
-
-// createTheme returns a custom huh theme matching the frontend React colors
-func createTheme() *huh.Theme {
-	t := huh.ThemeBase()
-
-	// Colors matching frontend React theme
-	cyan := lipgloss.Color("#06B6D4")    // Cyan-500 - primary
-	cyanLight := lipgloss.Color("#22D3EE") // Cyan-400 - accents
-	blue := lipgloss.Color("#3B82F6")    // Blue-500 - info
-	gray := lipgloss.Color("#9CA3AF")    // Gray-400 - muted
-	grayLight := lipgloss.Color("#E5E7EB") // Gray-200 - text
-	red := lipgloss.Color("#F87171")     // Red-400 - errors
-	slate := lipgloss.Color("#334155")   // Slate-700 - borders
-
-	// Group styles (section headers)
-	t.Group.Title = lipgloss.NewStyle().
-		Foreground(cyan).
-		Bold(true).
-		MarginBottom(1)
-	t.Group.Description = lipgloss.NewStyle().
-		Foreground(gray).
-		MarginBottom(1)
-
-	// Focused field styles
-	t.Focused.Base = lipgloss.NewStyle().
-		PaddingLeft(1).
-		BorderStyle(lipgloss.ThickBorder()).
-		BorderLeft(true).
-		BorderForeground(cyan)
-	t.Focused.Title = lipgloss.NewStyle().
-		Foreground(cyanLight).
-		Bold(true)
-	t.Focused.Description = lipgloss.NewStyle().
-		Foreground(gray)
-	t.Focused.ErrorIndicator = lipgloss.NewStyle().
-		Foreground(red).
-		SetString(" *")
-	t.Focused.ErrorMessage = lipgloss.NewStyle().
-		Foreground(red)
-
-	// Select field styles
-	t.Focused.SelectSelector = lipgloss.NewStyle().
-		Foreground(cyan).
-		SetString("> ")
-	t.Focused.Option = lipgloss.NewStyle().
-		Foreground(grayLight)
-	t.Focused.SelectedOption = lipgloss.NewStyle().
-		Foreground(cyan).
-		Bold(true)
-	t.Focused.NextIndicator = lipgloss.NewStyle().
-		Foreground(cyan).
-		MarginLeft(1).
-		SetString("→")
-	t.Focused.PrevIndicator = lipgloss.NewStyle().
-		Foreground(cyan).
-		MarginRight(1).
-		SetString("←")
-
-	// Text input styles
-	t.Focused.TextInput.Cursor = lipgloss.NewStyle().
-		Foreground(cyan)
-	t.Focused.TextInput.Placeholder = lipgloss.NewStyle().
-		Foreground(gray)
-	t.Focused.TextInput.Prompt = lipgloss.NewStyle().
-		Foreground(cyan)
-	t.Focused.TextInput.Text = lipgloss.NewStyle().
-		Foreground(grayLight)
-
-	// Button styles
-	t.Focused.FocusedButton = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#FFFFFF")).
-		Background(blue).
-		Padding(0, 2).
-		MarginRight(1)
-	t.Focused.BlurredButton = lipgloss.NewStyle().
-		Foreground(gray).
-		Background(slate).
-		Padding(0, 2).
-		MarginRight(1)
-
-	// Blurred field styles (inherit from focused with muted colors)
-	t.Blurred = t.Focused
-	t.Blurred.Base = lipgloss.NewStyle().
-		PaddingLeft(1).
-		BorderStyle(lipgloss.HiddenBorder()).
-		BorderLeft(true)
-	t.Blurred.Title = lipgloss.NewStyle().
-		Foreground(gray)
-	t.Blurred.SelectSelector = lipgloss.NewStyle().
-		Foreground(gray).
-		SetString("  ")
-	t.Blurred.Option = lipgloss.NewStyle().
-		Foreground(gray)
-
-	return t
-}
+var stepNames = []string{"Cell Sizing", "Cell Count", "Overhead & HA", "Host Removal", "Review"}
+
+// reviewStep is the read-only summary shown before the wizard completes
+const reviewStep = 5
 
 // Common cell memory sizes
 var memoryOptions = []huh.Option[string]{
@@ -215,6 +124,7 @@ func New(infra *client.InfrastructureState) *Wizard {
 		cellCount:   strconv.Itoa(input.ProposedCellCount),
 		overhead:    fmt.Sprintf("%.0f", input.OverheadPct),
 		haAdmission: strconv.Itoa(input.HAAdmissionPct),
+		hostsRemove: "0",
 	}
 
 	w.form = w.createStep1Form()
@@ -222,26 +132,56 @@ func New(infra *client.InfrastructureState) *Wizard {
 }
 
 func (w *Wizard) createStep1Form() *huh.Form {
+	var fields []huh.Field
+	// Only worth asking when there is more than one cluster to choose from
+	if w.hasMultipleClusters() {
+		fields = append(fields, huh.NewSelect[string]().
+			Title("Target cluster").
+			Description("Scope the scenario to one cluster, or plan across all of them").
+			Options(w.clusterOptions()...).
+			Value(&w.targetCluster))
+	}
+	fields = append(fields,
+		huh.NewSelect[string]().
+			Title("Memory per cell").
+			Description("Use ↑/↓ to select, Enter to confirm").
+			Options(memoryOptions...).
+			Value(&w.cellMemory),
+		huh.NewSelect[string]().
+			Title("CPU cores per cell").
+			Description("Use ↑/↓ to select, Enter to confirm").
+			Options(cpuOptions...).
+			Value(&w.cellCPU),
+		huh.NewSelect[string]().
+			Title("Disk per cell").
+			Description("Use ↑/↓ to select, Enter to confirm").
+			Options(diskOptions...).
+			Value(&w.cellDisk),
+	)
+
 	return huh.NewForm(
-		huh.NewGroup(
-			huh.NewSelect[string]().
-				Title("Memory per cell").
-				Description("Use ↑/↓ to select, Enter to confirm").
-				Options(memoryOptions...).
-				Value(&w.cellMemory),
-			huh.NewSelect[string]().
-				Title("CPU cores per cell").
-				Description("Use ↑/↓ to select, Enter to confirm").
-				Options(cpuOptions...).
-				Value(&w.cellCPU),
-			huh.NewSelect[string]().
-				Title("Disk per cell").
-				Description("Use ↑/↓ to select, Enter to confirm").
-				Options(diskOptions...).
-				Value(&w.cellDisk),
-		).Title("Step 1: Cell Sizing").
+		huh.NewGroup(fields...).Title("Step 1: Cell Sizing").
 			Description("Configure the size of each Diego cell VM"),
-	).WithTheme(createTheme())
+	).WithTheme(styles.FormTheme())
+}
+
+// hasMultipleClusters reports whether the infrastructure has clusters to pick between
+func (w *Wizard) hasMultipleClusters() bool {
+	return w.infra != nil && len(w.infra.Clusters) > 1
+}
+
+// clusterOptions lists "All clusters" followed by each cluster with its size
+// and host memory utilization
+func (w *Wizard) clusterOptions() []huh.Option[string] {
+	options := []huh.Option[string]{huh.NewOption("All clusters", "")}
+	for _, c := range w.infra.Clusters {
+		label := fmt.Sprintf("%s (%d hosts, %d cells", c.Name, c.HostCount, c.DiegoCellCount)
+		if c.MemoryGB > 0 {
+			label += fmt.Sprintf(", %.0f%% memory", float64(c.TotalCellMemoryGB)/float64(c.MemoryGB)*100)
+		}
+		options = append(options, huh.NewOption(label+")", c.Name))
+	}
+	return options
 }
 
 func (w *Wizard) createStep2Form() *huh.Form {
@@ -256,7 +196,7 @@ func (w *Wizard) createStep2Form() *huh.Form {
 				Validate(validatePositiveInt),
 		).Title("Step 2: Cell Count").
 			Description("How many Diego cells do you want in your scenario?"),
-	).WithTheme(createTheme())
+	).WithTheme(styles.FormTheme())
 }
 
 func (w *Wizard) createStep3Form() *huh.Form {
@@ -289,7 +229,22 @@ func (w *Wizard) createStep3Form() *huh.Form {
 				Value(&w.haAdmission),
 		).Title("Step 3: Overhead & HA").
 			Description("Configure overhead and high availability settings"),
-	).WithTheme(createTheme())
+	).WithTheme(styles.FormTheme())
+}
+
+func (w *Wizard) createStep4Form() *huh.Form {
+	return huh.NewForm(
+		huh.NewGroup(
+			huh.NewInput().
+				Title("Hosts to remove").
+				Description("Type a number and press Enter to review (0 keeps every host)").
+				Placeholder("e.g., 1").
+				CharLimit(3).
+				Value(&w.hostsRemove).
+				Validate(w.validateHostsToRemove),
+		).Title("Step 4: Host Removal").
+			Description("How many hosts will be out of service, e.g. for maintenance?"),
+	).WithTheme(styles.FormTheme())
 }
 
 // Init implements tea.Model
@@ -313,6 +268,18 @@ func (w *Wizard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if msg.String() == "esc" {
 			return w, func() tea.Msg { return WizardCancelledMsg{} }
 		}
+		// Every wizard input is numeric, so b is free to mean back
+		if msg.String() == "b" && w.CanGoBack() {
+			return w.goBack()
+		}
+		if w.step == reviewStep {
+			return w.updateReview(msg)
+		}
+	}
+
+	// The review step has no form to update
+	if w.step == reviewStep {
+		return w, nil
 	}
 
 	// Update the current form
@@ -333,6 +300,7 @@ func (w *Wizard) advanceStep() (tea.Model, tea.Cmd) {
 	switch w.step {
 	case 1:
 		// Parse step 1 values and move to step 2
+		w.input.TargetCluster = w.targetCluster
 		w.input.ProposedCellMemoryGB, _ = strconv.Atoi(w.cellMemory)
 		w.input.ProposedCellCPU, _ = strconv.Atoi(w.cellCPU)
 		w.input.ProposedCellDiskGB, _ = strconv.Atoi(w.cellDisk)
@@ -348,12 +316,22 @@ func (w *Wizard) advanceStep() (tea.Model, tea.Cmd) {
 		return w, w.form.Init()
 
 	case 3:
-		// Parse step 3 values and complete
+		// Parse step 3 values and move to step 4
 		var overheadFloat float64
 		fmt.Sscanf(w.overhead, "%f", &overheadFloat)
 		w.input.OverheadPct = overheadFloat
 		w.input.HAAdmissionPct, _ = strconv.Atoi(w.haAdmission)
+		w.step = 4
+		w.form = w.createStep4Form()
+		return w, w.form.Init()
+
+	case 4:
+		// Parse step 4 values and move to the review
+		w.input.HostsToRemove, _ = strconv.Atoi(w.hostsRemove)
+		w.step = reviewStep
+		return w, nil
 
+	case reviewStep:
 		return w, func() tea.Msg {
 			return WizardCompleteMsg{Input: w.input}
 		}
@@ -362,6 +340,46 @@ func (w *Wizard) advanceStep() (tea.Model, tea.Cmd) {
 	return w, nil
 }
 
+// updateReview confirms the scenario on Enter
+func (w *Wizard) updateReview(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.String() == "enter" {
+		return w.advanceStep()
+	}
+	return w, nil
+}
+
+// goBack returns to the previous step, rebuilding its form from the field
+// values already entered
+func (w *Wizard) goBack() (tea.Model, tea.Cmd) {
+	w.step--
+	w.form = w.createStepForm(w.step)
+	return w, w.form.Init()
+}
+
+// createStepForm builds the form for a form-based step (1 through 4)
+func (w *Wizard) createStepForm(step int) *huh.Form {
+	switch step {
+	case 2:
+		return w.createStep2Form()
+	case 3:
+		return w.createStep3Form()
+	case 4:
+		return w.createStep4Form()
+	default:
+		return w.createStep1Form()
+	}
+}
+
+// CanGoBack reports whether there is a previous step to return to
+func (w *Wizard) CanGoBack() bool {
+	return w.step > 1
+}
+
+// Reviewing reports whether the wizard is showing the confirmation summary
+func (w *Wizard) Reviewing() bool {
+	return w.step == reviewStep
+}
+
 // SetWidth sets the wizard width for proper rendering
 func (w *Wizard) SetWidth(width int) {
 	w.width = width
@@ -375,12 +393,70 @@ func (w *Wizard) View() string {
 	sb.WriteString(w.renderProgress())
 	sb.WriteString("\n\n")
 
-	// Form content
-	sb.WriteString(w.form.View())
+	// Form content, or the summary on the review step
+	if w.step == reviewStep {
+		sb.WriteString(w.renderReview())
+	} else {
+		sb.WriteString(w.form.View())
+	}
 
 	return sb.String()
 }
 
+// renderReview summarizes the entered scenario and its projected memory
+// reservation against the usable host memory, when the host layout is known
+func (w *Wizard) renderReview() string {
+	titleStyle := lipgloss.NewStyle().Foreground(styles.Primary).Bold(true)
+	labelStyle := lipgloss.NewStyle().Foreground(styles.Muted)
+	hintStyle := lipgloss.NewStyle().Foreground(styles.Muted).Italic(true)
+
+	in := w.input
+	rows := [][2]string{
+		{"Cell sizing", fmt.Sprintf("%d GB / %d vCPU / %d GB disk", in.ProposedCellMemoryGB, in.ProposedCellCPU, in.ProposedCellDiskGB)},
+		{"Cell count", strconv.Itoa(in.ProposedCellCount)},
+		{"Memory overhead", fmt.Sprintf("%.0f%%", in.OverheadPct)},
+		{"HA admission control", fmt.Sprintf("%d%%", in.HAAdmissionPct)},
+		{"Hosts to remove", strconv.Itoa(in.HostsToRemove)},
+	}
+	if w.hasMultipleClusters() {
+		target := in.TargetCluster
+		if target == "" {
+			target = "All clusters"
+		}
+		rows = append([][2]string{{"Target cluster", target}}, rows...)
+	}
+
+	reserved := in.ProposedCellCount * in.ProposedCellMemoryGB
+	reservation := fmt.Sprintf("%d cells x %d GB = %d GB", in.ProposedCellCount, in.ProposedCellMemoryGB, reserved)
+	if usable := usableHostMemoryGB(in); usable > 0 {
+		reservation += fmt.Sprintf(" of %d GB usable (%.0f%%)", usable, float64(reserved)/float64(usable)*100)
+	}
+	rows = append(rows, [2]string{"Projected memory", reservation})
+
+	var sb strings.Builder
+	sb.WriteString(titleStyle.Render("Step 5: Review"))
+	sb.WriteString("\n\n")
+	for _, row := range rows {
+		sb.WriteString(labelStyle.Render(fmt.Sprintf("  %-22s", row[0])))
+		sb.WriteString(row[1])
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n")
+	sb.WriteString(hintStyle.Render("Press Enter to run the scenario, or b to go back and edit"))
+
+	return sb.String()
+}
+
+// usableHostMemoryGB returns host memory left after removed hosts and the HA
+// reservation, or 0 when the host layout is unknown
+func usableHostMemoryGB(in *client.ScenarioInput) int {
+	hosts := in.HostCount - in.HostsToRemove
+	if hosts <= 0 || in.MemoryPerHostGB <= 0 {
+		return 0
+	}
+	return hosts * in.MemoryPerHostGB * (100 - in.HAAdmissionPct) / 100
+}
+
 // renderProgress renders the step progress indicator
 func (w *Wizard) renderProgress() string {
 	// Use width - 1 to ensure progress box fits within the frame
@@ -478,6 +554,19 @@ func validatePositiveInt(s string) error {
 	return nil
 }
 
+// validateHostsToRemove accepts 0 and, when the host count is known, any
+// count that leaves at least two hosts so N-1 capacity remains
+func (w *Wizard) validateHostsToRemove(s string) error {
+	v, err := strconv.Atoi(s)
+	if err != nil || v < 0 {
+		return fmt.Errorf("must be zero or a positive number")
+	}
+	if w.input.HostCount > 0 && v > w.input.HostCount-2 {
+		return fmt.Errorf("must leave at least 2 of %d hosts", w.input.HostCount)
+	}
+	return nil
+}
+
 func validatePercentage(s string) error {
 	var v float64
 	if _, err := fmt.Sscanf(s, "%f", &v); err != nil || v < 0 || v > 100 {
@@ -510,7 +599,7 @@ func (w *Wizard) Run() error {
 				Value(&w.cellDisk),
 		).Title("Step 1: Cell Sizing").
 			Description("Configure the size of each Diego cell VM"),
-	).WithTheme(createTheme())
+	).WithTheme(styles.FormTheme())
 
 	if err := form1.Run(); err != nil {
 		return err
@@ -532,7 +621,7 @@ func (w *Wizard) Run() error {
 				Validate(validatePositiveInt),
 		).Title("Step 2: Cell Count").
 			Description("How many Diego cells do you want in your scenario?"),
-	).WithTheme(createTheme())
+	).WithTheme(styles.FormTheme())
 
 	if err := form2.Run(); err != nil {
 		return err
@@ -570,7 +659,7 @@ func (w *Wizard) Run() error {
 				Value(&w.haAdmission),
 		).Title("Step 3: Overhead & HA").
 			Description("Configure overhead and high availability settings"),
-	).WithTheme(createTheme())
+	).WithTheme(styles.FormTheme())
 
 	if err := form3.Run(); err != nil {
 		return err
@@ -581,5 +670,12 @@ func (w *Wizard) Run() error {
 	w.input.OverheadPct = overheadFloat
 	w.input.HAAdmissionPct, _ = strconv.Atoi(w.haAdmission)
 
+	// Step 4: Host removal
+	if err := w.createStep4Form().Run(); err != nil {
+		return err
+	}
+
+	w.input.HostsToRemove, _ = strconv.Atoi(w.hostsRemove)
+
 	return nil
 }
@@ -4,8 +4,10 @@
 package wizard
 
 import (
+	"strings"
 	"testing"
 
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/markalston/diego-capacity-analyzer/cli/internal/client"
 )
 
@@ -151,6 +153,35 @@ func TestValidatePositiveInt(t *testing.T) {
 	}
 }
 
+func TestValidateHostsToRemove(t *testing.T) {
+	tests := []struct {
+		name      string
+		hostCount int
+		input     string
+		wantErr   bool
+	}{
+		{"zero", 4, "0", false},
+		{"leaves two hosts", 4, "2", false},
+		{"leaves one host", 4, "3", true},
+		{"unknown host count", 0, "5", false},
+		{"negative", 4, "-1", true},
+		{"not a number", 4, "abc", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := &Wizard{input: &client.ScenarioInput{HostCount: tc.hostCount}}
+			err := w.validateHostsToRemove(tc.input)
+			if tc.wantErr && err == nil {
+				t.Errorf("expected error for input %q", tc.input)
+			}
+			if !tc.wantErr && err != nil {
+				t.Errorf("unexpected error for input %q: %v", tc.input, err)
+			}
+		})
+	}
+}
+
 func TestValidatePercentage(t *testing.T) {
 	tests := []struct {
 		input   string
@@ -212,3 +243,156 @@ func TestCPUOptionsExist(t *testing.T) {
 		}
 	}
 }
+
+func TestWizardReviewStep(t *testing.T) {
+	infra := &client.InfrastructureState{
+		TotalHostCount: 4,
+		TotalCellCount: 10,
+		Clusters: []client.ClusterState{{
+			Name:                         "cluster-1",
+			DiegoCellMemoryGB:            64,
+			DiegoCellCPU:                 8,
+			MemoryGBPerHost:              512,
+			HAAdmissionControlPercentage: 25,
+		}},
+	}
+	w := New(infra)
+	w.step = 4
+	w.hostsRemove = "0"
+
+	_, cmd := w.advanceStep()
+	if cmd != nil {
+		t.Error("expected no completion command when entering the review step")
+	}
+	if !w.Reviewing() {
+		t.Fatalf("expected review step, got step %d", w.step)
+	}
+
+	view := w.View()
+	if !strings.Contains(view, "Step 5: Review") {
+		t.Error("expected review title in view")
+	}
+	// 10 cells x 64 GB = 640 GB of 4 x 512 GB less 25% HA = 1536 GB
+	if !strings.Contains(view, "10 cells x 64 GB = 640 GB of 1536 GB usable (42%)") {
+		t.Errorf("expected projected memory reservation in view, got:\n%s", view)
+	}
+
+	// b returns to the host removal step
+	w.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'b'}})
+	if w.step != 4 {
+		t.Errorf("expected b to return to step 4, got step %d", w.step)
+	}
+
+	// Enter on the review completes the wizard
+	w.step = reviewStep
+	_, cmd = w.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatal("expected a command on Enter")
+	}
+	msg, ok := cmd().(WizardCompleteMsg)
+	if !ok {
+		t.Fatal("expected WizardCompleteMsg on Enter")
+	}
+	if msg.Input.ProposedCellCount != 10 {
+		t.Errorf("expected cell count 10, got %d", msg.Input.ProposedCellCount)
+	}
+}
+
+func TestStepNamesIncludeReview(t *testing.T) {
+	if len(stepNames) != reviewStep {
+		t.Errorf("expected %d step names, got %d", reviewStep, len(stepNames))
+	}
+	if stepNames[len(stepNames)-1] != "Review" {
+		t.Errorf("expected last step to be Review, got %s", stepNames[len(stepNames)-1])
+	}
+}
+
+func TestWizardBackNavigation(t *testing.T) {
+	w := New(nil)
+	back := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'b'}}
+
+	// Nothing to go back to from step 1
+	w.Update(back)
+	if w.step != 1 {
+		t.Errorf("expected to stay on step 1, got step %d", w.step)
+	}
+	if w.CanGoBack() {
+		t.Error("expected CanGoBack to be false on step 1")
+	}
+
+	w.cellMemory = "128"
+	w.advanceStep()
+	w.cellCount = "42"
+	w.advanceStep()
+	if w.step != 3 {
+		t.Fatalf("expected step 3, got %d", w.step)
+	}
+
+	w.Update(back)
+	if w.step != 2 {
+		t.Errorf("expected b to return to step 2, got step %d", w.step)
+	}
+	if w.cellCount != "42" {
+		t.Errorf("expected cell count to be kept, got %q", w.cellCount)
+	}
+
+	w.Update(back)
+	if w.step != 1 {
+		t.Errorf("expected b to return to step 1, got step %d", w.step)
+	}
+	if w.cellMemory != "128" {
+		t.Errorf("expected cell memory to be kept, got %q", w.cellMemory)
+	}
+	if !strings.Contains(w.View(), "128 GB") {
+		t.Error("expected step 1 form to be rebuilt")
+	}
+
+	// Going forward again re-parses the kept values
+	w.advanceStep()
+	if w.input.ProposedCellMemoryGB != 128 {
+		t.Errorf("expected memory 128 after returning, got %d", w.input.ProposedCellMemoryGB)
+	}
+}
+
+func TestWizardTargetClusterPicker(t *testing.T) {
+	infra := &client.InfrastructureState{
+		Clusters: []client.ClusterState{
+			{Name: "cluster-a", HostCount: 4, DiegoCellCount: 10, MemoryGB: 2048, TotalCellMemoryGB: 1024, DiegoCellMemoryGB: 64},
+			{Name: "cluster-b", HostCount: 2, DiegoCellCount: 4, MemoryGB: 1024, TotalCellMemoryGB: 256, DiegoCellMemoryGB: 64},
+		},
+	}
+	w := New(infra)
+
+	options := w.clusterOptions()
+	if len(options) != 3 || options[0].Value != "" || options[1].Value != "cluster-a" {
+		t.Fatalf("expected All clusters followed by each cluster, got %+v", options)
+	}
+	if options[1].Key != "cluster-a (4 hosts, 10 cells, 50% memory)" {
+		t.Errorf("unexpected cluster label %q", options[1].Key)
+	}
+
+	w.targetCluster = "cluster-b"
+	w.advanceStep()
+	if w.input.TargetCluster != "cluster-b" {
+		t.Errorf("expected target cluster cluster-b, got %q", w.input.TargetCluster)
+	}
+	if !strings.Contains(w.renderReview(), "cluster-b") {
+		t.Error("expected target cluster in review")
+	}
+}
+
+func TestWizardSingleClusterHasNoPicker(t *testing.T) {
+	w := New(&client.InfrastructureState{
+		Clusters: []client.ClusterState{{Name: "cluster-a", DiegoCellMemoryGB: 64}},
+	})
+	if w.hasMultipleClusters() {
+		t.Error("expected no cluster picker for a single cluster")
+	}
+	w.advanceStep()
+	if w.input.TargetCluster != "" {
+		t.Errorf("expected no target cluster, got %q", w.input.TargetCluster)
+	}
+	if strings.Contains(w.renderReview(), "Target cluster") {
+		t.Error("expected no target cluster row in review")
+	}
+}
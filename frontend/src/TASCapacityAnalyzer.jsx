@@ -26,7 +26,10 @@ import WhatIfPanel from "./components/WhatIfPanel";
 import CellDetailTable from "./components/CellDetailTable";
 import ChatPanel from "./components/chat/ChatPanel";
 import { mockData } from "./data/mockData";
-import { calculateWhatIfMetrics } from "./utils/metricsCalculations";
+import {
+  calculateCellMetrics,
+  calculateWhatIfMetrics,
+} from "./utils/metricsCalculations";
 import "./TASCapacityAnalyzer.css";
 
 // Dev mode: enabled via ?dev=true query param or Vite dev server
@@ -138,15 +141,9 @@ Check browser console (F12) for details.`;
         ? data.cells
         : data.cells.filter((c) => c.isolation_segment === selectedSegment);
 
-    const totalMemory = filteredCells.reduce((sum, c) => sum + c.memory_mb, 0);
-    const totalAllocated = filteredCells.reduce(
-      (sum, c) => sum + c.allocated_mb,
-      0,
-    );
-    const totalUsed = filteredCells.reduce((sum, c) => sum + c.used_mb, 0);
-    const avgCpu =
-      filteredCells.reduce((sum, c) => sum + c.cpu_percent, 0) /
-      filteredCells.length;
+    // Cells without BOSH vitals are left out of the utilization figures
+    const cellMetrics = calculateCellMetrics(filteredCells);
+    const { totalMemory } = cellMetrics;
 
     const filteredApps =
       selectedSegment === "all"
@@ -181,14 +178,7 @@ Check browser console (F12) for details.`;
     );
 
     return {
-      totalCells: filteredCells.length,
-      totalMemory,
-      totalAllocated,
-      totalUsed,
-      avgCpu,
-      utilizationPercent: totalMemory > 0 ? (totalUsed / totalMemory) * 100 : 0,
-      allocationPercent:
-        totalMemory > 0 ? (totalAllocated / totalMemory) * 100 : 0,
+      ...cellMetrics,
       unusedMemory,
       unusedPercent:
         totalAppMemoryRequested > 0
@@ -339,6 +329,30 @@ Check browser console (F12) for details.`;
           id="dashboard-panel"
           aria-labelledby="dashboard-tab"
         >
+          {metrics.vitalsUnavailableCells > 0 && (
+            <div
+              className="mb-6 p-4 bg-amber-500/10 border border-amber-500/30 rounded-lg flex items-start gap-3"
+              role="alert"
+            >
+              <AlertTriangle
+                className="w-5 h-5 text-amber-400 flex-shrink-0 mt-0.5"
+                aria-hidden="true"
+              />
+              <div className="flex-1">
+                <p className="text-amber-300 text-sm font-semibold">
+                  {metrics.vitalsUnavailableCells}{" "}
+                  {metrics.vitalsUnavailableCells === 1 ? "cell" : "cells"}{" "}
+                  reporting no vitals
+                </p>
+                <p className="text-amber-400/60 text-xs mt-1">
+                  BOSH returned no memory vitals for these cells (agent
+                  unresponsive?). They are left out of utilization and CPU
+                  averages.
+                </p>
+              </div>
+            </div>
+          )}
+
           <MetricCards metrics={metrics} />
 
           {showWhatIf && (
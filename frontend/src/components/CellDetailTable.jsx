@@ -29,6 +29,20 @@ const CellDetailTable = ({ cells, selectedSegment }) => {
           </thead>
           <tbody>
             {filteredCells.map((cell) => {
+              if (cell.vitals_unavailable) {
+                return (
+                  <tr key={cell.id} className="cell-row border-b border-slate-800">
+                    <td className="py-3 px-4 font-semibold text-white">{cell.name}</td>
+                    <td className="py-3 px-4">
+                      <span className="segment-chip">{cell.isolation_segment}</span>
+                    </td>
+                    <td colSpan={5} className="py-3 px-4 text-amber-400">
+                      No vitals reported
+                    </td>
+                  </tr>
+                );
+              }
+
               const utilizationPercent = (cell.used_mb / cell.memory_mb) * 100;
               const status = utilizationPercent > 80 ? 'high' : utilizationPercent > 60 ? 'medium' : 'low';
 
@@ -104,6 +104,9 @@ const ScenarioAnalyzer = () => {
   const [enableTPS, setEnableTPS] = useState(false);
   const [tpsCurve, setTPSCurve] = useState(DEFAULT_TPS_CURVE);
 
+  // Backend capacity thresholds; null until loaded (results fall back to defaults)
+  const [thresholds, setThresholds] = useState(null);
+
   const handleDataLoaded = useCallback(
     async (data) => {
       console.log(
@@ -157,6 +160,11 @@ const ScenarioAnalyzer = () => {
     [showToast],
   );
 
+  // Load the backend's thresholds so results use the same boundaries as warnings
+  useEffect(() => {
+    scenarioApi.getThresholds().then(setThresholds);
+  }, []);
+
   // Load from localStorage on mount
   useEffect(() => {
     const saved = localStorage.getItem("scenario-infrastructure");
@@ -798,6 +806,7 @@ const ScenarioAnalyzer = () => {
           comparison={comparison}
           warnings={comparison.warnings}
           selectedResources={selectedResources}
+          thresholds={thresholds}
         />
       )}
     </div>
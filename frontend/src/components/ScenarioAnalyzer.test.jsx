@@ -21,6 +21,7 @@ vi.mock("../services/scenarioApi", () => ({
     getInfrastructureStatus: vi
       .fn()
       .mockResolvedValue({ vsphere_configured: false }),
+    getThresholds: vi.fn().mockResolvedValue(null),
   },
 }));
 
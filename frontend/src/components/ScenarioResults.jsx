@@ -52,13 +52,23 @@ const SectionHeader = ({ icon: Icon, label }) => (
   </div>
 );
 
+// Backend default free chunk thresholds, used until GET /api/v1/thresholds loads
+const DEFAULT_FREE_CHUNKS_WARNING = 20;
+const DEFAULT_FREE_CHUNKS_CRITICAL = 10;
+
 const ScenarioResults = ({
   comparison,
   warnings,
   selectedResources = ["memory"],
+  thresholds = null,
 }) => {
   if (!comparison) return null;
 
+  const freeChunksWarning =
+    thresholds?.free_chunks_warning ?? DEFAULT_FREE_CHUNKS_WARNING;
+  const freeChunksCritical =
+    thresholds?.free_chunks_critical ?? DEFAULT_FREE_CHUNKS_CRITICAL;
+
   const { current, proposed, delta } = comparison;
 
   // Ensure warnings is always an array (backend may return null)
@@ -577,9 +587,9 @@ const ScenarioResults = ({
                   <div className="flex flex-col items-center justify-center h-[120px]">
                     <div
                       className={`text-4xl font-mono font-bold ${
-                        proposed.free_chunks >= 20
+                        proposed.free_chunks >= freeChunksWarning
                           ? "text-emerald-400"
-                          : proposed.free_chunks >= 10
+                          : proposed.free_chunks >= freeChunksCritical
                             ? "text-amber-400"
                             : "text-red-400"
                       }`}
@@ -591,16 +601,16 @@ const ScenarioResults = ({
                     </div>
                     <div
                       className={`text-xs mt-2 px-2 py-0.5 rounded ${
-                        proposed.free_chunks >= 20
+                        proposed.free_chunks >= freeChunksWarning
                           ? "bg-emerald-900/30 text-emerald-400"
-                          : proposed.free_chunks >= 10
+                          : proposed.free_chunks >= freeChunksCritical
                             ? "bg-amber-900/30 text-amber-400"
                             : "bg-red-900/30 text-red-400"
                       }`}
                     >
-                      {proposed.free_chunks >= 20
+                      {proposed.free_chunks >= freeChunksWarning
                         ? "Healthy"
-                        : proposed.free_chunks >= 10
+                        : proposed.free_chunks >= freeChunksCritical
                           ? "Limited"
                           : "Constrained"}
                     </div>
@@ -619,3 +619,62 @@ describe("ScenarioResults Metric Grouping", () => {
     expect(screen.queryByText("Memory Utilization")).not.toBeInTheDocument();
   });
 });
+
+describe("ScenarioResults Staging Capacity thresholds", () => {
+  const stagingComparison = (freeChunks) => ({
+    current: {
+      cell_count: 10,
+      cell_memory_gb: 32,
+      cell_cpu: 4,
+      app_capacity_gb: 298,
+      utilization_pct: 50,
+      n1_utilization_pct: 60,
+      free_chunks: 100,
+      blast_radius_pct: 5,
+      instances_per_cell: 5,
+      fault_impact: 10,
+      estimated_tps: 0,
+      tps_status: "disabled",
+    },
+    proposed: {
+      cell_count: 20,
+      cell_memory_gb: 32,
+      cell_cpu: 4,
+      app_capacity_gb: 596,
+      utilization_pct: 25,
+      n1_utilization_pct: 70,
+      free_chunks: freeChunks,
+      blast_radius_pct: 2.5,
+      instances_per_cell: 2.5,
+      fault_impact: 5,
+      estimated_tps: 0,
+      tps_status: "disabled",
+    },
+    delta: {
+      capacity_change_gb: 298,
+      utilization_change_pct: -25,
+      resilience_change: "improved",
+    },
+  });
+
+  it("uses the default free chunk thresholds without backend thresholds", () => {
+    render(
+      <ScenarioResults comparison={stagingComparison(300)} warnings={[]} />,
+    );
+
+    expect(screen.getByText("Healthy")).toBeInTheDocument();
+  });
+
+  it("uses backend free chunk thresholds when provided", () => {
+    render(
+      <ScenarioResults
+        comparison={stagingComparison(300)}
+        warnings={[]}
+        thresholds={{ free_chunks_warning: 400, free_chunks_critical: 200 }}
+      />,
+    );
+
+    expect(screen.getByText("Limited")).toBeInTheDocument();
+    expect(screen.queryByText("Healthy")).not.toBeInTheDocument();
+  });
+});
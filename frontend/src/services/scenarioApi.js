@@ -78,6 +78,24 @@ export const scenarioApi = {
     });
   },
 
+  /**
+   * Get the capacity thresholds the backend applies (defaults plus any
+   * THRESHOLDS overrides)
+   * @returns {Promise<Object|null>} Thresholds, or null if unavailable
+   */
+  async getThresholds() {
+    try {
+      return await apiFetch(`${API_URL}/api/v1/thresholds`, {
+        method: "GET",
+        headers: { "Content-Type": "application/json" },
+        credentials: "include",
+      });
+    } catch (err) {
+      console.warn("[scenarioApi] Failed to load thresholds:", err);
+      return null;
+    }
+  },
+
   /**
    * Calculate max deployable cells given IaaS capacity
    * @param {Object} input - PlanningInput with cell_memory_gb, cell_cpu, overhead_pct
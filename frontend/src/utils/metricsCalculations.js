@@ -2,12 +2,15 @@
 // ABOUTME: Extracted for testability and reuse
 
 /**
- * Calculate cell metrics from filtered cells data
+ * Calculate cell metrics from filtered cells data. Cells flagged
+ * vitals_unavailable are counted but left out of the memory and CPU figures,
+ * since their zero usage is unknown rather than measured.
  */
 export function calculateCellMetrics(cells) {
   if (!cells || cells.length === 0) {
     return {
       totalCells: 0,
+      vitalsUnavailableCells: 0,
       totalMemory: 0,
       totalAllocated: 0,
       totalUsed: 0,
@@ -17,14 +20,18 @@ export function calculateCellMetrics(cells) {
     };
   }
 
-  const totalMemory = cells.reduce((sum, c) => sum + c.memory_mb, 0);
-  const totalAllocated = cells.reduce((sum, c) => sum + c.allocated_mb, 0);
-  const totalUsed = cells.reduce((sum, c) => sum + c.used_mb, 0);
+  const reporting = cells.filter((c) => !c.vitals_unavailable);
+  const totalMemory = reporting.reduce((sum, c) => sum + c.memory_mb, 0);
+  const totalAllocated = reporting.reduce((sum, c) => sum + c.allocated_mb, 0);
+  const totalUsed = reporting.reduce((sum, c) => sum + c.used_mb, 0);
   const avgCpu =
-    cells.reduce((sum, c) => sum + c.cpu_percent, 0) / cells.length;
+    reporting.length > 0
+      ? reporting.reduce((sum, c) => sum + c.cpu_percent, 0) / reporting.length
+      : 0;
 
   return {
     totalCells: cells.length,
+    vitalsUnavailableCells: cells.length - reporting.length,
     totalMemory,
     totalAllocated,
     totalUsed,
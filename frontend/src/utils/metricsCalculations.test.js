@@ -53,6 +53,26 @@ describe("calculateCellMetrics", () => {
     expect(result.utilizationPercent).toBe(50); // 5000/10000 * 100
     expect(result.allocationPercent).toBe(80); // 8000/10000 * 100
   });
+
+  it("leaves cells without vitals out of utilization and CPU", () => {
+    const cells = [
+      { memory_mb: 10000, allocated_mb: 8000, used_mb: 5000, cpu_percent: 50 },
+      {
+        memory_mb: 0,
+        allocated_mb: 0,
+        used_mb: 0,
+        cpu_percent: 0,
+        vitals_unavailable: true,
+      },
+    ];
+
+    const result = calculateCellMetrics(cells);
+
+    expect(result.totalCells).toBe(2);
+    expect(result.vitalsUnavailableCells).toBe(1);
+    expect(result.utilizationPercent).toBe(50);
+    expect(result.avgCpu).toBe(50);
+  });
 });
 
 describe("calculateAppMetrics", () => {
@@ -73,6 +73,8 @@ VSPHERE_DATACENTER=$(om curl -s --path /api/v0/staged/director/properties | jq -
 VSPHERE_USERNAME=$(om curl -s --path /api/v0/staged/director/properties | jq -r '.iaas_configuration?.vcenter_username')
 export VSPHERE_PASSWORD
 VSPHERE_PASSWORD=$(om staged-director-config --no-redact | yq '.iaas-configurations[].vcenter_password')
+export VSPHERE_CA_CERT
+VSPHERE_CA_CERT=$(om curl -s --path /api/v0/staged/director/properties | jq -r '.iaas_configuration?.vcenter_ca_certificate // empty')
 export VSPHERE_INSECURE
 VSPHERE_INSECURE=$(om curl -s --path /api/v0/staged/director/properties | jq -r '.iaas_configuration?.vcenter_ca_certificate' | jq -r 'if . == null then "true" else "false" end')
 
@@ -146,8 +148,15 @@ VSPHERE_DATACENTER=$VSPHERE_DATACENTER
 VSPHERE_USERNAME=$VSPHERE_USERNAME
 VSPHERE_PASSWORD=$VSPHERE_PASSWORD
 VSPHERE_INSECURE=$VSPHERE_INSECURE
+VSPHERE_CA_CERT="$VSPHERE_CA_CERT"
 EOF
 
+if [[ -n "${BOSH_ALL_PROXY:-}" ]]; then
+    cat >> .env << EOF
+VSPHERE_ALL_PROXY=$BOSH_ALL_PROXY
+EOF
+fi
+
 echo "Generated .env file with credentials for:"
 echo "  - BOSH Director: $BOSH_ENVIRONMENT"
 echo "  - CF Deployment: $BOSH_DEPLOYMENT"